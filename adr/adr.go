@@ -0,0 +1,290 @@
+// Package adr implements a region-aware adaptive data-rate (ADR) engine,
+// based on the algorithm recommended by Semtech.
+//
+// Based on the SNR history of the uplinks, the engine increases the
+// data-rate and / or decreases the TX power when there is enough link
+// margin and increases the TX power when the link margin is negative. The
+// number of transmissions (NbTrans) is adjusted based on the packet-loss.
+package adr
+
+import (
+	"errors"
+	"math"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
+)
+
+// DefaultHistoryCount defines the default number of uplinks required in the
+// history before the ADR engine makes any adjustments.
+const DefaultHistoryCount = 20
+
+// requiredSNRPerSF contains the demodulation floor (dB) by spreading-factor.
+var requiredSNRPerSF = map[int]float64{
+	5:  -2.5,
+	6:  -5,
+	7:  -7.5,
+	8:  -10,
+	9:  -12.5,
+	10: -15,
+	11: -17.5,
+	12: -20,
+}
+
+// pktLossRateTable contains the NbTrans values by packet-loss category
+// (< 5%, < 10%, < 30%, >= 30%) and current NbTrans.
+var pktLossRateTable = [][3]int{
+	{1, 1, 2},
+	{1, 2, 3},
+	{2, 3, 3},
+	{3, 3, 3},
+}
+
+// UplinkMetaData contains the meta-data of a received uplink.
+type UplinkMetaData struct {
+	FCnt   uint32
+	MaxSNR float64 // max SNR of all receiving gateways
+}
+
+// Request contains the ADR request parameters.
+type Request struct {
+	// Band defines the band of the device.
+	Band band.Band
+
+	// DR defines the current data-rate.
+	DR int
+
+	// TXPowerIndex defines the current TXPower index.
+	TXPowerIndex int
+
+	// NbTrans defines the current number of transmissions.
+	NbTrans int
+
+	// MaxDR defines the max. data-rate that can be used by the device.
+	MaxDR int
+
+	// MaxTXPowerIndex defines the max. TXPower index (lowest TX power)
+	// that can be used. When 0, the max. TXPower index of the band is used.
+	MaxTXPowerIndex int
+
+	// InstallationMargin defines the installation margin (dB).
+	InstallationMargin float64
+
+	// UplinkHistory contains the meta-data of the last received uplinks.
+	UplinkHistory []UplinkMetaData
+
+	// EnabledUplinkChannels contains the uplink channels enabled by the
+	// device.
+	EnabledUplinkChannels []int
+}
+
+// Response contains the ADR response parameters.
+type Response struct {
+	DR           int
+	TXPowerIndex int
+	NbTrans      int
+}
+
+// MarginPolicy defines the interface for calculating the link margin.
+type MarginPolicy interface {
+	// Margin returns the link margin (dB), given the uplink history, the
+	// required SNR for the current data-rate and the installation margin.
+	Margin(history []UplinkMetaData, requiredSNR, installationMargin float64) float64
+}
+
+// MaxSNRPolicy calculates the link margin based on the max SNR of the
+// uplink history.
+type MaxSNRPolicy struct{}
+
+// Margin implements the MarginPolicy interface.
+func (p MaxSNRPolicy) Margin(history []UplinkMetaData, requiredSNR, installationMargin float64) float64 {
+	snr := -math.MaxFloat64
+	for _, h := range history {
+		if h.MaxSNR > snr {
+			snr = h.MaxSNR
+		}
+	}
+	return snr - requiredSNR - installationMargin
+}
+
+// AverageSNRPolicy calculates the link margin based on the average SNR of
+// the uplink history.
+type AverageSNRPolicy struct{}
+
+// Margin implements the MarginPolicy interface.
+func (p AverageSNRPolicy) Margin(history []UplinkMetaData, requiredSNR, installationMargin float64) float64 {
+	var sum float64
+	for _, h := range history {
+		sum += h.MaxSNR
+	}
+	return sum/float64(len(history)) - requiredSNR - installationMargin
+}
+
+// Engine implements the ADR engine.
+type Engine struct {
+	marginPolicy MarginPolicy
+	historyCount int
+}
+
+// NewEngine creates a new ADR engine using the given margin policy. When
+// the policy is nil, MaxSNRPolicy is used. The engine only makes data-rate
+// and TX power adjustments once the history contains historyCount uplinks.
+// When historyCount is 0, DefaultHistoryCount is used.
+func NewEngine(policy MarginPolicy, historyCount int) *Engine {
+	if policy == nil {
+		policy = MaxSNRPolicy{}
+	}
+	if historyCount == 0 {
+		historyCount = DefaultHistoryCount
+	}
+
+	return &Engine{
+		marginPolicy: policy,
+		historyCount: historyCount,
+	}
+}
+
+// Handle handles the ADR request and returns the new ADR parameters.
+func (e *Engine) Handle(req Request) (Response, error) {
+	resp := Response{
+		DR:           req.DR,
+		TXPowerIndex: req.TXPowerIndex,
+		NbTrans:      req.NbTrans,
+	}
+
+	if req.Band == nil {
+		return resp, errors.New("lorawan/adr: band must be set")
+	}
+
+	if resp.NbTrans == 0 {
+		resp.NbTrans = 1
+	}
+	resp.NbTrans = getNbTrans(resp.NbTrans, getPacketLossPercentage(req.UplinkHistory))
+
+	// the device is using a data-rate higher than allowed, there is no need
+	// to calculate the margin as the data-rate must be lowered
+	if req.DR > req.MaxDR {
+		resp.DR = req.MaxDR
+		return resp, nil
+	}
+
+	if len(req.UplinkHistory) < e.historyCount {
+		return resp, nil
+	}
+
+	margin, err := GetMargin(req.Band, req.DR, req.UplinkHistory, 0, e.marginPolicy, req.InstallationMargin)
+	if err != nil {
+		return resp, err
+	}
+
+	maxTXPowerIndex := req.MaxTXPowerIndex
+	if maxTXPowerIndex == 0 {
+		maxTXPowerIndex = getMaxTXPowerIndex(req.Band)
+	}
+
+	nStep := int(margin / 3)
+
+	resp.DR, resp.TXPowerIndex = getIdealTXPowerIndexAndDR(nStep, resp.DR, resp.TXPowerIndex, req.MaxDR, maxTXPowerIndex)
+
+	return resp, nil
+}
+
+// GetLinkADRReqPayloads returns the LinkADRReqPayloads to send to the device
+// for the given request and response. It returns nil when the ADR
+// parameters are unchanged.
+func GetLinkADRReqPayloads(req Request, resp Response) []lorawan.LinkADRReqPayload {
+	if req.DR == resp.DR && req.TXPowerIndex == resp.TXPowerIndex && req.NbTrans == resp.NbTrans {
+		return nil
+	}
+
+	payloads := req.Band.GetLinkADRReqPayloadsForEnabledUplinkChannelIndices(req.EnabledUplinkChannels)
+	if len(payloads) == 0 {
+		var chMask lorawan.ChMask
+		for _, c := range req.EnabledUplinkChannels {
+			if c >= 0 && c < len(chMask) {
+				chMask[c] = true
+			}
+		}
+		payloads = []lorawan.LinkADRReqPayload{
+			{ChMask: chMask},
+		}
+	}
+
+	last := &payloads[len(payloads)-1]
+	last.DataRate = uint8(resp.DR)
+	last.TXPower = uint8(resp.TXPowerIndex)
+	last.Redundancy.NbRep = uint8(resp.NbTrans)
+
+	return payloads
+}
+
+func getIdealTXPowerIndexAndDR(nStep, dr, txPowerIndex, maxDR, maxTXPowerIndex int) (int, int) {
+	for nStep != 0 {
+		if nStep > 0 {
+			if dr < maxDR {
+				dr++
+			} else if txPowerIndex < maxTXPowerIndex {
+				txPowerIndex++
+			} else {
+				break
+			}
+			nStep--
+		} else {
+			if txPowerIndex > 0 {
+				txPowerIndex--
+			} else {
+				break
+			}
+			nStep++
+		}
+	}
+
+	return dr, txPowerIndex
+}
+
+func getMaxTXPowerIndex(b band.Band) int {
+	var i int
+	for {
+		if _, err := b.GetTXPowerOffset(i + 1); err != nil {
+			return i
+		}
+		i++
+	}
+}
+
+func getPacketLossPercentage(history []UplinkMetaData) float64 {
+	if len(history) < 2 {
+		return 0
+	}
+
+	var lost uint32
+	prev := history[0].FCnt
+	for _, h := range history[1:] {
+		if h.FCnt > prev {
+			lost += h.FCnt - prev - 1
+		}
+		prev = h.FCnt
+	}
+
+	return float64(lost) / float64(len(history)+int(lost)) * 100
+}
+
+func getNbTrans(currentNbTrans int, pktLossRate float64) int {
+	if currentNbTrans < 1 {
+		currentNbTrans = 1
+	}
+	if currentNbTrans > 3 {
+		currentNbTrans = 3
+	}
+
+	switch {
+	case pktLossRate < 5:
+		return pktLossRateTable[0][currentNbTrans-1]
+	case pktLossRate < 10:
+		return pktLossRateTable[1][currentNbTrans-1]
+	case pktLossRate < 30:
+		return pktLossRateTable[2][currentNbTrans-1]
+	default:
+		return pktLossRateTable[3][currentNbTrans-1]
+	}
+}
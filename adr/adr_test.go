@@ -0,0 +1,171 @@
+package adr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
+)
+
+func history(count int, snr float64) []UplinkMetaData {
+	var out []UplinkMetaData
+	for i := 0; i < count; i++ {
+		out = append(out, UplinkMetaData{FCnt: uint32(i), MaxSNR: snr})
+	}
+	return out
+}
+
+func TestEngine(t *testing.T) {
+	b, err := band.GetConfig(band.EU868, false, lorawan.DwellTimeNoLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name     string
+		Policy   MarginPolicy
+		Request  Request
+		Expected Response
+	}{
+		{
+			Name: "not enough history",
+			Request: Request{
+				Band:          b,
+				DR:            0,
+				NbTrans:       1,
+				MaxDR:         5,
+				UplinkHistory: history(10, 10),
+			},
+			Expected: Response{DR: 0, TXPowerIndex: 0, NbTrans: 1},
+		},
+		{
+			Name: "increase data-rate",
+			Request: Request{
+				Band:               b,
+				DR:                 0,
+				NbTrans:            1,
+				MaxDR:              5,
+				InstallationMargin: 10,
+				UplinkHistory:      history(20, -5),
+			},
+			// margin: -5 - -20 - 10 = 5, nStep = 1
+			Expected: Response{DR: 1, TXPowerIndex: 0, NbTrans: 1},
+		},
+		{
+			Name: "increase data-rate and decrease tx power",
+			Request: Request{
+				Band:               b,
+				DR:                 3,
+				NbTrans:            1,
+				MaxDR:              5,
+				InstallationMargin: 10,
+				UplinkHistory:      history(20, 10),
+			},
+			// margin: 10 - -12.5 - 10 = 12.5, nStep = 4
+			Expected: Response{DR: 5, TXPowerIndex: 2, NbTrans: 1},
+		},
+		{
+			Name: "tx power is capped by the max tx power index",
+			Request: Request{
+				Band:               b,
+				DR:                 5,
+				NbTrans:            1,
+				MaxDR:              5,
+				InstallationMargin: 0,
+				UplinkHistory:      history(20, 30),
+			},
+			Expected: Response{DR: 5, TXPowerIndex: 7, NbTrans: 1},
+		},
+		{
+			Name: "increase tx power",
+			Request: Request{
+				Band:               b,
+				DR:                 5,
+				TXPowerIndex:       3,
+				NbTrans:            1,
+				MaxDR:              5,
+				InstallationMargin: 10,
+				UplinkHistory:      history(20, -7),
+			},
+			// margin: -7 - -7.5 - 10 = -9.5, nStep = -3
+			Expected: Response{DR: 5, TXPowerIndex: 0, NbTrans: 1},
+		},
+		{
+			Name:   "average snr policy",
+			Policy: AverageSNRPolicy{},
+			Request: Request{
+				Band:               b,
+				DR:                 0,
+				NbTrans:            1,
+				MaxDR:              5,
+				InstallationMargin: 10,
+				UplinkHistory:      append(history(19, -20), UplinkMetaData{FCnt: 19, MaxSNR: 20}),
+			},
+			// average snr: -18, margin: -18 - -20 - 10 = -8, nStep = -2
+			Expected: Response{DR: 0, TXPowerIndex: 0, NbTrans: 1},
+		},
+		{
+			Name: "data-rate above max data-rate",
+			Request: Request{
+				Band:          b,
+				DR:            5,
+				NbTrans:       1,
+				MaxDR:         3,
+				UplinkHistory: history(20, 10),
+			},
+			Expected: Response{DR: 3, TXPowerIndex: 0, NbTrans: 1},
+		},
+		{
+			Name: "packet-loss increases nb trans",
+			Request: Request{
+				Band:    b,
+				DR:      0,
+				NbTrans: 1,
+				MaxDR:   5,
+				UplinkHistory: []UplinkMetaData{
+					{FCnt: 1, MaxSNR: -20},
+					{FCnt: 5, MaxSNR: -20},
+				},
+			},
+			Expected: Response{DR: 0, TXPowerIndex: 0, NbTrans: 3},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			resp, err := NewEngine(tst.Policy, 0).Handle(tst.Request)
+			assert.NoError(err)
+			assert.Equal(tst.Expected, resp)
+		})
+	}
+}
+
+func TestGetLinkADRReqPayloads(t *testing.T) {
+	assert := require.New(t)
+
+	b, err := band.GetConfig(band.EU868, false, lorawan.DwellTimeNoLimit)
+	assert.NoError(err)
+
+	req := Request{
+		Band:                  b,
+		DR:                    0,
+		NbTrans:               1,
+		EnabledUplinkChannels: []int{0, 1, 2},
+	}
+
+	assert.Nil(GetLinkADRReqPayloads(req, Response{DR: 0, NbTrans: 1}))
+	assert.Equal([]lorawan.LinkADRReqPayload{
+		{
+			DataRate: 5,
+			TXPower:  2,
+			ChMask:   lorawan.ChMask{true, true, true},
+			Redundancy: lorawan.Redundancy{
+				NbRep: 1,
+			},
+		},
+	}, GetLinkADRReqPayloads(req, Response{DR: 5, TXPowerIndex: 2, NbTrans: 1}))
+}
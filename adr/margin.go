@@ -0,0 +1,89 @@
+package adr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/brocaar/lorawan/band"
+)
+
+// PercentileSNRPolicy calculates the link margin based on the given
+// percentile (0 - 100) of the SNR values of the uplink history, using the
+// nearest-rank method. A percentile of 100 is equal to MaxSNRPolicy, lower
+// values make the margin less sensitive to a single outlier.
+type PercentileSNRPolicy struct {
+	Percentile float64
+}
+
+// Margin implements the MarginPolicy interface.
+func (p PercentileSNRPolicy) Margin(history []UplinkMetaData, requiredSNR, installationMargin float64) float64 {
+	if len(history) == 0 {
+		return -math.MaxFloat64
+	}
+
+	snrs := make([]float64, len(history))
+	for i, h := range history {
+		snrs[i] = h.MaxSNR
+	}
+	sort.Float64s(snrs)
+
+	rank := int(math.Ceil(p.Percentile / 100 * float64(len(snrs))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(snrs) {
+		rank = len(snrs)
+	}
+
+	return snrs[rank-1] - requiredSNR - installationMargin
+}
+
+// GetRequiredSNR returns the demodulation floor (dB) of the given data-rate
+// of the band. Only LoRa data-rates are supported.
+func GetRequiredSNR(b band.Band, dr int) (float64, error) {
+	dataRate, err := b.GetDataRate(dr)
+	if err != nil {
+		return 0, err
+	}
+	if dataRate.Modulation != band.LoRaModulation {
+		return 0, fmt.Errorf("lorawan/adr: unsupported modulation: %s", dataRate.Modulation)
+	}
+
+	requiredSNR, ok := requiredSNRPerSF[dataRate.SpreadFactor]
+	if !ok {
+		return 0, fmt.Errorf("lorawan/adr: unsupported spreading-factor: %d", dataRate.SpreadFactor)
+	}
+
+	return requiredSNR, nil
+}
+
+// GetMargin returns the link margin (dB) of the given data-rate of the band,
+// calculated by the given policy over the last window uplinks of the
+// history. When the window is 0, the complete history is used. When the
+// policy is nil, MaxSNRPolicy is used.
+//
+// This can be used by custom ADR algorithms which do not use the Engine.
+func GetMargin(b band.Band, dr int, history []UplinkMetaData, window int, policy MarginPolicy, installationMargin float64) (float64, error) {
+	if window < 0 {
+		return 0, errors.New("lorawan/adr: window must be >= 0")
+	}
+	if len(history) == 0 {
+		return 0, errors.New("lorawan/adr: uplink history is empty")
+	}
+	if policy == nil {
+		policy = MaxSNRPolicy{}
+	}
+
+	requiredSNR, err := GetRequiredSNR(b, dr)
+	if err != nil {
+		return 0, err
+	}
+
+	if window != 0 && window < len(history) {
+		history = history[len(history)-window:]
+	}
+
+	return policy.Margin(history, requiredSNR, installationMargin), nil
+}
@@ -0,0 +1,101 @@
+package adr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
+)
+
+func TestPercentileSNRPolicy(t *testing.T) {
+	var h []UplinkMetaData
+	for i := 1; i <= 10; i++ {
+		h = append(h, UplinkMetaData{FCnt: uint32(i), MaxSNR: float64(i)})
+	}
+
+	tests := []struct {
+		Name       string
+		Percentile float64
+		Expected   float64
+	}{
+		{"max", 100, 10},
+		{"90th percentile", 90, 9},
+		{"median", 50, 5},
+		{"min", 0, 1},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+			p := PercentileSNRPolicy{Percentile: tst.Percentile}
+			assert.Equal(tst.Expected-(-20)-10, p.Margin(h, -20, 10))
+		})
+	}
+}
+
+func TestGetMargin(t *testing.T) {
+	b, err := band.GetConfig(band.EU868, false, lorawan.DwellTimeNoLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := append(history(10, 20), history(5, -5)...)
+
+	tests := []struct {
+		Name          string
+		DR            int
+		History       []UplinkMetaData
+		Window        int
+		Policy        MarginPolicy
+		Expected      float64
+		ExpectedError string
+	}{
+		{
+			Name:     "complete history",
+			DR:       0,
+			History:  h,
+			Expected: 20 - -20 - 10,
+		},
+		{
+			Name:     "window",
+			DR:       0,
+			History:  h,
+			Window:   5,
+			Expected: -5 - -20 - 10,
+		},
+		{
+			Name:     "window exceeds history",
+			DR:       5,
+			History:  h,
+			Window:   20,
+			Policy:   AverageSNRPolicy{},
+			Expected: (10*20+5*-5)/15.0 - -7.5 - 10,
+		},
+		{
+			Name:          "empty history",
+			DR:            0,
+			ExpectedError: "lorawan/adr: uplink history is empty",
+		},
+		{
+			Name:          "fsk data-rate",
+			DR:            7,
+			History:       h,
+			ExpectedError: "lorawan/adr: unsupported modulation: FSK",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+			margin, err := GetMargin(b, tst.DR, tst.History, tst.Window, tst.Policy, 10)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+				return
+			}
+			assert.NoError(err)
+			assert.InDelta(tst.Expected, margin, 0.0001)
+		})
+	}
+}
@@ -1,13 +1,9 @@
-// Package airtime provides a function for calculating the time on air.
-// This implements the formula as defined by:
+// Package airtime provides functions for calculating the time on air.
+// The LoRa time on air implements the formula as defined by:
 // https://www.semtech.com/uploads/documents/LoraDesignGuide_STD.pdf.
 package airtime
 
-import (
-	"errors"
-	"math"
-	"time"
-)
+import "time"
 
 // CodingRate defines the coding-rate type.
 type CodingRate int
@@ -46,27 +42,5 @@ func CalculateLoRaPreambleDuration(symbolDuration time.Duration, preambleNumber
 // CalculateLoRaPayloadSymbolNumber returns the number of symbols that make
 // up the packet payload and header.
 func CalculateLoRaPayloadSymbolNumber(payloadSize, sf int, codingRate CodingRate, headerEnabled, lowDataRateOptimization bool) (int, error) {
-	var pl, spreadingFactor, h, de, cr float64
-
-	if codingRate < 1 || codingRate > 4 {
-		return 0, errors.New("codingRate must be between 1 - 4")
-	}
-
-	if lowDataRateOptimization {
-		de = 1
-	}
-
-	if !headerEnabled {
-		h = 1
-	}
-
-	pl = float64(payloadSize)
-	spreadingFactor = float64(sf)
-	cr = float64(codingRate)
-
-	a := 8*pl - 4*spreadingFactor + 28 + 16 - 20*h
-	b := 4 * (spreadingFactor - 2*de)
-	c := cr + 4
-
-	return int(8 + math.Max(math.Ceil(a/b)*c, 0)), nil
+	return calculateLoRaPayloadSymbolNumber(payloadSize, sf, codingRate, true, headerEnabled, lowDataRateOptimization)
 }
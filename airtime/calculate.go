@@ -0,0 +1,144 @@
+package airtime
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Modulation defines the modulation type.
+type Modulation string
+
+// Available modulation types. These match the band.Modulation values.
+const (
+	LoRa   Modulation = "LORA"
+	FSK    Modulation = "FSK"
+	LRFHSS Modulation = "LR_FHSS"
+)
+
+// LR-FHSS timing constants (488.28125 bits/s).
+const (
+	lrFHSSBitDuration    = 2048 * time.Microsecond
+	lrFHSSHeaderDuration = 114 * lrFHSSBitDuration // 233.472 ms
+)
+
+// DataRate defines the modulation parameters used for calculating the
+// time-on-air.
+type DataRate struct {
+	Modulation Modulation
+
+	// LoRa parameters.
+	SpreadingFactor int
+	Bandwidth       int // kHz
+	CodingRate      CodingRate
+
+	// FSK parameters.
+	BitRate int // bits per second
+
+	// LR-FHSS parameters.
+	LRFHSSCodingRate string // "1/3", "1/2", "2/3" or "5/6"
+}
+
+// Calculate calculates the time-on-air of a frame with the given payload
+// size (bytes), using the given data-rate. For LoRa, the preamble number is
+// the number of programmed preamble symbols (8 for LoRaWAN). For FSK, it is
+// the number of preamble bytes (5 for LoRaWAN) and the sync word (3 bytes)
+// and length byte are added. For LR-FHSS, the preamble, CRC, header and
+// low data-rate optimization arguments are ignored as these are defined by
+// the modulation (the payload CRC is always present).
+func Calculate(payloadSize int, dr DataRate, preambleNumber int, crc, explicitHeader, lowDataRateOptimization bool) (time.Duration, error) {
+	switch dr.Modulation {
+	case LoRa:
+		if dr.SpreadingFactor < 5 || dr.SpreadingFactor > 12 {
+			return 0, fmt.Errorf("invalid spreading-factor: %d", dr.SpreadingFactor)
+		}
+		if dr.Bandwidth <= 0 {
+			return 0, fmt.Errorf("invalid bandwidth: %d", dr.Bandwidth)
+		}
+
+		codingRate := dr.CodingRate
+		if codingRate == 0 {
+			codingRate = CodingRate45
+		}
+
+		symbolDuration := CalculateLoRaSymbolDuration(dr.SpreadingFactor, dr.Bandwidth)
+		symbols, err := calculateLoRaPayloadSymbolNumber(payloadSize, dr.SpreadingFactor, codingRate, crc, explicitHeader, lowDataRateOptimization)
+		if err != nil {
+			return 0, err
+		}
+
+		return CalculateLoRaPreambleDuration(symbolDuration, preambleNumber) + time.Duration(symbols)*symbolDuration, nil
+	case FSK:
+		if dr.BitRate <= 0 {
+			return 0, fmt.Errorf("invalid bit-rate: %d", dr.BitRate)
+		}
+
+		// preamble + sync word (3) + length (1) + payload + crc (2)
+		bytes := preambleNumber + 3 + 1 + payloadSize
+		if crc {
+			bytes += 2
+		}
+
+		return time.Duration(bytes*8) * time.Second / time.Duration(dr.BitRate), nil
+	case LRFHSS:
+		return calculateLRFHSSAirtime(payloadSize, dr.LRFHSSCodingRate)
+	default:
+		return 0, fmt.Errorf("unsupported modulation: %s", dr.Modulation)
+	}
+}
+
+// calculateLRFHSSAirtime implements the LR-FHSS time-on-air calculation.
+// The (coded) payload, including the CRC (2 bytes) and the trellis
+// termination (6 bits), is split in fragments of 48 bits, each prefixed
+// with 2 sync bits.
+func calculateLRFHSSAirtime(payloadSize int, codingRate string) (time.Duration, error) {
+	var headers, num, den int
+	switch codingRate {
+	case "1/3":
+		headers, num, den = 3, 3, 1
+	case "1/2":
+		headers, num, den = 2, 2, 1
+	case "2/3":
+		headers, num, den = 2, 3, 2
+	case "5/6":
+		headers, num, den = 2, 6, 5
+	default:
+		return 0, fmt.Errorf("invalid LR-FHSS coding-rate: %s", codingRate)
+	}
+
+	bits := (payloadSize+2)*8 + 6
+	coded := (bits*num + den - 1) / den
+
+	payloadBits := coded / 48 * 50
+	if rem := coded % 48; rem > 0 {
+		payloadBits += rem + 2
+	}
+
+	return time.Duration(headers)*lrFHSSHeaderDuration + time.Duration(payloadBits)*lrFHSSBitDuration, nil
+}
+
+func calculateLoRaPayloadSymbolNumber(payloadSize, sf int, codingRate CodingRate, crc, headerEnabled, lowDataRateOptimization bool) (int, error) {
+	var de, h, c float64
+
+	if codingRate < 1 || codingRate > 4 {
+		return 0, errors.New("codingRate must be between 1 - 4")
+	}
+
+	if lowDataRateOptimization {
+		de = 1
+	}
+	if !headerEnabled {
+		h = 1
+	}
+	if crc {
+		c = 1
+	}
+
+	spreadingFactor := float64(sf)
+
+	a := 8*float64(payloadSize) - 4*spreadingFactor + 28 + 16*c - 20*h
+	b := 4 * (spreadingFactor - 2*de)
+
+	return int(8 + math.Max(math.Ceil(a/b)*(float64(codingRate)+4), 0)), nil
+}
@@ -0,0 +1,101 @@
+package airtime
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCalculate(t *testing.T) {
+	tests := []struct {
+		Name                    string
+		PayloadSize             int
+		DataRate                DataRate
+		PreambleNum             int
+		CRC                     bool
+		ExplicitHeader          bool
+		LowDataRateOptimization bool
+		ExpectedAirtime         time.Duration
+		ExpectedError           bool
+	}{
+		{
+			Name:            "LoRa SF12 BW125",
+			PayloadSize:     13,
+			DataRate:        DataRate{Modulation: LoRa, SpreadingFactor: 12, Bandwidth: 125},
+			PreambleNum:     8,
+			CRC:             true,
+			ExplicitHeader:  true,
+			ExpectedAirtime: 1155072 * time.Microsecond,
+		},
+		{
+			Name:            "LoRa SF7 BW125",
+			PayloadSize:     13,
+			DataRate:        DataRate{Modulation: LoRa, SpreadingFactor: 7, Bandwidth: 125, CodingRate: CodingRate45},
+			PreambleNum:     8,
+			CRC:             true,
+			ExplicitHeader:  true,
+			ExpectedAirtime: 46336 * time.Microsecond,
+		},
+		{
+			Name:            "LoRa SF7 BW125 without CRC (downlink)",
+			PayloadSize:     13,
+			DataRate:        DataRate{Modulation: LoRa, SpreadingFactor: 7, Bandwidth: 125},
+			PreambleNum:     8,
+			ExplicitHeader:  true,
+			ExpectedAirtime: 41216 * time.Microsecond,
+		},
+		{
+			Name:          "LoRa invalid SF",
+			PayloadSize:   13,
+			DataRate:      DataRate{Modulation: LoRa, SpreadingFactor: 13, Bandwidth: 125},
+			ExpectedError: true,
+		},
+		{
+			Name:            "FSK 50kbps",
+			PayloadSize:     13,
+			DataRate:        DataRate{Modulation: FSK, BitRate: 50000},
+			PreambleNum:     5,
+			CRC:             true,
+			ExpectedAirtime: 3840 * time.Microsecond,
+		},
+		{
+			Name:            "LR-FHSS CR 1/3",
+			PayloadSize:     13,
+			DataRate:        DataRate{Modulation: LRFHSS, LRFHSSCodingRate: "1/3"},
+			ExpectedAirtime: 1507328 * time.Microsecond,
+		},
+		{
+			Name:            "LR-FHSS CR 2/3",
+			PayloadSize:     13,
+			DataRate:        DataRate{Modulation: LRFHSS, LRFHSSCodingRate: "2/3"},
+			ExpectedAirtime: 870400 * time.Microsecond,
+		},
+		{
+			Name:          "LR-FHSS invalid coding-rate",
+			PayloadSize:   13,
+			DataRate:      DataRate{Modulation: LRFHSS, LRFHSSCodingRate: "4/5"},
+			ExpectedError: true,
+		},
+		{
+			Name:          "Unknown modulation",
+			DataRate:      DataRate{Modulation: "FOO"},
+			ExpectedError: true,
+		},
+	}
+
+	Convey("Given a test-table", t, func() {
+		for i, test := range tests {
+			Convey(fmt.Sprintf("Test: %s [%d]", test.Name, i), func() {
+				d, err := Calculate(test.PayloadSize, test.DataRate, test.PreambleNum, test.CRC, test.ExplicitHeader, test.LowDataRateOptimization)
+				if test.ExpectedError {
+					So(err, ShouldNotBeNil)
+					return
+				}
+				So(err, ShouldBeNil)
+				So(d, ShouldEqual, test.ExpectedAirtime)
+			})
+		}
+	})
+}
@@ -0,0 +1,164 @@
+// Package applayer provides a FPort based registry of the application-layer
+// payload codecs, so that received payloads can be decoded using a single
+// entry point.
+//
+// The pre-defined codecs are registered under the default FPort of each
+// package:
+//
+//   - multicastsetup (200): multicastsetup.Commands
+//   - fragmentation (201): fragmentation.Commands
+//   - clocksync (202): clocksync.Commands
+//   - firmwaremanagement (203): firmwaremanagement.Commands
+//   - certification (224): certification.Commands
+//
+// Additional (user-defined) codecs can be registered using RegisterCodec.
+// The Dispatcher routes the decoded uplink commands to user-implemented
+// handlers and collects the answers to send as downlink.
+package applayer
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/brocaar/lorawan/applayer/certification"
+	"github.com/brocaar/lorawan/applayer/clocksync"
+	"github.com/brocaar/lorawan/applayer/firmwaremanagement"
+	"github.com/brocaar/lorawan/applayer/fragmentation"
+	"github.com/brocaar/lorawan/applayer/multicastsetup"
+)
+
+// ErrNoCodecForFPort is returned when no codec is registered for the FPort.
+var ErrNoCodecForFPort = errors.New("lorawan/applayer: no codec for given FPort")
+
+// Codec defines the interface of an application-layer payload codec.
+type Codec interface {
+	// Unmarshal decodes the given payload. The uplink argument defines the
+	// direction of the payload.
+	Unmarshal(uplink bool, b []byte) (interface{}, error)
+}
+
+// CodecFunc implements the Codec interface for an ordinary function.
+type CodecFunc func(uplink bool, b []byte) (interface{}, error)
+
+// Unmarshal calls f(uplink, b).
+func (f CodecFunc) Unmarshal(uplink bool, b []byte) (interface{}, error) {
+	return f(uplink, b)
+}
+
+var (
+	codecsMux sync.RWMutex
+	codecs    = make(map[uint8]Codec)
+
+	predefinedCodecs = map[uint8]Codec{
+		multicastsetup.DefaultFPort: CodecFunc(func(uplink bool, b []byte) (interface{}, error) {
+			var cmds multicastsetup.Commands
+			if err := cmds.UnmarshalBinary(uplink, b); err != nil {
+				return nil, err
+			}
+			return cmds, nil
+		}),
+		fragmentation.DefaultFPort: CodecFunc(func(uplink bool, b []byte) (interface{}, error) {
+			var cmds fragmentation.Commands
+			if err := cmds.UnmarshalBinary(uplink, b); err != nil {
+				return nil, err
+			}
+			return cmds, nil
+		}),
+		clocksync.DefaultFPort: CodecFunc(func(uplink bool, b []byte) (interface{}, error) {
+			var cmds clocksync.Commands
+			if err := cmds.UnmarshalBinary(uplink, b); err != nil {
+				return nil, err
+			}
+			return cmds, nil
+		}),
+		firmwaremanagement.DefaultFPort: CodecFunc(func(uplink bool, b []byte) (interface{}, error) {
+			var cmds firmwaremanagement.Commands
+			if err := cmds.UnmarshalBinary(uplink, b); err != nil {
+				return nil, err
+			}
+			return cmds, nil
+		}),
+		certification.DefaultFPort: CodecFunc(func(uplink bool, b []byte) (interface{}, error) {
+			var cmds certification.Commands
+			if err := cmds.UnmarshalBinary(uplink, b); err != nil {
+				return nil, err
+			}
+			return cmds, nil
+		}),
+	}
+)
+
+func init() {
+	for fPort, c := range predefinedCodecs {
+		codecs[fPort] = c
+	}
+}
+
+// RegisterCodec registers the given codec for the given FPort. Registering
+// a FPort which is already registered replaces the previous codec, e.g.
+// when a pre-defined package has been configured to use a non-default
+// FPort.
+func RegisterCodec(fPort uint8, c Codec) error {
+	// FPort 0 is used for mac-commands, FPorts 225..255 are reserved
+	if fPort == 0 || fPort > 224 {
+		return fmt.Errorf("lorawan/applayer: invalid FPort: %d", fPort)
+	}
+	if c == nil {
+		return errors.New("lorawan/applayer: codec must not be nil")
+	}
+
+	codecsMux.Lock()
+	defer codecsMux.Unlock()
+	codecs[fPort] = c
+
+	return nil
+}
+
+// UnregisterCodec removes the codec registered for the given FPort. For
+// the default FPorts of the pre-defined packages, the pre-defined codec is
+// restored.
+func UnregisterCodec(fPort uint8) {
+	codecsMux.Lock()
+	defer codecsMux.Unlock()
+
+	if c, ok := predefinedCodecs[fPort]; ok {
+		codecs[fPort] = c
+		return
+	}
+	delete(codecs, fPort)
+}
+
+// GetCodec returns the codec registered for the given FPort.
+func GetCodec(fPort uint8) (Codec, error) {
+	codecsMux.RLock()
+	defer codecsMux.RUnlock()
+
+	c, ok := codecs[fPort]
+	if !ok {
+		return nil, ErrNoCodecForFPort
+	}
+	return c, nil
+}
+
+// Unmarshal decodes the given uplink payload using the codec registered for
+// the given FPort. For the pre-defined packages, the returned value is of
+// the Commands type of the package (e.g. clocksync.Commands).
+func Unmarshal(fPort uint8, b []byte) (interface{}, error) {
+	return unmarshal(fPort, true, b)
+}
+
+// UnmarshalDownlink decodes the given downlink payload using the codec
+// registered for the given FPort (see Unmarshal).
+func UnmarshalDownlink(fPort uint8, b []byte) (interface{}, error) {
+	return unmarshal(fPort, false, b)
+}
+
+func unmarshal(fPort uint8, uplink bool, b []byte) (interface{}, error) {
+	c, err := GetCodec(fPort)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Unmarshal(uplink, b)
+}
@@ -0,0 +1,154 @@
+package applayer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan/applayer/certification"
+	"github.com/brocaar/lorawan/applayer/clocksync"
+	"github.com/brocaar/lorawan/applayer/firmwaremanagement"
+	"github.com/brocaar/lorawan/applayer/fragmentation"
+	"github.com/brocaar/lorawan/applayer/multicastsetup"
+)
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		Name          string
+		FPort         uint8
+		Uplink        bool
+		Bytes         []byte
+		Expected      interface{}
+		ExpectedError error
+	}{
+		{
+			Name:   "multicastsetup McGroupSetupAns",
+			FPort:  200,
+			Uplink: true,
+			Bytes:  []byte{0x02, 0x05},
+			Expected: multicastsetup.Commands{
+				{
+					CID: multicastsetup.McGroupSetupAns,
+					Payload: &multicastsetup.McGroupSetupAnsPayload{
+						McGroupIDHeader: multicastsetup.McGroupSetupAnsPayloadMcGroupIDHeader{
+							IDError:   true,
+							McGroupID: 1,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:   "fragmentation FragSessionDeleteReq",
+			FPort:  201,
+			Uplink: false,
+			Bytes:  []byte{0x03, 0x02},
+			Expected: fragmentation.Commands{
+				{
+					CID: fragmentation.FragSessionDeleteReq,
+					Payload: &fragmentation.FragSessionDeleteReqPayload{
+						Param: fragmentation.FragSessionDeleteReqPayloadParam{
+							FragIndex: 2,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:   "clocksync PackageVersionReq",
+			FPort:  202,
+			Uplink: false,
+			Bytes:  []byte{0x00},
+			Expected: clocksync.Commands{
+				{CID: clocksync.PackageVersionReq},
+			},
+		},
+		{
+			Name:   "firmwaremanagement DevVersionReq",
+			FPort:  203,
+			Uplink: false,
+			Bytes:  []byte{0x01},
+			Expected: firmwaremanagement.Commands{
+				{CID: firmwaremanagement.DevVersionReq},
+			},
+		},
+		{
+			Name:   "certification DutVersionsReq",
+			FPort:  224,
+			Uplink: false,
+			Bytes:  []byte{0x7f},
+			Expected: certification.Commands{
+				{CID: certification.DutVersionsReq},
+			},
+		},
+		{
+			Name:          "unregistered FPort",
+			FPort:         10,
+			Uplink:        true,
+			Bytes:         []byte{0x01},
+			ExpectedError: ErrNoCodecForFPort,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var out interface{}
+			var err error
+			if tst.Uplink {
+				out, err = Unmarshal(tst.FPort, tst.Bytes)
+			} else {
+				out, err = UnmarshalDownlink(tst.FPort, tst.Bytes)
+			}
+
+			if tst.ExpectedError != nil {
+				assert.Equal(tst.ExpectedError, err)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tst.Expected, out)
+		})
+	}
+}
+
+func TestRegisterCodec(t *testing.T) {
+	assert := require.New(t)
+
+	raw := CodecFunc(func(uplink bool, b []byte) (interface{}, error) {
+		if !uplink {
+			return nil, errors.New("uplink only")
+		}
+		return b, nil
+	})
+
+	assert.Error(RegisterCodec(0, raw))
+	assert.Error(RegisterCodec(225, raw))
+	assert.Error(RegisterCodec(10, nil))
+
+	// user-defined FPort
+	assert.NoError(RegisterCodec(10, raw))
+	out, err := Unmarshal(10, []byte{0x01, 0x02})
+	assert.NoError(err)
+	assert.Equal([]byte{0x01, 0x02}, out)
+
+	_, err = UnmarshalDownlink(10, []byte{0x01})
+	assert.Error(err)
+
+	UnregisterCodec(10)
+	_, err = Unmarshal(10, []byte{0x01})
+	assert.Equal(ErrNoCodecForFPort, err)
+
+	// overriding and restoring a pre-defined FPort
+	assert.NoError(RegisterCodec(clocksync.DefaultFPort, raw))
+	out, err = Unmarshal(clocksync.DefaultFPort, []byte{0x00})
+	assert.NoError(err)
+	assert.Equal([]byte{0x00}, out)
+
+	UnregisterCodec(clocksync.DefaultFPort)
+	out, err = Unmarshal(clocksync.DefaultFPort, []byte{0x00, 0x01, 0x01})
+	assert.NoError(err)
+	assert.IsType(clocksync.Commands{}, out)
+}
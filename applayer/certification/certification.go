@@ -0,0 +1,589 @@
+//go:generate stringer -type=CID
+
+// Package certification implements the LoRaWAN Certification Protocol
+// (TS009) v1.0.0, used for communicating with a device under test (DUT)
+// during (pre-)certification testing. The legacy Certification Protocol,
+// implemented by LoRaWAN 1.0.x devices, is provided by the Legacy prefixed
+// types.
+package certification
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// CID defines the command identifier.
+type CID byte
+
+// DefaultFPort defines the fPort reserved for the Certification Protocol.
+const DefaultFPort uint8 = 224
+
+// Package identifier and version as returned in the PackageVersionAns.
+const (
+	PackageIdentifier uint8 = 6
+	PackageVersion    uint8 = 1
+)
+
+// Available command identifiers.
+const (
+	PackageVersionReq        CID = 0x00
+	PackageVersionAns        CID = 0x00
+	DutResetReq              CID = 0x01
+	DutJoinReq               CID = 0x02
+	SwitchClassReq           CID = 0x03
+	ADRBitChangeReq          CID = 0x04
+	RegionalDutyCycleCtrlReq CID = 0x05
+	TxPeriodicityChangeReq   CID = 0x06
+	TxFramesCtrlReq          CID = 0x07
+	EchoPayloadReq           CID = 0x08
+	EchoPayloadAns           CID = 0x08
+	RxAppCntReq              CID = 0x09
+	RxAppCntAns              CID = 0x09
+	RxAppCntResetReq         CID = 0x0a
+	LinkCheckReq             CID = 0x20
+	DeviceTimeReq            CID = 0x21
+	PingSlotInfoReq          CID = 0x22
+	TxCwReq                  CID = 0x7d
+	DutFPort224DisableReq    CID = 0x7e
+	DutVersionsReq           CID = 0x7f
+	DutVersionsAns           CID = 0x7f
+)
+
+// Class defines the device class used by the SwitchClassReq.
+type Class uint8
+
+// Available classes.
+const (
+	ClassA Class = 0
+	ClassB Class = 1
+	ClassC Class = 2
+)
+
+// FrameType defines the frame type used by the TxFramesCtrlReq.
+type FrameType uint8
+
+// Available frame types.
+const (
+	FrameTypeNoChange    FrameType = 0
+	FrameTypeUnconfirmed FrameType = 1
+	FrameTypeConfirmed   FrameType = 2
+)
+
+// Errors
+var (
+	ErrNoPayloadForCID = errors.New("lorawan/applayer/certification: no payload for given CID")
+)
+
+// map[uplink]...
+var commandPayloadRegistry = map[bool]map[CID]func() CommandPayload{
+	true: map[CID]func() CommandPayload{
+		PackageVersionAns: func() CommandPayload { return &PackageVersionAnsPayload{} },
+		EchoPayloadAns:    func() CommandPayload { return &EchoPayloadAnsPayload{} },
+		RxAppCntAns:       func() CommandPayload { return &RxAppCntAnsPayload{} },
+		DutVersionsAns:    func() CommandPayload { return &DutVersionsAnsPayload{} },
+	},
+	false: map[CID]func() CommandPayload{
+		SwitchClassReq:           func() CommandPayload { return &SwitchClassReqPayload{} },
+		ADRBitChangeReq:          func() CommandPayload { return &ADRBitChangeReqPayload{} },
+		RegionalDutyCycleCtrlReq: func() CommandPayload { return &RegionalDutyCycleCtrlReqPayload{} },
+		TxPeriodicityChangeReq:   func() CommandPayload { return &TxPeriodicityChangeReqPayload{} },
+		TxFramesCtrlReq:          func() CommandPayload { return &TxFramesCtrlReqPayload{} },
+		EchoPayloadReq:           func() CommandPayload { return &EchoPayloadReqPayload{} },
+		PingSlotInfoReq:          func() CommandPayload { return &PingSlotInfoReqPayload{} },
+		TxCwReq:                  func() CommandPayload { return &TxCwReqPayload{} },
+	},
+}
+
+// GetCommandPayload returns a new CommandPayload for the given CID.
+func GetCommandPayload(uplink bool, c CID) (CommandPayload, error) {
+	v, ok := commandPayloadRegistry[uplink][c]
+	if !ok {
+		return nil, ErrNoPayloadForCID
+	}
+
+	return v(), nil
+}
+
+// CommandPayload defines the interface that a command payload must implement.
+type CommandPayload interface {
+	MarshalBinary() (data []byte, err error)
+	UnmarshalBinary(data []byte) error
+	Size() int
+}
+
+// Command defines the Command structure.
+type Command struct {
+	CID     CID
+	Payload CommandPayload
+}
+
+// MarshalBinary encodes the command to a slice of bytes.
+func (c Command) MarshalBinary() ([]byte, error) {
+	b := []byte{byte(c.CID)}
+
+	if c.Payload != nil {
+		p, err := c.Payload.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, p...)
+	}
+
+	return b, nil
+}
+
+// UnmarshalBinary decodes a slice of bytes into a command.
+func (c *Command) UnmarshalBinary(uplink bool, data []byte) error {
+	if len(data) == 0 {
+		return errors.New("lorawan/applayer/certification: at least 1 byte is expected")
+	}
+
+	c.CID = CID(data[0])
+
+	p, err := GetCommandPayload(uplink, c.CID)
+	if err != nil {
+		if err == ErrNoPayloadForCID {
+			return nil
+		}
+		return err
+	}
+
+	c.Payload = p
+	if err := c.Payload.UnmarshalBinary(data[1:]); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Size returns the size of the command in bytes.
+func (c Command) Size() int {
+	if c.Payload != nil {
+		return c.Payload.Size() + 1
+	}
+	return 1
+}
+
+// Commands defines a slice of commands.
+type Commands []Command
+
+// MarshalBinary encodes the commands to a slice of bytes.
+func (c Commands) MarshalBinary() ([]byte, error) {
+	var out []byte
+
+	for _, cmd := range c {
+		b, err := cmd.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b...)
+	}
+	return out, nil
+}
+
+// UnmarshalBinary decodes a slice of bytes into a slice of commands.
+func (c *Commands) UnmarshalBinary(uplink bool, data []byte) error {
+	var i int
+
+	for i < len(data) {
+		var cmd Command
+		if err := cmd.UnmarshalBinary(uplink, data[i:]); err != nil {
+			return err
+		}
+		i += cmd.Size()
+		*c = append(*c, cmd)
+	}
+
+	return nil
+}
+
+// PackageVersionAnsPayload implements the PackageVersionAns payload.
+type PackageVersionAnsPayload struct {
+	PackageIdentifier uint8
+	PackageVersion    uint8
+}
+
+// Size returns the payload size in bytes.
+func (p PackageVersionAnsPayload) Size() int {
+	return 2
+}
+
+// MarshalBinary encodes the payload to a slice of bytes.
+func (p PackageVersionAnsPayload) MarshalBinary() ([]byte, error) {
+	return []byte{
+		p.PackageIdentifier,
+		p.PackageVersion,
+	}, nil
+}
+
+// UnmarshalBinary decodes the payload from a slice of bytes.
+func (p *PackageVersionAnsPayload) UnmarshalBinary(data []byte) error {
+	if len(data) < p.Size() {
+		return fmt.Errorf("lorawan/applayer/certification: %d bytes are expected", p.Size())
+	}
+
+	p.PackageIdentifier = data[0]
+	p.PackageVersion = data[1]
+	return nil
+}
+
+// SwitchClassReqPayload implements the SwitchClassReq payload.
+type SwitchClassReqPayload struct {
+	Class Class
+}
+
+// Size returns the payload size in bytes.
+func (p SwitchClassReqPayload) Size() int {
+	return 1
+}
+
+// MarshalBinary encodes the payload to a slice of bytes.
+func (p SwitchClassReqPayload) MarshalBinary() ([]byte, error) {
+	if p.Class > ClassC {
+		return nil, fmt.Errorf("lorawan/applayer/certification: invalid Class: %d", p.Class)
+	}
+	return []byte{byte(p.Class)}, nil
+}
+
+// UnmarshalBinary decodes the payload from a slice of bytes.
+func (p *SwitchClassReqPayload) UnmarshalBinary(data []byte) error {
+	if len(data) < p.Size() {
+		return fmt.Errorf("lorawan/applayer/certification: %d bytes are expected", p.Size())
+	}
+
+	p.Class = Class(data[0])
+	return nil
+}
+
+// ADRBitChangeReqPayload implements the ADRBitChangeReq payload.
+type ADRBitChangeReqPayload struct {
+	ADREnabled bool
+}
+
+// Size returns the payload size in bytes.
+func (p ADRBitChangeReqPayload) Size() int {
+	return 1
+}
+
+// MarshalBinary encodes the payload to a slice of bytes.
+func (p ADRBitChangeReqPayload) MarshalBinary() ([]byte, error) {
+	b := make([]byte, p.Size())
+	if p.ADREnabled {
+		b[0] = 0x01
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes the payload from a slice of bytes.
+func (p *ADRBitChangeReqPayload) UnmarshalBinary(data []byte) error {
+	if len(data) < p.Size() {
+		return fmt.Errorf("lorawan/applayer/certification: %d bytes are expected", p.Size())
+	}
+
+	p.ADREnabled = data[0]&0x01 != 0
+	return nil
+}
+
+// RegionalDutyCycleCtrlReqPayload implements the RegionalDutyCycleCtrlReq payload.
+type RegionalDutyCycleCtrlReqPayload struct {
+	DutyCycleEnabled bool
+}
+
+// Size returns the payload size in bytes.
+func (p RegionalDutyCycleCtrlReqPayload) Size() int {
+	return 1
+}
+
+// MarshalBinary encodes the payload to a slice of bytes.
+func (p RegionalDutyCycleCtrlReqPayload) MarshalBinary() ([]byte, error) {
+	b := make([]byte, p.Size())
+	if p.DutyCycleEnabled {
+		b[0] = 0x01
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes the payload from a slice of bytes.
+func (p *RegionalDutyCycleCtrlReqPayload) UnmarshalBinary(data []byte) error {
+	if len(data) < p.Size() {
+		return fmt.Errorf("lorawan/applayer/certification: %d bytes are expected", p.Size())
+	}
+
+	p.DutyCycleEnabled = data[0]&0x01 != 0
+	return nil
+}
+
+// TxPeriodicityChangeReqPayload implements the TxPeriodicityChangeReq payload.
+type TxPeriodicityChangeReqPayload struct {
+	// Periodicity defines the uplink periodicity index, where 0 means the
+	// default periodicity of the DUT and 1 - 10 respectively 5, 10, 20, 30,
+	// 40, 50, 60, 120, 240 and 480 seconds.
+	Periodicity uint8
+}
+
+// Size returns the payload size in bytes.
+func (p TxPeriodicityChangeReqPayload) Size() int {
+	return 1
+}
+
+// MarshalBinary encodes the payload to a slice of bytes.
+func (p TxPeriodicityChangeReqPayload) MarshalBinary() ([]byte, error) {
+	if p.Periodicity > 10 {
+		return nil, errors.New("lorawan/applayer/certification: max value of Periodicity is 10")
+	}
+	return []byte{p.Periodicity}, nil
+}
+
+// UnmarshalBinary decodes the payload from a slice of bytes.
+func (p *TxPeriodicityChangeReqPayload) UnmarshalBinary(data []byte) error {
+	if len(data) < p.Size() {
+		return fmt.Errorf("lorawan/applayer/certification: %d bytes are expected", p.Size())
+	}
+
+	p.Periodicity = data[0]
+	return nil
+}
+
+// TxFramesCtrlReqPayload implements the TxFramesCtrlReq payload.
+type TxFramesCtrlReqPayload struct {
+	FrameType FrameType
+}
+
+// Size returns the payload size in bytes.
+func (p TxFramesCtrlReqPayload) Size() int {
+	return 1
+}
+
+// MarshalBinary encodes the payload to a slice of bytes.
+func (p TxFramesCtrlReqPayload) MarshalBinary() ([]byte, error) {
+	if p.FrameType > FrameTypeConfirmed {
+		return nil, fmt.Errorf("lorawan/applayer/certification: invalid FrameType: %d", p.FrameType)
+	}
+	return []byte{byte(p.FrameType)}, nil
+}
+
+// UnmarshalBinary decodes the payload from a slice of bytes.
+func (p *TxFramesCtrlReqPayload) UnmarshalBinary(data []byte) error {
+	if len(data) < p.Size() {
+		return fmt.Errorf("lorawan/applayer/certification: %d bytes are expected", p.Size())
+	}
+
+	p.FrameType = FrameType(data[0])
+	return nil
+}
+
+// EchoPayloadReqPayload implements the EchoPayloadReq payload.
+type EchoPayloadReqPayload struct {
+	Payload []byte
+}
+
+// Size returns the payload size in bytes.
+func (p EchoPayloadReqPayload) Size() int {
+	return len(p.Payload)
+}
+
+// MarshalBinary encodes the payload to a slice of bytes.
+func (p EchoPayloadReqPayload) MarshalBinary() ([]byte, error) {
+	b := make([]byte, p.Size())
+	copy(b, p.Payload)
+	return b, nil
+}
+
+// UnmarshalBinary decodes the payload from a slice of bytes.
+func (p *EchoPayloadReqPayload) UnmarshalBinary(data []byte) error {
+	p.Payload = make([]byte, len(data))
+	copy(p.Payload, data)
+	return nil
+}
+
+// EchoPayloadAnsPayload implements the EchoPayloadAns payload.
+type EchoPayloadAnsPayload struct {
+	Payload []byte
+}
+
+// NewEchoPayloadAnsPayload returns the EchoPayloadAns payload for the given
+// EchoPayloadReq payload. Each byte of the echoed payload is incremented by
+// one (modulo 256).
+func NewEchoPayloadAnsPayload(req EchoPayloadReqPayload) EchoPayloadAnsPayload {
+	out := EchoPayloadAnsPayload{
+		Payload: make([]byte, len(req.Payload)),
+	}
+	for i, b := range req.Payload {
+		out.Payload[i] = b + 1
+	}
+	return out
+}
+
+// Size returns the payload size in bytes.
+func (p EchoPayloadAnsPayload) Size() int {
+	return len(p.Payload)
+}
+
+// MarshalBinary encodes the payload to a slice of bytes.
+func (p EchoPayloadAnsPayload) MarshalBinary() ([]byte, error) {
+	b := make([]byte, p.Size())
+	copy(b, p.Payload)
+	return b, nil
+}
+
+// UnmarshalBinary decodes the payload from a slice of bytes.
+func (p *EchoPayloadAnsPayload) UnmarshalBinary(data []byte) error {
+	p.Payload = make([]byte, len(data))
+	copy(p.Payload, data)
+	return nil
+}
+
+// RxAppCntAnsPayload implements the RxAppCntAns payload.
+type RxAppCntAnsPayload struct {
+	// RxAppCnt contains the number of downlinks received by the DUT on
+	// FPort 224 (modulo 2^16).
+	RxAppCnt uint16
+}
+
+// Size returns the payload size in bytes.
+func (p RxAppCntAnsPayload) Size() int {
+	return 2
+}
+
+// MarshalBinary encodes the payload to a slice of bytes.
+func (p RxAppCntAnsPayload) MarshalBinary() ([]byte, error) {
+	b := make([]byte, p.Size())
+	binary.LittleEndian.PutUint16(b, p.RxAppCnt)
+	return b, nil
+}
+
+// UnmarshalBinary decodes the payload from a slice of bytes.
+func (p *RxAppCntAnsPayload) UnmarshalBinary(data []byte) error {
+	if len(data) < p.Size() {
+		return fmt.Errorf("lorawan/applayer/certification: %d bytes are expected", p.Size())
+	}
+
+	p.RxAppCnt = binary.LittleEndian.Uint16(data[0:2])
+	return nil
+}
+
+// PingSlotInfoReqPayload implements the PingSlotInfoReq payload. It
+// requests the DUT to send a PingSlotInfoReq mac-command.
+type PingSlotInfoReqPayload struct {
+	Periodicity uint8
+}
+
+// Size returns the payload size in bytes.
+func (p PingSlotInfoReqPayload) Size() int {
+	return 1
+}
+
+// MarshalBinary encodes the payload to a slice of bytes.
+func (p PingSlotInfoReqPayload) MarshalBinary() ([]byte, error) {
+	if p.Periodicity > 7 {
+		return nil, errors.New("lorawan/applayer/certification: max value of Periodicity is 7")
+	}
+	return []byte{p.Periodicity}, nil
+}
+
+// UnmarshalBinary decodes the payload from a slice of bytes.
+func (p *PingSlotInfoReqPayload) UnmarshalBinary(data []byte) error {
+	if len(data) < p.Size() {
+		return fmt.Errorf("lorawan/applayer/certification: %d bytes are expected", p.Size())
+	}
+
+	p.Periodicity = data[0] & 0x07
+	return nil
+}
+
+// TxCwReqPayload implements the TxCwReq payload, requesting the DUT to
+// transmit a continuous wave.
+type TxCwReqPayload struct {
+	Timeout   uint16 // seconds
+	Frequency uint32 // Hz, must be a multiple of 100
+	TxPower   int8   // dBm
+}
+
+// Size returns the payload size in bytes.
+func (p TxCwReqPayload) Size() int {
+	return 6
+}
+
+// MarshalBinary encodes the payload to a slice of bytes.
+func (p TxCwReqPayload) MarshalBinary() ([]byte, error) {
+	if p.Frequency%100 != 0 {
+		return nil, errors.New("lorawan/applayer/certification: Frequency must be a multiple of 100")
+	}
+	if p.Frequency/100 >= 1<<24 {
+		return nil, errors.New("lorawan/applayer/certification: max value of Frequency is 2^24-1 * 100")
+	}
+
+	b := make([]byte, p.Size())
+	binary.LittleEndian.PutUint16(b[0:2], p.Timeout)
+
+	freq := make([]byte, 4)
+	binary.LittleEndian.PutUint32(freq, p.Frequency/100)
+	copy(b[2:5], freq)
+
+	b[5] = byte(p.TxPower)
+	return b, nil
+}
+
+// UnmarshalBinary decodes the payload from a slice of bytes.
+func (p *TxCwReqPayload) UnmarshalBinary(data []byte) error {
+	if len(data) < p.Size() {
+		return fmt.Errorf("lorawan/applayer/certification: %d bytes are expected", p.Size())
+	}
+
+	p.Timeout = binary.LittleEndian.Uint16(data[0:2])
+
+	freq := make([]byte, 4)
+	copy(freq, data[2:5])
+	p.Frequency = binary.LittleEndian.Uint32(freq) * 100
+
+	p.TxPower = int8(data[5])
+	return nil
+}
+
+// Version defines a version as Major.Minor.Patch.Revision.
+type Version struct {
+	Major    uint8
+	Minor    uint8
+	Patch    uint8
+	Revision uint8
+}
+
+// String implements fmt.Stringer.
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d.%d", v.Major, v.Minor, v.Patch, v.Revision)
+}
+
+// DutVersionsAnsPayload implements the DutVersionsAns payload.
+type DutVersionsAnsPayload struct {
+	FwVersion      Version
+	LrwanVersion   Version
+	LrwanRpVersion Version
+}
+
+// Size returns the payload size in bytes.
+func (p DutVersionsAnsPayload) Size() int {
+	return 12
+}
+
+// MarshalBinary encodes the payload to a slice of bytes.
+func (p DutVersionsAnsPayload) MarshalBinary() ([]byte, error) {
+	var b []byte
+	for _, v := range []Version{p.FwVersion, p.LrwanVersion, p.LrwanRpVersion} {
+		b = append(b, v.Major, v.Minor, v.Patch, v.Revision)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes the payload from a slice of bytes.
+func (p *DutVersionsAnsPayload) UnmarshalBinary(data []byte) error {
+	if len(data) < p.Size() {
+		return fmt.Errorf("lorawan/applayer/certification: %d bytes are expected", p.Size())
+	}
+
+	for i, v := range []*Version{&p.FwVersion, &p.LrwanVersion, &p.LrwanRpVersion} {
+		b := data[i*4 : (i+1)*4]
+		*v = Version{Major: b[0], Minor: b[1], Patch: b[2], Revision: b[3]}
+	}
+	return nil
+}
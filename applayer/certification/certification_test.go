@@ -0,0 +1,338 @@
+package certification
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertification(t *testing.T) {
+	tests := []struct {
+		Name                   string
+		Command                Command
+		Bytes                  []byte
+		Uplink                 bool
+		ExpectedMarshalError   error
+		ExpectedUnmarshalError error
+	}{
+		{
+			Name: "PackageVersionReq",
+			Command: Command{
+				CID: PackageVersionReq,
+			},
+			Bytes: []byte{0x00},
+		},
+		{
+			Name:   "PackageVersionAns",
+			Uplink: true,
+			Command: Command{
+				CID: PackageVersionAns,
+				Payload: &PackageVersionAnsPayload{
+					PackageIdentifier: PackageIdentifier,
+					PackageVersion:    PackageVersion,
+				},
+			},
+			Bytes: []byte{0x00, 0x06, 0x01},
+		},
+		{
+			Name: "DutResetReq",
+			Command: Command{
+				CID: DutResetReq,
+			},
+			Bytes: []byte{0x01},
+		},
+		{
+			Name: "SwitchClassReq",
+			Command: Command{
+				CID: SwitchClassReq,
+				Payload: &SwitchClassReqPayload{
+					Class: ClassC,
+				},
+			},
+			Bytes: []byte{0x03, 0x02},
+		},
+		{
+			Name: "SwitchClassReq invalid class",
+			Command: Command{
+				CID: SwitchClassReq,
+				Payload: &SwitchClassReqPayload{
+					Class: 3,
+				},
+			},
+			ExpectedMarshalError: errors.New("lorawan/applayer/certification: invalid Class: 3"),
+		},
+		{
+			Name: "ADRBitChangeReq",
+			Command: Command{
+				CID: ADRBitChangeReq,
+				Payload: &ADRBitChangeReqPayload{
+					ADREnabled: true,
+				},
+			},
+			Bytes: []byte{0x04, 0x01},
+		},
+		{
+			Name: "RegionalDutyCycleCtrlReq",
+			Command: Command{
+				CID: RegionalDutyCycleCtrlReq,
+				Payload: &RegionalDutyCycleCtrlReqPayload{
+					DutyCycleEnabled: true,
+				},
+			},
+			Bytes: []byte{0x05, 0x01},
+		},
+		{
+			Name: "TxPeriodicityChangeReq",
+			Command: Command{
+				CID: TxPeriodicityChangeReq,
+				Payload: &TxPeriodicityChangeReqPayload{
+					Periodicity: 5,
+				},
+			},
+			Bytes: []byte{0x06, 0x05},
+		},
+		{
+			Name: "TxPeriodicityChangeReq invalid periodicity",
+			Command: Command{
+				CID: TxPeriodicityChangeReq,
+				Payload: &TxPeriodicityChangeReqPayload{
+					Periodicity: 11,
+				},
+			},
+			ExpectedMarshalError: errors.New("lorawan/applayer/certification: max value of Periodicity is 10"),
+		},
+		{
+			Name: "TxFramesCtrlReq",
+			Command: Command{
+				CID: TxFramesCtrlReq,
+				Payload: &TxFramesCtrlReqPayload{
+					FrameType: FrameTypeConfirmed,
+				},
+			},
+			Bytes: []byte{0x07, 0x02},
+		},
+		{
+			Name: "EchoPayloadReq",
+			Command: Command{
+				CID: EchoPayloadReq,
+				Payload: &EchoPayloadReqPayload{
+					Payload: []byte{0x01, 0x02, 0xff},
+				},
+			},
+			Bytes: []byte{0x08, 0x01, 0x02, 0xff},
+		},
+		{
+			Name:   "EchoPayloadAns",
+			Uplink: true,
+			Command: Command{
+				CID: EchoPayloadAns,
+				Payload: &EchoPayloadAnsPayload{
+					Payload: []byte{0x02, 0x03, 0x00},
+				},
+			},
+			Bytes: []byte{0x08, 0x02, 0x03, 0x00},
+		},
+		{
+			Name:   "RxAppCntAns",
+			Uplink: true,
+			Command: Command{
+				CID: RxAppCntAns,
+				Payload: &RxAppCntAnsPayload{
+					RxAppCnt: 0x0201,
+				},
+			},
+			Bytes: []byte{0x09, 0x01, 0x02},
+		},
+		{
+			Name:                   "RxAppCntAns invalid bytes",
+			Uplink:                 true,
+			Bytes:                  []byte{0x09, 0x01},
+			ExpectedUnmarshalError: errors.New("lorawan/applayer/certification: 2 bytes are expected"),
+		},
+		{
+			Name: "PingSlotInfoReq",
+			Command: Command{
+				CID: PingSlotInfoReq,
+				Payload: &PingSlotInfoReqPayload{
+					Periodicity: 7,
+				},
+			},
+			Bytes: []byte{0x22, 0x07},
+		},
+		{
+			Name: "TxCwReq",
+			Command: Command{
+				CID: TxCwReq,
+				Payload: &TxCwReqPayload{
+					Timeout:   10,
+					Frequency: 868100000,
+					TxPower:   14,
+				},
+			},
+			Bytes: []byte{0x7d, 0x0a, 0x00, 0x28, 0x76, 0x84, 0x0e},
+		},
+		{
+			Name: "TxCwReq invalid frequency",
+			Command: Command{
+				CID: TxCwReq,
+				Payload: &TxCwReqPayload{
+					Frequency: 868100001,
+				},
+			},
+			ExpectedMarshalError: errors.New("lorawan/applayer/certification: Frequency must be a multiple of 100"),
+		},
+		{
+			Name: "DutVersionsReq",
+			Command: Command{
+				CID: DutVersionsReq,
+			},
+			Bytes: []byte{0x7f},
+		},
+		{
+			Name:   "DutVersionsAns",
+			Uplink: true,
+			Command: Command{
+				CID: DutVersionsAns,
+				Payload: &DutVersionsAnsPayload{
+					FwVersion:      Version{Major: 1, Minor: 2, Patch: 3, Revision: 4},
+					LrwanVersion:   Version{Major: 1, Minor: 0, Patch: 4},
+					LrwanRpVersion: Version{Major: 2, Minor: 1, Patch: 0, Revision: 1},
+				},
+			},
+			Bytes: []byte{0x7f, 0x01, 0x02, 0x03, 0x04, 0x01, 0x00, 0x04, 0x00, 0x02, 0x01, 0x00, 0x01},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			if tst.ExpectedMarshalError != nil {
+				_, err := tst.Command.MarshalBinary()
+				assert.Equal(tst.ExpectedMarshalError, err)
+			} else if tst.ExpectedUnmarshalError != nil {
+				var cmd Command
+				err := cmd.UnmarshalBinary(tst.Uplink, tst.Bytes)
+				assert.Equal(tst.ExpectedUnmarshalError, err)
+			} else {
+				cmds := Commands{tst.Command}
+				b, err := cmds.MarshalBinary()
+				assert.NoError(err)
+				assert.Equal(tst.Bytes, b)
+
+				cmds = Commands{}
+				assert.NoError(cmds.UnmarshalBinary(tst.Uplink, tst.Bytes))
+				assert.Len(cmds, 1)
+				assert.Equal(tst.Command, cmds[0])
+			}
+		})
+	}
+}
+
+func TestNewEchoPayloadAnsPayload(t *testing.T) {
+	assert := require.New(t)
+
+	ans := NewEchoPayloadAnsPayload(EchoPayloadReqPayload{Payload: []byte{0x00, 0x7f, 0xff}})
+	assert.Equal([]byte{0x01, 0x80, 0x00}, ans.Payload)
+}
+
+func TestCIDString(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal("PackageVersionReq", PackageVersionReq.String())
+	assert.Equal("RxAppCntResetReq", RxAppCntResetReq.String())
+	assert.Equal("PingSlotInfoReq", PingSlotInfoReq.String())
+	assert.Equal("DutVersionsReq", DutVersionsReq.String())
+	assert.Equal("CID(64)", CID(64).String())
+}
+
+func TestLegacyCommand(t *testing.T) {
+	tests := []struct {
+		Name                   string
+		Command                LegacyCommand
+		Bytes                  []byte
+		Uplink                 bool
+		ExpectedUnmarshalError error
+	}{
+		{
+			Name:    "LegacyDeactivateTestModeReq",
+			Command: LegacyCommand{CID: LegacyDeactivateTestModeReq},
+			Bytes:   []byte{0x00},
+		},
+		{
+			Name: "LegacyActivateTestModeReq",
+			Command: LegacyCommand{
+				CID:     LegacyActivateTestModeReq,
+				Payload: &LegacyActivateTestModeReqPayload{},
+			},
+			Bytes: []byte{0x01, 0x01, 0x01, 0x01},
+		},
+		{
+			Name:                   "LegacyActivateTestModeReq invalid payload",
+			Bytes:                  []byte{0x01, 0x01, 0x02, 0x01},
+			ExpectedUnmarshalError: errors.New("lorawan/applayer/certification: invalid test-mode activation payload"),
+		},
+		{
+			Name: "LegacyEchoReq",
+			Command: LegacyCommand{
+				CID:     LegacyEchoReq,
+				Payload: &EchoPayloadReqPayload{Payload: []byte{0x01, 0x02}},
+			},
+			Bytes: []byte{0x04, 0x01, 0x02},
+		},
+		{
+			Name:   "LegacyEchoAns",
+			Uplink: true,
+			Command: LegacyCommand{
+				CID:     LegacyEchoAns,
+				Payload: &EchoPayloadAnsPayload{Payload: []byte{0x02, 0x03}},
+			},
+			Bytes: []byte{0x04, 0x02, 0x03},
+		},
+		{
+			Name: "LegacyTxCwReq",
+			Command: LegacyCommand{
+				CID: LegacyTxCwReq,
+				Payload: &TxCwReqPayload{
+					Timeout:   10,
+					Frequency: 868100000,
+					TxPower:   14,
+				},
+			},
+			Bytes: []byte{0x07, 0x0a, 0x00, 0x28, 0x76, 0x84, 0x0e},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var cmd LegacyCommand
+			err := cmd.UnmarshalBinary(tst.Uplink, tst.Bytes)
+			if tst.ExpectedUnmarshalError != nil {
+				assert.Equal(tst.ExpectedUnmarshalError, err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tst.Command, cmd)
+
+			b, err := tst.Command.MarshalBinary()
+			assert.NoError(err)
+			assert.Equal(tst.Bytes, b)
+		})
+	}
+}
+
+func TestLegacyDownlinkCounterPayload(t *testing.T) {
+	assert := require.New(t)
+
+	b, err := LegacyDownlinkCounterPayload{DownlinkCounter: 258}.MarshalBinary()
+	assert.NoError(err)
+	assert.Equal([]byte{0x01, 0x02}, b)
+
+	var pl LegacyDownlinkCounterPayload
+	assert.NoError(pl.UnmarshalBinary(b))
+	assert.Equal(uint16(258), pl.DownlinkCounter)
+	assert.Error(pl.UnmarshalBinary([]byte{0x01}))
+}
@@ -0,0 +1,154 @@
+package certification
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// LegacyCID defines the command identifier of the legacy (pre-TS009)
+// LoRaWAN Certification Protocol, as implemented by LoRaWAN 1.0.x devices.
+// Unlike TS009, each frame on the DefaultFPort contains a single command.
+type LegacyCID byte
+
+// Available legacy command identifiers.
+const (
+	LegacyDeactivateTestModeReq LegacyCID = 0x00
+	LegacyActivateTestModeReq   LegacyCID = 0x01
+	LegacyConfirmedFramesReq    LegacyCID = 0x02
+	LegacyUnconfirmedFramesReq  LegacyCID = 0x03
+	LegacyEchoReq               LegacyCID = 0x04
+	LegacyEchoAns               LegacyCID = 0x04
+	LegacyLinkCheckReq          LegacyCID = 0x05
+	LegacyJoinReq               LegacyCID = 0x06
+	LegacyTxCwReq               LegacyCID = 0x07
+)
+
+// map[uplink]...
+var legacyCommandPayloadRegistry = map[bool]map[LegacyCID]func() CommandPayload{
+	true: map[LegacyCID]func() CommandPayload{
+		LegacyEchoAns: func() CommandPayload { return &EchoPayloadAnsPayload{} },
+	},
+	false: map[LegacyCID]func() CommandPayload{
+		LegacyActivateTestModeReq: func() CommandPayload { return &LegacyActivateTestModeReqPayload{} },
+		LegacyEchoReq:             func() CommandPayload { return &EchoPayloadReqPayload{} },
+		LegacyTxCwReq:             func() CommandPayload { return &TxCwReqPayload{} },
+	},
+}
+
+// GetLegacyCommandPayload returns a new CommandPayload for the given legacy
+// CID.
+func GetLegacyCommandPayload(uplink bool, c LegacyCID) (CommandPayload, error) {
+	v, ok := legacyCommandPayloadRegistry[uplink][c]
+	if !ok {
+		return nil, ErrNoPayloadForCID
+	}
+
+	return v(), nil
+}
+
+// LegacyCommand defines the legacy Certification Protocol command
+// structure. In the uplink direction, the only command is the LegacyEchoAns.
+// All other uplinks in test-mode contain the LegacyDownlinkCounterPayload.
+type LegacyCommand struct {
+	CID     LegacyCID
+	Payload CommandPayload
+}
+
+// MarshalBinary encodes the command to a slice of bytes.
+func (c LegacyCommand) MarshalBinary() ([]byte, error) {
+	b := []byte{byte(c.CID)}
+
+	if c.Payload != nil {
+		p, err := c.Payload.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, p...)
+	}
+
+	return b, nil
+}
+
+// UnmarshalBinary decodes a slice of bytes into a command.
+func (c *LegacyCommand) UnmarshalBinary(uplink bool, data []byte) error {
+	if len(data) == 0 {
+		return errors.New("lorawan/applayer/certification: at least 1 byte is expected")
+	}
+
+	c.CID = LegacyCID(data[0])
+	c.Payload = nil
+
+	p, err := GetLegacyCommandPayload(uplink, c.CID)
+	if err != nil {
+		if err == ErrNoPayloadForCID {
+			return nil
+		}
+		return err
+	}
+
+	c.Payload = p
+	if err := c.Payload.UnmarshalBinary(data[1:]); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LegacyActivateTestModeReqPayload implements the LegacyActivateTestModeReq
+// payload. The device only enters test-mode when the CID is followed by
+// three 0x01 bytes.
+type LegacyActivateTestModeReqPayload struct{}
+
+// Size returns the payload size in bytes.
+func (p LegacyActivateTestModeReqPayload) Size() int {
+	return 3
+}
+
+// MarshalBinary encodes the payload to a slice of bytes.
+func (p LegacyActivateTestModeReqPayload) MarshalBinary() ([]byte, error) {
+	return []byte{0x01, 0x01, 0x01}, nil
+}
+
+// UnmarshalBinary decodes the payload from a slice of bytes.
+func (p *LegacyActivateTestModeReqPayload) UnmarshalBinary(data []byte) error {
+	if len(data) != p.Size() {
+		return fmt.Errorf("lorawan/applayer/certification: %d bytes are expected", p.Size())
+	}
+	for _, b := range data {
+		if b != 0x01 {
+			return errors.New("lorawan/applayer/certification: invalid test-mode activation payload")
+		}
+	}
+	return nil
+}
+
+// LegacyDownlinkCounterPayload implements the uplink payload sent by the
+// device in test-mode, containing the number of received downlinks on the
+// DefaultFPort.
+type LegacyDownlinkCounterPayload struct {
+	DownlinkCounter uint16
+}
+
+// Size returns the payload size in bytes.
+func (p LegacyDownlinkCounterPayload) Size() int {
+	return 2
+}
+
+// MarshalBinary encodes the payload to a slice of bytes. Note that, unlike
+// the other payloads, the counter is encoded MSB first.
+func (p LegacyDownlinkCounterPayload) MarshalBinary() ([]byte, error) {
+	b := make([]byte, p.Size())
+	binary.BigEndian.PutUint16(b, p.DownlinkCounter)
+	return b, nil
+}
+
+// UnmarshalBinary decodes the payload from a slice of bytes.
+func (p *LegacyDownlinkCounterPayload) UnmarshalBinary(data []byte) error {
+	if len(data) != p.Size() {
+		return fmt.Errorf("lorawan/applayer/certification: %d bytes are expected", p.Size())
+	}
+
+	p.DownlinkCounter = binary.BigEndian.Uint16(data)
+	return nil
+}
@@ -351,7 +351,7 @@ func (p ForceDeviceResyncReqPayload) Size() int {
 // MarshalBinary encodes the payload to a slice of bytes.
 func (p ForceDeviceResyncReqPayload) MarshalBinary() ([]byte, error) {
 	b := make([]byte, p.Size())
-	b[0] = p.ForceConf.NbTransmissions & 0x17 // first 3 bits
+	b[0] = p.ForceConf.NbTransmissions & 0x07 // first 3 bits
 	return b, nil
 }
 
@@ -360,6 +360,6 @@ func (p *ForceDeviceResyncReqPayload) UnmarshalBinary(data []byte) error {
 	if len(data) < p.Size() {
 		return fmt.Errorf("lorawan/applayer/clocksync: %d bytes are expected", p.Size())
 	}
-	p.ForceConf.NbTransmissions = data[0] & 0x17
+	p.ForceConf.NbTransmissions = data[0] & 0x07
 	return nil
 }
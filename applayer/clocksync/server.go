@@ -0,0 +1,228 @@
+package clocksync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/gps"
+)
+
+// DeviceState contains the clock synchronization state of a device as
+// tracked by the Server.
+type DeviceState struct {
+	// TokenAns contains the token of the last sent AppTimeAns.
+	TokenAns uint8
+
+	// TimeCorrection contains the correction (seconds) of the last
+	// AppTimeReq.
+	TimeCorrection int32
+
+	// SyncedAt contains the time (since GPS epoch) of the last AppTimeReq.
+	SyncedAt time.Duration
+
+	// Period contains the requested periodicity. The device should send an
+	// AppTimeReq every 128*2^Period seconds.
+	Period *uint8
+
+	// PeriodConfirmed is set when the device acknowledged the requested
+	// periodicity.
+	PeriodConfirmed bool
+
+	// PeriodNotSupported is set when the device answered that it does not
+	// support the DeviceAppTimePeriodicityReq.
+	PeriodNotSupported bool
+}
+
+// Server implements the server role for multiple devices. Besides
+// answering the AppTimeReq commands (see HandleUplink), it keeps track of
+// the AppTimeAns tokens and it sends a DeviceAppTimePeriodicityReq
+// (piggybacked with the next AppTimeReq answer) until the device
+// acknowledges the requested periodicity.
+// It is safe for concurrent use.
+type Server struct {
+	mu                sync.RWMutex
+	timeSinceGPSEpoch func() time.Duration
+	devices           map[lorawan.EUI64]*DeviceState
+}
+
+// NewServer returns a new Server. When timeSinceGPSEpoch is nil, the
+// system clock is used.
+func NewServer(timeSinceGPSEpoch func() time.Duration) *Server {
+	if timeSinceGPSEpoch == nil {
+		timeSinceGPSEpoch = func() time.Duration {
+			return gps.Time(time.Now()).TimeSinceGPSEpoch()
+		}
+	}
+
+	return &Server{
+		timeSinceGPSEpoch: timeSinceGPSEpoch,
+		devices:           make(map[lorawan.EUI64]*DeviceState),
+	}
+}
+
+// SetPeriodicity sets the requested periodicity of the device. The
+// DeviceAppTimePeriodicityReq is sent with the next answer to the device.
+func (s *Server) SetPeriodicity(devEUI lorawan.EUI64, period uint8) error {
+	if period > 15 {
+		return errors.New("lorawan/applayer/clocksync: max value of Period is 15")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := s.getState(devEUI)
+	d.Period = &period
+	d.PeriodConfirmed = false
+	d.PeriodNotSupported = false
+
+	return nil
+}
+
+// GetDeviceState returns the state of the given device. It returns false
+// when there is no state for the device.
+func (s *Server) GetDeviceState(devEUI lorawan.EUI64) (DeviceState, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	d, ok := s.devices[devEUI]
+	if !ok {
+		return DeviceState{}, false
+	}
+
+	out := *d
+	if d.Period != nil {
+		period := *d.Period
+		out.Period = &period
+	}
+	return out, true
+}
+
+// Remove removes all state of the given device.
+func (s *Server) Remove(devEUI lorawan.EUI64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.devices, devEUI)
+}
+
+// HandleUplink handles the given uplink commands of the device and returns
+// the downlink commands to send to the device. At most one
+// DeviceAppTimePeriodicityReq is returned per uplink.
+func (s *Server) HandleUplink(ctx context.Context, devEUI lorawan.EUI64, data []byte) (Commands, error) {
+	var cmds Commands
+	if err := cmds.UnmarshalBinary(true, data); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out Commands
+	var periodicityReq bool
+	for _, cmd := range cmds {
+		ans, req, err := s.handleCommand(devEUI, cmd)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ans...)
+		periodicityReq = periodicityReq || req
+	}
+
+	if periodicityReq {
+		out = s.appendPeriodicityReq(devEUI, out)
+	}
+
+	return out, nil
+}
+
+// HandleClockSyncCommand handles a single uplink command of the device and
+// returns the downlink commands to send to the device. This implements the
+// applayer.ClockSyncHandler interface. The DeviceAppTimePeriodicityReq is
+// only piggybacked with the answer to an AppTimeReq, of which the device
+// sends at most one per uplink.
+func (s *Server) HandleClockSyncCommand(ctx context.Context, devEUI lorawan.EUI64, cmd Command) (Commands, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out, periodicityReq, err := s.handleCommand(devEUI, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if periodicityReq {
+		out = s.appendPeriodicityReq(devEUI, out)
+	}
+
+	return out, nil
+}
+
+// handleCommand handles the given command and returns the answer and if
+// the DeviceAppTimePeriodicityReq can be piggybacked.
+func (s *Server) handleCommand(devEUI lorawan.EUI64, cmd Command) (Commands, bool, error) {
+	d := s.getState(devEUI)
+
+	switch cmd.CID {
+	case AppTimeReq:
+		pl, ok := cmd.Payload.(*AppTimeReqPayload)
+		if !ok {
+			return nil, false, errors.New("lorawan/applayer/clocksync: expected *AppTimeReqPayload")
+		}
+
+		now := s.timeSinceGPSEpoch()
+		correction, ans := handleAppTimeReq(now, pl)
+
+		d.SyncedAt = now
+		d.TimeCorrection = correction
+
+		if ans == nil {
+			return nil, true, nil
+		}
+
+		d.TokenAns = pl.Param.TokenReq
+		return Commands{*ans}, true, nil
+	case DeviceAppTimePeriodicityAns:
+		pl, ok := cmd.Payload.(*DeviceAppTimePeriodicityAnsPayload)
+		if !ok {
+			return nil, false, errors.New("lorawan/applayer/clocksync: expected *DeviceAppTimePeriodicityAnsPayload")
+		}
+
+		if pl.Status.NotSupported {
+			d.PeriodNotSupported = true
+		} else {
+			d.PeriodConfirmed = true
+			d.SyncedAt = s.timeSinceGPSEpoch()
+			d.TimeCorrection = int32(uint32(d.SyncedAt/time.Second) - pl.Time)
+		}
+	}
+
+	return nil, false, nil
+}
+
+// appendPeriodicityReq appends the DeviceAppTimePeriodicityReq when the
+// requested periodicity has not been acknowledged yet.
+func (s *Server) appendPeriodicityReq(devEUI lorawan.EUI64, out Commands) Commands {
+	d := s.getState(devEUI)
+	if d.Period == nil || d.PeriodConfirmed || d.PeriodNotSupported {
+		return out
+	}
+
+	return append(out, Command{
+		CID: DeviceAppTimePeriodicityReq,
+		Payload: &DeviceAppTimePeriodicityReqPayload{
+			Periodicity: DeviceAppTimePeriodicityReqPayloadPeriodicity{
+				Period: *d.Period,
+			},
+		},
+	})
+}
+
+func (s *Server) getState(devEUI lorawan.EUI64) *DeviceState {
+	d, ok := s.devices[devEUI]
+	if !ok {
+		d = &DeviceState{}
+		s.devices[devEUI] = d
+	}
+	return d
+}
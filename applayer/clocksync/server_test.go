@@ -0,0 +1,157 @@
+package clocksync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestServer(t *testing.T) {
+	assert := require.New(t)
+
+	serverTime := 1000 * time.Second
+	devEUI := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	device := Device{
+		Correction: -10 * time.Second,
+	}
+
+	server := NewServer(func() time.Duration { return serverTime })
+	_, ok := server.GetDeviceState(devEUI)
+	assert.False(ok)
+
+	assert.Error(server.SetPeriodicity(devEUI, 16))
+	assert.NoError(server.SetPeriodicity(devEUI, 2))
+
+	// device sends AppTimeReq
+	b, err := Commands{device.NewAppTimeReq(serverTime, false)}.MarshalBinary()
+	assert.NoError(err)
+
+	// server answers with the correction and the periodicity request
+	out, err := server.HandleUplink(context.Background(), devEUI, b)
+	assert.NoError(err)
+	assert.Equal(Commands{
+		{
+			CID: AppTimeAns,
+			Payload: &AppTimeAnsPayload{
+				TimeCorrection: 10,
+			},
+		},
+		{
+			CID: DeviceAppTimePeriodicityReq,
+			Payload: &DeviceAppTimePeriodicityReqPayload{
+				Periodicity: DeviceAppTimePeriodicityReqPayloadPeriodicity{
+					Period: 2,
+				},
+			},
+		},
+	}, out)
+
+	state, ok := server.GetDeviceState(devEUI)
+	assert.True(ok)
+	assert.Equal(int32(10), state.TimeCorrection)
+	assert.Equal(uint8(0), state.TokenAns)
+	assert.False(state.PeriodConfirmed)
+
+	// device applies the correction and acknowledges the periodicity
+	b, err = out.MarshalBinary()
+	assert.NoError(err)
+	out, err = device.HandleDownlink(serverTime, b)
+	assert.NoError(err)
+	assert.Equal(uint8(2), device.Period)
+	assert.Equal(time.Duration(0), device.Correction)
+
+	b, err = out.MarshalBinary()
+	assert.NoError(err)
+	out, err = server.HandleUplink(context.Background(), devEUI, b)
+	assert.NoError(err)
+	assert.Len(out, 0)
+
+	state, _ = server.GetDeviceState(devEUI)
+	assert.True(state.PeriodConfirmed)
+	assert.Equal(int32(0), state.TimeCorrection)
+
+	// next AppTimeReq uses the next token, the clock is in sync
+	serverTime += 128 * 4 * time.Second
+	b, err = Commands{device.NewAppTimeReq(serverTime, true)}.MarshalBinary()
+	assert.NoError(err)
+	out, err = server.HandleUplink(context.Background(), devEUI, b)
+	assert.NoError(err)
+	assert.Equal(Commands{
+		{
+			CID: AppTimeAns,
+			Payload: &AppTimeAnsPayload{
+				Param: AppTimeAnsPayloadParam{
+					TokenAns: 1,
+				},
+			},
+		},
+	}, out)
+
+	state, _ = server.GetDeviceState(devEUI)
+	assert.Equal(uint8(1), state.TokenAns)
+	assert.Equal(serverTime, state.SyncedAt)
+
+	server.Remove(devEUI)
+	_, ok = server.GetDeviceState(devEUI)
+	assert.False(ok)
+}
+
+func TestServerPeriodicityNotSupported(t *testing.T) {
+	assert := require.New(t)
+
+	devEUI := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	server := NewServer(nil)
+	assert.NoError(server.SetPeriodicity(devEUI, 1))
+
+	out, err := server.HandleClockSyncCommand(context.Background(), devEUI, Command{
+		CID: DeviceAppTimePeriodicityAns,
+		Payload: &DeviceAppTimePeriodicityAnsPayload{
+			Status: DeviceAppTimePeriodicityAnsPayloadStatus{
+				NotSupported: true,
+			},
+		},
+	})
+	assert.NoError(err)
+	assert.Len(out, 0)
+
+	state, _ := server.GetDeviceState(devEUI)
+	assert.True(state.PeriodNotSupported)
+	assert.False(state.PeriodConfirmed)
+}
+
+func TestServerPeriodicityReqOncePerUplink(t *testing.T) {
+	assert := require.New(t)
+
+	serverTime := 1000 * time.Second
+	devEUI := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	server := NewServer(func() time.Duration { return serverTime })
+	assert.NoError(server.SetPeriodicity(devEUI, 3))
+
+	device := Device{}
+	b, err := Commands{
+		{CID: PackageVersionReq},
+		device.NewAppTimeReq(serverTime, true),
+		device.NewAppTimeReq(serverTime, true),
+	}.MarshalBinary()
+	assert.NoError(err)
+
+	out, err := server.HandleUplink(context.Background(), devEUI, b)
+	assert.NoError(err)
+
+	var n int
+	for _, cmd := range out {
+		if cmd.CID == DeviceAppTimePeriodicityReq {
+			n++
+		}
+	}
+	assert.Equal(1, n)
+
+	// a command which is not an AppTimeReq does not trigger the request
+	out, err = server.HandleClockSyncCommand(context.Background(), devEUI, Command{CID: PackageVersionReq})
+	assert.NoError(err)
+	assert.Len(out, 0)
+}
@@ -0,0 +1,170 @@
+package clocksync
+
+import (
+	"errors"
+	"time"
+)
+
+// Package identifier and version as returned in the PackageVersionAns.
+const (
+	PackageIdentifier uint8 = 1
+	PackageVersion    uint8 = 1
+)
+
+// HandleUplink implements the server role. It handles the given uplink
+// commands, received at the given time (as time since GPS epoch), and
+// returns the downlink commands to send to the device.
+//
+// An AppTimeAns is returned when the device requested an answer or when
+// the device clock must be corrected.
+func HandleUplink(timeSinceGPSEpoch time.Duration, data []byte) (Commands, error) {
+	var cmds Commands
+	if err := cmds.UnmarshalBinary(true, data); err != nil {
+		return nil, err
+	}
+
+	var out Commands
+	for _, cmd := range cmds {
+		switch cmd.CID {
+		case AppTimeReq:
+			pl, ok := cmd.Payload.(*AppTimeReqPayload)
+			if !ok {
+				return nil, errors.New("lorawan/applayer/clocksync: expected *AppTimeReqPayload")
+			}
+
+			if _, ans := handleAppTimeReq(timeSinceGPSEpoch, pl); ans != nil {
+				out = append(out, *ans)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// handleAppTimeReq returns the time correction (seconds) of the given
+// AppTimeReq, received at the given time (as time since GPS epoch), and the
+// AppTimeAns to send or nil when no answer is required.
+func handleAppTimeReq(timeSinceGPSEpoch time.Duration, pl *AppTimeReqPayload) (int32, *Command) {
+	correction := int32(uint32(timeSinceGPSEpoch/time.Second) - pl.DeviceTime)
+	if correction == 0 && !pl.Param.AnsRequired {
+		return correction, nil
+	}
+
+	return correction, &Command{
+		CID: AppTimeAns,
+		Payload: &AppTimeAnsPayload{
+			TimeCorrection: correction,
+			Param: AppTimeAnsPayloadParam{
+				TokenAns: pl.Param.TokenReq,
+			},
+		},
+	}
+}
+
+// Device implements the device role, e.g. for device simulators.
+type Device struct {
+	// TokenReq contains the current AppTimeReq token.
+	TokenReq uint8
+
+	// Correction contains the correction which is applied to the device
+	// clock.
+	Correction time.Duration
+
+	// Period contains the periodicity as set by the
+	// DeviceAppTimePeriodicityReq. The device should send an AppTimeReq
+	// every 128*2^Period seconds.
+	Period uint8
+
+	// NbTransmissions contains the number of AppTimeReq transmissions
+	// requested by the ForceDeviceResyncReq, which are still pending.
+	NbTransmissions uint8
+}
+
+// DeviceTime returns the device time (seconds since GPS epoch), given the
+// (uncorrected) time since GPS epoch of the device clock.
+func (d *Device) DeviceTime(timeSinceGPSEpoch time.Duration) uint32 {
+	return uint32((timeSinceGPSEpoch + d.Correction) / time.Second)
+}
+
+// NewAppTimeReq returns a new AppTimeReq command, given the (uncorrected)
+// time since GPS epoch of the device clock. When a ForceDeviceResyncReq is
+// pending, the number of pending transmissions is decremented.
+func (d *Device) NewAppTimeReq(timeSinceGPSEpoch time.Duration, ansRequired bool) Command {
+	if d.NbTransmissions > 0 {
+		d.NbTransmissions--
+	}
+
+	return Command{
+		CID: AppTimeReq,
+		Payload: &AppTimeReqPayload{
+			DeviceTime: d.DeviceTime(timeSinceGPSEpoch),
+			Param: AppTimeReqPayloadParam{
+				AnsRequired: ansRequired,
+				TokenReq:    d.TokenReq,
+			},
+		},
+	}
+}
+
+// HandleDownlink handles the given downlink commands, given the
+// (uncorrected) time since GPS epoch of the device clock, and returns the
+// uplink commands to send to the server.
+//
+// An AppTimeAns is only applied when its token matches the current
+// TokenReq, after which the TokenReq is incremented. Pending AppTimeReq
+// transmissions requested by a ForceDeviceResyncReq must be sent using
+// NewAppTimeReq.
+func (d *Device) HandleDownlink(timeSinceGPSEpoch time.Duration, data []byte) (Commands, error) {
+	var cmds Commands
+	if err := cmds.UnmarshalBinary(false, data); err != nil {
+		return nil, err
+	}
+
+	var out Commands
+	for _, cmd := range cmds {
+		switch cmd.CID {
+		case PackageVersionReq:
+			out = append(out, Command{
+				CID: PackageVersionAns,
+				Payload: &PackageVersionAnsPayload{
+					PackageIdentifier: PackageIdentifier,
+					PackageVersion:    PackageVersion,
+				},
+			})
+		case AppTimeAns:
+			pl, ok := cmd.Payload.(*AppTimeAnsPayload)
+			if !ok {
+				return nil, errors.New("lorawan/applayer/clocksync: expected *AppTimeAnsPayload")
+			}
+
+			if pl.Param.TokenAns != d.TokenReq {
+				continue
+			}
+
+			d.Correction += time.Duration(pl.TimeCorrection) * time.Second
+			d.TokenReq = (d.TokenReq + 1) & 0x0f
+		case DeviceAppTimePeriodicityReq:
+			pl, ok := cmd.Payload.(*DeviceAppTimePeriodicityReqPayload)
+			if !ok {
+				return nil, errors.New("lorawan/applayer/clocksync: expected *DeviceAppTimePeriodicityReqPayload")
+			}
+
+			d.Period = pl.Periodicity.Period
+			out = append(out, Command{
+				CID: DeviceAppTimePeriodicityAns,
+				Payload: &DeviceAppTimePeriodicityAnsPayload{
+					Time: d.DeviceTime(timeSinceGPSEpoch),
+				},
+			})
+		case ForceDeviceResyncReq:
+			pl, ok := cmd.Payload.(*ForceDeviceResyncReqPayload)
+			if !ok {
+				return nil, errors.New("lorawan/applayer/clocksync: expected *ForceDeviceResyncReqPayload")
+			}
+
+			d.NbTransmissions = pl.ForceConf.NbTransmissions
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,109 @@
+package clocksync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSession(t *testing.T) {
+	assert := require.New(t)
+
+	serverTime := 1000 * time.Second
+	device := Device{
+		Correction: -10 * time.Second,
+	}
+
+	// device sends AppTimeReq
+	req := device.NewAppTimeReq(serverTime, false)
+	b, err := Commands{req}.MarshalBinary()
+	assert.NoError(err)
+
+	// server answers with the time correction
+	ans, err := HandleUplink(serverTime, b)
+	assert.NoError(err)
+	assert.Equal(Commands{
+		{
+			CID: AppTimeAns,
+			Payload: &AppTimeAnsPayload{
+				TimeCorrection: 10,
+			},
+		},
+	}, ans)
+
+	b, err = ans.MarshalBinary()
+	assert.NoError(err)
+
+	// device applies the correction
+	out, err := device.HandleDownlink(serverTime, b)
+	assert.NoError(err)
+	assert.Len(out, 0)
+	assert.Equal(time.Duration(0), device.Correction)
+	assert.Equal(uint8(1), device.TokenReq)
+
+	// the same answer is ignored as the token does not match
+	_, err = device.HandleDownlink(serverTime, b)
+	assert.NoError(err)
+	assert.Equal(time.Duration(0), device.Correction)
+
+	// no answer when the clock is in sync and no answer is required
+	b, err = Commands{device.NewAppTimeReq(serverTime, false)}.MarshalBinary()
+	assert.NoError(err)
+	ans, err = HandleUplink(serverTime, b)
+	assert.NoError(err)
+	assert.Len(ans, 0)
+
+	// answer when required
+	b, err = Commands{device.NewAppTimeReq(serverTime, true)}.MarshalBinary()
+	assert.NoError(err)
+	ans, err = HandleUplink(serverTime, b)
+	assert.NoError(err)
+	assert.Len(ans, 1)
+}
+
+func TestDeviceHandleDownlink(t *testing.T) {
+	assert := require.New(t)
+
+	device := Device{}
+	b, err := Commands{
+		{CID: PackageVersionReq},
+		{
+			CID: DeviceAppTimePeriodicityReq,
+			Payload: &DeviceAppTimePeriodicityReqPayload{
+				Periodicity: DeviceAppTimePeriodicityReqPayloadPeriodicity{Period: 3},
+			},
+		},
+		{
+			CID: ForceDeviceResyncReq,
+			Payload: &ForceDeviceResyncReqPayload{
+				ForceConf: ForceDeviceResyncReqPayloadForceConf{NbTransmissions: 2},
+			},
+		},
+	}.MarshalBinary()
+	assert.NoError(err)
+
+	out, err := device.HandleDownlink(100*time.Second, b)
+	assert.NoError(err)
+	assert.Equal(Commands{
+		{
+			CID: PackageVersionAns,
+			Payload: &PackageVersionAnsPayload{
+				PackageIdentifier: 1,
+				PackageVersion:    1,
+			},
+		},
+		{
+			CID: DeviceAppTimePeriodicityAns,
+			Payload: &DeviceAppTimePeriodicityAnsPayload{
+				Time: 100,
+			},
+		},
+	}, out)
+	assert.Equal(uint8(3), device.Period)
+	assert.Equal(uint8(2), device.NbTransmissions)
+
+	device.NewAppTimeReq(100*time.Second, false)
+	device.NewAppTimeReq(100*time.Second, false)
+	assert.Equal(uint8(0), device.NbTransmissions)
+}
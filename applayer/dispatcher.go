@@ -0,0 +1,127 @@
+package applayer
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/applayer/clocksync"
+	"github.com/brocaar/lorawan/applayer/firmwaremanagement"
+	"github.com/brocaar/lorawan/applayer/fragmentation"
+	"github.com/brocaar/lorawan/applayer/multicastsetup"
+)
+
+// ErrNoHandler is returned when no handler is set for the decoded payload.
+var ErrNoHandler = errors.New("lorawan/applayer: no handler for payload")
+
+// ClockSyncHandler handles the uplink Clock Synchronization commands of a
+// device and returns the commands to send as downlink.
+type ClockSyncHandler interface {
+	HandleClockSyncCommand(ctx context.Context, devEUI lorawan.EUI64, cmd clocksync.Command) (clocksync.Commands, error)
+}
+
+// FragmentationHandler handles the uplink Fragmented Data Block Transport
+// commands of a device and returns the commands to send as downlink.
+type FragmentationHandler interface {
+	HandleFragmentationCommand(ctx context.Context, devEUI lorawan.EUI64, cmd fragmentation.Command) (fragmentation.Commands, error)
+}
+
+// MulticastSetupHandler handles the uplink Remote Multicast Setup commands
+// of a device and returns the commands to send as downlink.
+type MulticastSetupHandler interface {
+	HandleMulticastSetupCommand(ctx context.Context, devEUI lorawan.EUI64, cmd multicastsetup.Command) (multicastsetup.Commands, error)
+}
+
+// FirmwareManagementHandler handles the uplink Firmware Management commands
+// of a device and returns the commands to send as downlink.
+type FirmwareManagementHandler interface {
+	HandleFirmwareManagementCommand(ctx context.Context, devEUI lorawan.EUI64, cmd firmwaremanagement.Command) (firmwaremanagement.Commands, error)
+}
+
+// Dispatcher decodes the application-layer uplink payloads using the
+// registered codecs (see RegisterCodec) and routes the decoded commands to
+// the handler of the protocol. Only the handlers of the supported protocols
+// need to be set.
+type Dispatcher struct {
+	ClockSync          ClockSyncHandler
+	Fragmentation      FragmentationHandler
+	MulticastSetup     MulticastSetupHandler
+	FirmwareManagement FirmwareManagementHandler
+}
+
+// commandHandler defines the handling of the commands of a protocol by
+// the Dispatcher.
+type commandHandler struct {
+	// isSet returns true when the handler of the protocol is set.
+	isSet func(d *Dispatcher) bool
+
+	// handle calls the handler of the protocol with the given command and
+	// returns the answers (as Commands slice of the protocol).
+	handle func(ctx context.Context, d *Dispatcher, devEUI lorawan.EUI64, cmd interface{}) (interface{}, error)
+}
+
+// commandHandlers contains the command handler by Commands type.
+var commandHandlers = map[reflect.Type]commandHandler{
+	reflect.TypeOf(clocksync.Commands{}): {
+		isSet: func(d *Dispatcher) bool { return d.ClockSync != nil },
+		handle: func(ctx context.Context, d *Dispatcher, devEUI lorawan.EUI64, cmd interface{}) (interface{}, error) {
+			return d.ClockSync.HandleClockSyncCommand(ctx, devEUI, cmd.(clocksync.Command))
+		},
+	},
+	reflect.TypeOf(fragmentation.Commands{}): {
+		isSet: func(d *Dispatcher) bool { return d.Fragmentation != nil },
+		handle: func(ctx context.Context, d *Dispatcher, devEUI lorawan.EUI64, cmd interface{}) (interface{}, error) {
+			return d.Fragmentation.HandleFragmentationCommand(ctx, devEUI, cmd.(fragmentation.Command))
+		},
+	},
+	reflect.TypeOf(multicastsetup.Commands{}): {
+		isSet: func(d *Dispatcher) bool { return d.MulticastSetup != nil },
+		handle: func(ctx context.Context, d *Dispatcher, devEUI lorawan.EUI64, cmd interface{}) (interface{}, error) {
+			return d.MulticastSetup.HandleMulticastSetupCommand(ctx, devEUI, cmd.(multicastsetup.Command))
+		},
+	},
+	reflect.TypeOf(firmwaremanagement.Commands{}): {
+		isSet: func(d *Dispatcher) bool { return d.FirmwareManagement != nil },
+		handle: func(ctx context.Context, d *Dispatcher, devEUI lorawan.EUI64, cmd interface{}) (interface{}, error) {
+			return d.FirmwareManagement.HandleFirmwareManagementCommand(ctx, devEUI, cmd.(firmwaremanagement.Command))
+		},
+	},
+}
+
+// HandleUplink decodes the given uplink payload and dispatches each command
+// to the handler of the protocol. It returns the encoded answers, which
+// must be sent as downlink using the same FPort, or nil when there is
+// nothing to send. ErrNoHandler is returned when no handler has been set
+// for the protocol.
+func (d *Dispatcher) HandleUplink(ctx context.Context, devEUI lorawan.EUI64, fPort uint8, b []byte) ([]byte, error) {
+	v, err := Unmarshal(fPort, b)
+	if err != nil {
+		return nil, err
+	}
+
+	h, ok := commandHandlers[reflect.TypeOf(v)]
+	if !ok || !h.isSet(d) {
+		return nil, ErrNoHandler
+	}
+
+	cmds := reflect.ValueOf(v)
+	out := reflect.MakeSlice(cmds.Type(), 0, 0)
+
+	for i := 0; i < cmds.Len(); i++ {
+		cmd := cmds.Index(i)
+
+		ans, err := h.handle(ctx, d, devEUI, cmd.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("lorawan/applayer: handle CID 0x%02x error: %w", cmd.FieldByName("CID").Uint(), err)
+		}
+		out = reflect.AppendSlice(out, reflect.ValueOf(ans))
+	}
+
+	if out.Len() == 0 {
+		return nil, nil
+	}
+	return out.Interface().(encoding.BinaryMarshaler).MarshalBinary()
+}
@@ -0,0 +1,123 @@
+package applayer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/applayer/clocksync"
+	"github.com/brocaar/lorawan/applayer/firmwaremanagement"
+	"github.com/brocaar/lorawan/applayer/fragmentation"
+	"github.com/brocaar/lorawan/applayer/multicastsetup"
+)
+
+type testHandler struct {
+	devEUI lorawan.EUI64
+	cmds   []interface{}
+	err    error
+}
+
+func (h *testHandler) HandleClockSyncCommand(ctx context.Context, devEUI lorawan.EUI64, cmd clocksync.Command) (clocksync.Commands, error) {
+	h.devEUI = devEUI
+	h.cmds = append(h.cmds, cmd)
+
+	if cmd.CID != clocksync.AppTimeReq {
+		return nil, h.err
+	}
+
+	return clocksync.Commands{
+		{
+			CID: clocksync.AppTimeAns,
+			Payload: &clocksync.AppTimeAnsPayload{
+				TimeCorrection: 10,
+			},
+		},
+	}, h.err
+}
+
+func (h *testHandler) HandleFragmentationCommand(ctx context.Context, devEUI lorawan.EUI64, cmd fragmentation.Command) (fragmentation.Commands, error) {
+	h.devEUI = devEUI
+	h.cmds = append(h.cmds, cmd)
+	return nil, h.err
+}
+
+func (h *testHandler) HandleMulticastSetupCommand(ctx context.Context, devEUI lorawan.EUI64, cmd multicastsetup.Command) (multicastsetup.Commands, error) {
+	h.devEUI = devEUI
+	h.cmds = append(h.cmds, cmd)
+	return nil, h.err
+}
+
+func (h *testHandler) HandleFirmwareManagementCommand(ctx context.Context, devEUI lorawan.EUI64, cmd firmwaremanagement.Command) (firmwaremanagement.Commands, error) {
+	h.devEUI = devEUI
+	h.cmds = append(h.cmds, cmd)
+	return nil, h.err
+}
+
+func TestDispatcher(t *testing.T) {
+	devEUI := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+
+	t.Run("clocksync answer", func(t *testing.T) {
+		assert := require.New(t)
+		h := testHandler{}
+		d := Dispatcher{ClockSync: &h}
+
+		// PackageVersionAns + AppTimeReq
+		b, err := d.HandleUplink(context.Background(), devEUI, clocksync.DefaultFPort, []byte{0x00, 0x01, 0x01, 0x01, 0x01, 0x02, 0x03, 0x04, 0x00})
+		assert.NoError(err)
+		assert.Equal([]byte{0x01, 0x0a, 0x00, 0x00, 0x00, 0x00}, b)
+		assert.Equal(devEUI, h.devEUI)
+		assert.Len(h.cmds, 2)
+	})
+
+	t.Run("no answer", func(t *testing.T) {
+		assert := require.New(t)
+		h := testHandler{}
+		d := Dispatcher{
+			Fragmentation:      &h,
+			MulticastSetup:     &h,
+			FirmwareManagement: &h,
+		}
+
+		for _, tst := range []struct {
+			FPort uint8
+			Bytes []byte
+		}{
+			{fragmentation.DefaultFPort, []byte{0x02, 0x00}},
+			{multicastsetup.DefaultFPort, []byte{0x02, 0x00}},
+			{firmwaremanagement.DefaultFPort, []byte{0x05, 0x00}},
+		} {
+			b, err := d.HandleUplink(context.Background(), devEUI, tst.FPort, tst.Bytes)
+			assert.NoError(err)
+			assert.Nil(b)
+		}
+
+		assert.Len(h.cmds, 3)
+		assert.IsType(fragmentation.Command{}, h.cmds[0])
+		assert.IsType(multicastsetup.Command{}, h.cmds[1])
+		assert.IsType(firmwaremanagement.Command{}, h.cmds[2])
+	})
+
+	t.Run("no handler", func(t *testing.T) {
+		assert := require.New(t)
+		d := Dispatcher{}
+
+		_, err := d.HandleUplink(context.Background(), devEUI, clocksync.DefaultFPort, []byte{0x00, 0x01, 0x01})
+		assert.Equal(ErrNoHandler, err)
+
+		_, err = d.HandleUplink(context.Background(), devEUI, 10, []byte{0x00})
+		assert.Equal(ErrNoCodecForFPort, err)
+	})
+
+	t.Run("handler error", func(t *testing.T) {
+		assert := require.New(t)
+		h := testHandler{err: errors.New("boom")}
+		d := Dispatcher{Fragmentation: &h}
+
+		_, err := d.HandleUplink(context.Background(), devEUI, fragmentation.DefaultFPort, []byte{0x02, 0x00})
+		assert.Error(err)
+		assert.True(errors.Is(err, h.err))
+	})
+}
@@ -0,0 +1,204 @@
+package firmwaremanagement
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirmwareManagement(t *testing.T) {
+	tests := []struct {
+		Name                   string
+		Command                Command
+		Bytes                  []byte
+		Uplink                 bool
+		ExpectedMarshalError   error
+		ExpectedUnmarshalError error
+	}{
+		{
+			Name: "PackageVersionReq",
+			Command: Command{
+				CID: PackageVersionReq,
+			},
+			Bytes: []byte{0x00},
+		},
+		{
+			Name:   "PackageVersionAns",
+			Uplink: true,
+			Command: Command{
+				CID: PackageVersionAns,
+				Payload: &PackageVersionAnsPayload{
+					PackageIdentifier: 4,
+					PackageVersion:    1,
+				},
+			},
+			Bytes: []byte{0x00, 0x04, 0x01},
+		},
+		{
+			Name: "DevVersionReq",
+			Command: Command{
+				CID: DevVersionReq,
+			},
+			Bytes: []byte{0x01},
+		},
+		{
+			Name:   "DevVersionAns",
+			Uplink: true,
+			Command: Command{
+				CID: DevVersionAns,
+				Payload: &DevVersionAnsPayload{
+					FWVersion: 0x04030201,
+					HWVersion: 0x08070605,
+				},
+			},
+			Bytes: []byte{0x01, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+		},
+		{
+			Name:                   "DevVersionAns invalid bytes",
+			Uplink:                 true,
+			Bytes:                  []byte{0x01, 0x01, 0x02, 0x03},
+			ExpectedUnmarshalError: errors.New("lorawan/applayer/firmwaremanagement: 8 bytes are expected"),
+		},
+		{
+			Name: "DevRebootTimeReq",
+			Command: Command{
+				CID: DevRebootTimeReq,
+				Payload: &DevRebootTimeReqPayload{
+					RebootTime: RebootCancel,
+				},
+			},
+			Bytes: []byte{0x02, 0xff, 0xff, 0xff, 0xff},
+		},
+		{
+			Name:   "DevRebootTimeAns",
+			Uplink: true,
+			Command: Command{
+				CID: DevRebootTimeAns,
+				Payload: &DevRebootTimeAnsPayload{
+					RebootTime: 0x04030201,
+				},
+			},
+			Bytes: []byte{0x02, 0x01, 0x02, 0x03, 0x04},
+		},
+		{
+			Name: "DevRebootCountdownReq",
+			Command: Command{
+				CID: DevRebootCountdownReq,
+				Payload: &DevRebootCountdownReqPayload{
+					Countdown: 0x030201,
+				},
+			},
+			Bytes: []byte{0x03, 0x01, 0x02, 0x03},
+		},
+		{
+			Name: "DevRebootCountdownReq countdown too large",
+			Command: Command{
+				CID: DevRebootCountdownReq,
+				Payload: &DevRebootCountdownReqPayload{
+					Countdown: 0x01000000,
+				},
+			},
+			ExpectedMarshalError: errors.New("lorawan/applayer/firmwaremanagement: max value of Countdown is 2^24-1"),
+		},
+		{
+			Name:   "DevRebootCountdownAns",
+			Uplink: true,
+			Command: Command{
+				CID: DevRebootCountdownAns,
+				Payload: &DevRebootCountdownAnsPayload{
+					Countdown: CountdownCancel,
+				},
+			},
+			Bytes: []byte{0x03, 0xff, 0xff, 0xff},
+		},
+		{
+			Name: "DevUpgradeImageReq",
+			Command: Command{
+				CID: DevUpgradeImageReq,
+			},
+			Bytes: []byte{0x04},
+		},
+		{
+			Name:   "DevUpgradeImageAns no image",
+			Uplink: true,
+			Command: Command{
+				CID: DevUpgradeImageAns,
+				Payload: &DevUpgradeImageAnsPayload{
+					Status: DevUpgradeImageAnsPayloadStatus{
+						UpImageStatus: UpImageStatusNoImage,
+					},
+				},
+			},
+			Bytes: []byte{0x04, 0x00},
+		},
+		{
+			Name:   "DevUpgradeImageAns valid image",
+			Uplink: true,
+			Command: Command{
+				CID: DevUpgradeImageAns,
+				Payload: &DevUpgradeImageAnsPayload{
+					Status: DevUpgradeImageAnsPayloadStatus{
+						UpImageStatus: UpImageStatusValid,
+					},
+					NwFWVersion: 0x04030201,
+				},
+			},
+			Bytes: []byte{0x04, 0x03, 0x01, 0x02, 0x03, 0x04},
+		},
+		{
+			Name:                   "DevUpgradeImageAns valid image invalid bytes",
+			Uplink:                 true,
+			Bytes:                  []byte{0x04, 0x03, 0x01},
+			ExpectedUnmarshalError: errors.New("lorawan/applayer/firmwaremanagement: 5 bytes are expected"),
+		},
+		{
+			Name: "DevDeleteImageReq",
+			Command: Command{
+				CID: DevDeleteImageReq,
+				Payload: &DevDeleteImageReqPayload{
+					FirmwareVersion: 0x04030201,
+				},
+			},
+			Bytes: []byte{0x05, 0x01, 0x02, 0x03, 0x04},
+		},
+		{
+			Name:   "DevDeleteImageAns",
+			Uplink: true,
+			Command: Command{
+				CID: DevDeleteImageAns,
+				Payload: &DevDeleteImageAnsPayload{
+					Status: DevDeleteImageAnsPayloadStatus{
+						ErrorInvalidVersion: true,
+					},
+				},
+			},
+			Bytes: []byte{0x05, 0x02},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			if tst.ExpectedMarshalError != nil {
+				_, err := tst.Command.MarshalBinary()
+				assert.Equal(tst.ExpectedMarshalError, err)
+			} else if tst.ExpectedUnmarshalError != nil {
+				var cmd Command
+				err := cmd.UnmarshalBinary(tst.Uplink, tst.Bytes)
+				assert.Equal(tst.ExpectedUnmarshalError, err)
+			} else {
+				cmds := Commands{tst.Command}
+				b, err := cmds.MarshalBinary()
+				assert.NoError(err)
+				assert.Equal(tst.Bytes, b)
+
+				cmds = Commands{}
+				assert.NoError(cmds.UnmarshalBinary(tst.Uplink, tst.Bytes))
+				assert.Len(cmds, 1)
+				assert.Equal(tst.Command, cmds[0])
+			}
+		})
+	}
+}
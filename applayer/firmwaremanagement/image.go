@@ -0,0 +1,90 @@
+package firmwaremanagement
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math/big"
+)
+
+// Image verification errors.
+var (
+	ErrInvalidCRC       = errors.New("lorawan/applayer/firmwaremanagement: invalid image CRC")
+	ErrInvalidHash      = errors.New("lorawan/applayer/firmwaremanagement: invalid image hash")
+	ErrInvalidSignature = errors.New("lorawan/applayer/firmwaremanagement: invalid image signature")
+)
+
+// ImageDigest contains the integrity values of a firmware image.
+type ImageDigest struct {
+	// CRC32 contains the CRC-32 (IEEE) checksum of the image.
+	CRC32 uint32
+
+	// SHA256 contains the SHA-256 hash of the image.
+	SHA256 [sha256.Size]byte
+}
+
+// NewImageDigest returns the ImageDigest of the given firmware image.
+func NewImageDigest(image []byte) ImageDigest {
+	return ImageDigest{
+		CRC32:  crc32.ChecksumIEEE(image),
+		SHA256: sha256.Sum256(image),
+	}
+}
+
+// Verify verifies the given (e.g. reassembled) firmware image against the
+// digest. It returns ErrInvalidCRC or ErrInvalidHash on mismatch.
+func (d ImageDigest) Verify(image []byte) error {
+	if crc32.ChecksumIEEE(image) != d.CRC32 {
+		return ErrInvalidCRC
+	}
+
+	hash := sha256.Sum256(image)
+	if !bytes.Equal(hash[:], d.SHA256[:]) {
+		return ErrInvalidHash
+	}
+
+	return nil
+}
+
+// SignImage signs the SHA-256 hash of the given firmware image using
+// ECDSA. The signature is encoded as r || s, each left-padded to the size
+// of the curve order (64 bytes for P-256), which is the format commonly
+// used by constrained devices.
+func SignImage(key *ecdsa.PrivateKey, image []byte) ([]byte, error) {
+	hash := sha256.Sum256(image)
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+	if err != nil {
+		return nil, fmt.Errorf("lorawan/applayer/firmwaremanagement: sign image error: %w", err)
+	}
+
+	size := (key.Curve.Params().N.BitLen() + 7) / 8
+	sig := make([]byte, 2*size)
+	r.FillBytes(sig[:size])
+	s.FillBytes(sig[size:])
+
+	return sig, nil
+}
+
+// VerifyImageSignature verifies the r || s encoded ECDSA signature of the
+// given firmware image (see SignImage). It returns ErrInvalidSignature when
+// the signature is not valid.
+func VerifyImageSignature(key *ecdsa.PublicKey, image, sig []byte) error {
+	size := (key.Curve.Params().N.BitLen() + 7) / 8
+	if len(sig) != 2*size {
+		return ErrInvalidSignature
+	}
+
+	hash := sha256.Sum256(image)
+	r := new(big.Int).SetBytes(sig[:size])
+	s := new(big.Int).SetBytes(sig[size:])
+
+	if !ecdsa.Verify(key, hash[:], r, s) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
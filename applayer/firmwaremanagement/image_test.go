@@ -0,0 +1,45 @@
+package firmwaremanagement
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageDigest(t *testing.T) {
+	assert := require.New(t)
+
+	image := []byte("123456789")
+	d := NewImageDigest(image)
+	assert.Equal(uint32(0xcbf43926), d.CRC32)
+	assert.NoError(d.Verify(image))
+
+	assert.Equal(ErrInvalidCRC, d.Verify([]byte("123456780")))
+
+	d.CRC32 = 0xcbf43926
+	d.SHA256[0] ^= 0xff
+	assert.Equal(ErrInvalidHash, d.Verify(image))
+}
+
+func TestImageSignature(t *testing.T) {
+	assert := require.New(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(err)
+
+	image := []byte{0x01, 0x02, 0x03, 0x04}
+	sig, err := SignImage(key, image)
+	assert.NoError(err)
+	assert.Len(sig, 64)
+
+	assert.NoError(VerifyImageSignature(&key.PublicKey, image, sig))
+	assert.Equal(ErrInvalidSignature, VerifyImageSignature(&key.PublicKey, []byte{0x01, 0x02, 0x03}, sig))
+	assert.Equal(ErrInvalidSignature, VerifyImageSignature(&key.PublicKey, image, sig[:63]))
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(err)
+	assert.Equal(ErrInvalidSignature, VerifyImageSignature(&other.PublicKey, image, sig))
+}
@@ -0,0 +1,177 @@
+package fragmentation
+
+import (
+	"errors"
+	"fmt"
+)
+
+type decoderRow struct {
+	coefficients []bool
+	data         []byte
+}
+
+// Decoder implements the receiver side of the Fragmented Data Block
+// Transport. It collects the uncoded and coded (redundancy) fragments as
+// produced by Encode and recovers the missing fragments once enough
+// fragments have been received.
+type Decoder struct {
+	nbFrag   int
+	fragSize int
+	padding  int
+
+	received int
+	lastN    int
+	seen     map[uint16]struct{}
+
+	// pivots contains the received fragments in row echelon form, indexed
+	// by the column of their first coefficient.
+	pivots []*decoderRow
+	rank   int
+}
+
+// NewDecoder returns a new Decoder given the number of (uncoded) fragments,
+// the fragment-size and the number of padding bytes, as communicated in
+// the FragSessionSetupReq.
+func NewDecoder(nbFrag, fragmentSize, padding int) (*Decoder, error) {
+	if nbFrag <= 0 {
+		return nil, fmt.Errorf("lorawan/applayer/fragmentation: invalid number of fragments: %d", nbFrag)
+	}
+	if fragmentSize <= 0 {
+		return nil, fmt.Errorf("lorawan/applayer/fragmentation: invalid fragment-size: %d", fragmentSize)
+	}
+	if padding < 0 || padding >= fragmentSize {
+		return nil, fmt.Errorf("lorawan/applayer/fragmentation: invalid padding: %d", padding)
+	}
+
+	return &Decoder{
+		nbFrag:   nbFrag,
+		fragSize: fragmentSize,
+		padding:  padding,
+		seen:     make(map[uint16]struct{}),
+		pivots:   make([]*decoderRow, nbFrag),
+	}, nil
+}
+
+// AddFragment adds the fragment with the given index N (as used by the
+// DataFragment command, starting at 1). Fragments with an index greater
+// than the number of fragments are handled as coded fragments. Duplicate
+// fragments are ignored. It returns true once the data block can be
+// reconstructed.
+func (d *Decoder) AddFragment(n uint16, data []byte) (bool, error) {
+	if n == 0 {
+		return false, errors.New("lorawan/applayer/fragmentation: fragment index must be >= 1")
+	}
+	if len(data) != d.fragSize {
+		return false, fmt.Errorf("lorawan/applayer/fragmentation: fragment of %d bytes expected", d.fragSize)
+	}
+
+	if _, ok := d.seen[n]; ok {
+		return d.Complete(), nil
+	}
+	d.seen[n] = struct{}{}
+	d.received++
+	if int(n) > d.lastN {
+		d.lastN = int(n)
+	}
+
+	if d.Complete() {
+		return true, nil
+	}
+
+	row := decoderRow{
+		coefficients: make([]bool, d.nbFrag),
+		data:         make([]byte, d.fragSize),
+	}
+	copy(row.data, data)
+
+	if int(n) <= d.nbFrag {
+		row.coefficients[n-1] = true
+	} else {
+		for i, c := range matrixLine(int(n)-d.nbFrag, d.nbFrag) {
+			row.coefficients[i] = c == 1
+		}
+	}
+
+	// Reduce the row using the existing pivot rows. As the first
+	// coefficient of each pivot row is at its pivot column, eliminating the
+	// columns in ascending order does not re-introduce lower coefficients.
+	for c := 0; c < d.nbFrag; c++ {
+		if !row.coefficients[c] {
+			continue
+		}
+
+		if d.pivots[c] == nil {
+			d.pivots[c] = &row
+			d.rank++
+			break
+		}
+
+		d.pivots[c].xorInto(&row)
+	}
+
+	return d.Complete(), nil
+}
+
+// AddDataFragment adds the fragment contained by the given DataFragment
+// payload (see AddFragment).
+func (d *Decoder) AddDataFragment(pl DataFragmentPayload) (bool, error) {
+	return d.AddFragment(pl.IndexAndN.N, pl.Payload)
+}
+
+// Complete returns true when the data block can be reconstructed.
+func (d *Decoder) Complete() bool {
+	return d.rank == d.nbFrag
+}
+
+// NbFragReceived returns the number of (unique) fragments received, as
+// reported by the FragSessionStatusAns.
+func (d *Decoder) NbFragReceived() int {
+	return d.received
+}
+
+// MissingFrag returns the number of fragments which are still needed to
+// reconstruct the data block, as reported by the FragSessionStatusAns.
+func (d *Decoder) MissingFrag() int {
+	return d.nbFrag - d.rank
+}
+
+// Data returns the reconstructed data block, with the padding removed.
+func (d *Decoder) Data() ([]byte, error) {
+	if !d.Complete() {
+		return nil, fmt.Errorf("lorawan/applayer/fragmentation: %d fragments missing", d.MissingFrag())
+	}
+
+	// back substitution, starting with the last column
+	solved := make([][]byte, d.nbFrag)
+	for c := d.nbFrag - 1; c >= 0; c-- {
+		p := d.pivots[c]
+		s := make([]byte, d.fragSize)
+		copy(s, p.data)
+
+		for j := c + 1; j < d.nbFrag; j++ {
+			if p.coefficients[j] {
+				for m := range s {
+					s[m] ^= solved[j][m]
+				}
+			}
+		}
+
+		solved[c] = s
+	}
+
+	out := make([]byte, 0, d.nbFrag*d.fragSize)
+	for _, s := range solved {
+		out = append(out, s...)
+	}
+
+	return out[:len(out)-d.padding], nil
+}
+
+func (r *decoderRow) xorInto(dst *decoderRow) {
+	for i := range r.coefficients {
+		dst.coefficients[i] = dst.coefficients[i] != r.coefficients[i]
+	}
+	for i := range r.data {
+		dst.data[i] ^= r.data[i]
+	}
+}
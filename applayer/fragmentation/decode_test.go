@@ -0,0 +1,160 @@
+package fragmentation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder(t *testing.T) {
+	data := make([]byte, 95)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+
+	tests := []struct {
+		Name       string
+		Redundancy int
+		Lost       []int // indices (starting at 1) of lost fragments
+		Complete   bool
+	}{
+		{
+			Name:     "no redundancy, no loss",
+			Complete: true,
+		},
+		{
+			Name:     "no redundancy, one lost",
+			Lost:     []int{3},
+			Complete: false,
+		},
+		{
+			Name:       "redundancy 5, two uncoded lost",
+			Redundancy: 5,
+			Lost:       []int{2, 7},
+			Complete:   true,
+		},
+		{
+			Name:       "redundancy 10, uncoded and coded lost",
+			Redundancy: 10,
+			Lost:       []int{1, 4, 5, 10, 12, 15},
+			Complete:   true,
+		},
+		{
+			Name:       "redundancy 2, too many lost",
+			Redundancy: 2,
+			Lost:       []int{1, 2, 3},
+			Complete:   false,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			setup, cmds, err := EncodeSession(0, data, 10, tst.Redundancy)
+			assert.NoError(err)
+
+			d, err := NewDecoder(int(setup.NbFrag), int(setup.FragSize), int(setup.Padding))
+			assert.NoError(err)
+
+			lost := make(map[int]bool)
+			for _, n := range tst.Lost {
+				lost[n] = true
+			}
+
+			var received int
+			for _, cmd := range cmds {
+				pl := cmd.Payload.(*DataFragmentPayload)
+				if lost[int(pl.IndexAndN.N)] {
+					continue
+				}
+
+				_, err := d.AddDataFragment(*pl)
+				assert.NoError(err)
+				received++
+			}
+
+			assert.Equal(received, d.NbFragReceived())
+			assert.Equal(tst.Complete, d.Complete())
+
+			out, err := d.Data()
+			if !tst.Complete {
+				assert.Error(err)
+				assert.NotEqual(0, d.MissingFrag())
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(0, d.MissingFrag())
+			assert.Equal(data, out)
+		})
+	}
+
+	t.Run("invalid fragment", func(t *testing.T) {
+		assert := require.New(t)
+
+		d, err := NewDecoder(2, 10, 0)
+		assert.NoError(err)
+
+		_, err = d.AddFragment(0, make([]byte, 10))
+		assert.Error(err)
+
+		_, err = d.AddFragment(1, make([]byte, 5))
+		assert.Error(err)
+
+		_, err = d.AddFragment(1, make([]byte, 10))
+		assert.NoError(err)
+		_, err = d.AddFragment(1, make([]byte, 10))
+		assert.NoError(err)
+		assert.Equal(1, d.NbFragReceived())
+	})
+}
+
+func TestDecoderStats(t *testing.T) {
+	assert := require.New(t)
+
+	setup, cmds, err := EncodeSession(0, make([]byte, 100), 10, 5)
+	assert.NoError(err)
+
+	d, err := NewDecoder(int(setup.NbFrag), int(setup.FragSize), int(setup.Padding))
+	assert.NoError(err)
+
+	// lose fragments 2 and 4
+	for _, cmd := range cmds {
+		pl := cmd.Payload.(*DataFragmentPayload)
+		if pl.IndexAndN.N == 2 || pl.IndexAndN.N == 4 {
+			continue
+		}
+		_, err := d.AddDataFragment(*pl)
+		assert.NoError(err)
+	}
+
+	assert.Equal(SessionStats{
+		NbFrag:             10,
+		FragmentsSent:      15,
+		NbFragReceived:     13,
+		LossRate:           2.0 / 15,
+		RedundancyConsumed: 0.4,
+	}, d.Stats())
+	assert.True(d.Stats().Completed())
+}
+
+func TestNewSessionStats(t *testing.T) {
+	assert := require.New(t)
+
+	s := NewSessionStats(10, 12, FragSessionStatusAnsPayload{
+		ReceivedAndIndex: FragSessionStatusAnsPayloadReceivedAndIndex{
+			NbFragReceived: 8,
+		},
+		MissingFrag: 2,
+	})
+	assert.Equal(SessionStats{
+		NbFrag:             10,
+		FragmentsSent:      12,
+		NbFragReceived:     8,
+		MissingFrag:        2,
+		LossRate:           4.0 / 12,
+		RedundancyConsumed: 2,
+	}, s)
+	assert.False(s.Completed())
+}
@@ -2,6 +2,7 @@ package fragmentation
 
 import (
 	"errors"
+	"fmt"
 )
 
 // Encode encodes the given slice of bytes to fragments including forward error correction.
@@ -38,6 +39,64 @@ func Encode(data []byte, fragmentSize, redundancy int) ([][]byte, error) {
 	return dataRows, nil
 }
 
+// EncodeSession pads and encodes the given data block (see Encode) and
+// returns the FragSessionSetupReq payload describing the session together
+// with the DataFragment commands to transmit, including the redundancy
+// fragments. The McGroupBitMask, BlockAckDelay and Descriptor fields of the
+// returned payload must be set by the caller when needed.
+func EncodeSession(fragIndex uint8, data []byte, fragmentSize, redundancy int) (FragSessionSetupReqPayload, Commands, error) {
+	var setup FragSessionSetupReqPayload
+
+	if fragIndex > 3 {
+		return setup, nil, fmt.Errorf("lorawan/applayer/fragmentation: max FragIndex value is 3, got: %d", fragIndex)
+	}
+	if fragmentSize <= 0 || fragmentSize > 255 {
+		return setup, nil, fmt.Errorf("lorawan/applayer/fragmentation: invalid fragment-size: %d", fragmentSize)
+	}
+	if len(data) == 0 {
+		return setup, nil, errors.New("lorawan/applayer/fragmentation: data must not be empty")
+	}
+
+	padding := (fragmentSize - (len(data) % fragmentSize)) % fragmentSize
+	padded := make([]byte, len(data)+padding)
+	copy(padded, data)
+
+	nbFrag := len(padded) / fragmentSize
+	if nbFrag+redundancy > 0x3fff {
+		return setup, nil, errors.New("lorawan/applayer/fragmentation: too many fragments")
+	}
+
+	fragments, err := Encode(padded, fragmentSize, redundancy)
+	if err != nil {
+		return setup, nil, err
+	}
+
+	setup = FragSessionSetupReqPayload{
+		FragSession: FragSessionSetupReqPayloadFragSession{
+			FragIndex: fragIndex,
+		},
+		NbFrag:   uint16(nbFrag),
+		FragSize: uint8(fragmentSize),
+		Padding:  uint8(padding),
+	}
+
+	var cmds Commands
+	for i, frag := range fragments {
+		cmds = append(cmds, Command{
+			CID: DataFragment,
+			Payload: &DataFragmentPayload{
+				IndexAndN: DataFragmentPayloadIndexAndN{
+					FragIndex: fragIndex,
+					N:         uint16(i + 1),
+				},
+				Payload: frag,
+			},
+		})
+	}
+
+	return setup, cmds, nil
+}
+
 func prbs23(x int) int {
 	b0 := x & 1
 	b1 := (x & 32) / 32
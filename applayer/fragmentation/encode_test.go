@@ -93,3 +93,48 @@ func TestEncode(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeSession(t *testing.T) {
+	assert := require.New(t)
+
+	data := make([]byte, 25)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	setup, cmds, err := EncodeSession(2, data, 10, 2)
+	assert.NoError(err)
+	assert.Equal(FragSessionSetupReqPayload{
+		FragSession: FragSessionSetupReqPayloadFragSession{
+			FragIndex: 2,
+		},
+		NbFrag:   3,
+		FragSize: 10,
+		Padding:  5,
+	}, setup)
+
+	padded := append(data, make([]byte, 5)...)
+	fragments, err := Encode(padded, 10, 2)
+	assert.NoError(err)
+	assert.Len(cmds, 5)
+
+	for i, cmd := range cmds {
+		assert.Equal(DataFragment, cmd.CID)
+		assert.Equal(&DataFragmentPayload{
+			IndexAndN: DataFragmentPayloadIndexAndN{
+				FragIndex: 2,
+				N:         uint16(i + 1),
+			},
+			Payload: fragments[i],
+		}, cmd.Payload)
+	}
+
+	_, _, err = EncodeSession(0, data, 0, 0)
+	assert.Error(err)
+
+	_, _, err = EncodeSession(0, nil, 10, 0)
+	assert.Error(err)
+
+	_, _, err = EncodeSession(4, data, 10, 0)
+	assert.EqualError(err, "lorawan/applayer/fragmentation: max FragIndex value is 3, got: 4")
+}
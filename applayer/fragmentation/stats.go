@@ -0,0 +1,72 @@
+package fragmentation
+
+// SessionStats contains the statistics of a fragmentation session.
+type SessionStats struct {
+	// NbFrag contains the number of uncoded fragments of the data block.
+	NbFrag int
+
+	// FragmentsSent contains the number of fragments (uncoded and coded)
+	// which have been sent. On the receiver side this is estimated by the
+	// highest received fragment index.
+	FragmentsSent int
+
+	// NbFragReceived contains the number of fragments received.
+	NbFragReceived int
+
+	// MissingFrag contains the number of fragments which are still needed
+	// to reconstruct the data block.
+	MissingFrag int
+
+	// LossRate contains the fraction (0 - 1) of the sent fragments which
+	// were lost.
+	LossRate float64
+
+	// RedundancyConsumed contains the fraction of the coded fragments
+	// which was needed to compensate for the lost fragments. A value > 1
+	// means that the redundancy was not sufficient. When no coded fragments
+	// were sent, it is set to the number of lost fragments.
+	RedundancyConsumed float64
+}
+
+// Completed returns true when the data block can be reconstructed.
+func (s SessionStats) Completed() bool {
+	return s.MissingFrag == 0 && s.NbFragReceived > 0
+}
+
+// NewSessionStats returns the session statistics for the sender side,
+// given the number of uncoded fragments, the number of fragments sent and
+// the FragSessionStatusAns of the device.
+func NewSessionStats(nbFrag, fragmentsSent int, ans FragSessionStatusAnsPayload) SessionStats {
+	return newSessionStats(nbFrag, fragmentsSent, int(ans.ReceivedAndIndex.NbFragReceived), int(ans.MissingFrag))
+}
+
+// Stats returns the session statistics for the receiver side.
+func (d *Decoder) Stats() SessionStats {
+	return newSessionStats(d.nbFrag, d.lastN, d.received, d.MissingFrag())
+}
+
+func newSessionStats(nbFrag, sent, received, missing int) SessionStats {
+	s := SessionStats{
+		NbFrag:         nbFrag,
+		FragmentsSent:  sent,
+		NbFragReceived: received,
+		MissingFrag:    missing,
+	}
+
+	lost := sent - received
+	if lost < 0 {
+		lost = 0
+	}
+
+	if sent > 0 {
+		s.LossRate = float64(lost) / float64(sent)
+	}
+
+	if redundancy := sent - nbFrag; redundancy > 0 {
+		s.RedundancyConsumed = float64(lost) / float64(redundancy)
+	} else if lost > 0 {
+		s.RedundancyConsumed = float64(lost)
+	}
+
+	return s
+}
@@ -0,0 +1,759 @@
+// Package fuota implements a FUOTA (firmware update over the air) deployment
+// orchestrator on top of the clocksync, multicastsetup and fragmentation
+// packages.
+//
+// A Deployment sequences the following steps for a set of devices:
+//
+//  1. Clock synchronization (optional)
+//  2. Multicast group setup
+//  3. Fragmentation session setup
+//  4. Class-C multicast session setup
+//  5. Transmission of the (encoded) fragments to the multicast group
+//  6. Fragmentation session status collection
+//
+// The actual transmission of downlinks is left to the caller through the
+// Hooks. Uplinks received on the application-layer fPorts must be passed
+// to HandleUplink while the deployment is running.
+package fuota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/applayer/clocksync"
+	"github.com/brocaar/lorawan/applayer/fragmentation"
+	"github.com/brocaar/lorawan/applayer/multicastsetup"
+	"github.com/brocaar/lorawan/gps"
+)
+
+// DefaultUnicastTimeout defines the default time to wait for the devices
+// to answer a unicast request.
+const DefaultUnicastTimeout = 5 * time.Minute
+
+// Errors
+var (
+	ErrTimeout   = errors.New("lorawan/applayer/fuota: timeout waiting for answer")
+	ErrNoDevices = errors.New("lorawan/applayer/fuota: no devices left in deployment")
+)
+
+// Hooks contains the functions used by the Deployment to transmit
+// downlinks.
+type Hooks struct {
+	// SendUnicast sends the given payload to the given device.
+	SendUnicast func(ctx context.Context, devEUI lorawan.EUI64, fPort uint8, data []byte) error
+
+	// SendMulticast sends the given payload to the given multicast group.
+	SendMulticast func(ctx context.Context, mcAddr lorawan.DevAddr, fPort uint8, data []byte) error
+}
+
+// Device defines a device participating in the deployment.
+type Device struct {
+	DevEUI    lorawan.EUI64
+	McRootKey lorawan.AES128Key
+}
+
+// Config defines the deployment configuration.
+type Config struct {
+	// Devices contains the devices participating in the deployment.
+	Devices []Device
+
+	// Hooks contains the downlink transmission hooks.
+	Hooks Hooks
+
+	// ClockSync enables the clock synchronization step. When disabled,
+	// the device clocks are expected to be in sync already.
+	ClockSync bool
+
+	// Multicast group.
+	McGroupID uint8
+	McAddr    lorawan.DevAddr
+	McKey     lorawan.AES128Key
+	MinMcFCnt uint32
+	MaxMcFCnt uint32
+
+	// Class-C multicast session.
+	DLFrequency    uint32 // Hz
+	DR             uint8
+	SessionTimeOut uint8 // the actual value in seconds is 2^SessionTimeOut
+
+	// SessionStartDelay defines the delay between sending the
+	// McClassCSessionReq and the start of the multicast session. This
+	// must leave enough time for the devices to answer. The fragments are
+	// sent once the session has started for all the devices, based on the
+	// TimeToStart of their answers.
+	SessionStartDelay time.Duration
+
+	// Fragmentation session.
+	FragIndex     uint8
+	FragSize      int
+	Redundancy    int
+	BlockAckDelay uint8
+	Descriptor    [4]byte
+	Payload       []byte
+
+	// FragmentInterval defines the delay between two multicast fragment
+	// transmissions.
+	FragmentInterval time.Duration
+
+	// UnicastTimeout defines the time to wait for the devices to answer
+	// a unicast request. When not set, DefaultUnicastTimeout is used.
+	UnicastTimeout time.Duration
+
+	// TimeSinceGPSEpoch returns the current time since GPS epoch. When not
+	// set, the system time is used.
+	TimeSinceGPSEpoch func() time.Duration
+
+	// OnProgress is called (when set) with a snapshot of the deployment
+	// progress after each completed step and after each sent fragment.
+	OnProgress func(Progress)
+}
+
+// Progress contains a snapshot of the deployment progress.
+type Progress struct {
+	// Stage contains the current deployment stage.
+	Stage Stage
+
+	// FragmentsTotal contains the number of fragments (including the
+	// redundancy fragments) of the session and FragmentsSent the number of
+	// fragments sent so far.
+	FragmentsTotal int
+	FragmentsSent  int
+
+	// Devices contains the total number of devices, DevicesFailed the
+	// number of devices which dropped out of the deployment and
+	// DevicesCompleted the number of devices which have received all
+	// fragments.
+	Devices          int
+	DevicesFailed    int
+	DevicesCompleted int
+
+	// Stats contains the fragmentation session statistics per device,
+	// for the devices which returned their session status.
+	Stats map[lorawan.EUI64]fragmentation.SessionStats
+
+	// AverageLossRate contains the average fragment loss rate and
+	// MaxRedundancyConsumed the max. fraction of the redundancy consumed
+	// of the devices which returned their session status.
+	AverageLossRate       float64
+	MaxRedundancyConsumed float64
+}
+
+// Stage defines the deployment stage.
+type Stage string
+
+// Deployment stages.
+const (
+	StageClockSync         Stage = "CLOCK_SYNC"
+	StageMcGroupSetup      Stage = "MC_GROUP_SETUP"
+	StageFragSessionSetup  Stage = "FRAG_SESSION_SETUP"
+	StageMcSessionSetup    Stage = "MC_SESSION_SETUP"
+	StageFragmentation     Stage = "FRAGMENTATION"
+	StageFragSessionStatus Stage = "FRAG_SESSION_STATUS"
+	StageDone              Stage = "DONE"
+)
+
+// DeviceState contains the deployment state of a device.
+type DeviceState struct {
+	ClockSync        bool
+	McGroupSetup     bool
+	FragSessionSetup bool
+	McSessionSetup   bool
+
+	// FragSessionStatus contains the FragSessionStatusAns payload of the
+	// device, when received.
+	FragSessionStatus *fragmentation.FragSessionStatusAnsPayload
+
+	// Error contains the error which caused the device to drop out of the
+	// deployment.
+	Error error
+}
+
+// Completed returns true when the device has received all fragments.
+func (s DeviceState) Completed() bool {
+	return s.Error == nil && s.FragSessionStatus != nil && s.FragSessionStatus.MissingFrag == 0 && !s.FragSessionStatus.Status.NotEnoughMatrixMemory
+}
+
+type uplink struct {
+	devEUI lorawan.EUI64
+	fPort  uint8
+	data   []byte
+}
+
+// Deployment implements a FUOTA deployment.
+type Deployment struct {
+	config  Config
+	uplinks map[lorawan.EUI64]chan uplink
+	notify  chan struct{}
+
+	mu             sync.RWMutex
+	states         map[lorawan.EUI64]*DeviceState
+	stage          Stage
+	fragmentsTotal int
+	fragmentsSent  int
+}
+
+// NewDeployment creates a new Deployment.
+func NewDeployment(config Config) (*Deployment, error) {
+	if config.Hooks.SendUnicast == nil || config.Hooks.SendMulticast == nil {
+		return nil, errors.New("lorawan/applayer/fuota: SendUnicast and SendMulticast hooks must be set")
+	}
+	if len(config.Devices) == 0 {
+		return nil, ErrNoDevices
+	}
+	if config.McGroupID > 3 {
+		return nil, fmt.Errorf("lorawan/applayer/fuota: invalid McGroupID: %d", config.McGroupID)
+	}
+	if config.FragIndex > 3 {
+		return nil, fmt.Errorf("lorawan/applayer/fuota: invalid FragIndex: %d", config.FragIndex)
+	}
+	if config.UnicastTimeout < 0 {
+		return nil, fmt.Errorf("lorawan/applayer/fuota: invalid UnicastTimeout: %s", config.UnicastTimeout)
+	}
+	if config.UnicastTimeout == 0 {
+		config.UnicastTimeout = DefaultUnicastTimeout
+	}
+	if config.TimeSinceGPSEpoch == nil {
+		config.TimeSinceGPSEpoch = func() time.Duration {
+			return gps.Time(time.Now()).TimeSinceGPSEpoch()
+		}
+	}
+
+	d := Deployment{
+		config:  config,
+		uplinks: make(map[lorawan.EUI64]chan uplink),
+		notify:  make(chan struct{}, 1),
+		states:  make(map[lorawan.EUI64]*DeviceState),
+	}
+
+	for _, dev := range config.Devices {
+		d.uplinks[dev.DevEUI] = make(chan uplink, 1)
+		d.states[dev.DevEUI] = &DeviceState{}
+	}
+
+	return &d, nil
+}
+
+// HandleUplink passes the given uplink to the deployment. It does not
+// block: the last uplink of each device is buffered until it is consumed by
+// the running deployment, an older (stale) uplink of the same device is
+// dropped. Uplinks of devices which are not part of the deployment are
+// ignored. It returns an error when the context is cancelled.
+func (d *Deployment) HandleUplink(ctx context.Context, devEUI lorawan.EUI64, fPort uint8, data []byte) error {
+	ch, ok := d.uplinks[devEUI]
+	if !ok {
+		return ctx.Err()
+	}
+
+	up := uplink{devEUI: devEUI, fPort: fPort, data: data}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		select {
+		case ch <- up:
+			// wake up the running step, a pending notification is enough
+			select {
+			case d.notify <- struct{}{}:
+			default:
+			}
+			return nil
+		default:
+		}
+
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
+
+// GetDeviceStates returns a copy of the deployment state of each device.
+func (d *Deployment) GetDeviceStates() map[lorawan.EUI64]DeviceState {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	out := make(map[lorawan.EUI64]DeviceState)
+	for devEUI, s := range d.states {
+		out[devEUI] = *s
+	}
+	return out
+}
+
+// GetProgress returns a snapshot of the deployment progress.
+func (d *Deployment) GetProgress() Progress {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	p := Progress{
+		Stage:          d.stage,
+		FragmentsTotal: d.fragmentsTotal,
+		FragmentsSent:  d.fragmentsSent,
+		Devices:        len(d.states),
+		Stats:          make(map[lorawan.EUI64]fragmentation.SessionStats),
+	}
+
+	var lossRateSum float64
+	for devEUI, s := range d.states {
+		if s.Error != nil {
+			p.DevicesFailed++
+			continue
+		}
+		if s.Completed() {
+			p.DevicesCompleted++
+		}
+
+		if s.FragSessionStatus != nil {
+			stats := fragmentation.NewSessionStats(d.fragmentsTotal-d.config.Redundancy, d.fragmentsSent, *s.FragSessionStatus)
+			p.Stats[devEUI] = stats
+
+			lossRateSum += stats.LossRate
+			if stats.RedundancyConsumed > p.MaxRedundancyConsumed {
+				p.MaxRedundancyConsumed = stats.RedundancyConsumed
+			}
+		}
+	}
+
+	if len(p.Stats) != 0 {
+		p.AverageLossRate = lossRateSum / float64(len(p.Stats))
+	}
+
+	return p
+}
+
+// Run executes the deployment steps. Devices which fail a step (e.g. by
+// returning an error or not answering within the UnicastTimeout) are
+// marked as failed and are excluded from the remaining steps. An error is
+// returned when the context is cancelled, a multicast transmission fails or
+// when no devices are left.
+func (d *Deployment) Run(ctx context.Context) error {
+	setup, fragments, err := fragmentation.EncodeSession(d.config.FragIndex, d.config.Payload, d.config.FragSize, d.config.Redundancy)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.fragmentsTotal = len(fragments)
+	d.mu.Unlock()
+
+	if d.config.ClockSync {
+		d.setStage(StageClockSync)
+		if err := d.stepClockSync(ctx); err != nil {
+			return err
+		}
+	}
+
+	d.setStage(StageMcGroupSetup)
+	if err := d.stepMcGroupSetup(ctx); err != nil {
+		return err
+	}
+
+	d.setStage(StageFragSessionSetup)
+	if err := d.stepFragSessionSetup(ctx, setup); err != nil {
+		return err
+	}
+
+	d.setStage(StageMcSessionSetup)
+	sessionStart, err := d.stepMcClassCSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	d.setStage(StageFragmentation)
+	if err := d.sendFragments(ctx, sessionStart, fragments); err != nil {
+		return err
+	}
+
+	d.setStage(StageFragSessionStatus)
+	if err := d.stepFragSessionStatus(ctx); err != nil {
+		return err
+	}
+
+	d.setStage(StageDone)
+	return nil
+}
+
+// setStage sets the current stage and reports the progress when the
+// previous stage has been completed.
+func (d *Deployment) setStage(stage Stage) {
+	d.mu.Lock()
+	prev := d.stage
+	d.stage = stage
+	d.mu.Unlock()
+
+	if prev != "" {
+		d.reportProgress()
+	}
+}
+
+func (d *Deployment) reportProgress() {
+	if d.config.OnProgress != nil {
+		d.config.OnProgress(d.GetProgress())
+	}
+}
+
+func (d *Deployment) stepClockSync(ctx context.Context) error {
+	cmd := clocksync.Command{
+		CID: clocksync.ForceDeviceResyncReq,
+		Payload: &clocksync.ForceDeviceResyncReqPayload{
+			ForceConf: clocksync.ForceDeviceResyncReqPayloadForceConf{
+				NbTransmissions: 1,
+			},
+		},
+	}
+	b, err := cmd.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	return d.unicastStep(ctx, clocksync.DefaultFPort, func(Device) ([]byte, error) { return b, nil }, func(devEUI lorawan.EUI64, data []byte) (bool, error) {
+		var cmds clocksync.Commands
+		if err := cmds.UnmarshalBinary(true, data); err != nil {
+			return false, err
+		}
+
+		found := false
+		for _, cmd := range cmds {
+			if cmd.CID == clocksync.AppTimeReq {
+				found = true
+			}
+		}
+		if !found {
+			return false, nil
+		}
+
+		ans, err := clocksync.HandleUplink(d.config.TimeSinceGPSEpoch(), data)
+		if err != nil {
+			return false, err
+		}
+		if len(ans) != 0 {
+			b, err := ans.MarshalBinary()
+			if err != nil {
+				return false, err
+			}
+			if err := d.config.Hooks.SendUnicast(ctx, devEUI, clocksync.DefaultFPort, b); err != nil {
+				return false, err
+			}
+		}
+
+		d.setState(devEUI, func(s *DeviceState) { s.ClockSync = true })
+		return true, nil
+	})
+}
+
+func (d *Deployment) stepMcGroupSetup(ctx context.Context) error {
+	return d.unicastStep(ctx, multicastsetup.DefaultFPort, func(dev Device) ([]byte, error) {
+		mcKEKey, err := multicastsetup.GetMcKEKey(dev.McRootKey)
+		if err != nil {
+			return nil, err
+		}
+
+		pl, err := multicastsetup.NewMcGroupSetupReqPayload(d.config.McGroupID, d.config.McAddr, d.config.McKey, mcKEKey, d.config.MinMcFCnt, d.config.MaxMcFCnt)
+		if err != nil {
+			return nil, err
+		}
+
+		cmd := multicastsetup.Command{
+			CID:     multicastsetup.McGroupSetupReq,
+			Payload: &pl,
+		}
+		return cmd.MarshalBinary()
+	}, func(devEUI lorawan.EUI64, data []byte) (bool, error) {
+		var cmds multicastsetup.Commands
+		if err := cmds.UnmarshalBinary(true, data); err != nil {
+			return false, err
+		}
+
+		for _, cmd := range cmds {
+			pl, ok := cmd.Payload.(*multicastsetup.McGroupSetupAnsPayload)
+			if !ok || pl.McGroupIDHeader.McGroupID != d.config.McGroupID {
+				continue
+			}
+
+			if pl.McGroupIDHeader.IDError {
+				return false, errors.New("lorawan/applayer/fuota: McGroupSetupAns IDError")
+			}
+
+			d.setState(devEUI, func(s *DeviceState) { s.McGroupSetup = true })
+			return true, nil
+		}
+
+		return false, nil
+	})
+}
+
+func (d *Deployment) stepFragSessionSetup(ctx context.Context, setup fragmentation.FragSessionSetupReqPayload) error {
+	setup.FragSession.McGroupBitMask[d.config.McGroupID] = true
+	setup.Control.BlockAckDelay = d.config.BlockAckDelay
+	setup.Descriptor = d.config.Descriptor
+
+	cmd := fragmentation.Command{
+		CID:     fragmentation.FragSessionSetupReq,
+		Payload: &setup,
+	}
+	b, err := cmd.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	return d.unicastStep(ctx, fragmentation.DefaultFPort, func(Device) ([]byte, error) { return b, nil }, func(devEUI lorawan.EUI64, data []byte) (bool, error) {
+		var cmds fragmentation.Commands
+		if err := cmds.UnmarshalBinary(true, data); err != nil {
+			return false, err
+		}
+
+		for _, cmd := range cmds {
+			pl, ok := cmd.Payload.(*fragmentation.FragSessionSetupAnsPayload)
+			if !ok || pl.StatusBitMask.FragIndex != d.config.FragIndex {
+				continue
+			}
+
+			s := pl.StatusBitMask
+			if s.WrongDescriptor || s.FragSessionIndexNotSupported || s.NotEnoughMemory || s.EncodingUnsupported {
+				return false, fmt.Errorf("lorawan/applayer/fuota: FragSessionSetupAns error (WrongDescriptor: %t, FragSessionIndexNotSupported: %t, NotEnoughMemory: %t, EncodingUnsupported: %t)",
+					s.WrongDescriptor, s.FragSessionIndexNotSupported, s.NotEnoughMemory, s.EncodingUnsupported)
+			}
+
+			d.setState(devEUI, func(s *DeviceState) { s.FragSessionSetup = true })
+			return true, nil
+		}
+
+		return false, nil
+	})
+}
+
+// stepMcClassCSession sets up the Class-C session and returns the session
+// start time (as time since GPS epoch). As the answers can arrive after the
+// requested session start, the returned start time is based on the
+// TimeToStart of the answers, and is never before the requested start time.
+func (d *Deployment) stepMcClassCSession(ctx context.Context) (time.Duration, error) {
+	sessionStart := (d.config.TimeSinceGPSEpoch() + d.config.SessionStartDelay).Truncate(time.Second)
+
+	cmd := multicastsetup.Command{
+		CID: multicastsetup.McClassCSessionReq,
+		Payload: &multicastsetup.McClassCSessionReqPayload{
+			McGroupIDHeader: multicastsetup.McClassCSessionReqPayloadMcGroupIDHeader{
+				McGroupID: d.config.McGroupID,
+			},
+			SessionTime: uint32(sessionStart / time.Second),
+			SessionTimeOut: multicastsetup.McClassCSessionReqPayloadSessionTimeOut{
+				TimeOut: d.config.SessionTimeOut,
+			},
+			DLFrequency: d.config.DLFrequency,
+			DR:          d.config.DR,
+		},
+	}
+	b, err := cmd.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+
+	err = d.unicastStep(ctx, multicastsetup.DefaultFPort, func(Device) ([]byte, error) { return b, nil }, func(devEUI lorawan.EUI64, data []byte) (bool, error) {
+		var cmds multicastsetup.Commands
+		if err := cmds.UnmarshalBinary(true, data); err != nil {
+			return false, err
+		}
+
+		for _, cmd := range cmds {
+			pl, ok := cmd.Payload.(*multicastsetup.McClassCSessionAnsPayload)
+			if !ok || pl.StatusAndMcGroupID.McGroupID != d.config.McGroupID {
+				continue
+			}
+
+			s := pl.StatusAndMcGroupID
+			if s.McGroupUndefined || s.FreqError || s.DRError {
+				return false, fmt.Errorf("lorawan/applayer/fuota: McClassCSessionAns error (McGroupUndefined: %t, FreqError: %t, DRError: %t)",
+					s.McGroupUndefined, s.FreqError, s.DRError)
+			}
+
+			if pl.TimeToStart != nil {
+				if start := d.config.TimeSinceGPSEpoch() + time.Duration(*pl.TimeToStart)*time.Second; start > sessionStart {
+					sessionStart = start
+				}
+			}
+
+			d.setState(devEUI, func(s *DeviceState) { s.McSessionSetup = true })
+			return true, nil
+		}
+
+		return false, nil
+	})
+
+	return sessionStart, err
+}
+
+func (d *Deployment) sendFragments(ctx context.Context, sessionStart time.Duration, fragments fragmentation.Commands) error {
+	if err := sleep(ctx, sessionStart-d.config.TimeSinceGPSEpoch()); err != nil {
+		return err
+	}
+
+	for i, cmd := range fragments {
+		if i != 0 {
+			if err := sleep(ctx, d.config.FragmentInterval); err != nil {
+				return err
+			}
+		}
+
+		b, err := cmd.MarshalBinary()
+		if err != nil {
+			return err
+		}
+
+		if err := d.config.Hooks.SendMulticast(ctx, d.config.McAddr, fragmentation.DefaultFPort, b); err != nil {
+			return fmt.Errorf("lorawan/applayer/fuota: send fragment %d error: %w", i+1, err)
+		}
+
+		d.mu.Lock()
+		d.fragmentsSent++
+		d.mu.Unlock()
+		d.reportProgress()
+	}
+
+	return nil
+}
+
+func (d *Deployment) stepFragSessionStatus(ctx context.Context) error {
+	cmd := fragmentation.Command{
+		CID: fragmentation.FragSessionStatusReq,
+		Payload: &fragmentation.FragSessionStatusReqPayload{
+			FragStatusReqParam: fragmentation.FragSessionStatusReqPayloadFragStatusReqParam{
+				FragIndex:    d.config.FragIndex,
+				Participants: true,
+			},
+		},
+	}
+	b, err := cmd.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	return d.unicastStep(ctx, fragmentation.DefaultFPort, func(Device) ([]byte, error) { return b, nil }, func(devEUI lorawan.EUI64, data []byte) (bool, error) {
+		var cmds fragmentation.Commands
+		if err := cmds.UnmarshalBinary(true, data); err != nil {
+			return false, err
+		}
+
+		for _, cmd := range cmds {
+			pl, ok := cmd.Payload.(*fragmentation.FragSessionStatusAnsPayload)
+			if !ok || pl.ReceivedAndIndex.FragIndex != d.config.FragIndex {
+				continue
+			}
+
+			d.setState(devEUI, func(s *DeviceState) { s.FragSessionStatus = pl })
+			return true, nil
+		}
+
+		return false, nil
+	})
+}
+
+// unicastStep sends the request returned by req to each device which has
+// not failed yet and waits for the answers. The handle function returns
+// true once the expected answer has been received from the device.
+func (d *Deployment) unicastStep(ctx context.Context, fPort uint8, req func(Device) ([]byte, error), handle func(lorawan.EUI64, []byte) (bool, error)) error {
+	pending := make(map[lorawan.EUI64]struct{})
+
+	for _, dev := range d.config.Devices {
+		if d.failed(dev.DevEUI) {
+			continue
+		}
+
+		b, err := req(dev)
+		if err == nil {
+			err = d.config.Hooks.SendUnicast(ctx, dev.DevEUI, fPort, b)
+		}
+		if err != nil {
+			d.fail(dev.DevEUI, err)
+			continue
+		}
+
+		pending[dev.DevEUI] = struct{}{}
+	}
+
+	if len(pending) == 0 {
+		return ErrNoDevices
+	}
+
+	timer := time.NewTimer(d.config.UnicastTimeout)
+	defer timer.Stop()
+
+	for len(pending) != 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			for devEUI := range pending {
+				d.fail(devEUI, ErrTimeout)
+			}
+			pending = nil
+		case <-d.notify:
+			for devEUI := range pending {
+				var up uplink
+				select {
+				case up = <-d.uplinks[devEUI]:
+				default:
+					continue
+				}
+
+				if up.fPort != fPort {
+					continue
+				}
+
+				done, err := handle(up.devEUI, up.data)
+				if err != nil {
+					d.fail(up.devEUI, err)
+					delete(pending, up.devEUI)
+					continue
+				}
+				if done {
+					delete(pending, up.devEUI)
+				}
+			}
+		}
+	}
+
+	for _, dev := range d.config.Devices {
+		if !d.failed(dev.DevEUI) {
+			return nil
+		}
+	}
+
+	return ErrNoDevices
+}
+
+func (d *Deployment) setState(devEUI lorawan.EUI64, f func(*DeviceState)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	f(d.states[devEUI])
+}
+
+func (d *Deployment) fail(devEUI lorawan.EUI64, err error) {
+	d.setState(devEUI, func(s *DeviceState) { s.Error = err })
+}
+
+func (d *Deployment) failed(devEUI lorawan.EUI64) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.states[devEUI].Error != nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
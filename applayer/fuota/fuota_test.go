@@ -0,0 +1,401 @@
+package fuota
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/applayer/clocksync"
+	"github.com/brocaar/lorawan/applayer/fragmentation"
+	"github.com/brocaar/lorawan/applayer/multicastsetup"
+)
+
+// testDevice simulates the device side of a FUOTA deployment.
+type testDevice struct {
+	sync.Mutex
+
+	devEUI    lorawan.EUI64
+	silent    bool // do not answer any request
+	clock     clocksync.Device
+	mcKey     lorawan.AES128Key
+	fragments int
+}
+
+type testNetwork struct {
+	t          *testing.T
+	deployment *Deployment
+	devices    map[lorawan.EUI64]*testDevice
+	now        time.Duration
+}
+
+func (n *testNetwork) uplink(ctx context.Context, devEUI lorawan.EUI64, fPort uint8, cmd interface{ MarshalBinary() ([]byte, error) }) {
+	b, err := cmd.MarshalBinary()
+	require.NoError(n.t, err)
+	go n.deployment.HandleUplink(ctx, devEUI, fPort, b)
+}
+
+func (n *testNetwork) sendUnicast(ctx context.Context, devEUI lorawan.EUI64, fPort uint8, data []byte) error {
+	dev := n.devices[devEUI]
+	dev.Lock()
+	defer dev.Unlock()
+
+	if dev.silent {
+		return nil
+	}
+
+	switch fPort {
+	case clocksync.DefaultFPort:
+		ans, err := dev.clock.HandleDownlink(n.now, data)
+		if err != nil {
+			return err
+		}
+		if len(ans) != 0 {
+			n.uplink(ctx, devEUI, fPort, ans)
+		}
+		if dev.clock.NbTransmissions > 0 {
+			n.uplink(ctx, devEUI, fPort, clocksync.Commands{dev.clock.NewAppTimeReq(n.now, true)})
+		}
+	case multicastsetup.DefaultFPort:
+		var cmds multicastsetup.Commands
+		if err := cmds.UnmarshalBinary(false, data); err != nil {
+			return err
+		}
+
+		for _, cmd := range cmds {
+			switch pl := cmd.Payload.(type) {
+			case *multicastsetup.McGroupSetupReqPayload:
+				mcKEKey, err := multicastsetup.GetMcKEKey(lorawan.AES128Key{0x01})
+				if err != nil {
+					return err
+				}
+				dev.mcKey, err = multicastsetup.DecryptMcKey(mcKEKey, pl.McKeyEncrypted)
+				if err != nil {
+					return err
+				}
+				n.uplink(ctx, devEUI, fPort, multicastsetup.Commands{{
+					CID: multicastsetup.McGroupSetupAns,
+					Payload: &multicastsetup.McGroupSetupAnsPayload{
+						McGroupIDHeader: multicastsetup.McGroupSetupAnsPayloadMcGroupIDHeader{McGroupID: pl.McGroupIDHeader.McGroupID},
+					},
+				}})
+			case *multicastsetup.McClassCSessionReqPayload:
+				timeToStart := pl.SessionTime - uint32(n.now/time.Second)
+				n.uplink(ctx, devEUI, fPort, multicastsetup.Commands{{
+					CID: multicastsetup.McClassCSessionAns,
+					Payload: &multicastsetup.McClassCSessionAnsPayload{
+						StatusAndMcGroupID: multicastsetup.McClassCSessionAnsPayloadStatusAndMcGroupID{McGroupID: pl.McGroupIDHeader.McGroupID},
+						TimeToStart:        &timeToStart,
+					},
+				}})
+			}
+		}
+	case fragmentation.DefaultFPort:
+		var cmds fragmentation.Commands
+		if err := cmds.UnmarshalBinary(false, data); err != nil {
+			return err
+		}
+
+		for _, cmd := range cmds {
+			switch pl := cmd.Payload.(type) {
+			case *fragmentation.FragSessionSetupReqPayload:
+				n.uplink(ctx, devEUI, fPort, fragmentation.Commands{{
+					CID: fragmentation.FragSessionSetupAns,
+					Payload: &fragmentation.FragSessionSetupAnsPayload{
+						StatusBitMask: fragmentation.FragSessionSetupAnsPayloadStatusBitMask{FragIndex: pl.FragSession.FragIndex},
+					},
+				}})
+			case *fragmentation.FragSessionStatusReqPayload:
+				n.uplink(ctx, devEUI, fPort, fragmentation.Commands{{
+					CID: fragmentation.FragSessionStatusAns,
+					Payload: &fragmentation.FragSessionStatusAnsPayload{
+						ReceivedAndIndex: fragmentation.FragSessionStatusAnsPayloadReceivedAndIndex{
+							FragIndex:      pl.FragStatusReqParam.FragIndex,
+							NbFragReceived: uint16(dev.fragments),
+						},
+					},
+				}})
+			}
+		}
+	}
+
+	return nil
+}
+
+func (n *testNetwork) sendMulticast(ctx context.Context, mcAddr lorawan.DevAddr, fPort uint8, data []byte) error {
+	for _, dev := range n.devices {
+		dev.Lock()
+		if !dev.silent {
+			dev.fragments++
+		}
+		dev.Unlock()
+	}
+	return nil
+}
+
+func TestDeployment(t *testing.T) {
+	assert := require.New(t)
+
+	n := testNetwork{
+		t:   t,
+		now: 1234 * time.Second,
+		devices: map[lorawan.EUI64]*testDevice{
+			{0x01}: {devEUI: lorawan.EUI64{0x01}, clock: clocksync.Device{Correction: -10 * time.Second}},
+			{0x02}: {devEUI: lorawan.EUI64{0x02}, silent: true},
+		},
+	}
+
+	mcKey := lorawan.AES128Key{0x05, 0x06}
+	var progress []Progress
+
+	d, err := NewDeployment(Config{
+		Devices: []Device{
+			{DevEUI: lorawan.EUI64{0x01}, McRootKey: lorawan.AES128Key{0x01}},
+			{DevEUI: lorawan.EUI64{0x02}, McRootKey: lorawan.AES128Key{0x02}},
+		},
+		Hooks: Hooks{
+			SendUnicast:   n.sendUnicast,
+			SendMulticast: n.sendMulticast,
+		},
+		ClockSync:         true,
+		McGroupID:         1,
+		McAddr:            lorawan.DevAddr{0x01, 0x02, 0x03, 0x04},
+		McKey:             mcKey,
+		MaxMcFCnt:         1000,
+		DLFrequency:       869525000,
+		DR:                3,
+		SessionTimeOut:    8,
+		FragIndex:         2,
+		FragSize:          10,
+		Redundancy:        3,
+		Payload:           make([]byte, 45),
+		UnicastTimeout:    100 * time.Millisecond,
+		TimeSinceGPSEpoch: func() time.Duration { return n.now },
+		OnProgress:        func(p Progress) { progress = append(progress, p) },
+	})
+	assert.NoError(err)
+	n.deployment = d
+
+	assert.NoError(d.Run(context.Background()))
+
+	// 6 completed steps + 8 fragments
+	assert.Len(progress, 14)
+	assert.Equal(StageMcGroupSetup, progress[0].Stage)
+	assert.Equal(1, progress[0].DevicesFailed)
+	assert.Equal(StageFragmentation, progress[4].Stage)
+	assert.Equal(1, progress[4].FragmentsSent)
+
+	p := d.GetProgress()
+	assert.Equal(progress[len(progress)-1], p)
+	assert.Equal(StageDone, p.Stage)
+	assert.Equal(8, p.FragmentsTotal)
+	assert.Equal(8, p.FragmentsSent)
+	assert.Equal(2, p.Devices)
+	assert.Equal(1, p.DevicesFailed)
+	assert.Equal(1, p.DevicesCompleted)
+	assert.Equal(map[lorawan.EUI64]fragmentation.SessionStats{
+		{0x01}: {
+			NbFrag:         5,
+			FragmentsSent:  8,
+			NbFragReceived: 8,
+		},
+	}, p.Stats)
+	assert.Equal(float64(0), p.AverageLossRate)
+
+	states := d.GetDeviceStates()
+	assert.Len(states, 2)
+
+	s := states[lorawan.EUI64{0x01}]
+	assert.NoError(s.Error)
+	assert.True(s.ClockSync)
+	assert.True(s.McGroupSetup)
+	assert.True(s.FragSessionSetup)
+	assert.True(s.McSessionSetup)
+	assert.NotNil(s.FragSessionStatus)
+	assert.EqualValues(8, s.FragSessionStatus.ReceivedAndIndex.NbFragReceived)
+	assert.True(s.Completed())
+
+	dev := n.devices[lorawan.EUI64{0x01}]
+	assert.Equal(time.Duration(0), dev.clock.Correction)
+	assert.Equal(mcKey, dev.mcKey)
+
+	s = states[lorawan.EUI64{0x02}]
+	assert.Equal(ErrTimeout, s.Error)
+	assert.False(s.ClockSync)
+	assert.False(s.Completed())
+}
+
+func TestDeploymentNoDevicesLeft(t *testing.T) {
+	assert := require.New(t)
+
+	n := testNetwork{
+		t: t,
+		devices: map[lorawan.EUI64]*testDevice{
+			{0x01}: {devEUI: lorawan.EUI64{0x01}, silent: true},
+		},
+	}
+
+	d, err := NewDeployment(Config{
+		Devices: []Device{{DevEUI: lorawan.EUI64{0x01}}},
+		Hooks: Hooks{
+			SendUnicast:   n.sendUnicast,
+			SendMulticast: n.sendMulticast,
+		},
+		FragSize:       10,
+		Payload:        make([]byte, 10),
+		UnicastTimeout: 10 * time.Millisecond,
+	})
+	assert.NoError(err)
+	n.deployment = d
+
+	assert.Equal(ErrNoDevices, d.Run(context.Background()))
+	assert.Equal(0, n.devices[lorawan.EUI64{0x01}].fragments)
+}
+
+func TestNewDeploymentValidation(t *testing.T) {
+	assert := require.New(t)
+
+	hooks := Hooks{
+		SendUnicast:   func(context.Context, lorawan.EUI64, uint8, []byte) error { return nil },
+		SendMulticast: func(context.Context, lorawan.DevAddr, uint8, []byte) error { return nil },
+	}
+
+	_, err := NewDeployment(Config{Devices: []Device{{}}})
+	assert.Error(err)
+
+	_, err = NewDeployment(Config{Hooks: hooks})
+	assert.Equal(ErrNoDevices, err)
+
+	_, err = NewDeployment(Config{Hooks: hooks, Devices: []Device{{}}, McGroupID: 4})
+	assert.Error(err)
+
+	_, err = NewDeployment(Config{Hooks: hooks, Devices: []Device{{}}, UnicastTimeout: -time.Second})
+	assert.Error(err)
+
+	d, err := NewDeployment(Config{Hooks: hooks, Devices: []Device{{}}})
+	assert.NoError(err)
+	assert.Equal(DefaultUnicastTimeout, d.config.UnicastTimeout)
+}
+
+func TestDeploymentHandleUplinkNonBlocking(t *testing.T) {
+	assert := require.New(t)
+
+	d, err := NewDeployment(Config{
+		Devices: []Device{{DevEUI: lorawan.EUI64{0x01}}},
+		Hooks: Hooks{
+			SendUnicast:   func(context.Context, lorawan.EUI64, uint8, []byte) error { return nil },
+			SendMulticast: func(context.Context, lorawan.DevAddr, uint8, []byte) error { return nil },
+		},
+	})
+	assert.NoError(err)
+
+	// no deployment is running, the stale uplinks are dropped
+	for i := 0; i < 3; i++ {
+		assert.NoError(d.HandleUplink(context.Background(), lorawan.EUI64{0x01}, 200, []byte{byte(i)}))
+	}
+	assert.Len(d.uplinks[lorawan.EUI64{0x01}], 1)
+	assert.Equal([]byte{2}, (<-d.uplinks[lorawan.EUI64{0x01}]).data)
+
+	// uplinks of unknown devices are ignored
+	assert.NoError(d.HandleUplink(context.Background(), lorawan.EUI64{0x02}, 200, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(context.Canceled, d.HandleUplink(ctx, lorawan.EUI64{0x01}, 200, nil))
+}
+
+func TestDeploymentInterleavedUplinks(t *testing.T) {
+	assert := require.New(t)
+
+	var d *Deployment
+	devA := lorawan.EUI64{0x01}
+	devB := lorawan.EUI64{0x02}
+
+	d, err := NewDeployment(Config{
+		Devices: []Device{{DevEUI: devA}, {DevEUI: devB}},
+		Hooks: Hooks{
+			SendUnicast: func(ctx context.Context, devEUI lorawan.EUI64, fPort uint8, data []byte) error {
+				if devEUI != devB {
+					return nil
+				}
+
+				// the answer of device B is followed by uplinks of device A
+				// (unrelated and answer), which must not evict it
+				for _, up := range []struct {
+					devEUI lorawan.EUI64
+					fPort  uint8
+				}{
+					{devB, fPort},
+					{devA, fPort + 1},
+					{devA, fPort + 1},
+					{devA, fPort},
+				} {
+					if err := d.HandleUplink(ctx, up.devEUI, up.fPort, []byte{up.devEUI[0]}); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			SendMulticast: func(context.Context, lorawan.DevAddr, uint8, []byte) error { return nil },
+		},
+		UnicastTimeout: 100 * time.Millisecond,
+	})
+	assert.NoError(err)
+
+	answers := make(map[lorawan.EUI64][]byte)
+	err = d.unicastStep(context.Background(), 200, func(Device) ([]byte, error) {
+		return nil, nil
+	}, func(devEUI lorawan.EUI64, b []byte) (bool, error) {
+		answers[devEUI] = b
+		return true, nil
+	})
+	assert.NoError(err)
+
+	assert.Equal(map[lorawan.EUI64][]byte{
+		devA: {0x01},
+		devB: {0x02},
+	}, answers)
+	for devEUI, s := range d.GetDeviceStates() {
+		assert.NoError(s.Error, devEUI.String())
+	}
+}
+
+func TestDeploymentSessionStartAfterAnswers(t *testing.T) {
+	assert := require.New(t)
+
+	var d *Deployment
+	now := 100 * time.Second
+	timeToStart := uint32(30)
+
+	d, err := NewDeployment(Config{
+		Devices: []Device{{DevEUI: lorawan.EUI64{0x01}}},
+		Hooks: Hooks{
+			SendUnicast: func(ctx context.Context, devEUI lorawan.EUI64, fPort uint8, data []byte) error {
+				// the answer arrives after the requested session start
+				now += 20 * time.Second
+
+				b, err := multicastsetup.Commands{{
+					CID: multicastsetup.McClassCSessionAns,
+					Payload: &multicastsetup.McClassCSessionAnsPayload{
+						TimeToStart: &timeToStart,
+					},
+				}}.MarshalBinary()
+				if err != nil {
+					return err
+				}
+				return d.HandleUplink(ctx, devEUI, fPort, b)
+			},
+			SendMulticast: func(context.Context, lorawan.DevAddr, uint8, []byte) error { return nil },
+		},
+		SessionStartDelay: 10 * time.Second,
+		TimeSinceGPSEpoch: func() time.Duration { return now },
+	})
+	assert.NoError(err)
+
+	sessionStart, err := d.stepMcClassCSession(context.Background())
+	assert.NoError(err)
+	assert.Equal(150*time.Second, sessionStart)
+}
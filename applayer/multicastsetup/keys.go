@@ -50,6 +50,56 @@ func GetMcNetSKey(mcKey lorawan.AES128Key, mcAddr lorawan.DevAddr) (lorawan.AES1
 	return getKey(mcKey, b)
 }
 
+// EncryptMcKey returns the McKeyEncrypted value of the McGroupSetupReq
+// payload, given the McKEKey and McKey. Note that the McKey is "encrypted"
+// using the AES decrypt operation, so that the device only needs to
+// implement the AES encrypt operation.
+func EncryptMcKey(mcKEKey, mcKey lorawan.AES128Key) ([16]byte, error) {
+	var out [16]byte
+
+	block, err := aes.NewCipher(mcKEKey[:])
+	if err != nil {
+		return out, err
+	}
+	if block.BlockSize() != len(out) {
+		return out, fmt.Errorf("block-size of %d bytes is expected", len(out))
+	}
+
+	block.Decrypt(out[:], mcKey[:])
+	return out, nil
+}
+
+// DecryptMcKey returns the McKey given the McKEKey and the McKeyEncrypted
+// value of the McGroupSetupReq payload. This is the inverse of EncryptMcKey.
+func DecryptMcKey(mcKEKey lorawan.AES128Key, mcKeyEncrypted [16]byte) (lorawan.AES128Key, error) {
+	return getKey(mcKEKey, mcKeyEncrypted)
+}
+
+// McSessionKeys contains the multicast group session keys.
+type McSessionKeys struct {
+	McAppSKey lorawan.AES128Key
+	McNetSKey lorawan.AES128Key
+}
+
+// GetMcSessionKeys returns the McAppSKey and McNetSKey given the McKey and
+// McAddr.
+func GetMcSessionKeys(mcKey lorawan.AES128Key, mcAddr lorawan.DevAddr) (McSessionKeys, error) {
+	var out McSessionKeys
+	var err error
+
+	out.McAppSKey, err = GetMcAppSKey(mcKey, mcAddr)
+	if err != nil {
+		return out, err
+	}
+
+	out.McNetSKey, err = GetMcNetSKey(mcKey, mcAddr)
+	if err != nil {
+		return out, err
+	}
+
+	return out, nil
+}
+
 func getKey(key lorawan.AES128Key, b [16]byte) (lorawan.AES128Key, error) {
 	var out lorawan.AES128Key
 
@@ -48,4 +48,29 @@ func TestKeys(t *testing.T) {
 		assert.NoError(err)
 		assert.Equal(lorawan.AES128Key{0xc3, 0xf6, 0xb3, 0x88, 0xba, 0xd6, 0xc0, 0x0, 0xb2, 0x32, 0x91, 0xad, 0x52, 0xc1, 0x1c, 0x7b}, key)
 	})
+
+	t.Run("EncryptMcKey", func(t *testing.T) {
+		assert := require.New(t)
+		mcKEKey, err := GetMcKEKey(mcRootKey)
+		assert.NoError(err)
+
+		encrypted, err := EncryptMcKey(mcKEKey, mcKey)
+		assert.NoError(err)
+		assert.NotEqual([16]byte(mcKey), encrypted)
+
+		// the device recovers the McKey using the AES encrypt operation
+		key, err := DecryptMcKey(mcKEKey, encrypted)
+		assert.NoError(err)
+		assert.Equal(mcKey, key)
+	})
+
+	t.Run("GetMcSessionKeys", func(t *testing.T) {
+		assert := require.New(t)
+		keys, err := GetMcSessionKeys(mcKey, mcAddr)
+		assert.NoError(err)
+		assert.Equal(McSessionKeys{
+			McAppSKey: lorawan.AES128Key{0x95, 0xcb, 0x45, 0x18, 0xee, 0x37, 0x56, 0x6, 0x73, 0x5b, 0xba, 0xcb, 0xdc, 0xe8, 0x37, 0xfa},
+			McNetSKey: lorawan.AES128Key{0xc3, 0xf6, 0xb3, 0x88, 0xba, 0xd6, 0xc0, 0x0, 0xb2, 0x32, 0x91, 0xad, 0x52, 0xc1, 0x1c, 0x7b},
+		}, keys)
+	})
 }
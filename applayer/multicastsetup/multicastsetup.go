@@ -340,6 +340,36 @@ type McGroupSetupReqPayload struct {
 	MaxMcFCnt       uint32
 }
 
+// NewMcGroupSetupReqPayload returns a new McGroupSetupReq payload for the
+// given multicast-group, with the McKey encrypted using the given McKEKey.
+func NewMcGroupSetupReqPayload(mcGroupID uint8, mcAddr lorawan.DevAddr, mcKey, mcKEKey lorawan.AES128Key, minMcFCnt, maxMcFCnt uint32) (McGroupSetupReqPayload, error) {
+	mcKeyEncrypted, err := EncryptMcKey(mcKEKey, mcKey)
+	if err != nil {
+		return McGroupSetupReqPayload{}, err
+	}
+
+	return McGroupSetupReqPayload{
+		McGroupIDHeader: McGroupSetupReqPayloadMcGroupIDHeader{
+			McGroupID: mcGroupID,
+		},
+		McAddr:         mcAddr,
+		McKeyEncrypted: mcKeyEncrypted,
+		MinMcFCnt:      minMcFCnt,
+		MaxMcFCnt:      maxMcFCnt,
+	}, nil
+}
+
+// GetMcSessionKeys decrypts the McKey using the given McKEKey and returns
+// the session keys of the multicast group.
+func (p McGroupSetupReqPayload) GetMcSessionKeys(mcKEKey lorawan.AES128Key) (McSessionKeys, error) {
+	mcKey, err := DecryptMcKey(mcKEKey, p.McKeyEncrypted)
+	if err != nil {
+		return McSessionKeys{}, err
+	}
+
+	return GetMcSessionKeys(mcKey, p.McAddr)
+}
+
 // McGroupSetupReqPayloadMcGroupIDHeader implements the McGroupSetupReq payload McGroupIDHeader field.
 type McGroupSetupReqPayloadMcGroupIDHeader struct {
 	McGroupID uint8
@@ -436,3 +436,34 @@ func TestUnmarshalCommands(t *testing.T) {
 	assert.NoError(cmds.UnmarshalBinary(true, b))
 	assert.Equal(commands, cmds)
 }
+
+func TestNewMcGroupSetupReqPayload(t *testing.T) {
+	assert := require.New(t)
+
+	mcAddr := lorawan.DevAddr{1, 2, 3, 4}
+	mcKey := lorawan.AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	mcKEKey := lorawan.AES128Key{4, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+
+	pl, err := NewMcGroupSetupReqPayload(2, mcAddr, mcKey, mcKEKey, 10, 100)
+	assert.NoError(err)
+
+	encrypted, err := EncryptMcKey(mcKEKey, mcKey)
+	assert.NoError(err)
+
+	assert.Equal(McGroupSetupReqPayload{
+		McGroupIDHeader: McGroupSetupReqPayloadMcGroupIDHeader{
+			McGroupID: 2,
+		},
+		McAddr:         mcAddr,
+		McKeyEncrypted: encrypted,
+		MinMcFCnt:      10,
+		MaxMcFCnt:      100,
+	}, pl)
+
+	keys, err := pl.GetMcSessionKeys(mcKEKey)
+	assert.NoError(err)
+
+	expected, err := GetMcSessionKeys(mcKey, mcAddr)
+	assert.NoError(err)
+	assert.Equal(expected, keys)
+}
@@ -0,0 +1,168 @@
+package multicastsetup
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/brocaar/lorawan/band"
+	"github.com/brocaar/lorawan/gps"
+)
+
+// GetSessionTime returns the SessionTime (seconds since GPS epoch, modulo
+// 2^32) for the given session start time.
+func GetSessionTime(start time.Time) uint32 {
+	return uint32(gps.Time(start).TimeSinceGPSEpoch() / time.Second)
+}
+
+// GetClassCSessionTimeOut returns the smallest SessionTimeOut value for
+// which the Class-C session (2^TimeOut seconds) lasts at least the given
+// duration.
+func GetClassCSessionTimeOut(duration time.Duration) (uint8, error) {
+	return getTimeOut(duration, time.Second)
+}
+
+// GetClassBSessionTimeOut returns the smallest TimeOut value for which the
+// Class-B session (2^TimeOut beacon periods) lasts at least the given
+// duration.
+func GetClassBSessionTimeOut(duration time.Duration) (uint8, error) {
+	return getTimeOut(duration, band.BeaconPeriod)
+}
+
+// GetClassBPeriodicity returns the Periodicity value for the given
+// ping-slot period, which must equal 2^Periodicity seconds (1 - 128
+// seconds).
+func GetClassBPeriodicity(pingSlotPeriod time.Duration) (uint8, error) {
+	for i := uint8(0); i <= 7; i++ {
+		if time.Duration(1<<i)*time.Second == pingSlotPeriod {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("lorawan/applayer/multicastsetup: invalid ping-slot period: %s", pingSlotPeriod)
+}
+
+// ValidateDownlink validates that the given frequency and data-rate can be
+// used for a multicast downlink within the given band. The frequency must
+// be a multiple of 100 Hz and equal to one of the downlink channels, the
+// RX2 frequency or a Class-B beacon frequency.
+func ValidateDownlink(b band.Band, frequency, dr int) error {
+	if frequency%100 != 0 {
+		return errors.New("lorawan/applayer/multicastsetup: DLFrequency must be a multiple of 100")
+	}
+
+	dataRate, err := b.GetDataRate(dr)
+	if err != nil {
+		return fmt.Errorf("lorawan/applayer/multicastsetup: get data-rate error: %w", err)
+	}
+	// some bands use the same modulation parameters for an uplink and a
+	// downlink data-rate, in which case the index differs
+	if i, err := b.GetDataRateIndex(false, dataRate); err != nil || i != dr {
+		return fmt.Errorf("lorawan/applayer/multicastsetup: data-rate %d can not be used for downlink", dr)
+	}
+
+	if frequency == b.GetDefaults().RX2Frequency {
+		return nil
+	}
+	for i := 0; ; i++ {
+		c, err := b.GetDownlinkChannel(i)
+		if err != nil {
+			break
+		}
+		if c.Frequency == frequency {
+			return nil
+		}
+	}
+	for i := 0; i < 8; i++ {
+		beacon, err := band.GetBeacon(b, time.Duration(i)*band.BeaconPeriod)
+		if err != nil {
+			break
+		}
+		if beacon.Frequency == frequency {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("lorawan/applayer/multicastsetup: frequency %d is not a downlink frequency of band %s", frequency, b.Name())
+}
+
+// NewMcClassCSessionReqPayload returns a new McClassCSessionReq payload for
+// a Class-C session starting at the given time and lasting at least the
+// given duration.
+func NewMcClassCSessionReqPayload(b band.Band, mcGroupID uint8, start time.Time, duration time.Duration, frequency, dr int) (McClassCSessionReqPayload, error) {
+	if mcGroupID > 3 {
+		return McClassCSessionReqPayload{}, errors.New("lorawan/applayer/multicastsetup: max value of McGroupID is 3")
+	}
+	if err := ValidateDownlink(b, frequency, dr); err != nil {
+		return McClassCSessionReqPayload{}, err
+	}
+
+	timeOut, err := GetClassCSessionTimeOut(duration)
+	if err != nil {
+		return McClassCSessionReqPayload{}, err
+	}
+
+	return McClassCSessionReqPayload{
+		McGroupIDHeader: McClassCSessionReqPayloadMcGroupIDHeader{
+			McGroupID: mcGroupID,
+		},
+		SessionTime: GetSessionTime(start),
+		SessionTimeOut: McClassCSessionReqPayloadSessionTimeOut{
+			TimeOut: timeOut,
+		},
+		DLFrequency: uint32(frequency),
+		DR:          uint8(dr),
+	}, nil
+}
+
+// NewMcClassBSessionReqPayload returns a new McClassBSessionReq payload for
+// a Class-B session lasting at least the given duration. As a Class-B
+// session starts at a beacon period, the SessionTime is rounded up to the
+// next beacon period when the start time is not aligned.
+func NewMcClassBSessionReqPayload(b band.Band, mcGroupID uint8, start time.Time, duration, pingSlotPeriod time.Duration, frequency, dr int) (McClassBSessionReqPayload, error) {
+	if mcGroupID > 3 {
+		return McClassBSessionReqPayload{}, errors.New("lorawan/applayer/multicastsetup: max value of McGroupID is 3")
+	}
+	if err := ValidateDownlink(b, frequency, dr); err != nil {
+		return McClassBSessionReqPayload{}, err
+	}
+
+	timeOut, err := GetClassBSessionTimeOut(duration)
+	if err != nil {
+		return McClassBSessionReqPayload{}, err
+	}
+
+	periodicity, err := GetClassBPeriodicity(pingSlotPeriod)
+	if err != nil {
+		return McClassBSessionReqPayload{}, err
+	}
+
+	sessionTime := GetSessionTime(start)
+	beaconPeriod := uint32(band.BeaconPeriod / time.Second)
+	if rem := sessionTime % beaconPeriod; rem != 0 {
+		sessionTime += beaconPeriod - rem
+	}
+
+	return McClassBSessionReqPayload{
+		McGroupIDHeader: McClassBSessionReqPayloadMcGroupIDHeader{
+			McGroupID: mcGroupID,
+		},
+		SessionTime: sessionTime,
+		TimeOutPeriodicity: McClassBSessionReqPayloadTimeOutPeriodicity{
+			Periodicity: periodicity,
+			TimeOut:     timeOut,
+		},
+		DLFrequency: uint32(frequency),
+		DR:          uint8(dr),
+	}, nil
+}
+
+func getTimeOut(duration, unit time.Duration) (uint8, error) {
+	for i := uint8(0); i <= 15; i++ {
+		if time.Duration(1<<i)*unit >= duration {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("lorawan/applayer/multicastsetup: session duration exceeds max timeout: %s", duration)
+}
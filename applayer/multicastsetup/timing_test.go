@@ -0,0 +1,135 @@
+package multicastsetup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
+)
+
+func TestSessionTimeOut(t *testing.T) {
+	assert := require.New(t)
+
+	tests := []struct {
+		Duration time.Duration
+		ClassC   uint8
+		ClassB   uint8
+	}{
+		{time.Second, 0, 0},
+		{time.Minute, 6, 0},
+		{time.Hour, 12, 5},
+		{24 * time.Hour, 17, 10},
+	}
+
+	for _, tst := range tests {
+		b, err := GetClassBSessionTimeOut(tst.Duration)
+		assert.NoError(err)
+		assert.Equal(tst.ClassB, b)
+
+		c, err := GetClassCSessionTimeOut(tst.Duration)
+		if tst.ClassC > 15 {
+			assert.Error(err)
+			continue
+		}
+		assert.NoError(err)
+		assert.Equal(tst.ClassC, c)
+	}
+
+	p, err := GetClassBPeriodicity(32 * time.Second)
+	assert.NoError(err)
+	assert.Equal(uint8(5), p)
+
+	_, err = GetClassBPeriodicity(3 * time.Second)
+	assert.Error(err)
+}
+
+func TestValidateDownlink(t *testing.T) {
+	eu868, err := band.GetConfig(band.EU868, false, lorawan.DwellTimeNoLimit)
+	require.NoError(t, err)
+	us915, err := band.GetConfig(band.US915, false, lorawan.DwellTimeNoLimit)
+	require.NoError(t, err)
+
+	tests := []struct {
+		Name      string
+		Band      band.Band
+		Frequency int
+		DR        int
+		Error     bool
+	}{
+		{"EU868 RX2", eu868, 869525000, 0, false},
+		{"EU868 default channel", eu868, 868100000, 5, false},
+		{"EU868 invalid frequency", eu868, 868300050, 5, true},
+		{"EU868 unknown frequency", eu868, 867100000, 5, true},
+		{"EU868 invalid DR", eu868, 869525000, 15, true},
+		{"US915 downlink channel", us915, 923300000, 8, false},
+		{"US915 uplink only DR", us915, 923300000, 4, true},
+		{"US915 uplink frequency", us915, 902300000, 8, true},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			err := ValidateDownlink(tst.Band, tst.Frequency, tst.DR)
+			if tst.Error {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewMcClassSessionReqPayload(t *testing.T) {
+	assert := require.New(t)
+
+	b, err := band.GetConfig(band.EU868, false, lorawan.DwellTimeNoLimit)
+	assert.NoError(err)
+
+	gpsEpoch := time.Date(1980, time.January, 6, 0, 0, 0, 0, time.UTC)
+	start := gpsEpoch.Add(1000 * time.Second)
+
+	t.Run("Class-C", func(t *testing.T) {
+		assert := require.New(t)
+
+		_, err := NewMcClassCSessionReqPayload(b, 4, start, time.Hour, 869525000, 0)
+		assert.Error(err)
+
+		pl, err := NewMcClassCSessionReqPayload(b, 2, start, time.Hour, 869525000, 0)
+		assert.NoError(err)
+		assert.Equal(McClassCSessionReqPayload{
+			McGroupIDHeader: McClassCSessionReqPayloadMcGroupIDHeader{
+				McGroupID: 2,
+			},
+			SessionTime: 1000,
+			SessionTimeOut: McClassCSessionReqPayloadSessionTimeOut{
+				TimeOut: 12,
+			},
+			DLFrequency: 869525000,
+			DR:          0,
+		}, pl)
+	})
+
+	t.Run("Class-B", func(t *testing.T) {
+		assert := require.New(t)
+
+		_, err := NewMcClassBSessionReqPayload(b, 1, start, time.Hour, 3*time.Second, 869525000, 3)
+		assert.Error(err)
+
+		pl, err := NewMcClassBSessionReqPayload(b, 1, start, time.Hour, 16*time.Second, 869525000, 3)
+		assert.NoError(err)
+		assert.Equal(McClassBSessionReqPayload{
+			McGroupIDHeader: McClassBSessionReqPayloadMcGroupIDHeader{
+				McGroupID: 1,
+			},
+			SessionTime: 1024,
+			TimeOutPeriodicity: McClassBSessionReqPayloadTimeOutPeriodicity{
+				Periodicity: 4,
+				TimeOut:     5,
+			},
+			DLFrequency: 869525000,
+			DR:          3,
+		}, pl)
+	})
+}
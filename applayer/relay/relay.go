@@ -0,0 +1,223 @@
+// Package relay implements the relay configuration payloads of the LoRaWAN
+// Relay Specification (TS011), so that the relay network configuration and
+// the list of trusted end-devices can be serialized, e.g. for provisioning a
+// relay through an application-layer channel.
+package relay
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/brocaar/lorawan"
+)
+
+// MaxTrustedDevices defines the max. number of trusted end-devices that can
+// be stored by a relay.
+const MaxTrustedDevices = 16
+
+// ChannelSettings implements the relay ChannelSettingsRelay field.
+type ChannelSettings struct {
+	StartStop         bool
+	CADPeriodicity    uint8
+	DefaultChIdx      uint8
+	SecondChIdx       uint8
+	SecondChDR        uint8
+	SecondChAckOffset uint8
+}
+
+// ConfigurationPayload implements the relay network configuration payload.
+type ConfigurationPayload struct {
+	ChannelSettings ChannelSettings
+	SecondChFreq    uint32 // Hz, must be a multiple of 100
+}
+
+// Size returns the payload size in number of bytes.
+func (p ConfigurationPayload) Size() int {
+	return 5
+}
+
+// MarshalBinary encodes the payload to a slice of bytes.
+func (p ConfigurationPayload) MarshalBinary() ([]byte, error) {
+	s := p.ChannelSettings
+	if s.CADPeriodicity > 7 {
+		return nil, errors.New("lorawan/applayer/relay: max value of CADPeriodicity is 7")
+	}
+	if s.DefaultChIdx > 1 {
+		return nil, errors.New("lorawan/applayer/relay: max value of DefaultChIdx is 1")
+	}
+	if s.SecondChIdx > 3 {
+		return nil, errors.New("lorawan/applayer/relay: max value of SecondChIdx is 3")
+	}
+	if s.SecondChDR > 15 {
+		return nil, errors.New("lorawan/applayer/relay: max value of SecondChDR is 15")
+	}
+	if s.SecondChAckOffset > 7 {
+		return nil, errors.New("lorawan/applayer/relay: max value of SecondChAckOffset is 7")
+	}
+	if p.SecondChFreq%100 != 0 {
+		return nil, errors.New("lorawan/applayer/relay: SecondChFreq must be a multiple of 100")
+	}
+	if p.SecondChFreq/100 >= 1<<24 {
+		return nil, errors.New("lorawan/applayer/relay: max value of SecondChFreq is 2^24-1 * 100")
+	}
+
+	var settings uint16
+	settings |= uint16(s.SecondChAckOffset)
+	settings |= uint16(s.SecondChDR) << 3
+	settings |= uint16(s.SecondChIdx) << 7
+	settings |= uint16(s.DefaultChIdx) << 9
+	settings |= uint16(s.CADPeriodicity) << 10
+	if s.StartStop {
+		settings |= 1 << 13
+	}
+
+	b := make([]byte, 6)
+	binary.LittleEndian.PutUint16(b[0:2], settings)
+	binary.LittleEndian.PutUint32(b[2:6], p.SecondChFreq/100)
+
+	return b[:p.Size()], nil
+}
+
+// UnmarshalBinary decodes the payload from a slice of bytes.
+func (p *ConfigurationPayload) UnmarshalBinary(data []byte) error {
+	if len(data) != p.Size() {
+		return fmt.Errorf("lorawan/applayer/relay: %d bytes are expected", p.Size())
+	}
+
+	settings := binary.LittleEndian.Uint16(data[0:2])
+	p.ChannelSettings = ChannelSettings{
+		SecondChAckOffset: uint8(settings & 0x07),
+		SecondChDR:        uint8((settings >> 3) & 0x0f),
+		SecondChIdx:       uint8((settings >> 7) & 0x03),
+		DefaultChIdx:      uint8((settings >> 9) & 0x01),
+		CADPeriodicity:    uint8((settings >> 10) & 0x07),
+		StartStop:         settings&(1<<13) != 0,
+	}
+
+	freq := make([]byte, 4)
+	copy(freq, data[2:5])
+	p.SecondChFreq = binary.LittleEndian.Uint32(freq) * 100
+
+	return nil
+}
+
+// UplinkLimit implements the uplink rate limit of a trusted end-device.
+type UplinkLimit struct {
+	BucketSize uint8
+	ReloadRate uint8
+}
+
+// TrustedDevice implements a trusted end-device entry of the relay, as
+// carried by the UpdateUplinkListReq.
+type TrustedDevice struct {
+	UplinkListIdx uint8
+	UplinkLimit   UplinkLimit
+	DevAddr       lorawan.DevAddr
+	WFCnt         uint32
+	RootWorSKey   lorawan.AES128Key
+}
+
+// Size returns the entry size in number of bytes.
+func (d TrustedDevice) Size() int {
+	return 26
+}
+
+// MarshalBinary encodes the entry to a slice of bytes.
+func (d TrustedDevice) MarshalBinary() ([]byte, error) {
+	if d.UplinkListIdx >= MaxTrustedDevices {
+		return nil, fmt.Errorf("lorawan/applayer/relay: max value of UplinkListIdx is %d", MaxTrustedDevices-1)
+	}
+	if d.UplinkLimit.BucketSize > 3 {
+		return nil, errors.New("lorawan/applayer/relay: max value of BucketSize is 3")
+	}
+	if d.UplinkLimit.ReloadRate > 63 {
+		return nil, errors.New("lorawan/applayer/relay: max value of ReloadRate is 63")
+	}
+
+	b := make([]byte, d.Size())
+	b[0] = d.UplinkListIdx
+	b[1] = d.UplinkLimit.BucketSize<<6 | d.UplinkLimit.ReloadRate
+
+	devAddr, err := d.DevAddr.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	copy(b[2:6], devAddr)
+
+	binary.LittleEndian.PutUint32(b[6:10], d.WFCnt)
+	copy(b[10:26], d.RootWorSKey[:])
+
+	return b, nil
+}
+
+// UnmarshalBinary decodes the entry from a slice of bytes.
+func (d *TrustedDevice) UnmarshalBinary(data []byte) error {
+	if len(data) != d.Size() {
+		return fmt.Errorf("lorawan/applayer/relay: %d bytes are expected", d.Size())
+	}
+
+	d.UplinkListIdx = data[0] & 0x0f
+	d.UplinkLimit = UplinkLimit{
+		BucketSize: data[1] >> 6,
+		ReloadRate: data[1] & 0x3f,
+	}
+	if err := d.DevAddr.UnmarshalBinary(data[2:6]); err != nil {
+		return err
+	}
+	d.WFCnt = binary.LittleEndian.Uint32(data[6:10])
+	copy(d.RootWorSKey[:], data[10:26])
+
+	return nil
+}
+
+// TrustedDeviceList defines the list of trusted end-devices of a relay.
+type TrustedDeviceList []TrustedDevice
+
+// MarshalBinary encodes the list to a slice of bytes.
+func (l TrustedDeviceList) MarshalBinary() ([]byte, error) {
+	if len(l) > MaxTrustedDevices {
+		return nil, fmt.Errorf("lorawan/applayer/relay: max number of trusted devices is %d", MaxTrustedDevices)
+	}
+
+	seen := make(map[uint8]struct{})
+	var out []byte
+
+	for _, d := range l {
+		if _, ok := seen[d.UplinkListIdx]; ok {
+			return nil, fmt.Errorf("lorawan/applayer/relay: duplicate UplinkListIdx %d", d.UplinkListIdx)
+		}
+		seen[d.UplinkListIdx] = struct{}{}
+
+		b, err := d.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b...)
+	}
+
+	return out, nil
+}
+
+// UnmarshalBinary decodes the list from a slice of bytes.
+func (l *TrustedDeviceList) UnmarshalBinary(data []byte) error {
+	size := TrustedDevice{}.Size()
+
+	if len(data)%size != 0 {
+		return fmt.Errorf("lorawan/applayer/relay: length must be a multiple of %d bytes", size)
+	}
+	if len(data)/size > MaxTrustedDevices {
+		return fmt.Errorf("lorawan/applayer/relay: max number of trusted devices is %d", MaxTrustedDevices)
+	}
+
+	*l = nil
+	for i := 0; i < len(data); i += size {
+		var d TrustedDevice
+		if err := d.UnmarshalBinary(data[i : i+size]); err != nil {
+			return err
+		}
+		*l = append(*l, d)
+	}
+
+	return nil
+}
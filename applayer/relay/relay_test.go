@@ -0,0 +1,118 @@
+package relay
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/brocaar/lorawan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigurationPayload(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Payload       ConfigurationPayload
+		Bytes         []byte
+		ExpectedError error
+	}{
+		{
+			Name: "all fields set",
+			Payload: ConfigurationPayload{
+				ChannelSettings: ChannelSettings{
+					StartStop:         true,
+					CADPeriodicity:    5,
+					DefaultChIdx:      1,
+					SecondChIdx:       2,
+					SecondChDR:        9,
+					SecondChAckOffset: 3,
+				},
+				SecondChFreq: 868100000,
+			},
+			// 0b0011_0111_0100_1011
+			Bytes: []byte{0x4b, 0x37, 0x28, 0x76, 0x84},
+		},
+		{
+			Name: "invalid CADPeriodicity",
+			Payload: ConfigurationPayload{
+				ChannelSettings: ChannelSettings{
+					CADPeriodicity: 8,
+				},
+			},
+			ExpectedError: errors.New("lorawan/applayer/relay: max value of CADPeriodicity is 7"),
+		},
+		{
+			Name: "invalid SecondChFreq",
+			Payload: ConfigurationPayload{
+				SecondChFreq: 868100001,
+			},
+			ExpectedError: errors.New("lorawan/applayer/relay: SecondChFreq must be a multiple of 100"),
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			b, err := tst.Payload.MarshalBinary()
+			if tst.ExpectedError != nil {
+				assert.Equal(tst.ExpectedError, err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tst.Bytes, b)
+
+			var pl ConfigurationPayload
+			assert.NoError(pl.UnmarshalBinary(b))
+			assert.Equal(tst.Payload, pl)
+		})
+	}
+}
+
+func TestTrustedDeviceList(t *testing.T) {
+	assert := require.New(t)
+
+	list := TrustedDeviceList{
+		{
+			UplinkListIdx: 0,
+			UplinkLimit:   UplinkLimit{BucketSize: 2, ReloadRate: 10},
+			DevAddr:       lorawan.DevAddr{0x01, 0x02, 0x03, 0x04},
+			WFCnt:         258,
+			RootWorSKey:   lorawan.AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		},
+		{
+			UplinkListIdx: 15,
+			DevAddr:       lorawan.DevAddr{0x05, 0x06, 0x07, 0x08},
+		},
+	}
+
+	b, err := list.MarshalBinary()
+	assert.NoError(err)
+	assert.Len(b, 52)
+	assert.Equal([]byte{
+		0x00, 0x8a, 0x04, 0x03, 0x02, 0x01, 0x02, 0x01, 0x00, 0x00,
+		1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16,
+	}, b[:26])
+
+	var out TrustedDeviceList
+	assert.NoError(out.UnmarshalBinary(b))
+	assert.Equal(list, out)
+
+	t.Run("duplicate index", func(t *testing.T) {
+		assert := require.New(t)
+		_, err := TrustedDeviceList{{UplinkListIdx: 1}, {UplinkListIdx: 1}}.MarshalBinary()
+		assert.Equal(errors.New("lorawan/applayer/relay: duplicate UplinkListIdx 1"), err)
+	})
+
+	t.Run("too many devices", func(t *testing.T) {
+		assert := require.New(t)
+		var out TrustedDeviceList
+		err := out.UnmarshalBinary(make([]byte, 17*26))
+		assert.Equal(errors.New("lorawan/applayer/relay: max number of trusted devices is 16"), err)
+	})
+
+	t.Run("invalid length", func(t *testing.T) {
+		assert := require.New(t)
+		var out TrustedDeviceList
+		assert.Error(out.UnmarshalBinary(make([]byte, 25)))
+	})
+}
@@ -0,0 +1,310 @@
+// Package stream implements a simple reliable transport for moving
+// payloads larger than a single frame between an application-server and a
+// device, in both directions.
+//
+// A message is split into data segments which are acknowledged by the
+// receiver using cumulative acks. The sender keeps at most WindowSize
+// unacknowledged segments in flight and retransmits the unacknowledged
+// segments (go-back-N) after the RetransmitTimeout.
+//
+// Each segment is encoded as:
+//
+//	<Header (1 byte)><Seq (2 bytes, LSB first)>[<Payload>]
+//
+// where the header contains the Ack flag (bit 7), the Last flag (bit 6) and
+// the MsgID (bits 0-3). For data segments, Seq contains the segment
+// sequence number. For acks, Seq contains the next expected sequence
+// number.
+package stream
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/brocaar/lorawan/applayer/fragmentation"
+)
+
+// Errors
+var (
+	ErrBusy = errors.New("lorawan/applayer/stream: a message is already being sent")
+)
+
+// Segment implements a stream segment.
+type Segment struct {
+	Ack     bool
+	Last    bool
+	MsgID   uint8
+	Seq     uint16
+	Payload []byte
+}
+
+// MarshalBinary encodes the segment to a slice of bytes.
+func (s Segment) MarshalBinary() ([]byte, error) {
+	if s.MsgID > 15 {
+		return nil, errors.New("lorawan/applayer/stream: max value of MsgID is 15")
+	}
+	if s.Ack && len(s.Payload) != 0 {
+		return nil, errors.New("lorawan/applayer/stream: ack must not contain payload")
+	}
+
+	b := make([]byte, 3+len(s.Payload))
+	b[0] = s.MsgID
+	if s.Ack {
+		b[0] |= 0x80
+	}
+	if s.Last {
+		b[0] |= 0x40
+	}
+	binary.LittleEndian.PutUint16(b[1:3], s.Seq)
+	copy(b[3:], s.Payload)
+
+	return b, nil
+}
+
+// UnmarshalBinary decodes the segment from a slice of bytes.
+func (s *Segment) UnmarshalBinary(data []byte) error {
+	if len(data) < 3 {
+		return errors.New("lorawan/applayer/stream: at least 3 bytes are expected")
+	}
+
+	s.Ack = data[0]&0x80 != 0
+	s.Last = data[0]&0x40 != 0
+	s.MsgID = data[0] & 0x0f
+	s.Seq = binary.LittleEndian.Uint16(data[1:3])
+	s.Payload = nil
+	if len(data) > 3 {
+		s.Payload = make([]byte, len(data)-3)
+		copy(s.Payload, data[3:])
+	}
+
+	return nil
+}
+
+// Config defines the stream configuration.
+type Config struct {
+	// SegmentSize defines the max. number of payload bytes per segment.
+	// The encoded segment is 3 bytes larger.
+	SegmentSize int
+
+	// WindowSize defines the max. number of unacknowledged segments.
+	WindowSize int
+
+	// RetransmitTimeout defines the time after which unacknowledged
+	// segments are retransmitted.
+	RetransmitTimeout time.Duration
+}
+
+type sender struct {
+	msgID    uint8
+	segments [][]byte
+	base     int // first unacknowledged segment
+	next     int // next segment to send
+	sentAt   time.Time
+}
+
+type receiver struct {
+	active   bool
+	msgID    uint8
+	expected uint16
+	data     []byte
+}
+
+// Conn implements one end of a stream connection, e.g. the application
+// server side for a single device, or the device side. It is not safe for
+// concurrent use.
+type Conn struct {
+	config Config
+
+	nextMsgID uint8
+	sender    *sender
+	receiver  receiver
+
+	// pending ack, sent with the next Poll
+	ack *Segment
+
+	// last completed incoming message, to re-ack retransmissions
+	completed    bool
+	completedID  uint8
+	completedSeq uint16
+}
+
+// NewConn returns a new Conn.
+func NewConn(config Config) (*Conn, error) {
+	if config.SegmentSize <= 0 {
+		return nil, fmt.Errorf("lorawan/applayer/stream: invalid segment-size: %d", config.SegmentSize)
+	}
+	if config.WindowSize <= 0 {
+		return nil, fmt.Errorf("lorawan/applayer/stream: invalid window-size: %d", config.WindowSize)
+	}
+
+	return &Conn{
+		config: config,
+	}, nil
+}
+
+// Send starts sending the given message. The segments are returned by
+// Poll. It returns ErrBusy when the previous message has not yet been
+// acknowledged completely.
+func (c *Conn) Send(data []byte) error {
+	if c.sender != nil {
+		return ErrBusy
+	}
+	if len(data) == 0 {
+		return errors.New("lorawan/applayer/stream: data must not be empty")
+	}
+	if (len(data)+c.config.SegmentSize-1)/c.config.SegmentSize > 0xffff {
+		return errors.New("lorawan/applayer/stream: too many segments")
+	}
+
+	// the fragmentation encoder expects a multiple of the segment-size,
+	// the padding is removed again from the last segment
+	padding := (c.config.SegmentSize - len(data)%c.config.SegmentSize) % c.config.SegmentSize
+	padded := make([]byte, len(data)+padding)
+	copy(padded, data)
+
+	segments, err := fragmentation.Encode(padded, c.config.SegmentSize, 0)
+	if err != nil {
+		return fmt.Errorf("lorawan/applayer/stream: encode segments error: %w", err)
+	}
+	last := len(segments) - 1
+	segments[last] = segments[last][:c.config.SegmentSize-padding]
+
+	s := sender{
+		msgID:    c.nextMsgID,
+		segments: segments,
+	}
+
+	c.sender = &s
+	c.nextMsgID = (c.nextMsgID + 1) & 0x0f
+
+	return nil
+}
+
+// Sending returns true when a message is being sent.
+func (c *Conn) Sending() bool {
+	return c.sender != nil
+}
+
+// Poll returns the encoded segments which must be transmitted, given the
+// current time. This includes pending acks, new data segments within the
+// window and retransmissions.
+func (c *Conn) Poll(now time.Time) ([][]byte, error) {
+	var segments []Segment
+
+	if c.ack != nil {
+		segments = append(segments, *c.ack)
+		c.ack = nil
+	}
+
+	if s := c.sender; s != nil {
+		// retransmit all unacknowledged segments on timeout
+		if s.next > s.base && now.Sub(s.sentAt) >= c.config.RetransmitTimeout {
+			s.next = s.base
+		}
+
+		for s.next < len(s.segments) && s.next-s.base < c.config.WindowSize {
+			segments = append(segments, Segment{
+				MsgID:   s.msgID,
+				Seq:     uint16(s.next),
+				Last:    s.next == len(s.segments)-1,
+				Payload: s.segments[s.next],
+			})
+			s.next++
+			s.sentAt = now
+		}
+	}
+
+	var out [][]byte
+	for _, seg := range segments {
+		b, err := seg.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+
+	return out, nil
+}
+
+// Handle handles the given received segment. When the segment completes an
+// incoming message, the message is returned.
+func (c *Conn) Handle(b []byte) ([]byte, error) {
+	var seg Segment
+	if err := seg.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+
+	if seg.Ack {
+		c.handleAck(seg)
+		return nil, nil
+	}
+
+	return c.handleData(seg), nil
+}
+
+func (c *Conn) handleAck(seg Segment) {
+	s := c.sender
+	if s == nil || seg.MsgID != s.msgID {
+		return
+	}
+
+	// cumulative ack: all segments before seg.Seq have been received
+	if int(seg.Seq) > s.base && int(seg.Seq) <= len(s.segments) {
+		s.base = int(seg.Seq)
+		if s.next < s.base {
+			s.next = s.base
+		}
+	}
+
+	if s.base == len(s.segments) {
+		c.sender = nil
+	}
+}
+
+func (c *Conn) handleData(seg Segment) []byte {
+	r := &c.receiver
+
+	// retransmission of the last completed message
+	if !r.active && c.completed && seg.MsgID == c.completedID {
+		c.setAck(seg.MsgID, c.completedSeq)
+		return nil
+	}
+
+	// a new message, only the first segment can start a message
+	if !r.active || seg.MsgID != r.msgID {
+		if seg.Seq != 0 {
+			return nil
+		}
+		*r = receiver{
+			active: true,
+			msgID:  seg.MsgID,
+		}
+	}
+
+	if seg.Seq == r.expected {
+		r.data = append(r.data, seg.Payload...)
+		r.expected++
+	}
+	c.setAck(r.msgID, r.expected)
+
+	if seg.Last && seg.Seq+1 == r.expected {
+		data := r.data
+		c.completed = true
+		c.completedID = r.msgID
+		c.completedSeq = r.expected
+		*r = receiver{}
+		return data
+	}
+
+	return nil
+}
+
+func (c *Conn) setAck(msgID uint8, seq uint16) {
+	c.ack = &Segment{
+		Ack:   true,
+		MsgID: msgID,
+		Seq:   seq,
+	}
+}
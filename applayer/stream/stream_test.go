@@ -0,0 +1,179 @@
+package stream
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSegment(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Segment Segment
+		Bytes   []byte
+	}{
+		{
+			Name: "data",
+			Segment: Segment{
+				MsgID:   3,
+				Seq:     0x0201,
+				Payload: []byte{0x01, 0x02, 0x03},
+			},
+			Bytes: []byte{0x03, 0x01, 0x02, 0x01, 0x02, 0x03},
+		},
+		{
+			Name: "last data",
+			Segment: Segment{
+				Last:    true,
+				MsgID:   15,
+				Seq:     5,
+				Payload: []byte{0x01},
+			},
+			Bytes: []byte{0x4f, 0x05, 0x00, 0x01},
+		},
+		{
+			Name: "ack",
+			Segment: Segment{
+				Ack:   true,
+				MsgID: 1,
+				Seq:   6,
+			},
+			Bytes: []byte{0x81, 0x06, 0x00},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			b, err := tst.Segment.MarshalBinary()
+			assert.NoError(err)
+			assert.Equal(tst.Bytes, b)
+
+			var seg Segment
+			assert.NoError(seg.UnmarshalBinary(b))
+			assert.Equal(tst.Segment, seg)
+		})
+	}
+}
+
+// transfer exchanges frames between a and b until both are idle, dropping
+// the frames for which drop returns true.
+func transfer(t *testing.T, a, b *Conn, drop func(n int) bool) (received [][]byte) {
+	assert := require.New(t)
+
+	now := time.Now()
+	n := 0
+
+	for i := 0; i < 1000; i++ {
+		idle := true
+
+		for _, pair := range [][2]*Conn{{a, b}, {b, a}} {
+			frames, err := pair[0].Poll(now)
+			assert.NoError(err)
+
+			for _, f := range frames {
+				idle = false
+				n++
+				if drop(n) {
+					continue
+				}
+
+				data, err := pair[1].Handle(f)
+				assert.NoError(err)
+				if data != nil {
+					received = append(received, data)
+				}
+			}
+		}
+
+		if idle && !a.Sending() && !b.Sending() {
+			return received
+		}
+
+		now = now.Add(time.Second)
+	}
+
+	t.Fatal("transfer did not complete")
+	return nil
+}
+
+func TestConn(t *testing.T) {
+	config := Config{
+		SegmentSize:       10,
+		WindowSize:        4,
+		RetransmitTimeout: 3 * time.Second,
+	}
+
+	data := make([]byte, 95)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	tests := []struct {
+		Name string
+		Drop func(n int) bool
+	}{
+		{
+			Name: "no loss",
+			Drop: func(int) bool { return false },
+		},
+		{
+			Name: "30% frame loss",
+			Drop: func() func(int) bool {
+				r := rand.New(rand.NewSource(1))
+				return func(int) bool { return r.Intn(10) < 3 }
+			}(),
+		},
+		{
+			Name: "first frames lost",
+			Drop: func(n int) bool { return n < 6 },
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			server, err := NewConn(config)
+			assert.NoError(err)
+			device, err := NewConn(config)
+			assert.NoError(err)
+
+			assert.NoError(server.Send(data))
+			assert.Equal(ErrBusy, server.Send(data))
+			assert.NoError(device.Send([]byte{0x01, 0x02, 0x03}))
+
+			received := transfer(t, server, device, tst.Drop)
+			assert.Equal([][]byte{{0x01, 0x02, 0x03}, data}, sortBySize(received))
+
+			// next message
+			assert.NoError(server.Send([]byte{0x04}))
+			received = transfer(t, server, device, tst.Drop)
+			assert.Equal([][]byte{{0x04}}, received)
+		})
+	}
+}
+
+func TestNewConn(t *testing.T) {
+	assert := require.New(t)
+
+	_, err := NewConn(Config{WindowSize: 1})
+	assert.Error(err)
+
+	_, err = NewConn(Config{SegmentSize: 1})
+	assert.Error(err)
+
+	c, err := NewConn(Config{SegmentSize: 1, WindowSize: 1})
+	assert.NoError(err)
+	assert.Error(c.Send(nil))
+}
+
+func sortBySize(in [][]byte) [][]byte {
+	if len(in) == 2 && len(in[0]) > len(in[1]) {
+		return [][]byte{in[1], in[0]}
+	}
+	return in
+}
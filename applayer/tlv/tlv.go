@@ -0,0 +1,362 @@
+// Package tlv implements a generic tag-length-value codec, as used by many
+// vendor specific application-layer protocols.
+//
+// Each item is encoded as:
+//
+//	<Tag (1 byte)><Length><Value>
+//
+// where the length is encoded either as a single byte (max. 255) or as an
+// unsigned varint (LEB128), depending on the LengthEncoding of the Codec.
+//
+// Structs can be mapped to items using the tlv struct-tag, e.g.:
+//
+//	type Status struct {
+//		Battery     uint8   `tlv:"1"`
+//		Temperature int16   `tlv:"2"`
+//		Name        string  `tlv:"3,omitempty"`
+//		Uptime      *uint32 `tlv:"4"`
+//	}
+//
+// Supported field types are bool, (unsigned) integers (fixed size, LSB
+// first), string, []byte, byte arrays and types implementing
+// encoding.BinaryMarshaler / encoding.BinaryUnmarshaler. Pointer fields are
+// omitted when nil. Fields with the omitempty option are omitted when they
+// contain the zero value. Unknown tags are ignored on decoding. Tagging an
+// unexported field returns an error.
+package tlv
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// LengthEncoding defines how the item length is encoded.
+type LengthEncoding int
+
+// Available length encodings.
+const (
+	LengthByte LengthEncoding = iota
+	LengthVarint
+)
+
+// Item defines a single TLV item.
+type Item struct {
+	Tag   uint8
+	Value []byte
+}
+
+// Codec implements the TLV codec.
+type Codec struct {
+	Length LengthEncoding
+}
+
+// EncodeItems encodes the given items to a slice of bytes.
+func (c Codec) EncodeItems(items []Item) ([]byte, error) {
+	var out []byte
+
+	for _, item := range items {
+		out = append(out, item.Tag)
+
+		switch c.Length {
+		case LengthByte:
+			if len(item.Value) > 255 {
+				return nil, fmt.Errorf("lorawan/applayer/tlv: value of tag %d exceeds 255 bytes", item.Tag)
+			}
+			out = append(out, uint8(len(item.Value)))
+		case LengthVarint:
+			b := make([]byte, binary.MaxVarintLen64)
+			out = append(out, b[:binary.PutUvarint(b, uint64(len(item.Value)))]...)
+		default:
+			return nil, fmt.Errorf("lorawan/applayer/tlv: invalid length encoding: %d", c.Length)
+		}
+
+		out = append(out, item.Value...)
+	}
+
+	return out, nil
+}
+
+// DecodeItems decodes the items from the given slice of bytes.
+func (c Codec) DecodeItems(data []byte) ([]Item, error) {
+	var items []Item
+
+	for len(data) > 0 {
+		tag := data[0]
+		data = data[1:]
+
+		var length uint64
+		switch c.Length {
+		case LengthByte:
+			if len(data) < 1 {
+				return nil, fmt.Errorf("lorawan/applayer/tlv: missing length of tag %d", tag)
+			}
+			length = uint64(data[0])
+			data = data[1:]
+		case LengthVarint:
+			var n int
+			length, n = binary.Uvarint(data)
+			if n <= 0 {
+				return nil, fmt.Errorf("lorawan/applayer/tlv: invalid length of tag %d", tag)
+			}
+			data = data[n:]
+		default:
+			return nil, fmt.Errorf("lorawan/applayer/tlv: invalid length encoding: %d", c.Length)
+		}
+
+		if uint64(len(data)) < length {
+			return nil, fmt.Errorf("lorawan/applayer/tlv: value of tag %d exceeds data", tag)
+		}
+
+		items = append(items, Item{
+			Tag:   tag,
+			Value: append([]byte(nil), data[:length]...),
+		})
+		data = data[length:]
+	}
+
+	return items, nil
+}
+
+// Marshal encodes the given struct (or pointer to struct) to a slice of
+// bytes, using the tlv struct-tags.
+func (c Codec) Marshal(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, errors.New("lorawan/applayer/tlv: nil pointer")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, errors.New("lorawan/applayer/tlv: struct expected")
+	}
+
+	fields, err := getFields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	for _, f := range fields {
+		fv := rv.Field(f.index)
+
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		} else if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		b, err := marshalValue(fv)
+		if err != nil {
+			return nil, fmt.Errorf("lorawan/applayer/tlv: marshal tag %d error: %w", f.tag, err)
+		}
+
+		items = append(items, Item{Tag: f.tag, Value: b})
+	}
+
+	return c.EncodeItems(items)
+}
+
+// Unmarshal decodes the given slice of bytes into the struct pointed to by
+// v, using the tlv struct-tags.
+func (c Codec) Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("lorawan/applayer/tlv: non-nil pointer to struct expected")
+	}
+	rv = rv.Elem()
+
+	fields, err := getFields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	byTag := make(map[uint8]field)
+	for _, f := range fields {
+		byTag[f.tag] = f
+	}
+
+	items, err := c.DecodeItems(data)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		f, ok := byTag[item.Tag]
+		if !ok {
+			continue
+		}
+
+		fv := rv.Field(f.index)
+		if fv.Kind() == reflect.Ptr {
+			fv.Set(reflect.New(fv.Type().Elem()))
+			fv = fv.Elem()
+		}
+
+		if err := unmarshalValue(fv, item.Value); err != nil {
+			return fmt.Errorf("lorawan/applayer/tlv: unmarshal tag %d error: %w", item.Tag, err)
+		}
+	}
+
+	return nil
+}
+
+type field struct {
+	index     int
+	tag       uint8
+	omitEmpty bool
+}
+
+func getFields(t reflect.Type) ([]field, error) {
+	var fields []field
+	seen := make(map[uint8]bool)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("tlv")
+		if !ok || tag == "-" {
+			continue
+		}
+		if sf.PkgPath != "" {
+			return nil, fmt.Errorf("lorawan/applayer/tlv: unexported field %s can not be tagged", sf.Name)
+		}
+
+		parts := strings.Split(tag, ",")
+		n, err := strconv.ParseUint(parts[0], 0, 8)
+		if err != nil {
+			return nil, fmt.Errorf("lorawan/applayer/tlv: invalid tag for field %s: %s", sf.Name, tag)
+		}
+		if seen[uint8(n)] {
+			return nil, fmt.Errorf("lorawan/applayer/tlv: duplicate tag %d", n)
+		}
+		seen[uint8(n)] = true
+
+		f := field{
+			index: i,
+			tag:   uint8(n),
+		}
+		for _, opt := range parts[1:] {
+			switch opt {
+			case "omitempty":
+				f.omitEmpty = true
+			default:
+				return nil, fmt.Errorf("lorawan/applayer/tlv: invalid tag option for field %s: %s", sf.Name, opt)
+			}
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields, nil
+}
+
+var (
+	binaryMarshalerType   = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+)
+
+func marshalValue(v reflect.Value) ([]byte, error) {
+	if v.Type().Implements(binaryMarshalerType) {
+		return v.Interface().(encoding.BinaryMarshaler).MarshalBinary()
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return []byte{0x01}, nil
+		}
+		return []byte{0x00}, nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return putUint(v.Uint(), int(v.Type().Size())), nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return putUint(uint64(v.Int()), int(v.Type().Size())), nil
+	case reflect.String:
+		return []byte(v.String()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return append([]byte(nil), v.Bytes()...), nil
+		}
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return b, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported type: %s", v.Type())
+}
+
+func unmarshalValue(v reflect.Value, b []byte) error {
+	if v.CanAddr() && v.Addr().Type().Implements(binaryUnmarshalerType) {
+		return v.Addr().Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(b)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if len(b) != 1 {
+			return fmt.Errorf("1 byte expected, got %d", len(b))
+		}
+		v.SetBool(b[0] != 0)
+		return nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := getUint(b, int(v.Type().Size()))
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+		return nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size := int(v.Type().Size())
+		u, err := getUint(b, size)
+		if err != nil {
+			return err
+		}
+		// sign extend
+		shift := uint(64 - 8*size)
+		v.SetInt(int64(u<<shift) >> shift)
+		return nil
+	case reflect.String:
+		v.SetString(string(b))
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(append([]byte(nil), b...))
+			return nil
+		}
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if len(b) != v.Len() {
+				return fmt.Errorf("%d bytes expected, got %d", v.Len(), len(b))
+			}
+			reflect.Copy(v, reflect.ValueOf(b))
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unsupported type: %s", v.Type())
+}
+
+func putUint(u uint64, size int) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, u)
+	return b[:size]
+}
+
+func getUint(b []byte, size int) (uint64, error) {
+	if len(b) != size {
+		return 0, fmt.Errorf("%d bytes expected, got %d", size, len(b))
+	}
+
+	var buf [8]byte
+	copy(buf[:], b)
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
@@ -0,0 +1,167 @@
+package tlv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestItems(t *testing.T) {
+	long := make([]byte, 300)
+
+	tests := []struct {
+		Name   string
+		Codec  Codec
+		Items  []Item
+		Bytes  []byte
+		Error  bool
+		Length int
+	}{
+		{
+			Name:  "byte length",
+			Codec: Codec{Length: LengthByte},
+			Items: []Item{
+				{Tag: 1, Value: []byte{0x01, 0x02}},
+				{Tag: 2},
+			},
+			Bytes: []byte{0x01, 0x02, 0x01, 0x02, 0x02, 0x00},
+		},
+		{
+			Name:  "varint length",
+			Codec: Codec{Length: LengthVarint},
+			Items: []Item{
+				{Tag: 3, Value: long},
+			},
+			Bytes: append([]byte{0x03, 0xac, 0x02}, long...),
+		},
+		{
+			Name:  "byte length exceeded",
+			Codec: Codec{Length: LengthByte},
+			Items: []Item{
+				{Tag: 3, Value: long},
+			},
+			Error: true,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			b, err := tst.Codec.EncodeItems(tst.Items)
+			if tst.Error {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tst.Bytes, b)
+
+			items, err := tst.Codec.DecodeItems(b)
+			assert.NoError(err)
+			assert.Equal(tst.Items, items)
+		})
+	}
+
+	t.Run("truncated", func(t *testing.T) {
+		assert := require.New(t)
+
+		_, err := Codec{}.DecodeItems([]byte{0x01})
+		assert.Error(err)
+
+		_, err = Codec{}.DecodeItems([]byte{0x01, 0x02, 0x01})
+		assert.Error(err)
+
+		_, err = Codec{Length: LengthVarint}.DecodeItems([]byte{0x01, 0x80})
+		assert.Error(err)
+	})
+}
+
+type testStatus struct {
+	Battery     uint8           `tlv:"1"`
+	Temperature int16           `tlv:"2"`
+	Name        string          `tlv:"3,omitempty"`
+	Uptime      *uint32         `tlv:"4"`
+	Enabled     bool            `tlv:"5"`
+	DevEUI      lorawan.EUI64   `tlv:"6"`
+	Raw         []byte          `tlv:"0x07,omitempty"`
+	Key         [2]byte         `tlv:"8"`
+	Ignored     int             `tlv:"-"`
+	DevAddr     lorawan.DevAddr `tlv:"9,omitempty"`
+}
+
+func TestMarshal(t *testing.T) {
+	assert := require.New(t)
+
+	uptime := uint32(0x01020304)
+	status := testStatus{
+		Battery:     100,
+		Temperature: -2,
+		Uptime:      &uptime,
+		Enabled:     true,
+		DevEUI:      lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		Key:         [2]byte{0xaa, 0xbb},
+		Ignored:     10,
+	}
+
+	b, err := Codec{}.Marshal(&status)
+	assert.NoError(err)
+	assert.Equal([]byte{
+		0x01, 0x01, 0x64,
+		0x02, 0x02, 0xfe, 0xff,
+		0x04, 0x04, 0x04, 0x03, 0x02, 0x01,
+		0x05, 0x01, 0x01,
+		0x06, 0x08, 0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01, // LSB first
+		0x08, 0x02, 0xaa, 0xbb,
+	}, b)
+
+	// unknown tags are ignored
+	b = append(b, 0x20, 0x01, 0x00)
+
+	var out testStatus
+	assert.NoError(Codec{}.Unmarshal(b, &out))
+	status.Ignored = 0
+	assert.Equal(status, out)
+
+	// invalid size
+	assert.Error(Codec{}.Unmarshal([]byte{0x02, 0x01, 0x00}, &out))
+
+	// not a struct
+	_, err = Codec{}.Marshal(10)
+	assert.Error(err)
+	assert.Error(Codec{}.Unmarshal(nil, out))
+}
+
+func TestInvalidStructTag(t *testing.T) {
+	assert := require.New(t)
+
+	_, err := Codec{}.Marshal(struct {
+		A uint8 `tlv:"1"`
+		B uint8 `tlv:"1"`
+	}{})
+	assert.Error(err)
+
+	_, err = Codec{}.Marshal(struct {
+		A uint8 `tlv:"256"`
+	}{})
+	assert.Error(err)
+
+	_, err = Codec{}.Marshal(struct {
+		A uint8 `tlv:"1,required"`
+	}{})
+	assert.Error(err)
+
+	_, err = Codec{}.Marshal(struct {
+		A float32 `tlv:"1"`
+	}{})
+	assert.Error(err)
+
+	type unexported struct {
+		A uint8 `tlv:"1"`
+		b uint8 `tlv:"2"`
+	}
+	_, err = Codec{}.Marshal(unexported{A: 1, b: 2})
+	assert.EqualError(err, "lorawan/applayer/tlv: unexported field b can not be tagged")
+	assert.EqualError(Codec{}.Unmarshal([]byte{0x02, 0x01, 0x02}, &unexported{}), "lorawan/applayer/tlv: unexported field b can not be tagged")
+}
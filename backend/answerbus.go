@@ -0,0 +1,250 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/pkg/errors"
+)
+
+// AnswerBus correlates the async answers to the pending requests. As the
+// answer can be received by any instance of a (clustered) requester, the
+// bus must deliver the published answer to the instance which subscribed
+// to the transaction, e.g. using Redis, NATS or MQTT pub/sub.
+//
+// Implementations must be safe for concurrent use.
+type AnswerBus interface {
+	// Subscribe subscribes to the answer of the given transaction. The
+	// subscription must be active when Subscribe returns, as the answer
+	// might be published before the request has returned.
+	Subscribe(ctx context.Context, transactionID uint32) (AnswerSubscription, error)
+
+	// Publish publishes the answer of the given transaction.
+	Publish(ctx context.Context, transactionID uint32, answer []byte) error
+}
+
+// AnswerSubscription defines the subscription to the answer of a single
+// transaction.
+type AnswerSubscription interface {
+	// Receive waits for the answer. It returns ErrAsyncTimeout after the
+	// given timeout or the context error when the context is done first.
+	Receive(ctx context.Context, timeout time.Duration) ([]byte, error)
+
+	// Close closes the subscription.
+	Close() error
+}
+
+// DefaultRedisKeyPrefix defines the default prefix of the Redis keys used
+// by the Redis AnswerBus.
+const DefaultRedisKeyPrefix = "lora:backend:async:"
+
+// RedisAnswerBusConfig holds the Redis AnswerBus configuration.
+type RedisAnswerBusConfig struct {
+	// KeyPrefix holds the prefix of the Redis keys, e.g. to separate the
+	// tenants of a shared Redis instance. When empty,
+	// DefaultRedisKeyPrefix is used.
+	KeyPrefix string
+
+	// TransactionTTL defines the TTL of the pending transaction records.
+	// When set, a record is stored under the "<KeyPrefix>tx:<TransactionID>"
+	// key for every subscribed transaction, so that operators can inspect
+	// (and expire) the pending transactions. The record is removed when
+	// the subscription is closed. When 0, no records are stored.
+	TransactionTTL time.Duration
+}
+
+// RedisTransactionRecord holds the pending transaction record, stored as
+// JSON by the Redis AnswerBus.
+type RedisTransactionRecord struct {
+	TransactionID uint32    `json:"transactionID"`
+	SubscribedAt  time.Time `json:"subscribedAt"`
+}
+
+type redisAnswerBus struct {
+	client redis.UniversalClient
+	config RedisAnswerBusConfig
+}
+
+// NewRedisAnswerBus returns an AnswerBus using Redis pub/sub. The given
+// client can be a (single node) Redis, Redis Cluster or Redis Sentinel
+// client.
+func NewRedisAnswerBus(client redis.UniversalClient, config RedisAnswerBusConfig) AnswerBus {
+	if config.KeyPrefix == "" {
+		config.KeyPrefix = DefaultRedisKeyPrefix
+	}
+
+	return &redisAnswerBus{
+		client: client,
+		config: config,
+	}
+}
+
+func (b *redisAnswerBus) Subscribe(ctx context.Context, transactionID uint32) (AnswerSubscription, error) {
+	sub := b.client.Subscribe(b.key(transactionID))
+
+	// wait for the subscription confirmation
+	if _, err := sub.Receive(); err != nil {
+		sub.Close()
+		return nil, errors.Wrap(err, "subscribe error")
+	}
+
+	out := redisAnswerSubscription{sub: sub}
+
+	if b.config.TransactionTTL > 0 {
+		bb, err := json.Marshal(RedisTransactionRecord{
+			TransactionID: transactionID,
+			SubscribedAt:  time.Now(),
+		})
+		if err != nil {
+			sub.Close()
+			return nil, errors.Wrap(err, "marshal transaction record error")
+		}
+
+		key := b.transactionKey(transactionID)
+		if err := b.client.Set(key, bb, b.config.TransactionTTL).Err(); err != nil {
+			sub.Close()
+			return nil, errors.Wrap(err, "set transaction record error")
+		}
+
+		out.close = func() error {
+			return b.client.Del(key).Err()
+		}
+	}
+
+	return &out, nil
+}
+
+func (b *redisAnswerBus) Publish(ctx context.Context, transactionID uint32, answer []byte) error {
+	if err := b.client.Publish(b.key(transactionID), answer).Err(); err != nil {
+		return errors.Wrap(err, "publish answer error")
+	}
+	return nil
+}
+
+func (b *redisAnswerBus) key(transactionID uint32) string {
+	return fmt.Sprintf("%s%d", b.config.KeyPrefix, transactionID)
+}
+
+func (b *redisAnswerBus) transactionKey(transactionID uint32) string {
+	return fmt.Sprintf("%stx:%d", b.config.KeyPrefix, transactionID)
+}
+
+type redisAnswerSubscription struct {
+	sub   *redis.PubSub
+	close func() error
+}
+
+func (s *redisAnswerSubscription) Receive(ctx context.Context, timeout time.Duration) ([]byte, error) {
+	ch := s.sub.Channel()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case msg, ok := <-ch:
+		if !ok {
+			return nil, errors.New("subscription closed")
+		}
+		return []byte(msg.Payload), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return nil, ErrAsyncTimeout
+	}
+}
+
+func (s *redisAnswerSubscription) Close() error {
+	err := s.sub.Close()
+	if s.close != nil {
+		if e := s.close(); e != nil && err == nil {
+			err = errors.Wrap(e, "delete transaction record error")
+		}
+	}
+	return err
+}
+
+type memoryAnswerBus struct {
+	mu   sync.Mutex
+	subs map[uint32][]chan []byte
+}
+
+// NewMemoryAnswerBus returns an in-memory AnswerBus. It can only be used
+// when the requests and the async answers are handled by the same process
+// (e.g. a single instance deployment without Redis).
+func NewMemoryAnswerBus() AnswerBus {
+	return &memoryAnswerBus{
+		subs: make(map[uint32][]chan []byte),
+	}
+}
+
+func (b *memoryAnswerBus) Subscribe(ctx context.Context, transactionID uint32) (AnswerSubscription, error) {
+	ch := make(chan []byte, 1)
+
+	b.mu.Lock()
+	b.subs[transactionID] = append(b.subs[transactionID], ch)
+	b.mu.Unlock()
+
+	return &memoryAnswerSubscription{bus: b, transactionID: transactionID, ch: ch}, nil
+}
+
+func (b *memoryAnswerBus) Publish(ctx context.Context, transactionID uint32, answer []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, ch := range b.subs[transactionID] {
+		// like pub/sub, the answer is dropped when it is not consumed
+		select {
+		case ch <- append([]byte(nil), answer...):
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (b *memoryAnswerBus) unsubscribe(transactionID uint32, ch chan []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var subs []chan []byte
+	for _, c := range b.subs[transactionID] {
+		if c != ch {
+			subs = append(subs, c)
+		}
+	}
+
+	if len(subs) == 0 {
+		delete(b.subs, transactionID)
+	} else {
+		b.subs[transactionID] = subs
+	}
+}
+
+type memoryAnswerSubscription struct {
+	bus           *memoryAnswerBus
+	transactionID uint32
+	ch            chan []byte
+}
+
+func (s *memoryAnswerSubscription) Receive(ctx context.Context, timeout time.Duration) ([]byte, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case bb := <-s.ch:
+		return bb, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return nil, ErrAsyncTimeout
+	}
+}
+
+func (s *memoryAnswerSubscription) Close() error {
+	s.bus.unsubscribe(s.transactionID, s.ch)
+	return nil
+}
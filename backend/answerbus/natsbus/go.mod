@@ -0,0 +1,12 @@
+module github.com/brocaar/lorawan/backend/answerbus/natsbus
+
+require (
+	github.com/brocaar/lorawan v0.0.0-00010101000000-000000000000
+	github.com/nats-io/nats.go v1.11.0
+	github.com/pkg/errors v0.9.1
+	github.com/stretchr/testify v1.4.0
+)
+
+replace github.com/brocaar/lorawan => ../../..
+
+go 1.15
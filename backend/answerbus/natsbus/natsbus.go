@@ -0,0 +1,105 @@
+// Package natsbus implements the backend.AnswerBus using NATS pub/sub.
+//
+// This package is a separate Go module, so that the NATS client is not a
+// dependency of the lorawan module.
+package natsbus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lorawan/backend"
+)
+
+// DefaultSubjectPrefix defines the default prefix of the NATS subjects.
+const DefaultSubjectPrefix = "lora.backend.async."
+
+// Config holds the NATS AnswerBus configuration.
+type Config struct {
+	// SubjectPrefix holds the prefix of the subjects, the transaction ID
+	// is appended to the prefix. When empty, DefaultSubjectPrefix is used.
+	SubjectPrefix string
+
+	// FlushTimeout defines the max. duration to wait for the confirmation
+	// of a subscription by the NATS server. When 0, the context of the
+	// Subscribe call must have a deadline.
+	FlushTimeout time.Duration
+}
+
+type answerBus struct {
+	conn   *nats.Conn
+	config Config
+}
+
+// New returns a new AnswerBus using the given NATS connection.
+func New(conn *nats.Conn, config Config) backend.AnswerBus {
+	if config.SubjectPrefix == "" {
+		config.SubjectPrefix = DefaultSubjectPrefix
+	}
+
+	return &answerBus{
+		conn:   conn,
+		config: config,
+	}
+}
+
+func (b *answerBus) Subscribe(ctx context.Context, transactionID uint32) (backend.AnswerSubscription, error) {
+	ch := make(chan *nats.Msg, 1)
+
+	sub, err := b.conn.ChanSubscribe(b.subject(transactionID), ch)
+	if err != nil {
+		return nil, errors.Wrap(err, "subscribe error")
+	}
+
+	// wait for the subscription confirmation, a flush round-trips to the
+	// server after the subscription has been sent
+	if b.config.FlushTimeout > 0 {
+		err = b.conn.FlushTimeout(b.config.FlushTimeout)
+	} else {
+		err = b.conn.FlushWithContext(ctx)
+	}
+	if err != nil {
+		sub.Unsubscribe()
+		return nil, errors.Wrap(err, "flush subscription error")
+	}
+
+	return &answerSubscription{sub: sub, ch: ch}, nil
+}
+
+func (b *answerBus) Publish(ctx context.Context, transactionID uint32, answer []byte) error {
+	if err := b.conn.Publish(b.subject(transactionID), answer); err != nil {
+		return errors.Wrap(err, "publish answer error")
+	}
+	return nil
+}
+
+func (b *answerBus) subject(transactionID uint32) string {
+	return fmt.Sprintf("%s%d", b.config.SubjectPrefix, transactionID)
+}
+
+type answerSubscription struct {
+	sub *nats.Subscription
+	ch  chan *nats.Msg
+}
+
+func (s *answerSubscription) Receive(ctx context.Context, timeout time.Duration) ([]byte, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case msg := <-s.ch:
+		return msg.Data, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return nil, backend.ErrAsyncTimeout
+	}
+}
+
+func (s *answerSubscription) Close() error {
+	return s.sub.Unsubscribe()
+}
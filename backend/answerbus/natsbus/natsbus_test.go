@@ -0,0 +1,209 @@
+package natsbus
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan/backend"
+)
+
+// testServer implements the subset of the NATS client protocol used by the
+// AnswerBus (CONNECT, PING, SUB, UNSUB and PUB), so that the tests do not
+// depend on a running NATS server.
+type testServer struct {
+	ln net.Listener
+
+	mu   sync.Mutex
+	subs map[*testSub]struct{}
+}
+
+type testSub struct {
+	conn    *testConn
+	subject string
+	sid     string
+}
+
+type testConn struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (c *testConn) write(format string, a ...interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(c.w, format, a...)
+}
+
+func newTestServer(t *testing.T) *testServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := testServer{
+		ln:   ln,
+		subs: make(map[*testSub]struct{}),
+	}
+	go s.accept()
+
+	return &s
+}
+
+func (s *testServer) url() string {
+	return "nats://" + s.ln.Addr().String()
+}
+
+func (s *testServer) close() {
+	s.ln.Close()
+}
+
+func (s *testServer) accept() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.serve(conn)
+	}
+}
+
+func (s *testServer) serve(nc net.Conn) {
+	defer nc.Close()
+
+	c := testConn{w: nc}
+	r := bufio.NewReader(nc)
+	sids := make(map[string]*testSub)
+
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, sub := range sids {
+			delete(s.subs, sub)
+		}
+	}()
+
+	c.write("INFO {\"server_id\":\"test\",\"max_payload\":1048576}\r\n")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			continue
+		}
+
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			c.write("PONG\r\n")
+		case "SUB":
+			sub := testSub{conn: &c, subject: args[1], sid: args[len(args)-1]}
+			sids[sub.sid] = &sub
+			s.mu.Lock()
+			s.subs[&sub] = struct{}{}
+			s.mu.Unlock()
+		case "UNSUB":
+			s.mu.Lock()
+			delete(s.subs, sids[args[1]])
+			s.mu.Unlock()
+			delete(sids, args[1])
+		case "PUB":
+			n, err := strconv.Atoi(args[len(args)-1])
+			if err != nil {
+				return
+			}
+			b := make([]byte, n+2)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return
+			}
+
+			s.mu.Lock()
+			for sub := range s.subs {
+				if sub.subject == args[1] {
+					sub.conn.write("MSG %s %s %d\r\n%s\r\n", sub.subject, sub.sid, n, b[:n])
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func TestAnswerBus(t *testing.T) {
+	assert := require.New(t)
+
+	s := newTestServer(t)
+	defer s.close()
+
+	conn, err := nats.Connect(s.url())
+	assert.NoError(err)
+	defer conn.Close()
+
+	bus := New(conn, Config{FlushTimeout: time.Second})
+
+	sub1, err := bus.Subscribe(context.Background(), 1)
+	assert.NoError(err)
+	sub2, err := bus.Subscribe(context.Background(), 2)
+	assert.NoError(err)
+
+	assert.NoError(bus.Publish(context.Background(), 1, []byte("answer")))
+
+	bb, err := sub1.Receive(context.Background(), time.Second)
+	assert.NoError(err)
+	assert.Equal([]byte("answer"), bb)
+
+	_, err = sub2.Receive(context.Background(), 10*time.Millisecond)
+	assert.Equal(backend.ErrAsyncTimeout, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = sub2.Receive(ctx, time.Second)
+	assert.Equal(context.Canceled, err)
+
+	// closed subscriptions do not receive answers
+	assert.NoError(sub1.Close())
+	assert.NoError(sub2.Close())
+	assert.NoError(conn.Flush())
+	assert.NoError(bus.Publish(context.Background(), 1, []byte("answer")))
+	assert.NoError(conn.Flush())
+
+	s.mu.Lock()
+	assert.Len(s.subs, 0)
+	s.mu.Unlock()
+}
+
+func TestAnswerBusSubject(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		subject string
+	}{
+		{
+			name:    "default prefix",
+			subject: "lora.backend.async.1234",
+		},
+		{
+			name:    "tenant prefix",
+			config:  Config{SubjectPrefix: "tenant-1.async."},
+			subject: "tenant-1.async.1234",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			b := New(nil, tst.config).(*answerBus)
+			assert.Equal(tst.subject, b.subject(1234))
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestMemoryAnswerBus(t *testing.T) {
+	assert := require.New(t)
+	bus := NewMemoryAnswerBus()
+
+	sub1, err := bus.Subscribe(context.Background(), 1)
+	assert.NoError(err)
+	sub2, err := bus.Subscribe(context.Background(), 1)
+	assert.NoError(err)
+	sub3, err := bus.Subscribe(context.Background(), 2)
+	assert.NoError(err)
+
+	assert.NoError(bus.Publish(context.Background(), 1, []byte("answer")))
+
+	// every subscriber of the transaction receives the answer
+	bb, err := sub1.Receive(context.Background(), time.Second)
+	assert.NoError(err)
+	assert.Equal([]byte("answer"), bb)
+	bb, err = sub2.Receive(context.Background(), time.Second)
+	assert.NoError(err)
+	assert.Equal([]byte("answer"), bb)
+
+	_, err = sub3.Receive(context.Background(), time.Millisecond)
+	assert.Equal(ErrAsyncTimeout, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = sub3.Receive(ctx, time.Second)
+	assert.Equal(context.Canceled, err)
+
+	// closed subscriptions do not receive answers
+	assert.NoError(sub1.Close())
+	assert.NoError(sub2.Close())
+	assert.NoError(sub3.Close())
+	assert.NoError(bus.Publish(context.Background(), 1, []byte("answer")))
+	assert.Len(bus.(*memoryAnswerBus).subs, 0)
+}
+
+func TestClientAnswerBus(t *testing.T) {
+	assert := require.New(t)
+
+	// the peer answers the request asynchronously, the answer callback is
+	// passed to HandleAnswer
+	requests := make(chan HomeNSReqPayload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req HomeNSReqPayload
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		requests <- req
+	}))
+	defer server.Close()
+
+	c, err := NewClient(ClientConfig{
+		SenderID:     "010203",
+		ReceiverID:   "030201",
+		Server:       server.URL,
+		AnswerBus:    NewMemoryAnswerBus(),
+		AsyncTimeout: time.Second,
+	})
+	assert.NoError(err)
+	assert.True(c.IsAsync())
+
+	go func() {
+		req := <-requests
+		c.HandleAnswer(context.Background(), HomeNSAnsPayload{
+			BasePayloadResult: BasePayloadResult{
+				BasePayload: BasePayload{
+					ProtocolVersion: req.ProtocolVersion,
+					SenderID:        req.ReceiverID,
+					ReceiverID:      req.SenderID,
+					TransactionID:   req.TransactionID,
+					MessageType:     HomeNSAns,
+				},
+				Result: Result{ResultCode: Success},
+			},
+			HNetID: lorawan.NetID{1, 2, 3},
+		})
+	}()
+
+	ans, err := c.HomeNSReq(context.Background(), HomeNSReqPayload{})
+	assert.NoError(err)
+	assert.Equal(lorawan.NetID{1, 2, 3}, ans.HNetID)
+}
+
+func TestClientRedisUniversalClient(t *testing.T) {
+	tests := []struct {
+		name   string
+		client redis.UniversalClient
+	}{
+		{"single node", redis.NewClient(&redis.Options{Addr: "redis:6379"})},
+		{"cluster", redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{"redis-1:6379", "redis-2:6379"}})},
+		{"sentinel", redis.NewFailoverClient(&redis.FailoverOptions{MasterName: "master", SentinelAddrs: []string{"sentinel:26379"}})},
+		{"universal", redis.NewUniversalClient(&redis.UniversalOptions{Addrs: []string{"redis-1:6379", "redis-2:6379"}})},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			assert := require.New(t)
+			defer tst.client.Close()
+
+			c, err := NewClient(ClientConfig{
+				RedisClient:  tst.client,
+				AsyncTimeout: time.Second,
+			})
+			assert.NoError(err)
+			assert.True(c.IsAsync())
+			assert.Equal(&redisAnswerBus{
+				client: tst.client,
+				config: RedisAnswerBusConfig{KeyPrefix: DefaultRedisKeyPrefix},
+			}, c.(*client).answerBus)
+		})
+	}
+}
+
+func TestRedisAnswerBusKeys(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         RedisAnswerBusConfig
+		key            string
+		transactionKey string
+	}{
+		{
+			name:           "default prefix",
+			key:            "lora:backend:async:1234",
+			transactionKey: "lora:backend:async:tx:1234",
+		},
+		{
+			name:           "tenant prefix",
+			config:         RedisAnswerBusConfig{KeyPrefix: "tenant-1:async:"},
+			key:            "tenant-1:async:1234",
+			transactionKey: "tenant-1:async:tx:1234",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			b := NewRedisAnswerBus(nil, tst.config).(*redisAnswerBus)
+			assert.Equal(tst.key, b.key(1234))
+			assert.Equal(tst.transactionKey, b.transactionKey(1234))
+		})
+	}
+}
+
+func TestClientRedisConfig(t *testing.T) {
+	assert := require.New(t)
+
+	redisClient := redis.NewClient(&redis.Options{Addr: "redis:6379"})
+	defer redisClient.Close()
+
+	c, err := NewClient(ClientConfig{
+		RedisClient:         redisClient,
+		RedisKeyPrefix:      "tenant-1:",
+		RedisTransactionTTL: time.Minute,
+		AsyncTimeout:        time.Second,
+	})
+	assert.NoError(err)
+	assert.Equal(RedisAnswerBusConfig{
+		KeyPrefix:      "tenant-1:",
+		TransactionTTL: time.Minute,
+	}, c.(*client).answerBus.(*redisAnswerBus).config)
+}
@@ -0,0 +1,114 @@
+package backend
+
+import (
+	"encoding/hex"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lorawan"
+)
+
+// ErrAppSKeyNotFound is returned when there is no AppSKey cached for the
+// given SessionKeyID. In this case the application-server should request
+// the AppSKey from the join-server using an AppSKeyReq.
+var ErrAppSKeyNotFound = errors.New("AppSKey not found")
+
+// AppSKeyCache implements the minimal key handling needed by an
+// application-server when the AppSKey is never exposed in clear to the
+// network-server. It unwraps the AppSKey KeyEnvelope (received through the
+// JoinAns or AppSKeyAns) using the KEK of the application-server and caches
+// the AppSKey by SessionKeyID.
+type AppSKeyCache struct {
+	kekStore KEKStore
+
+	mu   sync.RWMutex
+	keys map[string]lorawan.AES128Key
+}
+
+// NewAppSKeyCache creates a new AppSKeyCache. The given store is used to
+// lookup the KEK by the KEKLabel of the KeyEnvelope.
+func NewAppSKeyCache(kekStore KEKStore) *AppSKeyCache {
+	return &AppSKeyCache{
+		kekStore: kekStore,
+		keys:     make(map[string]lorawan.AES128Key),
+	}
+}
+
+// Set unwraps the given AppSKey KeyEnvelope and stores the AppSKey under
+// the given SessionKeyID. It returns the unwrapped AppSKey.
+func (c *AppSKeyCache) Set(sessionKeyID HEXBytes, appSKey *KeyEnvelope) (lorawan.AES128Key, error) {
+	if len(sessionKeyID) == 0 {
+		return lorawan.AES128Key{}, errors.New("SessionKeyID must be set")
+	}
+	if appSKey == nil {
+		return lorawan.AES128Key{}, errors.New("AppSKey must be set")
+	}
+
+	key, err := UnwrapKey(c.kekStore, appSKey)
+	if err != nil {
+		return key, errors.Wrap(err, "unwrap AppSKey error")
+	}
+
+	c.mu.Lock()
+	c.keys[hex.EncodeToString(sessionKeyID)] = key
+	c.mu.Unlock()
+
+	return key, nil
+}
+
+// SetFromJoinAns stores the AppSKey of the given JoinAns payload.
+func (c *AppSKeyCache) SetFromJoinAns(pl JoinAnsPayload) (lorawan.AES128Key, error) {
+	if err := pl.Result.Err(); err != nil {
+		return lorawan.AES128Key{}, err
+	}
+
+	return c.Set(pl.SessionKeyID, pl.AppSKey)
+}
+
+// SetFromAppSKeyAns stores the AppSKey of the given AppSKeyAns payload.
+func (c *AppSKeyCache) SetFromAppSKeyAns(pl AppSKeyAnsPayload) (lorawan.AES128Key, error) {
+	if err := pl.Result.Err(); err != nil {
+		return lorawan.AES128Key{}, err
+	}
+
+	return c.Set(pl.SessionKeyID, pl.AppSKey)
+}
+
+// Get returns the AppSKey for the given SessionKeyID.
+func (c *AppSKeyCache) Get(sessionKeyID HEXBytes) (lorawan.AES128Key, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	key, ok := c.keys[hex.EncodeToString(sessionKeyID)]
+	if !ok {
+		return key, ErrAppSKeyNotFound
+	}
+	return key, nil
+}
+
+// Delete removes the AppSKey for the given SessionKeyID.
+func (c *AppSKeyCache) Delete(sessionKeyID HEXBytes) {
+	c.mu.Lock()
+	delete(c.keys, hex.EncodeToString(sessionKeyID))
+	c.mu.Unlock()
+}
+
+// DecryptFRMPayload decrypts the given FRMPayload using the AppSKey stored
+// under the given SessionKeyID. ErrAppSKeyNotFound is returned when the
+// AppSKey is not in the cache.
+func (c *AppSKeyCache) DecryptFRMPayload(sessionKeyID HEXBytes, uplink bool, devAddr lorawan.DevAddr, fCnt uint32, data []byte) ([]byte, error) {
+	key, err := c.Get(sessionKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return lorawan.EncryptFRMPayload(key, uplink, devAddr, fCnt, data)
+}
+
+// EncryptFRMPayload encrypts the given FRMPayload using the AppSKey stored
+// under the given SessionKeyID. ErrAppSKeyNotFound is returned when the
+// AppSKey is not in the cache.
+func (c *AppSKeyCache) EncryptFRMPayload(sessionKeyID HEXBytes, uplink bool, devAddr lorawan.DevAddr, fCnt uint32, data []byte) ([]byte, error) {
+	return c.DecryptFRMPayload(sessionKeyID, uplink, devAddr, fCnt, data)
+}
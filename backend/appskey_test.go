@@ -0,0 +1,89 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestAppSKeyCache(t *testing.T) {
+	assert := require.New(t)
+
+	kek := lorawan.AES128Key{8, 7, 6, 5, 4, 3, 2, 1, 8, 7, 6, 5, 4, 3, 2, 1}
+	appSKey := lorawan.AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8}
+	devAddr := lorawan.DevAddr{1, 2, 3, 4}
+	sessionKeyID := HEXBytes{1, 2, 3, 4}
+
+	c := NewAppSKeyCache(KEKStoreFunc(func(label string) ([]byte, error) {
+		if label == "as-kek" {
+			return kek[:], nil
+		}
+		return nil, nil
+	}))
+
+	ke, err := NewKeyEnvelope("as-kek", kek[:], appSKey)
+	assert.NoError(err)
+
+	t.Run("Not cached", func(t *testing.T) {
+		assert := require.New(t)
+
+		_, err := c.DecryptFRMPayload(sessionKeyID, true, devAddr, 1, []byte{1, 2, 3})
+		assert.Equal(ErrAppSKeyNotFound, err)
+	})
+
+	t.Run("AppSKeyAns", func(t *testing.T) {
+		assert := require.New(t)
+
+		key, err := c.SetFromAppSKeyAns(AppSKeyAnsPayload{
+			BasePayloadResult: BasePayloadResult{
+				Result: Result{ResultCode: Success},
+			},
+			AppSKey:      ke,
+			SessionKeyID: sessionKeyID,
+		})
+		assert.NoError(err)
+		assert.Equal(appSKey, key)
+
+		key, err = c.Get(sessionKeyID)
+		assert.NoError(err)
+		assert.Equal(appSKey, key)
+	})
+
+	t.Run("Encrypt and decrypt", func(t *testing.T) {
+		assert := require.New(t)
+
+		exp, err := lorawan.EncryptFRMPayload(appSKey, true, devAddr, 1, []byte("hello"))
+		assert.NoError(err)
+
+		b, err := c.EncryptFRMPayload(sessionKeyID, true, devAddr, 1, []byte("hello"))
+		assert.NoError(err)
+		assert.Equal(exp, b)
+
+		b, err = c.DecryptFRMPayload(sessionKeyID, true, devAddr, 1, b)
+		assert.NoError(err)
+		assert.Equal([]byte("hello"), b)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		assert := require.New(t)
+
+		c.Delete(sessionKeyID)
+		_, err := c.Get(sessionKeyID)
+		assert.Equal(ErrAppSKeyNotFound, err)
+	})
+
+	t.Run("Unknown KEK label", func(t *testing.T) {
+		assert := require.New(t)
+
+		_, err := c.SetFromJoinAns(JoinAnsPayload{
+			BasePayloadResult: BasePayloadResult{
+				Result: Result{ResultCode: Success},
+			},
+			AppSKey:      &KeyEnvelope{KEKLabel: "foo", AESKey: ke.AESKey},
+			SessionKeyID: sessionKeyID,
+		})
+		assert.EqualError(err, "unwrap AppSKey error: no KEK for label foo")
+	})
+}
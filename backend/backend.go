@@ -5,11 +5,15 @@ import (
 	"crypto/aes"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	keywrap "github.com/NickBall/go-aes-key-wrap"
 	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
 	"github.com/pkg/errors"
 )
 
@@ -34,8 +38,13 @@ const (
 // Supported protocol versions.
 const (
 	ProtocolVersion1_0 = "1.0"
+	ProtocolVersion1_1 = "1.1"
 )
 
+// protocolVersions holds the supported protocol versions, in ascending
+// order.
+var protocolVersions = []string{ProtocolVersion1_0, ProtocolVersion1_1}
+
 // MessageType defines the message-type type.
 type MessageType string
 
@@ -63,6 +72,20 @@ const (
 	XmitDataAns MessageType = "XmitDataAns"
 )
 
+// Vendor-specific message types. These are not defined by the Backend
+// Interfaces specification and are prefixed with "VS-" to avoid a conflict
+// with message types added by future versions of the specification.
+const (
+	VSMcKEKeyReq MessageType = "VS-McKEKeyReq"
+	VSMcKEKeyAns MessageType = "VS-McKEKeyAns"
+)
+
+// AnswerMessageType returns the answer message-type of the given request
+// message-type, e.g. PRStartAns for PRStartReq.
+func AnswerMessageType(mt MessageType) MessageType {
+	return MessageType(strings.TrimSuffix(string(mt), "Req") + "Ans")
+}
+
 // ResultCode defines the result-code type.
 type ResultCode string
 
@@ -85,6 +108,7 @@ const (
 	InvalidProtocolVersion ResultCode = "InvalidProtocolVersion" // ProtocolVersion is not supported
 	StaleDeviceProfile     ResultCode = "StaleDeviceProfile"     // Device Profile is stale
 	MalformedRequest       ResultCode = "MalformedRequest"       // JSON parsing failed (missing object or incorrect content)
+	MalformedMessage       ResultCode = "MalformedMessage"       // JSON parsing failed (protocol version 1.1, replaces MalformedRequest)
 	FrameSizeError         ResultCode = "FrameSizeError"         // Wrong size of PHYPayload or FRMPayload
 	Other                  ResultCode = "Other"                  // Used for encoding error cases that are not standardized yet
 )
@@ -146,11 +170,39 @@ func (t *ISO8601Time) UnmarshalText(text []byte) error {
 // Frequency defines the frequency type (in Hz).
 type Frequency int
 
+// NewFrequencyFromMHz returns the Frequency for the given value in MHz
+// (e.g. 868.1). The value is rounded to the nearest Hz.
+func NewFrequencyFromMHz(mhz float64) Frequency {
+	return Frequency(math.Round(mhz * 1000000))
+}
+
+// NewFrequencyFromKHz returns the Frequency for the given value in kHz
+// (e.g. 868100). The value is rounded to the nearest Hz.
+func NewFrequencyFromKHz(khz float64) Frequency {
+	return Frequency(math.Round(khz * 1000))
+}
+
+// MHz returns the frequency in MHz.
+func (f Frequency) MHz() float64 {
+	return float64(f) / 1000000
+}
+
+// KHz returns the frequency in kHz.
+func (f Frequency) KHz() float64 {
+	return float64(f) / 1000
+}
+
+// Validate returns an error when the frequency is outside the frequency
+// range of the given band.
+func (f Frequency) Validate(b band.Band) error {
+	return band.ValidateFrequency(b, int(f))
+}
+
 // MarshalJSON implements the json.Marshaler interface.
 // This returns the frequency value in MHz (e.g. 868.1) to be compatible
 // with the LoRaWAN Backend Interfaces specification.
 func (f Frequency) MarshalJSON() ([]byte, error) {
-	return json.Marshal(float64(f) / 1000000)
+	return json.Marshal(f.MHz())
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
@@ -160,7 +212,7 @@ func (f *Frequency) UnmarshalJSON(str []byte) error {
 	if err != nil {
 		return errors.Wrap(err, "parse float error")
 	}
-	*f = Frequency(mhz * 1000000)
+	*f = NewFrequencyFromMHz(mhz)
 	return nil
 }
 
@@ -175,13 +227,15 @@ func (p Percentage) MarshalJSON() ([]byte, error) {
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
-// This parses a percentage presented as 0.1 (float) back to 10 (int).
+// This parses a percentage presented as 0.1 (float) back to 10 (int). The
+// value is rounded to the nearest percent, as e.g. 0.29 * 100 is not exactly
+// representable and would otherwise be truncated to 28.
 func (p *Percentage) UnmarshalJSON(str []byte) error {
 	perc, err := strconv.ParseFloat(string(str), 64)
 	if err != nil {
 		return errors.Wrap(err, "parse float error")
 	}
-	*p = Percentage(perc * 100)
+	*p = Percentage(math.Round(perc * 100))
 	return nil
 }
 
@@ -195,6 +249,8 @@ type BasePayload struct {
 	SenderToken     HEXBytes    `json:"SenderToken,omitempty"`
 	ReceiverToken   HEXBytes    `json:"ReceiverToken,omitempty"`
 	VSExtension     VSExtension `json:"VSExtension,omitempty"`
+	SenderNSID      string      `json:"SenderNSID,omitempty"`   // NSID of the sender NS (protocol version 1.1)
+	ReceiverNSID    string      `json:"ReceiverNSID,omitempty"` // NSID of the receiver NS (protocol version 1.1)
 }
 
 // BasePayloadResult defines the base payload that is sent with every result.
@@ -214,6 +270,38 @@ type Result struct {
 	Description string     `json:"Description"` // Detailed information related to the ResultCode (optional).
 }
 
+// Err returns a *ResultError when the ResultCode is not Success.
+func (r Result) Err() error {
+	if r.ResultCode == Success {
+		return nil
+	}
+	return &ResultError{
+		ResultCode:  r.ResultCode,
+		Description: r.Description,
+	}
+}
+
+// ResultError is returned when an answer contains a ResultCode other than
+// Success. Use errors.As to inspect the ResultCode, or errors.Is with a
+// *ResultError holding only the ResultCode to test for a specific code, e.g.
+// errors.Is(err, &ResultError{ResultCode: UnknownDevEUI}).
+type ResultError struct {
+	ResultCode  ResultCode
+	Description string
+}
+
+// Error implements the error interface.
+func (e *ResultError) Error() string {
+	return fmt.Sprintf("response error, code: %s, description: %s", e.ResultCode, e.Description)
+}
+
+// Is returns true when the target is a *ResultError with the same
+// ResultCode.
+func (e *ResultError) Is(target error) bool {
+	t, ok := target.(*ResultError)
+	return ok && t.ResultCode == e.ResultCode
+}
+
 // KeyEnvelope defines the key envelope object.
 type KeyEnvelope struct {
 	KEKLabel string   `json:"KEKLabel"`
@@ -302,19 +390,20 @@ type ULMetaData struct {
 
 // DLMetaData defines the downlink metadata.
 type DLMetaData struct {
-	DevEUI         *lorawan.EUI64  `json:"DevEUI,omitempty"`
-	FPort          *uint8          `json:"FPort,omitempty"`
-	FCntDown       *uint32         `json:"FCntDown,omitempty"`
-	Confirmed      bool            `json:"Confirmed,omitempty"`
-	DLFreq1        *float64        `json:"DLFreq1,omitempty"` // TODO: In MHz? At least DLFreq1 or DLFreq2 SHALL be present.
-	DLFreq2        *float64        `json:"DLFreq2,omitempty"` // TODO: In Mhz? At least DLFreq1 or DLFreq2 SHALL be present.
-	RXDelay1       *int            `json:"RXDelay1,omitempty"`
-	ClassMode      *string         `json:"ClassMode,omitempty"` // Only "A" and "C" are supported
-	DataRate1      *int            `json:"DataRate1,omitempty"` // Present only if DLFreq1 is present
-	DataRate2      *int            `json:"DataRate2,omitempty"` // Present only if DLFreq2 is present
-	FNSULToken     HEXBytes        `json:"FNSULToken,omitempty"`
-	GWInfo         []GWInfoElement `json:"GWInfo"`
-	HiPriorityFlag bool            `json:"HiPriorityFlag,omitempty"`
+	DevEUI         *lorawan.EUI64   `json:"DevEUI,omitempty"`
+	DevAddr        *lorawan.DevAddr `json:"DevAddr,omitempty"` // Protocol version 1.1
+	FPort          *uint8           `json:"FPort,omitempty"`
+	FCntDown       *uint32          `json:"FCntDown,omitempty"`
+	Confirmed      bool             `json:"Confirmed,omitempty"`
+	DLFreq1        *float64         `json:"DLFreq1,omitempty"` // TODO: In MHz? At least DLFreq1 or DLFreq2 SHALL be present.
+	DLFreq2        *float64         `json:"DLFreq2,omitempty"` // TODO: In Mhz? At least DLFreq1 or DLFreq2 SHALL be present.
+	RXDelay1       *int             `json:"RXDelay1,omitempty"`
+	ClassMode      *string          `json:"ClassMode,omitempty"` // Only "A" and "C" are supported
+	DataRate1      *int             `json:"DataRate1,omitempty"` // Present only if DLFreq1 is present
+	DataRate2      *int             `json:"DataRate2,omitempty"` // Present only if DLFreq2 is present
+	FNSULToken     HEXBytes         `json:"FNSULToken,omitempty"`
+	GWInfo         []GWInfoElement  `json:"GWInfo"`
+	HiPriorityFlag bool             `json:"HiPriorityFlag,omitempty"`
 }
 
 // JoinReqPayload defines the JoinReq message payload.
@@ -412,6 +501,33 @@ func (p AppSKeyAnsPayload) GetBasePayload() BasePayloadResult {
 	return p.BasePayloadResult
 }
 
+// McKEKeyReqPayload defines the VSMcKEKeyReq message payload. This message
+// is a vendor extension, it is not defined by the Backend Interfaces
+// specification. It is used by the application-server to request the
+// McKEKey of a device (derived from the McRootKey) from the join-server or
+// key management service, e.g. for setting up a FUOTA multicast-group.
+type McKEKeyReqPayload struct {
+	BasePayload
+	DevEUI lorawan.EUI64 `json:"DevEUI"`
+}
+
+// GetBasePayload returns the base payload.
+func (p McKEKeyReqPayload) GetBasePayload() BasePayload {
+	return p.BasePayload
+}
+
+// McKEKeyAnsPayload defines the VSMcKEKeyAns message payload.
+type McKEKeyAnsPayload struct {
+	BasePayloadResult
+	DevEUI  lorawan.EUI64 `json:"DevEUI"`
+	McKEKey *KeyEnvelope  `json:"McKEKey,omitempty"` // Mandatory when Result=Success
+}
+
+// GetBasePayload returns the base payload.
+func (p McKEKeyAnsPayload) GetBasePayload() BasePayloadResult {
+	return p.BasePayloadResult
+}
+
 // PRStartReqPayload defines the PRStartReq message payload.
 type PRStartReqPayload struct {
 	BasePayload
@@ -435,7 +551,7 @@ type PRStartAnsPayload struct {
 	FCntUp         *uint32          `json:"FCntUp,omitempty"`         // Optional when Result=Success
 	ServiceProfile *ServiceProfile  `json:"ServiceProfile,omitempty"` // Optional when Result=Success
 	DLMetaData     *DLMetaData      `json:"DLMetaData,omitempty"`     // Optional when Result=Success
-	DevAddr        *lorawan.DevAddr `json:"DevAddr,omitempty"`        // Optional when Result=Success (not specified in Backend Specs but needed for OTAA)
+	DevAddr        *lorawan.DevAddr `json:"DevAddr,omitempty"`        // Optional when Result=Success (protocol version 1.1, but also needed for OTAA when using 1.0)
 }
 
 // GetBasePayload returns the base payload.
@@ -540,6 +656,7 @@ func (p HomeNSReqPayload) GetBasePayload() BasePayload {
 type HomeNSAnsPayload struct {
 	BasePayloadResult
 	HNetID lorawan.NetID `json:"HNetID"`
+	HNSID  string        `json:"HNSID,omitempty"` // NSID of the home NS (protocol version 1.1)
 }
 
 // GetBasePayload returns the base payload.
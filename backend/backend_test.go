@@ -2,10 +2,13 @@ package backend
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/stretchr/testify/require"
 )
@@ -49,6 +52,33 @@ func TestFrequency(t *testing.T) {
 			So(f.UnmarshalJSON([]byte("868.2")), ShouldBeNil)
 			So(f, ShouldEqual, Frequency(868200000))
 		})
+
+		Convey("Then UnmarshalJSON does not truncate the value", func() {
+			So(f.UnmarshalJSON([]byte("868.1")), ShouldBeNil)
+			So(f, ShouldEqual, Frequency(868100000))
+		})
+
+		Convey("Then MHz and KHz return the expected values", func() {
+			So(f.MHz(), ShouldEqual, 868.1)
+			So(f.KHz(), ShouldEqual, 868100)
+		})
+
+		Convey("Then Validate validates the frequency against the band", func() {
+			eu868, err := band.GetConfig(band.EU868, false, lorawan.DwellTimeNoLimit)
+			So(err, ShouldBeNil)
+			us915, err := band.GetConfig(band.US915, false, lorawan.DwellTimeNoLimit)
+			So(err, ShouldBeNil)
+
+			So(f.Validate(eu868), ShouldBeNil)
+			So(f.Validate(us915), ShouldNotBeNil)
+		})
+	})
+
+	Convey("Then NewFrequencyFromMHz and NewFrequencyFromKHz return the expected value", t, func() {
+		So(NewFrequencyFromMHz(868.1), ShouldEqual, Frequency(868100000))
+		So(NewFrequencyFromMHz(923.3), ShouldEqual, Frequency(923300000))
+		So(NewFrequencyFromKHz(868100), ShouldEqual, Frequency(868100000))
+		So(NewFrequencyFromKHz(868100.5), ShouldEqual, Frequency(868100500))
 	})
 }
 
@@ -66,6 +96,11 @@ func TestPercentage(t *testing.T) {
 			So(p.UnmarshalJSON([]byte("0.02")), ShouldBeNil)
 			So(p, ShouldEqual, Percentage(2))
 		})
+
+		Convey("Then UnmarshalJSON does not truncate the value", func() {
+			So(p.UnmarshalJSON([]byte("0.29")), ShouldBeNil)
+			So(p, ShouldEqual, Percentage(29))
+		})
 	})
 }
 
@@ -88,6 +123,47 @@ func TestISO8601Time(t *testing.T) {
 	})
 }
 
+func TestResultError(t *testing.T) {
+	assert := require.New(t)
+
+	assert.NoError(Result{ResultCode: Success}.Err())
+
+	err := Result{ResultCode: UnknownDevEUI, Description: "device does not exist"}.Err()
+	assert.EqualError(err, "response error, code: UnknownDevEUI, description: device does not exist")
+	assert.True(errors.Is(err, &ResultError{ResultCode: UnknownDevEUI}))
+	assert.False(errors.Is(err, &ResultError{ResultCode: MICFailed}))
+
+	var resErr *ResultError
+	assert.True(errors.As(fmt.Errorf("request error: %w", err), &resErr))
+	assert.Equal(UnknownDevEUI, resErr.ResultCode)
+	assert.Equal("device does not exist", resErr.Description)
+}
+
+func TestProtocolVersion1_1Fields(t *testing.T) {
+	assert := require.New(t)
+
+	devAddr := lorawan.DevAddr{1, 2, 3, 4}
+	b, err := json.Marshal(HomeNSAnsPayload{
+		HNetID: lorawan.NetID{1, 2, 3},
+		HNSID:  "ns-1",
+	})
+	assert.NoError(err)
+	assert.Contains(string(b), `"HNSID":"ns-1"`)
+
+	b, err = json.Marshal(DLMetaData{DevAddr: &devAddr})
+	assert.NoError(err)
+	assert.Contains(string(b), `"DevAddr":"01020304"`)
+
+	// the 1.1 fields are omitted when not set, e.g. for protocol version 1.0
+	b, err = json.Marshal(HomeNSAnsPayload{})
+	assert.NoError(err)
+	assert.NotContains(string(b), "HNSID")
+
+	b, err = json.Marshal(DLMetaData{})
+	assert.NoError(err)
+	assert.NotContains(string(b), "DevAddr")
+}
+
 func TestKeyEnvelope(t *testing.T) {
 	key := lorawan.AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8}
 	kek := lorawan.AES128Key{8, 7, 6, 5, 4, 3, 2, 1, 8, 7, 6, 5, 4, 3, 2, 1}
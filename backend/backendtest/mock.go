@@ -0,0 +1,312 @@
+// Package backendtest provides test helpers for code using the backend
+// package: a mock implementation of the backend.Client interface for unit
+// tests without a HTTP peer or Redis, an in-process Backend Interfaces
+// peer for integration tests and the recording and replaying of backend
+// sessions for regression tests.
+package backendtest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/brocaar/lorawan/backend"
+)
+
+// Call defines a call recorded by the MockClient.
+type Call struct {
+	// MessageType holds the message-type of the request or of the answer
+	// in case of SendAnswer and HandleAnswer.
+	MessageType backend.MessageType
+
+	// Payload holds the request or answer payload.
+	Payload interface{}
+}
+
+type answer struct {
+	payload backend.Answer
+	err     error
+}
+
+// MockClient implements a programmable backend.Client. Answers are
+// configured per request message-type using AddAnswer and all calls are
+// recorded. When no answer is configured for a request, an error is
+// returned.
+//
+// The MockClient is safe for concurrent use.
+type MockClient struct {
+	mu                sync.Mutex
+	senderID          string
+	receiverID        string
+	async             bool
+	latency           time.Duration
+	answers           map[backend.MessageType][]answer
+	calls             []Call
+	nextTransactionID uint32
+	sendAnswerErr     error
+	handleAnswerErr   error
+}
+
+// NewMockClient creates a new MockClient.
+func NewMockClient(senderID, receiverID string) *MockClient {
+	return &MockClient{
+		senderID:   senderID,
+		receiverID: receiverID,
+		answers:    make(map[backend.MessageType][]answer),
+	}
+}
+
+// SetAsync sets the value returned by IsAsync.
+func (c *MockClient) SetAsync(async bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.async = async
+}
+
+// SetLatency sets the latency which is injected before every request and
+// answer call returns. When the context is done before, the context error
+// is returned.
+func (c *MockClient) SetLatency(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.latency = d
+}
+
+// AddAnswer adds an answer (or error) for the given request message-type
+// (e.g. backend.PRStartReq). Answers are returned in the order in which they
+// were added. The last answer is returned for all subsequent requests.
+// The answer must be of the answer type of the request (e.g.
+// backend.PRStartAnsPayload).
+func (c *MockClient) AddAnswer(requestType backend.MessageType, ans backend.Answer, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.answers[requestType] = append(c.answers[requestType], answer{payload: ans, err: err})
+}
+
+// SetSendAnswerError sets the error returned by SendAnswer.
+func (c *MockClient) SetSendAnswerError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sendAnswerErr = err
+}
+
+// SetHandleAnswerError sets the error returned by HandleAnswer.
+func (c *MockClient) SetHandleAnswerError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.handleAnswerErr = err
+}
+
+// Calls returns the recorded calls.
+func (c *MockClient) Calls() []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Call(nil), c.calls...)
+}
+
+// Reset removes the recorded calls and configured answers.
+func (c *MockClient) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls = nil
+	c.answers = make(map[backend.MessageType][]answer)
+}
+
+// GetSenderID implements backend.Client.
+func (c *MockClient) GetSenderID() string {
+	return c.senderID
+}
+
+// GetReceiverID implements backend.Client.
+func (c *MockClient) GetReceiverID() string {
+	return c.receiverID
+}
+
+// IsAsync implements backend.Client.
+func (c *MockClient) IsAsync() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.async
+}
+
+// GetRandomTransactionID implements backend.Client. To make tests
+// deterministic, this returns an incrementing ID, starting at 1.
+func (c *MockClient) GetRandomTransactionID() uint32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextTransactionID++
+	return c.nextTransactionID
+}
+
+// AllocateTransactionID implements backend.Client. Like
+// GetRandomTransactionID, this returns an incrementing ID, starting at 1.
+func (c *MockClient) AllocateTransactionID() (uint32, error) {
+	return c.GetRandomTransactionID(), nil
+}
+
+// JoinReq implements backend.Client.
+func (c *MockClient) JoinReq(ctx context.Context, pl backend.JoinReqPayload) (backend.JoinAnsPayload, error) {
+	var ans backend.JoinAnsPayload
+	err := c.request(ctx, backend.JoinReq, pl, &ans)
+	return ans, err
+}
+
+// RejoinReq implements backend.Client.
+func (c *MockClient) RejoinReq(ctx context.Context, pl backend.RejoinReqPayload) (backend.RejoinAnsPayload, error) {
+	var ans backend.RejoinAnsPayload
+	err := c.request(ctx, backend.RejoinReq, pl, &ans)
+	return ans, err
+}
+
+// AppSKeyReq implements backend.Client.
+func (c *MockClient) AppSKeyReq(ctx context.Context, pl backend.AppSKeyReqPayload) (backend.AppSKeyAnsPayload, error) {
+	var ans backend.AppSKeyAnsPayload
+	err := c.request(ctx, backend.AppSKeyReq, pl, &ans)
+	return ans, err
+}
+
+// PRStartReq implements backend.Client.
+func (c *MockClient) PRStartReq(ctx context.Context, pl backend.PRStartReqPayload) (backend.PRStartAnsPayload, error) {
+	var ans backend.PRStartAnsPayload
+	err := c.request(ctx, backend.PRStartReq, pl, &ans)
+	return ans, err
+}
+
+// PRStopReq implements backend.Client.
+func (c *MockClient) PRStopReq(ctx context.Context, pl backend.PRStopReqPayload) (backend.PRStopAnsPayload, error) {
+	var ans backend.PRStopAnsPayload
+	err := c.request(ctx, backend.PRStopReq, pl, &ans)
+	return ans, err
+}
+
+// HRStartReq implements backend.Client.
+func (c *MockClient) HRStartReq(ctx context.Context, pl backend.HRStartReqPayload) (backend.HRStartAnsPayload, error) {
+	var ans backend.HRStartAnsPayload
+	err := c.request(ctx, backend.HRStartReq, pl, &ans)
+	return ans, err
+}
+
+// HRStopReq implements backend.Client.
+func (c *MockClient) HRStopReq(ctx context.Context, pl backend.HRStopReqPayload) (backend.HRStopAnsPayload, error) {
+	var ans backend.HRStopAnsPayload
+	err := c.request(ctx, backend.HRStopReq, pl, &ans)
+	return ans, err
+}
+
+// XmitDataReq implements backend.Client.
+func (c *MockClient) XmitDataReq(ctx context.Context, pl backend.XmitDataReqPayload) (backend.XmitDataAnsPayload, error) {
+	var ans backend.XmitDataAnsPayload
+	err := c.request(ctx, backend.XmitDataReq, pl, &ans)
+	return ans, err
+}
+
+// ProfileReq implements backend.Client.
+func (c *MockClient) ProfileReq(ctx context.Context, pl backend.ProfileReqPayload) (backend.ProfileAnsPayload, error) {
+	var ans backend.ProfileAnsPayload
+	err := c.request(ctx, backend.ProfileReq, pl, &ans)
+	return ans, err
+}
+
+// HomeNSReq implements backend.Client.
+func (c *MockClient) HomeNSReq(ctx context.Context, pl backend.HomeNSReqPayload) (backend.HomeNSAnsPayload, error) {
+	var ans backend.HomeNSAnsPayload
+	err := c.request(ctx, backend.HomeNSReq, pl, &ans)
+	return ans, err
+}
+
+// SendAnswer implements backend.Client.
+func (c *MockClient) SendAnswer(ctx context.Context, pl backend.Answer) error {
+	c.mu.Lock()
+	c.calls = append(c.calls, Call{MessageType: pl.GetBasePayload().MessageType, Payload: pl})
+	err := c.sendAnswerErr
+	c.mu.Unlock()
+
+	if waitErr := c.wait(ctx); waitErr != nil {
+		return waitErr
+	}
+
+	return err
+}
+
+// HandleAnswer implements backend.Client.
+func (c *MockClient) HandleAnswer(ctx context.Context, pl backend.Answer) error {
+	c.mu.Lock()
+	c.calls = append(c.calls, Call{MessageType: pl.GetBasePayload().MessageType, Payload: pl})
+	err := c.handleAnswerErr
+	c.mu.Unlock()
+
+	if waitErr := c.wait(ctx); waitErr != nil {
+		return waitErr
+	}
+
+	return err
+}
+
+// request records the request and sets the configured answer. Like the
+// backend client, it returns an error when the ResultCode of the answer is
+// not Success.
+func (c *MockClient) request(ctx context.Context, mt backend.MessageType, pl backend.Request, out backend.Answer) error {
+	c.mu.Lock()
+	c.calls = append(c.calls, Call{MessageType: mt, Payload: pl})
+
+	var a answer
+	var ok bool
+	if answers := c.answers[mt]; len(answers) != 0 {
+		a, ok = answers[0], true
+		if len(answers) > 1 {
+			c.answers[mt] = answers[1:]
+		}
+	}
+	c.mu.Unlock()
+
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("backendtest: no answer configured for %s", mt)
+	}
+	if a.err != nil {
+		return a.err
+	}
+	if a.payload == nil {
+		return nil
+	}
+
+	av := reflect.ValueOf(a.payload)
+	ov := reflect.ValueOf(out).Elem()
+	if av.Type() != ov.Type() {
+		return fmt.Errorf("backendtest: answer for %s must be of type %s, got %s", mt, ov.Type(), av.Type())
+	}
+	ov.Set(av)
+
+	return a.payload.GetBasePayload().Result.Err()
+}
+
+func (c *MockClient) wait(ctx context.Context) error {
+	c.mu.Lock()
+	latency := c.latency
+	c.mu.Unlock()
+
+	if latency == 0 {
+		return nil
+	}
+
+	select {
+	case <-time.After(latency):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,112 @@
+package backendtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/backend"
+)
+
+func TestMockClient(t *testing.T) {
+	t.Run("answers and calls", func(t *testing.T) {
+		assert := require.New(t)
+
+		var c backend.Client = NewMockClient("010101", "020202")
+		m := c.(*MockClient)
+
+		ans := backend.PRStartAnsPayload{
+			BasePayloadResult: backend.BasePayloadResult{
+				BasePayload: backend.BasePayload{MessageType: backend.PRStartAns},
+				Result:      backend.Result{ResultCode: backend.Success},
+			},
+			DevEUI: &lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		}
+		m.AddAnswer(backend.PRStartReq, ans, nil)
+		m.AddAnswer(backend.PRStartReq, nil, errors.New("boom"))
+
+		req := backend.PRStartReqPayload{PHYPayload: backend.HEXBytes{1, 2, 3}}
+
+		resp, err := c.PRStartReq(context.Background(), req)
+		assert.NoError(err)
+		assert.Equal(ans, resp)
+
+		// the last answer is repeated
+		for i := 0; i < 2; i++ {
+			_, err = c.PRStartReq(context.Background(), req)
+			assert.EqualError(err, "boom")
+		}
+
+		_, err = c.XmitDataReq(context.Background(), backend.XmitDataReqPayload{})
+		assert.EqualError(err, "backendtest: no answer configured for XmitDataReq")
+
+		assert.NoError(c.SendAnswer(context.Background(), ans))
+
+		assert.Equal([]Call{
+			{MessageType: backend.PRStartReq, Payload: req},
+			{MessageType: backend.PRStartReq, Payload: req},
+			{MessageType: backend.PRStartReq, Payload: req},
+			{MessageType: backend.XmitDataReq, Payload: backend.XmitDataReqPayload{}},
+			{MessageType: backend.PRStartAns, Payload: ans},
+		}, m.Calls())
+
+		m.Reset()
+		assert.Len(m.Calls(), 0)
+	})
+
+	t.Run("result code error", func(t *testing.T) {
+		assert := require.New(t)
+
+		m := NewMockClient("010101", "020202")
+		m.AddAnswer(backend.HomeNSReq, backend.HomeNSAnsPayload{
+			BasePayloadResult: backend.BasePayloadResult{
+				Result: backend.Result{ResultCode: backend.UnknownDevEUI, Description: "not found"},
+			},
+		}, nil)
+
+		ans, err := m.HomeNSReq(context.Background(), backend.HomeNSReqPayload{})
+		assert.EqualError(err, "response error, code: UnknownDevEUI, description: not found")
+		assert.Equal(backend.UnknownDevEUI, ans.Result.ResultCode)
+	})
+
+	t.Run("invalid answer type", func(t *testing.T) {
+		assert := require.New(t)
+
+		m := NewMockClient("010101", "020202")
+		m.AddAnswer(backend.PRStopReq, backend.PRStartAnsPayload{}, nil)
+
+		_, err := m.PRStopReq(context.Background(), backend.PRStopReqPayload{})
+		assert.EqualError(err, "backendtest: answer for PRStopReq must be of type backend.PRStopAnsPayload, got backend.PRStartAnsPayload")
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		assert := require.New(t)
+
+		m := NewMockClient("010101", "020202")
+		m.SetLatency(time.Second)
+		m.AddAnswer(backend.ProfileReq, backend.ProfileAnsPayload{}, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := m.ProfileReq(ctx, backend.ProfileReqPayload{})
+		assert.Equal(context.DeadlineExceeded, err)
+	})
+
+	t.Run("transaction id", func(t *testing.T) {
+		assert := require.New(t)
+
+		m := NewMockClient("010101", "020202")
+		assert.Equal(uint32(1), m.GetRandomTransactionID())
+		assert.Equal(uint32(2), m.GetRandomTransactionID())
+		assert.Equal("010101", m.GetSenderID())
+		assert.Equal("020202", m.GetReceiverID())
+		assert.False(m.IsAsync())
+		m.SetAsync(true)
+		assert.True(m.IsAsync())
+	})
+}
@@ -0,0 +1,176 @@
+package backendtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/brocaar/lorawan/backend"
+	"github.com/brocaar/lorawan/backend/server"
+)
+
+// PeerConfig holds the Peer configuration.
+type PeerConfig struct {
+	// AsyncAnswerServer holds the URL of the requester to which the answers
+	// are sent when set. In this case, the Peer returns an empty HTTP
+	// response and sends the answer using backend.Client.SendAnswer.
+	// When empty, the answer is returned in the HTTP response (sync).
+	AsyncAnswerServer string
+}
+
+// Peer implements an in-process Backend Interfaces peer (e.g. a fNS, sNS,
+// hNS or join-server), for integration testing roaming flows against the
+// real backend.Client code paths. The requests are dispatched by a
+// server.Handler, to the handlers configured per request message-type.
+// Requests without handler are answered with the MalformedRequest
+// result-code (MalformedMessage for protocol version 1.1).
+//
+// The Peer is safe for concurrent use.
+type Peer struct {
+	server   *httptest.Server
+	handler  *server.Handler
+	mu       sync.Mutex
+	requests []backend.BasePayload
+	errors   []error
+}
+
+// NewPeer creates and starts a new Peer. Call Close to shut it down.
+func NewPeer(config PeerConfig) *Peer {
+	var p Peer
+
+	handlerConfig := server.HandlerConfig{
+		// async answers received by the peer are accepted, but not handled
+		HandleAnswerFunc: func(ctx context.Context, ans backend.Answer) error {
+			return nil
+		},
+	}
+	if config.AsyncAnswerServer != "" {
+		handlerConfig.AsyncClientFunc = func(req backend.BasePayload) (backend.Client, error) {
+			client, err := backend.NewClient(backend.ClientConfig{
+				SenderID:   req.ReceiverID,
+				ReceiverID: req.SenderID,
+				Server:     config.AsyncAnswerServer,
+			})
+			if err != nil {
+				p.addError(fmt.Errorf("new client error: %w", err))
+				return nil, err
+			}
+
+			return &peerClient{Client: client, peer: &p}, nil
+		}
+	}
+
+	p.handler = server.NewHandler(handlerConfig)
+	p.server = httptest.NewServer(http.HandlerFunc(p.serveHTTP))
+
+	return &p
+}
+
+// URL returns the URL of the Peer, to be used as the Server of the
+// backend.ClientConfig.
+func (p *Peer) URL() string {
+	return p.server.URL
+}
+
+// Close waits for pending async answers and shuts down the Peer.
+func (p *Peer) Close() {
+	p.handler.Wait()
+	p.server.Close()
+}
+
+// Handle registers the handler for the given request message-type. Errors
+// returned by the handler are recorded and answered with the Other
+// result-code.
+func (p *Peer) Handle(mt backend.MessageType, fn server.HandlerFunc) error {
+	return p.handler.Handle(mt, func(ctx context.Context, req backend.Request) (backend.Answer, error) {
+		ans, err := fn(ctx, req)
+		if err != nil {
+			p.addError(err)
+		}
+		return ans, err
+	})
+}
+
+// Requests returns the base payload of the received requests.
+func (p *Peer) Requests() []backend.BasePayload {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]backend.BasePayload(nil), p.requests...)
+}
+
+// Errors returns the errors which occurred while handling requests or
+// sending async answers.
+func (p *Peer) Errors() []error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]error(nil), p.errors...)
+}
+
+// NewBasePayloadResult returns the BasePayloadResult for answering the given
+// request, with the sender and receiver swapped and the answer message-type
+// set.
+func NewBasePayloadResult(req backend.BasePayload, resultCode backend.ResultCode, description string) backend.BasePayloadResult {
+	return backend.BasePayloadResult{
+		BasePayload: backend.BasePayload{
+			ProtocolVersion: req.ProtocolVersion,
+			SenderID:        req.ReceiverID,
+			ReceiverID:      req.SenderID,
+			TransactionID:   req.TransactionID,
+			MessageType:     backend.AnswerMessageType(req.MessageType),
+			ReceiverToken:   req.SenderToken,
+		},
+		Result: backend.Result{
+			ResultCode:  resultCode,
+			Description: description,
+		},
+	}
+}
+
+// serveHTTP records the base payload of the request, before passing it to
+// the server.Handler.
+func (p *Peer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		p.addError(fmt.Errorf("read body error: %w", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var req backend.BasePayload
+	if err := json.Unmarshal(b, &req); err == nil {
+		p.mu.Lock()
+		p.requests = append(p.requests, req)
+		p.mu.Unlock()
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(b))
+	p.handler.ServeHTTP(w, r)
+}
+
+func (p *Peer) addError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.errors = append(p.errors, err)
+}
+
+// peerClient records the errors of sending the async answers.
+type peerClient struct {
+	backend.Client
+	peer *Peer
+}
+
+func (c *peerClient) SendAnswer(ctx context.Context, ans backend.Answer) error {
+	if err := c.Client.SendAnswer(ctx, ans); err != nil {
+		c.peer.addError(fmt.Errorf("send answer error: %w", err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package backendtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/backend"
+)
+
+func TestPeerSync(t *testing.T) {
+	assert := require.New(t)
+
+	peer := NewPeer(PeerConfig{})
+	defer peer.Close()
+
+	devEUI := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	assert.NoError(peer.Handle(backend.PRStartReq, func(ctx context.Context, req backend.Request) (backend.Answer, error) {
+		pl := req.(backend.PRStartReqPayload)
+
+		return backend.PRStartAnsPayload{
+			BasePayloadResult: NewBasePayloadResult(pl.BasePayload, backend.Success, ""),
+			DevEUI:            &devEUI,
+		}, nil
+	}))
+
+	client, err := backend.NewClient(backend.ClientConfig{
+		SenderID:   "010101",
+		ReceiverID: "020202",
+		Server:     peer.URL(),
+	})
+	assert.NoError(err)
+
+	ans, err := client.PRStartReq(context.Background(), backend.PRStartReqPayload{
+		BasePayload: backend.BasePayload{TransactionID: 123},
+	})
+	assert.NoError(err)
+	assert.Equal(&devEUI, ans.DevEUI)
+	assert.Equal(backend.BasePayload{
+		ProtocolVersion: backend.ProtocolVersion1_0,
+		SenderID:        "020202",
+		ReceiverID:      "010101",
+		TransactionID:   123,
+		MessageType:     backend.PRStartAns,
+	}, ans.BasePayload)
+
+	// no handler registered
+	_, err = client.PRStopReq(context.Background(), backend.PRStopReqPayload{})
+	assert.EqualError(err, "response error, code: MalformedRequest, description: unexpected MessageType: PRStopReq")
+
+	// no handler registered, protocol version 1.1 is answered with
+	// MalformedMessage, after which the client falls back to 1.0
+	client, err = backend.NewClient(backend.ClientConfig{
+		SenderID:        "010101",
+		ReceiverID:      "020202",
+		Server:          peer.URL(),
+		ProtocolVersion: backend.ProtocolVersion1_1,
+	})
+	assert.NoError(err)
+
+	_, err = client.PRStopReq(context.Background(), backend.PRStopReqPayload{})
+	assert.EqualError(err, "response error, code: MalformedRequest, description: unexpected MessageType: PRStopReq")
+
+	requests := peer.Requests()
+	assert.Len(requests, 4)
+	assert.Equal(backend.ProtocolVersion1_1, requests[2].ProtocolVersion)
+	assert.Equal(backend.ProtocolVersion1_0, requests[3].ProtocolVersion)
+	assert.Len(peer.Errors(), 0)
+}
+
+func TestPeerAsync(t *testing.T) {
+	assert := require.New(t)
+
+	answers := make(chan backend.BasePayloadResult, 1)
+	requester := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var pl backend.BasePayloadResult
+		if err := json.NewDecoder(r.Body).Decode(&pl); err != nil {
+			t.Error(err)
+		}
+		answers <- pl
+	}))
+	defer requester.Close()
+
+	peer := NewPeer(PeerConfig{AsyncAnswerServer: requester.URL})
+	assert.NoError(peer.Handle(backend.XmitDataReq, func(ctx context.Context, req backend.Request) (backend.Answer, error) {
+		return backend.XmitDataAnsPayload{
+			BasePayloadResult: NewBasePayloadResult(req.GetBasePayload(), backend.Success, ""),
+		}, nil
+	}))
+
+	b, err := json.Marshal(backend.XmitDataReqPayload{
+		BasePayload: backend.BasePayload{
+			ProtocolVersion: backend.ProtocolVersion1_0,
+			SenderID:        "010101",
+			ReceiverID:      "020202",
+			TransactionID:   1234,
+			MessageType:     backend.XmitDataReq,
+		},
+	})
+	assert.NoError(err)
+
+	resp, err := http.Post(peer.URL(), "application/json", bytes.NewReader(b))
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+
+	select {
+	case ans := <-answers:
+		assert.Equal(uint32(1234), ans.TransactionID)
+		assert.Equal(backend.XmitDataAns, ans.MessageType)
+		assert.Equal("020202", ans.SenderID)
+		assert.Equal(backend.Success, ans.Result.ResultCode)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for async answer")
+	}
+
+	peer.Close()
+	assert.Len(peer.Errors(), 0)
+}
@@ -0,0 +1,309 @@
+package backendtest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/brocaar/lorawan/backend"
+)
+
+// Exchange holds a recorded request / answer exchange.
+type Exchange struct {
+	// MessageType holds the message-type of the request.
+	MessageType backend.MessageType `json:"messageType"`
+
+	// Request holds the request (JSON).
+	Request json.RawMessage `json:"request"`
+
+	// Answer holds the answer (JSON). This is empty when no answer was
+	// received.
+	Answer json.RawMessage `json:"answer,omitempty"`
+}
+
+// Recorder records exchanges to a writer, one JSON encoded Exchange per
+// line. Recorded sessions can be read using ReadExchanges and replayed using
+// Replay (as client) or Peer.Replay (as server stub).
+//
+// The Recorder is safe for concurrent use.
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewRecorder creates a new Recorder writing to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{
+		enc: json.NewEncoder(w),
+	}
+}
+
+// Record records the given request and answer. The answer can be nil.
+func (r *Recorder) Record(req backend.Request, ans backend.Answer) error {
+	reqB, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("backendtest: marshal request error: %w", err)
+	}
+
+	var ansB []byte
+	if ans != nil {
+		ansB, err = json.Marshal(ans)
+		if err != nil {
+			return fmt.Errorf("backendtest: marshal answer error: %w", err)
+		}
+	}
+
+	return r.write(Exchange{
+		MessageType: req.GetBasePayload().MessageType,
+		Request:     reqB,
+		Answer:      ansB,
+	})
+}
+
+// CaptureFunc returns a backend.CaptureFunc which records the exchanges of
+// a backend.Client, e.g.:
+//
+//	client, err := backend.NewClient(backend.ClientConfig{
+//		...
+//		CaptureFunc: recorder.CaptureFunc(),
+//	})
+//
+// Only exchanges containing a request are recorded. Answers sent using
+// SendAnswer are not recorded.
+func (r *Recorder) CaptureFunc() backend.CaptureFunc {
+	return func(c backend.Capture) {
+		e, ok := exchangeFromCapture(c)
+		if !ok {
+			return
+		}
+		_ = r.write(e)
+	}
+}
+
+func (r *Recorder) write(e Exchange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.enc.Encode(e); err != nil {
+		return fmt.Errorf("backendtest: write exchange error: %w", err)
+	}
+	return nil
+}
+
+// ReadExchanges reads the exchanges as written by the Recorder.
+func ReadExchanges(r io.Reader) ([]Exchange, error) {
+	var out []Exchange
+	dec := json.NewDecoder(r)
+
+	for {
+		var e Exchange
+		if err := dec.Decode(&e); err != nil {
+			if err == io.EOF {
+				return out, nil
+			}
+			return nil, fmt.Errorf("backendtest: read exchange %d error: %w", len(out), err)
+		}
+		out = append(out, e)
+	}
+}
+
+// Replay replays the given exchanges as client, by sending the recorded
+// requests to the given server in order and comparing the answers with the
+// recorded answers. The TransactionID is ignored when comparing. An error
+// is returned for the first mismatching answer. Replay only supports the
+// sync protocol scheme.
+func Replay(ctx context.Context, httpClient *http.Client, server string, exchanges []Exchange) error {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	for i, e := range exchanges {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, server, bytes.NewReader(e.Request))
+		if err != nil {
+			return fmt.Errorf("backendtest: exchange %d (%s): new request error: %w", i, e.MessageType, err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("backendtest: exchange %d (%s): http request error: %w", i, e.MessageType, err)
+		}
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("backendtest: exchange %d (%s): read body error: %w", i, e.MessageType, err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("backendtest: exchange %d (%s): expected 200, got: %d (%s)", i, e.MessageType, resp.StatusCode, bytes.TrimSpace(b))
+		}
+
+		if len(e.Answer) == 0 {
+			continue
+		}
+
+		equal, err := jsonEqual(e.Answer, b)
+		if err != nil {
+			return fmt.Errorf("backendtest: exchange %d (%s): compare answer error: %w", i, e.MessageType, err)
+		}
+		if !equal {
+			return fmt.Errorf("backendtest: exchange %d (%s): answer mismatch, expected: %s, got: %s", i, e.MessageType, e.Answer, bytes.TrimSpace(b))
+		}
+	}
+
+	return nil
+}
+
+// Replay configures the Peer as server stub for the given exchanges. For
+// every request message-type, the recorded answers are returned in order,
+// with the TransactionID of the received request. Received requests which
+// do not match the recorded request (ignoring the TransactionID), or for
+// which no recorded exchange is left, fail and are reported by Errors.
+// Exchanges without answer are skipped.
+func (p *Peer) Replay(exchanges []Exchange) {
+	var mu sync.Mutex
+	queues := make(map[backend.MessageType][]Exchange)
+	for _, e := range exchanges {
+		if len(e.Answer) == 0 {
+			continue
+		}
+		queues[e.MessageType] = append(queues[e.MessageType], e)
+	}
+
+	for mt := range queues {
+		mt := mt
+		p.Handle(mt, func(ctx context.Context, req backend.Request) (backend.Answer, error) {
+			mu.Lock()
+			queue := queues[mt]
+			if len(queue) == 0 {
+				mu.Unlock()
+				return nil, fmt.Errorf("backendtest: no recorded exchange left for %s", mt)
+			}
+			e := queue[0]
+			queues[mt] = queue[1:]
+			mu.Unlock()
+
+			body, err := json.Marshal(req)
+			if err != nil {
+				return nil, fmt.Errorf("backendtest: marshal request error: %w", err)
+			}
+
+			equal, err := jsonEqual(e.Request, body)
+			if err != nil {
+				return nil, fmt.Errorf("backendtest: compare request error: %w", err)
+			}
+			if !equal {
+				return nil, fmt.Errorf("backendtest: request mismatch (%s), expected: %s, got: %s", mt, e.Request, body)
+			}
+
+			return newReplayAnswer(e.Answer, req.GetBasePayload().TransactionID)
+		})
+	}
+}
+
+// replayAnswer implements backend.Answer for a recorded answer.
+type replayAnswer struct {
+	raw  json.RawMessage
+	base backend.BasePayloadResult
+}
+
+func newReplayAnswer(b []byte, transactionID uint32) (replayAnswer, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return replayAnswer{}, fmt.Errorf("backendtest: unmarshal answer error: %w", err)
+	}
+	m["TransactionID"] = transactionID
+
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return replayAnswer{}, fmt.Errorf("backendtest: marshal answer error: %w", err)
+	}
+
+	ans := replayAnswer{raw: raw}
+	if err := json.Unmarshal(raw, &ans.base); err != nil {
+		return replayAnswer{}, fmt.Errorf("backendtest: unmarshal answer error: %w", err)
+	}
+
+	return ans, nil
+}
+
+// GetBasePayload implements backend.Answer.
+func (a replayAnswer) GetBasePayload() backend.BasePayloadResult {
+	return a.base
+}
+
+// MarshalJSON returns the recorded answer.
+func (a replayAnswer) MarshalJSON() ([]byte, error) {
+	return a.raw, nil
+}
+
+// exchangeFromCapture returns the Exchange for the given capture. It returns
+// false when the capture does not contain a request.
+func exchangeFromCapture(c backend.Capture) (Exchange, bool) {
+	if len(c.Request) == 0 {
+		return Exchange{}, false
+	}
+
+	req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(c.Request)))
+	if err != nil {
+		return Exchange{}, false
+	}
+	reqB, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return Exchange{}, false
+	}
+
+	var base backend.BasePayload
+	if err := json.Unmarshal(reqB, &base); err != nil {
+		return Exchange{}, false
+	}
+
+	// answers sent by SendAnswer
+	if !strings.HasSuffix(string(base.MessageType), "Req") {
+		return Exchange{}, false
+	}
+
+	e := Exchange{
+		MessageType: base.MessageType,
+		Request:     bytes.TrimSpace(reqB),
+	}
+
+	if len(c.AsyncAnswer) != 0 {
+		e.Answer = bytes.TrimSpace(c.AsyncAnswer)
+	} else if len(c.Response) != 0 {
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(c.Response)), req)
+		if err == nil {
+			b, err := ioutil.ReadAll(resp.Body)
+			if err == nil && len(bytes.TrimSpace(b)) != 0 {
+				e.Answer = bytes.TrimSpace(b)
+			}
+		}
+	}
+
+	return e, true
+}
+
+// jsonEqual returns true when a and b hold the same JSON document, ignoring
+// the TransactionID.
+func jsonEqual(a, b []byte) (bool, error) {
+	var am, bm map[string]interface{}
+	if err := json.Unmarshal(a, &am); err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(b, &bm); err != nil {
+		return false, err
+	}
+
+	delete(am, "TransactionID")
+	delete(bm, "TransactionID")
+
+	return reflect.DeepEqual(am, bm), nil
+}
@@ -0,0 +1,151 @@
+package backendtest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/backend"
+)
+
+func TestRecordReplay(t *testing.T) {
+	assert := require.New(t)
+
+	devEUI := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	peer := NewPeer(PeerConfig{})
+	defer peer.Close()
+	assert.NoError(peer.Handle(backend.PRStartReq, func(ctx context.Context, req backend.Request) (backend.Answer, error) {
+		return backend.PRStartAnsPayload{
+			BasePayloadResult: NewBasePayloadResult(req.GetBasePayload(), backend.Success, ""),
+			DevEUI:            &devEUI,
+		}, nil
+	}))
+
+	// record the session
+	var buf bytes.Buffer
+	recorder := NewRecorder(&buf)
+	client, err := backend.NewClient(backend.ClientConfig{
+		SenderID:    "010101",
+		ReceiverID:  "020202",
+		Server:      peer.URL(),
+		CaptureFunc: recorder.CaptureFunc(),
+	})
+	assert.NoError(err)
+
+	_, err = client.PRStartReq(context.Background(), backend.PRStartReqPayload{
+		BasePayload: backend.BasePayload{TransactionID: 1},
+		PHYPayload:  backend.HEXBytes{1, 2, 3},
+	})
+	assert.NoError(err)
+	_, err = client.PRStopReq(context.Background(), backend.PRStopReqPayload{
+		BasePayload: backend.BasePayload{TransactionID: 2},
+	})
+	assert.Error(err)
+
+	exchanges, err := ReadExchanges(&buf)
+	assert.NoError(err)
+	assert.Len(exchanges, 2)
+	assert.Equal(backend.PRStartReq, exchanges[0].MessageType)
+	assert.Equal(backend.PRStopReq, exchanges[1].MessageType)
+	assert.NotEmpty(exchanges[0].Answer)
+	assert.NotEmpty(exchanges[1].Answer)
+
+	t.Run("replay as client", func(t *testing.T) {
+		assert := require.New(t)
+		assert.NoError(Replay(context.Background(), nil, peer.URL(), exchanges))
+
+		// the answer of the peer has changed
+		otherPeer := NewPeer(PeerConfig{})
+		defer otherPeer.Close()
+
+		err := Replay(context.Background(), nil, otherPeer.URL(), exchanges)
+		assert.Error(err)
+		assert.Contains(err.Error(), "backendtest: exchange 0 (PRStartReq): answer mismatch")
+	})
+
+	t.Run("replay as server stub", func(t *testing.T) {
+		assert := require.New(t)
+
+		stub := NewPeer(PeerConfig{})
+		defer stub.Close()
+		stub.Replay(exchanges)
+
+		client, err := backend.NewClient(backend.ClientConfig{
+			SenderID:   "010101",
+			ReceiverID: "020202",
+			Server:     stub.URL(),
+		})
+		assert.NoError(err)
+
+		ans, err := client.PRStartReq(context.Background(), backend.PRStartReqPayload{
+			BasePayload: backend.BasePayload{TransactionID: 10},
+			PHYPayload:  backend.HEXBytes{1, 2, 3},
+		})
+		assert.NoError(err)
+		assert.Equal(uint32(10), ans.TransactionID)
+		assert.Equal(&devEUI, ans.DevEUI)
+
+		_, err = client.PRStopReq(context.Background(), backend.PRStopReqPayload{
+			BasePayload: backend.BasePayload{TransactionID: 11},
+		})
+		assert.EqualError(err, "response error, code: MalformedRequest, description: unexpected MessageType: PRStopReq")
+
+		// no recorded exchange left
+		_, err = client.PRStartReq(context.Background(), backend.PRStartReqPayload{
+			BasePayload: backend.BasePayload{TransactionID: 12},
+			PHYPayload:  backend.HEXBytes{1, 2, 3},
+		})
+		assert.Error(err)
+		assert.Len(stub.Errors(), 1)
+		assert.EqualError(stub.Errors()[0], "backendtest: no recorded exchange left for PRStartReq")
+	})
+
+	t.Run("replay as server stub with request mismatch", func(t *testing.T) {
+		assert := require.New(t)
+
+		stub := NewPeer(PeerConfig{})
+		defer stub.Close()
+		stub.Replay(exchanges)
+
+		client, err := backend.NewClient(backend.ClientConfig{
+			SenderID:   "010101",
+			ReceiverID: "020202",
+			Server:     stub.URL(),
+		})
+		assert.NoError(err)
+
+		_, err = client.PRStartReq(context.Background(), backend.PRStartReqPayload{
+			PHYPayload: backend.HEXBytes{3, 2, 1},
+		})
+		assert.Error(err)
+		assert.Len(stub.Errors(), 1)
+		assert.Contains(stub.Errors()[0].Error(), "backendtest: request mismatch (PRStartReq)")
+	})
+}
+
+func TestRecorderRecord(t *testing.T) {
+	assert := require.New(t)
+
+	var buf bytes.Buffer
+	recorder := NewRecorder(&buf)
+
+	req := backend.ProfileReqPayload{
+		BasePayload: backend.BasePayload{
+			MessageType:   backend.ProfileReq,
+			TransactionID: 1,
+		},
+	}
+	assert.NoError(recorder.Record(req, nil))
+
+	exchanges, err := ReadExchanges(&buf)
+	assert.NoError(err)
+	assert.Len(exchanges, 1)
+	assert.Equal(backend.ProfileReq, exchanges[0].MessageType)
+	assert.Empty(exchanges[0].Answer)
+
+	_, err = ReadExchanges(bytes.NewBufferString("{invalid"))
+	assert.Error(err)
+}
@@ -0,0 +1,69 @@
+package backend
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBatchConcurrency defines the default number of concurrent requests
+// used by XmitDataReqBatch.
+const DefaultBatchConcurrency = 8
+
+// XmitDataResult holds the result of a single XmitDataReq of a batch.
+type XmitDataResult struct {
+	// Answer holds the XmitDataAns (when received).
+	Answer XmitDataAnsPayload
+
+	// Err holds the request error or the error of a non-Success result.
+	Err error
+}
+
+// XmitDataReqBatch sends the given XmitDataReq payloads to the peer of the
+// given client, using (at most) concurrency concurrent requests. When
+// concurrency is 0, DefaultBatchConcurrency is used. The returned results
+// are in the same order as the given payloads. This is intended for bulk
+// downlink scheduling, e.g. a multicast FUOTA campaign.
+//
+// Concurrent requests are pipelined over the keep-alive connections of the
+// client, see ClientConfig.MaxIdleConnsPerHost. When the context is
+// cancelled, the payloads which have not been sent yet are returned with
+// the context error.
+func XmitDataReqBatch(ctx context.Context, client Client, pls []XmitDataReqPayload, concurrency int) []XmitDataResult {
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	if concurrency > len(pls) {
+		concurrency = len(pls)
+	}
+
+	results := make([]XmitDataResult, len(pls))
+	indices := make(chan int)
+
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				results[i].Answer, results[i].Err = client.XmitDataReq(ctx, pls[i])
+			}
+		}()
+	}
+
+	for i := range pls {
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		select {
+		case indices <- i:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+		}
+	}
+	close(indices)
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,98 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestXmitDataReqBatch(t *testing.T) {
+	assert := require.New(t)
+
+	var conns int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req XmitDataReqPayload
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// the FRMPayload holds the item index, odd items fail
+		freq := float64(req.FRMPayload[0])
+		ans := XmitDataAnsPayload{
+			BasePayloadResult: BasePayloadResult{
+				BasePayload: BasePayload{
+					ProtocolVersion: req.ProtocolVersion,
+					SenderID:        req.ReceiverID,
+					ReceiverID:      req.SenderID,
+					TransactionID:   req.TransactionID,
+					MessageType:     XmitDataAns,
+				},
+				Result: Result{ResultCode: Success},
+			},
+			DLFreq1: &freq,
+		}
+		if req.FRMPayload[0]%2 == 1 {
+			ans.Result.ResultCode = UnknownDevEUI
+		}
+
+		json.NewEncoder(w).Encode(ans)
+	}))
+	server.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	c, err := NewClient(ClientConfig{
+		SenderID:            "010203",
+		ReceiverID:          "030201",
+		Server:              server.URL,
+		MaxIdleConnsPerHost: 4,
+	})
+	assert.NoError(err)
+
+	var pls []XmitDataReqPayload
+	for i := 0; i < 100; i++ {
+		pls = append(pls, XmitDataReqPayload{
+			FRMPayload: HEXBytes{byte(i)},
+		})
+	}
+
+	results := XmitDataReqBatch(context.Background(), c, pls, 4)
+	assert.Len(results, len(pls))
+
+	for i, res := range results {
+		if i%2 == 1 {
+			assert.Error(res.Err)
+			assert.Equal(UnknownDevEUI, res.Answer.Result.ResultCode)
+		} else {
+			assert.NoError(res.Err)
+			assert.Equal(float64(i), *res.Answer.DLFreq1)
+		}
+	}
+
+	// connections are re-used
+	assert.True(atomic.LoadInt32(&conns) <= 4)
+}
+
+func TestXmitDataReqBatchCancelled(t *testing.T) {
+	assert := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := XmitDataReqBatch(ctx, nil, make([]XmitDataReqPayload, 3), 0)
+	assert.Len(results, 3)
+	for _, res := range results {
+		assert.Equal(context.Canceled, res.Err)
+	}
+}
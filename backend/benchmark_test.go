@@ -0,0 +1,189 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+
+	"github.com/brocaar/lorawan"
+)
+
+func benchmarkXmitDataReqPayload() XmitDataReqPayload {
+	devAddr := lorawan.DevAddr{1, 2, 3, 4}
+	dr := 5
+	freq := 868.1
+	gwCnt := 2
+	rssi := -60
+	snr := 5.5
+
+	return XmitDataReqPayload{
+		BasePayload: BasePayload{
+			ProtocolVersion: ProtocolVersion1_0,
+			SenderID:        "010101",
+			ReceiverID:      "020202",
+			TransactionID:   1234,
+			MessageType:     XmitDataReq,
+		},
+		PHYPayload: HEXBytes{0x40, 0x04, 0x03, 0x02, 0x01, 0x80, 0x01, 0x00, 0x01, 0xa6, 0x94, 0x64, 0x26, 0x15, 0xd6, 0xc3, 0xb5, 0x82},
+		ULMetaData: &ULMetaData{
+			DevAddr:  &devAddr,
+			DataRate: &dr,
+			ULFreq:   &freq,
+			RecvTime: ISO8601Time(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+			RFRegion: "EU868",
+			GWCnt:    &gwCnt,
+			GWInfo: []GWInfoElement{
+				{ID: HEXBytes{1, 2, 3, 4, 5, 6, 7, 8}, RSSI: &rssi, SNR: &snr, ULToken: HEXBytes{1, 2, 3}},
+				{ID: HEXBytes{8, 7, 6, 5, 4, 3, 2, 1}, RSSI: &rssi, SNR: &snr, ULToken: HEXBytes{3, 2, 1}},
+			},
+		},
+	}
+}
+
+func BenchmarkXmitDataReqMarshalJSON(b *testing.B) {
+	pl := benchmarkXmitDataReqPayload()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(pl); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkXmitDataReqUnmarshalJSON(b *testing.B) {
+	data, err := json.Marshal(benchmarkXmitDataReqPayload())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var pl XmitDataReqPayload
+		if err := json.Unmarshal(data, &pl); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTransactionManager(b *testing.B) {
+	m := NewTransactionManager(0, nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		tx, err := m.Allocate()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, ok := m.Get(tx.TransactionID); !ok {
+			b.Fatal("transaction not found")
+		}
+		m.Release(tx.TransactionID)
+	}
+}
+
+func newBenchmarkAnswer(r *http.Request) (XmitDataAnsPayload, error) {
+	var req BasePayload
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return XmitDataAnsPayload{}, err
+	}
+
+	return XmitDataAnsPayload{
+		BasePayloadResult: BasePayloadResult{
+			BasePayload: BasePayload{
+				ProtocolVersion: req.ProtocolVersion,
+				SenderID:        req.ReceiverID,
+				ReceiverID:      req.SenderID,
+				TransactionID:   req.TransactionID,
+				MessageType:     XmitDataAns,
+			},
+			Result: Result{ResultCode: Success},
+		},
+	}, nil
+}
+
+func BenchmarkClientSync(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ans, err := newBenchmarkAnswer(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(ans)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{
+		SenderID:   "010101",
+		ReceiverID: "020202",
+		Server:     server.URL,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	pl := benchmarkXmitDataReqPayload()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		pl.TransactionID = 0
+		if _, err := client.XmitDataReq(context.Background(), pl); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkClientAsync benchmarks the async request / answer correlation
+// over Redis. It is skipped when Redis is not available.
+func BenchmarkClientAsync(b *testing.B) {
+	redisClient := redis.NewClient(&redis.Options{
+		Addr: "redis:6379",
+	})
+	defer redisClient.Close()
+	if err := redisClient.Ping().Err(); err != nil {
+		b.Skipf("redis is not available: %s", err)
+	}
+
+	var client Client
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ans, err := newBenchmarkAnswer(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		go client.HandleAnswer(context.Background(), ans)
+	}))
+	defer server.Close()
+
+	var err error
+	client, err = NewClient(ClientConfig{
+		SenderID:     "010101",
+		ReceiverID:   "020202",
+		Server:       server.URL,
+		RedisClient:  redisClient,
+		AsyncTimeout: time.Second,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	pl := benchmarkXmitDataReqPayload()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		pl.TransactionID = 0
+		if _, err := client.XmitDataReq(context.Background(), pl); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package backend
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Capability errors.
+var (
+	ErrNoCommonProtocolVersion = errors.New("no common protocol version")
+	ErrUnsupportedMessageType  = errors.New("message-type is not supported by peer")
+	ErrPeerRequiresAsync       = errors.New("peer requires the async protocol scheme")
+)
+
+// AsyncEmptyAnswerThreshold defines the number of consecutive empty answers
+// to synchronous requests after which a peer is assumed to use the async
+// protocol scheme (see CapabilityStore.RecordEmptyAnswer).
+const AsyncEmptyAnswerThreshold = 3
+
+// PeerCapabilities holds the (known) capabilities of a Backend Interfaces
+// peer. These can be configured up-front or learned from prior answers of
+// the peer (see CapabilityStore).
+type PeerCapabilities struct {
+	// ProtocolVersions holds the protocol versions supported by the peer.
+	// When empty, the supported versions are unknown.
+	ProtocolVersions []string
+
+	// RejectedProtocolVersions holds the protocol versions which have been
+	// rejected by the peer with an InvalidProtocolVersion result.
+	RejectedProtocolVersions []string
+
+	// MessageTypes holds the (request) message-types supported by the peer.
+	// When empty, all message-types are assumed to be supported.
+	MessageTypes []MessageType
+
+	// MessageTypeProtocolVersions holds the protocol version to use by
+	// message-type, overriding the negotiated version. This is learned
+	// when the peer answers a message-type with a malformed result for a
+	// version it otherwise supports.
+	MessageTypeProtocolVersions map[MessageType]string
+
+	// Async indicates that the peer uses the async protocol scheme, meaning
+	// that the answer is not returned in the HTTP response body. This is
+	// either configured or inferred from repeated empty answers.
+	Async bool
+}
+
+// SupportsMessageType returns true when the given message-type is supported
+// by the peer or when the supported message-types are unknown.
+func (p PeerCapabilities) SupportsMessageType(mt MessageType) bool {
+	if len(p.MessageTypes) == 0 {
+		return true
+	}
+	for _, m := range p.MessageTypes {
+		if m == mt {
+			return true
+		}
+	}
+	return false
+}
+
+// NegotiateProtocolVersion returns the highest protocol version of the
+// given local versions which is supported by the peer. When the supported
+// versions of the peer are unknown, the highest local version which has
+// not been rejected by the peer is returned.
+func (p PeerCapabilities) NegotiateProtocolVersion(local []string) (string, error) {
+	var out string
+	for _, v := range local {
+		if containsString(p.RejectedProtocolVersions, v) {
+			continue
+		}
+		if len(p.ProtocolVersions) != 0 && !containsString(p.ProtocolVersions, v) {
+			continue
+		}
+		if out == "" || compareProtocolVersion(v, out) > 0 {
+			out = v
+		}
+	}
+
+	if out == "" {
+		return "", ErrNoCommonProtocolVersion
+	}
+	return out, nil
+}
+
+// MessageTypeProtocolVersion returns the protocol version to use for the
+// given message-type. This is the version learned for the message-type,
+// when it is one of the given local versions, else the negotiated version.
+func (p PeerCapabilities) MessageTypeProtocolVersion(mt MessageType, local []string) (string, error) {
+	if v, ok := p.MessageTypeProtocolVersions[mt]; ok && containsString(local, v) && !containsString(p.RejectedProtocolVersions, v) {
+		return v, nil
+	}
+	return p.NegotiateProtocolVersion(local)
+}
+
+func (p PeerCapabilities) clone() PeerCapabilities {
+	out := PeerCapabilities{
+		ProtocolVersions:         append([]string(nil), p.ProtocolVersions...),
+		RejectedProtocolVersions: append([]string(nil), p.RejectedProtocolVersions...),
+		MessageTypes:             append([]MessageType(nil), p.MessageTypes...),
+		Async:                    p.Async,
+	}
+	if p.MessageTypeProtocolVersions != nil {
+		out.MessageTypeProtocolVersions = make(map[MessageType]string, len(p.MessageTypeProtocolVersions))
+		for k, v := range p.MessageTypeProtocolVersions {
+			out.MessageTypeProtocolVersions[k] = v
+		}
+	}
+	return out
+}
+
+// CapabilityStore holds the PeerCapabilities by peer ID (e.g. the NetID or
+// JoinEUI used as ReceiverID). Multiple clients can share the same store.
+//
+// The CapabilityStore is safe for concurrent use.
+type CapabilityStore struct {
+	mu           sync.RWMutex
+	peers        map[string]PeerCapabilities
+	emptyAnswers map[string]int
+}
+
+// NewCapabilityStore creates a new CapabilityStore.
+func NewCapabilityStore() *CapabilityStore {
+	return &CapabilityStore{
+		peers:        make(map[string]PeerCapabilities),
+		emptyAnswers: make(map[string]int),
+	}
+}
+
+// Set sets the capabilities of the given peer, e.g. from configuration.
+func (s *CapabilityStore) Set(peerID string, caps PeerCapabilities) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.peers[peerID] = caps.clone()
+}
+
+// Get returns the capabilities of the given peer. The returned bool
+// indicates if the peer is known.
+func (s *CapabilityStore) Get(peerID string) (PeerCapabilities, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	caps, ok := s.peers[peerID]
+	return caps.clone(), ok
+}
+
+// Record updates the capabilities of the given peer, given the protocol
+// version used for the request and the result of the answer. An
+// InvalidProtocolVersion result marks the version as rejected, any other
+// result marks the version as supported.
+func (s *CapabilityStore) Record(peerID, protocolVersion string, result ResultCode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	caps := s.peers[peerID]
+	if result == InvalidProtocolVersion {
+		caps.ProtocolVersions = removeString(caps.ProtocolVersions, protocolVersion)
+		if !containsString(caps.RejectedProtocolVersions, protocolVersion) {
+			caps.RejectedProtocolVersions = append(caps.RejectedProtocolVersions, protocolVersion)
+		}
+	} else {
+		caps.RejectedProtocolVersions = removeString(caps.RejectedProtocolVersions, protocolVersion)
+		if !containsString(caps.ProtocolVersions, protocolVersion) {
+			caps.ProtocolVersions = append(caps.ProtocolVersions, protocolVersion)
+		}
+	}
+	s.peers[peerID] = caps
+}
+
+// RecordMessageType records the protocol version to use for the given
+// message-type of the given peer.
+func (s *CapabilityStore) RecordMessageType(peerID string, mt MessageType, protocolVersion string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	caps := s.peers[peerID]
+	if caps.MessageTypeProtocolVersions == nil {
+		caps.MessageTypeProtocolVersions = make(map[MessageType]string)
+	}
+	caps.MessageTypeProtocolVersions[mt] = protocolVersion
+	s.peers[peerID] = caps
+}
+
+// RecordEmptyAnswer records that the given peer accepted a synchronous
+// request without returning the answer in the response body. The peer is
+// only marked as using the async protocol scheme after
+// AsyncEmptyAnswerThreshold consecutive empty answers, as a single empty
+// response might as well be caused by a misbehaving proxy or peer.
+func (s *CapabilityStore) RecordEmptyAnswer(peerID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.emptyAnswers[peerID]++
+	if s.emptyAnswers[peerID] < AsyncEmptyAnswerThreshold {
+		return
+	}
+
+	caps := s.peers[peerID]
+	caps.Async = true
+	s.peers[peerID] = caps
+}
+
+// ResetAsync resets the async protocol scheme of the given peer, e.g. after
+// the peer has been re-configured, and the recorded empty answers.
+func (s *CapabilityStore) ResetAsync(peerID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.emptyAnswers, peerID)
+	if caps, ok := s.peers[peerID]; ok {
+		caps.Async = false
+		s.peers[peerID] = caps
+	}
+}
+
+// compareProtocolVersion compares the two (major.minor) protocol versions.
+// It returns -1 when a < b, 0 when a == b and 1 when a > b.
+func compareProtocolVersion(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+
+		if x < y {
+			return -1
+		}
+		if x > y {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(list []string, s string) []string {
+	var out []string
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
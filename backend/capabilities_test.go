@@ -0,0 +1,386 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateProtocolVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		caps     PeerCapabilities
+		local    []string
+		expected string
+		err      error
+	}{
+		{
+			name:     "unknown peer",
+			local:    []string{ProtocolVersion1_0, ProtocolVersion1_1},
+			expected: ProtocolVersion1_1,
+		},
+		{
+			name:     "common version",
+			caps:     PeerCapabilities{ProtocolVersions: []string{ProtocolVersion1_0}},
+			local:    []string{ProtocolVersion1_1, ProtocolVersion1_0},
+			expected: ProtocolVersion1_0,
+		},
+		{
+			name:     "rejected version",
+			caps:     PeerCapabilities{RejectedProtocolVersions: []string{ProtocolVersion1_1}},
+			local:    []string{ProtocolVersion1_0, ProtocolVersion1_1},
+			expected: ProtocolVersion1_0,
+		},
+		{
+			name:  "no common version",
+			caps:  PeerCapabilities{ProtocolVersions: []string{ProtocolVersion1_1}},
+			local: []string{ProtocolVersion1_0},
+			err:   ErrNoCommonProtocolVersion,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			assert := require.New(t)
+			v, err := tst.caps.NegotiateProtocolVersion(tst.local)
+			assert.Equal(tst.err, err)
+			assert.Equal(tst.expected, v)
+		})
+	}
+}
+
+func TestCompareProtocolVersion(t *testing.T) {
+	assert := require.New(t)
+	assert.Equal(-1, compareProtocolVersion("1.0", "1.1"))
+	assert.Equal(0, compareProtocolVersion("1.1", "1.1"))
+	assert.Equal(1, compareProtocolVersion("1.10", "1.9"))
+	assert.Equal(1, compareProtocolVersion("2", "1.1"))
+}
+
+func TestCapabilityStore(t *testing.T) {
+	assert := require.New(t)
+	s := NewCapabilityStore()
+
+	_, ok := s.Get("010203")
+	assert.False(ok)
+
+	s.Set("010203", PeerCapabilities{MessageTypes: []MessageType{JoinReq}})
+	caps, ok := s.Get("010203")
+	assert.True(ok)
+	assert.True(caps.SupportsMessageType(JoinReq))
+	assert.False(caps.SupportsMessageType(PRStartReq))
+
+	s.Record("010203", ProtocolVersion1_1, InvalidProtocolVersion)
+	s.Record("010203", ProtocolVersion1_0, Success)
+	for i := 0; i < AsyncEmptyAnswerThreshold; i++ {
+		caps, _ = s.Get("010203")
+		assert.False(caps.Async)
+		s.RecordEmptyAnswer("010203")
+	}
+	caps, _ = s.Get("010203")
+	assert.Equal(PeerCapabilities{
+		ProtocolVersions:         []string{ProtocolVersion1_0},
+		RejectedProtocolVersions: []string{ProtocolVersion1_1},
+		MessageTypes:             []MessageType{JoinReq},
+		Async:                    true,
+	}, caps)
+
+	s.ResetAsync("010203")
+	caps, _ = s.Get("010203")
+	assert.False(caps.Async)
+
+	// a version supported later on (e.g. after a peer upgrade)
+	s.Record("010203", ProtocolVersion1_1, Success)
+	caps, _ = s.Get("010203")
+	assert.Equal([]string{ProtocolVersion1_0, ProtocolVersion1_1}, caps.ProtocolVersions)
+	assert.Len(caps.RejectedProtocolVersions, 0)
+}
+
+// newVersionedPeer returns a sync peer supporting the given protocol
+// versions. It answers an unsupported version with InvalidProtocolVersion
+// and records the versions of the received requests.
+func newVersionedPeer(versions []string, received *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req BasePayload
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		*received = append(*received, req.ProtocolVersion)
+
+		ans := HomeNSAnsPayload{
+			BasePayloadResult: BasePayloadResult{
+				BasePayload: BasePayload{
+					ProtocolVersion: req.ProtocolVersion,
+					SenderID:        req.ReceiverID,
+					ReceiverID:      req.SenderID,
+					TransactionID:   req.TransactionID,
+					MessageType:     HomeNSAns,
+				},
+				Result: Result{ResultCode: InvalidProtocolVersion},
+			},
+		}
+		if containsString(versions, req.ProtocolVersion) {
+			ans.Result.ResultCode = Success
+		}
+
+		json.NewEncoder(w).Encode(ans)
+	}))
+}
+
+func TestClientInteropMatrix(t *testing.T) {
+	v10 := []string{ProtocolVersion1_0}
+	v11 := []string{ProtocolVersion1_1}
+	both := []string{ProtocolVersion1_0, ProtocolVersion1_1}
+
+	tests := []struct {
+		client []string
+		peer   []string
+
+		// expected protocol versions of the received requests
+		expected []string
+		// expected result of the first request
+		firstErr bool
+	}{
+		{client: v10, peer: v10, expected: []string{"1.0", "1.0"}},
+		{client: v10, peer: v11, expected: []string{"1.0"}, firstErr: true},
+		{client: v10, peer: both, expected: []string{"1.0", "1.0"}},
+		{client: v11, peer: v10, expected: []string{"1.1"}, firstErr: true},
+		{client: v11, peer: v11, expected: []string{"1.1", "1.1"}},
+		{client: v11, peer: both, expected: []string{"1.1", "1.1"}},
+		{client: both, peer: v10, expected: []string{"1.1", "1.0", "1.0"}},
+		{client: both, peer: v11, expected: []string{"1.1", "1.1"}},
+		{client: both, peer: both, expected: []string{"1.1", "1.1"}},
+	}
+
+	for _, tst := range tests {
+		t.Run(fmt.Sprintf("client %v peer %v", tst.client, tst.peer), func(t *testing.T) {
+			assert := require.New(t)
+
+			var received []string
+			server := newVersionedPeer(tst.peer, &received)
+			defer server.Close()
+
+			c, err := NewClient(ClientConfig{
+				SenderID:         "010203",
+				ReceiverID:       "0102030405060708",
+				Server:           server.URL,
+				ProtocolVersions: tst.client,
+			})
+			assert.NoError(err)
+
+			_, err = c.HomeNSReq(context.Background(), HomeNSReqPayload{})
+			if tst.firstErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+
+			// the second request either uses the learned common version
+			// or fails without reaching the peer
+			_, err = c.HomeNSReq(context.Background(), HomeNSReqPayload{})
+			if !tst.firstErr {
+				assert.NoError(err)
+			} else {
+				assert.Equal(ErrNoCommonProtocolVersion, err)
+			}
+
+			assert.Equal(tst.expected, received)
+		})
+	}
+}
+
+func TestClientPeerCapabilities(t *testing.T) {
+	var received []string
+	server := newVersionedPeer([]string{ProtocolVersion1_0}, &received)
+	defer server.Close()
+
+	t.Run("unsupported message-type", func(t *testing.T) {
+		assert := require.New(t)
+
+		store := NewCapabilityStore()
+		store.Set("0102030405060708", PeerCapabilities{MessageTypes: []MessageType{JoinReq}})
+
+		c, err := NewClient(ClientConfig{
+			ReceiverID:   "0102030405060708",
+			Server:       server.URL,
+			Capabilities: store,
+		})
+		assert.NoError(err)
+
+		_, err = c.HomeNSReq(context.Background(), HomeNSReqPayload{})
+		assert.Equal(ErrUnsupportedMessageType, errors.Cause(err))
+		assert.Len(received, 0)
+	})
+
+	t.Run("configured async peer", func(t *testing.T) {
+		assert := require.New(t)
+
+		store := NewCapabilityStore()
+		store.Set("0102030405060708", PeerCapabilities{Async: true})
+
+		c, err := NewClient(ClientConfig{
+			ReceiverID:   "0102030405060708",
+			Server:       server.URL,
+			Capabilities: store,
+		})
+		assert.NoError(err)
+
+		_, err = c.HomeNSReq(context.Background(), HomeNSReqPayload{})
+		assert.Equal(ErrPeerRequiresAsync, err)
+		assert.Len(received, 0)
+	})
+
+	t.Run("learned async peer", func(t *testing.T) {
+		assert := require.New(t)
+
+		asyncServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer asyncServer.Close()
+
+		store := NewCapabilityStore()
+		c, err := NewClient(ClientConfig{
+			ReceiverID:   "0102030405060708",
+			Server:       asyncServer.URL,
+			Capabilities: store,
+		})
+		assert.NoError(err)
+
+		// a single empty answer is not enough evidence
+		for i := 0; i < AsyncEmptyAnswerThreshold; i++ {
+			caps, _ := store.Get("0102030405060708")
+			assert.False(caps.Async)
+
+			_, err = c.HomeNSReq(context.Background(), HomeNSReqPayload{})
+			assert.Equal(ErrPeerRequiresAsync, err)
+		}
+
+		caps, ok := store.Get("0102030405060708")
+		assert.True(ok)
+		assert.True(caps.Async)
+
+		store.ResetAsync("0102030405060708")
+		caps, _ = store.Get("0102030405060708")
+		assert.False(caps.Async)
+	})
+
+	t.Run("empty answers reset by sync answer", func(t *testing.T) {
+		assert := require.New(t)
+
+		var empty bool
+		flakyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if empty {
+				return
+			}
+			w.Write([]byte(`{"MessageType": "HomeNSAns", "Result": {"ResultCode": "Success"}}`))
+		}))
+		defer flakyServer.Close()
+
+		store := NewCapabilityStore()
+		c, err := NewClient(ClientConfig{
+			ReceiverID:   "0102030405060708",
+			Server:       flakyServer.URL,
+			Capabilities: store,
+		})
+		assert.NoError(err)
+
+		for i := 0; i < AsyncEmptyAnswerThreshold; i++ {
+			empty = i != 1
+			_, err = c.HomeNSReq(context.Background(), HomeNSReqPayload{})
+			if empty {
+				assert.Equal(ErrPeerRequiresAsync, err)
+			} else {
+				assert.NoError(err)
+			}
+		}
+
+		caps, _ := store.Get("0102030405060708")
+		assert.False(caps.Async)
+	})
+}
+
+func TestClientProtocolVersion1_1(t *testing.T) {
+	assert := require.New(t)
+
+	// the peer supports 1.1, except for PRStartReq
+	var received []BasePayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req BasePayload
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		received = append(received, req)
+
+		ans := BasePayloadResult{
+			BasePayload: BasePayload{
+				ProtocolVersion: req.ProtocolVersion,
+				SenderID:        req.ReceiverID,
+				ReceiverID:      req.SenderID,
+				TransactionID:   req.TransactionID,
+				MessageType:     AnswerMessageType(req.MessageType),
+			},
+			Result: Result{ResultCode: Success},
+		}
+		if req.MessageType == PRStartReq && req.ProtocolVersion == ProtocolVersion1_1 {
+			ans.Result.ResultCode = MalformedMessage
+		}
+
+		json.NewEncoder(w).Encode(ans)
+	}))
+	defer server.Close()
+
+	store := NewCapabilityStore()
+	c, err := NewClient(ClientConfig{
+		SenderID:        "010203",
+		ReceiverID:      "030201",
+		SenderNSID:      "ns-1",
+		ReceiverNSID:    "ns-2",
+		Server:          server.URL,
+		ProtocolVersion: ProtocolVersion1_1,
+		Capabilities:    store,
+	})
+	assert.NoError(err)
+
+	_, err = c.PRStartReq(context.Background(), PRStartReqPayload{})
+	assert.NoError(err)
+	_, err = c.PRStartReq(context.Background(), PRStartReqPayload{})
+	assert.NoError(err)
+	_, err = c.HomeNSReq(context.Background(), HomeNSReqPayload{})
+	assert.NoError(err)
+
+	assert.Len(received, 4)
+
+	// retried with 1.0, without the 1.1 fields
+	assert.Equal(ProtocolVersion1_1, received[0].ProtocolVersion)
+	assert.Equal("ns-1", received[0].SenderNSID)
+	assert.Equal("ns-2", received[0].ReceiverNSID)
+	assert.Equal(ProtocolVersion1_0, received[1].ProtocolVersion)
+	assert.Equal(received[0].TransactionID, received[1].TransactionID)
+	assert.Equal("", received[1].SenderNSID)
+	assert.Equal("", received[1].ReceiverNSID)
+
+	// the fallback is only used for PRStartReq
+	assert.Equal(ProtocolVersion1_0, received[2].ProtocolVersion)
+	assert.Equal(ProtocolVersion1_1, received[3].ProtocolVersion)
+
+	caps, _ := store.Get("030201")
+	assert.Equal(map[MessageType]string{PRStartReq: ProtocolVersion1_0}, caps.MessageTypeProtocolVersions)
+	assert.Len(caps.RejectedProtocolVersions, 0)
+}
+
+func TestClientConfigProtocolVersion(t *testing.T) {
+	assert := require.New(t)
+
+	_, err := NewClient(ClientConfig{ProtocolVersion: "2.0"})
+	assert.EqualError(err, "unsupported protocol version: 2.0")
+
+	c, err := NewClient(ClientConfig{ProtocolVersion: ProtocolVersion1_1})
+	assert.NoError(err)
+	assert.Equal([]string{ProtocolVersion1_0, ProtocolVersion1_1}, c.(*client).protocolVersions)
+}
@@ -0,0 +1,93 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"time"
+)
+
+// Capture holds the raw bytes of a single backend exchange, as sent and
+// received over the wire. It is intended for debugging, e.g. to show
+// exactly what was exchanged with a roaming partner.
+type Capture struct {
+	// TransactionID holds the transaction ID of the exchange.
+	TransactionID uint32
+
+	// MessageType holds the message-type of the sent payload.
+	MessageType MessageType
+
+	// TraceID holds the trace ID of the transaction, in case a
+	// TransactionManager is used.
+	TraceID string
+
+	// Time holds the time at which the exchange was started.
+	Time time.Time
+
+	// Duration holds the duration of the exchange.
+	Duration time.Duration
+
+	// Request holds the HTTP request as sent by the client (request-line,
+	// headers and body).
+	Request []byte
+
+	// Response holds the HTTP response as received by the client
+	// (status-line, headers and body).
+	Response []byte
+
+	// AsyncAnswer holds the async answer (JSON), as published by
+	// HandleAnswer, when the async protocol scheme is used.
+	AsyncAnswer []byte
+
+	// Error holds the error in case the exchange failed.
+	Error error
+}
+
+// CaptureFunc defines the function signature of the capture sink. It is
+// called once for every exchange, after it has completed. The function
+// must be safe for concurrent use.
+type CaptureFunc func(Capture)
+
+// captureRequest stores the HTTP request in the capture. It must be called
+// before the request is sent, the request body is restored.
+func captureRequest(c *Capture, req *http.Request) {
+	if c == nil {
+		return
+	}
+
+	b, err := httputil.DumpRequestOut(req, true)
+	if err != nil {
+		return
+	}
+	c.Request = b
+}
+
+// captureResponse stores the HTTP response in the capture. The response
+// body is restored.
+func captureResponse(c *Capture, resp *http.Response) {
+	if c == nil {
+		return
+	}
+
+	b, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return
+	}
+	c.Response = b
+}
+
+type captureKey struct{}
+
+// contextWithCapture returns a copy of the context holding the given
+// capture, used by the HTTP transport to store the request and response.
+func contextWithCapture(ctx context.Context, c *Capture) context.Context {
+	if c == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, captureKey{}, c)
+}
+
+func captureFromContext(ctx context.Context) *Capture {
+	c, _ := ctx.Value(captureKey{}).(*Capture)
+	return c
+}
@@ -0,0 +1,124 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestClientCapture(t *testing.T) {
+	assert := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(concurrencyTestHandler))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var captures []Capture
+
+	client, err := NewClient(ClientConfig{
+		SenderID:           "010101",
+		ReceiverID:         "020202",
+		Server:             server.URL,
+		TransactionManager: NewTransactionManager(0, func() (string, error) { return "trace-1", nil }),
+		CaptureFunc: func(c Capture) {
+			mu.Lock()
+			defer mu.Unlock()
+			captures = append(captures, c)
+		},
+	})
+	assert.NoError(err)
+
+	t.Run("Request", func(t *testing.T) {
+		assert := require.New(t)
+
+		ans, err := client.ProfileReq(context.Background(), ProfileReqPayload{
+			DevEUI: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		})
+		assert.NoError(err)
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Len(captures, 1)
+
+		c := captures[0]
+		assert.Equal(ans.TransactionID, c.TransactionID)
+		assert.Equal(ProfileReq, c.MessageType)
+		assert.Equal("trace-1", c.TraceID)
+		assert.NoError(c.Error)
+		assert.False(c.Time.IsZero())
+		assert.Nil(c.AsyncAnswer)
+
+		assert.True(strings.HasPrefix(string(c.Request), "POST / HTTP/1.1\r\n"))
+		assert.Contains(string(c.Request), "Content-Type: application/json\r\n")
+		assert.Contains(string(c.Request), `"DevEUI":"0102030405060708"`)
+
+		assert.True(strings.HasPrefix(string(c.Response), "HTTP/1.1 200 OK\r\n"))
+		assert.Contains(string(c.Response), `"MessageType":"ProfileAns"`)
+	})
+
+	t.Run("SendAnswer", func(t *testing.T) {
+		assert := require.New(t)
+
+		err := client.SendAnswer(context.Background(), ProfileAnsPayload{
+			BasePayloadResult: BasePayloadResult{
+				BasePayload: BasePayload{
+					TransactionID: 1234,
+					MessageType:   ProfileAns,
+				},
+			},
+		})
+		assert.Error(err)
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Len(captures, 2)
+
+		c := captures[1]
+		assert.Equal(uint32(1234), c.TransactionID)
+		assert.Equal(ProfileAns, c.MessageType)
+		assert.Equal(err, c.Error)
+		assert.Contains(string(c.Request), `"MessageType":"ProfileAns"`)
+		assert.True(strings.HasPrefix(string(c.Response), "HTTP/1.1 400 Bad Request\r\n"))
+	})
+}
+
+func TestClientDeterministic(t *testing.T) {
+	assert := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(concurrencyTestHandler))
+	defer server.Close()
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var captures []Capture
+
+	client, err := NewClient(ClientConfig{
+		SenderID:   "010101",
+		ReceiverID: "020202",
+		Server:     server.URL,
+		Rand:       bytes.NewReader([]byte{1, 0, 0, 0, 2, 0, 0, 0}),
+		Clock:      func() time.Time { return now },
+		CaptureFunc: func(c Capture) {
+			captures = append(captures, c)
+		},
+	})
+	assert.NoError(err)
+
+	assert.Equal(uint32(1), client.GetRandomTransactionID())
+
+	ans, err := client.ProfileReq(context.Background(), ProfileReqPayload{})
+	assert.NoError(err)
+	assert.Equal(uint32(2), ans.TransactionID)
+
+	assert.Len(captures, 1)
+	assert.Equal(now, captures[0].Time)
+	assert.Equal(time.Duration(0), captures[0].Duration)
+}
@@ -1,7 +1,6 @@
 package backend
 
 import (
-	"bytes"
 	"context"
 	"crypto/rand"
 	"crypto/tls"
@@ -9,8 +8,10 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"reflect"
 	"time"
 
 	"github.com/go-redis/redis/v7"
@@ -23,7 +24,8 @@ var (
 	ErrAsyncTimeout = errors.New("async timeout")
 )
 
-// Client defines the backend client interface.
+// Client defines the backend client interface. Implementations must be
+// safe for concurrent use by multiple goroutines.
 type Client interface {
 	// GetSenderID returns the SenderID.
 	GetSenderID() string
@@ -32,15 +34,26 @@ type Client interface {
 	// IsAsync returns a bool indicating if the client is async.
 	IsAsync() bool
 	// GetRandomTransactionID returns a random transaction id.
+	//
+	// Deprecated: use AllocateTransactionID, which returns the allocation
+	// error.
 	GetRandomTransactionID() uint32
+	// AllocateTransactionID returns a new transaction id.
+	AllocateTransactionID() (uint32, error)
 	// JoinReq method.
 	JoinReq(context.Context, JoinReqPayload) (JoinAnsPayload, error)
 	// RejoinReq method.
 	RejoinReq(context.Context, RejoinReqPayload) (RejoinAnsPayload, error)
+	// AppSKeyReq method.
+	AppSKeyReq(context.Context, AppSKeyReqPayload) (AppSKeyAnsPayload, error)
 	// PRStartReq method.
 	PRStartReq(context.Context, PRStartReqPayload) (PRStartAnsPayload, error)
 	// PRStopReq method.
 	PRStopReq(context.Context, PRStopReqPayload) (PRStopAnsPayload, error)
+	// HRStartReq method.
+	HRStartReq(context.Context, HRStartReqPayload) (HRStartAnsPayload, error)
+	// HRStopReq method.
+	HRStopReq(context.Context, HRStopReqPayload) (HRStopAnsPayload, error)
 	// XmitDataReq method.
 	XmitDataReq(context.Context, XmitDataReqPayload) (XmitDataAnsPayload, error)
 	// ProfileReq method.
@@ -64,18 +77,114 @@ type ClientConfig struct {
 
 	// RedisClient holds the optional Redis database client. When set the client
 	// will use the aysnc protocol scheme. In this case the client will wait
-	// AsyncTimeout before returning a timeout error.
+	// AsyncTimeout before returning a timeout error. This is a shorthand for
+	// setting AnswerBus to a Redis AnswerBus (see NewRedisAnswerBus). For
+	// Redis Cluster and Sentinel deployments, use redis.NewUniversalClient
+	// (or redis.NewClusterClient / redis.NewFailoverClient).
 	RedisClient redis.UniversalClient
 
-	// AsyncTimeout defines the async timeout. This must be set when RedisClient
-	// is set.
+	// RedisKeyPrefix holds the optional prefix of the Redis keys, used when
+	// RedisClient is set. When empty, DefaultRedisKeyPrefix is used.
+	RedisKeyPrefix string
+
+	// RedisTransactionTTL defines the optional TTL of the pending
+	// transaction records, stored in Redis when RedisClient is set. See
+	// RedisAnswerBusConfig for more information.
+	RedisTransactionTTL time.Duration
+
+	// AnswerBus holds the optional AnswerBus used for correlating the async
+	// answers to the pending requests. When set the client will use the
+	// async protocol scheme. In this case the client will wait AsyncTimeout
+	// before returning a timeout error.
+	AnswerBus AnswerBus
+
+	// LongPollURL holds the optional URL of the long-poll endpoint of the
+	// peer (or hub) over which async answers are delivered, as alternative
+	// to receiving these as callback POSTs and correlating them using Redis.
+	// When set (and RedisClient and AnswerBus are not set), the client will
+	// use the async
+	// protocol scheme and poll this endpoint while there are pending
+	// requests. The endpoint must respond with 200 and a JSON array of
+	// answers or with 204 when there are no answers.
+	LongPollURL string
+
+	// AsyncTimeout defines the async timeout. This must be set when
+	// RedisClient, AnswerBus or LongPollURL is set.
 	AsyncTimeout time.Duration
 
 	// Logger holds a Logger instance.
 	Logger *log.Logger
+
+	// TransactionManager holds the optional TransactionManager. When set,
+	// transaction IDs are allocated by the TransactionManager, which
+	// guarantees that IDs are not re-used by pending transactions, and the
+	// trace ID of the transaction is added to the log fields.
+	TransactionManager *TransactionManager
+
+	// CaptureFunc holds the optional capture sink. When set, the raw HTTP
+	// request and response (including headers) of every exchange are
+	// captured and passed to this function. This is intended for
+	// debugging as it adds overhead and might expose key material.
+	CaptureFunc CaptureFunc
+
+	// Rand holds the optional random source used for generating
+	// transaction IDs, when no TransactionManager is set. When nil,
+	// crypto/rand is used. A deterministic source can be used for
+	// reproducible tests, note that it must be safe for concurrent use
+	// when the client is used concurrently.
+	Rand io.Reader
+
+	// Clock holds the optional function returning the current time, used
+	// for the capture timestamps. When nil, time.Now is used.
+	Clock func() time.Time
+
+	// ProtocolVersion holds the highest Backend Interfaces protocol version
+	// used by the client. The supported lower versions are used as
+	// fallback, when the peer rejects this version. It is ignored when
+	// ProtocolVersions is set.
+	ProtocolVersion string
+
+	// ProtocolVersions holds the Backend Interfaces protocol versions
+	// supported by the client. The highest version supported by the peer
+	// is used for each request. When both ProtocolVersion and
+	// ProtocolVersions are empty, ProtocolVersion1_0 is used.
+	ProtocolVersions []string
+
+	// SenderNSID and ReceiverNSID hold the optional NSIDs of the sender and
+	// receiver network-servers. These are only sent when protocol version
+	// 1.1 (or higher) is used.
+	SenderNSID   string
+	ReceiverNSID string
+
+	// Capabilities holds the optional CapabilityStore holding the
+	// (configured) capabilities of the peer, by ReceiverID. The store is
+	// updated with the capabilities learned from the answers of the peer.
+	// When nil, a new store is created.
+	Capabilities *CapabilityStore
+
+	// Transport holds the optional Transport used for sending the requests
+	// and answers to the peer (e.g. gRPC, MQTT or in-process for tests).
+	// When nil, the messages are posted to the Server, using the TLS and
+	// Retry configuration. Note that the Capture request and response
+	// dumps are only available when using the HTTP transport.
+	Transport Transport
+
+	// Retry holds the optional retry policy for transient HTTP failures.
+	// By default, requests are not retried.
+	Retry RetryConfig
+
+	// MaxIdleConnsPerHost defines the max. number of idle (keep-alive)
+	// connections to the server which are kept for re-use. When 0, the
+	// net/http default (2) is used. This should be set to at least the
+	// concurrency of XmitDataReqBatch, to avoid re-connecting.
+	MaxIdleConnsPerHost int
 }
 
-// NewClient creates a new Client.
+// NewClient creates a new Client. The returned Client is safe for concurrent
+// use: its configuration is not modified after creation and concurrent
+// (async) requests are correlated by their transaction ID. Note that
+// concurrent requests must use unique transaction IDs, which is guaranteed
+// when the TransactionID is left 0 (optionally using a TransactionManager).
 func NewClient(config ClientConfig) (Client, error) {
 	httpClient := http.DefaultClient
 
@@ -111,12 +220,72 @@ func NewClient(config ClientConfig) (Client, error) {
 		}
 	}
 
+	if config.MaxIdleConnsPerHost != 0 {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if t, ok := httpClient.Transport.(*http.Transport); ok {
+			transport = t
+		}
+		transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+		httpClient = &http.Client{
+			Transport: transport,
+		}
+	}
+
+	if config.Rand == nil {
+		config.Rand = rand.Reader
+	}
+
+	if config.Clock == nil {
+		config.Clock = time.Now
+	}
+
+	if len(config.ProtocolVersions) == 0 && config.ProtocolVersion != "" {
+		for _, v := range protocolVersions {
+			if compareProtocolVersion(v, config.ProtocolVersion) <= 0 {
+				config.ProtocolVersions = append(config.ProtocolVersions, v)
+			}
+		}
+		if !containsString(config.ProtocolVersions, config.ProtocolVersion) {
+			return nil, fmt.Errorf("unsupported protocol version: %s", config.ProtocolVersion)
+		}
+	}
+
+	if len(config.ProtocolVersions) == 0 {
+		config.ProtocolVersions = []string{ProtocolVersion1_0}
+	}
+
+	if config.Capabilities == nil {
+		config.Capabilities = NewCapabilityStore()
+	}
+
 	if config.Logger == nil {
 		config.Logger = &log.Logger{
 			Out: ioutil.Discard,
 		}
 	}
 
+	if config.AnswerBus == nil && config.RedisClient != nil {
+		config.AnswerBus = NewRedisAnswerBus(config.RedisClient, RedisAnswerBusConfig{
+			KeyPrefix:      config.RedisKeyPrefix,
+			TransactionTTL: config.RedisTransactionTTL,
+		})
+	}
+
+	var longPoll *longPoller
+	if config.AnswerBus == nil && config.LongPollURL != "" {
+		longPoll = newLongPoller(config.LongPollURL, httpClient, config.Logger)
+	}
+
+	if config.Transport == nil {
+		config.Transport = &httpTransport{
+			httpClient: httpClient,
+			server:     config.Server,
+			retry:      config.Retry,
+			log:        config.Logger,
+			rand:       config.Rand,
+		}
+	}
+
 	config.Logger.WithFields(log.Fields{
 		"server":      config.Server,
 		"ca_cert":     config.CACert,
@@ -127,27 +296,41 @@ func NewClient(config ClientConfig) (Client, error) {
 	}).Debug("lorawan/backend: new backend client")
 
 	return &client{
-		log:             config.Logger,
-		server:          config.Server,
-		httpClient:      httpClient,
-		senderID:        config.SenderID,
-		receiverID:      config.ReceiverID,
-		protocolVersion: ProtocolVersion1_0,
-		redisClient:     config.RedisClient,
-		asyncTimeout:    config.AsyncTimeout,
+		log:              config.Logger,
+		transport:        config.Transport,
+		senderID:         config.SenderID,
+		receiverID:       config.ReceiverID,
+		senderNSID:       config.SenderNSID,
+		receiverNSID:     config.ReceiverNSID,
+		protocolVersions: config.ProtocolVersions,
+		capabilities:     config.Capabilities,
+		answerBus:        config.AnswerBus,
+		longPoll:         longPoll,
+		asyncTimeout:     config.AsyncTimeout,
+		txManager:        config.TransactionManager,
+		captureFunc:      config.CaptureFunc,
+		rand:             config.Rand,
+		now:              config.Clock,
 	}, nil
 
 }
 
 type client struct {
-	log             *log.Logger
-	server          string
-	httpClient      *http.Client
-	protocolVersion string
-	senderID        string
-	receiverID      string
-	redisClient     redis.UniversalClient
-	asyncTimeout    time.Duration
+	log              *log.Logger
+	transport        Transport
+	protocolVersions []string
+	capabilities     *CapabilityStore
+	senderID         string
+	receiverID       string
+	senderNSID       string
+	receiverNSID     string
+	answerBus        AnswerBus
+	longPoll         *longPoller
+	asyncTimeout     time.Duration
+	txManager        *TransactionManager
+	captureFunc      CaptureFunc
+	rand             io.Reader
+	now              func() time.Time
 }
 
 func (c *client) GetSenderID() string {
@@ -159,17 +342,87 @@ func (c *client) GetReceiverID() string {
 }
 
 func (c *client) IsAsync() bool {
-	return c.redisClient != nil
+	return c.answerBus != nil || c.longPoll != nil
+}
+
+// getProtocolVersion returns the protocol version to use for the given
+// message-type. It returns an empty string when there is no common version,
+// which is returned as error by request.
+func (c *client) getProtocolVersion(mt MessageType) string {
+	caps, _ := c.capabilities.Get(c.receiverID)
+	v, _ := caps.MessageTypeProtocolVersion(mt, c.protocolVersions)
+	return v
+}
+
+// fallbackProtocolVersion returns the protocol version to retry the given
+// request with, in case the peer rejected its protocol version. This is the
+// next lower version supported by the client which has not been rejected
+// by the peer. When the peer answered with a malformed result, the fallback
+// is only used for this message-type.
+func (c *client) fallbackProtocolVersion(pl BasePayload, result ResultCode) (string, bool) {
+	switch result {
+	case InvalidProtocolVersion, MalformedRequest, MalformedMessage:
+	default:
+		return "", false
+	}
+
+	caps, _ := c.capabilities.Get(c.receiverID)
+
+	var out string
+	for _, v := range c.protocolVersions {
+		if compareProtocolVersion(v, pl.ProtocolVersion) >= 0 || containsString(caps.RejectedProtocolVersions, v) {
+			continue
+		}
+		if out == "" || compareProtocolVersion(v, out) > 0 {
+			out = v
+		}
+	}
+	if out == "" {
+		return "", false
+	}
+
+	if result != InvalidProtocolVersion {
+		c.capabilities.RecordMessageType(c.receiverID, pl.MessageType, out)
+	}
+
+	return out, true
+}
+
+// setProtocolVersion sets the given protocol version and the fields
+// depending on it.
+func (c *client) setProtocolVersion(pl *BasePayload, protocolVersion string) {
+	pl.ProtocolVersion = protocolVersion
+	pl.SenderNSID = ""
+	pl.ReceiverNSID = ""
+
+	if compareProtocolVersion(protocolVersion, ProtocolVersion1_1) >= 0 {
+		pl.SenderNSID = c.senderNSID
+		pl.ReceiverNSID = c.receiverNSID
+	}
+}
+
+// checkCapabilities validates the given request against the known
+// capabilities of the peer.
+func (c *client) checkCapabilities(pl BasePayload) error {
+	caps, _ := c.capabilities.Get(c.receiverID)
+
+	if _, err := caps.NegotiateProtocolVersion(c.protocolVersions); err != nil {
+		return err
+	}
+	if !caps.SupportsMessageType(pl.MessageType) {
+		return errors.Wrap(ErrUnsupportedMessageType, string(pl.MessageType))
+	}
+	if caps.Async && !c.IsAsync() {
+		return ErrPeerRequiresAsync
+	}
+
+	return nil
 }
 
 func (c *client) JoinReq(ctx context.Context, pl JoinReqPayload) (JoinAnsPayload, error) {
-	pl.BasePayload.ProtocolVersion = c.protocolVersion
 	pl.BasePayload.SenderID = c.senderID
 	pl.BasePayload.ReceiverID = c.receiverID
 	pl.BasePayload.MessageType = JoinReq
-	if pl.BasePayload.TransactionID == 0 {
-		pl.BasePayload.TransactionID = c.GetRandomTransactionID()
-	}
 
 	var ans JoinAnsPayload
 
@@ -177,21 +430,17 @@ func (c *client) JoinReq(ctx context.Context, pl JoinReqPayload) (JoinAnsPayload
 		return ans, err
 	}
 
-	if ans.Result.ResultCode != Success {
-		return ans, fmt.Errorf("response error, code: %s, description: %s", ans.Result.ResultCode, ans.Result.Description)
+	if err := ans.Result.Err(); err != nil {
+		return ans, err
 	}
 
 	return ans, nil
 }
 
 func (c *client) RejoinReq(ctx context.Context, pl RejoinReqPayload) (RejoinAnsPayload, error) {
-	pl.BasePayload.ProtocolVersion = c.protocolVersion
 	pl.BasePayload.SenderID = c.senderID
 	pl.BasePayload.ReceiverID = c.receiverID
 	pl.BasePayload.MessageType = RejoinReq
-	if pl.BasePayload.TransactionID == 0 {
-		pl.BasePayload.TransactionID = c.GetRandomTransactionID()
-	}
 
 	var ans RejoinAnsPayload
 
@@ -199,21 +448,35 @@ func (c *client) RejoinReq(ctx context.Context, pl RejoinReqPayload) (RejoinAnsP
 		return ans, err
 	}
 
-	if ans.Result.ResultCode != Success {
-		return ans, fmt.Errorf("response error, code: %s, description: %s", ans.Result.ResultCode, ans.Result.Description)
+	if err := ans.Result.Err(); err != nil {
+		return ans, err
+	}
+
+	return ans, nil
+}
+
+func (c *client) AppSKeyReq(ctx context.Context, pl AppSKeyReqPayload) (AppSKeyAnsPayload, error) {
+	pl.BasePayload.SenderID = c.senderID
+	pl.BasePayload.ReceiverID = c.receiverID
+	pl.BasePayload.MessageType = AppSKeyReq
+
+	var ans AppSKeyAnsPayload
+
+	if err := c.request(ctx, pl, &ans); err != nil {
+		return ans, err
+	}
+
+	if err := ans.Result.Err(); err != nil {
+		return ans, err
 	}
 
 	return ans, nil
 }
 
 func (c *client) PRStartReq(ctx context.Context, pl PRStartReqPayload) (PRStartAnsPayload, error) {
-	pl.BasePayload.ProtocolVersion = c.protocolVersion
 	pl.BasePayload.SenderID = c.senderID
 	pl.BasePayload.ReceiverID = c.receiverID
 	pl.BasePayload.MessageType = PRStartReq
-	if pl.BasePayload.TransactionID == 0 {
-		pl.BasePayload.TransactionID = c.GetRandomTransactionID()
-	}
 
 	var ans PRStartAnsPayload
 
@@ -221,21 +484,17 @@ func (c *client) PRStartReq(ctx context.Context, pl PRStartReqPayload) (PRStartA
 		return ans, err
 	}
 
-	if ans.Result.ResultCode != Success {
-		return ans, fmt.Errorf("response error, code: %s, description: %s", ans.Result.ResultCode, ans.Result.Description)
+	if err := ans.Result.Err(); err != nil {
+		return ans, err
 	}
 
 	return ans, nil
 }
 
 func (c *client) PRStopReq(ctx context.Context, pl PRStopReqPayload) (PRStopAnsPayload, error) {
-	pl.BasePayload.ProtocolVersion = c.protocolVersion
 	pl.BasePayload.SenderID = c.senderID
 	pl.BasePayload.ReceiverID = c.receiverID
 	pl.BasePayload.MessageType = PRStopReq
-	if pl.BasePayload.TransactionID == 0 {
-		pl.BasePayload.TransactionID = c.GetRandomTransactionID()
-	}
 
 	var ans PRStopAnsPayload
 
@@ -243,21 +502,53 @@ func (c *client) PRStopReq(ctx context.Context, pl PRStopReqPayload) (PRStopAnsP
 		return ans, err
 	}
 
-	if ans.Result.ResultCode != Success {
-		return ans, fmt.Errorf("response error, code: %s, description: %s", ans.Result.ResultCode, ans.Result.Description)
+	if err := ans.Result.Err(); err != nil {
+		return ans, err
+	}
+
+	return ans, nil
+}
+
+func (c *client) HRStartReq(ctx context.Context, pl HRStartReqPayload) (HRStartAnsPayload, error) {
+	pl.BasePayload.SenderID = c.senderID
+	pl.BasePayload.ReceiverID = c.receiverID
+	pl.BasePayload.MessageType = HRStartReq
+
+	var ans HRStartAnsPayload
+
+	if err := c.request(ctx, pl, &ans); err != nil {
+		return ans, err
+	}
+
+	if err := ans.Result.Err(); err != nil {
+		return ans, err
+	}
+
+	return ans, nil
+}
+
+func (c *client) HRStopReq(ctx context.Context, pl HRStopReqPayload) (HRStopAnsPayload, error) {
+	pl.BasePayload.SenderID = c.senderID
+	pl.BasePayload.ReceiverID = c.receiverID
+	pl.BasePayload.MessageType = HRStopReq
+
+	var ans HRStopAnsPayload
+
+	if err := c.request(ctx, pl, &ans); err != nil {
+		return ans, err
+	}
+
+	if err := ans.Result.Err(); err != nil {
+		return ans, err
 	}
 
 	return ans, nil
 }
 
 func (c *client) XmitDataReq(ctx context.Context, pl XmitDataReqPayload) (XmitDataAnsPayload, error) {
-	pl.BasePayload.ProtocolVersion = c.protocolVersion
 	pl.BasePayload.SenderID = c.senderID
 	pl.BasePayload.ReceiverID = c.receiverID
 	pl.BasePayload.MessageType = XmitDataReq
-	if pl.BasePayload.TransactionID == 0 {
-		pl.BasePayload.TransactionID = c.GetRandomTransactionID()
-	}
 
 	var ans XmitDataAnsPayload
 
@@ -265,21 +556,17 @@ func (c *client) XmitDataReq(ctx context.Context, pl XmitDataReqPayload) (XmitDa
 		return ans, err
 	}
 
-	if ans.Result.ResultCode != Success {
-		return ans, fmt.Errorf("response error, code: %s, description: %s", ans.Result.ResultCode, ans.Result.Description)
+	if err := ans.Result.Err(); err != nil {
+		return ans, err
 	}
 
 	return ans, nil
 }
 
 func (c *client) ProfileReq(ctx context.Context, pl ProfileReqPayload) (ProfileAnsPayload, error) {
-	pl.BasePayload.ProtocolVersion = c.protocolVersion
 	pl.BasePayload.SenderID = c.senderID
 	pl.BasePayload.ReceiverID = c.receiverID
 	pl.BasePayload.MessageType = ProfileReq
-	if pl.BasePayload.TransactionID == 0 {
-		pl.BasePayload.TransactionID = c.GetRandomTransactionID()
-	}
 
 	var ans ProfileAnsPayload
 
@@ -287,21 +574,17 @@ func (c *client) ProfileReq(ctx context.Context, pl ProfileReqPayload) (ProfileA
 		return ans, err
 	}
 
-	if ans.Result.ResultCode != Success {
-		return ans, fmt.Errorf("response error, code: %s, description: %s", ans.Result.ResultCode, ans.Result.Description)
+	if err := ans.Result.Err(); err != nil {
+		return ans, err
 	}
 
 	return ans, nil
 }
 
 func (c *client) HomeNSReq(ctx context.Context, pl HomeNSReqPayload) (HomeNSAnsPayload, error) {
-	pl.BasePayload.ProtocolVersion = c.protocolVersion
 	pl.BasePayload.SenderID = c.senderID
 	pl.BasePayload.ReceiverID = c.receiverID
 	pl.BasePayload.MessageType = HomeNSReq
-	if pl.BasePayload.TransactionID == 0 {
-		pl.BasePayload.TransactionID = c.GetRandomTransactionID()
-	}
 
 	var ans HomeNSAnsPayload
 
@@ -309,17 +592,71 @@ func (c *client) HomeNSReq(ctx context.Context, pl HomeNSReqPayload) (HomeNSAnsP
 		return ans, err
 	}
 
-	if ans.Result.ResultCode != Success {
-		return ans, fmt.Errorf("response error, code: %s, description: %s", ans.Result.ResultCode, ans.Result.Description)
+	if err := ans.Result.Err(); err != nil {
+		return ans, err
 	}
 
 	return ans, nil
 }
 
+// request sends the given request to the peer and unmarshals the answer
+// into ans, using the negotiated protocol version. When the peer rejects
+// this version, the request is retried using the next lower version.
 func (c *client) request(ctx context.Context, pl Request, ans Answer) error {
-	b, err := json.Marshal(pl)
-	if err != nil {
-		return errors.Wrap(err, "json marshal error")
+	if err := c.checkCapabilities(pl.GetBasePayload()); err != nil {
+		return err
+	}
+
+	bp := pl.GetBasePayload()
+	if bp.TransactionID == 0 {
+		id, err := c.AllocateTransactionID()
+		if err != nil {
+			return err
+		}
+		bp.TransactionID = id
+	}
+
+	var traceID string
+	if c.txManager != nil {
+		if tx, ok := c.txManager.Get(bp.TransactionID); ok {
+			traceID = tx.TraceID
+			defer c.txManager.Release(tx.TransactionID)
+		}
+	}
+
+	c.setProtocolVersion(&bp, c.getProtocolVersion(bp.MessageType))
+
+	for {
+		pl = withBasePayload(pl, bp)
+		if err := c.exchange(ctx, pl, ans, traceID); err != nil {
+			return err
+		}
+
+		v, ok := c.fallbackProtocolVersion(bp, ans.GetBasePayload().Result.ResultCode)
+		if !ok {
+			return nil
+		}
+
+		c.log.WithFields(log.Fields{
+			"protocol_version": bp.ProtocolVersion,
+			"fallback_version": v,
+			"receiver_id":      bp.ReceiverID,
+			"message_type":     bp.MessageType,
+		}).Warning("lorawan/backend: protocol version rejected by peer, retrying")
+
+		c.setProtocolVersion(&bp, v)
+		resetAnswer(ans)
+	}
+}
+
+// exchange sends the given request and unmarshals the answer into ans.
+func (c *client) exchange(ctx context.Context, pl Request, ans Answer, traceID string) (err error) {
+	capture := c.newCapture(pl.GetBasePayload(), traceID)
+	if capture != nil {
+		defer func() {
+			capture.Error = err
+			c.sendCapture(capture)
+		}()
 	}
 
 	responseChan := make(chan []byte, 1)
@@ -327,49 +664,78 @@ func (c *client) request(ctx context.Context, pl Request, ans Answer) error {
 
 	// Setup async subscriber to receive response. Please note that we have to do
 	// this before making the request, as the response might come in, before the
-	// request has returned.
-	if c.IsAsync() {
-		key := c.getAsyncKey(pl.GetBasePayload().TransactionID)
+	// request has returned. The subscription must be confirmed, else the
+	// response could still be published before we are subscribed.
+	if c.answerBus != nil {
+		sub, err := c.answerBus.Subscribe(ctx, pl.GetBasePayload().TransactionID)
+		if err != nil {
+			return err
+		}
+		defer sub.Close()
 
 		go func() {
-			bb, err := c.readAsync(ctx, key)
+			bb, err := sub.Receive(ctx, c.asyncTimeout)
 			if err != nil {
 				errorChan <- err
 			} else {
 				responseChan <- bb
 			}
 		}()
-	}
+	} else if c.longPoll != nil {
+		answer, unsubscribe := c.longPoll.subscribe(pl.GetBasePayload().TransactionID)
+		defer unsubscribe()
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.server, bytes.NewReader(b))
-	if err != nil {
-		return errors.Wrap(err, "new request error")
-	}
-	req.Header.Add("Content-Type", "application/json")
+		go func() {
+			timer := time.NewTimer(c.asyncTimeout)
+			defer timer.Stop()
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return errors.Wrap(err, "http post error")
+			select {
+			case bb := <-answer:
+				responseChan <- bb
+			case <-ctx.Done():
+				errorChan <- ctx.Err()
+			case <-timer.C:
+				errorChan <- ErrAsyncTimeout
+			}
+		}()
 	}
-	defer resp.Body.Close()
 
-	// If async is not used, the http response contains the API response payload.
+	// the async goroutines above read ctx, use a new variable
+	reqCtx := contextWithCapture(ctx, capture)
+
+	// If async is not used, the transport returns the API response payload.
 	if !c.IsAsync() {
-		bb, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			errorChan <- err
+		buf := GetBuffer()
+		defer PutBuffer(buf)
+
+		if err := c.transport.SendRequest(reqCtx, pl, buf); err != nil {
+			return err
+		}
+
+		if buf.Len() == 0 {
+			// the peer has accepted the request, but will send the answer
+			// using the async protocol scheme
+			c.capabilities.RecordEmptyAnswer(c.receiverID)
+			errorChan <- ErrPeerRequiresAsync
 		} else {
-			responseChan <- bb
+			c.capabilities.ResetAsync(c.receiverID)
+			responseChan <- buf.Bytes()
 		}
+	} else if err := c.transport.SendRequest(reqCtx, pl, ioutil.Discard); err != nil {
+		return err
 	}
 
 	select {
 	case err := <-errorChan:
 		return err
 	case bb := <-responseChan:
+		if capture != nil && c.IsAsync() {
+			capture.AsyncAnswer = bb
+		}
 		if err := json.Unmarshal(bb, ans); err != nil {
 			return errors.Wrap(err, "unmarshal response error")
 		}
+		c.capabilities.Record(c.receiverID, pl.GetBasePayload().ProtocolVersion, ans.GetBasePayload().Result.ResultCode)
 	}
 
 	c.log.WithFields(log.Fields{
@@ -379,73 +745,114 @@ func (c *client) request(ctx context.Context, pl Request, ans Answer) error {
 		"transaction_id":   pl.GetBasePayload().TransactionID,
 		"message_type":     pl.GetBasePayload().MessageType,
 		"result_code":      ans.GetBasePayload().Result.ResultCode,
+		"trace_id":         traceID,
 	}).Info("lorawan/backend: finished backend api call")
 
 	return nil
 }
 
+// withBasePayload returns a copy of the given request, with its base payload
+// replaced by the given base payload.
+func withBasePayload(pl Request, bp BasePayload) Request {
+	v := reflect.New(reflect.TypeOf(pl)).Elem()
+	v.Set(reflect.ValueOf(pl))
+	v.FieldByName("BasePayload").Set(reflect.ValueOf(bp))
+	return v.Interface().(Request)
+}
+
+// resetAnswer resets the given answer (pointer) to its zero value.
+func resetAnswer(ans Answer) {
+	v := reflect.ValueOf(ans).Elem()
+	v.Set(reflect.Zero(v.Type()))
+}
+
 func (c *client) HandleAnswer(ctx context.Context, pl Answer) error {
 	if !c.IsAsync() {
 		return errors.New("async is not configured")
 	}
 
-	b, err := json.Marshal(pl)
+	buf, err := encodeJSON(pl)
 	if err != nil {
 		return errors.Wrap(err, "marshal answer error")
 	}
+	defer PutBuffer(buf)
 
-	err = c.redisClient.Publish(c.getAsyncKey(pl.GetBasePayload().TransactionID), b).Err()
-	if err != nil {
-		return errors.Wrap(err, "publish answer error")
+	// answers received as callback are matched directly to the pending
+	// long-poll requests
+	if c.answerBus == nil {
+		return c.longPoll.deliver(append([]byte(nil), buf.Bytes()...))
 	}
 
-	return nil
+	return c.answerBus.Publish(ctx, pl.GetBasePayload().TransactionID, buf.Bytes())
 }
 
-func (c *client) SendAnswer(ctx context.Context, pl Answer) error {
-	b, err := json.Marshal(pl)
-	if err != nil {
-		return errors.Wrap(err, "json marshal error")
+func (c *client) SendAnswer(ctx context.Context, pl Answer) (err error) {
+	capture := c.newCapture(pl.GetBasePayload().BasePayload, "")
+	if capture != nil {
+		defer func() {
+			capture.Error = err
+			c.sendCapture(capture)
+		}()
 	}
 
-	// TODO add context for cancellation
-	resp, err := c.httpClient.Post(c.server, "application/json", bytes.NewReader(b))
+	return c.transport.SendAnswer(contextWithCapture(ctx, capture), pl)
+}
+
+// GetRandomTransactionID returns a new transaction ID, or 0 when no ID could
+// be allocated. As 0 means that no transaction ID is set, the request
+// methods will then try to allocate an ID again and return the error.
+//
+// Deprecated: use AllocateTransactionID.
+func (c *client) GetRandomTransactionID() uint32 {
+	id, err := c.AllocateTransactionID()
 	if err != nil {
-		return errors.Wrap(err, "http post error")
+		c.log.WithError(err).Error("lorawan/backend: allocate transaction id error")
+		return 0
 	}
-	defer resp.Body.Close()
+	return id
+}
 
-	if resp.StatusCode != 200 {
-		bb, err := ioutil.ReadAll(resp.Body)
+// AllocateTransactionID returns a new transaction ID. When a
+// TransactionManager is configured, the ID is allocated by the
+// TransactionManager, else a random ID is returned.
+func (c *client) AllocateTransactionID() (uint32, error) {
+	if c.txManager != nil {
+		tx, err := c.txManager.Allocate()
 		if err != nil {
-			return errors.Wrap(err, "read body error")
+			return 0, errors.Wrap(err, "allocate transaction id error")
 		}
-		return fmt.Errorf("expected: 200, got: %d (%s)", resp.StatusCode, string(bb))
+		return tx.TransactionID, nil
 	}
 
-	return nil
-}
-
-func (c *client) GetRandomTransactionID() uint32 {
-	b := make([]byte, 4)
-	rand.Read(b)
-	return binary.LittleEndian.Uint32(b)
-}
+	for {
+		b := make([]byte, 4)
+		if _, err := io.ReadFull(c.rand, b); err != nil {
+			return 0, errors.Wrap(err, "read random bytes error")
+		}
 
-func (c *client) getAsyncKey(id uint32) string {
-	return fmt.Sprintf("lora:backend:async:%d", id)
+		// 0 indicates that no transaction ID was set
+		if id := binary.LittleEndian.Uint32(b); id != 0 {
+			return id, nil
+		}
+	}
 }
 
-func (c *client) readAsync(ctx context.Context, key string) ([]byte, error) {
-	sub := c.redisClient.Subscribe(key)
-	defer sub.Close()
-
-	ch := sub.Channel()
+// newCapture returns a new Capture for the given payload, or nil when no
+// CaptureFunc is configured.
+func (c *client) newCapture(pl BasePayload, traceID string) *Capture {
+	if c.captureFunc == nil {
+		return nil
+	}
 
-	select {
-	case msg := <-ch:
-		return []byte(msg.Payload), nil
-	case <-time.After(c.asyncTimeout):
-		return nil, ErrAsyncTimeout
+	return &Capture{
+		TransactionID: pl.TransactionID,
+		MessageType:   pl.MessageType,
+		TraceID:       traceID,
+		Time:          c.now(),
 	}
 }
+
+func (c *client) sendCapture(capture *Capture) {
+	capture.Duration = c.now().Sub(capture.Time)
+	c.captureFunc(*capture)
+}
@@ -3,9 +3,13 @@ package backend
 import (
 	"context"
 	"encoding/json"
+	"encoding/pem"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -150,6 +154,54 @@ func (ts *SyncClientTestSuite) TestRejoinReq() {
 	assert.Equal(string(reqB), ts.apiRequest)
 }
 
+func (ts *SyncClientTestSuite) TestAppSKeyReq() {
+	assert := require.New(ts.T())
+
+	req := AppSKeyReqPayload{
+		BasePayload: BasePayload{
+			ProtocolVersion: ProtocolVersion1_0,
+			SenderID:        "010101",
+			ReceiverID:      "020202",
+			TransactionID:   123,
+			MessageType:     AppSKeyReq,
+		},
+		DevEUI:       lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		SessionKeyID: HEXBytes{1, 2, 3},
+	}
+	reqB, err := json.Marshal(req)
+	assert.NoError(err)
+
+	resp := AppSKeyAnsPayload{
+		BasePayloadResult: BasePayloadResult{
+			BasePayload: BasePayload{
+				ProtocolVersion: ProtocolVersion1_0,
+				SenderID:        "020202",
+				ReceiverID:      "010101",
+				TransactionID:   123,
+				MessageType:     AppSKeyAns,
+			},
+			Result: Result{
+				ResultCode: Success,
+			},
+		},
+		DevEUI: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		AppSKey: &KeyEnvelope{
+			KEKLabel: "as-1",
+			AESKey:   HEXBytes{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		},
+		SessionKeyID: HEXBytes{1, 2, 3},
+	}
+	respB, err := json.Marshal(resp)
+	assert.NoError(err)
+	ts.apiResponse = string(respB)
+
+	apiResp, err := ts.client.AppSKeyReq(context.Background(), req)
+	assert.NoError(err)
+	assert.Equal(resp, apiResp)
+
+	assert.Equal(string(reqB), ts.apiRequest)
+}
+
 func (ts *SyncClientTestSuite) TestPRStartReq() {
 	assert := require.New(ts.T())
 
@@ -263,6 +315,109 @@ func (ts *SyncClientTestSuite) TestPRStopReq() {
 	assert.Equal(string(reqB), ts.apiRequest)
 }
 
+func (ts *SyncClientTestSuite) TestHRStartReq() {
+	assert := require.New(ts.T())
+
+	devAddr := lorawan.DevAddr{1, 2, 3, 4}
+	dr := 2
+	uFreq := 868.1
+	lifetime := 60
+
+	req := HRStartReqPayload{
+		BasePayload: BasePayload{
+			ProtocolVersion: ProtocolVersion1_0,
+			SenderID:        "010101",
+			ReceiverID:      "020202",
+			TransactionID:   123,
+			MessageType:     HRStartReq,
+		},
+		MACVersion: "1.0.3",
+		PHYPayload: []byte{1, 2, 3, 4},
+		DevAddr:    devAddr,
+		ULMetaData: ULMetaData{
+			DevAddr:  &devAddr,
+			DataRate: &dr,
+			ULFreq:   &uFreq,
+			RecvTime: ISO8601Time(time.Now()),
+			RFRegion: string(band.EU868),
+		},
+		DLSettings: lorawan.DLSettings{RX2DataRate: 3},
+		RxDelay:    1,
+	}
+	reqB, err := json.Marshal(req)
+	assert.NoError(err)
+
+	resp := HRStartAnsPayload{
+		BasePayloadResult: BasePayloadResult{
+			BasePayload: BasePayload{
+				ProtocolVersion: ProtocolVersion1_0,
+				SenderID:        "020202",
+				ReceiverID:      "010101",
+				TransactionID:   123,
+				MessageType:     HRStartAns,
+			},
+			Result: Result{
+				ResultCode: Success,
+			},
+		},
+		PHYPayload: HEXBytes{4, 3, 2, 1},
+		Lifetime:   &lifetime,
+		NwkSKey: &KeyEnvelope{
+			AESKey: HEXBytes{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		},
+	}
+	respB, err := json.Marshal(resp)
+	assert.NoError(err)
+	ts.apiResponse = string(respB)
+
+	apiResp, err := ts.client.HRStartReq(context.Background(), req)
+	assert.NoError(err)
+	assert.Equal(resp, apiResp)
+
+	assert.Equal(string(reqB), ts.apiRequest)
+}
+
+func (ts *SyncClientTestSuite) TestHRStopReq() {
+	assert := require.New(ts.T())
+
+	req := HRStopReqPayload{
+		BasePayload: BasePayload{
+			ProtocolVersion: ProtocolVersion1_0,
+			SenderID:        "010101",
+			ReceiverID:      "020202",
+			TransactionID:   123,
+			MessageType:     HRStopReq,
+		},
+		DevEUI: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+	}
+	reqB, err := json.Marshal(req)
+	assert.NoError(err)
+
+	resp := HRStopAnsPayload{
+		BasePayloadResult: BasePayloadResult{
+			BasePayload: BasePayload{
+				ProtocolVersion: ProtocolVersion1_0,
+				SenderID:        "020202",
+				ReceiverID:      "010101",
+				TransactionID:   123,
+				MessageType:     HRStopAns,
+			},
+			Result: Result{
+				ResultCode: Success,
+			},
+		},
+	}
+	respB, err := json.Marshal(resp)
+	assert.NoError(err)
+	ts.apiResponse = string(respB)
+
+	apiResp, err := ts.client.HRStopReq(context.Background(), req)
+	assert.NoError(err)
+	assert.Equal(resp, apiResp)
+
+	assert.Equal(string(reqB), ts.apiRequest)
+}
+
 func (ts *SyncClientTestSuite) TestXmitDataReq() {
 	assert := require.New(ts.T())
 
@@ -492,6 +647,42 @@ func (ts *AysncClientTestSuite) TestRequestTimeout() {
 	assert.Equal(ErrAsyncTimeout, errors.Cause(err))
 }
 
+func (ts *AysncClientTestSuite) TestTransactionRecord() {
+	assert := require.New(ts.T())
+
+	client, err := NewClient(ClientConfig{
+		SenderID:            "010101",
+		ReceiverID:          "020202",
+		Server:              ts.server.URL,
+		RedisClient:         ts.redisClient,
+		RedisKeyPrefix:      "test:async:",
+		RedisTransactionTTL: time.Minute,
+		AsyncTimeout:        time.Millisecond * 100,
+	})
+	assert.NoError(err)
+
+	// the record exists while the request is pending
+	records := make(chan []byte, 1)
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		bb, _ := ts.redisClient.Get("test:async:tx:123").Bytes()
+		records <- bb
+	}()
+
+	_, err = client.HomeNSReq(context.Background(), HomeNSReqPayload{
+		BasePayload: BasePayload{TransactionID: 123},
+	})
+	assert.Equal(ErrAsyncTimeout, err)
+
+	var record RedisTransactionRecord
+	assert.NoError(json.Unmarshal(<-records, &record))
+	assert.Equal(uint32(123), record.TransactionID)
+
+	n, err := ts.redisClient.Exists("test:async:tx:123").Result()
+	assert.NoError(err)
+	assert.Equal(int64(0), n)
+}
+
 func (ts *AysncClientTestSuite) TestWrongTransactionID() {
 	assert := require.New(ts.T())
 
@@ -605,6 +796,44 @@ func (ts *AysncClientTestSuite) TestRejoinReq() {
 	assert.Equal(ans, resp)
 }
 
+func (ts *AysncClientTestSuite) TestAppSKeyReq() {
+	assert := require.New(ts.T())
+
+	req := AppSKeyReqPayload{
+		BasePayload: BasePayload{
+			ProtocolVersion: ProtocolVersion1_0,
+			SenderID:        "010101",
+			ReceiverID:      "020202",
+			TransactionID:   123,
+			MessageType:     AppSKeyReq,
+		},
+	}
+
+	ans := AppSKeyAnsPayload{
+		BasePayloadResult: BasePayloadResult{
+			BasePayload: BasePayload{
+				ProtocolVersion: ProtocolVersion1_0,
+				ReceiverID:      "010101",
+				SenderID:        "020202",
+				TransactionID:   123,
+				MessageType:     AppSKeyAns,
+			},
+			Result: Result{
+				ResultCode: Success,
+			},
+		},
+	}
+
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		assert.NoError(ts.client.HandleAnswer(context.Background(), ans))
+	}()
+
+	resp, err := ts.client.AppSKeyReq(context.Background(), req)
+	assert.NoError(err)
+	assert.Equal(ans, resp)
+}
+
 func (ts *AysncClientTestSuite) TestPRStartReq() {
 	assert := require.New(ts.T())
 
@@ -681,6 +910,82 @@ func (ts *AysncClientTestSuite) TestPRStopReq() {
 	assert.Equal(ans, resp)
 }
 
+func (ts *AysncClientTestSuite) TestHRStartReq() {
+	assert := require.New(ts.T())
+
+	req := HRStartReqPayload{
+		BasePayload: BasePayload{
+			ProtocolVersion: ProtocolVersion1_0,
+			SenderID:        "010101",
+			ReceiverID:      "020202",
+			TransactionID:   123,
+			MessageType:     HRStartReq,
+		},
+	}
+
+	ans := HRStartAnsPayload{
+		BasePayloadResult: BasePayloadResult{
+			BasePayload: BasePayload{
+				ProtocolVersion: ProtocolVersion1_0,
+				ReceiverID:      "010101",
+				SenderID:        "020202",
+				TransactionID:   123,
+				MessageType:     HRStartAns,
+			},
+			Result: Result{
+				ResultCode: Success,
+			},
+		},
+	}
+
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		assert.NoError(ts.client.HandleAnswer(context.Background(), ans))
+	}()
+
+	resp, err := ts.client.HRStartReq(context.Background(), req)
+	assert.NoError(err)
+	assert.Equal(ans, resp)
+}
+
+func (ts *AysncClientTestSuite) TestHRStopReq() {
+	assert := require.New(ts.T())
+
+	req := HRStopReqPayload{
+		BasePayload: BasePayload{
+			ProtocolVersion: ProtocolVersion1_0,
+			SenderID:        "010101",
+			ReceiverID:      "020202",
+			TransactionID:   123,
+			MessageType:     HRStopReq,
+		},
+	}
+
+	ans := HRStopAnsPayload{
+		BasePayloadResult: BasePayloadResult{
+			BasePayload: BasePayload{
+				ProtocolVersion: ProtocolVersion1_0,
+				ReceiverID:      "010101",
+				SenderID:        "020202",
+				TransactionID:   123,
+				MessageType:     HRStopAns,
+			},
+			Result: Result{
+				ResultCode: Success,
+			},
+		},
+	}
+
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		assert.NoError(ts.client.HandleAnswer(context.Background(), ans))
+	}()
+
+	resp, err := ts.client.HRStopReq(context.Background(), req)
+	assert.NoError(err)
+	assert.Equal(ans, resp)
+}
+
 func (ts *AysncClientTestSuite) TestXmitDataReq() {
 	assert := require.New(ts.T())
 
@@ -830,6 +1135,226 @@ func (ts *AysncClientTestSuite) apiHandler(w http.ResponseWriter, r *http.Reques
 	w.Write([]byte(ts.apiResponse))
 }
 
+func (ts *AysncClientTestSuite) TestConcurrentRequests() {
+	assert := require.New(ts.T())
+
+	var asyncClient Client
+	asyncServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ans, err := newConcurrencyTestAnswer(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// publish the answers in random order, while requests are pending
+		go func() {
+			time.Sleep(time.Duration(ans.GetBasePayload().TransactionID%10) * time.Millisecond)
+			if err := asyncClient.HandleAnswer(context.Background(), ans); err != nil {
+				ts.T().Error(err)
+			}
+		}()
+	}))
+	defer asyncServer.Close()
+
+	syncServer := httptest.NewServer(http.HandlerFunc(concurrencyTestHandler))
+	defer syncServer.Close()
+
+	var err error
+	asyncClient, err = NewClient(ClientConfig{
+		SenderID:           "010101",
+		ReceiverID:         "020202",
+		Server:             asyncServer.URL,
+		RedisClient:        ts.redisClient,
+		AsyncTimeout:       time.Second * 5,
+		TransactionManager: NewTransactionManager(0, nil),
+	})
+	assert.NoError(err)
+
+	syncClient, err := NewClient(ClientConfig{
+		SenderID:   "010101",
+		ReceiverID: "020202",
+		Server:     syncServer.URL,
+	})
+	assert.NoError(err)
+
+	assert.NoError(runConcurrentRequests([]Client{asyncClient, syncClient}, 200))
+}
+
 func TestAysncClient(t *testing.T) {
 	suite.Run(t, new(AysncClientTestSuite))
 }
+
+// newConcurrencyTestAnswer returns the answer for the given request, using
+// the transaction ID of the request.
+func newConcurrencyTestAnswer(r *http.Request) (Answer, error) {
+	var req BasePayload
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+
+	base := BasePayloadResult{
+		BasePayload: BasePayload{
+			ProtocolVersion: req.ProtocolVersion,
+			SenderID:        req.ReceiverID,
+			ReceiverID:      req.SenderID,
+			TransactionID:   req.TransactionID,
+		},
+		Result: Result{
+			ResultCode: Success,
+		},
+	}
+
+	switch req.MessageType {
+	case PRStartReq:
+		base.MessageType = PRStartAns
+		return PRStartAnsPayload{BasePayloadResult: base}, nil
+	case XmitDataReq:
+		base.MessageType = XmitDataAns
+		return XmitDataAnsPayload{BasePayloadResult: base}, nil
+	case ProfileReq:
+		base.MessageType = ProfileAns
+		return ProfileAnsPayload{BasePayloadResult: base}, nil
+	default:
+		return nil, fmt.Errorf("unexpected message-type: %s", req.MessageType)
+	}
+}
+
+func concurrencyTestHandler(w http.ResponseWriter, r *http.Request) {
+	ans, err := newConcurrencyTestAnswer(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(ans)
+}
+
+// runConcurrentRequests executes n concurrent requests for each of the
+// given clients, mixing message-types, and validates that each request
+// received the answer matching its transaction ID.
+func runConcurrentRequests(clients []Client, n int) error {
+	var wg sync.WaitGroup
+	errs := make(chan error, n*len(clients))
+
+	for _, c := range clients {
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(c Client, i int) {
+				defer wg.Done()
+
+				id, err := c.AllocateTransactionID()
+				if err != nil {
+					errs <- err
+					return
+				}
+				base := BasePayload{TransactionID: id}
+
+				var ans BasePayloadResult
+				switch i % 3 {
+				case 0:
+					var pl PRStartAnsPayload
+					pl, err = c.PRStartReq(context.Background(), PRStartReqPayload{BasePayload: base})
+					ans = pl.BasePayloadResult
+				case 1:
+					var pl XmitDataAnsPayload
+					pl, err = c.XmitDataReq(context.Background(), XmitDataReqPayload{BasePayload: base})
+					ans = pl.BasePayloadResult
+				case 2:
+					var pl ProfileAnsPayload
+					pl, err = c.ProfileReq(context.Background(), ProfileReqPayload{BasePayload: base})
+					ans = pl.BasePayloadResult
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+				if ans.TransactionID != id {
+					errs <- fmt.Errorf("expected TransactionID %d, got %d", id, ans.TransactionID)
+				}
+			}(c, i)
+		}
+	}
+
+	wg.Wait()
+	close(errs)
+
+	return <-errs
+}
+
+func TestClientConcurrentRequests(t *testing.T) {
+	assert := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(concurrencyTestHandler))
+	defer server.Close()
+
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(concurrencyTestHandler))
+	defer tlsServer.Close()
+
+	caCert, err := ioutil.TempFile("", "ca-cert")
+	assert.NoError(err)
+	defer os.Remove(caCert.Name())
+	assert.NoError(pem.Encode(caCert, &pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw}))
+	assert.NoError(caCert.Close())
+
+	client, err := NewClient(ClientConfig{
+		SenderID:   "010101",
+		ReceiverID: "020202",
+		Server:     server.URL,
+	})
+	assert.NoError(err)
+
+	txClient, err := NewClient(ClientConfig{
+		SenderID:           "010101",
+		ReceiverID:         "030303",
+		Server:             server.URL,
+		TransactionManager: NewTransactionManager(0, nil),
+	})
+	assert.NoError(err)
+
+	tlsClient, err := NewClient(ClientConfig{
+		SenderID:   "010101",
+		ReceiverID: "040404",
+		Server:     tlsServer.URL,
+		CACert:     caCert.Name(),
+	})
+	assert.NoError(err)
+
+	assert.NoError(runConcurrentRequests([]Client{client, txClient, tlsClient}, 200))
+}
+
+func TestClientContextCancellation(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client, err := NewClient(ClientConfig{
+		SenderID:   "010101",
+		ReceiverID: "020202",
+		Server:     server.URL,
+	})
+	require.NoError(t, err)
+
+	t.Run("request", func(t *testing.T) {
+		assert := require.New(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := client.HomeNSReq(ctx, HomeNSReqPayload{})
+		assert.Error(err)
+		assert.True(errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("send answer", func(t *testing.T) {
+		assert := require.New(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		err := client.SendAnswer(ctx, HomeNSAnsPayload{})
+		assert.Error(err)
+		assert.True(errors.Is(err, context.DeadlineExceeded))
+	})
+}
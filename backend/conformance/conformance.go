@@ -0,0 +1,471 @@
+// Package conformance implements a LoRaWAN Backend Interfaces conformance
+// test suite. It exercises a peer implementation (e.g. a roaming partner or
+// join-server) with a matrix of protocol versions and message-types and
+// validates the answer correlation, required answer fields, result codes
+// and the async behavior of the peer. The outcome is returned as a Report.
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brocaar/lorawan/backend"
+)
+
+// DefaultTimeout defines the default timeout of a single check.
+const DefaultTimeout = 10 * time.Second
+
+// Config holds the conformance suite configuration.
+type Config struct {
+	// Server holds the URL of the peer under test.
+	Server string
+
+	// SenderID and ReceiverID hold the IDs used in the requests.
+	SenderID   string
+	ReceiverID string
+
+	// HTTPClient holds the optional HTTP client (e.g. configured with TLS
+	// certificates). When not set, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// ProtocolVersions holds the protocol versions to test. When empty,
+	// backend.ProtocolVersion1_0 is tested.
+	ProtocolVersions []string
+
+	// MessageTypes holds the request message-types to test. As the
+	// supported message-types depend on the role of the peer (e.g.
+	// join-server or network-server), this must be set.
+	MessageTypes []backend.MessageType
+
+	// Requests holds the optional request templates by message-type, e.g.
+	// containing the DevEUI of a device known by the peer. The base
+	// payload fields are set by the suite. When no template is set for a
+	// message-type, a generic request is used.
+	Requests map[backend.MessageType]backend.Request
+
+	// AsyncListener holds the optional listener for receiving async
+	// answers. When set, the peer is expected to use the async protocol
+	// scheme and to send the answers to this listener.
+	AsyncListener net.Listener
+
+	// Timeout defines the timeout of a single check. When not set,
+	// DefaultTimeout is used.
+	Timeout time.Duration
+
+	// Rand holds the optional random source used for generating the
+	// transaction IDs. When nil, crypto/rand is used. A deterministic
+	// source can be used for reproducible runs.
+	Rand io.Reader
+}
+
+// Result holds the result of a single check.
+type Result struct {
+	// Name holds the name of the check, in the format
+	// ProtocolVersion/MessageType/check.
+	Name string
+
+	// Error holds the failure reason, it is nil when the check passed.
+	Error error
+
+	// Duration holds the duration of the check.
+	Duration time.Duration
+}
+
+// Passed returns true when the check passed.
+func (r Result) Passed() bool {
+	return r.Error == nil
+}
+
+// Report holds the conformance report.
+type Report struct {
+	Results []Result
+}
+
+// Passed returns true when all checks passed.
+func (r Report) Passed() bool {
+	for _, res := range r.Results {
+		if !res.Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteTo writes the human-readable report to w.
+func (r Report) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	var passed, failed int
+
+	for _, res := range r.Results {
+		if res.Passed() {
+			passed++
+			fmt.Fprintf(&buf, "PASS  %s (%s)\n", res.Name, res.Duration.Round(time.Millisecond))
+		} else {
+			failed++
+			fmt.Fprintf(&buf, "FAIL  %s (%s): %s\n", res.Name, res.Duration.Round(time.Millisecond), res.Error)
+		}
+	}
+	fmt.Fprintf(&buf, "\n%d passed, %d failed\n", passed, failed)
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// check defines a single conformance check.
+type check struct {
+	name string
+	run  func(ctx context.Context, s *suite, pv string, mt backend.MessageType) error
+}
+
+var checks = []check{
+	{"answer", checkAnswer},
+	{"malformed-request", checkMalformedRequest},
+	{"invalid-protocol-version", checkInvalidProtocolVersion},
+}
+
+// Run runs the conformance suite against the configured peer.
+func Run(ctx context.Context, config Config) (Report, error) {
+	var report Report
+
+	if config.Server == "" {
+		return report, errors.New("lorawan/backend/conformance: Server must be set")
+	}
+	if len(config.MessageTypes) == 0 {
+		return report, errors.New("lorawan/backend/conformance: MessageTypes must be set")
+	}
+	for _, mt := range config.MessageTypes {
+		if _, ok := config.Requests[mt]; ok {
+			continue
+		}
+		if _, ok := defaultRequests[mt]; !ok {
+			return report, fmt.Errorf("lorawan/backend/conformance: unsupported message-type: %s", mt)
+		}
+	}
+
+	s := suite{
+		config:  config,
+		answers: make(map[uint32]chan []byte),
+	}
+	if s.config.HTTPClient == nil {
+		s.config.HTTPClient = http.DefaultClient
+	}
+	if len(s.config.ProtocolVersions) == 0 {
+		s.config.ProtocolVersions = []string{backend.ProtocolVersion1_0}
+	}
+	if s.config.Timeout == 0 {
+		s.config.Timeout = DefaultTimeout
+	}
+	if s.config.Rand == nil {
+		s.config.Rand = rand.Reader
+	}
+
+	if s.config.AsyncListener != nil {
+		srv := http.Server{Handler: http.HandlerFunc(s.handleAsyncAnswer)}
+		go srv.Serve(s.config.AsyncListener)
+		defer srv.Close()
+	}
+
+	for _, pv := range s.config.ProtocolVersions {
+		for _, mt := range s.config.MessageTypes {
+			for _, c := range checks {
+				checkCtx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+				start := time.Now()
+				err := c.run(checkCtx, &s, pv, mt)
+				cancel()
+
+				report.Results = append(report.Results, Result{
+					Name:     fmt.Sprintf("%s/%s/%s", pv, mt, c.name),
+					Error:    err,
+					Duration: time.Since(start),
+				})
+			}
+		}
+	}
+
+	return report, nil
+}
+
+type suite struct {
+	config Config
+
+	mu      sync.Mutex
+	answers map[uint32]chan []byte
+}
+
+// exchange holds the raw request / answer exchange.
+type exchange struct {
+	request    []byte
+	statusCode int
+	answer     []byte // sync or async answer
+	async      bool   // answer was received async
+}
+
+// newRequest returns the JSON object of the request for the given
+// message-type, with the base payload fields set.
+func (s *suite) newRequest(pv string, mt backend.MessageType) (map[string]interface{}, uint32, error) {
+	req, ok := s.config.Requests[mt]
+	if !ok {
+		req = defaultRequests[mt]
+	}
+
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("marshal request error: %w", err)
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, 0, fmt.Errorf("unmarshal request error: %w", err)
+	}
+
+	var id [4]byte
+	if _, err := io.ReadFull(s.config.Rand, id[:]); err != nil {
+		return nil, 0, err
+	}
+	transactionID := binary.LittleEndian.Uint32(id[:])
+
+	obj["ProtocolVersion"] = pv
+	obj["SenderID"] = s.config.SenderID
+	obj["ReceiverID"] = s.config.ReceiverID
+	obj["TransactionID"] = transactionID
+	obj["MessageType"] = mt
+
+	return obj, transactionID, nil
+}
+
+// send sends the given request and returns the exchange. In async mode,
+// it waits for the async answer, unless the peer responds with an error
+// status code.
+func (s *suite) send(ctx context.Context, obj map[string]interface{}, transactionID uint32) (exchange, error) {
+	var ex exchange
+	var err error
+
+	ex.request, err = json.Marshal(obj)
+	if err != nil {
+		return ex, fmt.Errorf("marshal request error: %w", err)
+	}
+
+	var answerChan chan []byte
+	if s.config.AsyncListener != nil {
+		answerChan = make(chan []byte, 1)
+		s.mu.Lock()
+		s.answers[transactionID] = answerChan
+		s.mu.Unlock()
+
+		defer func() {
+			s.mu.Lock()
+			delete(s.answers, transactionID)
+			s.mu.Unlock()
+		}()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.config.Server, bytes.NewReader(ex.request))
+	if err != nil {
+		return ex, fmt.Errorf("new request error: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.config.HTTPClient.Do(req)
+	if err != nil {
+		return ex, fmt.Errorf("http post error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	ex.statusCode = resp.StatusCode
+	ex.answer, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return ex, fmt.Errorf("read response error: %w", err)
+	}
+
+	if answerChan == nil || resp.StatusCode != http.StatusOK {
+		return ex, nil
+	}
+
+	select {
+	case ex.answer = <-answerChan:
+		ex.async = true
+		return ex, nil
+	case <-ctx.Done():
+		return ex, errors.New("async answer timeout")
+	}
+}
+
+func (s *suite) handleAsyncAnswer(w http.ResponseWriter, r *http.Request) {
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var pl backend.BasePayload
+	if err := json.Unmarshal(b, &pl); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	answerChan, ok := s.answers[pl.TransactionID]
+	s.mu.Unlock()
+
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case answerChan <- b:
+	default:
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// checkAnswer validates that the peer answers a request with the matching
+// answer message-type, transaction ID and sender / receiver IDs and with
+// a valid result code.
+func checkAnswer(ctx context.Context, s *suite, pv string, mt backend.MessageType) error {
+	obj, transactionID, err := s.newRequest(pv, mt)
+	if err != nil {
+		return err
+	}
+
+	ex, err := s.send(ctx, obj, transactionID)
+	if err != nil {
+		return err
+	}
+
+	if ex.statusCode != http.StatusOK {
+		return fmt.Errorf("expected HTTP status 200, got %d (%s)", ex.statusCode, bytes.TrimSpace(ex.answer))
+	}
+
+	var ans backend.BasePayloadResult
+	if err := json.Unmarshal(ex.answer, &ans); err != nil {
+		return fmt.Errorf("unmarshal answer error: %w", err)
+	}
+
+	var errs []string
+	if exp := backend.AnswerMessageType(mt); ans.MessageType != exp {
+		errs = append(errs, fmt.Sprintf("expected MessageType %s, got %s", exp, ans.MessageType))
+	}
+	if ans.TransactionID != transactionID {
+		errs = append(errs, fmt.Sprintf("expected TransactionID %d, got %d", transactionID, ans.TransactionID))
+	}
+	if ans.ProtocolVersion != pv {
+		errs = append(errs, fmt.Sprintf("expected ProtocolVersion %s, got %s", pv, ans.ProtocolVersion))
+	}
+	if ans.SenderID != s.config.ReceiverID {
+		errs = append(errs, fmt.Sprintf("expected SenderID %s, got %s", s.config.ReceiverID, ans.SenderID))
+	}
+	if ans.ReceiverID != s.config.SenderID {
+		errs = append(errs, fmt.Sprintf("expected ReceiverID %s, got %s", s.config.SenderID, ans.ReceiverID))
+	}
+	if !resultCodes[ans.Result.ResultCode] {
+		errs = append(errs, fmt.Sprintf("invalid ResultCode: %q", ans.Result.ResultCode))
+	}
+
+	if len(errs) != 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// checkMalformedRequest validates that the peer rejects a request with an
+// invalid field value.
+func checkMalformedRequest(ctx context.Context, s *suite, pv string, mt backend.MessageType) error {
+	obj, transactionID, err := s.newRequest(pv, mt)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range []string{"PHYPayload", "DevEUI", "DevAddr", "ULMetaData"} {
+		if _, ok := obj[field]; ok {
+			obj[field] = 1234
+			break
+		}
+	}
+
+	return expectFailure(ctx, s, obj, transactionID)
+}
+
+// checkInvalidProtocolVersion validates that the peer rejects a request
+// with an unsupported protocol version.
+func checkInvalidProtocolVersion(ctx context.Context, s *suite, pv string, mt backend.MessageType) error {
+	obj, transactionID, err := s.newRequest(pv, mt)
+	if err != nil {
+		return err
+	}
+	obj["ProtocolVersion"] = "0.9"
+
+	return expectFailure(ctx, s, obj, transactionID)
+}
+
+// expectFailure sends the request and validates that the peer responds
+// with an error status code or with a non-Success result code.
+func expectFailure(ctx context.Context, s *suite, obj map[string]interface{}, transactionID uint32) error {
+	ex, err := s.send(ctx, obj, transactionID)
+	if err != nil {
+		return err
+	}
+
+	if ex.statusCode != http.StatusOK && !ex.async {
+		return nil
+	}
+
+	var ans backend.BasePayloadResult
+	if err := json.Unmarshal(ex.answer, &ans); err != nil {
+		return fmt.Errorf("unmarshal answer error: %w", err)
+	}
+	if ans.Result.ResultCode == backend.Success {
+		return errors.New("expected a failure ResultCode, got Success")
+	}
+	if ans.TransactionID != transactionID {
+		return fmt.Errorf("expected TransactionID %d, got %d", transactionID, ans.TransactionID)
+	}
+
+	return nil
+}
+
+// MessageTypes returns the request message-types supported by the suite.
+func MessageTypes() []backend.MessageType {
+	var out []backend.MessageType
+	for mt := range defaultRequests {
+		out = append(out, mt)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+var resultCodes = map[backend.ResultCode]bool{
+	backend.Success:                true,
+	backend.MICFailed:              true,
+	backend.JoinReqFailed:          true,
+	backend.NoRoamingAgreement:     true,
+	backend.DevRoamingDisallowed:   true,
+	backend.RoamingActDisallowed:   true,
+	backend.ActivationDisallowed:   true,
+	backend.UnknownDevEUI:          true,
+	backend.UnknownDevAddr:         true,
+	backend.UnknownSender:          true,
+	backend.UnknownReceiver:        true,
+	backend.Deferred:               true,
+	backend.XmitFailed:             true,
+	backend.InvalidFPort:           true,
+	backend.InvalidProtocolVersion: true,
+	backend.StaleDeviceProfile:     true,
+	backend.MalformedRequest:       true,
+	backend.FrameSizeError:         true,
+	backend.Other:                  true,
+}
@@ -0,0 +1,194 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan/backend"
+	"github.com/brocaar/lorawan/backend/backendtest"
+	"github.com/brocaar/lorawan/backend/server"
+)
+
+// conformingHandler answers the request with the matching result-code. The
+// malformed requests are rejected by the server.Handler of the Peer.
+func conformingHandler(ctx context.Context, req backend.Request) (backend.Answer, error) {
+	pl := req.GetBasePayload()
+	if pl.ProtocolVersion != backend.ProtocolVersion1_0 {
+		return backendtest.NewBasePayloadResult(pl, backend.InvalidProtocolVersion, ""), nil
+	}
+	return backendtest.NewBasePayloadResult(pl, backend.Success, ""), nil
+}
+
+func newTestPeer(config backendtest.PeerConfig, fn server.HandlerFunc) *backendtest.Peer {
+	peer := backendtest.NewPeer(config)
+	for _, mt := range testMessageTypes() {
+		if err := peer.Handle(mt, fn); err != nil {
+			panic(err)
+		}
+	}
+	return peer
+}
+
+func testMessageTypes() []backend.MessageType {
+	return []backend.MessageType{backend.JoinReq, backend.PRStartReq, backend.ProfileReq, backend.XmitDataReq}
+}
+
+func TestRunSync(t *testing.T) {
+	assert := require.New(t)
+
+	peer := newTestPeer(backendtest.PeerConfig{}, conformingHandler)
+	defer peer.Close()
+
+	report, err := Run(context.Background(), Config{
+		Server:       peer.URL(),
+		SenderID:     "010101",
+		ReceiverID:   "020202",
+		MessageTypes: testMessageTypes(),
+	})
+	assert.NoError(err)
+	assert.Len(report.Results, 4*len(checks))
+	for _, res := range report.Results {
+		assert.NoError(res.Error, res.Name)
+	}
+	assert.True(report.Passed())
+	assert.Equal("1.0/JoinReq/answer", report.Results[0].Name)
+
+	var buf bytes.Buffer
+	_, err = report.WriteTo(&buf)
+	assert.NoError(err)
+	assert.Contains(buf.String(), "PASS  1.0/XmitDataReq/malformed-request")
+	assert.Contains(buf.String(), "12 passed, 0 failed")
+}
+
+func TestRunRand(t *testing.T) {
+	assert := require.New(t)
+
+	peer := newTestPeer(backendtest.PeerConfig{}, conformingHandler)
+	defer peer.Close()
+
+	report, err := Run(context.Background(), Config{
+		Server:       peer.URL(),
+		SenderID:     "010101",
+		ReceiverID:   "020202",
+		MessageTypes: []backend.MessageType{backend.ProfileReq},
+		Rand:         bytes.NewReader(bytes.Repeat([]byte{1, 0, 0, 0}, len(checks))),
+	})
+	assert.NoError(err)
+	assert.True(report.Passed())
+
+	requests := peer.Requests()
+	assert.Len(requests, len(checks))
+	for _, req := range requests {
+		assert.Equal(uint32(1), req.TransactionID)
+	}
+}
+
+func TestRunAsync(t *testing.T) {
+	assert := require.New(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+
+	peer := newTestPeer(backendtest.PeerConfig{
+		AsyncAnswerServer: "http://" + ln.Addr().String(),
+	}, conformingHandler)
+	defer peer.Close()
+
+	report, err := Run(context.Background(), Config{
+		Server:        peer.URL(),
+		SenderID:      "010101",
+		ReceiverID:    "020202",
+		MessageTypes:  testMessageTypes(),
+		AsyncListener: ln,
+	})
+	assert.NoError(err)
+	for _, res := range report.Results {
+		assert.NoError(res.Error, res.Name)
+	}
+	assert.True(report.Passed())
+}
+
+func TestRunNonConforming(t *testing.T) {
+	assert := require.New(t)
+
+	peer := newTestPeer(backendtest.PeerConfig{}, func(ctx context.Context, req backend.Request) (backend.Answer, error) {
+		pl := req.GetBasePayload()
+		ans := backendtest.NewBasePayloadResult(pl, backend.Success, "")
+		ans.SenderID = pl.SenderID
+		return ans, nil
+	})
+	defer peer.Close()
+
+	report, err := Run(context.Background(), Config{
+		Server:       peer.URL(),
+		SenderID:     "010101",
+		ReceiverID:   "020202",
+		MessageTypes: []backend.MessageType{backend.ProfileReq},
+	})
+	assert.NoError(err)
+	assert.False(report.Passed())
+	assert.Len(report.Results, 3)
+
+	assert.EqualError(report.Results[0].Error, "expected SenderID 020202, got 010101")
+	// malformed requests are rejected by the server.Handler of the Peer
+	assert.NoError(report.Results[1].Error)
+	assert.EqualError(report.Results[2].Error, "expected a failure ResultCode, got Success")
+
+	var buf bytes.Buffer
+	_, err = report.WriteTo(&buf)
+	assert.NoError(err)
+	assert.Contains(buf.String(), "FAIL  1.0/ProfileReq/answer")
+	assert.Contains(buf.String(), "1 passed, 2 failed")
+}
+
+func TestRunAsyncTimeout(t *testing.T) {
+	assert := require.New(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+
+	// the peer answers sync, while async is expected
+	peer := newTestPeer(backendtest.PeerConfig{}, conformingHandler)
+	defer peer.Close()
+
+	report, err := Run(context.Background(), Config{
+		Server:        peer.URL(),
+		MessageTypes:  []backend.MessageType{backend.ProfileReq},
+		AsyncListener: ln,
+		Timeout:       100 * time.Millisecond,
+	})
+	assert.NoError(err)
+	assert.EqualError(report.Results[0].Error, "async answer timeout")
+}
+
+func TestRunConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		err    string
+	}{
+		{"no server", Config{MessageTypes: []backend.MessageType{backend.JoinReq}}, "lorawan/backend/conformance: Server must be set"},
+		{"no message-types", Config{Server: "http://localhost"}, "lorawan/backend/conformance: MessageTypes must be set"},
+		{"answer message-type", Config{Server: "http://localhost", MessageTypes: []backend.MessageType{backend.JoinAns}}, "lorawan/backend/conformance: unsupported message-type: JoinAns"},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			_, err := Run(context.Background(), tst.config)
+			require.EqualError(t, err, tst.err)
+		})
+	}
+}
+
+func TestMessageTypes(t *testing.T) {
+	assert := require.New(t)
+
+	mts := MessageTypes()
+	assert.Len(mts, 11)
+	assert.Equal(backend.AppSKeyReq, mts[0])
+}
@@ -0,0 +1,69 @@
+package conformance
+
+import (
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/backend"
+)
+
+// defaultRequests holds the generic request templates used when no
+// template is configured for a message-type. As the device identifiers are
+// unlikely to be known by the peer, a non-Success ResultCode (e.g.
+// UnknownDevEUI) is a valid answer to these requests.
+var defaultRequests = map[backend.MessageType]backend.Request{
+	backend.JoinReq: backend.JoinReqPayload{
+		MACVersion: "1.0.3",
+		PHYPayload: backend.HEXBytes{0x00, 0x02, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01, 0x02, 0x01, 0x0a, 0x0b, 0x0c, 0x0d},
+		DevEUI:     lorawan.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+		DevAddr:    lorawan.DevAddr{0x01, 0x02, 0x03, 0x04},
+		RxDelay:    1,
+	},
+	backend.RejoinReq: backend.RejoinReqPayload{
+		MACVersion: "1.1.0",
+		PHYPayload: backend.HEXBytes{0xc0, 0x00, 0x01, 0x02, 0x03, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x01, 0x00, 0x0a, 0x0b, 0x0c, 0x0d},
+		DevEUI:     lorawan.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+		DevAddr:    lorawan.DevAddr{0x01, 0x02, 0x03, 0x04},
+		RxDelay:    1,
+	},
+	backend.AppSKeyReq: backend.AppSKeyReqPayload{
+		DevEUI:       lorawan.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+		SessionKeyID: backend.HEXBytes{0x01, 0x02, 0x03, 0x04},
+	},
+	backend.VSMcKEKeyReq: backend.McKEKeyReqPayload{
+		DevEUI: lorawan.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+	},
+	backend.PRStartReq: backend.PRStartReqPayload{
+		PHYPayload: backend.HEXBytes{0x40, 0x04, 0x03, 0x02, 0x01, 0x80, 0x01, 0x00, 0x01, 0x99, 0xc4, 0xe5, 0xe4, 0x3e, 0x0b, 0x05},
+		ULMetaData: backend.ULMetaData{
+			DevAddr:  &lorawan.DevAddr{0x01, 0x02, 0x03, 0x04},
+			RFRegion: "EU868",
+		},
+	},
+	backend.PRStopReq: backend.PRStopReqPayload{
+		DevEUI: lorawan.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+	},
+	backend.HRStartReq: backend.HRStartReqPayload{
+		MACVersion: "1.0.3",
+		PHYPayload: backend.HEXBytes{0x20, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10},
+		DevAddr:    lorawan.DevAddr{0x01, 0x02, 0x03, 0x04},
+		ULMetaData: backend.ULMetaData{
+			RFRegion: "EU868",
+		},
+		RxDelay: 1,
+	},
+	backend.HRStopReq: backend.HRStopReqPayload{
+		DevEUI: lorawan.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+	},
+	backend.HomeNSReq: backend.HomeNSReqPayload{
+		DevEUI: lorawan.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+	},
+	backend.ProfileReq: backend.ProfileReqPayload{
+		DevEUI: lorawan.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+	},
+	backend.XmitDataReq: backend.XmitDataReqPayload{
+		PHYPayload: backend.HEXBytes{0x40, 0x04, 0x03, 0x02, 0x01, 0x80, 0x01, 0x00, 0x01, 0x99, 0xc4, 0xe5, 0xe4, 0x3e, 0x0b, 0x05},
+		ULMetaData: &backend.ULMetaData{
+			DevAddr:  &lorawan.DevAddr{0x01, 0x02, 0x03, 0x04},
+			RFRegion: "EU868",
+		},
+	},
+}
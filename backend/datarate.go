@@ -0,0 +1,107 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lorawan/band"
+)
+
+// DataRate defines a data-rate, expressed either as a regional data-rate
+// index or as modulation parameters (e.g. LoRa SF7 / 125 kHz).
+//
+// When expressed as index, it is marshaled to JSON as an integer, which
+// makes it compatible with the DataRate field of the ULMetaData and
+// DLMetaData. When expressed as modulation parameters, it is marshaled as
+// JSON object. Use Resolve to convert the modulation parameters to the
+// index of a given band before sending it to a Backend Interfaces peer.
+type DataRate struct {
+	index      int
+	parameters *band.DataRate
+}
+
+// NewDataRateIndex returns a DataRate for the given data-rate index.
+func NewDataRateIndex(dr int) DataRate {
+	return DataRate{index: dr}
+}
+
+// NewDataRateParameters returns a DataRate for the given modulation
+// parameters.
+func NewDataRateParameters(dr band.DataRate) DataRate {
+	return DataRate{parameters: &dr}
+}
+
+// IsIndex returns true when the data-rate is expressed as index.
+func (d DataRate) IsIndex() bool {
+	return d.parameters == nil
+}
+
+// Index returns the data-rate index within the given band. The uplink
+// argument is used for bands defining different indices for the same
+// modulation parameters in uplink and downlink direction.
+func (d DataRate) Index(b band.Band, uplink bool) (int, error) {
+	if d.IsIndex() {
+		if _, err := b.GetDataRate(d.index); err != nil {
+			return 0, err
+		}
+		return d.index, nil
+	}
+
+	return b.GetDataRateIndex(uplink, *d.parameters)
+}
+
+// Parameters returns the modulation parameters of the data-rate within
+// the given band.
+func (d DataRate) Parameters(b band.Band) (band.DataRate, error) {
+	if d.IsIndex() {
+		return b.GetDataRate(d.index)
+	}
+
+	return *d.parameters, nil
+}
+
+// Resolve returns the DataRate expressed as data-rate index of the given
+// band.
+func (d DataRate) Resolve(b band.Band, uplink bool) (DataRate, error) {
+	dr, err := d.Index(b, uplink)
+	if err != nil {
+		return DataRate{}, err
+	}
+
+	return NewDataRateIndex(dr), nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (d DataRate) MarshalJSON() ([]byte, error) {
+	if d.IsIndex() {
+		return json.Marshal(d.index)
+	}
+
+	return json.Marshal(d.parameters)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (d *DataRate) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+
+	if len(b) != 0 && b[0] == '{' {
+		var dr band.DataRate
+		if err := json.Unmarshal(b, &dr); err != nil {
+			return errors.Wrap(err, "unmarshal data-rate parameters error")
+		}
+		if dr.Modulation == "" {
+			return errors.New("data-rate modulation must be set")
+		}
+		*d = NewDataRateParameters(dr)
+		return nil
+	}
+
+	var dr int
+	if err := json.Unmarshal(b, &dr); err != nil {
+		return errors.Wrap(err, "unmarshal data-rate index error")
+	}
+	*d = NewDataRateIndex(dr)
+	return nil
+}
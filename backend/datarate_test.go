@@ -0,0 +1,87 @@
+package backend
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
+)
+
+func TestDataRate(t *testing.T) {
+	b, err := band.GetConfig(band.EU868, false, lorawan.DwellTimeNoLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sf7 := band.DataRate{Modulation: band.LoRaModulation, SpreadFactor: 7, Bandwidth: 125}
+
+	tests := []struct {
+		Name               string
+		DataRate           DataRate
+		ExpectedJSON       string
+		ExpectedIndex      int
+		ExpectedParameters band.DataRate
+	}{
+		{
+			Name:               "index",
+			DataRate:           NewDataRateIndex(5),
+			ExpectedJSON:       `5`,
+			ExpectedIndex:      5,
+			ExpectedParameters: sf7,
+		},
+		{
+			Name:               "parameters",
+			DataRate:           NewDataRateParameters(sf7),
+			ExpectedJSON:       `{"modulation":"LORA","spreadFactor":7,"bandwidth":125}`,
+			ExpectedIndex:      5,
+			ExpectedParameters: sf7,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			b1, err := json.Marshal(tst.DataRate)
+			assert.NoError(err)
+			assert.Equal(tst.ExpectedJSON, string(b1))
+
+			var dr DataRate
+			assert.NoError(json.Unmarshal(b1, &dr))
+			assert.Equal(tst.DataRate, dr)
+
+			i, err := dr.Index(b, true)
+			assert.NoError(err)
+			assert.Equal(tst.ExpectedIndex, i)
+
+			params, err := dr.Parameters(b)
+			assert.NoError(err)
+			assert.True(params.Modulation == tst.ExpectedParameters.Modulation &&
+				params.SpreadFactor == tst.ExpectedParameters.SpreadFactor &&
+				params.Bandwidth == tst.ExpectedParameters.Bandwidth)
+
+			resolved, err := dr.Resolve(b, true)
+			assert.NoError(err)
+			assert.True(resolved.IsIndex())
+
+			b2, err := json.Marshal(resolved)
+			assert.NoError(err)
+			assert.Equal(`5`, string(b2))
+		})
+	}
+
+	t.Run("invalid index", func(t *testing.T) {
+		assert := require.New(t)
+		_, err := NewDataRateIndex(20).Index(b, true)
+		assert.Error(err)
+	})
+
+	t.Run("missing modulation", func(t *testing.T) {
+		assert := require.New(t)
+		var dr DataRate
+		assert.EqualError(json.Unmarshal([]byte(`{"spreadFactor":7}`), &dr), "data-rate modulation must be set")
+	})
+}
@@ -0,0 +1,82 @@
+//go:build go1.18
+// +build go1.18
+
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+var fuzzPayloads = map[MessageType]func() interface{}{
+	JoinReq:      func() interface{} { return &JoinReqPayload{} },
+	JoinAns:      func() interface{} { return &JoinAnsPayload{} },
+	RejoinReq:    func() interface{} { return &RejoinReqPayload{} },
+	RejoinAns:    func() interface{} { return &RejoinAnsPayload{} },
+	AppSKeyReq:   func() interface{} { return &AppSKeyReqPayload{} },
+	AppSKeyAns:   func() interface{} { return &AppSKeyAnsPayload{} },
+	VSMcKEKeyReq: func() interface{} { return &McKEKeyReqPayload{} },
+	VSMcKEKeyAns: func() interface{} { return &McKEKeyAnsPayload{} },
+	PRStartReq:   func() interface{} { return &PRStartReqPayload{} },
+	PRStartAns:   func() interface{} { return &PRStartAnsPayload{} },
+	PRStopReq:    func() interface{} { return &PRStopReqPayload{} },
+	PRStopAns:    func() interface{} { return &PRStopAnsPayload{} },
+	HRStartReq:   func() interface{} { return &HRStartReqPayload{} },
+	HRStartAns:   func() interface{} { return &HRStartAnsPayload{} },
+	HRStopReq:    func() interface{} { return &HRStopReqPayload{} },
+	HRStopAns:    func() interface{} { return &HRStopAnsPayload{} },
+	HomeNSReq:    func() interface{} { return &HomeNSReqPayload{} },
+	HomeNSAns:    func() interface{} { return &HomeNSAnsPayload{} },
+	ProfileReq:   func() interface{} { return &ProfileReqPayload{} },
+	ProfileAns:   func() interface{} { return &ProfileAnsPayload{} },
+	XmitDataReq:  func() interface{} { return &XmitDataReqPayload{} },
+	XmitDataAns:  func() interface{} { return &XmitDataAnsPayload{} },
+}
+
+func FuzzPayloadUnmarshalJSON(f *testing.F) {
+	f.Add([]byte(`{"ProtocolVersion":"1.0","SenderID":"000000","ReceiverID":"0102030405060708","TransactionID":1234,"MessageType":"JoinReq","MACVersion":"1.0.2","PHYPayload":"00040302010403020105040302050403022d106a990e12","DevEUI":"0102030405060708","DevAddr":"01020304","DLSettings":"00","RxDelay":1,"CFList":""}`))
+	f.Add([]byte(`{"ProtocolVersion":"1.0","SenderID":"0102030405060708","ReceiverID":"000000","TransactionID":1234,"MessageType":"JoinAns","PHYPayload":"20493eeb51fba2116f810edb3742975142","Result":{"ResultCode":"Success"},"Lifetime":3600,"NwkSKey":{"KEKLabel":"","AESKey":"000102030405060708090a0b0c0d0e0f"}}`))
+	f.Add([]byte(`{"ProtocolVersion":"1.0","SenderID":"010203","ReceiverID":"030201","TransactionID":1,"MessageType":"PRStartReq","PHYPayload":"4004030201800100","ULMetaData":{"DevAddr":"01020304","DataRate":5,"ULFreq":868.1,"RecvTime":"2020-01-01T00:00:00Z","RFRegion":"EU868","GWCnt":1,"GWInfo":[{"ID":"0102030405060708","RSSI":-60,"SNR":5.5,"ULToken":"0102"}]}}`))
+	f.Add([]byte(`{"ProtocolVersion":"1.0","SenderID":"010203","ReceiverID":"030201","TransactionID":2,"MessageType":"XmitDataReq","PHYPayload":"6004030201000100","DLMetaData":{"DevEUI":"0102030405060708","DLFreq1":868.1,"DataRate1":5,"RXDelay1":1,"ClassMode":"A","GWInfo":[{"ULToken":"0102"}]}}`))
+	f.Add([]byte(`{"ProtocolVersion":"1.0","SenderID":"010203","ReceiverID":"030201","TransactionID":3,"MessageType":"ProfileAns","Result":{"ResultCode":"Success"},"DeviceProfile":{"SupportsJoin":true,"RFRegion":"EU868","RXFreq2":869.525},"RoamingActivationType":"Active"}`))
+	f.Add([]byte(`{"MessageType":"HRStartReq","ULFreq":"868.1"}`))
+	f.Add([]byte(`{"MessageType":"VS-McKEKeyAns","McKEKey":{"KEKLabel":"as","AESKey":"00"}}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var base BasePayload
+		if err := json.Unmarshal(data, &base); err != nil {
+			return
+		}
+
+		newFunc, ok := fuzzPayloads[base.MessageType]
+		if !ok {
+			return
+		}
+
+		v1 := newFunc()
+		if err := json.Unmarshal(data, v1); err != nil {
+			return
+		}
+
+		b, err := json.Marshal(v1)
+		if err != nil {
+			return
+		}
+
+		v2 := newFunc()
+		if err := json.Unmarshal(b, v2); err != nil {
+			t.Fatalf("%T: unmarshal json %s error: %s", v2, b, err)
+		}
+
+		// nil and empty values are not distinguished, compare the JSON
+		// encoding instead of the decoded objects
+		b2, err := json.Marshal(v2)
+		if err != nil {
+			t.Fatalf("%T: marshal json error: %s", v2, err)
+		}
+		if !bytes.Equal(b, b2) {
+			t.Fatalf("%T: json round-trip mismatch: %s != %s", v1, b, b2)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package backend
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lorawan"
+)
+
+// NetworkSession holds the network-server view of a session which was
+// activated by the join-server. As the (serving) network-server does not
+// hold the root keys, it is unable to validate the MIC of the join-accept.
+// It only has access to the network session keys. The AppSKey remains
+// encrypted and must be forwarded to the application-server, or it can
+// be requested by the application-server using the SessionKeyID.
+type NetworkSession struct {
+	// PHYPayload holds the (encrypted) join-accept which must be sent to
+	// the device.
+	PHYPayload lorawan.PHYPayload
+
+	// MACVersion holds the MAC version of the session. LoRaWAN 1.0 is
+	// assumed when the NwkSKey is returned.
+	MACVersion lorawan.MACVersion
+
+	// Network session keys. For LoRaWAN 1.0, all three keys are set to
+	// the NwkSKey.
+	FNwkSIntKey lorawan.AES128Key
+	SNwkSIntKey lorawan.AES128Key
+	NwkSEncKey  lorawan.AES128Key
+
+	// AppSKey holds the (wrapped) AppSKey. This is nil when the join-server
+	// returned a SessionKeyID instead.
+	AppSKey *KeyEnvelope
+
+	// SessionKeyID holds the optional session-key ID.
+	SessionKeyID HEXBytes
+
+	// Lifetime holds the session lifetime (0 = not set).
+	Lifetime time.Duration
+}
+
+// GetSessionContext returns a lorawan.SessionContext for the given DevAddr
+// containing the network session keys. The AppSKey is not set.
+func (s NetworkSession) GetSessionContext(devAddr lorawan.DevAddr) lorawan.SessionContext {
+	return lorawan.SessionContext{
+		MACVersion:  s.MACVersion,
+		DevAddr:     devAddr,
+		FNwkSIntKey: s.FNwkSIntKey,
+		SNwkSIntKey: s.SNwkSIntKey,
+		NwkSEncKey:  s.NwkSEncKey,
+	}
+}
+
+// GetNetworkSessionFromJoinAns validates the given JoinAns payload and
+// returns the network session, unwrapping the network session keys using
+// the KEKs of the given store. Only the network session keys are
+// unwrapped.
+func GetNetworkSessionFromJoinAns(pl JoinAnsPayload, kekStore KEKStore) (NetworkSession, error) {
+	if err := pl.Result.Err(); err != nil {
+		return NetworkSession{}, err
+	}
+
+	return getNetworkSession(pl.PHYPayload, pl.Lifetime, pl.NwkSKey, pl.FNwkSIntKey, pl.SNwkSIntKey, pl.NwkSEncKey, pl.AppSKey, pl.SessionKeyID, kekStore)
+}
+
+// GetNetworkSessionFromRejoinAns validates the given RejoinAns payload and
+// returns the network session, unwrapping the network session keys using
+// the KEKs of the given store. Only the network session keys are
+// unwrapped.
+func GetNetworkSessionFromRejoinAns(pl RejoinAnsPayload, kekStore KEKStore) (NetworkSession, error) {
+	if err := pl.Result.Err(); err != nil {
+		return NetworkSession{}, err
+	}
+
+	return getNetworkSession(pl.PHYPayload, pl.Lifetime, pl.NwkSKey, pl.FNwkSIntKey, pl.SNwkSIntKey, pl.NwkSEncKey, pl.AppSKey, pl.SessionKeyID, kekStore)
+}
+
+func getNetworkSession(phyB HEXBytes, lifetime *int, nwkSKey, fNwkSIntKey, sNwkSIntKey, nwkSEncKey, appSKey *KeyEnvelope, sessionKeyID HEXBytes, kekStore KEKStore) (NetworkSession, error) {
+	var out NetworkSession
+	var err error
+
+	if err := out.PHYPayload.UnmarshalBinary(phyB[:]); err != nil {
+		return out, errors.Wrap(err, "unmarshal phypayload error")
+	}
+	if out.PHYPayload.MHDR.MType != lorawan.JoinAccept {
+		return out, fmt.Errorf("expected MType %s, got %s", lorawan.JoinAccept, out.PHYPayload.MHDR.MType)
+	}
+
+	if appSKey == nil && len(sessionKeyID) == 0 {
+		return out, errors.New("AppSKey or SessionKeyID must be set")
+	}
+	out.AppSKey = appSKey
+	out.SessionKeyID = sessionKeyID
+
+	if lifetime != nil {
+		out.Lifetime = time.Duration(*lifetime) * time.Second
+	}
+
+	if nwkSKey != nil {
+		out.MACVersion = lorawan.LoRaWAN1_0
+		out.FNwkSIntKey, err = UnwrapKey(kekStore, nwkSKey)
+		if err != nil {
+			return out, errors.Wrap(err, "unwrap NwkSKey error")
+		}
+		out.SNwkSIntKey = out.FNwkSIntKey
+		out.NwkSEncKey = out.FNwkSIntKey
+
+		return out, nil
+	}
+
+	if fNwkSIntKey == nil || sNwkSIntKey == nil || nwkSEncKey == nil {
+		return out, errors.New("NwkSKey or FNwkSIntKey, SNwkSIntKey and NwkSEncKey must be set")
+	}
+
+	out.MACVersion = lorawan.LoRaWAN1_1
+	out.FNwkSIntKey, err = UnwrapKey(kekStore, fNwkSIntKey)
+	if err != nil {
+		return out, errors.Wrap(err, "unwrap FNwkSIntKey error")
+	}
+	out.SNwkSIntKey, err = UnwrapKey(kekStore, sNwkSIntKey)
+	if err != nil {
+		return out, errors.Wrap(err, "unwrap SNwkSIntKey error")
+	}
+	out.NwkSEncKey, err = UnwrapKey(kekStore, nwkSEncKey)
+	if err != nil {
+		return out, errors.Wrap(err, "unwrap NwkSEncKey error")
+	}
+
+	return out, nil
+}
@@ -0,0 +1,168 @@
+package backend
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestGetNetworkSessionFromJoinAns(t *testing.T) {
+	kek := lorawan.AES128Key{8, 7, 6, 5, 4, 3, 2, 1, 8, 7, 6, 5, 4, 3, 2, 1}
+	kekStore := KEKStoreFunc(func(label string) ([]byte, error) {
+		if label == "ns-kek" {
+			return kek[:], nil
+		}
+		return nil, errors.New("unknown kek")
+	})
+
+	phy := lorawan.PHYPayload{
+		MHDR: lorawan.MHDR{
+			MType: lorawan.JoinAccept,
+			Major: lorawan.LoRaWANR1,
+		},
+		MACPayload: &lorawan.DataPayload{Bytes: make([]byte, 12)},
+	}
+	phyB, err := phy.MarshalBinary()
+	require.NoError(t, err)
+
+	wrap := func(label string, key lorawan.AES128Key) *KeyEnvelope {
+		var ke *KeyEnvelope
+		var err error
+		if label == "" {
+			ke, err = NewKeyEnvelope("", nil, key)
+		} else {
+			ke, err = NewKeyEnvelope(label, kek[:], key)
+		}
+		require.NoError(t, err)
+		return ke
+	}
+
+	appSKey := &KeyEnvelope{KEKLabel: "as-kek", AESKey: HEXBytes{1, 2, 3}}
+	lifetime := 3600
+
+	tests := []struct {
+		Name            string
+		JoinAns         JoinAnsPayload
+		ExpectedSession NetworkSession
+		ExpectedError   string
+	}{
+		{
+			Name: "LoRaWAN 1.0 with wrapped NwkSKey",
+			JoinAns: JoinAnsPayload{
+				PHYPayload:        HEXBytes(phyB),
+				BasePayloadResult: BasePayloadResult{Result: Result{ResultCode: Success}},
+				Lifetime:          &lifetime,
+				NwkSKey:           wrap("ns-kek", lorawan.AES128Key{1}),
+				AppSKey:           appSKey,
+			},
+			ExpectedSession: NetworkSession{
+				PHYPayload:  phy,
+				MACVersion:  lorawan.LoRaWAN1_0,
+				FNwkSIntKey: lorawan.AES128Key{1},
+				SNwkSIntKey: lorawan.AES128Key{1},
+				NwkSEncKey:  lorawan.AES128Key{1},
+				AppSKey:     appSKey,
+				Lifetime:    time.Hour,
+			},
+		},
+		{
+			Name: "LoRaWAN 1.1 with SessionKeyID",
+			JoinAns: JoinAnsPayload{
+				PHYPayload:        HEXBytes(phyB),
+				BasePayloadResult: BasePayloadResult{Result: Result{ResultCode: Success}},
+				FNwkSIntKey:       wrap("ns-kek", lorawan.AES128Key{1}),
+				SNwkSIntKey:       wrap("ns-kek", lorawan.AES128Key{2}),
+				NwkSEncKey:        wrap("", lorawan.AES128Key{3}),
+				SessionKeyID:      HEXBytes{1, 2, 3, 4},
+			},
+			ExpectedSession: NetworkSession{
+				PHYPayload:   phy,
+				MACVersion:   lorawan.LoRaWAN1_1,
+				FNwkSIntKey:  lorawan.AES128Key{1},
+				SNwkSIntKey:  lorawan.AES128Key{2},
+				NwkSEncKey:   lorawan.AES128Key{3},
+				SessionKeyID: HEXBytes{1, 2, 3, 4},
+			},
+		},
+		{
+			Name: "Result is not Success",
+			JoinAns: JoinAnsPayload{
+				BasePayloadResult: BasePayloadResult{Result: Result{ResultCode: MICFailed, Description: "invalid mic"}},
+			},
+			ExpectedError: "response error, code: MICFailed, description: invalid mic",
+		},
+		{
+			Name: "No AppSKey or SessionKeyID",
+			JoinAns: JoinAnsPayload{
+				PHYPayload:        HEXBytes(phyB),
+				BasePayloadResult: BasePayloadResult{Result: Result{ResultCode: Success}},
+				NwkSKey:           wrap("ns-kek", lorawan.AES128Key{1}),
+			},
+			ExpectedError: "AppSKey or SessionKeyID must be set",
+		},
+		{
+			Name: "Missing LoRaWAN 1.1 network key",
+			JoinAns: JoinAnsPayload{
+				PHYPayload:        HEXBytes(phyB),
+				BasePayloadResult: BasePayloadResult{Result: Result{ResultCode: Success}},
+				FNwkSIntKey:       wrap("ns-kek", lorawan.AES128Key{1}),
+				AppSKey:           appSKey,
+			},
+			ExpectedError: "NwkSKey or FNwkSIntKey, SNwkSIntKey and NwkSEncKey must be set",
+		},
+		{
+			Name: "Unknown KEK label",
+			JoinAns: JoinAnsPayload{
+				PHYPayload:        HEXBytes(phyB),
+				BasePayloadResult: BasePayloadResult{Result: Result{ResultCode: Success}},
+				NwkSKey:           &KeyEnvelope{KEKLabel: "foo", AESKey: HEXBytes{1, 2, 3}},
+				AppSKey:           appSKey,
+			},
+			ExpectedError: "unwrap NwkSKey error: get kek error: unknown kek",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			ns, err := GetNetworkSessionFromJoinAns(tst.JoinAns, kekStore)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tst.ExpectedSession, ns)
+		})
+	}
+}
+
+func TestGetNetworkSessionFromRejoinAns(t *testing.T) {
+	assert := require.New(t)
+
+	phy := lorawan.PHYPayload{
+		MHDR: lorawan.MHDR{
+			MType: lorawan.UnconfirmedDataDown,
+			Major: lorawan.LoRaWANR1,
+		},
+		MACPayload: &lorawan.MACPayload{},
+	}
+	phyB, err := phy.MarshalBinary()
+	assert.NoError(err)
+
+	nwkSKey, err := NewKeyEnvelope("", nil, lorawan.AES128Key{1})
+	assert.NoError(err)
+
+	_, err = GetNetworkSessionFromRejoinAns(RejoinAnsPayload{
+		PHYPayload:        HEXBytes(phyB),
+		BasePayloadResult: BasePayloadResult{Result: Result{ResultCode: Success}},
+		NwkSKey:           nwkSKey,
+		SessionKeyID:      HEXBytes{1},
+	}, nil)
+	assert.EqualError(err, "expected MType JoinAccept, got UnconfirmedDataDown")
+}
@@ -128,13 +128,9 @@ func (h *handler) returnError(w http.ResponseWriter, code int, resultCode backen
 		ResultCode:  resultCode,
 		Description: msg,
 	}
-	b, err := json.Marshal(pl)
-	if err != nil {
+	if err := json.NewEncoder(w).Encode(pl); err != nil {
 		h.log.WithError(err).Error("backend/joinserver: marshal json error")
-		return
 	}
-
-	w.Write(b)
 }
 
 func (h *handler) returnJoinReqError(w http.ResponseWriter, basePL backend.BasePayload, code int, resultCode backend.ResultCode, msg string) {
@@ -200,13 +196,11 @@ func (h *handler) returnHomeNSReqError(w http.ResponseWriter, basePL backend.Bas
 func (h *handler) returnPayload(w http.ResponseWriter, code int, pl interface{}) {
 	w.WriteHeader(code)
 
-	b, err := json.Marshal(pl)
-	if err != nil {
+	// the encoder writes directly to the response, without allocating
+	// an intermediate buffer
+	if err := json.NewEncoder(w).Encode(pl); err != nil {
 		h.log.WithError(err).Error("backend/joinserver: marshal json error")
-		return
 	}
-
-	w.Write(b)
 }
 
 func (h *handler) handleJoinReq(w http.ResponseWriter, b []byte) {
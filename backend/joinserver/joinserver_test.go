@@ -507,6 +507,11 @@ func (ts *JoinServerTestSuite) TestRejoinRequest() {
 	rj1PHYBytes, err := rj1PHY.MarshalBinary()
 	assert.NoError(err)
 
+	rj1PHYInvalidMIC := rj1PHY
+	rj1PHYInvalidMIC.MIC = lorawan.MIC{1, 2, 3, 4}
+	rj1PHYInvalidMICBytes, err := rj1PHYInvalidMIC.MarshalBinary()
+	assert.NoError(err)
+
 	rj2PHY := lorawan.PHYPayload{
 		MHDR: lorawan.MHDR{
 			MType: lorawan.RejoinRequest,
@@ -718,6 +723,45 @@ func (ts *JoinServerTestSuite) TestRejoinRequest() {
 				},
 			},
 		},
+		{
+			Name:       "rejoin-request type 1 with invalid mic",
+			DeviceKeys: map[lorawan.EUI64]DeviceKeys{dk.DevEUI: dk},
+			RequestPayload: backend.RejoinReqPayload{
+				BasePayload: backend.BasePayload{
+					ProtocolVersion: backend.ProtocolVersion1_0,
+					SenderID:        "010203",
+					ReceiverID:      "0807060504030201",
+					TransactionID:   1234,
+					MessageType:     backend.RejoinReq,
+				},
+				MACVersion: "1.1.0",
+				PHYPayload: backend.HEXBytes(rj1PHYInvalidMICBytes),
+				DevEUI:     dk.DevEUI,
+				DevAddr:    lorawan.DevAddr{1, 2, 3, 4},
+				DLSettings: lorawan.DLSettings{
+					OptNeg:      true,
+					RX2DataRate: 5,
+					RX1DROffset: 1,
+				},
+				RxDelay: 1,
+				CFList:  backend.HEXBytes(cFListB),
+			},
+			ExpectedAnsPayload: backend.RejoinAnsPayload{
+				BasePayloadResult: backend.BasePayloadResult{
+					BasePayload: backend.BasePayload{
+						ProtocolVersion: backend.ProtocolVersion1_0,
+						SenderID:        "0807060504030201",
+						ReceiverID:      "010203",
+						TransactionID:   1234,
+						MessageType:     backend.RejoinAns,
+					},
+					Result: backend.Result{
+						ResultCode:  backend.MICFailed,
+						Description: "invalid mic",
+					},
+				},
+			},
+		},
 		{
 			Name:       "valid rejoin-request type 2",
 			DeviceKeys: map[lorawan.EUI64]DeviceKeys{dk.DevEUI: dk},
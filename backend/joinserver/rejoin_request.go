@@ -11,6 +11,7 @@ import (
 
 var rejoinTasks = []func(*context) error{
 	setRejoinContext,
+	validateRejoinMIC,
 	setJoinNonce,
 	setSessionKeys,
 	createRejoinAnsPayload,
@@ -99,6 +100,30 @@ func setRejoinContext(ctx *context) error {
 	return nil
 }
 
+// validateRejoinMIC validates the MIC of the rejoin-request. Only the
+// rejoin-request type 1 can be validated by the join-server as type 0 and 2
+// are signed using the SNwkSIntKey, which must be validated by the
+// network-server.
+func validateRejoinMIC(ctx *context) error {
+	if ctx.joinType != lorawan.RejoinRequestType1 {
+		return nil
+	}
+
+	jsIntKey, err := getJSIntKey(ctx.deviceKeys.NwkKey, ctx.devEUI)
+	if err != nil {
+		return err
+	}
+
+	ok, err := ctx.phyPayload.ValidateRejoinRequestMIC(lorawan.AES128Key{}, jsIntKey)
+	if err != nil {
+		return errors.Wrap(err, "validate mic error")
+	}
+	if !ok {
+		return ErrInvalidMIC
+	}
+	return nil
+}
+
 func createRejoinAnsPayload(ctx *context) error {
 	var cFList *lorawan.CFList
 	if len(ctx.rejoinReqPayload.CFList[:]) != 0 {
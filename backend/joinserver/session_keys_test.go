@@ -0,0 +1,48 @@
+package joinserver
+
+import (
+	"testing"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/cryptotest"
+)
+
+func TestSessionKeys(t *testing.T) {
+	cryptotest.CheckSessionKeys(t, func(optNeg bool, nwkKey, appKey lorawan.AES128Key, netID lorawan.NetID, joinEUI lorawan.EUI64, joinNonce lorawan.JoinNonce, devNonce lorawan.DevNonce) (cryptotest.SessionKeys, error) {
+		var keys cryptotest.SessionKeys
+		var err error
+
+		keys.FNwkSIntKey, err = getFNwkSIntKey(optNeg, nwkKey, netID, joinEUI, joinNonce, devNonce)
+		if err != nil {
+			return keys, err
+		}
+
+		if optNeg {
+			keys.AppSKey, err = getAppSKey(optNeg, appKey, netID, joinEUI, joinNonce, devNonce)
+		} else {
+			keys.AppSKey, err = getAppSKey(optNeg, nwkKey, netID, joinEUI, joinNonce, devNonce)
+		}
+		if err != nil {
+			return keys, err
+		}
+
+		keys.SNwkSIntKey, err = getSNwkSIntKey(optNeg, nwkKey, netID, joinEUI, joinNonce, devNonce)
+		if err != nil {
+			return keys, err
+		}
+
+		keys.NwkSEncKey, err = getNwkSEncKey(optNeg, nwkKey, netID, joinEUI, joinNonce, devNonce)
+		return keys, err
+	})
+}
+
+func TestJSKeys(t *testing.T) {
+	cryptotest.CheckJSKeys(t, func(nwkKey lorawan.AES128Key, devEUI lorawan.EUI64) (lorawan.AES128Key, lorawan.AES128Key, error) {
+		jsIntKey, err := getJSIntKey(nwkKey, devEUI)
+		if err != nil {
+			return jsIntKey, lorawan.AES128Key{}, err
+		}
+		jsEncKey, err := getJSEncKey(nwkKey, devEUI)
+		return jsIntKey, jsEncKey, err
+	})
+}
@@ -0,0 +1,99 @@
+package backend
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lorawan"
+)
+
+// KEKStore holds the Key Encryption Keys (KEK) by KEK label, e.g. one label
+// and KEK per peer as agreed between the operators. Implementations must
+// be safe for concurrent use.
+type KEKStore interface {
+	// GetKEK returns the KEK for the given label. It returns an empty
+	// slice when no KEK exists for the given label.
+	GetKEK(label string) ([]byte, error)
+}
+
+// KEKStoreFunc is an adapter to allow the use of an ordinary function as
+// KEKStore.
+type KEKStoreFunc func(label string) ([]byte, error)
+
+// GetKEK implements KEKStore.
+func (f KEKStoreFunc) GetKEK(label string) ([]byte, error) {
+	return f(label)
+}
+
+type kekStore map[string][]byte
+
+// NewKEKStore returns an in-memory KEKStore holding the given KEKs by
+// label.
+func NewKEKStore(keks map[string][]byte) KEKStore {
+	s := make(kekStore, len(keks))
+	for label, kek := range keks {
+		s[label] = append([]byte(nil), kek...)
+	}
+	return s
+}
+
+func (s kekStore) GetKEK(label string) ([]byte, error) {
+	return s[label], nil
+}
+
+// WrapKey wraps the given key in a KeyEnvelope, using the KEK with the given
+// label. When the label is empty, the key is sent in plaintext. Unlike
+// NewKeyEnvelope, it returns an error when the store has no KEK for the
+// label, so that a missing KEK never results in a plaintext key.
+func WrapKey(store KEKStore, label string, key lorawan.AES128Key) (*KeyEnvelope, error) {
+	if label == "" {
+		return NewKeyEnvelope("", nil, key)
+	}
+
+	var kek []byte
+	if store != nil {
+		var err error
+		kek, err = store.GetKEK(label)
+		if err != nil {
+			return nil, errors.Wrap(err, "get kek error")
+		}
+	}
+	if len(kek) == 0 {
+		return nil, fmt.Errorf("no KEK for label %s", label)
+	}
+
+	return NewKeyEnvelope(label, kek, key)
+}
+
+// UnwrapKey unwraps the given KeyEnvelope, using the KEK of its KEKLabel.
+// When the KEKLabel is empty, the key is expected to be in plaintext.
+func UnwrapKey(store KEKStore, ke *KeyEnvelope) (lorawan.AES128Key, error) {
+	var key lorawan.AES128Key
+
+	if ke == nil {
+		return key, errors.New("key envelope must not be nil")
+	}
+
+	if ke.KEKLabel == "" {
+		if len(ke.AESKey) != len(key) {
+			return key, fmt.Errorf("expected %d bytes plaintext key, got %d", len(key), len(ke.AESKey))
+		}
+		copy(key[:], ke.AESKey)
+		return key, nil
+	}
+
+	var kek []byte
+	if store != nil {
+		var err error
+		kek, err = store.GetKEK(ke.KEKLabel)
+		if err != nil {
+			return key, errors.Wrap(err, "get kek error")
+		}
+	}
+	if len(kek) == 0 {
+		return key, fmt.Errorf("no KEK for label %s", ke.KEKLabel)
+	}
+
+	return ke.Unwrap(kek)
+}
@@ -0,0 +1,97 @@
+package backend
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestKEKStore(t *testing.T) {
+	assert := require.New(t)
+
+	keks := map[string][]byte{
+		"ns-1": {1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8},
+	}
+	store := NewKEKStore(keks)
+
+	// the store holds a copy
+	keks["ns-1"][0] = 0
+	kek, err := store.GetKEK("ns-1")
+	assert.NoError(err)
+	assert.Equal([]byte{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8}, kek)
+
+	kek, err = store.GetKEK("ns-2")
+	assert.NoError(err)
+	assert.Len(kek, 0)
+}
+
+func TestWrapUnwrapKey(t *testing.T) {
+	key := lorawan.AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	store := NewKEKStore(map[string][]byte{
+		"ns-1": {1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8},
+	})
+
+	tests := []struct {
+		name      string
+		store     KEKStore
+		label     string
+		wrapErr   string
+		plaintext bool
+	}{
+		{name: "wrapped", store: store, label: "ns-1"},
+		{name: "plaintext", store: store, plaintext: true},
+		{name: "plaintext without store", plaintext: true},
+		{name: "unknown label", store: store, label: "ns-2", wrapErr: "no KEK for label ns-2"},
+		{name: "without store", label: "ns-1", wrapErr: "no KEK for label ns-1"},
+		{
+			name: "store error",
+			store: KEKStoreFunc(func(label string) ([]byte, error) {
+				return nil, errors.New("boom")
+			}),
+			label:   "ns-1",
+			wrapErr: "get kek error: boom",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			ke, err := WrapKey(tst.store, tst.label, key)
+			if tst.wrapErr != "" {
+				assert.EqualError(err, tst.wrapErr)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tst.label, ke.KEKLabel)
+			assert.Equal(tst.plaintext, HEXBytes(key[:]).String() == ke.AESKey.String())
+
+			out, err := UnwrapKey(tst.store, ke)
+			assert.NoError(err)
+			assert.Equal(key, out)
+		})
+	}
+}
+
+func TestUnwrapKeyErrors(t *testing.T) {
+	assert := require.New(t)
+
+	ke, err := NewKeyEnvelope("ns-1", []byte{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8}, lorawan.AES128Key{1})
+	assert.NoError(err)
+
+	_, err = UnwrapKey(nil, nil)
+	assert.EqualError(err, "key envelope must not be nil")
+
+	_, err = UnwrapKey(nil, ke)
+	assert.EqualError(err, "no KEK for label ns-1")
+
+	_, err = UnwrapKey(NewKEKStore(nil), ke)
+	assert.EqualError(err, "no KEK for label ns-1")
+
+	// wrong KEK
+	_, err = UnwrapKey(NewKEKStore(map[string][]byte{"ns-1": make([]byte, 16)}), ke)
+	assert.Error(err)
+}
@@ -0,0 +1,142 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// longPollRetryInterval defines the interval between two polls, after a
+// failed poll.
+const longPollRetryInterval = time.Second
+
+// longPoller receives the async answers from the long-poll endpoint of the
+// peer (or hub) and matches these by transaction ID to the pending
+// requests. The endpoint is only polled while there are pending requests.
+//
+// The endpoint must respond with 200 and a JSON array of answer payloads
+// (e.g. when an answer becomes available or after its poll timeout) or with
+// 204 when there are no answers.
+type longPoller struct {
+	url        string
+	httpClient *http.Client
+	log        *log.Logger
+
+	mu      sync.Mutex
+	polling bool
+	waiters map[uint32]chan []byte
+}
+
+func newLongPoller(url string, httpClient *http.Client, logger *log.Logger) *longPoller {
+	return &longPoller{
+		url:        url,
+		httpClient: httpClient,
+		log:        logger,
+		waiters:    make(map[uint32]chan []byte),
+	}
+}
+
+// subscribe registers a waiter for the answer of the given transaction and
+// starts polling when not already polling. The returned func must be
+// called to unregister the waiter.
+func (p *longPoller) subscribe(id uint32) (<-chan []byte, func()) {
+	ch := make(chan []byte, 1)
+
+	p.mu.Lock()
+	p.waiters[id] = ch
+	if !p.polling {
+		p.polling = true
+		go p.poll()
+	}
+	p.mu.Unlock()
+
+	return ch, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		if p.waiters[id] == ch {
+			delete(p.waiters, id)
+		}
+	}
+}
+
+// deliver passes the given answer to the waiter of its transaction.
+func (p *longPoller) deliver(bb []byte) error {
+	var pl BasePayload
+	if err := json.Unmarshal(bb, &pl); err != nil {
+		return errors.Wrap(err, "unmarshal answer error")
+	}
+
+	p.mu.Lock()
+	ch, ok := p.waiters[pl.TransactionID]
+	delete(p.waiters, pl.TransactionID)
+	p.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("no pending request for transaction %d", pl.TransactionID)
+	}
+
+	ch <- bb
+	return nil
+}
+
+func (p *longPoller) poll() {
+	for {
+		p.mu.Lock()
+		if len(p.waiters) == 0 {
+			p.polling = false
+			p.mu.Unlock()
+			return
+		}
+		p.mu.Unlock()
+
+		answers, err := p.receive()
+		if err != nil {
+			p.log.WithError(err).WithField("url", p.url).Error("lorawan/backend: long-poll error")
+			time.Sleep(longPollRetryInterval)
+			continue
+		}
+
+		for _, bb := range answers {
+			if err := p.deliver(bb); err != nil {
+				p.log.WithError(err).Warning("lorawan/backend: deliver long-poll answer error")
+			}
+		}
+	}
+}
+
+func (p *longPoller) receive() ([]json.RawMessage, error) {
+	req, err := http.NewRequestWithContext(context.Background(), "GET", p.url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "new request error")
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "http get error")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		io.Copy(ioutil.Discard, resp.Body)
+		return nil, nil
+	case http.StatusOK:
+		var answers []json.RawMessage
+		if err := json.NewDecoder(resp.Body).Decode(&answers); err != nil {
+			return nil, errors.Wrap(err, "unmarshal answers error")
+		}
+		return answers, nil
+	default:
+		bb, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("expected: 200 or 204, got: %d (%s)", resp.StatusCode, string(bb))
+	}
+}
@@ -0,0 +1,142 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+// longPollHub implements a peer which accepts requests and delivers the
+// answers over a long-poll endpoint.
+type longPollHub struct {
+	answers chan HomeNSAnsPayload
+	polls   chan struct{}
+}
+
+func (h *longPollHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/poll":
+		h.polls <- struct{}{}
+
+		select {
+		case ans := <-h.answers:
+			json.NewEncoder(w).Encode([]HomeNSAnsPayload{ans})
+		case <-time.After(50 * time.Millisecond):
+			w.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		var req HomeNSReqPayload
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if req.DevEUI[0] == 0 {
+			// do not answer
+			return
+		}
+
+		h.answers <- HomeNSAnsPayload{
+			BasePayloadResult: BasePayloadResult{
+				BasePayload: BasePayload{
+					ProtocolVersion: req.ProtocolVersion,
+					SenderID:        req.ReceiverID,
+					ReceiverID:      req.SenderID,
+					TransactionID:   req.TransactionID,
+					MessageType:     HomeNSAns,
+				},
+				Result: Result{ResultCode: Success},
+			},
+			HNetID: lorawan.NetID{1, 2, 3},
+		}
+	}
+}
+
+func TestLongPollClient(t *testing.T) {
+	assert := require.New(t)
+
+	hub := longPollHub{
+		answers: make(chan HomeNSAnsPayload, 10),
+		polls:   make(chan struct{}, 100),
+	}
+	server := httptest.NewServer(&hub)
+	defer server.Close()
+
+	c, err := NewClient(ClientConfig{
+		SenderID:     "010203",
+		ReceiverID:   "0102030405060708",
+		Server:       server.URL + "/api",
+		LongPollURL:  server.URL + "/poll",
+		AsyncTimeout: 200 * time.Millisecond,
+	})
+	assert.NoError(err)
+	assert.True(c.IsAsync())
+
+	t.Run("answer", func(t *testing.T) {
+		assert := require.New(t)
+
+		ans, err := c.HomeNSReq(context.Background(), HomeNSReqPayload{
+			DevEUI: lorawan.EUI64{1},
+		})
+		assert.NoError(err)
+		assert.Equal(lorawan.NetID{1, 2, 3}, ans.HNetID)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		assert := require.New(t)
+
+		_, err := c.HomeNSReq(context.Background(), HomeNSReqPayload{})
+		assert.Equal(ErrAsyncTimeout, err)
+	})
+
+	t.Run("answer by callback", func(t *testing.T) {
+		assert := require.New(t)
+
+		// wait for the polls of the previous requests to finish
+		time.Sleep(100 * time.Millisecond)
+		for len(hub.polls) > 0 {
+			<-hub.polls
+		}
+
+		go func() {
+			// the request is pending once the endpoint is polled
+			<-hub.polls
+			assert.NoError(c.HandleAnswer(context.Background(), HomeNSAnsPayload{
+				BasePayloadResult: BasePayloadResult{
+					BasePayload: BasePayload{
+						TransactionID: 1234,
+						MessageType:   HomeNSAns,
+					},
+					Result: Result{ResultCode: Success},
+				},
+				HNetID: lorawan.NetID{3, 2, 1},
+			}))
+		}()
+
+		ans, err := c.HomeNSReq(context.Background(), HomeNSReqPayload{
+			BasePayload: BasePayload{
+				TransactionID: 1234,
+			},
+		})
+		assert.NoError(err)
+		assert.Equal(lorawan.NetID{3, 2, 1}, ans.HNetID)
+	})
+
+	t.Run("polling stops", func(t *testing.T) {
+		assert := require.New(t)
+
+		time.Sleep(100 * time.Millisecond)
+		for len(hub.polls) > 0 {
+			<-hub.polls
+		}
+		time.Sleep(100 * time.Millisecond)
+		assert.Len(hub.polls, 0)
+	})
+}
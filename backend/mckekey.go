@@ -0,0 +1,70 @@
+package backend
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lorawan"
+)
+
+// NewMcKEKeyEnvelope returns the given McKEKey wrapped with the given KEK of
+// the application-server. The McKEKey is derived from the McRootKey of the
+// device, e.g. using multicastsetup.GetMcKEKey. Unlike NewKeyEnvelope, the
+// KEK is mandatory as the McKEKey protects all the multicast keys of the
+// device and must never be sent in clear.
+func NewMcKEKeyEnvelope(kekLabel string, kek []byte, mcKEKey lorawan.AES128Key) (*KeyEnvelope, error) {
+	if kekLabel == "" || len(kek) == 0 {
+		return nil, errors.New("KEK must be set for McKEKey")
+	}
+
+	return NewKeyEnvelope(kekLabel, kek, mcKEKey)
+}
+
+// NewMcKEKeyAnsPayload returns the VSMcKEKeyAns payload (join-server side)
+// for the given VSMcKEKeyReq, containing the McKEKey of the device wrapped
+// with the given KEK of the application-server.
+func NewMcKEKeyAnsPayload(req McKEKeyReqPayload, kekLabel string, kek []byte, mcKEKey lorawan.AES128Key) (McKEKeyAnsPayload, error) {
+	ke, err := NewMcKEKeyEnvelope(kekLabel, kek, mcKEKey)
+	if err != nil {
+		return McKEKeyAnsPayload{}, err
+	}
+
+	return McKEKeyAnsPayload{
+		BasePayloadResult: BasePayloadResult{
+			BasePayload: BasePayload{
+				ProtocolVersion: req.ProtocolVersion,
+				SenderID:        req.ReceiverID,
+				ReceiverID:      req.SenderID,
+				TransactionID:   req.TransactionID,
+				MessageType:     VSMcKEKeyAns,
+			},
+			Result: Result{
+				ResultCode: Success,
+			},
+		},
+		DevEUI:  req.DevEUI,
+		McKEKey: ke,
+	}, nil
+}
+
+// UnwrapMcKEKey returns the unwrapped McKEKey (application-server side) of
+// the given VSMcKEKeyAns payload. The given store is used to lookup the KEK
+// by the KEKLabel of the KeyEnvelope. A McKEKey which was sent in clear is
+// rejected.
+func UnwrapMcKEKey(pl McKEKeyAnsPayload, kekStore KEKStore) (lorawan.AES128Key, error) {
+	if err := pl.Result.Err(); err != nil {
+		return lorawan.AES128Key{}, err
+	}
+	if pl.McKEKey == nil {
+		return lorawan.AES128Key{}, errors.New("McKEKey must be set")
+	}
+	if pl.McKEKey.KEKLabel == "" {
+		return lorawan.AES128Key{}, errors.New("McKEKey must not be sent in clear")
+	}
+
+	key, err := UnwrapKey(kekStore, pl.McKEKey)
+	if err != nil {
+		return key, errors.Wrap(err, "unwrap McKEKey error")
+	}
+
+	return key, nil
+}
@@ -0,0 +1,86 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/applayer/multicastsetup"
+)
+
+func TestMcKEKey(t *testing.T) {
+	assert := require.New(t)
+
+	kek := lorawan.AES128Key{8, 7, 6, 5, 4, 3, 2, 1, 8, 7, 6, 5, 4, 3, 2, 1}
+	mcRootKey := lorawan.AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8}
+	devEUI := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	kekStore := KEKStoreFunc(func(label string) ([]byte, error) {
+		if label == "as-kek" {
+			return kek[:], nil
+		}
+		return nil, nil
+	})
+
+	mcKEKey, err := multicastsetup.GetMcKEKey(mcRootKey)
+	assert.NoError(err)
+
+	t.Run("KEK is mandatory", func(t *testing.T) {
+		assert := require.New(t)
+
+		_, err := NewMcKEKeyEnvelope("", nil, mcKEKey)
+		assert.Error(err)
+	})
+
+	t.Run("VSMcKEKeyReq / VSMcKEKeyAns", func(t *testing.T) {
+		assert := require.New(t)
+
+		req := McKEKeyReqPayload{
+			BasePayload: BasePayload{
+				ProtocolVersion: ProtocolVersion1_0,
+				SenderID:        "as",
+				ReceiverID:      "0102030405060708",
+				TransactionID:   1234,
+				MessageType:     VSMcKEKeyReq,
+			},
+			DevEUI: devEUI,
+		}
+
+		ans, err := NewMcKEKeyAnsPayload(req, "as-kek", kek[:], mcKEKey)
+		assert.NoError(err)
+		assert.Equal(VSMcKEKeyAns, ans.MessageType)
+		assert.Equal("0102030405060708", ans.SenderID)
+		assert.Equal("as", ans.ReceiverID)
+		assert.Equal(uint32(1234), ans.TransactionID)
+		assert.Equal(devEUI, ans.DevEUI)
+		assert.Equal("as-kek", ans.McKEKey.KEKLabel)
+		assert.NotEqual(HEXBytes(mcKEKey[:]), ans.McKEKey.AESKey)
+
+		key, err := UnwrapMcKEKey(ans, kekStore)
+		assert.NoError(err)
+		assert.Equal(mcKEKey, key)
+	})
+
+	t.Run("Plaintext McKEKey is rejected", func(t *testing.T) {
+		assert := require.New(t)
+
+		_, err := UnwrapMcKEKey(McKEKeyAnsPayload{
+			BasePayloadResult: BasePayloadResult{
+				Result: Result{ResultCode: Success},
+			},
+			McKEKey: &KeyEnvelope{AESKey: HEXBytes(mcKEKey[:])},
+		}, kekStore)
+		assert.Error(err)
+	})
+
+	t.Run("Error result", func(t *testing.T) {
+		assert := require.New(t)
+
+		_, err := UnwrapMcKEKey(McKEKeyAnsPayload{
+			BasePayloadResult: BasePayloadResult{
+				Result: Result{ResultCode: UnknownDevEUI},
+			},
+		}, kekStore)
+		assert.Error(err)
+	})
+}
@@ -0,0 +1,74 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// maxPooledBufferSize defines the max. capacity of a buffer which is
+// returned to the pool. Larger buffers are dropped, to avoid holding on to
+// the memory of exceptionally large payloads.
+const maxPooledBufferSize = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// GetBuffer returns an empty buffer from the pool used by the client for
+// encoding and reading payloads. It can be used by (high-throughput) server
+// implementations to avoid per-message allocations. The buffer must be
+// returned using PutBuffer once its bytes are no longer referenced.
+func GetBuffer() *bytes.Buffer {
+	b := bufferPool.Get().(*bytes.Buffer)
+	b.Reset()
+	return b
+}
+
+// PutBuffer returns the given buffer to the pool.
+func PutBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(b)
+}
+
+// encodeJSON returns a pooled buffer containing the JSON encoding of v,
+// equal to the output of json.Marshal. The buffer must be returned using
+// PutBuffer.
+func encodeJSON(v interface{}) (*bytes.Buffer, error) {
+	b := GetBuffer()
+	if err := json.NewEncoder(b).Encode(v); err != nil {
+		PutBuffer(b)
+		return nil, err
+	}
+
+	// remove the newline added by the encoder
+	b.Truncate(b.Len() - 1)
+	return b, nil
+}
+
+// pooledBody implements the http.Request body for a pooled buffer. The
+// buffer is returned to the pool when the body is closed by the
+// http.Transport, which guarantees that the body is no longer read.
+type pooledBody struct {
+	bytes.Reader
+	buf  *bytes.Buffer
+	once sync.Once
+}
+
+func newPooledBody(b *bytes.Buffer) *pooledBody {
+	body := pooledBody{buf: b}
+	body.Reset(b.Bytes())
+	return &body
+}
+
+// Close returns the buffer to the pool.
+func (b *pooledBody) Close() error {
+	b.once.Do(func() {
+		PutBuffer(b.buf)
+	})
+	return nil
+}
@@ -0,0 +1,97 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBufferPool(t *testing.T) {
+	assert := require.New(t)
+
+	b := GetBuffer()
+	b.WriteString("foo")
+	PutBuffer(b)
+
+	b = GetBuffer()
+	assert.Equal(0, b.Len())
+	PutBuffer(b)
+
+	// large buffers are not pooled, this must not panic
+	b = GetBuffer()
+	b.Grow(2 * maxPooledBufferSize)
+	PutBuffer(b)
+}
+
+func TestEncodeJSON(t *testing.T) {
+	assert := require.New(t)
+
+	pl := ProfileReqPayload{
+		BasePayload: BasePayload{
+			ProtocolVersion: ProtocolVersion1_0,
+			TransactionID:   1234,
+			MessageType:     ProfileReq,
+		},
+	}
+
+	expected, err := json.Marshal(pl)
+	assert.NoError(err)
+
+	b, err := encodeJSON(pl)
+	assert.NoError(err)
+	assert.Equal(expected, b.Bytes())
+	PutBuffer(b)
+
+	_, err = encodeJSON(func() {})
+	assert.Error(err)
+}
+
+func TestPooledBody(t *testing.T) {
+	assert := require.New(t)
+
+	buf := GetBuffer()
+	buf.WriteString("hello")
+
+	body := newPooledBody(buf)
+	b, err := ioutil.ReadAll(body)
+	assert.NoError(err)
+	assert.Equal([]byte("hello"), b)
+
+	// closing more than once must not return the buffer twice
+	assert.NoError(body.Close())
+	assert.NoError(body.Close())
+}
+
+func BenchmarkEncodeJSON(b *testing.B) {
+	pl := XmitDataReqPayload{
+		BasePayload: BasePayload{
+			ProtocolVersion: ProtocolVersion1_0,
+			TransactionID:   1234,
+			MessageType:     XmitDataReq,
+		},
+		PHYPayload: HEXBytes(bytes.Repeat([]byte{1}, 64)),
+	}
+
+	b.Run("json.Marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := json.Marshal(pl); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("encodeJSON", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf, err := encodeJSON(pl)
+			if err != nil {
+				b.Fatal(err)
+			}
+			PutBuffer(buf)
+		}
+	})
+}
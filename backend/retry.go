@@ -0,0 +1,76 @@
+package backend
+
+import (
+	"math"
+	"net/http"
+	"time"
+)
+
+// Default retry values.
+const (
+	DefaultRetryInitialBackoff = 100 * time.Millisecond
+	DefaultRetryMaxBackoff     = 5 * time.Second
+	DefaultRetryMultiplier     = 2
+)
+
+// RetryConfig defines the retry policy for transient HTTP failures:
+// transport errors (e.g. connection reset or timeout) and 502, 503 and 504
+// responses. Requests are retried using the same transaction ID. Note that
+// a request is not retried when the context is done.
+type RetryConfig struct {
+	// MaxAttempts defines the max. number of attempts, including the
+	// first attempt. When <= 1, requests are not retried.
+	MaxAttempts int
+
+	// InitialBackoff defines the backoff before the first retry. When 0,
+	// DefaultRetryInitialBackoff is used.
+	InitialBackoff time.Duration
+
+	// MaxBackoff defines the max. backoff between two attempts. When 0,
+	// DefaultRetryMaxBackoff is used.
+	MaxBackoff time.Duration
+
+	// Multiplier defines the factor by which the backoff is increased
+	// after each retry. When 0, DefaultRetryMultiplier is used.
+	Multiplier float64
+
+	// Jitter defines the random fraction (0 - 1) by which the backoff is
+	// decreased, to avoid clients retrying in lock-step.
+	Jitter float64
+}
+
+// backoff returns the backoff before the given retry (starting at 1).
+// The jitter is applied using the given random value (0 - 1).
+func (c RetryConfig) backoff(retry int, random float64) time.Duration {
+	initial := c.InitialBackoff
+	if initial == 0 {
+		initial = DefaultRetryInitialBackoff
+	}
+	max := c.MaxBackoff
+	if max == 0 {
+		max = DefaultRetryMaxBackoff
+	}
+	multiplier := c.Multiplier
+	if multiplier == 0 {
+		multiplier = DefaultRetryMultiplier
+	}
+
+	d := float64(initial) * math.Pow(multiplier, float64(retry-1))
+	if d > float64(max) {
+		d = float64(max)
+	}
+	d -= d * c.Jitter * random
+
+	return time.Duration(d)
+}
+
+// isRetryableStatus returns true when the given status code indicates a
+// transient failure.
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,148 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryConfigBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   RetryConfig
+		retry    int
+		random   float64
+		expected time.Duration
+	}{
+		{"defaults first retry", RetryConfig{}, 1, 0, 100 * time.Millisecond},
+		{"defaults third retry", RetryConfig{}, 3, 0, 400 * time.Millisecond},
+		{"max backoff", RetryConfig{}, 10, 0, 5 * time.Second},
+		{"multiplier", RetryConfig{InitialBackoff: time.Second, Multiplier: 3}, 2, 0, 3 * time.Second},
+		{"jitter", RetryConfig{Jitter: 0.5}, 1, 0.5, 75 * time.Millisecond},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			require.Equal(t, tst.expected, tst.config.backoff(tst.retry, tst.random))
+		})
+	}
+}
+
+// newFlakyServer returns a server which fails the first n requests, using
+// the given fail function.
+func newFlakyServer(n int32, fail func(w http.ResponseWriter), requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(requests, 1) <= n {
+			fail(w)
+			return
+		}
+
+		var req HomeNSReqPayload
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(HomeNSAnsPayload{
+			BasePayloadResult: BasePayloadResult{
+				BasePayload: BasePayload{
+					ProtocolVersion: req.ProtocolVersion,
+					TransactionID:   req.TransactionID,
+					MessageType:     HomeNSAns,
+				},
+				Result: Result{ResultCode: Success},
+			},
+		})
+	}))
+}
+
+func TestClientRetry(t *testing.T) {
+	unavailable := func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	internalError := func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	connectionReset := func(w http.ResponseWriter) {
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}
+
+	tests := []struct {
+		name        string
+		fail        func(w http.ResponseWriter)
+		failures    int32
+		maxAttempts int
+		requests    int32
+		err         bool
+	}{
+		{"no retry", unavailable, 1, 0, 1, true},
+		{"retry unavailable", unavailable, 2, 3, 3, false},
+		{"max attempts", unavailable, 3, 3, 3, true},
+		{"retry connection reset", connectionReset, 2, 3, 3, false},
+		{"internal error is not retried", internalError, 1, 3, 1, true},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var requests int32
+			server := newFlakyServer(tst.failures, tst.fail, &requests)
+			defer server.Close()
+
+			var transactionIDs []uint32
+			c, err := NewClient(ClientConfig{
+				Server: server.URL,
+				Retry: RetryConfig{
+					MaxAttempts:    tst.maxAttempts,
+					InitialBackoff: time.Millisecond,
+					Jitter:         0.5,
+				},
+				CaptureFunc: func(c Capture) {
+					transactionIDs = append(transactionIDs, c.TransactionID)
+				},
+			})
+			assert.NoError(err)
+
+			_, err = c.HomeNSReq(context.Background(), HomeNSReqPayload{})
+			if tst.err {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(tst.requests, atomic.LoadInt32(&requests))
+			assert.Len(transactionIDs, 1)
+		})
+	}
+}
+
+func TestClientRetryContext(t *testing.T) {
+	assert := require.New(t)
+
+	var requests int32
+	server := newFlakyServer(10, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadGateway)
+	}, &requests)
+	defer server.Close()
+
+	c, err := NewClient(ClientConfig{
+		Server: server.URL,
+		Retry: RetryConfig{
+			MaxAttempts:    10,
+			InitialBackoff: time.Hour,
+		},
+	})
+	assert.NoError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = c.HomeNSReq(ctx, HomeNSReqPayload{})
+	assert.Error(err)
+	assert.Equal(context.DeadlineExceeded, errors.Cause(err))
+	assert.Equal(int32(1), atomic.LoadInt32(&requests))
+}
@@ -0,0 +1,401 @@
+// Package server provides a http.Handler which implements the plumbing of a
+// LoRaWAN Backend Interfaces endpoint (e.g. fNS, sNS, hNS or join-server).
+// It parses the incoming requests, dispatches these by message-type to the
+// registered handler functions and returns the answers, either in the HTTP
+// response (sync) or using a backend.Client (async).
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lorawan/backend"
+)
+
+// requestTypes holds the payload types of the requests, by message-type.
+var requestTypes = map[backend.MessageType]reflect.Type{
+	backend.JoinReq:      reflect.TypeOf(backend.JoinReqPayload{}),
+	backend.RejoinReq:    reflect.TypeOf(backend.RejoinReqPayload{}),
+	backend.AppSKeyReq:   reflect.TypeOf(backend.AppSKeyReqPayload{}),
+	backend.VSMcKEKeyReq: reflect.TypeOf(backend.McKEKeyReqPayload{}),
+	backend.PRStartReq:   reflect.TypeOf(backend.PRStartReqPayload{}),
+	backend.PRStopReq:    reflect.TypeOf(backend.PRStopReqPayload{}),
+	backend.HRStartReq:   reflect.TypeOf(backend.HRStartReqPayload{}),
+	backend.HRStopReq:    reflect.TypeOf(backend.HRStopReqPayload{}),
+	backend.HomeNSReq:    reflect.TypeOf(backend.HomeNSReqPayload{}),
+	backend.ProfileReq:   reflect.TypeOf(backend.ProfileReqPayload{}),
+	backend.XmitDataReq:  reflect.TypeOf(backend.XmitDataReqPayload{}),
+}
+
+// answerTypes holds the payload types of the answers, by message-type.
+var answerTypes = map[backend.MessageType]reflect.Type{
+	backend.JoinAns:      reflect.TypeOf(backend.JoinAnsPayload{}),
+	backend.RejoinAns:    reflect.TypeOf(backend.RejoinAnsPayload{}),
+	backend.AppSKeyAns:   reflect.TypeOf(backend.AppSKeyAnsPayload{}),
+	backend.VSMcKEKeyAns: reflect.TypeOf(backend.McKEKeyAnsPayload{}),
+	backend.PRStartAns:   reflect.TypeOf(backend.PRStartAnsPayload{}),
+	backend.PRStopAns:    reflect.TypeOf(backend.PRStopAnsPayload{}),
+	backend.HRStartAns:   reflect.TypeOf(backend.HRStartAnsPayload{}),
+	backend.HRStopAns:    reflect.TypeOf(backend.HRStopAnsPayload{}),
+	backend.HomeNSAns:    reflect.TypeOf(backend.HomeNSAnsPayload{}),
+	backend.ProfileAns:   reflect.TypeOf(backend.ProfileAnsPayload{}),
+	backend.XmitDataAns:  reflect.TypeOf(backend.XmitDataAnsPayload{}),
+}
+
+// HandlerFunc handles the given request. The request holds the payload of
+// the message-type (e.g. backend.PRStartReqPayload). The returned answer
+// must be the answer payload of the message-type (e.g.
+// backend.PRStartAnsPayload). Empty BasePayload fields of the answer are
+// filled in from the request and an empty ResultCode is set to Success.
+//
+// When an error is returned, the request is answered with the ResultCode of
+// the error when it is a *backend.ResultError, else with the Other
+// ResultCode.
+type HandlerFunc func(ctx context.Context, req backend.Request) (backend.Answer, error)
+
+// HandlerConfig holds the handler configuration.
+type HandlerConfig struct {
+	Logger *log.Logger
+
+	// AsyncClientFunc returns the client for sending the answer of the
+	// given request, using the async protocol scheme. In this case the
+	// HTTP request is acknowledged with an empty response before the
+	// request is handled. When nil, the answer is returned in the HTTP
+	// response (sync).
+	AsyncClientFunc func(req backend.BasePayload) (backend.Client, error)
+
+	// HandleAnswerFunc handles the async answers received by the endpoint,
+	// e.g. the HandleAnswer method of the backend.Client which sent the
+	// request. When nil, received answers are rejected.
+	HandleAnswerFunc func(ctx context.Context, ans backend.Answer) error
+}
+
+// Handler dispatches the Backend Interfaces requests by message-type to the
+// registered handler functions. Requests without handler are answered with
+// the MalformedRequest ResultCode, or the MalformedMessage ResultCode for
+// protocol version 1.1 requests.
+//
+// The Handler is safe for concurrent use.
+type Handler struct {
+	config HandlerConfig
+	log    *log.Logger
+
+	mu       sync.RWMutex
+	handlers map[backend.MessageType]HandlerFunc
+	wg       sync.WaitGroup
+}
+
+// NewHandler creates a new Handler.
+func NewHandler(config HandlerConfig) *Handler {
+	h := Handler{
+		config:   config,
+		log:      config.Logger,
+		handlers: make(map[backend.MessageType]HandlerFunc),
+	}
+
+	if h.log == nil {
+		h.log = &log.Logger{
+			Out: ioutil.Discard,
+		}
+	}
+
+	return &h
+}
+
+// Handle registers the handler function for the given request message-type.
+func (h *Handler) Handle(mt backend.MessageType, fn HandlerFunc) error {
+	if _, ok := requestTypes[mt]; !ok {
+		return fmt.Errorf("backend/server: invalid request MessageType: %s", mt)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.handlers[mt] = fn
+	return nil
+}
+
+// Wait waits until the pending async requests have been handled and
+// answered.
+func (h *Handler) Wait() {
+	h.wg.Wait()
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		h.returnError(w, http.StatusInternalServerError, backend.Other, "read body error")
+		return
+	}
+
+	var basePL backend.BasePayload
+	if err := json.Unmarshal(b, &basePL); err != nil {
+		h.returnMalformed(w, basePL, err.Error())
+		return
+	}
+
+	h.log.WithFields(log.Fields{
+		"message_type":   basePL.MessageType,
+		"sender_id":      basePL.SenderID,
+		"receiver_id":    basePL.ReceiverID,
+		"transaction_id": basePL.TransactionID,
+	}).Info("backend/server: request received")
+
+	if t, ok := answerTypes[basePL.MessageType]; ok {
+		h.handleAnswer(w, r.Context(), t, b)
+		return
+	}
+
+	req, err := decodeRequest(basePL.MessageType, b)
+	if err != nil {
+		h.returnMalformed(w, basePL, err.Error())
+		return
+	}
+
+	if h.config.AsyncClientFunc == nil {
+		ans := h.handleRequest(r.Context(), basePL, req)
+		h.returnPayload(w, http.StatusOK, ans)
+		return
+	}
+
+	client, err := h.config.AsyncClientFunc(basePL)
+	if err != nil {
+		msg := fmt.Sprintf("get async client error: %s", err)
+		h.log.WithFields(log.Fields{
+			"error": msg,
+		}).Error("backend/server: error handling request")
+
+		h.returnPayload(w, http.StatusInternalServerError, newAnswer(basePL, backend.Result{
+			ResultCode:  backend.Other,
+			Description: msg,
+		}))
+		return
+	}
+
+	// the request context is cancelled when this method returns
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+
+		ans := h.handleRequest(context.Background(), basePL, req)
+		if err := client.SendAnswer(context.Background(), ans); err != nil {
+			h.log.WithError(err).WithFields(log.Fields{
+				"message_type":   ans.GetBasePayload().MessageType,
+				"receiver_id":    ans.GetBasePayload().ReceiverID,
+				"transaction_id": ans.GetBasePayload().TransactionID,
+			}).Error("backend/server: send async answer error")
+		}
+	}()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleRequest calls the handler function of the given request and returns
+// the completed answer.
+func (h *Handler) handleRequest(ctx context.Context, basePL backend.BasePayload, req backend.Request) backend.Answer {
+	h.mu.RLock()
+	fn, ok := h.handlers[basePL.MessageType]
+	h.mu.RUnlock()
+
+	if !ok {
+		return newAnswer(basePL, backend.Result{
+			ResultCode:  malformedResultCode(basePL.ProtocolVersion),
+			Description: fmt.Sprintf("unexpected MessageType: %s", basePL.MessageType),
+		})
+	}
+
+	ans, err := fn(ctx, req)
+	if err != nil {
+		h.log.WithError(err).WithFields(log.Fields{
+			"message_type":   basePL.MessageType,
+			"sender_id":      basePL.SenderID,
+			"transaction_id": basePL.TransactionID,
+		}).Error("backend/server: handle request error")
+
+		result := backend.Result{
+			ResultCode:  backend.Other,
+			Description: err.Error(),
+		}
+
+		var resErr *backend.ResultError
+		if errors.As(err, &resErr) {
+			result.ResultCode = resErr.ResultCode
+			result.Description = resErr.Description
+		}
+
+		return newAnswer(basePL, result)
+	}
+
+	if ans == nil {
+		return newAnswer(basePL, backend.Result{ResultCode: backend.Success})
+	}
+
+	return completeAnswer(basePL, ans)
+}
+
+func (h *Handler) handleAnswer(w http.ResponseWriter, ctx context.Context, t reflect.Type, b []byte) {
+	if h.config.HandleAnswerFunc == nil {
+		h.returnError(w, http.StatusBadRequest, backend.Other, "unexpected answer")
+		return
+	}
+
+	v := reflect.New(t)
+	if err := json.Unmarshal(b, v.Interface()); err != nil {
+		h.returnError(w, http.StatusBadRequest, backend.Other, err.Error())
+		return
+	}
+
+	if err := h.config.HandleAnswerFunc(ctx, v.Elem().Interface().(backend.Answer)); err != nil {
+		h.returnError(w, http.StatusInternalServerError, backend.Other, fmt.Sprintf("handle answer error: %s", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) returnError(w http.ResponseWriter, code int, resultCode backend.ResultCode, msg string) {
+	h.log.WithFields(log.Fields{
+		"error": msg,
+	}).Error("backend/server: error handling request")
+
+	h.returnPayload(w, code, backend.Result{
+		ResultCode:  resultCode,
+		Description: msg,
+	})
+}
+
+// returnMalformed returns the answer to a request which could not be
+// decoded, using the (partially) decoded base payload.
+func (h *Handler) returnMalformed(w http.ResponseWriter, basePL backend.BasePayload, msg string) {
+	h.log.WithFields(log.Fields{
+		"error": msg,
+	}).Error("backend/server: malformed request")
+
+	h.returnPayload(w, http.StatusBadRequest, newAnswer(basePL, backend.Result{
+		ResultCode:  malformedResultCode(basePL.ProtocolVersion),
+		Description: msg,
+	}))
+}
+
+func (h *Handler) returnPayload(w http.ResponseWriter, code int, pl interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(pl); err != nil {
+		h.log.WithError(err).Error("backend/server: marshal json error")
+	}
+}
+
+// decodeRequest decodes the given request into the payload of the given
+// message-type. The payload of an unknown message-type is decoded as
+// BasePayload, so that it can be answered.
+func decodeRequest(mt backend.MessageType, b []byte) (backend.Request, error) {
+	t, ok := requestTypes[mt]
+	if !ok {
+		t = reflect.TypeOf(unknownRequest{})
+	}
+
+	v := reflect.New(t)
+	if err := json.Unmarshal(b, v.Interface()); err != nil {
+		return nil, err
+	}
+
+	return v.Elem().Interface().(backend.Request), nil
+}
+
+// unknownRequest holds the base payload of a request with an unknown
+// message-type.
+type unknownRequest struct {
+	backend.BasePayload
+}
+
+// GetBasePayload returns the base payload.
+func (p unknownRequest) GetBasePayload() backend.BasePayload {
+	return p.BasePayload
+}
+
+// malformedResultCode returns the ResultCode for malformed requests of the
+// given protocol version. Backend Interfaces 1.1 replaces MalformedRequest
+// by MalformedMessage.
+func malformedResultCode(protocolVersion string) backend.ResultCode {
+	if protocolVersion == backend.ProtocolVersion1_1 {
+		return backend.MalformedMessage
+	}
+	return backend.MalformedRequest
+}
+
+// newAnswer returns the answer payload to the given request, holding only
+// the base payload and the given result.
+func newAnswer(req backend.BasePayload, result backend.Result) backend.Answer {
+	var ans backend.Answer = backend.BasePayloadResult{Result: result}
+
+	if t, ok := answerTypes[backend.AnswerMessageType(req.MessageType)]; ok {
+		v := reflect.New(t).Elem()
+		v.FieldByName("BasePayloadResult").Set(reflect.ValueOf(ans))
+		ans = v.Interface().(backend.Answer)
+	}
+
+	return completeAnswer(req, ans)
+}
+
+// completeAnswer fills in the empty base payload fields of the given answer,
+// using the given request.
+func completeAnswer(req backend.BasePayload, ans backend.Answer) backend.Answer {
+	v := reflect.ValueOf(ans)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	bp := ans.GetBasePayload()
+	if bp.ProtocolVersion == "" {
+		bp.ProtocolVersion = req.ProtocolVersion
+	}
+	if bp.SenderID == "" {
+		bp.SenderID = req.ReceiverID
+	}
+	if bp.ReceiverID == "" {
+		bp.ReceiverID = req.SenderID
+	}
+	if bp.TransactionID == 0 {
+		bp.TransactionID = req.TransactionID
+	}
+	if bp.MessageType == "" && req.MessageType != "" {
+		bp.MessageType = backend.AnswerMessageType(req.MessageType)
+	}
+	if bp.ReceiverToken == nil {
+		bp.ReceiverToken = req.SenderToken
+	}
+	if bp.SenderNSID == "" {
+		bp.SenderNSID = req.ReceiverNSID
+	}
+	if bp.ReceiverNSID == "" {
+		bp.ReceiverNSID = req.SenderNSID
+	}
+	if bp.Result.ResultCode == "" {
+		bp.Result.ResultCode = backend.Success
+	}
+
+	if v.Type() == reflect.TypeOf(backend.BasePayloadResult{}) {
+		return bp
+	}
+
+	// answers which do not embed the BasePayloadResult (e.g. implementing
+	// json.Marshaler) are returned as-is
+	if _, ok := v.Type().FieldByName("BasePayloadResult"); !ok {
+		return ans
+	}
+
+	out := reflect.New(v.Type()).Elem()
+	out.Set(v)
+	out.FieldByName("BasePayloadResult").Set(reflect.ValueOf(bp))
+	return out.Interface().(backend.Answer)
+}
@@ -0,0 +1,295 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/backend"
+	"github.com/brocaar/lorawan/backend/backendtest"
+	"github.com/brocaar/lorawan/backend/server"
+)
+
+func newTestHandler(t *testing.T, config server.HandlerConfig) *server.Handler {
+	assert := require.New(t)
+
+	h := server.NewHandler(config)
+	assert.NoError(h.Handle(backend.PRStartReq, func(ctx context.Context, req backend.Request) (backend.Answer, error) {
+		pl := req.(backend.PRStartReqPayload)
+		if len(pl.PHYPayload) == 0 {
+			return nil, &backend.ResultError{ResultCode: backend.FrameSizeError, Description: "empty PHYPayload"}
+		}
+
+		lifetime := 60
+		return backend.PRStartAnsPayload{
+			DevEUI:   &lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+			Lifetime: &lifetime,
+		}, nil
+	}))
+	assert.NoError(h.Handle(backend.PRStopReq, func(ctx context.Context, req backend.Request) (backend.Answer, error) {
+		return nil, errors.New("boom")
+	}))
+
+	return h
+}
+
+func TestHandlerSync(t *testing.T) {
+	assert := require.New(t)
+
+	ts := httptest.NewServer(newTestHandler(t, server.HandlerConfig{}))
+	defer ts.Close()
+
+	client, err := backend.NewClient(backend.ClientConfig{
+		SenderID:   "010203",
+		ReceiverID: "030201",
+		Server:     ts.URL,
+	})
+	assert.NoError(err)
+
+	t.Run("answer", func(t *testing.T) {
+		assert := require.New(t)
+
+		ans, err := client.PRStartReq(context.Background(), backend.PRStartReqPayload{
+			BasePayload: backend.BasePayload{
+				TransactionID: 1234,
+				SenderToken:   backend.HEXBytes{1, 2},
+			},
+			PHYPayload: backend.HEXBytes{1, 2, 3},
+		})
+		assert.NoError(err)
+		assert.Equal(backend.BasePayloadResult{
+			BasePayload: backend.BasePayload{
+				ProtocolVersion: backend.ProtocolVersion1_0,
+				SenderID:        "030201",
+				ReceiverID:      "010203",
+				TransactionID:   1234,
+				MessageType:     backend.PRStartAns,
+				ReceiverToken:   backend.HEXBytes{1, 2},
+			},
+			Result: backend.Result{ResultCode: backend.Success},
+		}, ans.BasePayloadResult)
+		assert.Equal(60, *ans.Lifetime)
+		assert.Equal(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, *ans.DevEUI)
+	})
+
+	t.Run("result error", func(t *testing.T) {
+		assert := require.New(t)
+
+		ans, err := client.PRStartReq(context.Background(), backend.PRStartReqPayload{})
+		assert.True(errors.Is(err, &backend.ResultError{ResultCode: backend.FrameSizeError}))
+		assert.Equal(backend.PRStartAns, ans.MessageType)
+		assert.Equal("empty PHYPayload", ans.Result.Description)
+	})
+
+	t.Run("handler error", func(t *testing.T) {
+		assert := require.New(t)
+
+		ans, err := client.PRStopReq(context.Background(), backend.PRStopReqPayload{})
+		assert.True(errors.Is(err, &backend.ResultError{ResultCode: backend.Other}))
+		assert.Equal(backend.PRStopAns, ans.MessageType)
+		assert.Equal("boom", ans.Result.Description)
+	})
+
+	t.Run("no handler", func(t *testing.T) {
+		assert := require.New(t)
+
+		ans, err := client.HomeNSReq(context.Background(), backend.HomeNSReqPayload{})
+		assert.True(errors.Is(err, &backend.ResultError{ResultCode: backend.MalformedRequest}))
+		assert.Equal(backend.HomeNSAns, ans.MessageType)
+	})
+}
+
+func TestHandlerAsync(t *testing.T) {
+	assert := require.New(t)
+
+	asyncClient := backendtest.NewMockClient("030201", "010203")
+
+	var requests []backend.BasePayload
+	h := newTestHandler(t, server.HandlerConfig{
+		AsyncClientFunc: func(req backend.BasePayload) (backend.Client, error) {
+			requests = append(requests, req)
+			return asyncClient, nil
+		},
+	})
+
+	b, err := json.Marshal(backend.PRStartReqPayload{
+		BasePayload: backend.BasePayload{
+			ProtocolVersion: backend.ProtocolVersion1_0,
+			SenderID:        "010203",
+			ReceiverID:      "030201",
+			TransactionID:   1234,
+			MessageType:     backend.PRStartReq,
+		},
+		PHYPayload: backend.HEXBytes{1, 2, 3},
+	})
+	assert.NoError(err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/", bytes.NewReader(b)))
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal(0, w.Body.Len())
+
+	h.Wait()
+
+	assert.Len(requests, 1)
+	assert.Equal(uint32(1234), requests[0].TransactionID)
+
+	calls := asyncClient.Calls()
+	assert.Len(calls, 1)
+	assert.Equal(backend.PRStartAns, calls[0].MessageType)
+
+	ans := calls[0].Payload.(backend.PRStartAnsPayload)
+	assert.Equal(uint32(1234), ans.TransactionID)
+	assert.Equal("010203", ans.ReceiverID)
+	assert.Equal(backend.Success, ans.Result.ResultCode)
+}
+
+func TestHandlerAnswers(t *testing.T) {
+	ans := backend.PRStartAnsPayload{
+		BasePayloadResult: backend.BasePayloadResult{
+			BasePayload: backend.BasePayload{
+				ProtocolVersion: backend.ProtocolVersion1_0,
+				SenderID:        "030201",
+				ReceiverID:      "010203",
+				TransactionID:   1234,
+				MessageType:     backend.PRStartAns,
+			},
+			Result: backend.Result{ResultCode: backend.Success},
+		},
+	}
+	b, err := json.Marshal(ans)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		handleAnswer func(ctx context.Context, ans backend.Answer) error
+		expectedCode int
+	}{
+		{
+			name:         "not configured",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "handled",
+			handleAnswer: func(ctx context.Context, pl backend.Answer) error {
+				if _, ok := pl.(backend.PRStartAnsPayload); !ok {
+					return errors.New("unexpected answer type")
+				}
+				return nil
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "handle error",
+			handleAnswer: func(ctx context.Context, pl backend.Answer) error {
+				return errors.New("no pending request")
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			h := server.NewHandler(server.HandlerConfig{HandleAnswerFunc: tst.handleAnswer})
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/", bytes.NewReader(b)))
+			assert.Equal(tst.expectedCode, w.Code)
+		})
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	assert := require.New(t)
+
+	h := server.NewHandler(server.HandlerConfig{})
+	assert.EqualError(h.Handle(backend.PRStartAns, nil), "backend/server: invalid request MessageType: PRStartAns")
+
+}
+
+func TestHandlerMalformedRequest(t *testing.T) {
+	tests := []struct {
+		name                string
+		body                string
+		expectedMessageType backend.MessageType
+		expectedResultCode  backend.ResultCode
+	}{
+		{
+			name:               "invalid json",
+			body:               `{`,
+			expectedResultCode: backend.MalformedRequest,
+		},
+		{
+			name:                "invalid payload 1.0",
+			body:                `{"ProtocolVersion": "1.0", "SenderID": "010203", "ReceiverID": "030201", "TransactionID": 1234, "MessageType": "PRStartReq", "PHYPayload": 123}`,
+			expectedMessageType: backend.PRStartAns,
+			expectedResultCode:  backend.MalformedRequest,
+		},
+		{
+			name:                "invalid payload 1.1",
+			body:                `{"ProtocolVersion": "1.1", "SenderID": "010203", "ReceiverID": "030201", "TransactionID": 1234, "MessageType": "PRStartReq", "PHYPayload": 123}`,
+			expectedMessageType: backend.PRStartAns,
+			expectedResultCode:  backend.MalformedMessage,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			h := server.NewHandler(server.HandlerConfig{})
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/", bytes.NewReader([]byte(tst.body))))
+			assert.Equal(http.StatusBadRequest, w.Code)
+
+			var ans backend.BasePayloadResult
+			assert.NoError(json.NewDecoder(w.Body).Decode(&ans))
+			assert.Equal(tst.expectedMessageType, ans.MessageType)
+			assert.Equal(tst.expectedResultCode, ans.Result.ResultCode)
+			assert.NotEmpty(ans.Result.Description)
+		})
+	}
+}
+
+func TestHandlerUnknownMessageType(t *testing.T) {
+	tests := []struct {
+		protocolVersion    string
+		expectedResultCode backend.ResultCode
+	}{
+		{backend.ProtocolVersion1_0, backend.MalformedRequest},
+		{backend.ProtocolVersion1_1, backend.MalformedMessage},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.protocolVersion, func(t *testing.T) {
+			assert := require.New(t)
+
+			b, err := json.Marshal(backend.BasePayload{
+				ProtocolVersion: tst.protocolVersion,
+				SenderID:        "010203",
+				ReceiverID:      "030201",
+				TransactionID:   1234,
+				MessageType:     "UnknownReq",
+			})
+			assert.NoError(err)
+
+			h := server.NewHandler(server.HandlerConfig{})
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/", bytes.NewReader(b)))
+			assert.Equal(http.StatusOK, w.Code)
+
+			var ans backend.BasePayloadResult
+			assert.NoError(json.NewDecoder(w.Body).Decode(&ans))
+			assert.Equal(backend.MessageType("UnknownAns"), ans.MessageType)
+			assert.Equal(tst.expectedResultCode, ans.Result.ResultCode)
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package backend
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultTransactionTTL defines the default duration after which an
+// allocated transaction ID is released when it was not released explicitly.
+const DefaultTransactionTTL = 5 * time.Minute
+
+// maxAllocateAttempts defines the max. number of attempts to find an unused
+// transaction ID.
+const maxAllocateAttempts = 100
+
+// TraceIDFunc defines the function signature for generating the internal
+// trace ID (e.g. UUID or ULID) mapped to a transaction ID.
+type TraceIDFunc func() (string, error)
+
+// Transaction defines an allocated transaction.
+type Transaction struct {
+	// TransactionID holds the (wire) transaction ID.
+	TransactionID uint32
+
+	// TraceID holds the internal ID, which is unique over all transactions
+	// and can be used in logs and metrics.
+	TraceID string
+
+	// AllocatedAt holds the allocation timestamp.
+	AllocatedAt time.Time
+}
+
+// TransactionManager allocates transaction IDs which are not in use by any
+// other pending transaction and maps these to an internal trace ID. As the
+// Backend Interfaces transaction ID is only 32 bits, the same ID will be
+// re-used over time. The trace ID can be used to unambiguously identify a
+// transaction in logs and metrics.
+//
+// The TransactionManager is safe for concurrent use.
+type TransactionManager struct {
+	mu sync.Mutex
+
+	ttl          time.Duration
+	rand         io.Reader
+	now          func() time.Time
+	traceID      TraceIDFunc
+	transactions map[uint32]Transaction
+
+	// queue holds the allocated transactions in order of allocation, so
+	// that expiration does not need to scan all transactions. Released
+	// transactions are removed from the queue lazily.
+	queue []Transaction
+}
+
+// NewTransactionManager creates a new TransactionManager. Transactions which
+// are not released within the given TTL are released automatically. When
+// the TTL is 0, DefaultTransactionTTL is used. When traceID is nil, random
+// (version 4) UUIDs are used as trace IDs.
+func NewTransactionManager(ttl time.Duration, traceID TraceIDFunc) *TransactionManager {
+	if ttl == 0 {
+		ttl = DefaultTransactionTTL
+	}
+
+	m := TransactionManager{
+		ttl:          ttl,
+		rand:         rand.Reader,
+		now:          time.Now,
+		traceID:      traceID,
+		transactions: make(map[uint32]Transaction),
+	}
+
+	if m.traceID == nil {
+		m.traceID = m.newUUID
+	}
+
+	return &m
+}
+
+// SetRand sets the random source used for generating the transaction IDs
+// and the default trace IDs. By default, crypto/rand is used. A
+// deterministic source can be used for reproducible tests.
+func (m *TransactionManager) SetRand(r io.Reader) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rand = r
+}
+
+// SetClock sets the function returning the current time, used for the
+// allocation timestamps and expiration. By default, time.Now is used.
+func (m *TransactionManager) SetClock(now func() time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.now = now
+}
+
+// Allocate allocates a new transaction.
+func (m *TransactionManager) Allocate() (Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.expire(now)
+
+	for i := 0; i < maxAllocateAttempts; i++ {
+		id, err := m.randomUint32()
+		if err != nil {
+			return Transaction{}, err
+		}
+
+		// 0 is used by the client to indicate that no transaction ID was set
+		if _, ok := m.transactions[id]; ok || id == 0 {
+			continue
+		}
+
+		traceID, err := m.traceID()
+		if err != nil {
+			return Transaction{}, errors.Wrap(err, "generate trace id error")
+		}
+
+		tx := Transaction{
+			TransactionID: id,
+			TraceID:       traceID,
+			AllocatedAt:   now,
+		}
+		m.transactions[id] = tx
+		m.queue = append(m.queue, tx)
+
+		return tx, nil
+	}
+
+	return Transaction{}, errors.New("allocate transaction id error: no free transaction id found")
+}
+
+// Get returns the pending transaction for the given transaction ID.
+func (m *TransactionManager) Get(id uint32) (Transaction, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tx, ok := m.transactions[id]
+	if !ok || m.now().Sub(tx.AllocatedAt) > m.ttl {
+		return Transaction{}, false
+	}
+
+	return tx, true
+}
+
+// Release releases the given transaction ID, after which it can be
+// allocated again.
+func (m *TransactionManager) Release(id uint32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.transactions, id)
+}
+
+// Len returns the number of pending transactions.
+func (m *TransactionManager) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.expire(m.now())
+	return len(m.transactions)
+}
+
+func (m *TransactionManager) expire(now time.Time) {
+	var n int
+	for _, tx := range m.queue {
+		if now.Sub(tx.AllocatedAt) <= m.ttl {
+			break
+		}
+
+		// the ID might have been released and allocated again
+		if pending, ok := m.transactions[tx.TransactionID]; ok && pending.TraceID == tx.TraceID {
+			delete(m.transactions, tx.TransactionID)
+		}
+		n++
+	}
+
+	m.queue = m.queue[n:]
+}
+
+func (m *TransactionManager) randomUint32() (uint32, error) {
+	b := make([]byte, 4)
+	if _, err := io.ReadFull(m.rand, b); err != nil {
+		return 0, errors.Wrap(err, "read random bytes error")
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+// newUUID returns a random (version 4) UUID.
+func (m *TransactionManager) newUUID() (string, error) {
+	var b [16]byte
+	if _, err := io.ReadFull(m.rand, b[:]); err != nil {
+		return "", errors.Wrap(err, "read random bytes error")
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+
+	return string(buf), nil
+}
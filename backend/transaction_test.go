@@ -0,0 +1,182 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionManager(t *testing.T) {
+	t.Run("collision check", func(t *testing.T) {
+		assert := require.New(t)
+
+		m := NewTransactionManager(0, nil)
+		// the second allocation reads the same id and must retry
+		m.SetRand(bytes.NewReader([]byte{
+			1, 0, 0, 0, // id
+			1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, // uuid
+			1, 0, 0, 0, // id (collision)
+			0, 0, 0, 0, // id (reserved)
+			2, 0, 0, 0, // id
+			1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, // uuid
+		}))
+
+		tx1, err := m.Allocate()
+		assert.NoError(err)
+		assert.Equal(uint32(1), tx1.TransactionID)
+		assert.Equal("01020304-0506-4708-890a-0b0c0d0e0f10", tx1.TraceID)
+
+		tx2, err := m.Allocate()
+		assert.NoError(err)
+		assert.Equal(uint32(2), tx2.TransactionID)
+		assert.Equal(2, m.Len())
+
+		tx, ok := m.Get(1)
+		assert.True(ok)
+		assert.Equal(tx1, tx)
+
+		m.Release(1)
+		_, ok = m.Get(1)
+		assert.False(ok)
+		assert.Equal(1, m.Len())
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		assert := require.New(t)
+
+		now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		m := NewTransactionManager(time.Minute, nil)
+		m.SetClock(func() time.Time { return now })
+
+		tx, err := m.Allocate()
+		assert.NoError(err)
+		assert.Equal(now, tx.AllocatedAt)
+
+		now = now.Add(time.Minute)
+		_, ok := m.Get(tx.TransactionID)
+		assert.True(ok)
+
+		now = now.Add(time.Second)
+		_, ok = m.Get(tx.TransactionID)
+		assert.False(ok)
+		assert.Equal(0, m.Len())
+	})
+
+	t.Run("ttl of re-allocated id", func(t *testing.T) {
+		assert := require.New(t)
+
+		now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		m := NewTransactionManager(time.Minute, nil)
+		m.SetClock(func() time.Time { return now })
+		m.SetRand(bytes.NewReader([]byte{
+			1, 0, 0, 0, // id
+			1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, // uuid
+			1, 0, 0, 0, // id (released)
+			2, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, // uuid
+		}))
+
+		_, err := m.Allocate()
+		assert.NoError(err)
+		m.Release(1)
+
+		now = now.Add(30 * time.Second)
+		tx, err := m.Allocate()
+		assert.NoError(err)
+		assert.Equal(uint32(1), tx.TransactionID)
+
+		// the expiration of the released allocation must not release the
+		// new allocation
+		now = now.Add(31 * time.Second)
+		assert.Equal(1, m.Len())
+		_, ok := m.Get(1)
+		assert.True(ok)
+
+		now = now.Add(30 * time.Second)
+		assert.Equal(0, m.Len())
+	})
+
+	t.Run("custom trace id", func(t *testing.T) {
+		assert := require.New(t)
+
+		m := NewTransactionManager(0, func() (string, error) {
+			return "01ARZ3NDEKTSV4RRFFQ69G5FAV", nil
+		})
+		tx, err := m.Allocate()
+		assert.NoError(err)
+		assert.Equal("01ARZ3NDEKTSV4RRFFQ69G5FAV", tx.TraceID)
+	})
+
+	t.Run("random uuid", func(t *testing.T) {
+		assert := require.New(t)
+
+		m := NewTransactionManager(0, nil)
+		tx, err := m.Allocate()
+		assert.NoError(err)
+		assert.Regexp(regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), tx.TraceID)
+	})
+
+	t.Run("client releases transaction", func(t *testing.T) {
+		assert := require.New(t)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req XmitDataReqPayload
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatal(err)
+			}
+
+			json.NewEncoder(w).Encode(XmitDataAnsPayload{
+				BasePayloadResult: BasePayloadResult{
+					BasePayload: BasePayload{
+						TransactionID: req.TransactionID,
+						MessageType:   XmitDataAns,
+					},
+					Result: Result{ResultCode: Success},
+				},
+			})
+		}))
+		defer server.Close()
+
+		m := NewTransactionManager(0, nil)
+		client, err := NewClient(ClientConfig{
+			SenderID:           "010101",
+			ReceiverID:         "020202",
+			Server:             server.URL,
+			TransactionManager: m,
+		})
+		assert.NoError(err)
+
+		_, err = client.XmitDataReq(context.Background(), XmitDataReqPayload{})
+		assert.NoError(err)
+		assert.Equal(0, m.Len())
+	})
+
+	t.Run("client returns allocation error", func(t *testing.T) {
+		assert := require.New(t)
+
+		m := NewTransactionManager(0, func() (string, error) {
+			return "", errors.New("trace id error")
+		})
+		client, err := NewClient(ClientConfig{
+			SenderID:           "010101",
+			ReceiverID:         "020202",
+			Server:             "http://localhost",
+			TransactionManager: m,
+		})
+		assert.NoError(err)
+
+		_, err = client.AllocateTransactionID()
+		assert.EqualError(err, "allocate transaction id error: generate trace id error: trace id error")
+		assert.Equal(uint32(0), client.GetRandomTransactionID())
+
+		_, err = client.XmitDataReq(context.Background(), XmitDataReqPayload{})
+		assert.EqualError(err, "allocate transaction id error: generate trace id error: trace id error")
+	})
+}
@@ -0,0 +1,150 @@
+package backend
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Transport defines the interface for sending the Backend Interfaces
+// messages to the peer. The client handles the message fields, the
+// (async) answer correlation, captures and logging. Implementations must be
+// safe for concurrent use by multiple goroutines.
+type Transport interface {
+	// SendRequest sends the given request. When the peer returns the
+	// answer synchronously, the (JSON encoded) answer must be written to
+	// w. When the answer is sent asynchronously, nothing is written.
+	SendRequest(ctx context.Context, pl Request, w io.Writer) error
+
+	// SendAnswer sends the given (async) answer.
+	SendAnswer(ctx context.Context, pl Answer) error
+}
+
+// httpTransport implements the HTTP(S) POST Transport.
+type httpTransport struct {
+	httpClient *http.Client
+	server     string
+	retry      RetryConfig
+	log        *log.Logger
+	rand       io.Reader
+}
+
+func (t *httpTransport) SendRequest(ctx context.Context, pl Request, w io.Writer) error {
+	resp, err := t.post(ctx, pl)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	n, err := io.Copy(w, resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "read body error")
+	}
+
+	// the body of a non-200 response can hold an answer with an error
+	// result code
+	if n == 0 && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("expected: 200, got: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (t *httpTransport) SendAnswer(ctx context.Context, pl Answer) error {
+	resp, err := t.post(ctx, pl)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		bb, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return errors.Wrap(err, "read body error")
+		}
+		return fmt.Errorf("expected: 200, got: %d (%s)", resp.StatusCode, string(bb))
+	}
+
+	return nil
+}
+
+// post posts the given payload to the server. Transient failures are
+// retried according to the retry configuration. The capture holds the last
+// attempt.
+func (t *httpTransport) post(ctx context.Context, pl interface{}) (*http.Response, error) {
+	capture := captureFromContext(ctx)
+
+	for attempt := 1; ; attempt++ {
+		req, err := t.newJSONRequest(ctx, pl)
+		if err != nil {
+			return nil, err
+		}
+		captureRequest(capture, req)
+
+		resp, err := t.httpClient.Do(req)
+		if err == nil && (!isRetryableStatus(resp.StatusCode) || attempt >= t.retry.MaxAttempts) {
+			captureResponse(capture, resp)
+			return resp, nil
+		}
+		if err != nil && (ctx.Err() != nil || attempt >= t.retry.MaxAttempts) {
+			return nil, errors.Wrap(err, "http post error")
+		}
+
+		if err == nil {
+			captureResponse(capture, resp)
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		backoff := t.retry.backoff(attempt, t.randomFloat())
+		t.log.WithFields(log.Fields{
+			"attempt": attempt,
+			"backoff": backoff,
+		}).WithError(err).Warning("lorawan/backend: retrying backend api call")
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Wrap(ctx.Err(), "http post error")
+		case <-timer.C:
+		}
+	}
+}
+
+// randomFloat returns a random value in [0, 1) read from the random source.
+func (t *httpTransport) randomFloat() float64 {
+	var b [8]byte
+	if _, err := io.ReadFull(t.rand, b[:]); err != nil {
+		return 0
+	}
+	return float64(binary.BigEndian.Uint64(b[:])>>11) / (1 << 53)
+}
+
+// newJSONRequest returns a new POST request to the server, with the JSON
+// encoding of the given payload as body. The body uses a pooled buffer,
+// which is returned to the pool when the request body is closed.
+func (t *httpTransport) newJSONRequest(ctx context.Context, pl interface{}) (*http.Request, error) {
+	buf, err := encodeJSON(pl)
+	if err != nil {
+		return nil, errors.Wrap(err, "json marshal error")
+	}
+
+	body := newPooledBody(buf)
+	req, err := http.NewRequestWithContext(ctx, "POST", t.server, body)
+	if err != nil {
+		body.Close()
+		return nil, errors.Wrap(err, "new request error")
+	}
+	req.ContentLength = int64(buf.Len())
+	req.Header.Set("Content-Type", "application/json")
+
+	return req, nil
+}
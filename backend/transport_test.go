@@ -0,0 +1,92 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+// inProcessTransport implements an in-process Transport, answering the
+// HomeNSReq requests synchronously.
+type inProcessTransport struct {
+	mu      sync.Mutex
+	answers []Answer
+}
+
+func (t *inProcessTransport) SendRequest(ctx context.Context, pl Request, w io.Writer) error {
+	req, ok := pl.(HomeNSReqPayload)
+	if !ok {
+		return errors.New("unexpected request")
+	}
+
+	return json.NewEncoder(w).Encode(HomeNSAnsPayload{
+		BasePayloadResult: BasePayloadResult{
+			BasePayload: BasePayload{
+				ProtocolVersion: req.ProtocolVersion,
+				SenderID:        req.ReceiverID,
+				ReceiverID:      req.SenderID,
+				TransactionID:   req.TransactionID,
+				MessageType:     HomeNSAns,
+			},
+			Result: Result{ResultCode: Success},
+		},
+		HNetID: lorawan.NetID{1, 2, 3},
+	})
+}
+
+func (t *inProcessTransport) SendAnswer(ctx context.Context, pl Answer) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.answers = append(t.answers, pl)
+	return nil
+}
+
+func TestClientTransport(t *testing.T) {
+	assert := require.New(t)
+
+	var captures []Capture
+	transport := inProcessTransport{}
+	c, err := NewClient(ClientConfig{
+		SenderID:   "010203",
+		ReceiverID: "0102030405060708",
+		Transport:  &transport,
+		CaptureFunc: func(c Capture) {
+			captures = append(captures, c)
+		},
+	})
+	assert.NoError(err)
+
+	ans, err := c.HomeNSReq(context.Background(), HomeNSReqPayload{})
+	assert.NoError(err)
+	assert.Equal(lorawan.NetID{1, 2, 3}, ans.HNetID)
+	assert.Equal("010203", ans.ReceiverID)
+
+	_, err = c.JoinReq(context.Background(), JoinReqPayload{})
+	assert.EqualError(err, "unexpected request")
+
+	pl := PRStartAnsPayload{
+		BasePayloadResult: BasePayloadResult{
+			BasePayload: BasePayload{
+				TransactionID: 1234,
+				MessageType:   PRStartAns,
+			},
+		},
+	}
+	assert.NoError(c.SendAnswer(context.Background(), pl))
+	assert.Equal([]Answer{pl}, transport.answers)
+
+	// the captures do not hold HTTP dumps
+	assert.Len(captures, 3)
+	for _, c := range captures {
+		assert.Nil(c.Request)
+		assert.Nil(c.Response)
+	}
+}
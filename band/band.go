@@ -23,6 +23,7 @@ const (
 	LoRaWAN_1_0_1 = "1.0.1"
 	LoRaWAN_1_0_2 = "1.0.2"
 	LoRaWAN_1_0_3 = "1.0.3"
+	LoRaWAN_1_0_4 = "1.0.4"
 	LoRaWAN_1_1_0 = "1.1.0"
 )
 
@@ -33,6 +34,9 @@ const (
 	RegParamRevC           = "C"
 	RegParamRevRP002_1_0_0 = "RP002-1.0.0"
 	RegParamRevRP002_1_0_1 = "RP002-1.0.1"
+	RegParamRevRP002_1_0_2 = "RP002-1.0.2"
+	RegParamRevRP002_1_0_3 = "RP002-1.0.3"
+	RegParamRevRP002_1_0_4 = "RP002-1.0.4"
 )
 
 // Available ISM bands (deprecated, use the common name).
@@ -51,16 +55,20 @@ const (
 
 // Available ISM bands (by common name).
 const (
-	EU868 Name = "EU868"
-	US915 Name = "US915"
-	CN779 Name = "CN779"
-	EU433 Name = "EU433"
-	AU915 Name = "AU915"
-	CN470 Name = "CN470"
-	AS923 Name = "AS923"
-	KR920 Name = "KR920"
-	IN865 Name = "IN865"
-	RU864 Name = "RU864"
+	EU868   Name = "EU868"
+	US915   Name = "US915"
+	CN779   Name = "CN779"
+	EU433   Name = "EU433"
+	AU915   Name = "AU915"
+	CN470   Name = "CN470"
+	AS923   Name = "AS923"
+	AS923_2 Name = "AS923-2"
+	AS923_3 Name = "AS923-3"
+	AS923_4 Name = "AS923-4"
+	KR920   Name = "KR920"
+	IN865   Name = "IN865"
+	RU864   Name = "RU864"
+	ISM2400 Name = "ISM2400"
 )
 
 // Modulation defines the modulation type.
@@ -68,8 +76,9 @@ type Modulation string
 
 // Possible modulation types.
 const (
-	LoRaModulation Modulation = "LORA"
-	FSKModulation  Modulation = "FSK"
+	LoRaModulation   Modulation = "LORA"
+	FSKModulation    Modulation = "FSK"
+	LRFHSSModulation Modulation = "LR_FHSS"
 )
 
 // DataRate defines a data rate
@@ -80,6 +89,23 @@ type DataRate struct {
 	SpreadFactor int        `json:"spreadFactor,omitempty"` // used for LoRa
 	Bandwidth    int        `json:"bandwidth,omitempty"`    // in kHz, used for LoRa
 	BitRate      int        `json:"bitRate,omitempty"`      // bits per second, used for FSK
+
+	// LR-FHSS parameters.
+	CodingRate           string `json:"codingRate,omitempty"`           // e.g. "1/3", used for LR-FHSS
+	OccupiedChannelWidth int    `json:"occupiedChannelWidth,omitempty"` // in Hz, used for LR-FHSS
+
+	regParamRevision string // the revision introducing the data-rate, empty when always available
+}
+
+// equal returns true when the modulation parameters of both data-rates are
+// equal.
+func (d DataRate) equal(dr DataRate) bool {
+	return d.Modulation == dr.Modulation &&
+		d.SpreadFactor == dr.SpreadFactor &&
+		d.Bandwidth == dr.Bandwidth &&
+		d.BitRate == dr.BitRate &&
+		d.CodingRate == dr.CodingRate &&
+		d.OccupiedChannelWidth == dr.OccupiedChannelWidth
 }
 
 // MaxPayloadSize defines the max payload size
@@ -144,7 +170,8 @@ type Band interface {
 	GetRX1DataRateIndex(uplinkDR, rx1DROffset int) (int, error)
 
 	// GetTXPowerOffset returns the TX Power offset for the given offset
-	// index.
+	// index. For bands instantiated using GetConfigForRevision, the offsets
+	// of the given Regional Parameters revision are used.
 	GetTXPowerOffset(txPower int) (int, error)
 
 	// AddChannel adds an extra (user-configured) uplink / downlink channel.
@@ -242,20 +269,29 @@ type band struct {
 	rx1DataRateTable      map[int][]int
 	uplinkChannels        []Channel
 	downlinkChannels      []Channel
-	txPowerOffsets        []int
+	txPowerOffsets        map[string][]int // Regional Parameters Revision / TXPower offsets
+
+	// protocolVersion and regParamRevision are set when the band was
+	// instantiated for a specific revision (see GetConfigForRevision).
+	protocolVersion  string
+	regParamRevision string
 }
 
 func (b *band) GetDataRateIndex(uplink bool, dataRate DataRate) (int, error) {
 	for i, d := range b.dataRates {
+		if !b.dataRateAvailable(d) {
+			continue
+		}
+
 		// some bands implement different data-rates with the same parameters
 		// for uplink and downlink
 		if uplink {
-			if d.uplink == true && d.Modulation == dataRate.Modulation && d.Bandwidth == dataRate.Bandwidth && d.BitRate == dataRate.BitRate && d.SpreadFactor == dataRate.SpreadFactor {
+			if d.uplink == true && d.equal(dataRate) {
 				return i, nil
 			}
 		}
 		if !uplink {
-			if d.downlink == true && d.Modulation == dataRate.Modulation && d.Bandwidth == dataRate.Bandwidth && d.BitRate == dataRate.BitRate && d.SpreadFactor == dataRate.SpreadFactor {
+			if d.downlink == true && d.equal(dataRate) {
 				return i, nil
 			}
 		}
@@ -265,7 +301,7 @@ func (b *band) GetDataRateIndex(uplink bool, dataRate DataRate) (int, error) {
 
 func (b *band) GetDataRate(dr int) (DataRate, error) {
 	d, ok := b.dataRates[dr]
-	if !ok {
+	if !ok || !b.dataRateAvailable(d) {
 		return DataRate{}, errors.New("lorawan/band: invalid data-rate")
 	}
 
@@ -273,6 +309,16 @@ func (b *band) GetDataRate(dr int) (DataRate, error) {
 }
 
 func (b *band) GetMaxPayloadSizeForDataRateIndex(protocolVersion, regParamRevision string, dr int) (MaxPayloadSize, error) {
+	if protocolVersion == "" {
+		protocolVersion = b.protocolVersion
+	}
+	if regParamRevision == "" {
+		regParamRevision = b.regParamRevision
+	}
+	if d, ok := b.dataRates[dr]; ok && regParamRevisionBefore(regParamRevision, d.regParamRevision) {
+		return MaxPayloadSize{}, errors.New("lorawan/band: invalid data-rate")
+	}
+
 	regParamMap, ok := b.maxPayloadSizePerDR[protocolVersion]
 	if !ok {
 		regParamMap, ok = b.maxPayloadSizePerDR[latest]
@@ -310,10 +356,15 @@ func (b *band) GetRX1DataRateIndex(uplinkDR, rx1DROffset int) (int, error) {
 }
 
 func (b *band) GetTXPowerOffset(txPower int) (int, error) {
-	if txPower > len(b.txPowerOffsets)-1 {
+	offsets, ok := b.txPowerOffsets[b.regParamRevision]
+	if !ok {
+		offsets = b.txPowerOffsets[latest]
+	}
+
+	if txPower < 0 || txPower > len(offsets)-1 {
 		return 0, errors.New("lorawan/band: invalid tx-power")
 	}
-	return b.txPowerOffsets[txPower], nil
+	return offsets[txPower], nil
 }
 
 func (b *band) AddChannel(frequency, minDR, maxDR int) error {
@@ -485,9 +536,20 @@ func (b *band) getCFListChannelMask() *lorawan.CFList {
 func (b *band) getCFListChannels() *lorawan.CFList {
 	var pl lorawan.CFListChannelPayload
 
+	// The device assumes the data-rate range of the default channels for
+	// the channels in the CFList, e.g. DR0 - DR5 for EU868 and DR0 - DR7
+	// for ISM2400.
+	var minDR, maxDR int
+	for _, c := range b.uplinkChannels {
+		if !c.custom {
+			minDR, maxDR = c.MinDR, c.MaxDR
+			break
+		}
+	}
+
 	var i int
 	for _, c := range b.uplinkChannels {
-		if c.custom && i < len(pl.Channels) && c.MinDR == 0 && c.MaxDR == 5 {
+		if c.custom && i < len(pl.Channels) && c.MinDR == minDR && c.MaxDR == maxDR {
 			pl.Channels[i] = uint32(c.Frequency)
 			i++
 		}
@@ -632,32 +694,47 @@ func channelIsActive(channels []int, i int) bool {
 	return false
 }
 
+type newBandFunc func(name Name, repeaterCompatible bool, dt lorawan.DwellTime) (Band, error)
+
+// predefinedBands contains the pre-defined bands by name.
+var predefinedBands = map[Name]newBandFunc{
+	AS_923:     func(_ Name, rc bool, dt lorawan.DwellTime) (Band, error) { return newAS923Band(AS923, rc, dt) },
+	AS923:      newAS923Band,
+	AS923_2:    newAS923Band,
+	AS923_3:    newAS923Band,
+	AS923_4:    newAS923Band,
+	AU_915_928: func(_ Name, rc bool, dt lorawan.DwellTime) (Band, error) { return newAU915Band(rc, dt) },
+	AU915:      func(_ Name, rc bool, dt lorawan.DwellTime) (Band, error) { return newAU915Band(rc, dt) },
+	CN_470_510: func(_ Name, rc bool, _ lorawan.DwellTime) (Band, error) { return newCN470Band(rc) },
+	CN470:      func(_ Name, rc bool, _ lorawan.DwellTime) (Band, error) { return newCN470Band(rc) },
+	CN_779_787: func(_ Name, rc bool, _ lorawan.DwellTime) (Band, error) { return newCN779Band(rc) },
+	CN779:      func(_ Name, rc bool, _ lorawan.DwellTime) (Band, error) { return newCN779Band(rc) },
+	EU_433:     func(_ Name, rc bool, _ lorawan.DwellTime) (Band, error) { return newEU433Band(rc) },
+	EU433:      func(_ Name, rc bool, _ lorawan.DwellTime) (Band, error) { return newEU433Band(rc) },
+	EU_863_870: func(_ Name, rc bool, _ lorawan.DwellTime) (Band, error) { return newEU863Band(rc) },
+	EU868:      func(_ Name, rc bool, _ lorawan.DwellTime) (Band, error) { return newEU863Band(rc) },
+	IN_865_867: func(_ Name, rc bool, _ lorawan.DwellTime) (Band, error) { return newIN865Band(rc) },
+	IN865:      func(_ Name, rc bool, _ lorawan.DwellTime) (Band, error) { return newIN865Band(rc) },
+	KR_920_923: func(_ Name, rc bool, _ lorawan.DwellTime) (Band, error) { return newKR920Band(rc) },
+	KR920:      func(_ Name, rc bool, _ lorawan.DwellTime) (Band, error) { return newKR920Band(rc) },
+	US_902_928: func(_ Name, rc bool, _ lorawan.DwellTime) (Band, error) { return newUS902Band(rc) },
+	US915:      func(_ Name, rc bool, _ lorawan.DwellTime) (Band, error) { return newUS902Band(rc) },
+	RU_864_870: func(_ Name, rc bool, _ lorawan.DwellTime) (Band, error) { return newRU864Band(rc) },
+	RU864:      func(_ Name, rc bool, _ lorawan.DwellTime) (Band, error) { return newRU864Band(rc) },
+	ISM2400:    func(_ Name, rc bool, _ lorawan.DwellTime) (Band, error) { return newISM2400Band(rc) },
+}
+
 // GetConfig returns the band configuration for the given band.
 // Please refer to the LoRaWAN specification for more details about the effect
-// of the repeater and dwell time arguments.
+// of the repeater and dwell time arguments. Bands registered using
+// RegisterBand can be retrieved by their registered name.
 func GetConfig(name Name, repeaterCompatible bool, dt lorawan.DwellTime) (Band, error) {
-	switch name {
-	case AS_923, AS923:
-		return newAS923Band(repeaterCompatible, dt)
-	case AU_915_928, AU915:
-		return newAU915Band(repeaterCompatible, dt)
-	case CN_470_510, CN470:
-		return newCN470Band(repeaterCompatible)
-	case CN_779_787, CN779:
-		return newCN779Band(repeaterCompatible)
-	case EU_433, EU433:
-		return newEU433Band(repeaterCompatible)
-	case EU_863_870, EU868:
-		return newEU863Band(repeaterCompatible)
-	case IN_865_867, IN865:
-		return newIN865Band(repeaterCompatible)
-	case KR_920_923, KR920:
-		return newKR920Band(repeaterCompatible)
-	case US_902_928, US915:
-		return newUS902Band(repeaterCompatible)
-	case RU_864_870, RU864:
-		return newRU864Band(repeaterCompatible)
-	default:
-		return nil, fmt.Errorf("lorawan/band: band %s is undefined", name)
+	if f, ok := predefinedBands[name]; ok {
+		return f(name, repeaterCompatible, dt)
+	}
+
+	if b, ok := getCustomBand(name, repeaterCompatible); ok {
+		return b, nil
 	}
+	return nil, fmt.Errorf("lorawan/band: band %s is undefined", name)
 }
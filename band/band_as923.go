@@ -7,18 +7,29 @@ import (
 	"github.com/brocaar/lorawan"
 )
 
+// as923FrequencyOffsets defines the frequency offset (Hz) of each AS923
+// group, relative to the AS923-1 frequencies.
+var as923FrequencyOffsets = map[Name]int{
+	AS923:   0,
+	AS923_2: -1800000,
+	AS923_3: -6600000,
+	AS923_4: -5900000,
+}
+
 type as923Band struct {
 	band
-	dwellTime lorawan.DwellTime
+	name            Name
+	dwellTime       lorawan.DwellTime
+	frequencyOffset int
 }
 
 func (b *as923Band) Name() string {
-	return "AS923"
+	return string(b.name)
 }
 
 func (b *as923Band) GetDefaults() Defaults {
 	return Defaults{
-		RX2Frequency:     923200000,
+		RX2Frequency:     923200000 + b.frequencyOffset,
 		RX2DataRate:      2,
 		ReceiveDelay1:    time.Second,
 		ReceiveDelay2:    time.Second * 2,
@@ -36,7 +47,7 @@ func (b *as923Band) GetDefaultMaxUplinkEIRP() float32 {
 }
 
 func (b *as923Band) GetPingSlotFrequency(lorawan.DevAddr, time.Duration) (int, error) {
-	return 923400000, nil
+	return 923400000 + b.frequencyOffset, nil
 }
 
 func (b *as923Band) GetRX1ChannelIndexForUplinkChannelIndex(uplinkChannel int) (int, error) {
@@ -79,9 +90,16 @@ func (b *as923Band) ImplementsTXParamSetup(protocolVersion string) bool {
 	return true
 }
 
-func newAS923Band(repeaterCompatible bool, dt lorawan.DwellTime) (Band, error) {
+func newAS923Band(name Name, repeaterCompatible bool, dt lorawan.DwellTime) (Band, error) {
+	frequencyOffset, ok := as923FrequencyOffsets[name]
+	if !ok {
+		return nil, fmt.Errorf("lorawan/band: band %s is undefined", name)
+	}
+
 	b := as923Band{
-		dwellTime: dt,
+		name:            name,
+		dwellTime:       dt,
+		frequencyOffset: frequencyOffset,
 		band: band{
 			supportsExtraChannels: true,
 			dataRates: map[int]DataRate{
@@ -95,23 +113,25 @@ func newAS923Band(repeaterCompatible bool, dt lorawan.DwellTime) (Band, error) {
 				7: {Modulation: FSKModulation, BitRate: 50000, uplink: true, downlink: true},
 			},
 			rx1DataRateTable: map[int][]int{}, // implemented as function
-			txPowerOffsets: []int{
-				0,   // 0
-				-2,  // 1
-				-4,  // 2
-				-6,  // 3
-				-8,  // 4
-				-10, // 5
-				-12, // 6
-				-14, // 7
+			txPowerOffsets: map[string][]int{
+				latest: {
+					0,   // 0
+					-2,  // 1
+					-4,  // 2
+					-6,  // 3
+					-8,  // 4
+					-10, // 5
+					-12, // 6
+					-14, // 7
+				},
 			},
 			uplinkChannels: []Channel{
-				{Frequency: 923200000, MinDR: 0, MaxDR: 5, enabled: true},
-				{Frequency: 923400000, MinDR: 0, MaxDR: 5, enabled: true},
+				{Frequency: 923200000 + frequencyOffset, MinDR: 0, MaxDR: 5, enabled: true},
+				{Frequency: 923400000 + frequencyOffset, MinDR: 0, MaxDR: 5, enabled: true},
 			},
 			downlinkChannels: []Channel{
-				{Frequency: 923200000, MinDR: 0, MaxDR: 5, enabled: true},
-				{Frequency: 923400000, MinDR: 0, MaxDR: 5, enabled: true},
+				{Frequency: 923200000 + frequencyOffset, MinDR: 0, MaxDR: 5, enabled: true},
+				{Frequency: 923400000 + frequencyOffset, MinDR: 0, MaxDR: 5, enabled: true},
 			},
 		},
 	}
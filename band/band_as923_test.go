@@ -111,3 +111,47 @@ func TestAS923Band(t *testing.T) {
 		})
 	})
 }
+
+func TestAS923Groups(t *testing.T) {
+	Convey("Given a set of tests", t, func() {
+		tests := []struct {
+			Name              Name
+			UplinkChannels    []int
+			RX2Frequency      int
+			PingSlotFrequency int
+		}{
+			{AS923, []int{923200000, 923400000}, 923200000, 923400000},
+			{AS923_2, []int{921400000, 921600000}, 921400000, 921600000},
+			{AS923_3, []int{916600000, 916800000}, 916600000, 916800000},
+			{AS923_4, []int{917300000, 917500000}, 917300000, 917500000},
+		}
+
+		for i, test := range tests {
+			Convey(fmt.Sprintf("Testing %s [%d]", test.Name, i), func() {
+				band, err := GetConfig(test.Name, false, lorawan.DwellTime400ms)
+				So(err, ShouldBeNil)
+				So(band.Name(), ShouldEqual, string(test.Name))
+
+				for j, f := range test.UplinkChannels {
+					c, err := band.GetUplinkChannel(j)
+					So(err, ShouldBeNil)
+					So(c.Frequency, ShouldEqual, f)
+
+					c, err = band.GetDownlinkChannel(j)
+					So(err, ShouldBeNil)
+					So(c.Frequency, ShouldEqual, f)
+				}
+
+				So(band.GetDefaults().RX2Frequency, ShouldEqual, test.RX2Frequency)
+
+				f, err := band.GetPingSlotFrequency(lorawan.DevAddr{}, 0)
+				So(err, ShouldBeNil)
+				So(f, ShouldEqual, test.PingSlotFrequency)
+
+				beacon, err := GetBeacon(band, 0)
+				So(err, ShouldBeNil)
+				So(beacon.Frequency, ShouldEqual, test.PingSlotFrequency)
+			})
+		}
+	})
+}
@@ -37,7 +37,7 @@ func (b *au915Band) GetDefaultMaxUplinkEIRP() float32 {
 }
 
 func (b *au915Band) GetPingSlotFrequency(devAddr lorawan.DevAddr, beaconTime time.Duration) (int, error) {
-	downlinkChannel := (int(binary.BigEndian.Uint32(devAddr[:])) + int(beaconTime/(128*time.Second))) % 8
+	downlinkChannel := (int(binary.BigEndian.Uint32(devAddr[:])) + int(beaconTime/BeaconPeriod)) % 8
 	return b.downlinkChannels[downlinkChannel].Frequency, nil
 }
 
@@ -165,19 +165,23 @@ func (b *au915Band) ImplementsTXParamSetup(protocolVersion string) bool {
 	return true
 }
 
+// au915TXPowerOffsetsRevB contains the TXPower offsets up to Regional
+// Parameters revision C, defining TXPower 0 - 10 (RFU above).
+var au915TXPowerOffsetsRevB = []int{0, -2, -4, -6, -8, -10, -12, -14, -16, -18, -20}
+
 func newAU915Band(repeaterCompatible bool, dt lorawan.DwellTime) (Band, error) {
 	b := au915Band{
 		dwellTime: dt,
 		band: band{
 			dataRates: map[int]DataRate{
-				0: {Modulation: LoRaModulation, SpreadFactor: 12, Bandwidth: 125, uplink: true},
-				1: {Modulation: LoRaModulation, SpreadFactor: 11, Bandwidth: 125, uplink: true},
-				2: {Modulation: LoRaModulation, SpreadFactor: 10, Bandwidth: 125, uplink: true},
-				3: {Modulation: LoRaModulation, SpreadFactor: 9, Bandwidth: 125, uplink: true},
-				4: {Modulation: LoRaModulation, SpreadFactor: 8, Bandwidth: 125, uplink: true},
-				5: {Modulation: LoRaModulation, SpreadFactor: 7, Bandwidth: 125, uplink: true},
-				6: {Modulation: LoRaModulation, SpreadFactor: 8, Bandwidth: 500, uplink: true},
-				// 7
+				0:  {Modulation: LoRaModulation, SpreadFactor: 12, Bandwidth: 125, uplink: true},
+				1:  {Modulation: LoRaModulation, SpreadFactor: 11, Bandwidth: 125, uplink: true},
+				2:  {Modulation: LoRaModulation, SpreadFactor: 10, Bandwidth: 125, uplink: true},
+				3:  {Modulation: LoRaModulation, SpreadFactor: 9, Bandwidth: 125, uplink: true},
+				4:  {Modulation: LoRaModulation, SpreadFactor: 8, Bandwidth: 125, uplink: true},
+				5:  {Modulation: LoRaModulation, SpreadFactor: 7, Bandwidth: 125, uplink: true},
+				6:  {Modulation: LoRaModulation, SpreadFactor: 8, Bandwidth: 500, uplink: true},
+				7:  {Modulation: LRFHSSModulation, CodingRate: "1/3", OccupiedChannelWidth: 1523000, uplink: true, regParamRevision: RegParamRevRP002_1_0_2},
 				8:  {Modulation: LoRaModulation, SpreadFactor: 12, Bandwidth: 500, downlink: true},
 				9:  {Modulation: LoRaModulation, SpreadFactor: 11, Bandwidth: 500, downlink: true},
 				10: {Modulation: LoRaModulation, SpreadFactor: 10, Bandwidth: 500, downlink: true},
@@ -193,23 +197,29 @@ func newAU915Band(repeaterCompatible bool, dt lorawan.DwellTime) (Band, error) {
 				4: {12, 11, 10, 9, 8, 8},
 				5: {13, 12, 11, 10, 9, 8},
 				6: {13, 13, 12, 11, 10, 9},
+				7: {9, 8, 8, 8, 8, 8},
 			},
-			txPowerOffsets: []int{
-				0,   // 0
-				-2,  // 1
-				-4,  // 2
-				-6,  // 3
-				-8,  // 4
-				-10, // 5
-				-12, // 6
-				-14, // 7
-				-16, // 8
-				-18, // 9
-				-20, // 10
-				-22, // 11
-				-24, // 12
-				-26, // 13
-				-28, // 14
+			txPowerOffsets: map[string][]int{
+				RegParamRevA: au915TXPowerOffsetsRevB,
+				RegParamRevB: au915TXPowerOffsetsRevB,
+				RegParamRevC: au915TXPowerOffsetsRevB,
+				latest: { // RP002-1.0.0 and later
+					0,   // 0
+					-2,  // 1
+					-4,  // 2
+					-6,  // 3
+					-8,  // 4
+					-10, // 5
+					-12, // 6
+					-14, // 7
+					-16, // 8
+					-18, // 9
+					-20, // 10
+					-22, // 11
+					-24, // 12
+					-26, // 13
+					-28, // 14
+				},
 			},
 			uplinkChannels:   make([]Channel, 72),
 			downlinkChannels: make([]Channel, 8),
@@ -259,14 +269,14 @@ func newAU915Band(repeaterCompatible bool, dt lorawan.DwellTime) (Band, error) {
 				},
 				latest: map[string]map[int]MaxPayloadSize{
 					latest: map[int]MaxPayloadSize{ // RP002-1.0.0, RP002-1.0.1
-						0: {M: 0, N: 0},
-						1: {M: 0, N: 0},
-						2: {M: 19, N: 11},
-						3: {M: 61, N: 53},
-						4: {M: 133, N: 125},
-						5: {M: 230, N: 222},
-						6: {M: 230, N: 222},
-						// 7
+						0:  {M: 0, N: 0},
+						1:  {M: 0, N: 0},
+						2:  {M: 19, N: 11},
+						3:  {M: 61, N: 53},
+						4:  {M: 133, N: 125},
+						5:  {M: 230, N: 222},
+						6:  {M: 230, N: 222},
+						7:  {M: 58, N: 50},
 						8:  {M: 41, N: 33},
 						9:  {M: 117, N: 109},
 						10: {M: 230, N: 222},
@@ -365,14 +375,14 @@ func newAU915Band(repeaterCompatible bool, dt lorawan.DwellTime) (Band, error) {
 				},
 				latest: map[string]map[int]MaxPayloadSize{
 					latest: map[int]MaxPayloadSize{ // RP002-1.0.0, RP002-1.0.1
-						0: {M: 59, N: 51},
-						1: {M: 59, N: 51},
-						2: {M: 59, N: 51},
-						3: {M: 123, N: 115},
-						4: {M: 230, N: 222},
-						5: {M: 230, N: 222},
-						6: {M: 230, N: 222},
-						// 7
+						0:  {M: 59, N: 51},
+						1:  {M: 59, N: 51},
+						2:  {M: 59, N: 51},
+						3:  {M: 123, N: 115},
+						4:  {M: 230, N: 222},
+						5:  {M: 230, N: 222},
+						6:  {M: 230, N: 222},
+						7:  {M: 58, N: 50},
 						8:  {M: 41, N: 33},
 						9:  {M: 117, N: 109},
 						10: {M: 230, N: 222},
@@ -426,14 +436,14 @@ func newAU915Band(repeaterCompatible bool, dt lorawan.DwellTime) (Band, error) {
 				},
 				latest: map[string]map[int]MaxPayloadSize{
 					latest: map[int]MaxPayloadSize{ // RP002-1.0.0, RP002-1.0.1
-						0: {M: 0, N: 0},
-						1: {M: 0, N: 0},
-						2: {M: 19, N: 11},
-						3: {M: 61, N: 53},
-						4: {M: 133, N: 125},
-						5: {M: 250, N: 242},
-						6: {M: 250, N: 242},
-						// 7
+						0:  {M: 0, N: 0},
+						1:  {M: 0, N: 0},
+						2:  {M: 19, N: 11},
+						3:  {M: 61, N: 53},
+						4:  {M: 133, N: 125},
+						5:  {M: 250, N: 242},
+						6:  {M: 250, N: 242},
+						7:  {M: 58, N: 50},
 						8:  {M: 61, N: 53},
 						9:  {M: 137, N: 129},
 						10: {M: 250, N: 242},
@@ -532,14 +542,14 @@ func newAU915Band(repeaterCompatible bool, dt lorawan.DwellTime) (Band, error) {
 				},
 				latest: map[string]map[int]MaxPayloadSize{
 					latest: map[int]MaxPayloadSize{ // RP002-1.0.0, RP002-1.0.1
-						0: {M: 59, N: 51},
-						1: {M: 59, N: 51},
-						2: {M: 59, N: 51},
-						3: {M: 123, N: 115},
-						4: {M: 250, N: 242},
-						5: {M: 250, N: 242},
-						6: {M: 250, N: 242},
-						// 7
+						0:  {M: 59, N: 51},
+						1:  {M: 59, N: 51},
+						2:  {M: 59, N: 51},
+						3:  {M: 123, N: 115},
+						4:  {M: 250, N: 242},
+						5:  {M: 250, N: 242},
+						6:  {M: 250, N: 242},
+						7:  {M: 58, N: 50},
 						8:  {M: 61, N: 53},
 						9:  {M: 137, N: 129},
 						10: {M: 250, N: 242},
@@ -35,7 +35,7 @@ func (b *cn470Band) GetDefaultMaxUplinkEIRP() float32 {
 }
 
 func (b *cn470Band) GetPingSlotFrequency(devAddr lorawan.DevAddr, beaconTime time.Duration) (int, error) {
-	downlinkChannel := (int(binary.BigEndian.Uint32(devAddr[:])) + int(beaconTime/(128*time.Second))) % 8
+	downlinkChannel := (int(binary.BigEndian.Uint32(devAddr[:])) + int(beaconTime/BeaconPeriod)) % 8
 	return b.downlinkChannels[downlinkChannel].Frequency, nil
 }
 
@@ -82,15 +82,17 @@ func newCN470Band(repeaterCompatible bool) (Band, error) {
 				4: {4, 3, 2, 1, 0, 0},
 				5: {5, 4, 3, 2, 1, 0},
 			},
-			txPowerOffsets: []int{
-				0,   // 0
-				-2,  // 1
-				-4,  // 2
-				-6,  // 3
-				-8,  // 4
-				-10, // 5
-				-12, // 6
-				-14, // 7
+			txPowerOffsets: map[string][]int{
+				latest: {
+					0,   // 0
+					-2,  // 1
+					-4,  // 2
+					-6,  // 3
+					-8,  // 4
+					-10, // 5
+					-12, // 6
+					-14, // 7
+				},
 			},
 			uplinkChannels:   make([]Channel, 96),
 			downlinkChannels: make([]Channel, 48),
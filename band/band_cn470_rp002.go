@@ -0,0 +1,244 @@
+package band
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/brocaar/lorawan"
+)
+
+// CN470Plan defines the CN470 channel plan as introduced by RP002-1.0.1.
+// The channel plan depends on the antenna type (20 MHz or 26 MHz) and
+// the plan type (A or B).
+type CN470Plan string
+
+// Available CN470 channel plans.
+const (
+	CN470Plan20MHzA CN470Plan = "20MHz_A"
+	CN470Plan20MHzB CN470Plan = "20MHz_B"
+	CN470Plan26MHzA CN470Plan = "26MHz_A"
+	CN470Plan26MHzB CN470Plan = "26MHz_B"
+)
+
+// cn470JoinFrequencies contains the common join channels per channel plan.
+var cn470JoinFrequencies = map[CN470Plan][]int{
+	CN470Plan20MHzA: {470900000, 472500000, 474100000, 475700000, 504100000, 505700000, 507300000, 508900000},
+	CN470Plan20MHzB: {479900000, 499900000},
+	CN470Plan26MHzA: {470300000, 472300000, 474300000, 476300000, 478300000},
+	CN470Plan26MHzB: {480300000, 482300000, 484300000, 486300000, 488300000},
+}
+
+type cn470RP002Band struct {
+	band
+	plan CN470Plan
+}
+
+func (b *cn470RP002Band) Name() string {
+	return "CN470"
+}
+
+func (b *cn470RP002Band) GetDefaults() Defaults {
+	d := Defaults{
+		RX2DataRate:      1,
+		ReceiveDelay1:    time.Second,
+		ReceiveDelay2:    time.Second * 2,
+		JoinAcceptDelay1: time.Second * 5,
+		JoinAcceptDelay2: time.Second * 6,
+	}
+
+	switch b.plan {
+	case CN470Plan20MHzA:
+		d.RX2Frequency = 485300000
+	case CN470Plan20MHzB:
+		d.RX2Frequency = 486900000
+	case CN470Plan26MHzA:
+		d.RX2Frequency = 492500000
+	case CN470Plan26MHzB:
+		d.RX2Frequency = 502500000
+	}
+
+	return d
+}
+
+func (b *cn470RP002Band) GetDownlinkTXPower(freq int) int {
+	return 14
+}
+
+func (b *cn470RP002Band) GetDefaultMaxUplinkEIRP() float32 {
+	return 19.15
+}
+
+func (b *cn470RP002Band) GetPingSlotFrequency(devAddr lorawan.DevAddr, beaconTime time.Duration) (int, error) {
+	downlinkChannel := (int(binary.BigEndian.Uint32(devAddr[:])) + int(beaconTime/BeaconPeriod)) % len(b.downlinkChannels)
+	return b.downlinkChannels[downlinkChannel].Frequency, nil
+}
+
+func (b *cn470RP002Band) GetRX1ChannelIndexForUplinkChannelIndex(uplinkChannel int) (int, error) {
+	if uplinkChannel > len(b.uplinkChannels)-1 {
+		return 0, ErrChannelDoesNotExist
+	}
+	return uplinkChannel % len(b.downlinkChannels), nil
+}
+
+func (b *cn470RP002Band) GetRX1FrequencyForUplinkFrequency(uplinkFrequency int) (int, error) {
+	uplinkChan, err := b.GetUplinkChannelIndex(uplinkFrequency, true)
+	if err != nil {
+		return 0, err
+	}
+
+	rx1Chan, err := b.GetRX1ChannelIndexForUplinkChannelIndex(uplinkChan)
+	if err != nil {
+		return 0, err
+	}
+
+	return b.downlinkChannels[rx1Chan].Frequency, nil
+}
+
+func (b *cn470RP002Band) ImplementsTXParamSetup(protocolVersion string) bool {
+	return false
+}
+
+// GetCN470Config returns the CN470 band configuration for the given
+// RP002 channel plan.
+func GetCN470Config(plan CN470Plan, repeaterCompatible bool) (Band, error) {
+	return newCN470RP002Band(plan, repeaterCompatible)
+}
+
+// GetCN470JoinFrequencies returns the common join channel frequencies of
+// the given RP002 channel plan. A device which does not know its channel
+// plan scans these channels when joining.
+func GetCN470JoinFrequencies(plan CN470Plan) ([]int, error) {
+	freqs, ok := cn470JoinFrequencies[plan]
+	if !ok {
+		return nil, fmt.Errorf("lorawan/band: unknown CN470 channel plan: %s", plan)
+	}
+
+	out := make([]int, len(freqs))
+	copy(out, freqs)
+	return out, nil
+}
+
+func newCN470RP002Band(plan CN470Plan, repeaterCompatible bool) (Band, error) {
+	b := cn470RP002Band{
+		plan: plan,
+		band: band{
+			dataRates: map[int]DataRate{
+				0: {Modulation: LoRaModulation, SpreadFactor: 12, Bandwidth: 125, uplink: true, downlink: true},
+				1: {Modulation: LoRaModulation, SpreadFactor: 11, Bandwidth: 125, uplink: true, downlink: true},
+				2: {Modulation: LoRaModulation, SpreadFactor: 10, Bandwidth: 125, uplink: true, downlink: true},
+				3: {Modulation: LoRaModulation, SpreadFactor: 9, Bandwidth: 125, uplink: true, downlink: true},
+				4: {Modulation: LoRaModulation, SpreadFactor: 8, Bandwidth: 125, uplink: true, downlink: true},
+				5: {Modulation: LoRaModulation, SpreadFactor: 7, Bandwidth: 125, uplink: true, downlink: true},
+				6: {Modulation: LoRaModulation, SpreadFactor: 7, Bandwidth: 500, uplink: true, downlink: true},
+				7: {Modulation: FSKModulation, BitRate: 50000, uplink: true, downlink: true},
+			},
+			rx1DataRateTable: map[int][]int{
+				0: {0, 0, 0, 0, 0, 0},
+				1: {1, 0, 0, 0, 0, 0},
+				2: {2, 1, 0, 0, 0, 0},
+				3: {3, 2, 1, 0, 0, 0},
+				4: {4, 3, 2, 1, 0, 0},
+				5: {5, 4, 3, 2, 1, 0},
+				6: {6, 5, 4, 3, 2, 1},
+				7: {7, 6, 5, 4, 3, 2},
+			},
+			txPowerOffsets: map[string][]int{
+				latest: {
+					0,   // 0
+					-2,  // 1
+					-4,  // 2
+					-6,  // 3
+					-8,  // 4
+					-10, // 5
+					-12, // 6
+					-14, // 7
+				},
+			},
+		},
+	}
+
+	if repeaterCompatible {
+		b.band.maxPayloadSizePerDR = map[string]map[string]map[int]MaxPayloadSize{
+			latest: map[string]map[int]MaxPayloadSize{
+				latest: map[int]MaxPayloadSize{ // RP002-1.0.1
+					0: {M: 0, N: 0},
+					1: {M: 31, N: 23},
+					2: {M: 94, N: 86},
+					3: {M: 172, N: 164},
+					4: {M: 230, N: 222},
+					5: {M: 230, N: 222},
+					6: {M: 230, N: 222},
+					7: {M: 230, N: 222},
+				},
+			},
+		}
+	} else {
+		b.band.maxPayloadSizePerDR = map[string]map[string]map[int]MaxPayloadSize{
+			latest: map[string]map[int]MaxPayloadSize{
+				latest: map[int]MaxPayloadSize{ // RP002-1.0.1
+					0: {M: 0, N: 0},
+					1: {M: 31, N: 23},
+					2: {M: 94, N: 86},
+					3: {M: 192, N: 184},
+					4: {M: 250, N: 242},
+					5: {M: 250, N: 242},
+					6: {M: 250, N: 242},
+					7: {M: 250, N: 242},
+				},
+			},
+		}
+	}
+
+	switch plan {
+	case CN470Plan20MHzA, CN470Plan20MHzB:
+		// The 20 MHz plans define two groups of 32 channels. The RX1
+		// channel equals the uplink channel.
+		groups := [2]int{470300000, 503500000}
+		if plan == CN470Plan20MHzB {
+			groups = [2]int{476900000, 496500000}
+		}
+
+		for _, start := range groups {
+			for i := 0; i < 32; i++ {
+				c := Channel{
+					Frequency: start + (i * 200000),
+					MinDR:     0,
+					MaxDR:     5,
+					enabled:   true,
+				}
+				b.uplinkChannels = append(b.uplinkChannels, c)
+				b.downlinkChannels = append(b.downlinkChannels, c)
+			}
+		}
+	case CN470Plan26MHzA, CN470Plan26MHzB:
+		// The 26 MHz plans define 48 uplink channels and 24 downlink
+		// channels.
+		uplinkStart, downlinkStart := 470300000, 490100000
+		if plan == CN470Plan26MHzB {
+			uplinkStart, downlinkStart = 480300000, 500100000
+		}
+
+		for i := 0; i < 48; i++ {
+			b.uplinkChannels = append(b.uplinkChannels, Channel{
+				Frequency: uplinkStart + (i * 200000),
+				MinDR:     0,
+				MaxDR:     5,
+				enabled:   true,
+			})
+		}
+
+		for i := 0; i < 24; i++ {
+			b.downlinkChannels = append(b.downlinkChannels, Channel{
+				Frequency: downlinkStart + (i * 200000),
+				MinDR:     0,
+				MaxDR:     5,
+				enabled:   true,
+			})
+		}
+	default:
+		return nil, fmt.Errorf("lorawan/band: unknown CN470 channel plan: %s", plan)
+	}
+
+	return &b, nil
+}
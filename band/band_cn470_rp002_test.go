@@ -0,0 +1,57 @@
+package band
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCN470RP002Band(t *testing.T) {
+	Convey("Given the CN470 RP002 channel plans", t, func() {
+		tests := []struct {
+			Plan             CN470Plan
+			UplinkChannels   int
+			DownlinkChannels int
+			RX2Frequency     int
+			UplinkFrequency  int
+			RX1Frequency     int
+		}{
+			{CN470Plan20MHzA, 64, 64, 485300000, 503500000, 503500000},
+			{CN470Plan20MHzB, 64, 64, 486900000, 479900000, 479900000},
+			{CN470Plan26MHzA, 48, 24, 492500000, 474900000, 494700000},
+			{CN470Plan26MHzB, 48, 24, 502500000, 480300000, 500100000},
+		}
+
+		for _, tst := range tests {
+			Convey("Testing plan "+string(tst.Plan), func() {
+				b, err := GetCN470Config(tst.Plan, false)
+				So(err, ShouldBeNil)
+
+				So(b.GetUplinkChannelIndices(), ShouldHaveLength, tst.UplinkChannels)
+				So(b.GetDefaults().RX2Frequency, ShouldEqual, tst.RX2Frequency)
+				So(b.GetDefaults().RX2DataRate, ShouldEqual, 1)
+
+				f, err := b.GetRX1FrequencyForUplinkFrequency(tst.UplinkFrequency)
+				So(err, ShouldBeNil)
+				So(f, ShouldEqual, tst.RX1Frequency)
+
+				_, err = b.GetDownlinkChannel(tst.DownlinkChannels - 1)
+				So(err, ShouldBeNil)
+				_, err = b.GetDownlinkChannel(tst.DownlinkChannels)
+				So(err, ShouldNotBeNil)
+
+				joinFreqs, err := GetCN470JoinFrequencies(tst.Plan)
+				So(err, ShouldBeNil)
+				for _, f := range joinFreqs {
+					_, err := b.GetUplinkChannelIndex(f, true)
+					So(err, ShouldBeNil)
+				}
+			})
+		}
+
+		Convey("Then an unknown plan returns an error", func() {
+			_, err := GetCN470Config("foo", false)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,264 @@
+package band
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/brocaar/lorawan"
+)
+
+var (
+	customBandsMux sync.RWMutex
+	customBands    = make(map[Name]CustomBandConfig)
+)
+
+// CustomDataRate defines a data-rate of a custom band.
+type CustomDataRate struct {
+	DataRate
+
+	// Uplink and Downlink define if the data-rate can be used for uplink
+	// and / or downlink transmissions.
+	Uplink   bool
+	Downlink bool
+}
+
+// CustomBandConfig defines the configuration of a custom (e.g. private or
+// experimental) band, which can be registered using RegisterBand.
+type CustomBandConfig struct {
+	// DataRates defines the data-rates by index.
+	DataRates map[int]CustomDataRate
+
+	// MaxPayloadSizes defines the max-payload size by data-rate index.
+	MaxPayloadSizes map[int]MaxPayloadSize
+
+	// RepeaterMaxPayloadSizes defines the max-payload size by data-rate
+	// index in case of repeater compatibility. When not set, MaxPayloadSizes
+	// is used.
+	RepeaterMaxPayloadSizes map[int]MaxPayloadSize
+
+	// RX1DataRateTable defines the RX1 data-rate by uplink data-rate index
+	// and RX1 data-rate offset.
+	RX1DataRateTable map[int][]int
+
+	// TXPowerOffsets defines the TX power offsets (dB) by TXPower index.
+	TXPowerOffsets []int
+
+	// UplinkChannels defines the default uplink channels.
+	UplinkChannels []Channel
+
+	// DownlinkChannels defines the downlink channels. When not set, RX1 uses
+	// the uplink channel. Else the RX1 channel is the uplink channel index
+	// modulo the number of downlink channels.
+	DownlinkChannels []Channel
+
+	// SupportsExtraChannels defines if extra channels can be added.
+	SupportsExtraChannels bool
+
+	// Defaults defines the band defaults.
+	Defaults Defaults
+
+	// DownlinkTXPower defines the downlink TX power (dBm).
+	DownlinkTXPower int
+
+	// MaxUplinkEIRP defines the default max uplink EIRP (dBm).
+	MaxUplinkEIRP float32
+
+	// PingSlotFrequency defines the Class-B ping-slot frequency (Hz). When
+	// not set, the RX2 frequency is used.
+	PingSlotFrequency int
+
+	// Beacon defines the Class-B beacon frequency and data-rate. When the
+	// frequency is not set, Class-B beacons are not defined for the band.
+	Beacon Beacon
+
+	// ImplementsTXParamSetup defines if devices implement the TxParamSetup
+	// mac-command.
+	ImplementsTXParamSetup bool
+}
+
+// RegisterBand registers the given custom band configuration under the
+// given name, after which it can be retrieved using GetConfig. It returns
+// an error when the name conflicts with a pre-defined band. Registering a
+// name which is already registered replaces the previous configuration.
+func RegisterBand(name Name, config CustomBandConfig) error {
+	if name == "" {
+		return errors.New("lorawan/band: band name must be set")
+	}
+	if isPredefinedBand(name) {
+		return fmt.Errorf("lorawan/band: band %s is pre-defined", name)
+	}
+	if len(config.DataRates) == 0 {
+		return errors.New("lorawan/band: at least one data-rate must be defined")
+	}
+	if len(config.UplinkChannels) == 0 {
+		return errors.New("lorawan/band: at least one uplink channel must be defined")
+	}
+
+	customBandsMux.Lock()
+	defer customBandsMux.Unlock()
+	customBands[name] = config
+
+	return nil
+}
+
+// UnregisterBand removes the custom band registered under the given name.
+func UnregisterBand(name Name) {
+	customBandsMux.Lock()
+	defer customBandsMux.Unlock()
+	delete(customBands, name)
+}
+
+func isPredefinedBand(name Name) bool {
+	_, ok := predefinedBands[name]
+	return ok
+}
+
+func getCustomBand(name Name, repeaterCompatible bool) (Band, bool) {
+	customBandsMux.RLock()
+	config, ok := customBands[name]
+	customBandsMux.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	return newCustomBand(name, config, repeaterCompatible), true
+}
+
+type customBand struct {
+	band
+	name   Name
+	config CustomBandConfig
+}
+
+func (b *customBand) Name() string {
+	return string(b.name)
+}
+
+func (b *customBand) GetDefaults() Defaults {
+	return b.config.Defaults
+}
+
+func (b *customBand) GetDownlinkTXPower(freq int) int {
+	return b.config.DownlinkTXPower
+}
+
+func (b *customBand) GetDefaultMaxUplinkEIRP() float32 {
+	return b.config.MaxUplinkEIRP
+}
+
+func (b *customBand) GetPingSlotFrequency(lorawan.DevAddr, time.Duration) (int, error) {
+	if b.config.PingSlotFrequency != 0 {
+		return b.config.PingSlotFrequency, nil
+	}
+	return b.config.Defaults.RX2Frequency, nil
+}
+
+// AddChannel adds an extra (uplink) channel. When the band defines its own
+// downlink channels, these are not changed as the RX1 channel is the uplink
+// channel index modulo the number of downlink channels. Else the channel is
+// also added as downlink channel, as RX1 uses the uplink channel.
+func (b *customBand) AddChannel(frequency, minDR, maxDR int) error {
+	if len(b.config.DownlinkChannels) == 0 {
+		return b.band.AddChannel(frequency, minDR, maxDR)
+	}
+
+	if !b.supportsExtraChannels {
+		return errors.New("lorawan/band: band does not support extra channels")
+	}
+
+	b.uplinkChannels = append(b.uplinkChannels, Channel{
+		Frequency: frequency,
+		MinDR:     minDR,
+		MaxDR:     maxDR,
+		custom:    true,
+		enabled:   frequency != 0,
+	})
+	return nil
+}
+
+func (b *customBand) GetRX1ChannelIndexForUplinkChannelIndex(uplinkChannel int) (int, error) {
+	if len(b.config.DownlinkChannels) == 0 {
+		return uplinkChannel, nil
+	}
+	return uplinkChannel % len(b.downlinkChannels), nil
+}
+
+func (b *customBand) GetRX1FrequencyForUplinkFrequency(uplinkFrequency int) (int, error) {
+	if len(b.config.DownlinkChannels) == 0 {
+		return uplinkFrequency, nil
+	}
+
+	uplinkChan, err := b.GetUplinkChannelIndex(uplinkFrequency, true)
+	if err != nil {
+		return 0, err
+	}
+
+	rx1Chan, err := b.GetRX1ChannelIndexForUplinkChannelIndex(uplinkChan)
+	if err != nil {
+		return 0, err
+	}
+
+	return b.downlinkChannels[rx1Chan].Frequency, nil
+}
+
+func (b *customBand) ImplementsTXParamSetup(protocolVersion string) bool {
+	return b.config.ImplementsTXParamSetup
+}
+
+func newCustomBand(name Name, config CustomBandConfig, repeaterCompatible bool) Band {
+	b := customBand{
+		name:   name,
+		config: config,
+		band: band{
+			supportsExtraChannels: config.SupportsExtraChannels,
+			dataRates:             make(map[int]DataRate),
+			rx1DataRateTable:      config.RX1DataRateTable,
+			txPowerOffsets:        map[string][]int{latest: config.TXPowerOffsets},
+		},
+	}
+
+	for i, dr := range config.DataRates {
+		d := dr.DataRate
+		d.uplink = dr.Uplink
+		d.downlink = dr.Downlink
+		b.band.dataRates[i] = d
+	}
+
+	maxPayloadSizes := config.MaxPayloadSizes
+	if repeaterCompatible && config.RepeaterMaxPayloadSizes != nil {
+		maxPayloadSizes = config.RepeaterMaxPayloadSizes
+	}
+	b.band.maxPayloadSizePerDR = map[string]map[string]map[int]MaxPayloadSize{
+		latest: map[string]map[int]MaxPayloadSize{
+			latest: maxPayloadSizes,
+		},
+	}
+
+	// channels are copied as the band state (e.g. enabled channels) must
+	// not be shared between band instances
+	for _, c := range config.UplinkChannels {
+		b.band.uplinkChannels = append(b.band.uplinkChannels, Channel{
+			Frequency: c.Frequency,
+			MinDR:     c.MinDR,
+			MaxDR:     c.MaxDR,
+			enabled:   true,
+		})
+	}
+	// without downlink channels, RX1 uses the uplink channel
+	downlinkChannels := config.DownlinkChannels
+	if len(downlinkChannels) == 0 {
+		downlinkChannels = config.UplinkChannels
+	}
+	for _, c := range downlinkChannels {
+		b.band.downlinkChannels = append(b.band.downlinkChannels, Channel{
+			Frequency: c.Frequency,
+			MinDR:     c.MinDR,
+			MaxDR:     c.MaxDR,
+			enabled:   true,
+		})
+	}
+
+	return &b
+}
@@ -0,0 +1,150 @@
+package band
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brocaar/lorawan"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCustomBand(t *testing.T) {
+	Convey("Given a custom band configuration", t, func() {
+		config := CustomBandConfig{
+			DataRates: map[int]CustomDataRate{
+				0: {DataRate: DataRate{Modulation: LoRaModulation, SpreadFactor: 12, Bandwidth: 812}, Uplink: true, Downlink: true},
+				1: {DataRate: DataRate{Modulation: LoRaModulation, SpreadFactor: 11, Bandwidth: 812}, Uplink: true, Downlink: true},
+			},
+			MaxPayloadSizes: map[int]MaxPayloadSize{
+				0: {M: 59, N: 51},
+				1: {M: 123, N: 115},
+			},
+			RepeaterMaxPayloadSizes: map[int]MaxPayloadSize{
+				0: {M: 59, N: 51},
+				1: {M: 100, N: 92},
+			},
+			RX1DataRateTable: map[int][]int{
+				0: {0, 0},
+				1: {1, 0},
+			},
+			TXPowerOffsets: []int{0, -2, -4},
+			UplinkChannels: []Channel{
+				{Frequency: 2410000000, MinDR: 0, MaxDR: 1},
+				{Frequency: 2420000000, MinDR: 0, MaxDR: 1},
+			},
+			SupportsExtraChannels: true,
+			Defaults: Defaults{
+				RX2Frequency:     2430000000,
+				RX2DataRate:      0,
+				ReceiveDelay1:    time.Second,
+				ReceiveDelay2:    time.Second * 2,
+				JoinAcceptDelay1: time.Second * 5,
+				JoinAcceptDelay2: time.Second * 6,
+			},
+			DownlinkTXPower: 10,
+			MaxUplinkEIRP:   10,
+		}
+
+		Convey("Then it can not be registered under a pre-defined name", func() {
+			So(RegisterBand(EU868, config), ShouldNotBeNil)
+		})
+
+		Convey("When registering the custom band", func() {
+			So(RegisterBand("PRIVATE2400", config), ShouldBeNil)
+			defer UnregisterBand("PRIVATE2400")
+
+			Convey("Then GetConfig returns the custom band", func() {
+				b, err := GetConfig("PRIVATE2400", false, lorawan.DwellTimeNoLimit)
+				So(err, ShouldBeNil)
+				So(b.Name(), ShouldEqual, "PRIVATE2400")
+				So(b.GetDefaults(), ShouldResemble, config.Defaults)
+				So(b.GetUplinkChannelIndices(), ShouldResemble, []int{0, 1})
+				So(b.GetEnabledUplinkChannelIndices(), ShouldResemble, []int{0, 1})
+
+				dr, err := b.GetDataRateIndex(true, DataRate{Modulation: LoRaModulation, SpreadFactor: 11, Bandwidth: 812})
+				So(err, ShouldBeNil)
+				So(dr, ShouldEqual, 1)
+
+				ps, err := b.GetMaxPayloadSizeForDataRateIndex(LoRaWAN_1_0_3, RegParamRevA, 1)
+				So(err, ShouldBeNil)
+				So(ps, ShouldResemble, MaxPayloadSize{M: 123, N: 115})
+
+				f, err := b.GetRX1FrequencyForUplinkFrequency(2420000000)
+				So(err, ShouldBeNil)
+				So(f, ShouldEqual, 2420000000)
+
+				f, err = b.GetPingSlotFrequency(lorawan.DevAddr{}, 0)
+				So(err, ShouldBeNil)
+				So(f, ShouldEqual, 2430000000)
+
+				eirp, err := GetTXPowerEIRP(b, 2)
+				So(err, ShouldBeNil)
+				So(eirp, ShouldEqual, 6)
+			})
+
+			Convey("Then GetConfig returns the repeater max-payload sizes", func() {
+				b, err := GetConfig("PRIVATE2400", true, lorawan.DwellTimeNoLimit)
+				So(err, ShouldBeNil)
+
+				ps, err := b.GetMaxPayloadSizeForDataRateIndex(latest, latest, 1)
+				So(err, ShouldBeNil)
+				So(ps, ShouldResemble, MaxPayloadSize{M: 100, N: 92})
+			})
+
+			Convey("Then band instances do not share channel state", func() {
+				b1, err := GetConfig("PRIVATE2400", false, lorawan.DwellTimeNoLimit)
+				So(err, ShouldBeNil)
+				So(b1.DisableUplinkChannelIndex(0), ShouldBeNil)
+
+				b2, err := GetConfig("PRIVATE2400", false, lorawan.DwellTimeNoLimit)
+				So(err, ShouldBeNil)
+				So(b2.GetEnabledUplinkChannelIndices(), ShouldResemble, []int{0, 1})
+			})
+		})
+
+		Convey("Then without downlink channels RX1 uses the uplink channel", func() {
+			b := newCustomBand("PRIVATE2400", config, false)
+			So(b.AddChannel(2440000000, 0, 1), ShouldBeNil)
+
+			for i, f := range []int{2410000000, 2420000000, 2440000000} {
+				rx1, err := b.GetRX1ChannelIndexForUplinkChannelIndex(i)
+				So(err, ShouldBeNil)
+				So(rx1, ShouldEqual, i)
+
+				c, err := b.GetDownlinkChannel(rx1)
+				So(err, ShouldBeNil)
+				So(c.Frequency, ShouldEqual, f)
+			}
+		})
+
+		Convey("Then AddChannel does not change the downlink channels", func() {
+			config.DownlinkChannels = []Channel{
+				{Frequency: 2450000000, MinDR: 0, MaxDR: 1},
+			}
+			b := newCustomBand("PRIVATE2400", config, false)
+			So(b.AddChannel(2440000000, 0, 1), ShouldBeNil)
+			So(b.GetUplinkChannelIndices(), ShouldResemble, []int{0, 1, 2})
+
+			_, err := b.GetDownlinkChannel(1)
+			So(err, ShouldNotBeNil)
+
+			rx1, err := b.GetRX1ChannelIndexForUplinkChannelIndex(2)
+			So(err, ShouldBeNil)
+			So(rx1, ShouldEqual, 0)
+
+			f, err := b.GetRX1FrequencyForUplinkFrequency(2420000000)
+			So(err, ShouldBeNil)
+			So(f, ShouldEqual, 2450000000)
+		})
+
+		Convey("When the custom band is unregistered", func() {
+			So(RegisterBand("PRIVATE2400", config), ShouldBeNil)
+			UnregisterBand("PRIVATE2400")
+
+			Convey("Then GetConfig returns an error", func() {
+				_, err := GetConfig("PRIVATE2400", false, lorawan.DwellTimeNoLimit)
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -73,13 +73,15 @@ func newEU433Band(repeaterCompatible bool) (Band, error) {
 				6: {6, 5, 4, 3, 2, 1},
 				7: {7, 6, 5, 4, 3, 2},
 			},
-			txPowerOffsets: []int{
-				0,
-				-2,
-				-4,
-				-6,
-				-8,
-				-10,
+			txPowerOffsets: map[string][]int{
+				latest: {
+					0,
+					-2,
+					-4,
+					-6,
+					-8,
+					-10,
+				},
 			},
 			uplinkChannels: []Channel{
 				{Frequency: 433175000, MinDR: 0, MaxDR: 5, enabled: true},
@@ -62,34 +62,44 @@ func newEU863Band(repeatedCompatible bool) (Band, error) {
 		band: band{
 			supportsExtraChannels: true,
 			dataRates: map[int]DataRate{
-				0: {Modulation: LoRaModulation, SpreadFactor: 12, Bandwidth: 125, uplink: true, downlink: true},
-				1: {Modulation: LoRaModulation, SpreadFactor: 11, Bandwidth: 125, uplink: true, downlink: true},
-				2: {Modulation: LoRaModulation, SpreadFactor: 10, Bandwidth: 125, uplink: true, downlink: true},
-				3: {Modulation: LoRaModulation, SpreadFactor: 9, Bandwidth: 125, uplink: true, downlink: true},
-				4: {Modulation: LoRaModulation, SpreadFactor: 8, Bandwidth: 125, uplink: true, downlink: true},
-				5: {Modulation: LoRaModulation, SpreadFactor: 7, Bandwidth: 125, uplink: true, downlink: true},
-				6: {Modulation: LoRaModulation, SpreadFactor: 7, Bandwidth: 250, uplink: true, downlink: true},
-				7: {Modulation: FSKModulation, BitRate: 50000, uplink: true, downlink: true},
+				0:  {Modulation: LoRaModulation, SpreadFactor: 12, Bandwidth: 125, uplink: true, downlink: true},
+				1:  {Modulation: LoRaModulation, SpreadFactor: 11, Bandwidth: 125, uplink: true, downlink: true},
+				2:  {Modulation: LoRaModulation, SpreadFactor: 10, Bandwidth: 125, uplink: true, downlink: true},
+				3:  {Modulation: LoRaModulation, SpreadFactor: 9, Bandwidth: 125, uplink: true, downlink: true},
+				4:  {Modulation: LoRaModulation, SpreadFactor: 8, Bandwidth: 125, uplink: true, downlink: true},
+				5:  {Modulation: LoRaModulation, SpreadFactor: 7, Bandwidth: 125, uplink: true, downlink: true},
+				6:  {Modulation: LoRaModulation, SpreadFactor: 7, Bandwidth: 250, uplink: true, downlink: true},
+				7:  {Modulation: FSKModulation, BitRate: 50000, uplink: true, downlink: true},
+				8:  {Modulation: LRFHSSModulation, CodingRate: "1/3", OccupiedChannelWidth: 137000, uplink: true, regParamRevision: RegParamRevRP002_1_0_2},
+				9:  {Modulation: LRFHSSModulation, CodingRate: "2/3", OccupiedChannelWidth: 137000, uplink: true, regParamRevision: RegParamRevRP002_1_0_2},
+				10: {Modulation: LRFHSSModulation, CodingRate: "1/3", OccupiedChannelWidth: 336000, uplink: true, regParamRevision: RegParamRevRP002_1_0_2},
+				11: {Modulation: LRFHSSModulation, CodingRate: "2/3", OccupiedChannelWidth: 336000, uplink: true, regParamRevision: RegParamRevRP002_1_0_2},
 			},
 			rx1DataRateTable: map[int][]int{
-				0: {0, 0, 0, 0, 0, 0},
-				1: {1, 0, 0, 0, 0, 0},
-				2: {2, 1, 0, 0, 0, 0},
-				3: {3, 2, 1, 0, 0, 0},
-				4: {4, 3, 2, 1, 0, 0},
-				5: {5, 4, 3, 2, 1, 0},
-				6: {6, 5, 4, 3, 2, 1},
-				7: {7, 6, 5, 4, 3, 2},
-			},
-			txPowerOffsets: []int{
-				0,
-				-2,
-				-4,
-				-6,
-				-8,
-				-10,
-				-12,
-				-14,
+				0:  {0, 0, 0, 0, 0, 0},
+				1:  {1, 0, 0, 0, 0, 0},
+				2:  {2, 1, 0, 0, 0, 0},
+				3:  {3, 2, 1, 0, 0, 0},
+				4:  {4, 3, 2, 1, 0, 0},
+				5:  {5, 4, 3, 2, 1, 0},
+				6:  {6, 5, 4, 3, 2, 1},
+				7:  {7, 6, 5, 4, 3, 2},
+				8:  {1, 0, 0, 0, 0, 0},
+				9:  {2, 1, 0, 0, 0, 0},
+				10: {1, 0, 0, 0, 0, 0},
+				11: {2, 1, 0, 0, 0, 0},
+			},
+			txPowerOffsets: map[string][]int{
+				latest: {
+					0,
+					-2,
+					-4,
+					-6,
+					-8,
+					-10,
+					-12,
+					-14,
+				},
 			},
 			uplinkChannels: []Channel{
 				{Frequency: 868100000, MinDR: 0, MaxDR: 5, enabled: true},
@@ -167,15 +177,19 @@ func newEU863Band(repeatedCompatible bool) (Band, error) {
 				},
 			},
 			latest: map[string]map[int]MaxPayloadSize{
-				latest: map[int]MaxPayloadSize{ // RP002-1.0.0, RP002-1.0.1
-					0: {M: 59, N: 51},
-					1: {M: 59, N: 51},
-					2: {M: 59, N: 51},
-					3: {M: 123, N: 115},
-					4: {M: 230, N: 222},
-					5: {M: 230, N: 222},
-					6: {M: 230, N: 222},
-					7: {M: 230, N: 222},
+				latest: map[int]MaxPayloadSize{ // RP002-1.0.0, RP002-1.0.1, RP002-1.0.2 (LR-FHSS)
+					0:  {M: 59, N: 51},
+					1:  {M: 59, N: 51},
+					2:  {M: 59, N: 51},
+					3:  {M: 123, N: 115},
+					4:  {M: 230, N: 222},
+					5:  {M: 230, N: 222},
+					6:  {M: 230, N: 222},
+					7:  {M: 230, N: 222},
+					8:  {M: 58, N: 50},
+					9:  {M: 123, N: 115},
+					10: {M: 58, N: 50},
+					11: {M: 123, N: 115},
 				},
 			},
 		}
@@ -230,15 +244,19 @@ func newEU863Band(repeatedCompatible bool) (Band, error) {
 				},
 			},
 			latest: map[string]map[int]MaxPayloadSize{
-				latest: map[int]MaxPayloadSize{ // RP002-1.0.0, RP002-1.0.1
-					0: {M: 59, N: 51},
-					1: {M: 59, N: 51},
-					2: {M: 59, N: 51},
-					3: {M: 123, N: 115},
-					4: {M: 250, N: 242},
-					5: {M: 250, N: 242},
-					6: {M: 250, N: 242},
-					7: {M: 250, N: 242},
+				latest: map[int]MaxPayloadSize{ // RP002-1.0.0, RP002-1.0.1, RP002-1.0.2 (LR-FHSS)
+					0:  {M: 59, N: 51},
+					1:  {M: 59, N: 51},
+					2:  {M: 59, N: 51},
+					3:  {M: 123, N: 115},
+					4:  {M: 250, N: 242},
+					5:  {M: 250, N: 242},
+					6:  {M: 250, N: 242},
+					7:  {M: 250, N: 242},
+					8:  {M: 58, N: 50},
+					9:  {M: 123, N: 115},
+					10: {M: 58, N: 50},
+					11: {M: 123, N: 115},
 				},
 			},
 		}
@@ -73,18 +73,20 @@ func newIN865Band(repeaterCompatible bool) (Band, error) {
 				// 6
 				7: {7, 6, 5, 4, 3, 2, 7, 7},
 			},
-			txPowerOffsets: []int{
-				0,
-				-2,
-				-4,
-				-6,
-				-8,
-				-10,
-				-12,
-				-14,
-				-16,
-				-18,
-				-20,
+			txPowerOffsets: map[string][]int{
+				latest: {
+					0,
+					-2,
+					-4,
+					-6,
+					-8,
+					-10,
+					-12,
+					-14,
+					-16,
+					-18,
+					-20,
+				},
 			},
 			uplinkChannels: []Channel{
 				{Frequency: 865062500, MinDR: 0, MaxDR: 5, enabled: true},
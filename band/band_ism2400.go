@@ -0,0 +1,134 @@
+package band
+
+import (
+	"time"
+
+	"github.com/brocaar/lorawan"
+)
+
+type ism2400Band struct {
+	band
+}
+
+func (b *ism2400Band) Name() string {
+	return "ISM2400"
+}
+
+func (b *ism2400Band) GetDefaults() Defaults {
+	return Defaults{
+		RX2Frequency:     2423000000,
+		RX2DataRate:      0,
+		ReceiveDelay1:    time.Second,
+		ReceiveDelay2:    time.Second * 2,
+		JoinAcceptDelay1: time.Second * 5,
+		JoinAcceptDelay2: time.Second * 6,
+	}
+}
+
+func (b *ism2400Band) GetDownlinkTXPower(freq int) int {
+	return 10
+}
+
+func (b *ism2400Band) GetDefaultMaxUplinkEIRP() float32 {
+	return 10
+}
+
+func (b *ism2400Band) GetPingSlotFrequency(lorawan.DevAddr, time.Duration) (int, error) {
+	return 2424000000, nil
+}
+
+func (b *ism2400Band) GetRX1ChannelIndexForUplinkChannelIndex(uplinkChannel int) (int, error) {
+	return uplinkChannel, nil
+}
+
+func (b *ism2400Band) GetRX1FrequencyForUplinkFrequency(uplinkFrequency int) (int, error) {
+	return uplinkFrequency, nil
+}
+
+func (b *ism2400Band) ImplementsTXParamSetup(protocolVersion string) bool {
+	return false
+}
+
+func newISM2400Band(repeaterCompatible bool) (Band, error) {
+	b := ism2400Band{
+		band: band{
+			supportsExtraChannels: true,
+			dataRates: map[int]DataRate{
+				0: {Modulation: LoRaModulation, SpreadFactor: 12, Bandwidth: 812, uplink: true, downlink: true},
+				1: {Modulation: LoRaModulation, SpreadFactor: 11, Bandwidth: 812, uplink: true, downlink: true},
+				2: {Modulation: LoRaModulation, SpreadFactor: 10, Bandwidth: 812, uplink: true, downlink: true},
+				3: {Modulation: LoRaModulation, SpreadFactor: 9, Bandwidth: 812, uplink: true, downlink: true},
+				4: {Modulation: LoRaModulation, SpreadFactor: 8, Bandwidth: 812, uplink: true, downlink: true},
+				5: {Modulation: LoRaModulation, SpreadFactor: 7, Bandwidth: 812, uplink: true, downlink: true},
+				6: {Modulation: LoRaModulation, SpreadFactor: 6, Bandwidth: 812, uplink: true, downlink: true},
+				7: {Modulation: LoRaModulation, SpreadFactor: 5, Bandwidth: 812, uplink: true, downlink: true},
+			},
+			rx1DataRateTable: map[int][]int{
+				0: {0, 0, 0, 0, 0, 0},
+				1: {1, 0, 0, 0, 0, 0},
+				2: {2, 1, 0, 0, 0, 0},
+				3: {3, 2, 1, 0, 0, 0},
+				4: {4, 3, 2, 1, 0, 0},
+				5: {5, 4, 3, 2, 1, 0},
+				6: {6, 5, 4, 3, 2, 1},
+				7: {7, 6, 5, 4, 3, 2},
+			},
+			txPowerOffsets: map[string][]int{
+				latest: {
+					0,
+					-2,
+					-4,
+					-6,
+					-8,
+					-10,
+					-12,
+					-14,
+				},
+			},
+			uplinkChannels: []Channel{
+				{Frequency: 2403000000, MinDR: 0, MaxDR: 7, enabled: true},
+				{Frequency: 2425000000, MinDR: 0, MaxDR: 7, enabled: true},
+				{Frequency: 2479000000, MinDR: 0, MaxDR: 7, enabled: true},
+			},
+			downlinkChannels: []Channel{
+				{Frequency: 2403000000, MinDR: 0, MaxDR: 7, enabled: true},
+				{Frequency: 2425000000, MinDR: 0, MaxDR: 7, enabled: true},
+				{Frequency: 2479000000, MinDR: 0, MaxDR: 7, enabled: true},
+			},
+		},
+	}
+
+	if repeaterCompatible {
+		b.band.maxPayloadSizePerDR = map[string]map[string]map[int]MaxPayloadSize{
+			latest: map[string]map[int]MaxPayloadSize{
+				latest: map[int]MaxPayloadSize{ // LoRaWAN 2.4GHz 1.0
+					0: {M: 59, N: 51},
+					1: {M: 123, N: 115},
+					2: {M: 230, N: 222},
+					3: {M: 230, N: 222},
+					4: {M: 230, N: 222},
+					5: {M: 230, N: 222},
+					6: {M: 230, N: 222},
+					7: {M: 230, N: 222},
+				},
+			},
+		}
+	} else {
+		b.band.maxPayloadSizePerDR = map[string]map[string]map[int]MaxPayloadSize{
+			latest: map[string]map[int]MaxPayloadSize{
+				latest: map[int]MaxPayloadSize{ // LoRaWAN 2.4GHz 1.0
+					0: {M: 59, N: 51},
+					1: {M: 123, N: 115},
+					2: {M: 248, N: 240},
+					3: {M: 248, N: 240},
+					4: {M: 248, N: 240},
+					5: {M: 248, N: 240},
+					6: {M: 248, N: 240},
+					7: {M: 248, N: 240},
+				},
+			},
+		}
+	}
+
+	return &b, nil
+}
@@ -0,0 +1,73 @@
+package band
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brocaar/lorawan"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestISM2400Band(t *testing.T) {
+	Convey("Given the ISM2400 band is selected", t, func() {
+		band, err := GetConfig(ISM2400, false, lorawan.DwellTimeNoLimit)
+		So(err, ShouldBeNil)
+
+		Convey("Then GetDefaults returns the expected value", func() {
+			So(band.GetDefaults(), ShouldResemble, Defaults{
+				RX2Frequency:     2423000000,
+				RX2DataRate:      0,
+				ReceiveDelay1:    time.Second,
+				ReceiveDelay2:    time.Second * 2,
+				JoinAcceptDelay1: time.Second * 5,
+				JoinAcceptDelay2: time.Second * 6,
+			})
+		})
+
+		Convey("Given two extra channels", func() {
+			So(band.AddChannel(2410000000, 0, 7), ShouldBeNil)
+			So(band.AddChannel(2420000000, 0, 5), ShouldBeNil)
+
+			Convey("Then GetCFList only returns the channel with the default data-rate range", func() {
+				So(band.GetCFList(LoRaWAN_1_0_3), ShouldResemble, &lorawan.CFList{
+					CFListType: lorawan.CFListChannel,
+					Payload: &lorawan.CFListChannelPayload{
+						Channels: [5]uint32{2410000000},
+					},
+				})
+			})
+		})
+
+		Convey("Then GetDataRate returns the expected value", func() {
+			dr, err := band.GetDataRate(7)
+			So(err, ShouldBeNil)
+			So(dr, ShouldResemble, DataRate{Modulation: LoRaModulation, SpreadFactor: 5, Bandwidth: 812, uplink: true, downlink: true})
+		})
+
+		Convey("Then GetMaxPayloadSizeForDataRateIndex returns the expected value", func() {
+			ps, err := band.GetMaxPayloadSizeForDataRateIndex("", "", 2)
+			So(err, ShouldBeNil)
+			So(ps, ShouldResemble, MaxPayloadSize{M: 248, N: 240})
+		})
+
+		Convey("Then GetRX1DataRateIndex returns the expected value", func() {
+			dr, err := band.GetRX1DataRateIndex(7, 5)
+			So(err, ShouldBeNil)
+			So(dr, ShouldEqual, 2)
+		})
+
+		Convey("Then the default uplink channels are returned", func() {
+			So(band.GetStandardUplinkChannelIndices(), ShouldResemble, []int{0, 1, 2})
+
+			c, err := band.GetUplinkChannel(2)
+			So(err, ShouldBeNil)
+			So(c.Frequency, ShouldEqual, 2479000000)
+		})
+
+		Convey("Then GetRX1FrequencyForUplinkFrequency returns the expected value", func() {
+			f, err := band.GetRX1FrequencyForUplinkFrequency(2425000000)
+			So(err, ShouldBeNil)
+			So(f, ShouldEqual, 2425000000)
+		})
+	})
+}
@@ -73,15 +73,17 @@ func newKR920Band(repeaterCompatible bool) (Band, error) {
 				6: {0, 0, 0, 0, 0, 0, 0, 0},
 				7: {7, 5, 5, 4, 3, 2, 7, 7},
 			},
-			txPowerOffsets: []int{
-				0,
-				-2,
-				-4,
-				-6,
-				-8,
-				-10,
-				-12,
-				-14,
+			txPowerOffsets: map[string][]int{
+				latest: {
+					0,
+					-2,
+					-4,
+					-6,
+					-8,
+					-10,
+					-12,
+					-14,
+				},
 			},
 			uplinkChannels: []Channel{
 				{Frequency: 922100000, MinDR: 0, MaxDR: 5, enabled: true},
@@ -73,15 +73,17 @@ func newRU864Band(repeaterCompatible bool) (Band, error) {
 				6: {6, 5, 4, 3, 2, 1},
 				7: {7, 6, 5, 4, 3, 2},
 			},
-			txPowerOffsets: []int{
-				0,
-				-2,
-				-4,
-				-6,
-				-8,
-				-10,
-				-12,
-				-14,
+			txPowerOffsets: map[string][]int{
+				latest: {
+					0,
+					-2,
+					-4,
+					-6,
+					-8,
+					-10,
+					-12,
+					-14,
+				},
 			},
 			uplinkChannels: []Channel{
 				{Frequency: 868900000, MinDR: 0, MaxDR: 5, enabled: true},
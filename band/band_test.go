@@ -0,0 +1,49 @@
+package band
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/brocaar/lorawan"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLRFHSSDataRates(t *testing.T) {
+	Convey("Given a set of tests", t, func() {
+		tests := []struct {
+			Name     Name
+			DR       int
+			DataRate DataRate
+		}{
+			{EU868, 8, DataRate{Modulation: LRFHSSModulation, CodingRate: "1/3", OccupiedChannelWidth: 137000}},
+			{EU868, 9, DataRate{Modulation: LRFHSSModulation, CodingRate: "2/3", OccupiedChannelWidth: 137000}},
+			{EU868, 10, DataRate{Modulation: LRFHSSModulation, CodingRate: "1/3", OccupiedChannelWidth: 336000}},
+			{EU868, 11, DataRate{Modulation: LRFHSSModulation, CodingRate: "2/3", OccupiedChannelWidth: 336000}},
+			{US915, 5, DataRate{Modulation: LRFHSSModulation, CodingRate: "1/3", OccupiedChannelWidth: 1523000}},
+			{US915, 6, DataRate{Modulation: LRFHSSModulation, CodingRate: "2/3", OccupiedChannelWidth: 1523000}},
+			{AU915, 7, DataRate{Modulation: LRFHSSModulation, CodingRate: "1/3", OccupiedChannelWidth: 1523000}},
+		}
+
+		for i, test := range tests {
+			Convey(fmt.Sprintf("Testing %s DR%d [%d]", test.Name, test.DR, i), func() {
+				b, err := GetConfig(test.Name, false, lorawan.DwellTimeNoLimit)
+				So(err, ShouldBeNil)
+
+				dr, err := b.GetDataRate(test.DR)
+				So(err, ShouldBeNil)
+				So(dr.equal(test.DataRate), ShouldBeTrue)
+
+				Convey("Then GetDataRateIndex returns the uplink data-rate index", func() {
+					i, err := b.GetDataRateIndex(true, test.DataRate)
+					So(err, ShouldBeNil)
+					So(i, ShouldEqual, test.DR)
+				})
+
+				Convey("Then GetDataRateIndex does not return a downlink data-rate index", func() {
+					_, err := b.GetDataRateIndex(false, test.DataRate)
+					So(err, ShouldNotBeNil)
+				})
+			})
+		}
+	})
+}
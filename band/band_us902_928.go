@@ -36,7 +36,7 @@ func (b *us902Band) GetDefaultMaxUplinkEIRP() float32 {
 }
 
 func (b *us902Band) GetPingSlotFrequency(devAddr lorawan.DevAddr, beaconTime time.Duration) (int, error) {
-	downlinkChannel := (int(binary.BigEndian.Uint32(devAddr[:])) + int(beaconTime/(128*time.Second))) % 8
+	downlinkChannel := (int(binary.BigEndian.Uint32(devAddr[:])) + int(beaconTime/BeaconPeriod)) % 8
 	return b.downlinkChannels[downlinkChannel].Frequency, nil
 }
 
@@ -157,6 +157,10 @@ func (b *us902Band) ImplementsTXParamSetup(protocolVersion string) bool {
 	return false
 }
 
+// us902TXPowerOffsetsRevB contains the TXPower offsets up to Regional
+// Parameters revision C, defining TXPower 0 - 10 (RFU above).
+var us902TXPowerOffsetsRevB = []int{0, -2, -4, -6, -8, -10, -12, -14, -16, -18, -20}
+
 func newUS902Band(repeaterCompatible bool) (Band, error) {
 	b := us902Band{
 		band: band{
@@ -166,7 +170,9 @@ func newUS902Band(repeaterCompatible bool) (Band, error) {
 				2: {Modulation: LoRaModulation, SpreadFactor: 8, Bandwidth: 125, uplink: true},
 				3: {Modulation: LoRaModulation, SpreadFactor: 7, Bandwidth: 125, uplink: true},
 				4: {Modulation: LoRaModulation, SpreadFactor: 8, Bandwidth: 500, uplink: true},
-				// 5 - 7
+				5: {Modulation: LRFHSSModulation, CodingRate: "1/3", OccupiedChannelWidth: 1523000, uplink: true, regParamRevision: RegParamRevRP002_1_0_2},
+				6: {Modulation: LRFHSSModulation, CodingRate: "2/3", OccupiedChannelWidth: 1523000, uplink: true, regParamRevision: RegParamRevRP002_1_0_2},
+				// 7
 				8:  {Modulation: LoRaModulation, SpreadFactor: 12, Bandwidth: 500, downlink: true},
 				9:  {Modulation: LoRaModulation, SpreadFactor: 11, Bandwidth: 500, downlink: true},
 				10: {Modulation: LoRaModulation, SpreadFactor: 10, Bandwidth: 500, downlink: true},
@@ -180,7 +186,9 @@ func newUS902Band(repeaterCompatible bool) (Band, error) {
 				2: {12, 11, 10, 9},
 				3: {13, 12, 11, 10},
 				4: {13, 13, 12, 11},
-				// 5 - 7
+				5: {10, 9, 8, 8},
+				6: {11, 10, 9, 8},
+				// 7
 				8:  {8, 8, 8, 8},
 				9:  {9, 8, 8, 8},
 				10: {10, 9, 8, 8},
@@ -188,18 +196,27 @@ func newUS902Band(repeaterCompatible bool) (Band, error) {
 				12: {12, 11, 10, 9},
 				13: {13, 12, 11, 10},
 			},
-			txPowerOffsets: []int{
-				0,
-				-2,
-				-4,
-				-6,
-				-8,
-				-10,
-				-12,
-				-14,
-				-16,
-				-18,
-				-20,
+			txPowerOffsets: map[string][]int{
+				RegParamRevA: us902TXPowerOffsetsRevB,
+				RegParamRevB: us902TXPowerOffsetsRevB,
+				RegParamRevC: us902TXPowerOffsetsRevB,
+				latest: { // RP002-1.0.0 and later
+					0,   // 0
+					-2,  // 1
+					-4,  // 2
+					-6,  // 3
+					-8,  // 4
+					-10, // 5
+					-12, // 6
+					-14, // 7
+					-16, // 8
+					-18, // 9
+					-20, // 10
+					-22, // 11
+					-24, // 12
+					-26, // 13
+					-28, // 14
+				},
 			},
 			uplinkChannels:   make([]Channel, 72),
 			downlinkChannels: make([]Channel, 8),
@@ -295,7 +312,9 @@ func newUS902Band(repeaterCompatible bool) (Band, error) {
 					2: {M: 133, N: 125},
 					3: {M: 230, N: 222},
 					4: {M: 230, N: 222},
-					// 5-7
+					5: {M: 58, N: 50},
+					6: {M: 133, N: 125},
+					// 7
 					8:  {M: 41, N: 33},
 					9:  {M: 117, N: 109},
 					10: {M: 230, N: 222},
@@ -394,7 +413,9 @@ func newUS902Band(repeaterCompatible bool) (Band, error) {
 					2: {M: 133, N: 125},
 					3: {M: 250, N: 242},
 					4: {M: 250, N: 242},
-					// 5-7
+					5: {M: 58, N: 50},
+					6: {M: 133, N: 125},
+					// 7
 					8:  {M: 61, N: 53},
 					9:  {M: 137, N: 129},
 					10: {M: 250, N: 242},
@@ -0,0 +1,109 @@
+package band
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lorawan"
+)
+
+// ChannelPlan defines a (JSON serializable) channel-plan description, which
+// extends a pre-defined (or registered) band with extra channels and the
+// set of enabled uplink channels. It can be used to store a channel-plan
+// in a configuration file.
+type ChannelPlan struct {
+	// Band defines the name of the band.
+	Band Name `json:"band"`
+
+	// RepeaterCompatible defines if the band is repeater compatible.
+	RepeaterCompatible bool `json:"repeaterCompatible,omitempty"`
+
+	// DwellTime defines the dwell time of the band.
+	DwellTime lorawan.DwellTime `json:"dwellTime,omitempty"`
+
+	// ExtraChannels defines the extra (e.g. NewChannelReq provisioned)
+	// uplink channels.
+	ExtraChannels []ExtraChannel `json:"extraChannels,omitempty"`
+
+	// EnabledUplinkChannels defines the enabled uplink channel indices.
+	// When empty, all uplink channels are enabled.
+	EnabledUplinkChannels []int `json:"enabledUplinkChannels,omitempty"`
+}
+
+// ExtraChannel defines an extra uplink channel.
+type ExtraChannel struct {
+	Frequency int `json:"frequency"` // Hz
+	MinDR     int `json:"minDR"`
+	MaxDR     int `json:"maxDR"`
+}
+
+// GetChannelPlan returns the channel-plan description of the given band.
+// As the repeater compatibility and dwell time can not be retrieved from
+// the band, these must be set by the caller when needed.
+func GetChannelPlan(b Band) (ChannelPlan, error) {
+	if _, ok := b.(*cn470RP002Band); ok {
+		return ChannelPlan{}, errors.New("lorawan/band: channel-plan of RP002 CN470 band is not supported")
+	}
+
+	plan := ChannelPlan{
+		Band: Name(b.Name()),
+	}
+
+	for _, i := range b.GetCustomUplinkChannelIndices() {
+		c, err := b.GetUplinkChannel(i)
+		if err != nil {
+			return plan, errors.Wrap(err, "get uplink channel error")
+		}
+
+		plan.ExtraChannels = append(plan.ExtraChannels, ExtraChannel{
+			Frequency: c.Frequency,
+			MinDR:     c.MinDR,
+			MaxDR:     c.MaxDR,
+		})
+	}
+
+	if len(b.GetDisabledUplinkChannelIndices()) != 0 {
+		plan.EnabledUplinkChannels = b.GetEnabledUplinkChannelIndices()
+	}
+
+	return plan, nil
+}
+
+// GetBand returns the band for the channel-plan description, with the
+// extra channels added and the uplink channels enabled / disabled.
+func (p ChannelPlan) GetBand() (Band, error) {
+	b, err := GetConfig(p.Band, p.RepeaterCompatible, p.DwellTime)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range p.ExtraChannels {
+		if err := b.AddChannel(c.Frequency, c.MinDR, c.MaxDR); err != nil {
+			return nil, errors.Wrap(err, "add channel error")
+		}
+	}
+
+	if len(p.EnabledUplinkChannels) == 0 {
+		return b, nil
+	}
+
+	enabled := make(map[int]struct{})
+	for _, i := range p.EnabledUplinkChannels {
+		if _, err := b.GetUplinkChannel(i); i < 0 || err != nil {
+			return nil, fmt.Errorf("lorawan/band: invalid enabled uplink channel: %d", i)
+		}
+		enabled[i] = struct{}{}
+	}
+
+	for _, i := range b.GetUplinkChannelIndices() {
+		if _, ok := enabled[i]; ok {
+			continue
+		}
+		if err := b.DisableUplinkChannelIndex(i); err != nil {
+			return nil, errors.Wrap(err, "disable uplink channel error")
+		}
+	}
+
+	return b, nil
+}
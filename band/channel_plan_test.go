@@ -0,0 +1,78 @@
+package band
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/brocaar/lorawan"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestChannelPlan(t *testing.T) {
+	Convey("Given the EU868 band with extra channels", t, func() {
+		b, err := GetConfig(EU868, false, lorawan.DwellTimeNoLimit)
+		So(err, ShouldBeNil)
+		So(b.AddChannel(867100000, 0, 5), ShouldBeNil)
+		So(b.AddChannel(868800000, 7, 7), ShouldBeNil)
+
+		Convey("Then GetChannelPlan returns the expected channel-plan", func() {
+			plan, err := GetChannelPlan(b)
+			So(err, ShouldBeNil)
+			So(plan, ShouldResemble, ChannelPlan{
+				Band: EU868,
+				ExtraChannels: []ExtraChannel{
+					{Frequency: 867100000, MinDR: 0, MaxDR: 5},
+					{Frequency: 868800000, MinDR: 7, MaxDR: 7},
+				},
+			})
+
+			Convey("Then it can be marshaled and unmarshaled", func() {
+				bb, err := json.Marshal(plan)
+				So(err, ShouldBeNil)
+				So(string(bb), ShouldEqual, `{"band":"EU868","extraChannels":[{"frequency":867100000,"minDR":0,"maxDR":5},{"frequency":868800000,"minDR":7,"maxDR":7}]}`)
+
+				var plan2 ChannelPlan
+				So(json.Unmarshal(bb, &plan2), ShouldBeNil)
+				So(plan2, ShouldResemble, plan)
+
+				Convey("Then GetBand returns the band with the extra channels", func() {
+					b2, err := plan2.GetBand()
+					So(err, ShouldBeNil)
+					So(b2.GetCustomUplinkChannelIndices(), ShouldResemble, []int{3, 4})
+
+					c, err := b2.GetUplinkChannel(4)
+					So(err, ShouldBeNil)
+					So(c.Frequency, ShouldEqual, 868800000)
+				})
+			})
+		})
+	})
+
+	Convey("Given the US915 band with only sub-band 2 enabled", t, func() {
+		b, err := GetConfig(US915, false, lorawan.DwellTimeNoLimit)
+		So(err, ShouldBeNil)
+		So(EnableSubBands(b, 2), ShouldBeNil)
+
+		Convey("Then the channel-plan round-trips the enabled channels", func() {
+			plan, err := GetChannelPlan(b)
+			So(err, ShouldBeNil)
+			So(plan.EnabledUplinkChannels, ShouldResemble, []int{8, 9, 10, 11, 12, 13, 14, 15, 65})
+
+			b2, err := plan.GetBand()
+			So(err, ShouldBeNil)
+			So(b2.GetEnabledUplinkChannelIndices(), ShouldResemble, plan.EnabledUplinkChannels)
+		})
+	})
+
+	Convey("Given a channel-plan with an invalid enabled channel", t, func() {
+		plan := ChannelPlan{
+			Band:                  US915,
+			EnabledUplinkChannels: []int{100},
+		}
+
+		Convey("Then GetBand returns an error", func() {
+			_, err := plan.GetBand()
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
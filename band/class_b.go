@@ -0,0 +1,153 @@
+package band
+
+import (
+	"fmt"
+	"time"
+)
+
+// BeaconPeriod defines the Class-B beacon period.
+const BeaconPeriod = 128 * time.Second
+
+// Beacon defines the Class-B beacon parameters.
+type Beacon struct {
+	Frequency int // Hz
+	DataRate  int
+}
+
+// GetBeacon returns the Class-B beacon frequency and data-rate for the
+// given beacon time (time since GPS epoch). For US915, AU915 and CN470 the
+// beacon hops over 8 channels, in which case the beacon channel is
+// floor(beaconTime / BeaconPeriod) modulo 8. For the RP002 CN470 channel
+// plans, the beacon hops over the downlink channels of the plan. For custom
+// bands, the beacon is defined by the CustomBandConfig.
+func GetBeacon(b Band, beaconTime time.Duration) (Beacon, error) {
+	beaconChannel := int(beaconTime/BeaconPeriod) % 8
+
+	switch v := b.(type) {
+	case *eu863Band:
+		return Beacon{Frequency: 869525000, DataRate: 3}, nil
+	case *us902Band:
+		return Beacon{Frequency: v.downlinkChannels[beaconChannel].Frequency, DataRate: 8}, nil
+	case *au915Band:
+		return Beacon{Frequency: v.downlinkChannels[beaconChannel].Frequency, DataRate: 8}, nil
+	case *cn470Band:
+		return Beacon{Frequency: 508300000 + (beaconChannel * 200000), DataRate: 2}, nil
+	case *cn470RP002Band:
+		beaconChannel := int(beaconTime/BeaconPeriod) % len(v.downlinkChannels)
+		return Beacon{Frequency: v.downlinkChannels[beaconChannel].Frequency, DataRate: 2}, nil
+	case *cn779Band:
+		return Beacon{Frequency: 785000000, DataRate: 3}, nil
+	case *eu443Band:
+		return Beacon{Frequency: 434665000, DataRate: 3}, nil
+	case *as923Band:
+		return Beacon{Frequency: 923400000 + v.frequencyOffset, DataRate: 3}, nil
+	case *kr920Band:
+		return Beacon{Frequency: 923100000, DataRate: 3}, nil
+	case *in865Band:
+		return Beacon{Frequency: 866550000, DataRate: 4}, nil
+	case *ru864Band:
+		return Beacon{Frequency: 869100000, DataRate: 3}, nil
+	case *ism2400Band:
+		return Beacon{Frequency: 2424000000, DataRate: 3}, nil
+	case *customBand:
+		if v.config.Beacon.Frequency == 0 {
+			return Beacon{}, fmt.Errorf("lorawan/band: beacon is not defined for band %s", b.Name())
+		}
+		return v.config.Beacon, nil
+	default:
+		return Beacon{}, fmt.Errorf("lorawan/band: beacon is not defined for band %s", b.Name())
+	}
+}
+
+// GetPingSlotDataRate returns the default Class-B ping-slot data-rate,
+// which is equal to the beacon data-rate. The ping-slot frequency is
+// returned by Band.GetPingSlotFrequency.
+func GetPingSlotDataRate(b Band) (int, error) {
+	beacon, err := GetBeacon(b, 0)
+	if err != nil {
+		return 0, err
+	}
+	return beacon.DataRate, nil
+}
+
+// Class-B ping-slot timing constants.
+const (
+	// PingSlotLength defines the length of a ping slot.
+	PingSlotLength = 30 * time.Millisecond
+
+	// BeaconWindow defines the part of the beacon period in which the
+	// ping slots are located (the beacon period minus the beacon reserved
+	// and beacon guard time).
+	BeaconWindow = 4096 * PingSlotLength
+)
+
+// GetPingNb returns the number of ping slots per beacon period for the
+// given PingSlotInfoReq periodicity (0 - 7).
+func GetPingNb(periodicity int) (int, error) {
+	if periodicity < 0 || periodicity > 7 {
+		return 0, fmt.Errorf("lorawan/band: invalid ping-slot periodicity: %d", periodicity)
+	}
+	return 1 << uint(7-periodicity), nil
+}
+
+// GetPeriodicityForPingNb returns the PingSlotInfoReq periodicity for the
+// given number of ping slots per beacon period (1, 2, 4, ..., 128).
+func GetPeriodicityForPingNb(pingNb int) (int, error) {
+	for p := 0; p <= 7; p++ {
+		if 1<<uint(7-p) == pingNb {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("lorawan/band: invalid ping number: %d", pingNb)
+}
+
+// GetPingPeriod returns the time between two ping slots for the given
+// PingSlotInfoReq periodicity, e.g. 0.96 seconds for periodicity 0 and
+// 122.88 seconds for periodicity 7.
+func GetPingPeriod(periodicity int) (time.Duration, error) {
+	pingNb, err := GetPingNb(periodicity)
+	if err != nil {
+		return 0, err
+	}
+	return BeaconWindow / time.Duration(pingNb), nil
+}
+
+// GetPingSlotLatency returns the expected (mean) and the max. latency of a
+// Class-B downlink for the given PingSlotInfoReq periodicity, assuming that
+// the downlink is enqueued at a random moment. This takes into account the
+// gap between the last ping slot of a beacon period and the first ping slot
+// of the next beacon period.
+func GetPingSlotLatency(periodicity int) (expected, max time.Duration, err error) {
+	pingNb, err := GetPingNb(periodicity)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	pingPeriod := BeaconWindow / time.Duration(pingNb)
+	max = pingPeriod + BeaconPeriod - BeaconWindow
+
+	// mean of the waiting time over the intervals between the ping slots,
+	// calculated in microseconds to avoid an overflow
+	p := int64(pingPeriod / time.Microsecond)
+	m := int64(max / time.Microsecond)
+	sum := int64(pingNb-1)*p*p + m*m
+	expected = time.Duration(sum/(2*int64(BeaconPeriod/time.Microsecond))) * time.Microsecond
+
+	return expected, max, nil
+}
+
+// GetPeriodicityForLatency returns the highest PingSlotInfoReq periodicity
+// (thus the lowest number of ping slots, saving device power) for which the
+// max. Class-B downlink latency does not exceed the given latency.
+func GetPeriodicityForLatency(maxLatency time.Duration) (int, error) {
+	for p := 7; p >= 0; p-- {
+		_, max, err := GetPingSlotLatency(p)
+		if err != nil {
+			return 0, err
+		}
+		if max <= maxLatency {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("lorawan/band: max latency %s can not be met", maxLatency)
+}
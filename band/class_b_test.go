@@ -0,0 +1,134 @@
+package band
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/brocaar/lorawan"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetBeacon(t *testing.T) {
+	Convey("Given a set of tests", t, func() {
+		tests := []struct {
+			Name       Name
+			BeaconTime time.Duration
+			Beacon     Beacon
+			Error      bool
+		}{
+			{EU868, 0, Beacon{Frequency: 869525000, DataRate: 3}, false},
+			{US915, 0, Beacon{Frequency: 923300000, DataRate: 8}, false},
+			{US915, BeaconPeriod, Beacon{Frequency: 923900000, DataRate: 8}, false},
+			{US915, 9 * BeaconPeriod, Beacon{Frequency: 923900000, DataRate: 8}, false},
+			{AU915, 7 * BeaconPeriod, Beacon{Frequency: 927500000, DataRate: 8}, false},
+			{CN470, 2 * BeaconPeriod, Beacon{Frequency: 508700000, DataRate: 2}, false},
+			{AS923, 0, Beacon{Frequency: 923400000, DataRate: 3}, false},
+			{KR920, 0, Beacon{Frequency: 923100000, DataRate: 3}, false},
+			{IN865, 0, Beacon{Frequency: 866550000, DataRate: 4}, false},
+			{RU864, 0, Beacon{Frequency: 869100000, DataRate: 3}, false},
+			{ISM2400, 0, Beacon{Frequency: 2424000000, DataRate: 3}, false},
+		}
+
+		for i, test := range tests {
+			Convey(fmt.Sprintf("Testing %s [%d]", test.Name, i), func() {
+				b, err := GetConfig(test.Name, false, lorawan.DwellTimeNoLimit)
+				So(err, ShouldBeNil)
+
+				beacon, err := GetBeacon(b, test.BeaconTime)
+				if test.Error {
+					So(err, ShouldNotBeNil)
+					return
+				}
+				So(err, ShouldBeNil)
+				So(beacon, ShouldResemble, test.Beacon)
+
+				dr, err := GetPingSlotDataRate(b)
+				So(err, ShouldBeNil)
+				So(dr, ShouldEqual, test.Beacon.DataRate)
+			})
+		}
+
+		Convey("Testing the CN470 RP002 channel plans", func() {
+			b, err := GetCN470Config(CN470Plan20MHzA, false)
+			So(err, ShouldBeNil)
+
+			beacon, err := GetBeacon(b, 2*BeaconPeriod)
+			So(err, ShouldBeNil)
+			So(beacon, ShouldResemble, Beacon{Frequency: 470700000, DataRate: 2})
+
+			dr, err := GetPingSlotDataRate(b)
+			So(err, ShouldBeNil)
+			So(dr, ShouldEqual, 2)
+		})
+
+		Convey("Testing a custom band", func() {
+			b := newCustomBand("PRIVATE2400", CustomBandConfig{}, false)
+			_, err := GetBeacon(b, 0)
+			So(err, ShouldNotBeNil)
+
+			b = newCustomBand("PRIVATE2400", CustomBandConfig{
+				Beacon: Beacon{Frequency: 2425000000, DataRate: 1},
+			}, false)
+			beacon, err := GetBeacon(b, 0)
+			So(err, ShouldBeNil)
+			So(beacon, ShouldResemble, Beacon{Frequency: 2425000000, DataRate: 1})
+
+			dr, err := GetPingSlotDataRate(b)
+			So(err, ShouldBeNil)
+			So(dr, ShouldEqual, 1)
+		})
+	})
+}
+
+func TestPingSlotPeriodicity(t *testing.T) {
+	Convey("Given a set of tests", t, func() {
+		tests := []struct {
+			Periodicity     int
+			PingNb          int
+			PingPeriod      time.Duration
+			ExpectedLatency time.Duration
+			MaxLatency      time.Duration
+		}{
+			{0, 128, 960 * time.Millisecond, 601600 * time.Microsecond, 6080 * time.Millisecond},
+			{4, 8, 15360 * time.Millisecond, 80896 * 100 * time.Microsecond, 20480 * time.Millisecond},
+			{7, 1, 122880 * time.Millisecond, 64 * time.Second, 128 * time.Second},
+		}
+
+		for i, test := range tests {
+			Convey(fmt.Sprintf("Testing periodicity %d [%d]", test.Periodicity, i), func() {
+				pingNb, err := GetPingNb(test.Periodicity)
+				So(err, ShouldBeNil)
+				So(pingNb, ShouldEqual, test.PingNb)
+
+				p, err := GetPeriodicityForPingNb(test.PingNb)
+				So(err, ShouldBeNil)
+				So(p, ShouldEqual, test.Periodicity)
+
+				pingPeriod, err := GetPingPeriod(test.Periodicity)
+				So(err, ShouldBeNil)
+				So(pingPeriod, ShouldEqual, test.PingPeriod)
+
+				expected, max, err := GetPingSlotLatency(test.Periodicity)
+				So(err, ShouldBeNil)
+				So(expected, ShouldEqual, test.ExpectedLatency)
+				So(max, ShouldEqual, test.MaxLatency)
+
+				p, err = GetPeriodicityForLatency(test.MaxLatency)
+				So(err, ShouldBeNil)
+				So(p, ShouldEqual, test.Periodicity)
+			})
+		}
+
+		Convey("Invalid values return an error", func() {
+			_, err := GetPingNb(8)
+			So(err, ShouldNotBeNil)
+
+			_, err = GetPeriodicityForPingNb(3)
+			So(err, ShouldNotBeNil)
+
+			_, err = GetPeriodicityForLatency(time.Second)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
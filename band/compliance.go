@@ -0,0 +1,121 @@
+package band
+
+import (
+	"errors"
+	"time"
+
+	"github.com/brocaar/lorawan"
+)
+
+// Compliance errors.
+var (
+	ErrMaxEIRPExceeded   = errors.New("lorawan/band: max EIRP exceeded")
+	ErrDutyCycleExceeded = errors.New("lorawan/band: duty-cycle exceeded")
+)
+
+// PlannedTransmission defines a planned (uplink or downlink) transmission.
+type PlannedTransmission struct {
+	Time           time.Time
+	Uplink         bool
+	Frequency      int // Hz
+	DataRate       int
+	PHYPayloadSize int
+	EIRP           float32 // dBm
+}
+
+// ComplianceVerdict contains the result of validating a planned
+// transmission against the regulatory limits of the band.
+type ComplianceVerdict struct {
+	// Allowed is true when there are no violations.
+	Allowed bool
+
+	// TimeOnAir of the transmission.
+	TimeOnAir time.Duration
+
+	// Violations contains the violated limits, e.g. ErrDwellTimeExceeded,
+	// ErrMaxEIRPExceeded or ErrDutyCycleExceeded.
+	Violations []error
+
+	// NextAllowed contains the earliest time at which the transmission
+	// would not exceed the duty-cycle limitation. It is only set in case
+	// of a duty-cycle violation which can be resolved by waiting.
+	NextAllowed time.Time
+}
+
+// ComplianceValidator validates planned transmissions against the
+// regulatory limits of a band: the max EIRP, dwell time and (optionally)
+// duty-cycle.
+type ComplianceValidator struct {
+	band      Band
+	dwellTime lorawan.DwellTime
+	dutyCycle *DutyCycleTracker
+}
+
+// NewComplianceValidator creates a new ComplianceValidator. The dwell time
+// limitation is only validated when set to lorawan.DwellTime400ms. The
+// duty-cycle is only validated when a DutyCycleTracker is given.
+func NewComplianceValidator(b Band, dt lorawan.DwellTime, dutyCycle *DutyCycleTracker) *ComplianceValidator {
+	return &ComplianceValidator{
+		band:      b,
+		dwellTime: dt,
+		dutyCycle: dutyCycle,
+	}
+}
+
+// Validate validates the given planned transmission. An error is returned
+// when the transmission can not be validated, e.g. because of an invalid
+// data-rate.
+func (v *ComplianceValidator) Validate(tx PlannedTransmission) (ComplianceVerdict, error) {
+	var verdict ComplianceVerdict
+
+	toa, err := GetTimeOnAir(v.band, tx.DataRate, tx.PHYPayloadSize)
+	if err != nil {
+		return verdict, err
+	}
+	verdict.TimeOnAir = toa
+
+	maxEIRP := v.band.GetDefaultMaxUplinkEIRP()
+	if !tx.Uplink {
+		maxEIRP = float32(v.band.GetDownlinkTXPower(tx.Frequency))
+	}
+	if tx.EIRP > maxEIRP {
+		verdict.Violations = append(verdict.Violations, ErrMaxEIRPExceeded)
+	}
+
+	if v.dwellTime == lorawan.DwellTime400ms && toa > MaxDwellTime {
+		verdict.Violations = append(verdict.Violations, ErrDwellTimeExceeded)
+	}
+
+	if v.dutyCycle != nil {
+		if _, err := v.dutyCycle.GetSubBand(tx.Frequency); err != nil {
+			return verdict, err
+		}
+
+		ok, next, err := v.dutyCycle.CanTransmit(tx.Time, tx.Frequency, toa)
+		if err != nil || !ok {
+			verdict.Violations = append(verdict.Violations, ErrDutyCycleExceeded)
+		}
+		if err == nil && !ok {
+			verdict.NextAllowed = next
+		}
+	}
+
+	verdict.Allowed = len(verdict.Violations) == 0
+
+	return verdict, nil
+}
+
+// Record records the given transmission in the DutyCycleTracker (if set).
+// This must be called after the transmission has been performed.
+func (v *ComplianceValidator) Record(tx PlannedTransmission) error {
+	if v.dutyCycle == nil {
+		return nil
+	}
+
+	toa, err := GetTimeOnAir(v.band, tx.DataRate, tx.PHYPayloadSize)
+	if err != nil {
+		return err
+	}
+
+	return v.dutyCycle.Record(tx.Time, tx.Frequency, toa)
+}
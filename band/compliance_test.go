@@ -0,0 +1,99 @@
+package band
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brocaar/lorawan"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestComplianceValidator(t *testing.T) {
+	Convey("Given the EU868 band and a duty-cycle tracker", t, func() {
+		b, err := GetConfig(EU868, false, lorawan.DwellTimeNoLimit)
+		So(err, ShouldBeNil)
+
+		v := NewComplianceValidator(b, lorawan.DwellTimeNoLimit, NewDutyCycleTracker(EU868DutyCycleSubBands, DefaultDutyCycleWindow))
+		now := time.Now()
+
+		tx := PlannedTransmission{
+			Time:           now,
+			Uplink:         true,
+			Frequency:      868100000,
+			DataRate:       5,
+			PHYPayloadSize: 20,
+			EIRP:           14,
+		}
+
+		Convey("Then a compliant transmission is allowed", func() {
+			verdict, err := v.Validate(tx)
+			So(err, ShouldBeNil)
+			So(verdict.Allowed, ShouldBeTrue)
+			So(verdict.Violations, ShouldBeEmpty)
+			So(verdict.TimeOnAir, ShouldBeGreaterThan, 0)
+		})
+
+		Convey("Then exceeding the max EIRP is a violation", func() {
+			tx.EIRP = 20
+			verdict, err := v.Validate(tx)
+			So(err, ShouldBeNil)
+			So(verdict.Allowed, ShouldBeFalse)
+			So(verdict.Violations, ShouldResemble, []error{ErrMaxEIRPExceeded})
+		})
+
+		Convey("Then a downlink is validated against the downlink TX power", func() {
+			tx.Uplink = false
+			tx.Frequency = 869525000
+			tx.EIRP = 27
+			verdict, err := v.Validate(tx)
+			So(err, ShouldBeNil)
+			So(verdict.Allowed, ShouldBeTrue)
+		})
+
+		Convey("When the duty-cycle budget has been used", func() {
+			So(v.dutyCycle.Record(now.Add(-time.Minute), 868100000, 36*time.Second), ShouldBeNil)
+
+			Convey("Then the transmission is not allowed", func() {
+				verdict, err := v.Validate(tx)
+				So(err, ShouldBeNil)
+				So(verdict.Allowed, ShouldBeFalse)
+				So(verdict.Violations, ShouldResemble, []error{ErrDutyCycleExceeded})
+				So(verdict.NextAllowed, ShouldEqual, now.Add(-time.Minute).Add(time.Hour))
+			})
+		})
+
+		Convey("Then Record records the transmission", func() {
+			So(v.Record(tx), ShouldBeNil)
+			used, err := v.dutyCycle.Usage(now, 868100000)
+			So(err, ShouldBeNil)
+			So(used, ShouldBeGreaterThan, 0)
+		})
+
+		Convey("Then a frequency outside the duty-cycle sub-bands returns an error", func() {
+			tx.Frequency = 869300000
+			_, err := v.Validate(tx)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given the AS923 band with 400ms dwell time", t, func() {
+		b, err := GetConfig(AS923, false, lorawan.DwellTime400ms)
+		So(err, ShouldBeNil)
+
+		v := NewComplianceValidator(b, lorawan.DwellTime400ms, nil)
+
+		Convey("Then exceeding the dwell time is a violation", func() {
+			verdict, err := v.Validate(PlannedTransmission{
+				Time:           time.Now(),
+				Uplink:         true,
+				Frequency:      923200000,
+				DataRate:       2,
+				PHYPayloadSize: 30,
+				EIRP:           14,
+			})
+			So(err, ShouldBeNil)
+			So(verdict.Allowed, ShouldBeFalse)
+			So(verdict.Violations, ShouldResemble, []error{ErrDwellTimeExceeded})
+		})
+	})
+}
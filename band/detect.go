@@ -0,0 +1,107 @@
+package band
+
+import (
+	"sort"
+
+	"github.com/brocaar/lorawan"
+)
+
+// frequencyRange defines the frequency range (Hz) of a band.
+type frequencyRange struct {
+	name Name
+	min  int
+	max  int
+}
+
+// frequencyRanges defines the frequency ranges of the bands, as used by
+// DetectBand and ValidateFrequency.
+var frequencyRanges = []frequencyRange{
+	{EU868, 863000000, 870000000},
+	{US915, 902000000, 928000000},
+	{CN779, 779000000, 787000000},
+	{EU433, 433050000, 434790000},
+	{AU915, 915000000, 928000000},
+	{CN470, 470000000, 510000000},
+	{AS923, 915000000, 928000000},
+	{AS923_2, 915000000, 928000000},
+	{AS923_3, 915000000, 928000000},
+	{AS923_4, 915000000, 928000000},
+	{KR920, 920900000, 923300000},
+	{IN865, 865000000, 867000000},
+	{RU864, 864000000, 870000000},
+	{ISM2400, 2400000000, 2500000000},
+}
+
+// Observation defines an observed uplink transmission.
+type Observation struct {
+	// Frequency (Hz).
+	Frequency int
+
+	// DataRate of the uplink (optional). When the Modulation is not set,
+	// the data-rate is not taken into account.
+	DataRate DataRate
+}
+
+// BandCandidate defines a band matching a set of observations.
+type BandCandidate struct {
+	Name Name
+
+	// Matches defines the number of observations within the band frequency
+	// range (and matching a band data-rate, when set).
+	Matches int
+
+	// ChannelMatches defines the number of observations matching a default
+	// uplink channel of the band.
+	ChannelMatches int
+}
+
+// DetectBand returns the bands matching the given observed uplinks, most
+// likely band first. Bands are ordered by the number of matching
+// observations, then by the number of observations matching a default
+// uplink channel. Bands without any matching observation are not returned.
+//
+// As different bands can share the same frequencies, this is a heuristic
+// and the result should be considered as a suggestion.
+func DetectBand(observations []Observation) []BandCandidate {
+	var out []BandCandidate
+
+	for _, fr := range frequencyRanges {
+		b, err := GetConfig(fr.name, false, lorawan.DwellTimeNoLimit)
+		if err != nil {
+			continue
+		}
+
+		c := BandCandidate{Name: fr.name}
+
+		for _, o := range observations {
+			if o.Frequency < fr.min || o.Frequency > fr.max {
+				continue
+			}
+
+			if o.DataRate.Modulation != "" {
+				if _, err := b.GetDataRateIndex(true, o.DataRate); err != nil {
+					continue
+				}
+			}
+
+			c.Matches++
+
+			if _, err := b.GetUplinkChannelIndex(o.Frequency, true); err == nil {
+				c.ChannelMatches++
+			}
+		}
+
+		if c.Matches != 0 {
+			out = append(out, c)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Matches != out[j].Matches {
+			return out[i].Matches > out[j].Matches
+		}
+		return out[i].ChannelMatches > out[j].ChannelMatches
+	})
+
+	return out
+}
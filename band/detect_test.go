@@ -0,0 +1,89 @@
+package band
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDetectBand(t *testing.T) {
+	Convey("Given a set of tests", t, func() {
+		tests := []struct {
+			Name         string
+			Observations []Observation
+			Expected     Name
+		}{
+			{
+				Name: "EU868 default channels",
+				Observations: []Observation{
+					{Frequency: 868100000},
+					{Frequency: 868300000},
+					{Frequency: 868500000},
+				},
+				Expected: EU868,
+			},
+			{
+				Name: "EU868 extra channels",
+				Observations: []Observation{
+					{Frequency: 867100000},
+					{Frequency: 867300000},
+					{Frequency: 868100000},
+				},
+				Expected: EU868,
+			},
+			{
+				Name: "US915 sub-band 2",
+				Observations: []Observation{
+					{Frequency: 903900000},
+					{Frequency: 904100000},
+					{Frequency: 904600000},
+				},
+				Expected: US915,
+			},
+			{
+				Name: "AU915 sub-band 2",
+				Observations: []Observation{
+					{Frequency: 916800000},
+					{Frequency: 917000000},
+				},
+				Expected: AU915,
+			},
+			{
+				Name: "AS923-2",
+				Observations: []Observation{
+					{Frequency: 921400000, DataRate: DataRate{Modulation: LoRaModulation, SpreadFactor: 7, Bandwidth: 250}},
+					{Frequency: 921600000, DataRate: DataRate{Modulation: LoRaModulation, SpreadFactor: 7, Bandwidth: 250}},
+				},
+				Expected: AS923_2,
+			},
+			{
+				Name: "RU864",
+				Observations: []Observation{
+					{Frequency: 868900000, DataRate: DataRate{Modulation: LoRaModulation, SpreadFactor: 7, Bandwidth: 125}},
+					{Frequency: 869100000, DataRate: DataRate{Modulation: LoRaModulation, SpreadFactor: 7, Bandwidth: 125}},
+				},
+				Expected: RU864,
+			},
+			{
+				Name: "US915 500 kHz channel",
+				Observations: []Observation{
+					{Frequency: 904600000, DataRate: DataRate{Modulation: LoRaModulation, SpreadFactor: 8, Bandwidth: 500}},
+				},
+				Expected: US915,
+			},
+		}
+
+		for i, test := range tests {
+			Convey(fmt.Sprintf("Testing: %s [%d]", test.Name, i), func() {
+				candidates := DetectBand(test.Observations)
+				So(candidates, ShouldNotBeEmpty)
+				So(candidates[0].Name, ShouldEqual, test.Expected)
+			})
+		}
+
+		Convey("Then no candidates are returned for unknown frequencies", func() {
+			So(DetectBand([]Observation{{Frequency: 100000000}}), ShouldBeEmpty)
+		})
+	})
+}
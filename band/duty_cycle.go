@@ -0,0 +1,182 @@
+package band
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DutyCycleSubBand defines a (regulatory) sub-band with its duty-cycle
+// limitation.
+type DutyCycleSubBand struct {
+	Name         string
+	MinFrequency int     // Hz, inclusive
+	MaxFrequency int     // Hz, exclusive
+	DutyCycle    float64 // e.g. 0.01 for 1%
+}
+
+// EU868DutyCycleSubBands contains the ETSI EN 300 220 sub-bands as used by
+// the EU868 band.
+var EU868DutyCycleSubBands = []DutyCycleSubBand{
+	{Name: "g", MinFrequency: 863000000, MaxFrequency: 865000000, DutyCycle: 0.001},
+	{Name: "g1", MinFrequency: 865000000, MaxFrequency: 868600000, DutyCycle: 0.01},
+	{Name: "g2", MinFrequency: 868700000, MaxFrequency: 869200000, DutyCycle: 0.001},
+	{Name: "g3", MinFrequency: 869400000, MaxFrequency: 869650000, DutyCycle: 0.1},
+	{Name: "g4", MinFrequency: 869700000, MaxFrequency: 870000000, DutyCycle: 0.01},
+}
+
+// DefaultDutyCycleWindow defines the default duty-cycle observation window.
+const DefaultDutyCycleWindow = time.Hour
+
+type transmission struct {
+	start   time.Time
+	airtime time.Duration
+}
+
+// DutyCycleTracker tracks the airtime per sub-band over a rolling window.
+// It can be used by gateways and network-servers to decide if a
+// transmission is allowed, and if not, when it will be allowed.
+// It is safe for concurrent use.
+//
+// The given times do not need to be increasing, e.g. a transmission can be
+// recorded ahead of its (scheduled) start. Transmissions are kept for two
+// windows after the latest given time, thus a given time can go back by up
+// to one window.
+type DutyCycleTracker struct {
+	subBands []DutyCycleSubBand
+	window   time.Duration
+
+	mu      sync.Mutex
+	latest  time.Time
+	history map[int][]transmission // sub-band index / transmissions
+}
+
+// NewDutyCycleTracker creates a new DutyCycleTracker for the given
+// sub-bands and observation window.
+func NewDutyCycleTracker(subBands []DutyCycleSubBand, window time.Duration) *DutyCycleTracker {
+	return &DutyCycleTracker{
+		subBands: subBands,
+		window:   window,
+		history:  make(map[int][]transmission),
+	}
+}
+
+// GetSubBand returns the sub-band for the given frequency.
+func (t *DutyCycleTracker) GetSubBand(frequency int) (DutyCycleSubBand, error) {
+	i, err := t.getSubBandIndex(frequency)
+	if err != nil {
+		return DutyCycleSubBand{}, err
+	}
+	return t.subBands[i], nil
+}
+
+// Record records a transmission at the given frequency.
+func (t *DutyCycleTracker) Record(start time.Time, frequency int, airtime time.Duration) error {
+	i, err := t.getSubBandIndex(frequency)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.expire(i, start)
+	t.history[i] = append(t.history[i], transmission{start: start, airtime: airtime})
+	sort.Slice(t.history[i], func(a, b int) bool {
+		return t.history[i][a].start.Before(t.history[i][b].start)
+	})
+
+	return nil
+}
+
+// Usage returns the airtime used within the window ending at the given
+// time, for the sub-band of the given frequency.
+func (t *DutyCycleTracker) Usage(now time.Time, frequency int) (time.Duration, error) {
+	i, err := t.getSubBandIndex(frequency)
+	if err != nil {
+		return 0, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var used time.Duration
+	for _, tx := range t.expire(i, now) {
+		used += tx.airtime
+	}
+	return used, nil
+}
+
+// CanTransmit returns if a transmission with the given airtime at the given
+// frequency is allowed at the given time. When it is not allowed, it
+// returns the earliest time at which it will be allowed (given that no
+// other transmissions are recorded in the meantime).
+func (t *DutyCycleTracker) CanTransmit(now time.Time, frequency int, airtime time.Duration) (bool, time.Time, error) {
+	i, err := t.getSubBandIndex(frequency)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	budget := time.Duration(float64(t.window) * t.subBands[i].DutyCycle)
+	if airtime > budget {
+		return false, time.Time{}, fmt.Errorf("lorawan/band: airtime %s exceeds the duty-cycle budget of %s", airtime, budget)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	txs := t.expire(i, now)
+
+	var used time.Duration
+	for _, tx := range txs {
+		used += tx.airtime
+	}
+
+	if used+airtime <= budget {
+		return true, now, nil
+	}
+
+	// Transmissions leave the window in order of their start time.
+	for _, tx := range txs {
+		used -= tx.airtime
+		if used+airtime <= budget {
+			return false, tx.start.Add(t.window), nil
+		}
+	}
+
+	// This can not happen as airtime <= budget.
+	return false, time.Time{}, nil
+}
+
+// expire removes the transmissions which can not be within the window of
+// any time going back by up to one window from the latest given time and
+// returns the transmissions within the window
+// ending at the given time.
+func (t *DutyCycleTracker) expire(i int, now time.Time) []transmission {
+	if now.After(t.latest) {
+		t.latest = now
+	}
+
+	t.history[i] = transmissionsAfter(t.history[i], t.latest.Add(-2*t.window))
+	return transmissionsAfter(t.history[i], now.Add(-t.window))
+}
+
+// transmissionsAfter returns the (sorted) transmissions which started after
+// the given time.
+func transmissionsAfter(txs []transmission, start time.Time) []transmission {
+	var n int
+	for n < len(txs) && !txs[n].start.After(start) {
+		n++
+	}
+	return txs[n:]
+}
+
+func (t *DutyCycleTracker) getSubBandIndex(frequency int) (int, error) {
+	for i, sb := range t.subBands {
+		if frequency >= sb.MinFrequency && frequency < sb.MaxFrequency {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("lorawan/band: no duty-cycle sub-band for frequency: %d", frequency)
+}
@@ -0,0 +1,88 @@
+package band
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDutyCycleTracker(t *testing.T) {
+	Convey("Given a DutyCycleTracker for EU868", t, func() {
+		tracker := NewDutyCycleTracker(EU868DutyCycleSubBands, DefaultDutyCycleWindow)
+		now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+		Convey("Then GetSubBand returns the expected sub-band", func() {
+			sb, err := tracker.GetSubBand(869525000)
+			So(err, ShouldBeNil)
+			So(sb.Name, ShouldEqual, "g3")
+
+			_, err = tracker.GetSubBand(869300000)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Then a transmission exceeding the budget returns an error", func() {
+			_, _, err := tracker.CanTransmit(now, 868100000, 37*time.Second)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("When recording 30 seconds of airtime on g1", func() {
+			So(tracker.Record(now.Add(-50*time.Minute), 868100000, 20*time.Second), ShouldBeNil)
+			So(tracker.Record(now.Add(-10*time.Minute), 868300000, 10*time.Second), ShouldBeNil)
+
+			Convey("Then the usage is 30 seconds", func() {
+				used, err := tracker.Usage(now, 868500000)
+				So(err, ShouldBeNil)
+				So(used, ShouldEqual, 30*time.Second)
+			})
+
+			Convey("Then a 6 second transmission is allowed", func() {
+				ok, at, err := tracker.CanTransmit(now, 868100000, 6*time.Second)
+				So(err, ShouldBeNil)
+				So(ok, ShouldBeTrue)
+				So(at, ShouldEqual, now)
+			})
+
+			Convey("Then a 7 second transmission is allowed once the first transmission expired", func() {
+				ok, at, err := tracker.CanTransmit(now, 868100000, 7*time.Second)
+				So(err, ShouldBeNil)
+				So(ok, ShouldBeFalse)
+				So(at, ShouldEqual, now.Add(10*time.Minute))
+
+				ok, _, err = tracker.CanTransmit(at, 868100000, 7*time.Second)
+				So(err, ShouldBeNil)
+				So(ok, ShouldBeTrue)
+			})
+
+			Convey("Then a time going backwards does not remove transmissions", func() {
+				used, err := tracker.Usage(now.Add(-30*time.Minute), 868100000)
+				So(err, ShouldBeNil)
+				So(used, ShouldEqual, 30*time.Second)
+
+				used, err = tracker.Usage(now, 868100000)
+				So(err, ShouldBeNil)
+				So(used, ShouldEqual, 30*time.Second)
+			})
+
+			Convey("When recording a transmission ahead of its start", func() {
+				So(tracker.Record(now.Add(20*time.Minute), 868100000, 5*time.Second), ShouldBeNil)
+
+				Convey("Then the transmissions within the window ending now are kept", func() {
+					used, err := tracker.Usage(now, 868100000)
+					So(err, ShouldBeNil)
+					So(used, ShouldEqual, 35*time.Second)
+
+					used, err = tracker.Usage(now.Add(20*time.Minute), 868100000)
+					So(err, ShouldBeNil)
+					So(used, ShouldEqual, 15*time.Second)
+				})
+			})
+
+			Convey("Then the g3 sub-band is not affected", func() {
+				used, err := tracker.Usage(now, 869525000)
+				So(err, ShouldBeNil)
+				So(used, ShouldEqual, 0)
+			})
+		})
+	})
+}
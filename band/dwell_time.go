@@ -0,0 +1,103 @@
+package band
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/airtime"
+)
+
+// MaxDwellTime defines the maximum dwell time when the 400 ms dwell time
+// limitation is in effect.
+const MaxDwellTime = 400 * time.Millisecond
+
+// ErrDwellTimeExceeded is returned when the time-on-air of a transmission
+// exceeds the dwell time limitation.
+var ErrDwellTimeExceeded = errors.New("lorawan/band: dwell time exceeded")
+
+// minMACPayloadSize defines the size of the FHDR without FOpts:
+// DevAddr (4) + FCtrl (1) + FCnt (2).
+const minMACPayloadSize = 7
+
+// GetTimeOnAir returns the time-on-air of a PHYPayload of the given size
+// (in bytes), transmitted using the given data-rate. For LoRa modulation,
+// an 8 symbol preamble, explicit header and coding-rate 4/5 are assumed.
+func GetTimeOnAir(b Band, dr int, phyPayloadSize int) (time.Duration, error) {
+	dataRate, err := b.GetDataRate(dr)
+	if err != nil {
+		return 0, err
+	}
+
+	switch dataRate.Modulation {
+	case LoRaModulation:
+		ldro := dataRate.SpreadFactor >= 11 && dataRate.Bandwidth == 125
+		return airtime.CalculateLoRaAirtime(phyPayloadSize, dataRate.SpreadFactor, dataRate.Bandwidth, 8, airtime.CodingRate45, true, ldro)
+	case FSKModulation:
+		return airtime.Calculate(phyPayloadSize, airtime.DataRate{Modulation: airtime.FSK, BitRate: dataRate.BitRate}, 5, true, true, false)
+	case LRFHSSModulation:
+		return airtime.Calculate(phyPayloadSize, airtime.DataRate{Modulation: airtime.LRFHSS, LRFHSSCodingRate: dataRate.CodingRate}, 0, true, true, false)
+	default:
+		return 0, fmt.Errorf("lorawan/band: unsupported modulation: %s", dataRate.Modulation)
+	}
+}
+
+// ValidateDwellTime validates that the time-on-air of a PHYPayload of the
+// given size, transmitted using the given data-rate, does not exceed the
+// given dwell time limitation. It returns ErrDwellTimeExceeded when it does.
+func ValidateDwellTime(b Band, dt lorawan.DwellTime, dr int, phyPayloadSize int) error {
+	if dt != lorawan.DwellTime400ms {
+		return nil
+	}
+
+	toa, err := GetTimeOnAir(b, dr, phyPayloadSize)
+	if err != nil {
+		return err
+	}
+
+	if toa > MaxDwellTime {
+		return ErrDwellTimeExceeded
+	}
+
+	return nil
+}
+
+// GetMaxPayloadSizeForDwellTime returns the max-payload size for the given
+// data-rate index, protocol version and regional-parameters revision (see
+// Band.GetMaxPayloadSizeForDataRateIndex), reduced so that the time-on-air
+// does not exceed the given dwell time limitation. When the data-rate can
+// not be used under the dwell time limitation, M and N are 0.
+func GetMaxPayloadSizeForDwellTime(b Band, protocolVersion, regParamRevision string, dr int, dt lorawan.DwellTime) (MaxPayloadSize, error) {
+	ps, err := b.GetMaxPayloadSizeForDataRateIndex(protocolVersion, regParamRevision, dr)
+	if err != nil {
+		return ps, err
+	}
+
+	if dt != lorawan.DwellTime400ms {
+		return ps, nil
+	}
+
+	for ps.M > 0 {
+		// M is the max MACPayload size, the PHYPayload adds MHDR and MIC.
+		err := ValidateDwellTime(b, dt, dr, ps.M+5)
+		if err == nil {
+			break
+		}
+		if err != ErrDwellTimeExceeded {
+			return ps, err
+		}
+
+		ps.M--
+		ps.N--
+	}
+
+	if ps.N < 0 {
+		ps.N = 0
+	}
+	if ps.M < minMACPayloadSize {
+		ps = MaxPayloadSize{}
+	}
+
+	return ps, nil
+}
@@ -0,0 +1,60 @@
+package band
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brocaar/lorawan"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDwellTime(t *testing.T) {
+	Convey("Given the AS923 band", t, func() {
+		b, err := GetConfig(AS923, false, lorawan.DwellTime400ms)
+		So(err, ShouldBeNil)
+
+		Convey("Then GetTimeOnAir returns the expected value", func() {
+			// SF10 / 125 kHz, 13 bytes
+			toa, err := GetTimeOnAir(b, 2, 13)
+			So(err, ShouldBeNil)
+			So(toa, ShouldEqual, 288768*time.Microsecond)
+
+			// FSK 50 kbps, 13 bytes
+			toa, err = GetTimeOnAir(b, 7, 13)
+			So(err, ShouldBeNil)
+			So(toa, ShouldEqual, 3840*time.Microsecond)
+		})
+
+		Convey("Then ValidateDwellTime returns the expected result", func() {
+			So(ValidateDwellTime(b, lorawan.DwellTime400ms, 2, 13), ShouldBeNil)
+			So(ValidateDwellTime(b, lorawan.DwellTime400ms, 2, 30), ShouldEqual, ErrDwellTimeExceeded)
+			So(ValidateDwellTime(b, lorawan.DwellTimeNoLimit, 2, 30), ShouldBeNil)
+		})
+
+		Convey("Then GetMaxPayloadSizeForDwellTime returns a size within the dwell time", func() {
+			ps, err := GetMaxPayloadSizeForDwellTime(b, "", "", 2, lorawan.DwellTime400ms)
+			So(err, ShouldBeNil)
+			So(ps.M, ShouldBeGreaterThanOrEqualTo, minMACPayloadSize)
+			So(ValidateDwellTime(b, lorawan.DwellTime400ms, 2, ps.M+5), ShouldBeNil)
+			So(ValidateDwellTime(b, lorawan.DwellTime400ms, 2, ps.M+6), ShouldEqual, ErrDwellTimeExceeded)
+
+			ps, err = GetMaxPayloadSizeForDwellTime(b, "", "", 5, lorawan.DwellTimeNoLimit)
+			So(err, ShouldBeNil)
+			exp, err := b.GetMaxPayloadSizeForDataRateIndex("", "", 5)
+			So(err, ShouldBeNil)
+			So(ps, ShouldResemble, exp)
+		})
+	})
+
+	Convey("Given the EU868 band", t, func() {
+		b, err := GetConfig(EU868, false, lorawan.DwellTimeNoLimit)
+		So(err, ShouldBeNil)
+
+		Convey("Then GetTimeOnAir returns the expected LR-FHSS value", func() {
+			// LR-FHSS CR 1/3, 13 bytes
+			toa, err := GetTimeOnAir(b, 8, 13)
+			So(err, ShouldBeNil)
+			So(toa, ShouldEqual, 1507328*time.Microsecond)
+		})
+	})
+}
@@ -0,0 +1,30 @@
+package band
+
+import "fmt"
+
+// GetFrequencyRange returns the frequency range (Hz, inclusive) of the band
+// with the given name.
+func GetFrequencyRange(name Name) (min, max int, err error) {
+	for _, fr := range frequencyRanges {
+		if fr.name == name {
+			return fr.min, fr.max, nil
+		}
+	}
+
+	return 0, 0, fmt.Errorf("lorawan/band: unknown frequency range for band: %s", name)
+}
+
+// ValidateFrequency returns an error when the given frequency (Hz) is
+// outside the frequency range of the given band.
+func ValidateFrequency(b Band, frequency int) error {
+	min, max, err := GetFrequencyRange(Name(b.Name()))
+	if err != nil {
+		return err
+	}
+
+	if frequency < min || frequency > max {
+		return fmt.Errorf("lorawan/band: frequency %d Hz is outside the %s frequency range (%d - %d Hz)", frequency, b.Name(), min, max)
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package band
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestValidateFrequency(t *testing.T) {
+	Convey("Given the EU868 band", t, func() {
+		b, err := GetConfig(EU868, false, lorawan.DwellTimeNoLimit)
+		So(err, ShouldBeNil)
+
+		Convey("Then a frequency within the band is valid", func() {
+			So(ValidateFrequency(b, 868100000), ShouldBeNil)
+			So(ValidateFrequency(b, 870000000), ShouldBeNil)
+		})
+
+		Convey("Then a frequency outside the band is invalid", func() {
+			So(ValidateFrequency(b, 915000000), ShouldNotBeNil)
+			So(ValidateFrequency(b, 868100), ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given an unknown band name", t, func() {
+		_, _, err := GetFrequencyRange(Name("foo"))
+		So(err, ShouldNotBeNil)
+	})
+}
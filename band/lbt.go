@@ -0,0 +1,57 @@
+package band
+
+import (
+	"strings"
+	"time"
+)
+
+// LBT defines the listen-before-talk requirements of a region.
+type LBT struct {
+	// RSSITarget defines the clear-channel assessment threshold (dBm).
+	// The channel is considered free when the RSSI is below this value.
+	RSSITarget float32
+
+	// ScanTime defines the minimum duration the channel must be sensed
+	// free before transmitting.
+	ScanTime time.Duration
+
+	// MinFrequency and MaxFrequency define the frequency range (Hz) to
+	// which the LBT requirements apply.
+	MinFrequency int
+	MaxFrequency int
+}
+
+// AppliesTo returns true when the LBT requirements apply to the given
+// frequency.
+func (l LBT) AppliesTo(frequency int) bool {
+	return frequency >= l.MinFrequency && frequency <= l.MaxFrequency
+}
+
+// GetLBT returns the listen-before-talk requirements for the given band
+// and ISO 3166-1 alpha-2 country code. As the AS923 band is used by
+// multiple countries, the requirements depend on the country. It returns
+// false when listen-before-talk is not required.
+func GetLBT(name Name, countryCode string) (LBT, bool) {
+	switch name {
+	case KR_920_923, KR920:
+		// Korean radio regulations.
+		return LBT{
+			RSSITarget:   -65,
+			ScanTime:     5 * time.Millisecond,
+			MinFrequency: 920900000,
+			MaxFrequency: 923300000,
+		}, true
+	case AS_923, AS923:
+		if strings.ToUpper(countryCode) == "JP" {
+			// ARIB STD-T108.
+			return LBT{
+				RSSITarget:   -80,
+				ScanTime:     5 * time.Millisecond,
+				MinFrequency: 920600000,
+				MaxFrequency: 928000000,
+			}, true
+		}
+	}
+
+	return LBT{}, false
+}
@@ -0,0 +1,35 @@
+package band
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetLBT(t *testing.T) {
+	Convey("Given a set of tests", t, func() {
+		tests := []struct {
+			Name        Name
+			CountryCode string
+			Required    bool
+			Frequency   int
+			AppliesTo   bool
+		}{
+			{KR920, "", true, 922100000, true},
+			{KR_920_923, "KR", true, 920000000, false},
+			{AS923, "jp", true, 923200000, true},
+			{AS923, "SG", false, 923200000, false},
+			{EU868, "", false, 868100000, false},
+		}
+
+		for _, tst := range tests {
+			lbt, ok := GetLBT(tst.Name, tst.CountryCode)
+			So(ok, ShouldEqual, tst.Required)
+			So(lbt.AppliesTo(tst.Frequency), ShouldEqual, tst.AppliesTo)
+			if ok {
+				So(lbt.ScanTime, ShouldEqual, 5*time.Millisecond)
+			}
+		}
+	})
+}
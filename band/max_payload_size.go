@@ -0,0 +1,51 @@
+package band
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lorawan"
+)
+
+// GetMaxPayloadSize returns the max-payload size for the given band,
+// repeater compatibility, dwell time and data-rate index, using the most
+// recent implemented protocol-version and regional-parameters revision.
+// Use Band.GetMaxPayloadSizeForDataRateIndex when communicating with a
+// device implementing an older revision.
+func GetMaxPayloadSize(name Name, repeaterCompatible bool, dt lorawan.DwellTime, dr int) (MaxPayloadSize, error) {
+	b, err := GetConfig(name, repeaterCompatible, dt)
+	if err != nil {
+		return MaxPayloadSize{}, err
+	}
+
+	return b.GetMaxPayloadSizeForDataRateIndex(latest, latest, dr)
+}
+
+// GetMaxPayloadSizeTable returns the max-payload sizes per data-rate index
+// for the given band, repeater compatibility, dwell time, protocol-version
+// and regional-parameters revision. Data-rates which are not defined by the
+// band are not included.
+func GetMaxPayloadSizeTable(name Name, repeaterCompatible bool, dt lorawan.DwellTime, protocolVersion, regParamRevision string) (map[int]MaxPayloadSize, error) {
+	b, err := GetConfig(name, repeaterCompatible, dt)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[int]MaxPayloadSize)
+	for dr := 0; dr < 16; dr++ {
+		if _, err := b.GetDataRate(dr); err != nil {
+			continue
+		}
+
+		ps, err := b.GetMaxPayloadSizeForDataRateIndex(protocolVersion, regParamRevision, dr)
+		if err != nil {
+			continue
+		}
+		out[dr] = ps
+	}
+
+	if len(out) == 0 {
+		return nil, errors.New("lorawan/band: no max-payload sizes defined")
+	}
+
+	return out, nil
+}
@@ -0,0 +1,35 @@
+package band
+
+import (
+	"testing"
+
+	"github.com/brocaar/lorawan"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetMaxPayloadSize(t *testing.T) {
+	Convey("Given the AS923 band", t, func() {
+		Convey("Then GetMaxPayloadSize takes the dwell time into account", func() {
+			ps, err := GetMaxPayloadSize(AS923, true, lorawan.DwellTime400ms, 2)
+			So(err, ShouldBeNil)
+			So(ps, ShouldResemble, MaxPayloadSize{M: 19, N: 11})
+
+			ps, err = GetMaxPayloadSize(AS923, true, lorawan.DwellTimeNoLimit, 2)
+			So(err, ShouldBeNil)
+			So(ps, ShouldResemble, MaxPayloadSize{M: 123, N: 115})
+		})
+
+		Convey("Then GetMaxPayloadSizeTable returns all data-rates", func() {
+			table, err := GetMaxPayloadSizeTable(AS923, false, lorawan.DwellTime400ms, LoRaWAN_1_0_3, RegParamRevA)
+			So(err, ShouldBeNil)
+			So(table, ShouldHaveLength, 8)
+			So(table[0], ShouldResemble, MaxPayloadSize{})
+			So(table[5], ShouldResemble, MaxPayloadSize{M: 250, N: 242})
+		})
+	})
+
+	Convey("Then an unknown band returns an error", t, func() {
+		_, err := GetMaxPayloadSize("foo", false, lorawan.DwellTimeNoLimit, 0)
+		So(err, ShouldNotBeNil)
+	})
+}
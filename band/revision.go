@@ -0,0 +1,79 @@
+package band
+
+import (
+	"fmt"
+
+	"github.com/brocaar/lorawan"
+)
+
+// regParamRevisions contains the implemented Regional Parameters revisions,
+// oldest first.
+var regParamRevisions = []string{
+	RegParamRevA,
+	RegParamRevB,
+	RegParamRevC,
+	RegParamRevRP002_1_0_0,
+	RegParamRevRP002_1_0_1,
+	RegParamRevRP002_1_0_2,
+	RegParamRevRP002_1_0_3,
+	RegParamRevRP002_1_0_4,
+}
+
+func regParamRevisionIndex(rev string) int {
+	for i, r := range regParamRevisions {
+		if r == rev {
+			return i
+		}
+	}
+	return -1
+}
+
+// regParamRevisionBefore returns true when revision a is older than
+// revision b. An unknown (or empty) revision is considered to be the most
+// recent revision.
+func regParamRevisionBefore(a, b string) bool {
+	ai := regParamRevisionIndex(a)
+	bi := regParamRevisionIndex(b)
+	if ai == -1 || bi == -1 {
+		return false
+	}
+	return ai < bi
+}
+
+// dataRateAvailable returns true when the data-rate is available for the
+// revision the band was instantiated with.
+func (b *band) dataRateAvailable(d DataRate) bool {
+	return !regParamRevisionBefore(b.regParamRevision, d.regParamRevision)
+}
+
+func (b *band) setRevision(protocolVersion, regParamRevision string) {
+	b.protocolVersion = protocolVersion
+	b.regParamRevision = regParamRevision
+}
+
+// GetConfigForRevision returns the band configuration for the given band,
+// for devices implementing the given LoRaWAN protocol-version and Regional
+// Parameters revision. Data-rates which were not defined in the given
+// revision are not available, the TXPower offsets of the given revision are
+// used and the given protocol-version and revision are used by
+// GetMaxPayloadSizeForDataRateIndex when called with empty values. See GetConfig for the other arguments.
+func GetConfigForRevision(name Name, repeaterCompatible bool, dt lorawan.DwellTime, protocolVersion, regParamRevision string) (Band, error) {
+	if regParamRevisionIndex(regParamRevision) == -1 {
+		return nil, fmt.Errorf("lorawan/band: unknown regional parameters revision: %s", regParamRevision)
+	}
+
+	b, err := GetConfig(name, repeaterCompatible, dt)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, ok := b.(interface {
+		setRevision(protocolVersion, regParamRevision string)
+	})
+	if !ok {
+		return nil, fmt.Errorf("lorawan/band: band %s does not support revision selection", name)
+	}
+	rs.setRevision(protocolVersion, regParamRevision)
+
+	return b, nil
+}
@@ -0,0 +1,66 @@
+package band
+
+import (
+	"testing"
+
+	"github.com/brocaar/lorawan"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetConfigForRevision(t *testing.T) {
+	Convey("Given the EU868 band for LoRaWAN 1.0.3 and RP002-1.0.1", t, func() {
+		b, err := GetConfigForRevision(EU868, false, lorawan.DwellTimeNoLimit, LoRaWAN_1_0_3, RegParamRevRP002_1_0_1)
+		So(err, ShouldBeNil)
+
+		Convey("Then the LR-FHSS data-rates are not available", func() {
+			_, err := b.GetDataRate(8)
+			So(err, ShouldNotBeNil)
+
+			_, err = b.GetDataRateIndex(true, DataRate{Modulation: LRFHSSModulation, CodingRate: "1/3", OccupiedChannelWidth: 137000})
+			So(err, ShouldNotBeNil)
+
+			_, err = b.GetMaxPayloadSizeForDataRateIndex("", "", 8)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Then the LoRa data-rates are available", func() {
+			dr, err := b.GetDataRate(5)
+			So(err, ShouldBeNil)
+			So(dr.SpreadFactor, ShouldEqual, 7)
+
+			ps, err := b.GetMaxPayloadSizeForDataRateIndex("", "", 5)
+			So(err, ShouldBeNil)
+			So(ps, ShouldResemble, MaxPayloadSize{M: 250, N: 242})
+		})
+	})
+
+	Convey("Given the EU868 band for LoRaWAN 1.0.4 and RP002-1.0.2", t, func() {
+		b, err := GetConfigForRevision(EU868, false, lorawan.DwellTimeNoLimit, LoRaWAN_1_0_4, RegParamRevRP002_1_0_2)
+		So(err, ShouldBeNil)
+
+		Convey("Then the LR-FHSS data-rates are available", func() {
+			dr, err := b.GetDataRate(8)
+			So(err, ShouldBeNil)
+			So(dr.Modulation, ShouldEqual, LRFHSSModulation)
+
+			ps, err := b.GetMaxPayloadSizeForDataRateIndex("", "", 8)
+			So(err, ShouldBeNil)
+			So(ps, ShouldResemble, MaxPayloadSize{M: 58, N: 50})
+		})
+	})
+
+	Convey("Given the EU868 band without revision", t, func() {
+		b, err := GetConfig(EU868, false, lorawan.DwellTimeNoLimit)
+		So(err, ShouldBeNil)
+
+		Convey("Then the LR-FHSS max-payload size is not available for an older revision", func() {
+			_, err := b.GetMaxPayloadSizeForDataRateIndex(LoRaWAN_1_0_3, RegParamRevRP002_1_0_0, 8)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Then an unknown revision returns an error", t, func() {
+		_, err := GetConfigForRevision(EU868, false, lorawan.DwellTimeNoLimit, LoRaWAN_1_0_3, "foo")
+		So(err, ShouldNotBeNil)
+	})
+}
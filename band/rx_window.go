@@ -0,0 +1,52 @@
+package band
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lorawan"
+)
+
+// RXWindow defines the frequency and data-rate of a receive window.
+type RXWindow struct {
+	Frequency int // Hz
+	DataRate  int
+}
+
+// GetRX1Window returns the RX1 frequency and data-rate given the uplink
+// frequency, uplink data-rate and RX1 data-rate offset.
+//
+// For AS923, the lowest RX1 data-rate depends on the DownlinkDwellTime of
+// the device (as configured using the TxParamSetupReq mac-command), which
+// can differ from the dwell time the band was configured with. For other
+// bands the downlink dwell time argument is ignored.
+func GetRX1Window(b Band, uplinkFrequency, uplinkDR, rx1DROffset int, downlinkDwellTime lorawan.DwellTime) (RXWindow, error) {
+	var out RXWindow
+	var err error
+
+	out.Frequency, err = b.GetRX1FrequencyForUplinkFrequency(uplinkFrequency)
+	if err != nil {
+		return out, errors.Wrap(err, "get rx1 frequency error")
+	}
+
+	if as923, ok := b.(*as923Band); ok && as923.dwellTime != downlinkDwellTime {
+		tmp := *as923
+		tmp.dwellTime = downlinkDwellTime
+		b = &tmp
+	}
+
+	out.DataRate, err = b.GetRX1DataRateIndex(uplinkDR, rx1DROffset)
+	if err != nil {
+		return out, errors.Wrap(err, "get rx1 data-rate error")
+	}
+
+	return out, nil
+}
+
+// GetRX2Window returns the default RX2 frequency and data-rate of the band.
+func GetRX2Window(b Band) RXWindow {
+	d := b.GetDefaults()
+	return RXWindow{
+		Frequency: d.RX2Frequency,
+		DataRate:  d.RX2DataRate,
+	}
+}
@@ -0,0 +1,40 @@
+package band
+
+import (
+	"testing"
+
+	"github.com/brocaar/lorawan"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRXWindow(t *testing.T) {
+	Convey("Given a set of tests", t, func() {
+		tests := []struct {
+			Name              Name
+			DwellTime         lorawan.DwellTime
+			UplinkFrequency   int
+			UplinkDR          int
+			RX1DROffset       int
+			DownlinkDwellTime lorawan.DwellTime
+			ExpectedRX1       RXWindow
+			ExpectedRX2       RXWindow
+		}{
+			{EU868, lorawan.DwellTimeNoLimit, 868300000, 5, 1, lorawan.DwellTimeNoLimit, RXWindow{868300000, 4}, RXWindow{869525000, 0}},
+			{US915, lorawan.DwellTimeNoLimit, 902500000, 3, 0, lorawan.DwellTimeNoLimit, RXWindow{923900000, 13}, RXWindow{923300000, 8}},
+			{AU915, lorawan.DwellTimeNoLimit, 915400000, 2, 1, lorawan.DwellTimeNoLimit, RXWindow{923900000, 9}, RXWindow{923300000, 8}},
+			{AS923, lorawan.DwellTimeNoLimit, 923200000, 2, 5, lorawan.DwellTimeNoLimit, RXWindow{923200000, 0}, RXWindow{923200000, 2}},
+			{AS923, lorawan.DwellTimeNoLimit, 923200000, 2, 5, lorawan.DwellTime400ms, RXWindow{923200000, 2}, RXWindow{923200000, 2}},
+			{AS923, lorawan.DwellTime400ms, 923200000, 2, 5, lorawan.DwellTimeNoLimit, RXWindow{923200000, 0}, RXWindow{923200000, 2}},
+		}
+
+		for _, tst := range tests {
+			b, err := GetConfig(tst.Name, false, tst.DwellTime)
+			So(err, ShouldBeNil)
+
+			rx1, err := GetRX1Window(b, tst.UplinkFrequency, tst.UplinkDR, tst.RX1DROffset, tst.DownlinkDwellTime)
+			So(err, ShouldBeNil)
+			So(rx1, ShouldResemble, tst.ExpectedRX1)
+			So(GetRX2Window(b), ShouldResemble, tst.ExpectedRX2)
+		}
+	})
+}
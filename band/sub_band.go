@@ -0,0 +1,123 @@
+package band
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lorawan"
+)
+
+// SubBands defines the number of sub-bands of the 64 + 8 channel plans
+// (US915 and AU915). Each sub-band contains eight 125 kHz channels and
+// one 500 kHz channel. Sub-bands are numbered 1 - 8.
+const SubBands = 8
+
+// ErrNoFixedChannelPlan is returned when the sub-band utilities are used
+// with a band not implementing the 64 + 8 channel plan.
+var ErrNoFixedChannelPlan = errors.New("lorawan/band: band does not implement the 64 + 8 channel plan")
+
+// GetSubBandUplinkChannelIndices returns the uplink channel indices of the
+// given sub-band (1 - 8). These are the eight 125 kHz channels and the
+// 500 kHz channel of the sub-band.
+func GetSubBandUplinkChannelIndices(subBand int) ([]int, error) {
+	if subBand < 1 || subBand > SubBands {
+		return nil, fmt.Errorf("lorawan/band: invalid sub-band: %d", subBand)
+	}
+
+	var out []int
+	for i := 0; i < 8; i++ {
+		out = append(out, (subBand-1)*8+i)
+	}
+	out = append(out, 64+subBand-1)
+
+	return out, nil
+}
+
+// GetSubBandForUplinkChannelIndex returns the sub-band (1 - 8) of the given
+// uplink channel index.
+func GetSubBandForUplinkChannelIndex(channel int) (int, error) {
+	switch {
+	case channel >= 0 && channel < 64:
+		return channel/8 + 1, nil
+	case channel >= 64 && channel < 72:
+		return channel - 64 + 1, nil
+	default:
+		return 0, ErrChannelDoesNotExist
+	}
+}
+
+// EnableSubBands enables the uplink channels of the given sub-bands and
+// disables all other uplink channels of the given band.
+func EnableSubBands(b Band, subBands ...int) error {
+	if !isFixedChannelPlan(b) {
+		return ErrNoFixedChannelPlan
+	}
+
+	for _, c := range b.GetUplinkChannelIndices() {
+		if err := b.DisableUplinkChannelIndex(c); err != nil {
+			return errors.Wrap(err, "disable uplink channel error")
+		}
+	}
+
+	for _, sb := range subBands {
+		channels, err := GetSubBandUplinkChannelIndices(sb)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range channels {
+			if err := b.EnableUplinkChannelIndex(c); err != nil {
+				return errors.Wrap(err, "enable uplink channel error")
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetLinkADRReqPayloadsForSubBand returns the LinkADRReq payloads to
+// restrict a device to the given sub-band. The first payload turns off all
+// 125 kHz channels (ChMaskCntl=7) and enables the 500 kHz channel of the
+// sub-band, the second payload enables the 125 kHz channels of the
+// sub-band. The DataRate, TXPower and NbRep fields must be set by the
+// caller, the device only applies these from the last payload.
+func GetLinkADRReqPayloadsForSubBand(subBand int) ([]lorawan.LinkADRReqPayload, error) {
+	if subBand < 1 || subBand > SubBands {
+		return nil, fmt.Errorf("lorawan/band: invalid sub-band: %d", subBand)
+	}
+
+	out := []lorawan.LinkADRReqPayload{
+		{Redundancy: lorawan.Redundancy{ChMaskCntl: 7}},
+		{Redundancy: lorawan.Redundancy{ChMaskCntl: uint8((subBand - 1) / 2)}},
+	}
+
+	out[0].ChMask[subBand-1] = true
+
+	offset := ((subBand - 1) % 2) * 8
+	for i := 0; i < 8; i++ {
+		out[1].ChMask[offset+i] = true
+	}
+
+	return out, nil
+}
+
+// GetRX1ChannelForUplinkChannelIndex returns the RX1 downlink channel
+// for the given uplink channel index.
+func GetRX1ChannelForUplinkChannelIndex(b Band, uplinkChannel int) (Channel, error) {
+	c, err := b.GetRX1ChannelIndexForUplinkChannelIndex(uplinkChannel)
+	if err != nil {
+		return Channel{}, errors.Wrap(err, "get rx1 channel index error")
+	}
+
+	return b.GetDownlinkChannel(c)
+}
+
+func isFixedChannelPlan(b Band) bool {
+	switch b.(type) {
+	case *us902Band, *au915Band:
+		return true
+	default:
+		return false
+	}
+}
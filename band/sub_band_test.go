@@ -0,0 +1,76 @@
+package band
+
+import (
+	"testing"
+
+	"github.com/brocaar/lorawan"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSubBand(t *testing.T) {
+	Convey("Given the US915 band", t, func() {
+		b, err := GetConfig(US915, false, lorawan.DwellTimeNoLimit)
+		So(err, ShouldBeNil)
+
+		Convey("Then GetSubBandUplinkChannelIndices returns the expected channels", func() {
+			c, err := GetSubBandUplinkChannelIndices(2)
+			So(err, ShouldBeNil)
+			So(c, ShouldResemble, []int{8, 9, 10, 11, 12, 13, 14, 15, 65})
+
+			_, err = GetSubBandUplinkChannelIndices(9)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Then GetSubBandForUplinkChannelIndex returns the expected sub-band", func() {
+			for c, sb := range map[int]int{0: 1, 15: 2, 63: 8, 64: 1, 71: 8} {
+				s, err := GetSubBandForUplinkChannelIndex(c)
+				So(err, ShouldBeNil)
+				So(s, ShouldEqual, sb)
+			}
+
+			_, err := GetSubBandForUplinkChannelIndex(72)
+			So(err, ShouldEqual, ErrChannelDoesNotExist)
+		})
+
+		Convey("When enabling sub-band 2", func() {
+			So(EnableSubBands(b, 2), ShouldBeNil)
+
+			Convey("Then only the channels of sub-band 2 are enabled", func() {
+				So(b.GetEnabledUplinkChannelIndices(), ShouldResemble, []int{8, 9, 10, 11, 12, 13, 14, 15, 65})
+			})
+		})
+
+		Convey("Then GetLinkADRReqPayloadsForSubBand returns payloads enabling only the sub-band", func() {
+			for sb := 1; sb <= SubBands; sb++ {
+				pls, err := GetLinkADRReqPayloadsForSubBand(sb)
+				So(err, ShouldBeNil)
+				So(pls, ShouldHaveLength, 2)
+
+				exp, err := GetSubBandUplinkChannelIndices(sb)
+				So(err, ShouldBeNil)
+
+				channels, err := b.GetEnabledUplinkChannelIndicesForLinkADRReqPayloads(b.GetUplinkChannelIndices(), pls)
+				So(err, ShouldBeNil)
+				So(channels, ShouldHaveLength, len(exp))
+				for _, c := range exp {
+					So(channels, ShouldContain, c)
+				}
+			}
+		})
+
+		Convey("Then GetRX1ChannelForUplinkChannelIndex returns the expected channel", func() {
+			c, err := GetRX1ChannelForUplinkChannelIndex(b, 9)
+			So(err, ShouldBeNil)
+			So(c.Frequency, ShouldEqual, 923900000)
+		})
+	})
+
+	Convey("Given the EU868 band", t, func() {
+		b, err := GetConfig(EU868, false, lorawan.DwellTimeNoLimit)
+		So(err, ShouldBeNil)
+
+		Convey("Then EnableSubBands returns an error", func() {
+			So(EnableSubBands(b, 1), ShouldEqual, ErrNoFixedChannelPlan)
+		})
+	})
+}
@@ -0,0 +1,95 @@
+package band
+
+import "time"
+
+// DefaultMaxFCntGap defines the default MAX_FCNT_GAP value.
+const DefaultMaxFCntGap = 16384
+
+// Timing defines the receive-window and join-accept timing parameters.
+// The defaults are returned by GetTiming, and can be overridden per
+// deployment using Override.
+type Timing struct {
+	// ReceiveDelay1 defines the delay between the end of the uplink and
+	// the opening of the RX1 receive window.
+	ReceiveDelay1 time.Duration `json:"receiveDelay1"`
+
+	// ReceiveDelay2 defines the delay between the end of the uplink and
+	// the opening of the RX2 receive window.
+	ReceiveDelay2 time.Duration `json:"receiveDelay2"`
+
+	// JoinAcceptDelay1 defines the delay between the end of the join-request
+	// and the opening of the RX1 receive window.
+	JoinAcceptDelay1 time.Duration `json:"joinAcceptDelay1"`
+
+	// JoinAcceptDelay2 defines the delay between the end of the join-request
+	// and the opening of the RX2 receive window.
+	JoinAcceptDelay2 time.Duration `json:"joinAcceptDelay2"`
+
+	// MaxFCntGap defines the maximum allowed gap between the expected and
+	// received frame-counter.
+	MaxFCntGap uint32 `json:"maxFCntGap"`
+}
+
+// GetTiming returns the default timing parameters of the given band.
+func GetTiming(b Band) Timing {
+	d := b.GetDefaults()
+	return Timing{
+		ReceiveDelay1:    d.ReceiveDelay1,
+		ReceiveDelay2:    d.ReceiveDelay2,
+		JoinAcceptDelay1: d.JoinAcceptDelay1,
+		JoinAcceptDelay2: d.JoinAcceptDelay2,
+		MaxFCntGap:       DefaultMaxFCntGap,
+	}
+}
+
+// Override returns a copy of the timing parameters, with the non-zero
+// values of o applied.
+func (t Timing) Override(o Timing) Timing {
+	if o.ReceiveDelay1 != 0 {
+		t.ReceiveDelay1 = o.ReceiveDelay1
+	}
+	if o.ReceiveDelay2 != 0 {
+		t.ReceiveDelay2 = o.ReceiveDelay2
+	}
+	if o.JoinAcceptDelay1 != 0 {
+		t.JoinAcceptDelay1 = o.JoinAcceptDelay1
+	}
+	if o.JoinAcceptDelay2 != 0 {
+		t.JoinAcceptDelay2 = o.JoinAcceptDelay2
+	}
+	if o.MaxFCntGap != 0 {
+		t.MaxFCntGap = o.MaxFCntGap
+	}
+	return t
+}
+
+// WithRXTimingSetup returns a copy of the timing parameters, with the
+// receive delays set according to the Delay field of the RXTimingSetupReq
+// mac-command. A delay of 0 equals to 1 second. RX2 opens one second
+// after RX1.
+func (t Timing) WithRXTimingSetup(delay uint8) Timing {
+	if delay == 0 {
+		delay = 1
+	}
+	t.ReceiveDelay1 = time.Duration(delay) * time.Second
+	t.ReceiveDelay2 = t.ReceiveDelay1 + time.Second
+	return t
+}
+
+// RX1 returns the opening time of the RX1 receive window, given the end
+// time of the uplink. For join-requests, joinRequest must be set to true.
+func (t Timing) RX1(uplinkEnd time.Time, joinRequest bool) time.Time {
+	if joinRequest {
+		return uplinkEnd.Add(t.JoinAcceptDelay1)
+	}
+	return uplinkEnd.Add(t.ReceiveDelay1)
+}
+
+// RX2 returns the opening time of the RX2 receive window, given the end
+// time of the uplink. For join-requests, joinRequest must be set to true.
+func (t Timing) RX2(uplinkEnd time.Time, joinRequest bool) time.Time {
+	if joinRequest {
+		return uplinkEnd.Add(t.JoinAcceptDelay2)
+	}
+	return uplinkEnd.Add(t.ReceiveDelay2)
+}
@@ -0,0 +1,60 @@
+package band
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brocaar/lorawan"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTiming(t *testing.T) {
+	Convey("Given the EU868 band", t, func() {
+		b, err := GetConfig(EU868, false, lorawan.DwellTimeNoLimit)
+		So(err, ShouldBeNil)
+
+		Convey("Then GetTiming returns the band defaults", func() {
+			So(GetTiming(b), ShouldResemble, Timing{
+				ReceiveDelay1:    time.Second,
+				ReceiveDelay2:    2 * time.Second,
+				JoinAcceptDelay1: 5 * time.Second,
+				JoinAcceptDelay2: 6 * time.Second,
+				MaxFCntGap:       DefaultMaxFCntGap,
+			})
+		})
+
+		Convey("Then Override only overrides the non-zero values", func() {
+			timing := GetTiming(b).Override(Timing{
+				ReceiveDelay1: 3 * time.Second,
+				ReceiveDelay2: 4 * time.Second,
+			})
+			So(timing, ShouldResemble, Timing{
+				ReceiveDelay1:    3 * time.Second,
+				ReceiveDelay2:    4 * time.Second,
+				JoinAcceptDelay1: 5 * time.Second,
+				JoinAcceptDelay2: 6 * time.Second,
+				MaxFCntGap:       DefaultMaxFCntGap,
+			})
+		})
+
+		Convey("Then WithRXTimingSetup sets the receive delays", func() {
+			timing := GetTiming(b).WithRXTimingSetup(0)
+			So(timing.ReceiveDelay1, ShouldEqual, time.Second)
+			So(timing.ReceiveDelay2, ShouldEqual, 2*time.Second)
+
+			timing = GetTiming(b).WithRXTimingSetup(5)
+			So(timing.ReceiveDelay1, ShouldEqual, 5*time.Second)
+			So(timing.ReceiveDelay2, ShouldEqual, 6*time.Second)
+		})
+
+		Convey("Then RX1 and RX2 return the receive window opening times", func() {
+			timing := GetTiming(b)
+			now := time.Now()
+
+			So(timing.RX1(now, false), ShouldEqual, now.Add(time.Second))
+			So(timing.RX2(now, false), ShouldEqual, now.Add(2*time.Second))
+			So(timing.RX1(now, true), ShouldEqual, now.Add(5*time.Second))
+			So(timing.RX2(now, true), ShouldEqual, now.Add(6*time.Second))
+		})
+	})
+}
@@ -0,0 +1,38 @@
+package band
+
+import (
+	"errors"
+	"fmt"
+)
+
+// GetTXPowerEIRP returns the EIRP (dBm) for the given TXPower index, as
+// used by the LinkADRReq mac-command. The EIRP is relative to the default
+// max uplink EIRP of the band (see Band.GetDefaultMaxUplinkEIRP).
+func GetTXPowerEIRP(b Band, txPower int) (float32, error) {
+	if txPower < 0 {
+		return 0, fmt.Errorf("lorawan/band: invalid tx-power: %d", txPower)
+	}
+
+	offset, err := b.GetTXPowerOffset(txPower)
+	if err != nil {
+		return 0, err
+	}
+
+	return b.GetDefaultMaxUplinkEIRP() + float32(offset), nil
+}
+
+// GetTXPowerIndexForEIRP returns the TXPower index resulting in the highest
+// EIRP which does not exceed the given EIRP (dBm). It returns an error when
+// the given EIRP is below the EIRP of the lowest TXPower index.
+func GetTXPowerIndexForEIRP(b Band, eirp float32) (int, error) {
+	for i := 0; ; i++ {
+		e, err := GetTXPowerEIRP(b, i)
+		if err != nil {
+			return 0, errors.New("lorawan/band: eirp is below the minimum tx-power")
+		}
+
+		if e <= eirp {
+			return i, nil
+		}
+	}
+}
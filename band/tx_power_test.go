@@ -0,0 +1,81 @@
+package band
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/brocaar/lorawan"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTXPowerEIRP(t *testing.T) {
+	Convey("Given a set of tests", t, func() {
+		tests := []struct {
+			Name    Name
+			TXPower int
+			EIRP    float32
+			Error   bool
+		}{
+			{EU868, 0, 16, false},
+			{EU868, 7, 2, false},
+			{EU868, 8, 0, true},
+			{EU868, -1, 0, true},
+			{US915, 0, 30, false},
+			{US915, 10, 10, false},
+			{US915, 14, 2, false},
+			{US915, 15, 0, true},
+			{AS923, 5, 6, false},
+		}
+
+		for i, test := range tests {
+			Convey(fmt.Sprintf("Testing %s TXPower %d [%d]", test.Name, test.TXPower, i), func() {
+				b, err := GetConfig(test.Name, false, lorawan.DwellTimeNoLimit)
+				So(err, ShouldBeNil)
+
+				eirp, err := GetTXPowerEIRP(b, test.TXPower)
+				if test.Error {
+					So(err, ShouldNotBeNil)
+					return
+				}
+				So(err, ShouldBeNil)
+				So(eirp, ShouldEqual, test.EIRP)
+
+				Convey("Then GetTXPowerIndexForEIRP returns the TXPower index", func() {
+					txPower, err := GetTXPowerIndexForEIRP(b, eirp)
+					So(err, ShouldBeNil)
+					So(txPower, ShouldEqual, test.TXPower)
+				})
+			})
+		}
+	})
+
+	Convey("Given the US915 band for Regional Parameters revision B", t, func() {
+		b, err := GetConfigForRevision(US915, false, lorawan.DwellTimeNoLimit, LoRaWAN_1_0_2, RegParamRevB)
+		So(err, ShouldBeNil)
+
+		Convey("Then TXPower 10 is the highest TXPower index", func() {
+			eirp, err := GetTXPowerEIRP(b, 10)
+			So(err, ShouldBeNil)
+			So(eirp, ShouldEqual, 10)
+
+			_, err = GetTXPowerEIRP(b, 11)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given the EU868 band", t, func() {
+		b, err := GetConfig(EU868, false, lorawan.DwellTimeNoLimit)
+		So(err, ShouldBeNil)
+
+		Convey("Then GetTXPowerIndexForEIRP rounds down to the next TXPower index", func() {
+			txPower, err := GetTXPowerIndexForEIRP(b, 13)
+			So(err, ShouldBeNil)
+			So(txPower, ShouldEqual, 2)
+		})
+
+		Convey("Then GetTXPowerIndexForEIRP returns an error when below the minimum", func() {
+			_, err := GetTXPowerIndexForEIRP(b, 1)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,184 @@
+package lorawan
+
+import (
+	"fmt"
+	"testing"
+)
+
+func benchmarkDataPHYPayload() PHYPayload {
+	fPort := uint8(10)
+	return PHYPayload{
+		MHDR: MHDR{
+			MType: UnconfirmedDataUp,
+			Major: LoRaWANR1,
+		},
+		MACPayload: &MACPayload{
+			FHDR: FHDR{
+				DevAddr: DevAddr{1, 2, 3, 4},
+				FCtrl: FCtrl{
+					ADR: true,
+				},
+				FCnt: 10,
+				FOpts: []Payload{
+					&MACCommand{
+						CID: DevStatusAns,
+						Payload: &DevStatusAnsPayload{
+							Battery: 115,
+							Margin:  7,
+						},
+					},
+				},
+			},
+			FPort:      &fPort,
+			FRMPayload: []Payload{&DataPayload{Bytes: make([]byte, 51)}},
+		},
+	}
+}
+
+func BenchmarkPHYPayloadMarshalBinary(b *testing.B) {
+	phy := benchmarkDataPHYPayload()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := phy.MarshalBinary(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPHYPayloadAppendBinary(b *testing.B) {
+	phy := benchmarkDataPHYPayload()
+	buf := make([]byte, 0, 255)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var err error
+		if buf, err = phy.AppendBinary(buf[:0]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPHYPayloadUnmarshalBinary(b *testing.B) {
+	phy := benchmarkDataPHYPayload()
+	data, err := phy.MarshalBinary()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var phy PHYPayload
+		if err := phy.UnmarshalBinary(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPHYPayloadMarshalJSON(b *testing.B) {
+	phy := benchmarkDataPHYPayload()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := phy.MarshalJSON(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSetUplinkDataMIC(b *testing.B) {
+	for _, macVersion := range []MACVersion{LoRaWAN1_0, LoRaWAN1_1} {
+		b.Run(fmt.Sprintf("LoRaWAN1_%d", macVersion), func(b *testing.B) {
+			phy := benchmarkDataPHYPayload()
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if err := phy.SetUplinkDataMIC(macVersion, 0, 5, 1, AES128Key{1}, AES128Key{2}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSetDownlinkDataMIC(b *testing.B) {
+	phy := benchmarkDataPHYPayload()
+	phy.MHDR.MType = UnconfirmedDataDown
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := phy.SetDownlinkDataMIC(LoRaWAN1_0, 0, AES128Key{1}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSetUplinkJoinMIC(b *testing.B) {
+	phy := PHYPayload{
+		MHDR: MHDR{
+			MType: JoinRequest,
+			Major: LoRaWANR1,
+		},
+		MACPayload: &JoinRequestPayload{
+			JoinEUI:  EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+			DevEUI:   EUI64{8, 7, 6, 5, 4, 3, 2, 1},
+			DevNonce: 258,
+		},
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := phy.SetUplinkJoinMIC(AES128Key{1}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncryptFRMPayload(b *testing.B) {
+	for _, size := range []int{11, 51, 242} {
+		b.Run(fmt.Sprintf("%d bytes", size), func(b *testing.B) {
+			data := make([]byte, size)
+			b.ReportAllocs()
+			b.SetBytes(int64(size))
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := EncryptFRMPayload(AES128Key{1}, true, DevAddr{1, 2, 3, 4}, uint32(i), data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkEncryptJoinAcceptPayload(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		phy := PHYPayload{
+			MHDR: MHDR{
+				MType: JoinAccept,
+				Major: LoRaWANR1,
+			},
+			MACPayload: &JoinAcceptPayload{
+				JoinNonce: 65793,
+				HomeNetID: NetID{2, 2, 2},
+				DevAddr:   DevAddr{1, 2, 3, 4},
+			},
+		}
+
+		if err := phy.SetDownlinkJoinMIC(JoinRequestType, EUI64{}, 0, AES128Key{1}); err != nil {
+			b.Fatal(err)
+		}
+		if err := phy.EncryptJoinAcceptPayload(AES128Key{1}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
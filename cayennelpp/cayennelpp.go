@@ -0,0 +1,475 @@
+// Package cayennelpp implements the Cayenne Low Power Payload (LPP) format,
+// including the extended data types.
+//
+// A payload consists of one or multiple measurements, each encoded as:
+//
+//	<Channel (1 byte)><Type (1 byte)><Value (N bytes)>
+//
+// All multi-byte values are encoded as MSB first.
+package cayennelpp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Type defines the LPP data type.
+type Type byte
+
+// Available data types.
+const (
+	DigitalInputType  Type = 0
+	DigitalOutputType Type = 1
+	AnalogInputType   Type = 2
+	AnalogOutputType  Type = 3
+	GenericSensorType Type = 100
+	IlluminanceType   Type = 101
+	PresenceType      Type = 102
+	TemperatureType   Type = 103
+	HumidityType      Type = 104
+	AccelerometerType Type = 113
+	BarometerType     Type = 115
+	VoltageType       Type = 116
+	CurrentType       Type = 117
+	FrequencyType     Type = 118
+	PercentageType    Type = 120
+	AltitudeType      Type = 121
+	ConcentrationType Type = 125
+	PowerType         Type = 128
+	DistanceType      Type = 130
+	EnergyType        Type = 131
+	DirectionType     Type = 132
+	UnixTimeType      Type = 133
+	GyrometerType     Type = 134
+	ColourType        Type = 135
+	GPSLocationType   Type = 136
+	SwitchType        Type = 142
+)
+
+// ErrUnknownType is returned when decoding an unknown data type.
+var ErrUnknownType = errors.New("lorawan/cayennelpp: unknown data type")
+
+// Value defines the interface of a measurement value.
+type Value interface {
+	Type() Type
+}
+
+// scalar is implemented by the single-value types.
+type scalar interface {
+	Value
+	float() float64
+}
+
+// scalarDef defines the encoding of a single-value type.
+type scalarDef struct {
+	size    int
+	signed  bool
+	divisor float64
+	new     func(float64) Value
+}
+
+var scalarDefs = map[Type]scalarDef{
+	DigitalInputType:  {1, false, 1, func(f float64) Value { return DigitalInput(f) }},
+	DigitalOutputType: {1, false, 1, func(f float64) Value { return DigitalOutput(f) }},
+	AnalogInputType:   {2, true, 100, func(f float64) Value { return AnalogInput(f) }},
+	AnalogOutputType:  {2, true, 100, func(f float64) Value { return AnalogOutput(f) }},
+	GenericSensorType: {4, false, 1, func(f float64) Value { return GenericSensor(f) }},
+	IlluminanceType:   {2, false, 1, func(f float64) Value { return Illuminance(f) }},
+	PresenceType:      {1, false, 1, func(f float64) Value { return Presence(f) }},
+	TemperatureType:   {2, true, 10, func(f float64) Value { return Temperature(f) }},
+	HumidityType:      {1, false, 2, func(f float64) Value { return Humidity(f) }},
+	BarometerType:     {2, false, 10, func(f float64) Value { return Barometer(f) }},
+	VoltageType:       {2, false, 100, func(f float64) Value { return Voltage(f) }},
+	CurrentType:       {2, false, 1000, func(f float64) Value { return Current(f) }},
+	FrequencyType:     {4, false, 1, func(f float64) Value { return Frequency(f) }},
+	PercentageType:    {1, false, 1, func(f float64) Value { return Percentage(f) }},
+	AltitudeType:      {2, true, 1, func(f float64) Value { return Altitude(f) }},
+	ConcentrationType: {2, false, 1, func(f float64) Value { return Concentration(f) }},
+	PowerType:         {2, false, 1, func(f float64) Value { return Power(f) }},
+	DistanceType:      {4, false, 1000, func(f float64) Value { return Distance(f) }},
+	EnergyType:        {4, false, 1000, func(f float64) Value { return Energy(f) }},
+	DirectionType:     {2, false, 1, func(f float64) Value { return Direction(f) }},
+	UnixTimeType:      {4, false, 1, func(f float64) Value { return UnixTime(f) }},
+	SwitchType:        {1, false, 1, func(f float64) Value { return Switch(f) }},
+}
+
+// DigitalInput implements the digital input type.
+type DigitalInput uint8
+
+// DigitalOutput implements the digital output type.
+type DigitalOutput uint8
+
+// AnalogInput implements the analog input type (0.01 signed).
+type AnalogInput float64
+
+// AnalogOutput implements the analog output type (0.01 signed).
+type AnalogOutput float64
+
+// GenericSensor implements the generic sensor type.
+type GenericSensor uint32
+
+// Illuminance implements the illuminance type (lux).
+type Illuminance uint16
+
+// Presence implements the presence type.
+type Presence uint8
+
+// Temperature implements the temperature type (°C).
+type Temperature float64
+
+// Humidity implements the relative humidity type (%).
+type Humidity float64
+
+// Barometer implements the barometric pressure type (hPa).
+type Barometer float64
+
+// Voltage implements the voltage type (V).
+type Voltage float64
+
+// Current implements the current type (A).
+type Current float64
+
+// Frequency implements the frequency type (Hz).
+type Frequency uint32
+
+// Percentage implements the percentage type (%).
+type Percentage uint8
+
+// Altitude implements the altitude type (m).
+type Altitude int16
+
+// Concentration implements the concentration type (ppm).
+type Concentration uint16
+
+// Power implements the power type (W).
+type Power uint16
+
+// Distance implements the distance type (m).
+type Distance float64
+
+// Energy implements the energy type (kWh).
+type Energy float64
+
+// Direction implements the direction type (degrees).
+type Direction uint16
+
+// UnixTime implements the unix time type (seconds since Unix epoch).
+type UnixTime uint32
+
+// Switch implements the switch type (0 = off, 1 = on).
+type Switch uint8
+
+// Type returns the data type.
+func (v DigitalInput) Type() Type { return DigitalInputType }
+
+// Type returns the data type.
+func (v DigitalOutput) Type() Type { return DigitalOutputType }
+
+// Type returns the data type.
+func (v AnalogInput) Type() Type { return AnalogInputType }
+
+// Type returns the data type.
+func (v AnalogOutput) Type() Type { return AnalogOutputType }
+
+// Type returns the data type.
+func (v GenericSensor) Type() Type { return GenericSensorType }
+
+// Type returns the data type.
+func (v Illuminance) Type() Type { return IlluminanceType }
+
+// Type returns the data type.
+func (v Presence) Type() Type { return PresenceType }
+
+// Type returns the data type.
+func (v Temperature) Type() Type { return TemperatureType }
+
+// Type returns the data type.
+func (v Humidity) Type() Type { return HumidityType }
+
+// Type returns the data type.
+func (v Barometer) Type() Type { return BarometerType }
+
+// Type returns the data type.
+func (v Voltage) Type() Type { return VoltageType }
+
+// Type returns the data type.
+func (v Current) Type() Type { return CurrentType }
+
+// Type returns the data type.
+func (v Frequency) Type() Type { return FrequencyType }
+
+// Type returns the data type.
+func (v Percentage) Type() Type { return PercentageType }
+
+// Type returns the data type.
+func (v Altitude) Type() Type { return AltitudeType }
+
+// Type returns the data type.
+func (v Concentration) Type() Type { return ConcentrationType }
+
+// Type returns the data type.
+func (v Power) Type() Type { return PowerType }
+
+// Type returns the data type.
+func (v Distance) Type() Type { return DistanceType }
+
+// Type returns the data type.
+func (v Energy) Type() Type { return EnergyType }
+
+// Type returns the data type.
+func (v Direction) Type() Type { return DirectionType }
+
+// Type returns the data type.
+func (v UnixTime) Type() Type { return UnixTimeType }
+
+// Type returns the data type.
+func (v Switch) Type() Type { return SwitchType }
+
+func (v DigitalInput) float() float64  { return float64(v) }
+func (v DigitalOutput) float() float64 { return float64(v) }
+func (v AnalogInput) float() float64   { return float64(v) }
+func (v AnalogOutput) float() float64  { return float64(v) }
+func (v GenericSensor) float() float64 { return float64(v) }
+func (v Illuminance) float() float64   { return float64(v) }
+func (v Presence) float() float64      { return float64(v) }
+func (v Temperature) float() float64   { return float64(v) }
+func (v Humidity) float() float64      { return float64(v) }
+func (v Barometer) float() float64     { return float64(v) }
+func (v Voltage) float() float64       { return float64(v) }
+func (v Current) float() float64       { return float64(v) }
+func (v Frequency) float() float64     { return float64(v) }
+func (v Percentage) float() float64    { return float64(v) }
+func (v Altitude) float() float64      { return float64(v) }
+func (v Concentration) float() float64 { return float64(v) }
+func (v Power) float() float64         { return float64(v) }
+func (v Distance) float() float64      { return float64(v) }
+func (v Energy) float() float64        { return float64(v) }
+func (v Direction) float() float64     { return float64(v) }
+func (v UnixTime) float() float64      { return float64(v) }
+func (v Switch) float() float64        { return float64(v) }
+
+// Accelerometer implements the accelerometer type (G per axis).
+type Accelerometer struct {
+	X float64
+	Y float64
+	Z float64
+}
+
+// Type returns the data type.
+func (v Accelerometer) Type() Type { return AccelerometerType }
+
+// Gyrometer implements the gyrometer type (°/s per axis).
+type Gyrometer struct {
+	X float64
+	Y float64
+	Z float64
+}
+
+// Type returns the data type.
+func (v Gyrometer) Type() Type { return GyrometerType }
+
+// Colour implements the RGB colour type.
+type Colour struct {
+	R uint8
+	G uint8
+	B uint8
+}
+
+// Type returns the data type.
+func (v Colour) Type() Type { return ColourType }
+
+// GPSLocation implements the GPS location type.
+type GPSLocation struct {
+	Latitude  float64 // degrees
+	Longitude float64 // degrees
+	Altitude  float64 // meters
+}
+
+// Type returns the data type.
+func (v GPSLocation) Type() Type { return GPSLocationType }
+
+// Measurement contains a single measurement.
+type Measurement struct {
+	Channel uint8
+	Value   Value
+}
+
+// Payload contains a list of measurements.
+type Payload []Measurement
+
+// MarshalBinary encodes the payload to a slice of bytes.
+func (p Payload) MarshalBinary() ([]byte, error) {
+	var out []byte
+
+	for _, m := range p {
+		if m.Value == nil {
+			return nil, fmt.Errorf("lorawan/cayennelpp: value of channel %d must not be nil", m.Channel)
+		}
+
+		b, err := marshalValue(m.Value)
+		if err != nil {
+			return nil, fmt.Errorf("lorawan/cayennelpp: channel %d: %w", m.Channel, err)
+		}
+
+		out = append(out, m.Channel, byte(m.Value.Type()))
+		out = append(out, b...)
+	}
+
+	return out, nil
+}
+
+// UnmarshalBinary decodes the payload from a slice of bytes.
+func (p *Payload) UnmarshalBinary(data []byte) error {
+	var out Payload
+
+	for len(data) != 0 {
+		if len(data) < 2 {
+			return errors.New("lorawan/cayennelpp: at least 2 bytes are expected")
+		}
+
+		channel := data[0]
+		t := Type(data[1])
+		data = data[2:]
+
+		size, err := valueSize(t)
+		if err != nil {
+			return err
+		}
+		if len(data) < size {
+			return fmt.Errorf("lorawan/cayennelpp: %d bytes are expected for type %d", size, t)
+		}
+
+		v, err := unmarshalValue(t, data[:size])
+		if err != nil {
+			return err
+		}
+		data = data[size:]
+
+		out = append(out, Measurement{Channel: channel, Value: v})
+	}
+
+	*p = out
+	return nil
+}
+
+func valueSize(t Type) (int, error) {
+	if def, ok := scalarDefs[t]; ok {
+		return def.size, nil
+	}
+
+	switch t {
+	case AccelerometerType, GyrometerType:
+		return 6, nil
+	case ColourType:
+		return 3, nil
+	case GPSLocationType:
+		return 9, nil
+	default:
+		return 0, ErrUnknownType
+	}
+}
+
+func marshalValue(v Value) ([]byte, error) {
+	switch v := v.(type) {
+	case scalar:
+		def := scalarDefs[v.Type()]
+		b := make([]byte, def.size)
+		if err := putInt(b, v.float(), def.divisor, def.signed); err != nil {
+			return nil, err
+		}
+		return b, nil
+	case Accelerometer:
+		return putInts(2, true, []float64{v.X, v.Y, v.Z}, []float64{1000, 1000, 1000})
+	case Gyrometer:
+		return putInts(2, true, []float64{v.X, v.Y, v.Z}, []float64{100, 100, 100})
+	case Colour:
+		return []byte{v.R, v.G, v.B}, nil
+	case GPSLocation:
+		return putInts(3, true, []float64{v.Latitude, v.Longitude, v.Altitude}, []float64{10000, 10000, 100})
+	default:
+		return nil, ErrUnknownType
+	}
+}
+
+func unmarshalValue(t Type, b []byte) (Value, error) {
+	if def, ok := scalarDefs[t]; ok {
+		return def.new(getInt(b, def.divisor, def.signed)), nil
+	}
+
+	switch t {
+	case AccelerometerType:
+		return Accelerometer{
+			X: getInt(b[0:2], 1000, true),
+			Y: getInt(b[2:4], 1000, true),
+			Z: getInt(b[4:6], 1000, true),
+		}, nil
+	case GyrometerType:
+		return Gyrometer{
+			X: getInt(b[0:2], 100, true),
+			Y: getInt(b[2:4], 100, true),
+			Z: getInt(b[4:6], 100, true),
+		}, nil
+	case ColourType:
+		return Colour{R: b[0], G: b[1], B: b[2]}, nil
+	case GPSLocationType:
+		return GPSLocation{
+			Latitude:  getInt(b[0:3], 10000, true),
+			Longitude: getInt(b[3:6], 10000, true),
+			Altitude:  getInt(b[6:9], 100, true),
+		}, nil
+	default:
+		return nil, ErrUnknownType
+	}
+}
+
+func putInts(size int, signed bool, values, divisors []float64) ([]byte, error) {
+	b := make([]byte, size*len(values))
+	for i := range values {
+		if err := putInt(b[i*size:(i+1)*size], values[i], divisors[i], signed); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// putInt encodes the value multiplied by the given divisor as MSB first
+// integer, using the length of b as size.
+func putInt(b []byte, value, divisor float64, signed bool) error {
+	bits := uint(len(b) * 8)
+	i := int64(math.Round(value * divisor))
+
+	var min, max int64
+	if signed {
+		min, max = -(1 << (bits - 1)), (1<<(bits-1))-1
+	} else {
+		min, max = 0, (1<<bits)-1
+	}
+	if i < min || i > max {
+		return fmt.Errorf("value %v out of range", value)
+	}
+
+	u := uint64(i)
+	for n := len(b) - 1; n >= 0; n-- {
+		b[n] = byte(u)
+		u >>= 8
+	}
+
+	return nil
+}
+
+// getInt decodes the MSB first integer and returns it divided by the given
+// divisor.
+func getInt(b []byte, divisor float64, signed bool) float64 {
+	var u uint64
+	for _, v := range b {
+		u = u<<8 | uint64(v)
+	}
+
+	bits := uint(len(b) * 8)
+	if signed && u&(1<<(bits-1)) != 0 {
+		return float64(int64(u)-(1<<bits)) / divisor
+	}
+
+	return float64(u) / divisor
+}
@@ -0,0 +1,115 @@
+package cayennelpp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayload(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Payload Payload
+		Bytes   []byte
+	}{
+		{
+			Name: "temperature and temperature",
+			Payload: Payload{
+				{Channel: 3, Value: Temperature(27.2)},
+				{Channel: 5, Value: Temperature(25.5)},
+			},
+			Bytes: []byte{0x03, 0x67, 0x01, 0x10, 0x05, 0x67, 0x00, 0xff},
+		},
+		{
+			Name: "digital output and negative temperature",
+			Payload: Payload{
+				{Channel: 1, Value: DigitalOutput(255)},
+				{Channel: 2, Value: Temperature(-4.1)},
+			},
+			Bytes: []byte{0x01, 0x01, 0xff, 0x02, 0x67, 0xff, 0xd7},
+		},
+		{
+			Name: "accelerometer",
+			Payload: Payload{
+				{Channel: 6, Value: Accelerometer{X: 1.234, Y: -1.234, Z: 0}},
+			},
+			Bytes: []byte{0x06, 0x71, 0x04, 0xd2, 0xfb, 0x2e, 0x00, 0x00},
+		},
+		{
+			Name: "gps location",
+			Payload: Payload{
+				{Channel: 1, Value: GPSLocation{Latitude: 42.3519, Longitude: -87.9094, Altitude: 10}},
+			},
+			Bytes: []byte{0x01, 0x88, 0x06, 0x76, 0x5f, 0xf2, 0x96, 0x0a, 0x00, 0x03, 0xe8},
+		},
+		{
+			Name: "extended types",
+			Payload: Payload{
+				{Channel: 1, Value: Voltage(3.3)},
+				{Channel: 2, Value: Current(1.5)},
+				{Channel: 3, Value: Frequency(50)},
+				{Channel: 4, Value: Percentage(80)},
+				{Channel: 5, Value: Altitude(-12)},
+				{Channel: 6, Value: Distance(12.345)},
+				{Channel: 7, Value: UnixTime(1600000000)},
+				{Channel: 8, Value: Colour{R: 1, G: 2, B: 3}},
+				{Channel: 9, Value: Switch(1)},
+				{Channel: 10, Value: Gyrometer{X: 1.5, Y: -1.5, Z: 0.01}},
+			},
+			Bytes: []byte{
+				0x01, 0x74, 0x01, 0x4a,
+				0x02, 0x75, 0x05, 0xdc,
+				0x03, 0x76, 0x00, 0x00, 0x00, 0x32,
+				0x04, 0x78, 0x50,
+				0x05, 0x79, 0xff, 0xf4,
+				0x06, 0x82, 0x00, 0x00, 0x30, 0x39,
+				0x07, 0x85, 0x5f, 0x5e, 0x10, 0x00,
+				0x08, 0x87, 0x01, 0x02, 0x03,
+				0x09, 0x8e, 0x01,
+				0x0a, 0x86, 0x00, 0x96, 0xff, 0x6a, 0x00, 0x01,
+			},
+		},
+		{
+			Name: "humidity, illuminance, presence and barometer",
+			Payload: Payload{
+				{Channel: 1, Value: Humidity(48.5)},
+				{Channel: 2, Value: Illuminance(1000)},
+				{Channel: 3, Value: Presence(1)},
+				{Channel: 4, Value: Barometer(1013.2)},
+			},
+			Bytes: []byte{0x01, 0x68, 0x61, 0x02, 0x65, 0x03, 0xe8, 0x03, 0x66, 0x01, 0x04, 0x73, 0x27, 0x94},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			b, err := tst.Payload.MarshalBinary()
+			assert.NoError(err)
+			assert.Equal(tst.Bytes, b)
+
+			var pl Payload
+			assert.NoError(pl.UnmarshalBinary(tst.Bytes))
+			assert.Equal(tst.Payload, pl)
+		})
+	}
+}
+
+func TestPayloadErrors(t *testing.T) {
+	assert := require.New(t)
+
+	_, err := Payload{{Channel: 1, Value: Temperature(4000)}}.MarshalBinary()
+	assert.Error(err)
+
+	_, err = Payload{{Channel: 1, Value: Voltage(-1)}}.MarshalBinary()
+	assert.Error(err)
+
+	_, err = Payload{{Channel: 1}}.MarshalBinary()
+	assert.Error(err)
+
+	var pl Payload
+	assert.Equal(ErrUnknownType, pl.UnmarshalBinary([]byte{0x01, 0xff, 0x00}))
+	assert.Error(pl.UnmarshalBinary([]byte{0x01}))
+	assert.Error(pl.UnmarshalBinary([]byte{0x01, 0x67, 0x01}))
+}
@@ -0,0 +1,109 @@
+package lorawan
+
+import (
+	"hash"
+	"sync"
+
+	"github.com/jacobsa/crypto/cmac"
+)
+
+// CMACCache caches the CMAC state (AES key schedule and K1 / K2 subkeys)
+// by key, so that repeated MIC computations using the same session key
+// do not need to re-compute these. It is safe for concurrent use.
+//
+// The number of cached keys is bounded by the size given to NewCMACCache.
+// When the limit is reached, a random cached key is evicted. Unlike LRU
+// eviction, this does not require write-locking the cache on every hit.
+type CMACCache struct {
+	size int
+
+	mu    sync.RWMutex
+	pools map[AES128Key]*sync.Pool
+}
+
+// NewCMACCache creates a new CMACCache holding the state of at most size
+// keys. A size of 0 means that the cache is unbounded.
+func NewCMACCache(size int) *CMACCache {
+	return &CMACCache{
+		size:  size,
+		pools: make(map[AES128Key]*sync.Pool),
+	}
+}
+
+// Len returns the number of cached keys.
+func (c *CMACCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.pools)
+}
+
+// Flush removes all the cached keys.
+func (c *CMACCache) Flush() {
+	c.mu.Lock()
+	c.pools = make(map[AES128Key]*sync.Pool)
+	c.mu.Unlock()
+}
+
+func (c *CMACCache) getPool(key AES128Key) *sync.Pool {
+	c.mu.RLock()
+	p, ok := c.pools[key]
+	c.mu.RUnlock()
+	if ok {
+		return p
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if p, ok := c.pools[key]; ok {
+		return p
+	}
+
+	if c.size > 0 && len(c.pools) >= c.size {
+		// the map iteration order is random
+		for k := range c.pools {
+			delete(c.pools, k)
+			break
+		}
+	}
+
+	p = &sync.Pool{}
+	c.pools[key] = p
+	return p
+}
+
+// sum returns the CMAC of the given data using the given key. When the
+// CMACCache is nil, a new CMAC state is created.
+func (c *CMACCache) sum(key AES128Key, data ...[]byte) ([]byte, error) {
+	var h hash.Hash
+	var pool *sync.Pool
+
+	if c != nil {
+		pool = c.getPool(key)
+		if v := pool.Get(); v != nil {
+			h = v.(hash.Hash)
+			h.Reset()
+		}
+	}
+
+	if h == nil {
+		var err error
+		h, err = cmac.New(key[:])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, b := range data {
+		if _, err := h.Write(b); err != nil {
+			return nil, err
+		}
+	}
+	out := h.Sum([]byte{})
+
+	if pool != nil {
+		pool.Put(h)
+	}
+
+	return out, nil
+}
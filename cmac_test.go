@@ -0,0 +1,109 @@
+package lorawan
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCMACCache(t *testing.T) {
+	t.Run("Sum matches uncached", func(t *testing.T) {
+		assert := require.New(t)
+
+		c := NewCMACCache(0)
+		key := AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8}
+
+		exp, err := (*CMACCache)(nil).sum(key, []byte{1, 2, 3}, []byte{4, 5})
+		assert.NoError(err)
+
+		for i := 0; i < 3; i++ {
+			b, err := c.sum(key, []byte{1, 2, 3}, []byte{4, 5})
+			assert.NoError(err)
+			assert.Equal(exp, b)
+		}
+		assert.Equal(1, c.Len())
+
+		c.Flush()
+		assert.Equal(0, c.Len())
+	})
+
+	t.Run("Size limit", func(t *testing.T) {
+		assert := require.New(t)
+
+		c := NewCMACCache(2)
+		for i := 0; i < 3; i++ {
+			_, err := c.sum(AES128Key{byte(i)}, []byte{1})
+			assert.NoError(err)
+		}
+		assert.Equal(2, c.Len())
+
+		// the last added key is never evicted
+		c.mu.RLock()
+		_, ok := c.pools[AES128Key{2}]
+		c.mu.RUnlock()
+		assert.True(ok)
+	})
+
+	t.Run("Concurrent use", func(t *testing.T) {
+		assert := require.New(t)
+
+		c := NewCMACCache(0)
+		key := AES128Key{1}
+		exp, err := c.sum(key, []byte("hello"))
+		assert.NoError(err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					b, err := c.sum(key, []byte("hello"))
+					if err != nil || string(b) != string(exp) {
+						t.Error("unexpected cmac")
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("SessionContext", func(t *testing.T) {
+		assert := require.New(t)
+
+		var fPort1 uint8 = 1
+		s := SessionContext{
+			MACVersion:  LoRaWAN1_1,
+			DevAddr:     DevAddr{1, 2, 3, 4},
+			SNwkSIntKey: AES128Key{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2},
+			FNwkSIntKey: AES128Key{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 3},
+			CMACCache:   NewCMACCache(0),
+		}
+
+		phy := PHYPayload{
+			MHDR: MHDR{
+				MType: UnconfirmedDataUp,
+				Major: LoRaWANR1,
+			},
+			MACPayload: &MACPayload{
+				FHDR: FHDR{
+					DevAddr: s.DevAddr,
+					FCnt:    10,
+				},
+				FPort: &fPort1,
+			},
+		}
+
+		assert.NoError(s.SetUplinkDataMIC(&phy, 2, 3))
+		ok, err := phy.ValidateUplinkDataMIC(s.MACVersion, s.ConfFCnt, 2, 3, s.FNwkSIntKey, s.SNwkSIntKey)
+		assert.NoError(err)
+		assert.True(ok)
+
+		ok, err = s.ValidateUplinkDataMIC(phy, 2, 3)
+		assert.NoError(err)
+		assert.True(ok)
+		assert.Equal(2, s.CMACCache.Len())
+	})
+}
@@ -0,0 +1,173 @@
+// Command lorawan-backend-conformance runs the Backend Interfaces
+// conformance suite (see the backend/conformance package) against a peer
+// implementation, e.g. a roaming partner or join-server, and prints the
+// pass / fail report. It exits with status 1 when a check failed.
+//
+// Usage:
+//
+//	lorawan-backend-conformance -config config.json
+//
+// The configuration file has the following format:
+//
+//	{
+//		"sender_id": "000000",
+//		"receiver_id": "010203",
+//		"server": "https://example.com/api/roaming",
+//		"ca_cert": "",
+//		"tls_cert": "",
+//		"tls_key": "",
+//		"protocol_versions": ["1.0"],
+//		"message_types": ["PRStartReq", "PRStopReq", "ProfileReq", "XmitDataReq"],
+//		"requests": {
+//			"ProfileReq": {"DevEUI": "0102030405060708"}
+//		},
+//		"async_listen": "",
+//		"timeout": "10s"
+//	}
+//
+// The optional requests object contains the request templates by
+// message-type, e.g. containing the identifiers of a device known by the
+// peer. When async_listen is set (e.g. ":8090"), the peer is expected to
+// use the async protocol scheme and to send the answers as HTTP POST to
+// the given address.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"time"
+
+	"github.com/brocaar/lorawan/backend"
+	"github.com/brocaar/lorawan/backend/conformance"
+	"github.com/brocaar/lorawan/internal/backendcmd"
+)
+
+type config struct {
+	backendcmd.TLSConfig
+
+	SenderID         string                     `json:"sender_id"`
+	ReceiverID       string                     `json:"receiver_id"`
+	Server           string                     `json:"server"`
+	ProtocolVersions []string                   `json:"protocol_versions"`
+	MessageTypes     []backend.MessageType      `json:"message_types"`
+	Requests         map[string]json.RawMessage `json:"requests"`
+	AsyncListen      string                     `json:"async_listen"`
+	Timeout          string                     `json:"timeout"`
+}
+
+func main() {
+	var (
+		configFile string
+		server     string
+	)
+
+	fs := flag.NewFlagSet("lorawan-backend-conformance", flag.ExitOnError)
+	fs.StringVar(&configFile, "config", "", "path to the JSON configuration file")
+	fs.StringVar(&server, "server", "", "server URL (overrides the configuration)")
+	fs.Parse(os.Args[1:])
+
+	if configFile == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	b, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		fatal(err)
+	}
+	var cfg config
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		fatal(fmt.Errorf("parse config error: %w", err))
+	}
+	if server != "" {
+		cfg.Server = server
+	}
+
+	confCfg, err := newConformanceConfig(cfg)
+	if err != nil {
+		fatal(err)
+	}
+
+	if cfg.AsyncListen != "" {
+		confCfg.AsyncListener, err = net.Listen("tcp", cfg.AsyncListen)
+		if err != nil {
+			fatal(fmt.Errorf("async listen error: %w", err))
+		}
+		defer confCfg.AsyncListener.Close()
+	}
+
+	passed, err := run(context.Background(), os.Stdout, confCfg)
+	if err != nil {
+		fatal(err)
+	}
+	if !passed {
+		os.Exit(1)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "lorawan-backend-conformance: %s\n", err)
+	os.Exit(1)
+}
+
+// run runs the conformance suite and writes the report to w. It returns
+// true when all checks passed.
+func run(ctx context.Context, w io.Writer, cfg conformance.Config) (bool, error) {
+	report, err := conformance.Run(ctx, cfg)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := report.WriteTo(w); err != nil {
+		return false, err
+	}
+
+	return report.Passed(), nil
+}
+
+// newConformanceConfig returns the conformance.Config for the given
+// configuration. The AsyncListener is not set.
+func newConformanceConfig(cfg config) (conformance.Config, error) {
+	out := conformance.Config{
+		Server:           cfg.Server,
+		SenderID:         cfg.SenderID,
+		ReceiverID:       cfg.ReceiverID,
+		ProtocolVersions: cfg.ProtocolVersions,
+		MessageTypes:     cfg.MessageTypes,
+		Requests:         make(map[backend.MessageType]backend.Request),
+	}
+
+	for mt, b := range cfg.Requests {
+		req, ok := backendcmd.NewRequest(backend.MessageType(mt))
+		if !ok {
+			return out, fmt.Errorf("unsupported request message-type: %s", mt)
+		}
+
+		if err := json.Unmarshal(b, req); err != nil {
+			return out, fmt.Errorf("invalid %s request: %w", mt, err)
+		}
+		out.Requests[backend.MessageType(mt)] = req
+	}
+
+	if cfg.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return out, fmt.Errorf("parse timeout error: %w", err)
+		}
+		out.Timeout = timeout
+	}
+
+	httpClient, err := cfg.NewHTTPClient()
+	if err != nil {
+		return out, err
+	}
+	out.HTTPClient = httpClient
+
+	return out, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/backend"
+	"github.com/brocaar/lorawan/backend/backendtest"
+)
+
+func TestNewConformanceConfig(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		assert := require.New(t)
+
+		var cfg config
+		assert.NoError(json.Unmarshal([]byte(`{
+			"sender_id": "010101",
+			"receiver_id": "020202",
+			"server": "http://localhost:8080",
+			"protocol_versions": ["1.0"],
+			"message_types": ["ProfileReq"],
+			"requests": {
+				"ProfileReq": {"DevEUI": "0102030405060708"}
+			},
+			"timeout": "5s"
+		}`), &cfg))
+
+		out, err := newConformanceConfig(cfg)
+		assert.NoError(err)
+		assert.Equal("http://localhost:8080", out.Server)
+		assert.Equal([]backend.MessageType{backend.ProfileReq}, out.MessageTypes)
+		assert.Equal(5*time.Second, out.Timeout)
+		assert.Equal(&backend.ProfileReqPayload{
+			DevEUI: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		}, out.Requests[backend.ProfileReq])
+	})
+
+	t.Run("unsupported message-type", func(t *testing.T) {
+		_, err := newConformanceConfig(config{
+			Requests: map[string]json.RawMessage{"ProfileAns": json.RawMessage(`{}`)},
+		})
+		require.EqualError(t, err, "unsupported request message-type: ProfileAns")
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		_, err := newConformanceConfig(config{
+			Requests: map[string]json.RawMessage{"ProfileReq": json.RawMessage(`{"DevEUI": 1}`)},
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid timeout", func(t *testing.T) {
+		_, err := newConformanceConfig(config{Timeout: "foo"})
+		require.Error(t, err)
+	})
+}
+
+func TestRun(t *testing.T) {
+	assert := require.New(t)
+
+	devEUI := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+
+	peer := backendtest.NewPeer(backendtest.PeerConfig{})
+	defer peer.Close()
+	assert.NoError(peer.Handle(backend.ProfileReq, func(ctx context.Context, r backend.Request) (backend.Answer, error) {
+		pl := r.(backend.ProfileReqPayload)
+		req := pl.BasePayload
+		if req.ProtocolVersion != backend.ProtocolVersion1_0 {
+			return backendtest.NewBasePayloadResult(req, backend.InvalidProtocolVersion, ""), nil
+		}
+		if pl.DevEUI != devEUI {
+			return backendtest.NewBasePayloadResult(req, backend.UnknownDevEUI, ""), nil
+		}
+		return backend.ProfileAnsPayload{
+			BasePayloadResult: backendtest.NewBasePayloadResult(req, backend.Success, ""),
+		}, nil
+	}))
+
+	cfg, err := newConformanceConfig(config{
+		SenderID:     "010101",
+		ReceiverID:   "020202",
+		Server:       peer.URL(),
+		MessageTypes: []backend.MessageType{backend.ProfileReq, backend.PRStopReq},
+		Requests: map[string]json.RawMessage{
+			"ProfileReq": json.RawMessage(`{"DevEUI": "0102030405060708"}`),
+		},
+	})
+	assert.NoError(err)
+
+	var buf bytes.Buffer
+	passed, err := run(context.Background(), &buf, cfg)
+	assert.NoError(err)
+
+	// the peer does not implement PRStopReq, but a MalformedRequest
+	// answer is still a conforming answer
+	assert.True(passed)
+	assert.Contains(buf.String(), "PASS  1.0/ProfileReq/answer")
+	assert.Contains(buf.String(), "PASS  1.0/ProfileReq/malformed-request")
+	assert.Contains(buf.String(), "PASS  1.0/ProfileReq/invalid-protocol-version")
+	assert.Contains(buf.String(), "PASS  1.0/PRStopReq/answer")
+	assert.Contains(buf.String(), "6 passed, 0 failed")
+}
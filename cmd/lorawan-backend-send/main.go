@@ -0,0 +1,364 @@
+// Command lorawan-backend-send sends a single LoRaWAN Backend Interfaces
+// request to a roaming partner or join-server and prints the raw exchange.
+// It is intended for bilateral interoperability testing.
+//
+// Usage:
+//
+//	lorawan-backend-send -config config.json -type PRStartReq [-payload payload.json] [-field Name=Value ...]
+//
+// The configuration file has the following format:
+//
+//	{
+//		"sender_id": "000000",
+//		"receiver_id": "010203",
+//		"server": "https://example.com/api/roaming",
+//		"ca_cert": "",
+//		"tls_cert": "",
+//		"tls_key": "",
+//		"protocol_version": "1.0",
+//		"async_listen": "",
+//		"async_timeout": "10s"
+//	}
+//
+// When async_listen is set (e.g. ":8090"), the async protocol scheme is used
+// and the answer is expected as HTTP POST on the given address.
+//
+// The request payload is read from the JSON file given by -payload ("-"
+// for stdin) and / or constructed from -field flags. The value of a field
+// is used as-is when it is valid JSON and as string otherwise, e.g.
+// -field DevEUI=0102030405060708 -field ULMetaData='{"DataRate":5}'. The
+// ProtocolVersion, SenderID, ReceiverID, TransactionID and MessageType
+// fields are set automatically, unless given.
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/brocaar/lorawan/backend"
+	"github.com/brocaar/lorawan/internal/backendcmd"
+)
+
+type config struct {
+	backendcmd.TLSConfig
+
+	SenderID        string `json:"sender_id"`
+	ReceiverID      string `json:"receiver_id"`
+	Server          string `json:"server"`
+	ProtocolVersion string `json:"protocol_version"`
+	AsyncListen     string `json:"async_listen"`
+	AsyncTimeout    string `json:"async_timeout"`
+}
+
+type fieldsFlag []string
+
+func (f *fieldsFlag) String() string {
+	return strings.Join(*f, ", ")
+}
+
+func (f *fieldsFlag) Set(s string) error {
+	if !strings.Contains(s, "=") {
+		return errors.New("field must be in Name=Value format")
+	}
+	*f = append(*f, s)
+	return nil
+}
+
+func main() {
+	var (
+		configFile  string
+		messageType string
+		payloadFile string
+		fields      fieldsFlag
+		cfg         config
+	)
+
+	fs := flag.NewFlagSet("lorawan-backend-send", flag.ExitOnError)
+	fs.StringVar(&configFile, "config", "", "path to the JSON configuration file")
+	fs.StringVar(&messageType, "type", "", "request message-type (e.g. PRStartReq)")
+	fs.StringVar(&payloadFile, "payload", "", "path to the JSON request payload (- for stdin)")
+	fs.Var(&fields, "field", "request payload field in Name=Value format (can be repeated)")
+	fs.StringVar(&cfg.Server, "server", "", "server URL (overrides the configuration)")
+	fs.StringVar(&cfg.SenderID, "sender-id", "", "SenderID (overrides the configuration)")
+	fs.StringVar(&cfg.ReceiverID, "receiver-id", "", "ReceiverID (overrides the configuration)")
+	fs.Parse(os.Args[1:])
+
+	if configFile != "" {
+		b, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			fatal(err)
+		}
+		var fileCfg config
+		if err := json.Unmarshal(b, &fileCfg); err != nil {
+			fatal(fmt.Errorf("parse config error: %w", err))
+		}
+		cfg = mergeConfig(fileCfg, cfg)
+	}
+
+	var payload []byte
+	switch payloadFile {
+	case "":
+	case "-":
+		b, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			fatal(err)
+		}
+		payload = b
+	default:
+		b, err := ioutil.ReadFile(payloadFile)
+		if err != nil {
+			fatal(err)
+		}
+		payload = b
+	}
+
+	req, err := buildRequest(cfg, backend.MessageType(messageType), payload, fields)
+	if err != nil {
+		fatal(err)
+	}
+
+	var asyncListener net.Listener
+	if cfg.AsyncListen != "" {
+		asyncListener, err = net.Listen("tcp", cfg.AsyncListen)
+		if err != nil {
+			fatal(fmt.Errorf("async listen error: %w", err))
+		}
+		defer asyncListener.Close()
+	}
+
+	httpClient, err := cfg.NewHTTPClient()
+	if err != nil {
+		fatal(err)
+	}
+
+	timeout := 10 * time.Second
+	if cfg.AsyncTimeout != "" {
+		timeout, err = time.ParseDuration(cfg.AsyncTimeout)
+		if err != nil {
+			fatal(fmt.Errorf("parse async_timeout error: %w", err))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	ans, err := send(ctx, os.Stdout, httpClient, cfg.Server, req, asyncListener)
+	if err != nil {
+		fatal(err)
+	}
+	if ans.Result.ResultCode != backend.Success {
+		os.Exit(1)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "lorawan-backend-send: %s\n", err)
+	os.Exit(1)
+}
+
+// mergeConfig returns the file configuration, overridden by the non-empty
+// values of the flags configuration.
+func mergeConfig(file, flags config) config {
+	if flags.Server != "" {
+		file.Server = flags.Server
+	}
+	if flags.SenderID != "" {
+		file.SenderID = flags.SenderID
+	}
+	if flags.ReceiverID != "" {
+		file.ReceiverID = flags.ReceiverID
+	}
+	return file
+}
+
+// buildRequest constructs the request of the given message-type from the
+// (optional) JSON payload and fields. The result is validated by decoding
+// it into the request struct.
+func buildRequest(cfg config, mt backend.MessageType, payload []byte, fields []string) (backend.Request, error) {
+	req, ok := backendcmd.NewRequest(mt)
+	if !ok {
+		return nil, fmt.Errorf("unsupported message-type: %s", mt)
+	}
+
+	obj := make(map[string]json.RawMessage)
+	if len(bytes.TrimSpace(payload)) != 0 {
+		if err := json.Unmarshal(payload, &obj); err != nil {
+			return nil, fmt.Errorf("parse payload error: %w", err)
+		}
+	}
+
+	for _, f := range fields {
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid field: %s", f)
+		}
+		if json.Valid([]byte(kv[1])) {
+			obj[kv[0]] = json.RawMessage(kv[1])
+		} else {
+			b, err := json.Marshal(kv[1])
+			if err != nil {
+				return nil, err
+			}
+			obj[kv[0]] = b
+		}
+	}
+
+	protocolVersion := cfg.ProtocolVersion
+	if protocolVersion == "" {
+		protocolVersion = backend.ProtocolVersion1_0
+	}
+
+	setDefault(obj, "ProtocolVersion", protocolVersion)
+	setDefault(obj, "SenderID", cfg.SenderID)
+	setDefault(obj, "ReceiverID", cfg.ReceiverID)
+	setDefault(obj, "MessageType", mt)
+	if _, ok := obj["TransactionID"]; !ok {
+		var b [4]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return nil, err
+		}
+		setDefault(obj, "TransactionID", binary.LittleEndian.Uint32(b[:]))
+	}
+
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, req); err != nil {
+		return nil, fmt.Errorf("invalid %s payload: %w", mt, err)
+	}
+
+	return req, nil
+}
+
+func setDefault(obj map[string]json.RawMessage, key string, value interface{}) {
+	if _, ok := obj[key]; ok {
+		return
+	}
+	b, _ := json.Marshal(value)
+	obj[key] = b
+}
+
+// send sends the request to the server and prints the raw exchange to w.
+// When the asyncListener is set, the answer is expected as HTTP POST on
+// this listener.
+func send(ctx context.Context, w io.Writer, httpClient *http.Client, server string, req backend.Request, asyncListener net.Listener) (backend.BasePayloadResult, error) {
+	var ans backend.BasePayloadResult
+	transactionID := req.GetBasePayload().TransactionID
+
+	answers := make(chan []byte, 1)
+	unexpected := make(chan []byte, 10)
+	if asyncListener != nil {
+		srv := &http.Server{
+			Handler: http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				b, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					rw.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				var pl backend.BasePayload
+				if err := json.Unmarshal(b, &pl); err != nil || pl.TransactionID != transactionID {
+					select {
+					case unexpected <- b:
+					default:
+					}
+					rw.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				select {
+				case answers <- b:
+				default:
+				}
+				rw.WriteHeader(http.StatusOK)
+			}),
+		}
+		go srv.Serve(asyncListener)
+		defer srv.Close()
+	}
+
+	b, err := json.MarshalIndent(req, "", "  ")
+	if err != nil {
+		return ans, fmt.Errorf("marshal request error: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", server, bytes.NewReader(b))
+	if err != nil {
+		return ans, fmt.Errorf("new request error: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	fmt.Fprintf(w, "> POST %s\n> Content-Type: application/json\n>\n%s\n", server, b)
+
+	start := time.Now()
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return ans, fmt.Errorf("http post error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return ans, fmt.Errorf("read response error: %w", err)
+	}
+
+	fmt.Fprintf(w, "\n< %s (%s)\n", resp.Status, time.Since(start).Round(time.Millisecond))
+	for _, k := range []string{"Content-Type", "Content-Length"} {
+		if v := resp.Header.Get(k); v != "" {
+			fmt.Fprintf(w, "< %s: %s\n", k, v)
+		}
+	}
+	fmt.Fprintf(w, "<\n%s\n", indent(body))
+
+	if asyncListener != nil {
+	asyncLoop:
+		for {
+			select {
+			case b := <-unexpected:
+				fmt.Fprintf(w, "\n< ignoring unexpected async request\n%s\n", indent(b))
+			case body = <-answers:
+				fmt.Fprintf(w, "\n< async answer (%s)\n%s\n", time.Since(start).Round(time.Millisecond), indent(body))
+				break asyncLoop
+			case <-ctx.Done():
+				return ans, errors.New("async timeout")
+			}
+		}
+	} else if resp.StatusCode != http.StatusOK {
+		return ans, fmt.Errorf("expected 200 response, got: %d", resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(body, &ans); err != nil {
+		return ans, fmt.Errorf("unmarshal answer error: %w", err)
+	}
+
+	fmt.Fprintf(w, "\nResult: %s", ans.Result.ResultCode)
+	if ans.Result.Description != "" {
+		fmt.Fprintf(w, " (%s)", ans.Result.Description)
+	}
+	fmt.Fprintln(w)
+
+	return ans, nil
+}
+
+func indent(b []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, b, "", "  "); err != nil {
+		return b
+	}
+	return buf.Bytes()
+}
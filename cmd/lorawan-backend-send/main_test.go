@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/backend"
+	"github.com/brocaar/lorawan/backend/backendtest"
+)
+
+func TestBuildRequest(t *testing.T) {
+	cfg := config{
+		SenderID:   "010101",
+		ReceiverID: "020202",
+	}
+
+	t.Run("payload and fields", func(t *testing.T) {
+		assert := require.New(t)
+
+		req, err := buildRequest(cfg, backend.PRStartReq, []byte(`{"TransactionID":123,"PHYPayload":"01020304"}`), []string{
+			"ULMetaData={\"DataRate\":5}",
+			"SenderID=030303",
+		})
+		assert.NoError(err)
+
+		pl := req.(*backend.PRStartReqPayload)
+		assert.Equal(backend.BasePayload{
+			ProtocolVersion: backend.ProtocolVersion1_0,
+			SenderID:        "030303",
+			ReceiverID:      "020202",
+			TransactionID:   123,
+			MessageType:     backend.PRStartReq,
+		}, pl.BasePayload)
+		assert.Equal(backend.HEXBytes{1, 2, 3, 4}, pl.PHYPayload)
+		assert.Equal(5, *pl.ULMetaData.DataRate)
+	})
+
+	t.Run("random transaction id", func(t *testing.T) {
+		assert := require.New(t)
+
+		req, err := buildRequest(cfg, backend.JoinReq, nil, []string{"DevEUI=0102030405060708"})
+		assert.NoError(err)
+		assert.NotZero(req.GetBasePayload().TransactionID)
+		assert.Equal(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, req.(*backend.JoinReqPayload).DevEUI)
+	})
+
+	t.Run("invalid payload", func(t *testing.T) {
+		assert := require.New(t)
+
+		_, err := buildRequest(cfg, backend.JoinReq, nil, []string{"DevEUI=zz"})
+		assert.Error(err)
+	})
+
+	t.Run("unsupported message-type", func(t *testing.T) {
+		assert := require.New(t)
+
+		_, err := buildRequest(cfg, backend.JoinAns, nil, nil)
+		assert.EqualError(err, "unsupported message-type: JoinAns")
+	})
+}
+
+func TestSend(t *testing.T) {
+	handler := func(ctx context.Context, req backend.Request) (backend.Answer, error) {
+		return backend.PRStartAnsPayload{
+			BasePayloadResult: backendtest.NewBasePayloadResult(req.GetBasePayload(), backend.Success, ""),
+		}, nil
+	}
+
+	t.Run("sync", func(t *testing.T) {
+		assert := require.New(t)
+
+		peer := backendtest.NewPeer(backendtest.PeerConfig{})
+		defer peer.Close()
+		assert.NoError(peer.Handle(backend.PRStartReq, handler))
+
+		req, err := buildRequest(config{SenderID: "010101", ReceiverID: "020202"}, backend.PRStartReq, nil, []string{"TransactionID=123"})
+		assert.NoError(err)
+
+		var out bytes.Buffer
+		ans, err := send(context.Background(), &out, http.DefaultClient, peer.URL(), req, nil)
+		assert.NoError(err)
+		assert.Equal(backend.Success, ans.Result.ResultCode)
+		assert.Equal(uint32(123), ans.TransactionID)
+
+		assert.Contains(out.String(), "> POST "+peer.URL())
+		assert.Contains(out.String(), `"MessageType": "PRStartReq"`)
+		assert.Contains(out.String(), "< 200 OK")
+		assert.Contains(out.String(), `"MessageType": "PRStartAns"`)
+		assert.Contains(out.String(), "Result: Success")
+	})
+
+	t.Run("async", func(t *testing.T) {
+		assert := require.New(t)
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(err)
+		defer ln.Close()
+
+		peer := backendtest.NewPeer(backendtest.PeerConfig{AsyncAnswerServer: "http://" + ln.Addr().String()})
+		defer peer.Close()
+		assert.NoError(peer.Handle(backend.PRStartReq, handler))
+
+		req, err := buildRequest(config{SenderID: "010101", ReceiverID: "020202"}, backend.PRStartReq, nil, nil)
+		assert.NoError(err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		var out bytes.Buffer
+		ans, err := send(ctx, &out, http.DefaultClient, peer.URL(), req, ln)
+		assert.NoError(err)
+		assert.Equal(backend.Success, ans.Result.ResultCode)
+		assert.Equal(req.GetBasePayload().TransactionID, ans.TransactionID)
+		assert.Contains(out.String(), "< async answer")
+	})
+}
@@ -0,0 +1,314 @@
+// Command lorawan-decode decodes a raw LoRaWAN frame (PHYPayload), given as
+// HEX or base64 encoded string, and prints an annotated dump or the JSON
+// representation of the frame. When the session keys are given, the MIC is
+// validated and the payload is decrypted.
+//
+// Usage:
+//
+//	lorawan-decode [flags] <frame>
+//
+// Examples:
+//
+//	lorawan-decode 40040302018001000199c4e5e43e0b05
+//	lorawan-decode -nwkskey 01020304050607080910111213141516 -appskey 16151413121110090807060504030201 -json gAQDAgEDAAAGcwcK4mTU9+EX0sA=
+//
+// When no frame argument is given, the frame is read from stdin.
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/brocaar/lorawan"
+)
+
+// MIC validation states.
+const (
+	micNotChecked = "not checked"
+	micValid      = "valid"
+	micInvalid    = "invalid"
+)
+
+type options struct {
+	macVersion  string
+	nwkKey      lorawan.AES128Key
+	nwkSKey     lorawan.AES128Key
+	fNwkSIntKey lorawan.AES128Key
+	nwkSEncKey  lorawan.AES128Key
+	appSKey     lorawan.AES128Key
+	fCnt        uint
+	confFCnt    uint
+	txDR        uint
+	txCh        uint
+	json        bool
+}
+
+type result struct {
+	MIC        string              `json:"mic"`
+	PHYPayload *lorawan.PHYPayload `json:"phyPayload"`
+}
+
+func main() {
+	var opts options
+
+	fs := flag.NewFlagSet("lorawan-decode", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: lorawan-decode [flags] <frame (HEX or base64)>\n\n")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&opts.macVersion, "mac-version", "1.0", "LoRaWAN MAC version (1.0 or 1.1)")
+	fs.Var(&opts.nwkKey, "nwkkey", "NwkKey (AppKey for LoRaWAN 1.0), for join-request MIC validation and join-accept decryption")
+	fs.Var(&opts.nwkSKey, "nwkskey", "NwkSKey (SNwkSIntKey for LoRaWAN 1.1), for data MIC validation")
+	fs.Var(&opts.fNwkSIntKey, "fnwksintkey", "FNwkSIntKey, for LoRaWAN 1.1 uplink MIC validation")
+	fs.Var(&opts.nwkSEncKey, "nwksenckey", "NwkSEncKey, for LoRaWAN 1.1 mac-command decryption")
+	fs.Var(&opts.appSKey, "appskey", "AppSKey, for FRMPayload decryption")
+	fs.UintVar(&opts.fCnt, "fcnt", 0, "full 32 bit frame-counter (only the 16 LSB are transmitted)")
+	fs.UintVar(&opts.confFCnt, "conffcnt", 0, "frame-counter of the confirmed frame that is acknowledged (LoRaWAN 1.1)")
+	fs.UintVar(&opts.txDR, "txdr", 0, "uplink data-rate (LoRaWAN 1.1)")
+	fs.UintVar(&opts.txCh, "txch", 0, "uplink channel (LoRaWAN 1.1)")
+	fs.BoolVar(&opts.json, "json", false, "print the decoded frame as JSON instead of the annotated dump")
+	fs.Parse(os.Args[1:])
+
+	var input string
+	switch fs.NArg() {
+	case 0:
+		b, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			fatal(err)
+		}
+		input = string(b)
+	case 1:
+		input = fs.Arg(0)
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	data, err := parseFrame(input)
+	if err != nil {
+		fatal(err)
+	}
+
+	if err := run(os.Stdout, data, opts); err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "lorawan-decode: %s\n", err)
+	os.Exit(1)
+}
+
+// parseFrame parses the given HEX or base64 encoded frame.
+func parseFrame(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if s == "" {
+		return nil, errors.New("frame is empty")
+	}
+
+	if b, err := hex.DecodeString(s); err == nil {
+		return b, nil
+	}
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	if b, err := base64.RawStdEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+
+	return nil, errors.New("frame must be HEX or base64 encoded")
+}
+
+// run decodes the given frame and writes the output to w.
+func run(w io.Writer, data []byte, opts options) error {
+	var macVersion lorawan.MACVersion
+	switch opts.macVersion {
+	case "1.0", "1.0.0", "1.0.1", "1.0.2", "1.0.3", "1.0.4":
+		macVersion = lorawan.LoRaWAN1_0
+	case "1.1", "1.1.0":
+		macVersion = lorawan.LoRaWAN1_1
+	default:
+		return fmt.Errorf("invalid mac-version: %s", opts.macVersion)
+	}
+
+	var phy lorawan.PHYPayload
+	decodeErr := phy.UnmarshalBinary(data)
+
+	if !opts.json {
+		fmt.Fprint(w, lorawan.DumpFrame(data))
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("decode frame error: %w", decodeErr)
+	}
+
+	mic, err := decode(&phy, macVersion, opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.json {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result{
+			MIC:        mic,
+			PHYPayload: &phy,
+		})
+	}
+
+	fmt.Fprintf(w, "\nMIC: %s\n", mic)
+
+	macPL, ok := phy.MACPayload.(*lorawan.MACPayload)
+	if !ok {
+		if jaPL, ok := phy.MACPayload.(*lorawan.JoinAcceptPayload); ok {
+			b, err := json.Marshal(jaPL)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "JoinAcceptPayload: %s\n", b)
+		}
+		return nil
+	}
+
+	for _, pl := range macPL.FHDR.FOpts {
+		if mac, ok := pl.(*lorawan.MACCommand); ok {
+			fmt.Fprintf(w, "FOpts: %s\n", formatMACCommand(mac))
+		}
+	}
+	for _, pl := range macPL.FRMPayload {
+		switch v := pl.(type) {
+		case *lorawan.MACCommand:
+			fmt.Fprintf(w, "FRMPayload: %s\n", formatMACCommand(v))
+		case *lorawan.DataPayload:
+			if opts.appSKey != (lorawan.AES128Key{}) {
+				fmt.Fprintf(w, "FRMPayload (decrypted): %s\n", hex.EncodeToString(v.Bytes))
+			}
+		}
+	}
+
+	return nil
+}
+
+// decode validates the MIC and decrypts the frame, depending on the
+// provided keys. It returns the MIC validation state.
+func decode(phy *lorawan.PHYPayload, macVersion lorawan.MACVersion, opts options) (string, error) {
+	var zeroKey lorawan.AES128Key
+	mic := micNotChecked
+
+	switch phy.MHDR.MType {
+	case lorawan.JoinRequest:
+		if opts.nwkKey == zeroKey {
+			return mic, nil
+		}
+		ok, err := phy.ValidateUplinkJoinMIC(opts.nwkKey)
+		if err != nil {
+			return mic, fmt.Errorf("validate MIC error: %w", err)
+		}
+		return micState(ok), nil
+
+	case lorawan.JoinAccept:
+		if opts.nwkKey == zeroKey {
+			return mic, nil
+		}
+		if err := phy.DecryptJoinAcceptPayload(opts.nwkKey); err != nil {
+			return mic, fmt.Errorf("decrypt join-accept error: %w", err)
+		}
+		jaPL := phy.MACPayload.(*lorawan.JoinAcceptPayload)
+
+		// the LoRaWAN 1.1 MIC requires the JSIntKey, JoinEUI and DevNonce
+		if jaPL.DLSettings.OptNeg {
+			return mic, nil
+		}
+		ok, err := phy.ValidateDownlinkJoinMIC(lorawan.JoinRequestType, lorawan.EUI64{}, 0, opts.nwkKey)
+		if err != nil {
+			return mic, fmt.Errorf("validate MIC error: %w", err)
+		}
+		return micState(ok), nil
+
+	case lorawan.UnconfirmedDataUp, lorawan.ConfirmedDataUp, lorawan.UnconfirmedDataDown, lorawan.ConfirmedDataDown:
+		macPL := phy.MACPayload.(*lorawan.MACPayload)
+		uplink := phy.MHDR.MType == lorawan.UnconfirmedDataUp || phy.MHDR.MType == lorawan.ConfirmedDataUp
+
+		if opts.fCnt != 0 {
+			if uint16(opts.fCnt) != uint16(macPL.FHDR.FCnt) {
+				return mic, fmt.Errorf("16 LSB of fcnt %d do not match the FCnt of the frame (%d)", opts.fCnt, macPL.FHDR.FCnt)
+			}
+			macPL.FHDR.FCnt = uint32(opts.fCnt)
+		}
+
+		if opts.nwkSKey != zeroKey {
+			var ok bool
+			var err error
+			if uplink {
+				fNwkSIntKey := opts.fNwkSIntKey
+				if macVersion == lorawan.LoRaWAN1_0 {
+					fNwkSIntKey = opts.nwkSKey
+				}
+				ok, err = phy.ValidateUplinkDataMIC(macVersion, uint32(opts.confFCnt), uint8(opts.txDR), uint8(opts.txCh), fNwkSIntKey, opts.nwkSKey)
+			} else {
+				ok, err = phy.ValidateDownlinkDataMIC(macVersion, uint32(opts.confFCnt), opts.nwkSKey)
+			}
+			if err != nil {
+				return mic, fmt.Errorf("validate MIC error: %w", err)
+			}
+			mic = micState(ok)
+		}
+
+		// mac-commands in FOpts are only encrypted for LoRaWAN 1.1
+		if macVersion == lorawan.LoRaWAN1_1 && opts.nwkSEncKey != zeroKey {
+			if err := phy.DecryptFOpts(opts.nwkSEncKey); err != nil {
+				return mic, fmt.Errorf("decrypt FOpts error: %w", err)
+			}
+		} else if macVersion == lorawan.LoRaWAN1_0 {
+			if err := phy.DecodeFOptsToMACCommands(); err != nil {
+				return mic, fmt.Errorf("decode FOpts error: %w", err)
+			}
+		}
+
+		// mac-commands in the FRMPayload are encrypted with the
+		// NwkSKey (LoRaWAN 1.0) or NwkSEncKey (LoRaWAN 1.1)
+		frmPayloadKey := opts.appSKey
+		if macPL.FPort != nil && *macPL.FPort == 0 {
+			frmPayloadKey = opts.nwkSKey
+			if macVersion == lorawan.LoRaWAN1_1 {
+				frmPayloadKey = opts.nwkSEncKey
+			}
+		}
+		if frmPayloadKey != zeroKey {
+			if err := phy.DecryptFRMPayload(frmPayloadKey); err != nil {
+				return mic, fmt.Errorf("decrypt FRMPayload error: %w", err)
+			}
+		}
+	}
+
+	return mic, nil
+}
+
+func micState(ok bool) string {
+	if ok {
+		return micValid
+	}
+	return micInvalid
+}
+
+func formatMACCommand(mac *lorawan.MACCommand) string {
+	if mac.Payload == nil {
+		return mac.CID.String()
+	}
+
+	b, err := json.Marshal(mac.Payload)
+	if err != nil {
+		return mac.CID.String()
+	}
+
+	return fmt.Sprintf("%s %s", mac.CID, bytes.TrimSpace(b))
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestParseFrame(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []byte
+		err      string
+	}{
+		{"hex", "01020304", []byte{1, 2, 3, 4}, ""},
+		{"hex with prefix", " 0x01020304\n", []byte{1, 2, 3, 4}, ""},
+		{"base64", "AQIDBA==", []byte{1, 2, 3, 4}, ""},
+		{"base64 without padding", "AQIDBA", []byte{1, 2, 3, 4}, ""},
+		{"empty", "", nil, "frame is empty"},
+		{"invalid", "???", nil, "frame must be HEX or base64 encoded"},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			assert := require.New(t)
+			b, err := parseFrame(tst.input)
+			if tst.err != "" {
+				assert.EqualError(err, tst.err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tst.expected, b)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	nwkSKey := lorawan.AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	appSKey := lorawan.AES128Key{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1}
+	frame := []byte{0x80, 0x04, 0x03, 0x02, 0x01, 0x03, 0x00, 0x00, 0x06, 0x73, 0x07, 0x0a, 0xe2, 0x64, 0xd4, 0xf7, 0xe1, 0x17, 0xd2, 0xc0}
+
+	t.Run("dump without keys", func(t *testing.T) {
+		assert := require.New(t)
+		var buf bytes.Buffer
+		assert.NoError(run(&buf, frame, options{macVersion: "1.0"}))
+		assert.Contains(buf.String(), "0001  DevAddr           04 03 02 01")
+		assert.Contains(buf.String(), "MIC: not checked")
+		assert.NotContains(buf.String(), "FRMPayload (decrypted)")
+	})
+
+	t.Run("dump with keys", func(t *testing.T) {
+		assert := require.New(t)
+		var buf bytes.Buffer
+		assert.NoError(run(&buf, frame, options{macVersion: "1.0", nwkSKey: nwkSKey, appSKey: appSKey}))
+		assert.Contains(buf.String(), "MIC: valid")
+		assert.Contains(buf.String(), `FOpts: DevStatusReq {"battery":115,"margin":7}`)
+		assert.Contains(buf.String(), "FRMPayload (decrypted): 01020304")
+	})
+
+	t.Run("invalid MIC", func(t *testing.T) {
+		assert := require.New(t)
+		var buf bytes.Buffer
+		assert.NoError(run(&buf, frame, options{macVersion: "1.0", nwkSKey: appSKey}))
+		assert.Contains(buf.String(), "MIC: invalid")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		assert := require.New(t)
+		var buf bytes.Buffer
+		assert.NoError(run(&buf, frame, options{macVersion: "1.0", nwkSKey: nwkSKey, appSKey: appSKey, json: true}))
+
+		var res struct {
+			MIC        string `json:"mic"`
+			PHYPayload struct {
+				MACPayload struct {
+					FRMPayload []struct {
+						Bytes []byte `json:"bytes"`
+					} `json:"frmPayload"`
+				} `json:"macPayload"`
+			} `json:"phyPayload"`
+		}
+		assert.NoError(json.Unmarshal(buf.Bytes(), &res))
+		assert.Equal("valid", res.MIC)
+		assert.Equal([]byte{1, 2, 3, 4}, res.PHYPayload.MACPayload.FRMPayload[0].Bytes)
+	})
+
+	t.Run("join-accept", func(t *testing.T) {
+		assert := require.New(t)
+		var buf bytes.Buffer
+		ja := []byte{0x20, 0x49, 0x3e, 0xeb, 0x51, 0xfb, 0xa2, 0x11, 0x6f, 0x81, 0x0e, 0xdb, 0x37, 0x42, 0x97, 0x51, 0x42}
+		nwkKey := lorawan.AES128Key{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+		assert.NoError(run(&buf, ja, options{macVersion: "1.0", nwkKey: nwkKey}))
+		assert.Contains(buf.String(), "MIC: valid")
+		assert.Contains(buf.String(), `"devAddr":"02031980"`)
+	})
+
+	t.Run("invalid frame", func(t *testing.T) {
+		assert := require.New(t)
+		var buf bytes.Buffer
+		assert.Error(run(&buf, []byte{0x40, 0x01}, options{macVersion: "1.0"}))
+	})
+
+	t.Run("invalid mac-version", func(t *testing.T) {
+		assert := require.New(t)
+		assert.EqualError(run(&bytes.Buffer{}, frame, options{macVersion: "2.0"}), "invalid mac-version: 2.0")
+	})
+}
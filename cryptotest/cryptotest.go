@@ -0,0 +1,253 @@
+// Package cryptotest provides known-answer test vectors for the LoRaWAN
+// session key derivation, MIC computation, data frame and join-accept
+// encoding, together with helpers to validate an implementation against
+// these vectors. This allows network-
+// and join-server implementations to verify their integration (key order,
+// byte endianness, ...) in their own test suites.
+package cryptotest
+
+import (
+	"testing"
+
+	"github.com/brocaar/lorawan"
+)
+
+// SessionKeys holds the derived session keys. For LoRaWAN 1.0.x only the
+// NwkSKey (stored as FNwkSIntKey) and AppSKey are derived.
+type SessionKeys struct {
+	FNwkSIntKey lorawan.AES128Key
+	SNwkSIntKey lorawan.AES128Key
+	NwkSEncKey  lorawan.AES128Key
+	AppSKey     lorawan.AES128Key
+}
+
+// SessionKeyVector defines a session key derivation test vector.
+type SessionKeyVector struct {
+	Name      string
+	OptNeg    bool              // LoRaWAN 1.1 key derivation
+	NwkKey    lorawan.AES128Key // LoRaWAN 1.0.x AppKey
+	AppKey    lorawan.AES128Key // LoRaWAN 1.1 only
+	NetID     lorawan.NetID
+	JoinEUI   lorawan.EUI64
+	JoinNonce lorawan.JoinNonce
+	DevNonce  lorawan.DevNonce
+	Expected  SessionKeys
+}
+
+// JSKeyVector defines a JSIntKey and JSEncKey derivation test vector
+// (LoRaWAN 1.1 only).
+type JSKeyVector struct {
+	Name             string
+	NwkKey           lorawan.AES128Key
+	DevEUI           lorawan.EUI64
+	ExpectedJSIntKey lorawan.AES128Key
+	ExpectedJSEncKey lorawan.AES128Key
+}
+
+// DataMICVector defines a data frame MIC test vector.
+type DataMICVector struct {
+	Name        string
+	MACVersion  lorawan.MACVersion
+	PHYPayload  []byte // as transmitted
+	FCnt        uint32 // full 32 bit frame-counter
+	ConfFCnt    uint32 // LoRaWAN 1.1 only
+	TXDR        uint8  // LoRaWAN 1.1 uplink only
+	TXCh        uint8  // LoRaWAN 1.1 uplink only
+	FNwkSIntKey lorawan.AES128Key
+	SNwkSIntKey lorawan.AES128Key
+	ExpectedMIC lorawan.MIC
+}
+
+// JoinRequestMICVector defines a join-request MIC test vector.
+type JoinRequestMICVector struct {
+	Name        string
+	PHYPayload  []byte
+	NwkKey      lorawan.AES128Key
+	ExpectedMIC lorawan.MIC
+}
+
+// SessionKeyVectors contains the session key derivation test vectors.
+var SessionKeyVectors = []SessionKeyVector{
+	{
+		Name:      "LoRaWAN 1.0",
+		NwkKey:    lorawan.AES128Key{0x2b, 0x7e, 0x15, 0x16, 0x28, 0xae, 0xd2, 0xa6, 0xab, 0xf7, 0x15, 0x88, 0x09, 0xcf, 0x4f, 0x3c},
+		NetID:     lorawan.NetID{0x00, 0x00, 0x13},
+		JoinEUI:   lorawan.EUI64{0x70, 0xb3, 0xd5, 0x7e, 0xd0, 0x00, 0x00, 0x01},
+		JoinNonce: 0x000102,
+		DevNonce:  0x0a0b,
+		Expected: SessionKeys{
+			FNwkSIntKey: lorawan.MustKey("6cc3d7ff43c5d3a20a43a4245bb0ff11"),
+			AppSKey:     lorawan.MustKey("83adad26e1f5b802683181ddcbe9e48d"),
+		},
+	},
+	{
+		Name:      "LoRaWAN 1.1",
+		OptNeg:    true,
+		NwkKey:    lorawan.AES128Key{0x2b, 0x7e, 0x15, 0x16, 0x28, 0xae, 0xd2, 0xa6, 0xab, 0xf7, 0x15, 0x88, 0x09, 0xcf, 0x4f, 0x3c},
+		AppKey:    lorawan.AES128Key{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10},
+		NetID:     lorawan.NetID{0x00, 0x00, 0x13},
+		JoinEUI:   lorawan.EUI64{0x70, 0xb3, 0xd5, 0x7e, 0xd0, 0x00, 0x00, 0x01},
+		JoinNonce: 0x000102,
+		DevNonce:  0x0a0b,
+		Expected: SessionKeys{
+			FNwkSIntKey: lorawan.MustKey("0356ede4b7e6ae7944911dc8f970ec52"),
+			SNwkSIntKey: lorawan.MustKey("b3c8cb5db85d40f3831e0c25f2e7fe5a"),
+			NwkSEncKey:  lorawan.MustKey("4d88301eac490ea8a94b83ce1cd03f77"),
+			AppSKey:     lorawan.MustKey("4ec1700077a09808e6bb0f669f13a171"),
+		},
+	},
+}
+
+// JSKeyVectors contains the JSIntKey and JSEncKey derivation test vectors.
+var JSKeyVectors = []JSKeyVector{
+	{
+		Name:             "LoRaWAN 1.1",
+		NwkKey:           lorawan.AES128Key{0x2b, 0x7e, 0x15, 0x16, 0x28, 0xae, 0xd2, 0xa6, 0xab, 0xf7, 0x15, 0x88, 0x09, 0xcf, 0x4f, 0x3c},
+		DevEUI:           lorawan.EUI64{0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01},
+		ExpectedJSIntKey: lorawan.MustKey("8995c39f9cd51dafef8cabd18e6fdf25"),
+		ExpectedJSEncKey: lorawan.MustKey("ce2f92fc9e22d827b8a7f7da23634948"),
+	},
+}
+
+// DataMICVectors contains the data frame MIC test vectors.
+var DataMICVectors = []DataMICVector{
+	{
+		Name:        "LoRaWAN 1.0 uplink",
+		MACVersion:  lorawan.LoRaWAN1_0,
+		PHYPayload:  []byte{64, 4, 3, 2, 1, 128, 1, 0, 1, 166, 148, 100, 38, 21, 214, 195, 181, 130},
+		FCnt:        1,
+		FNwkSIntKey: lorawan.AES128Key{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2},
+		SNwkSIntKey: lorawan.AES128Key{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2},
+		ExpectedMIC: lorawan.MIC{214, 195, 181, 130},
+	},
+	{
+		Name:        "LoRaWAN 1.1 uplink",
+		MACVersion:  lorawan.LoRaWAN1_1,
+		PHYPayload:  []byte{64, 4, 3, 2, 1, 128, 1, 0, 1, 166, 148, 100, 38, 21, 118, 18, 54, 106},
+		FCnt:        1,
+		ConfFCnt:    1,
+		TXDR:        2,
+		TXCh:        3,
+		SNwkSIntKey: lorawan.AES128Key{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2},
+		FNwkSIntKey: lorawan.AES128Key{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 3},
+		ExpectedMIC: lorawan.MIC{118, 18, 54, 106},
+	},
+	{
+		Name:        "LoRaWAN 1.1 uplink with ACK (ConfFCnt is used)",
+		MACVersion:  lorawan.LoRaWAN1_1,
+		PHYPayload:  []byte{64, 4, 3, 2, 1, 160, 1, 0, 1, 166, 148, 100, 38, 21, 248, 66, 196, 185},
+		FCnt:        1,
+		ConfFCnt:    1,
+		TXDR:        2,
+		TXCh:        3,
+		SNwkSIntKey: lorawan.AES128Key{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2},
+		FNwkSIntKey: lorawan.AES128Key{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 3},
+		ExpectedMIC: lorawan.MIC{248, 66, 196, 185},
+	},
+}
+
+// JoinRequestMICVectors contains the join-request MIC test vectors.
+var JoinRequestMICVectors = []JoinRequestMICVector{
+	{
+		Name:        "Join-request",
+		PHYPayload:  []byte{0, 4, 3, 2, 1, 4, 3, 2, 1, 5, 4, 3, 2, 5, 4, 3, 2, 45, 16, 106, 153, 14, 18},
+		NwkKey:      lorawan.AES128Key{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+		ExpectedMIC: lorawan.MIC{106, 153, 14, 18},
+	},
+}
+
+// DeriveSessionKeysFunc defines the session key derivation function under
+// test. For LoRaWAN 1.0.x (optNeg=false), the appKey must be ignored.
+type DeriveSessionKeysFunc func(optNeg bool, nwkKey, appKey lorawan.AES128Key, netID lorawan.NetID, joinEUI lorawan.EUI64, joinNonce lorawan.JoinNonce, devNonce lorawan.DevNonce) (SessionKeys, error)
+
+// DeriveJSKeysFunc defines the JSIntKey and JSEncKey derivation function
+// under test.
+type DeriveJSKeysFunc func(nwkKey lorawan.AES128Key, devEUI lorawan.EUI64) (jsIntKey, jsEncKey lorawan.AES128Key, err error)
+
+// DataMICFunc defines the data frame MIC function under test.
+type DataMICFunc func(v DataMICVector) (lorawan.MIC, error)
+
+// JoinRequestMICFunc defines the join-request MIC function under test.
+type JoinRequestMICFunc func(phyPayload []byte, nwkKey lorawan.AES128Key) (lorawan.MIC, error)
+
+// CheckSessionKeys validates the given function against SessionKeyVectors.
+// For LoRaWAN 1.0.x vectors, only the FNwkSIntKey (NwkSKey) and AppSKey are
+// compared.
+func CheckSessionKeys(t testing.TB, fn DeriveSessionKeysFunc) {
+	t.Helper()
+
+	for _, v := range SessionKeyVectors {
+		keys, err := fn(v.OptNeg, v.NwkKey, v.AppKey, v.NetID, v.JoinEUI, v.JoinNonce, v.DevNonce)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", v.Name, err)
+			continue
+		}
+
+		if !v.OptNeg {
+			keys.SNwkSIntKey = lorawan.AES128Key{}
+			keys.NwkSEncKey = lorawan.AES128Key{}
+		}
+
+		compareKey(t, v.Name, "FNwkSIntKey", v.Expected.FNwkSIntKey, keys.FNwkSIntKey)
+		compareKey(t, v.Name, "SNwkSIntKey", v.Expected.SNwkSIntKey, keys.SNwkSIntKey)
+		compareKey(t, v.Name, "NwkSEncKey", v.Expected.NwkSEncKey, keys.NwkSEncKey)
+		compareKey(t, v.Name, "AppSKey", v.Expected.AppSKey, keys.AppSKey)
+	}
+}
+
+// CheckJSKeys validates the given function against JSKeyVectors.
+func CheckJSKeys(t testing.TB, fn DeriveJSKeysFunc) {
+	t.Helper()
+
+	for _, v := range JSKeyVectors {
+		jsIntKey, jsEncKey, err := fn(v.NwkKey, v.DevEUI)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", v.Name, err)
+			continue
+		}
+
+		compareKey(t, v.Name, "JSIntKey", v.ExpectedJSIntKey, jsIntKey)
+		compareKey(t, v.Name, "JSEncKey", v.ExpectedJSEncKey, jsEncKey)
+	}
+}
+
+// CheckDataMIC validates the given function against DataMICVectors.
+func CheckDataMIC(t testing.TB, fn DataMICFunc) {
+	t.Helper()
+
+	for _, v := range DataMICVectors {
+		mic, err := fn(v)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", v.Name, err)
+			continue
+		}
+		if mic != v.ExpectedMIC {
+			t.Errorf("%s: expected MIC %s, got %s", v.Name, v.ExpectedMIC, mic)
+		}
+	}
+}
+
+// CheckJoinRequestMIC validates the given function against
+// JoinRequestMICVectors.
+func CheckJoinRequestMIC(t testing.TB, fn JoinRequestMICFunc) {
+	t.Helper()
+
+	for _, v := range JoinRequestMICVectors {
+		mic, err := fn(v.PHYPayload, v.NwkKey)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", v.Name, err)
+			continue
+		}
+		if mic != v.ExpectedMIC {
+			t.Errorf("%s: expected MIC %s, got %s", v.Name, v.ExpectedMIC, mic)
+		}
+	}
+}
+
+func compareKey(t testing.TB, name, keyName string, expected, got lorawan.AES128Key) {
+	t.Helper()
+
+	if expected != got {
+		t.Errorf("%s: expected %s %s, got %s", name, keyName, expected, got)
+	}
+}
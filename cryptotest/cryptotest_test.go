@@ -0,0 +1,35 @@
+package cryptotest
+
+import (
+	"testing"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestDataMIC(t *testing.T) {
+	CheckDataMIC(t, func(v DataMICVector) (lorawan.MIC, error) {
+		var phy lorawan.PHYPayload
+		if err := phy.UnmarshalBinary(v.PHYPayload); err != nil {
+			return lorawan.MIC{}, err
+		}
+		phy.MACPayload.(*lorawan.MACPayload).FHDR.FCnt = v.FCnt
+
+		if err := phy.SetUplinkDataMIC(v.MACVersion, v.ConfFCnt, v.TXDR, v.TXCh, v.FNwkSIntKey, v.SNwkSIntKey); err != nil {
+			return lorawan.MIC{}, err
+		}
+		return phy.MIC, nil
+	})
+}
+
+func TestJoinRequestMIC(t *testing.T) {
+	CheckJoinRequestMIC(t, func(b []byte, nwkKey lorawan.AES128Key) (lorawan.MIC, error) {
+		var phy lorawan.PHYPayload
+		if err := phy.UnmarshalBinary(b); err != nil {
+			return lorawan.MIC{}, err
+		}
+		if err := phy.SetUplinkJoinMIC(nwkKey); err != nil {
+			return lorawan.MIC{}, err
+		}
+		return phy.MIC, nil
+	})
+}
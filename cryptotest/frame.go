@@ -0,0 +1,194 @@
+package cryptotest
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/brocaar/lorawan"
+)
+
+// FrameVector defines a LoRaWAN 1.0.x data frame test vector. The FOpts are
+// sent in clear and the FRMPayload is encrypted with the AppSKey.
+type FrameVector struct {
+	Name       string
+	MType      lorawan.MType
+	DevAddr    lorawan.DevAddr
+	FCtrl      lorawan.FCtrl // FOptsLen is set by the encoder
+	FCnt       uint32        // full 32 bit frame-counter
+	FOpts      []byte
+	FPort      uint8
+	FRMPayload []byte // plaintext
+	NwkSKey    lorawan.AES128Key
+	AppSKey    lorawan.AES128Key
+	PHYPayload []byte // as transmitted
+}
+
+// JoinAcceptVector defines a join-accept (answer to a join-request) test
+// vector. For LoRaWAN 1.0.x, the AppKey is used as NwkKey and JSIntKey.
+type JoinAcceptVector struct {
+	Name       string
+	NwkKey     lorawan.AES128Key // encryption key
+	JSIntKey   lorawan.AES128Key // MIC key (equal to the NwkKey for LoRaWAN 1.0.x)
+	JoinEUI    lorawan.EUI64     // LoRaWAN 1.1 MIC only
+	DevNonce   lorawan.DevNonce  // LoRaWAN 1.1 MIC only
+	Payload    lorawan.JoinAcceptPayload
+	PHYPayload []byte // as transmitted (encrypted)
+}
+
+// FrameVectors contains the data frame encoding test vectors.
+var FrameVectors = []FrameVector{
+	{
+		Name:       "Confirmed uplink with FOpts",
+		MType:      lorawan.ConfirmedDataUp,
+		DevAddr:    lorawan.DevAddr{1, 2, 3, 4},
+		FCnt:       0,
+		FOpts:      []byte{0x06, 0x73, 0x07}, // DevStatusAns
+		FPort:      10,
+		FRMPayload: []byte{1, 2, 3, 4},
+		NwkSKey:    lorawan.AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		AppSKey:    lorawan.AES128Key{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+		PHYPayload: []byte{0x80, 0x04, 0x03, 0x02, 0x01, 0x03, 0x00, 0x00, 0x06, 0x73, 0x07, 0x0a, 0xe2, 0x64, 0xd4, 0xf7, 0xe1, 0x17, 0xd2, 0xc0},
+	},
+	{
+		Name:       "Unconfirmed downlink with 32 bit frame-counter",
+		MType:      lorawan.UnconfirmedDataDown,
+		DevAddr:    lorawan.DevAddr{1, 2, 3, 4},
+		FCtrl:      lorawan.FCtrl{ADR: true, ACK: true},
+		FCnt:       0x00010203,
+		FPort:      5,
+		FRMPayload: []byte("hello"),
+		NwkSKey:    lorawan.AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		AppSKey:    lorawan.AES128Key{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+		PHYPayload: []byte{0x60, 0x04, 0x03, 0x02, 0x01, 0xa0, 0x03, 0x02, 0x05, 0x69, 0x8c, 0x6f, 0x57, 0x08, 0x61, 0xeb, 0x99, 0x88},
+	},
+}
+
+// JoinAcceptVectors contains the join-accept encryption test vectors.
+var JoinAcceptVectors = []JoinAcceptVector{
+	{
+		Name:     "LoRaWAN 1.0",
+		NwkKey:   lorawan.MustKey("00112233445566778899aabbccddeeff"),
+		JSIntKey: lorawan.MustKey("00112233445566778899aabbccddeeff"),
+		Payload: lorawan.JoinAcceptPayload{
+			JoinNonce: 5704647,
+			HomeNetID: lorawan.NetID{34, 17, 1},
+			DevAddr:   lorawan.DevAddr{2, 3, 25, 128},
+		},
+		PHYPayload: []byte{0x20, 0x49, 0x3e, 0xeb, 0x51, 0xfb, 0xa2, 0x11, 0x6f, 0x81, 0x0e, 0xdb, 0x37, 0x42, 0x97, 0x51, 0x42},
+	},
+	{
+		Name:     "LoRaWAN 1.0 with JoinEUI and DevNonce (ignored)",
+		NwkKey:   lorawan.AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		JSIntKey: lorawan.AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		JoinEUI:  lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1},
+		DevNonce: 258,
+		Payload: lorawan.JoinAcceptPayload{
+			JoinNonce: 65793,
+			HomeNetID: lorawan.NetID{2, 2, 2},
+			DevAddr:   lorawan.DevAddr{1, 2, 3, 4},
+		},
+		PHYPayload: []byte{0x20, 0x23, 0xcf, 0x33, 0x54, 0x89, 0xaa, 0xe3, 0x18, 0x3c, 0x0b, 0xe0, 0xba, 0xa8, 0xde, 0xe5, 0xf3},
+	},
+	{
+		Name:     "LoRaWAN 1.1",
+		NwkKey:   lorawan.AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		JSIntKey: lorawan.AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		JoinEUI:  lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1},
+		DevNonce: 258,
+		Payload: lorawan.JoinAcceptPayload{
+			JoinNonce:  65793,
+			HomeNetID:  lorawan.NetID{2, 2, 2},
+			DevAddr:    lorawan.DevAddr{1, 2, 3, 4},
+			DLSettings: lorawan.DLSettings{OptNeg: true},
+		},
+		PHYPayload: []byte{0x20, 0x7a, 0xbe, 0xea, 0x06, 0xb0, 0x29, 0x20, 0xf1, 0x1c, 0x02, 0xd0, 0x34, 0x8f, 0xcf, 0x18, 0x15},
+	},
+}
+
+// EncodeFrameFunc defines the data frame encoding function under test. It
+// must return the PHYPayload bytes for the given vector.
+type EncodeFrameFunc func(v FrameVector) ([]byte, error)
+
+// DecodeFrameFunc defines the data frame decoding function under test. It
+// must validate the MIC of v.PHYPayload and return the FPort and decrypted
+// FRMPayload.
+type DecodeFrameFunc func(v FrameVector) (fPort uint8, frmPayload []byte, err error)
+
+// EncryptJoinAcceptFunc defines the join-accept encoding function under
+// test. It must return the encrypted PHYPayload bytes for the given vector.
+type EncryptJoinAcceptFunc func(v JoinAcceptVector) ([]byte, error)
+
+// DecryptJoinAcceptFunc defines the join-accept decoding function under
+// test. It must decrypt and validate the MIC of v.PHYPayload and return the
+// join-accept payload.
+type DecryptJoinAcceptFunc func(v JoinAcceptVector) (lorawan.JoinAcceptPayload, error)
+
+// CheckEncodeFrame validates the given function against FrameVectors.
+func CheckEncodeFrame(t testing.TB, fn EncodeFrameFunc) {
+	t.Helper()
+
+	for _, v := range FrameVectors {
+		b, err := fn(v)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", v.Name, err)
+			continue
+		}
+		if !bytes.Equal(b, v.PHYPayload) {
+			t.Errorf("%s: expected PHYPayload %x, got %x", v.Name, v.PHYPayload, b)
+		}
+	}
+}
+
+// CheckDecodeFrame validates the given function against FrameVectors.
+func CheckDecodeFrame(t testing.TB, fn DecodeFrameFunc) {
+	t.Helper()
+
+	for _, v := range FrameVectors {
+		fPort, frmPayload, err := fn(v)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", v.Name, err)
+			continue
+		}
+		if fPort != v.FPort {
+			t.Errorf("%s: expected FPort %d, got %d", v.Name, v.FPort, fPort)
+		}
+		if !bytes.Equal(frmPayload, v.FRMPayload) {
+			t.Errorf("%s: expected FRMPayload %x, got %x", v.Name, v.FRMPayload, frmPayload)
+		}
+	}
+}
+
+// CheckEncryptJoinAccept validates the given function against
+// JoinAcceptVectors.
+func CheckEncryptJoinAccept(t testing.TB, fn EncryptJoinAcceptFunc) {
+	t.Helper()
+
+	for _, v := range JoinAcceptVectors {
+		b, err := fn(v)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", v.Name, err)
+			continue
+		}
+		if !bytes.Equal(b, v.PHYPayload) {
+			t.Errorf("%s: expected PHYPayload %x, got %x", v.Name, v.PHYPayload, b)
+		}
+	}
+}
+
+// CheckDecryptJoinAccept validates the given function against
+// JoinAcceptVectors.
+func CheckDecryptJoinAccept(t testing.TB, fn DecryptJoinAcceptFunc) {
+	t.Helper()
+
+	for _, v := range JoinAcceptVectors {
+		pl, err := fn(v)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", v.Name, err)
+			continue
+		}
+		if !reflect.DeepEqual(pl, v.Payload) {
+			t.Errorf("%s: expected payload %+v, got %+v", v.Name, v.Payload, pl)
+		}
+	}
+}
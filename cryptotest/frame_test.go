@@ -0,0 +1,127 @@
+package cryptotest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestEncodeFrame(t *testing.T) {
+	CheckEncodeFrame(t, func(v FrameVector) ([]byte, error) {
+		fPort := v.FPort
+		macPL := lorawan.MACPayload{
+			FHDR: lorawan.FHDR{
+				DevAddr: v.DevAddr,
+				FCtrl:   v.FCtrl,
+				FCnt:    v.FCnt,
+			},
+			FPort:      &fPort,
+			FRMPayload: []lorawan.Payload{&lorawan.DataPayload{Bytes: v.FRMPayload}},
+		}
+		if len(v.FOpts) != 0 {
+			macPL.FHDR.FOpts = []lorawan.Payload{&lorawan.DataPayload{Bytes: v.FOpts}}
+		}
+
+		phy := lorawan.PHYPayload{
+			MHDR: lorawan.MHDR{
+				MType: v.MType,
+				Major: lorawan.LoRaWANR1,
+			},
+			MACPayload: &macPL,
+		}
+
+		if err := phy.EncryptFRMPayload(v.AppSKey); err != nil {
+			return nil, err
+		}
+
+		switch v.MType {
+		case lorawan.UnconfirmedDataUp, lorawan.ConfirmedDataUp:
+			if err := phy.SetUplinkDataMIC(lorawan.LoRaWAN1_0, 0, 0, 0, v.NwkSKey, v.NwkSKey); err != nil {
+				return nil, err
+			}
+		default:
+			if err := phy.SetDownlinkDataMIC(lorawan.LoRaWAN1_0, 0, v.NwkSKey); err != nil {
+				return nil, err
+			}
+		}
+
+		return phy.MarshalBinary()
+	})
+}
+
+func TestDecodeFrame(t *testing.T) {
+	CheckDecodeFrame(t, func(v FrameVector) (uint8, []byte, error) {
+		var phy lorawan.PHYPayload
+		if err := phy.UnmarshalBinary(v.PHYPayload); err != nil {
+			return 0, nil, err
+		}
+		macPL := phy.MACPayload.(*lorawan.MACPayload)
+		macPL.FHDR.FCnt = v.FCnt
+
+		var ok bool
+		var err error
+		switch v.MType {
+		case lorawan.UnconfirmedDataUp, lorawan.ConfirmedDataUp:
+			ok, err = phy.ValidateUplinkDataMIC(lorawan.LoRaWAN1_0, 0, 0, 0, v.NwkSKey, v.NwkSKey)
+		default:
+			ok, err = phy.ValidateDownlinkDataMIC(lorawan.LoRaWAN1_0, 0, v.NwkSKey)
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		if !ok {
+			return 0, nil, errors.New("invalid MIC")
+		}
+
+		if err := phy.DecryptFRMPayload(v.AppSKey); err != nil {
+			return 0, nil, err
+		}
+
+		return *macPL.FPort, macPL.FRMPayload[0].(*lorawan.DataPayload).Bytes, nil
+	})
+}
+
+func TestEncryptJoinAccept(t *testing.T) {
+	CheckEncryptJoinAccept(t, func(v JoinAcceptVector) ([]byte, error) {
+		pl := v.Payload
+		phy := lorawan.PHYPayload{
+			MHDR: lorawan.MHDR{
+				MType: lorawan.JoinAccept,
+				Major: lorawan.LoRaWANR1,
+			},
+			MACPayload: &pl,
+		}
+
+		if err := phy.SetDownlinkJoinMIC(lorawan.JoinRequestType, v.JoinEUI, v.DevNonce, v.JSIntKey); err != nil {
+			return nil, err
+		}
+		if err := phy.EncryptJoinAcceptPayload(v.NwkKey); err != nil {
+			return nil, err
+		}
+
+		return phy.MarshalBinary()
+	})
+}
+
+func TestDecryptJoinAccept(t *testing.T) {
+	CheckDecryptJoinAccept(t, func(v JoinAcceptVector) (lorawan.JoinAcceptPayload, error) {
+		var phy lorawan.PHYPayload
+		if err := phy.UnmarshalBinary(v.PHYPayload); err != nil {
+			return lorawan.JoinAcceptPayload{}, err
+		}
+		if err := phy.DecryptJoinAcceptPayload(v.NwkKey); err != nil {
+			return lorawan.JoinAcceptPayload{}, err
+		}
+
+		ok, err := phy.ValidateDownlinkJoinMIC(lorawan.JoinRequestType, v.JoinEUI, v.DevNonce, v.JSIntKey)
+		if err != nil {
+			return lorawan.JoinAcceptPayload{}, err
+		}
+		if !ok {
+			return lorawan.JoinAcceptPayload{}, errors.New("invalid MIC")
+		}
+
+		return *phy.MACPayload.(*lorawan.JoinAcceptPayload), nil
+	})
+}
@@ -0,0 +1,63 @@
+package lorawan
+
+import (
+	"encoding/binary"
+	"sync"
+)
+
+// DevAddrAllocator defines the interface for allocating DevAddrs, e.g. by
+// a network server on OTAA activation.
+type DevAddrAllocator interface {
+	// Allocate returns the next DevAddr.
+	Allocate() (DevAddr, error)
+}
+
+// SequentialDevAddrAllocator allocates DevAddrs sequentially within the
+// DevAddr prefix of a NetID. After the last NwkAddr of the prefix has been
+// allocated, it wraps around to the first NwkAddr.
+//
+// The SequentialDevAddrAllocator is safe for concurrent use.
+type SequentialDevAddrAllocator struct {
+	mu      sync.Mutex
+	netID   NetID
+	next    uint32
+	nwkMask uint32
+}
+
+// NewSequentialDevAddrAllocator creates a new SequentialDevAddrAllocator for
+// the given NetID. The first allocated DevAddr will have the given NwkAddr
+// (e.g. restored from a previous Next call), which is truncated to the
+// number of NwkAddr bits of the NetID type.
+func NewSequentialDevAddrAllocator(netID NetID, next uint32) *SequentialDevAddrAllocator {
+	p := netID.DevAddrPrefix()
+	nwkMask := uint32(1<<uint(32-p.Length)) - 1
+
+	return &SequentialDevAddrAllocator{
+		netID:   netID,
+		next:    next & nwkMask,
+		nwkMask: nwkMask,
+	}
+}
+
+// Allocate implements the DevAddrAllocator interface.
+func (a *SequentialDevAddrAllocator) Allocate() (DevAddr, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var devAddr DevAddr
+	binary.BigEndian.PutUint32(devAddr[:], a.next)
+	devAddr.SetAddrPrefix(a.netID)
+
+	a.next = (a.next + 1) & a.nwkMask
+
+	return devAddr, nil
+}
+
+// Next returns the NwkAddr of the next DevAddr that will be allocated. This
+// value can be persisted to continue the allocation after a restart.
+func (a *SequentialDevAddrAllocator) Next() uint32 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.next
+}
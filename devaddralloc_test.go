@@ -0,0 +1,54 @@
+package lorawan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequentialDevAddrAllocator(t *testing.T) {
+	tests := []struct {
+		Name      string
+		NetID     NetID
+		Next      uint32
+		Expected  []DevAddr
+		NextAfter uint32
+	}{
+		{
+			Name:      "type 0",
+			NetID:     NetID{0x00, 0x00, 0x13},
+			Next:      0,
+			Expected:  []DevAddr{{0x26, 0x00, 0x00, 0x00}, {0x26, 0x00, 0x00, 0x01}},
+			NextAfter: 2,
+		},
+		{
+			Name:      "type 0 wrap around",
+			NetID:     NetID{0x00, 0x00, 0x13},
+			Next:      0x1fffffe,
+			Expected:  []DevAddr{{0x27, 0xff, 0xff, 0xfe}, {0x27, 0xff, 0xff, 0xff}, {0x26, 0x00, 0x00, 0x00}},
+			NextAfter: 1,
+		},
+		{
+			Name:      "type 7",
+			NetID:     NetID{0xe0, 0x00, 0x01},
+			Next:      0x7f,
+			Expected:  []DevAddr{{0xfe, 0x00, 0x00, 0xff}, {0xfe, 0x00, 0x00, 0x80}},
+			NextAfter: 1,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var alloc DevAddrAllocator = NewSequentialDevAddrAllocator(tst.NetID, tst.Next)
+			for _, exp := range tst.Expected {
+				devAddr, err := alloc.Allocate()
+				assert.NoError(err)
+				assert.Equal(exp, devAddr)
+				assert.True(devAddr.IsNetID(tst.NetID))
+			}
+			assert.Equal(tst.NextAfter, alloc.(*SequentialDevAddrAllocator).Next())
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package lorawan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DevAddrPrefix represents a DevAddr prefix, e.g. the DevAddr space owned
+// by a NetID. Its text representation is CIDR-like, e.g. 5a000000/7.
+type DevAddrPrefix struct {
+	// Addr contains the prefix bits. The bits after the prefix are zero.
+	Addr DevAddr
+
+	// Length contains the prefix length in bits.
+	Length int
+}
+
+// ParseDevAddrPrefix parses the given DevAddr prefix (e.g. 5a000000/7).
+// The bits after the prefix length are cleared.
+func ParseDevAddrPrefix(s string) (DevAddrPrefix, error) {
+	var p DevAddrPrefix
+	if err := p.UnmarshalText([]byte(s)); err != nil {
+		return DevAddrPrefix{}, err
+	}
+	return p, nil
+}
+
+// String implements fmt.Stringer.
+func (p DevAddrPrefix) String() string {
+	return fmt.Sprintf("%s/%d", p.Addr, p.Length)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p DevAddrPrefix) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *DevAddrPrefix) UnmarshalText(text []byte) error {
+	parts := strings.SplitN(string(text), "/", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("lorawan: DevAddr prefix must be in the format <DevAddr>/<length>, got: %s", text)
+	}
+
+	var addr DevAddr
+	if err := addr.UnmarshalText([]byte(parts[0])); err != nil {
+		return err
+	}
+
+	length, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return fmt.Errorf("lorawan: invalid DevAddr prefix length: %w", err)
+	}
+	if length < 0 || length > 32 {
+		return fmt.Errorf("lorawan: DevAddr prefix length must be between 0 and 32, got: %d", length)
+	}
+
+	p.Length = length
+	binary.BigEndian.PutUint32(p.Addr[:], binary.BigEndian.Uint32(addr[:])&p.mask())
+
+	return nil
+}
+
+// Matches returns true when the given DevAddr is within the prefix.
+func (p DevAddrPrefix) Matches(devAddr DevAddr) bool {
+	mask := p.mask()
+	return binary.BigEndian.Uint32(devAddr[:])&mask == binary.BigEndian.Uint32(p.Addr[:])&mask
+}
+
+// Contains returns true when the given prefix is a subset of (or equal to)
+// the prefix.
+func (p DevAddrPrefix) Contains(other DevAddrPrefix) bool {
+	return other.Length >= p.Length && p.Matches(other.Addr)
+}
+
+// Overlaps returns true when both prefixes have DevAddrs in common.
+func (p DevAddrPrefix) Overlaps(other DevAddrPrefix) bool {
+	return p.Contains(other) || other.Contains(p)
+}
+
+func (p DevAddrPrefix) mask() uint32 {
+	if p.Length <= 0 {
+		return 0
+	}
+	if p.Length >= 32 {
+		return 0xffffffff
+	}
+	return ^uint32(0) << uint32(32-p.Length)
+}
+
+// DevAddrPrefixes represents a set of DevAddr prefixes, e.g. a routing
+// filter.
+type DevAddrPrefixes []DevAddrPrefix
+
+// Matches returns true when the given DevAddr matches any of the prefixes.
+func (ps DevAddrPrefixes) Matches(devAddr DevAddr) bool {
+	for _, p := range ps {
+		if p.Matches(devAddr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add returns the set with the given prefix added. When the prefix is
+// already covered by the set, the set is returned unchanged. Prefixes
+// covered by the given prefix are removed.
+func (ps DevAddrPrefixes) Add(prefix DevAddrPrefix) DevAddrPrefixes {
+	var out DevAddrPrefixes
+	for _, p := range ps {
+		if p.Contains(prefix) {
+			return ps
+		}
+		if !prefix.Contains(p) {
+			out = append(out, p)
+		}
+	}
+	return append(out, prefix)
+}
+
+// Remove returns the set without the prefixes which are covered by the
+// given prefix.
+func (ps DevAddrPrefixes) Remove(prefix DevAddrPrefix) DevAddrPrefixes {
+	var out DevAddrPrefixes
+	for _, p := range ps {
+		if !prefix.Contains(p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Union returns the union of both sets.
+func (ps DevAddrPrefixes) Union(other DevAddrPrefixes) DevAddrPrefixes {
+	out := append(DevAddrPrefixes(nil), ps...)
+	for _, p := range other {
+		out = out.Add(p)
+	}
+	return out
+}
+
+// Intersection returns the prefixes covered by both sets.
+func (ps DevAddrPrefixes) Intersection(other DevAddrPrefixes) DevAddrPrefixes {
+	var out DevAddrPrefixes
+	for _, a := range ps {
+		for _, b := range other {
+			switch {
+			case a.Contains(b):
+				out = out.Add(b)
+			case b.Contains(a):
+				out = out.Add(a)
+			}
+		}
+	}
+	return out
+}
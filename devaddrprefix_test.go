@@ -0,0 +1,138 @@
+package lorawan
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevAddrPrefix(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Text    string
+		Prefix  DevAddrPrefix
+		String  string
+		Error   bool
+		Matches []DevAddr
+		NoMatch []DevAddr
+	}{
+		{
+			Name:    "NetID type 0",
+			Text:    "5a000000/7",
+			Prefix:  DevAddrPrefix{Addr: DevAddr{90, 0, 0, 0}, Length: 7},
+			String:  "5a000000/7",
+			Matches: []DevAddr{{90, 0, 0, 0}, {91, 255, 255, 255}},
+			NoMatch: []DevAddr{{92, 0, 0, 0}, {89, 255, 255, 255}},
+		},
+		{
+			Name:    "bits after prefix are cleared",
+			Text:    "01ffffff/8",
+			Prefix:  DevAddrPrefix{Addr: DevAddr{1, 0, 0, 0}, Length: 8},
+			String:  "01000000/8",
+			Matches: []DevAddr{{1, 2, 3, 4}},
+		},
+		{
+			Name:    "match all",
+			Text:    "00000000/0",
+			Prefix:  DevAddrPrefix{},
+			String:  "00000000/0",
+			Matches: []DevAddr{{0, 0, 0, 0}, {255, 255, 255, 255}},
+		},
+		{
+			Name:    "single address",
+			Text:    "01020304/32",
+			Prefix:  DevAddrPrefix{Addr: DevAddr{1, 2, 3, 4}, Length: 32},
+			String:  "01020304/32",
+			Matches: []DevAddr{{1, 2, 3, 4}},
+			NoMatch: []DevAddr{{1, 2, 3, 5}},
+		},
+		{
+			Name:  "missing length",
+			Text:  "01020304",
+			Error: true,
+		},
+		{
+			Name:  "invalid length",
+			Text:  "01020304/33",
+			Error: true,
+		},
+		{
+			Name:  "invalid DevAddr",
+			Text:  "010203/8",
+			Error: true,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			p, err := ParseDevAddrPrefix(tst.Text)
+			if tst.Error {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tst.Prefix, p)
+			assert.Equal(tst.String, p.String())
+
+			for _, a := range tst.Matches {
+				assert.True(p.Matches(a), a.String())
+			}
+			for _, a := range tst.NoMatch {
+				assert.False(p.Matches(a), a.String())
+			}
+		})
+	}
+}
+
+func TestDevAddrPrefixJSON(t *testing.T) {
+	assert := require.New(t)
+
+	in := struct {
+		Prefix DevAddrPrefix `json:"prefix"`
+	}{
+		Prefix: NetID{0, 0, 109}.DevAddrPrefix(),
+	}
+
+	b, err := json.Marshal(in)
+	assert.NoError(err)
+	assert.Equal(`{"prefix":"5a000000/7"}`, string(b))
+
+	in.Prefix = DevAddrPrefix{}
+	assert.NoError(json.Unmarshal(b, &in))
+	assert.Equal(NetID{0, 0, 109}.DevAddrPrefix(), in.Prefix)
+}
+
+func TestDevAddrPrefixes(t *testing.T) {
+	assert := require.New(t)
+
+	p8, _ := ParseDevAddrPrefix("01000000/8")
+	p16, _ := ParseDevAddrPrefix("01020000/16")
+	p24, _ := ParseDevAddrPrefix("01020300/24")
+	other, _ := ParseDevAddrPrefix("02000000/8")
+
+	assert.True(p8.Contains(p16))
+	assert.False(p16.Contains(p8))
+	assert.True(p16.Overlaps(p8))
+	assert.False(p8.Overlaps(other))
+
+	var set DevAddrPrefixes
+	set = set.Add(p16)
+	set = set.Add(p24)
+	assert.Equal(DevAddrPrefixes{p16}, set)
+
+	set = set.Add(other)
+	set = set.Add(p8)
+	assert.Equal(DevAddrPrefixes{other, p8}, set)
+	assert.True(set.Matches(DevAddr{1, 255, 0, 0}))
+	assert.True(set.Matches(DevAddr{2, 0, 0, 0}))
+	assert.False(set.Matches(DevAddr{3, 0, 0, 0}))
+
+	assert.Equal(DevAddrPrefixes{p8}, set.Remove(other))
+
+	assert.Equal(DevAddrPrefixes{other, p8}, DevAddrPrefixes{p16, other}.Union(DevAddrPrefixes{p8}))
+	assert.Equal(DevAddrPrefixes{p24}, DevAddrPrefixes{p8, other}.Intersection(DevAddrPrefixes{p24}))
+	assert.Len(DevAddrPrefixes{other}.Intersection(DevAddrPrefixes{p8}), 0)
+}
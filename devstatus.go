@@ -0,0 +1,171 @@
+package lorawan
+
+import (
+	"sync"
+	"time"
+)
+
+// DevStatusAns battery values with a special meaning.
+const (
+	BatteryExternalPower uint8 = 0
+	BatteryUnavailable   uint8 = 255
+)
+
+// ExternalPower returns true when the device is connected to an external
+// power source.
+func (p DevStatusAnsPayload) ExternalPower() bool {
+	return p.Battery == BatteryExternalPower
+}
+
+// BatteryLevel returns the battery level as percentage. It returns false
+// when the device is connected to an external power source or when it was
+// not able to measure the battery level.
+func (p DevStatusAnsPayload) BatteryLevel() (float32, bool) {
+	if p.Battery == BatteryExternalPower || p.Battery == BatteryUnavailable {
+		return 0, false
+	}
+
+	return float32(p.Battery) / 254 * 100, true
+}
+
+// DevStatus holds the interpreted DevStatusAns of a device.
+type DevStatus struct {
+	// ExternalPower is set when the device is connected to an external
+	// power source.
+	ExternalPower bool `json:"externalPower"`
+
+	// BatteryLevelUnavailable is set when the device was not able to
+	// measure the battery level.
+	BatteryLevelUnavailable bool `json:"batteryLevelUnavailable"`
+
+	// BatteryLevel contains the battery level (%).
+	BatteryLevel float32 `json:"batteryLevel"`
+
+	// Margin contains the demodulation SNR margin (dB) of the DevStatusReq.
+	Margin int `json:"margin"`
+
+	// ReceivedAt contains the time at which the DevStatusAns was received.
+	ReceivedAt time.Time `json:"receivedAt"`
+}
+
+// NewDevStatus returns the DevStatus for the given DevStatusAns payload.
+func NewDevStatus(p DevStatusAnsPayload, receivedAt time.Time) DevStatus {
+	level, _ := p.BatteryLevel()
+
+	return DevStatus{
+		ExternalPower:           p.ExternalPower(),
+		BatteryLevelUnavailable: p.Battery == BatteryUnavailable,
+		BatteryLevel:            level,
+		Margin:                  int(p.Margin),
+		ReceivedAt:              receivedAt,
+	}
+}
+
+// DevStatusPolicy defines how often a DevStatusReq must be sent to a
+// device.
+type DevStatusPolicy struct {
+	// Interval defines the interval between two DevStatusReq mac-commands.
+	// When set to 0, no DevStatusReq is requested.
+	Interval time.Duration `json:"interval"`
+}
+
+type devStatusState struct {
+	policy      *DevStatusPolicy
+	requestedAt time.Time
+	status      *DevStatus
+}
+
+// DevStatusTracker keeps track of the DevStatusReq requests and the
+// DevStatusAns answers per device and decides when a new DevStatusReq must
+// be sent. It is safe for concurrent use.
+type DevStatusTracker struct {
+	mu            sync.RWMutex
+	defaultPolicy DevStatusPolicy
+	devices       map[EUI64]*devStatusState
+}
+
+// NewDevStatusTracker returns a new DevStatusTracker using the given
+// default policy for devices without device specific policy.
+func NewDevStatusTracker(defaultPolicy DevStatusPolicy) *DevStatusTracker {
+	return &DevStatusTracker{
+		defaultPolicy: defaultPolicy,
+		devices:       make(map[EUI64]*devStatusState),
+	}
+}
+
+// SetPolicy sets the device specific policy.
+func (t *DevStatusTracker) SetPolicy(devEUI EUI64, policy DevStatusPolicy) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.getState(devEUI).policy = &policy
+}
+
+// RequestDue returns true when a DevStatusReq must be sent to the device,
+// e.g. with the next downlink.
+func (t *DevStatusTracker) RequestDue(devEUI EUI64, now time.Time) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	policy := t.defaultPolicy
+	s, ok := t.devices[devEUI]
+	if ok && s.policy != nil {
+		policy = *s.policy
+	}
+
+	if policy.Interval == 0 {
+		return false
+	}
+	if !ok || s.requestedAt.IsZero() {
+		return true
+	}
+
+	return now.Sub(s.requestedAt) >= policy.Interval
+}
+
+// Requested must be called when a DevStatusReq has been sent to the device.
+func (t *DevStatusTracker) Requested(devEUI EUI64, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.getState(devEUI).requestedAt = now
+}
+
+// HandleAns stores the given DevStatusAns of the device and returns the
+// interpreted DevStatus.
+func (t *DevStatusTracker) HandleAns(devEUI EUI64, p DevStatusAnsPayload, now time.Time) DevStatus {
+	status := NewDevStatus(p, now)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.getState(devEUI).status = &status
+
+	return status
+}
+
+// GetStatus returns the last received DevStatus of the device. It returns
+// false when no DevStatusAns has been received yet.
+func (t *DevStatusTracker) GetStatus(devEUI EUI64) (DevStatus, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	s, ok := t.devices[devEUI]
+	if !ok || s.status == nil {
+		return DevStatus{}, false
+	}
+	return *s.status, true
+}
+
+// Remove removes all state of the given device.
+func (t *DevStatusTracker) Remove(devEUI EUI64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.devices, devEUI)
+}
+
+func (t *DevStatusTracker) getState(devEUI EUI64) *devStatusState {
+	s, ok := t.devices[devEUI]
+	if !ok {
+		s = &devStatusState{}
+		t.devices[devEUI] = s
+	}
+	return s
+}
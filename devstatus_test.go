@@ -0,0 +1,101 @@
+package lorawan
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDevStatus(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		Name     string
+		Payload  DevStatusAnsPayload
+		Expected DevStatus
+	}{
+		{
+			Name:    "external power",
+			Payload: DevStatusAnsPayload{Battery: 0, Margin: 10},
+			Expected: DevStatus{
+				ExternalPower: true,
+				Margin:        10,
+				ReceivedAt:    now,
+			},
+		},
+		{
+			Name:    "unavailable",
+			Payload: DevStatusAnsPayload{Battery: 255, Margin: -5},
+			Expected: DevStatus{
+				BatteryLevelUnavailable: true,
+				Margin:                  -5,
+				ReceivedAt:              now,
+			},
+		},
+		{
+			Name:    "full",
+			Payload: DevStatusAnsPayload{Battery: 254},
+			Expected: DevStatus{
+				BatteryLevel: 100,
+				ReceivedAt:   now,
+			},
+		},
+		{
+			Name:    "half",
+			Payload: DevStatusAnsPayload{Battery: 127, Margin: 31},
+			Expected: DevStatus{
+				BatteryLevel: 50,
+				Margin:       31,
+				ReceivedAt:   now,
+			},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+			assert.Equal(tst.Expected, NewDevStatus(tst.Payload, now))
+		})
+	}
+}
+
+func TestDevStatusTracker(t *testing.T) {
+	assert := require.New(t)
+
+	now := time.Now()
+	devA := EUI64{1}
+	devB := EUI64{2}
+
+	tr := NewDevStatusTracker(DevStatusPolicy{Interval: time.Hour})
+
+	// never requested
+	assert.True(tr.RequestDue(devA, now))
+	tr.Requested(devA, now)
+	assert.False(tr.RequestDue(devA, now.Add(time.Minute)))
+	assert.True(tr.RequestDue(devA, now.Add(time.Hour)))
+
+	// device specific policy
+	tr.SetPolicy(devB, DevStatusPolicy{})
+	assert.False(tr.RequestDue(devB, now))
+
+	tr.SetPolicy(devB, DevStatusPolicy{Interval: time.Minute})
+	tr.Requested(devB, now)
+	assert.True(tr.RequestDue(devB, now.Add(time.Minute)))
+
+	// status
+	_, ok := tr.GetStatus(devA)
+	assert.False(ok)
+
+	status := tr.HandleAns(devA, DevStatusAnsPayload{Battery: 254, Margin: 7}, now)
+	assert.Equal(float32(100), status.BatteryLevel)
+
+	s, ok := tr.GetStatus(devA)
+	assert.True(ok)
+	assert.Equal(status, s)
+
+	tr.Remove(devA)
+	_, ok = tr.GetStatus(devA)
+	assert.False(ok)
+	assert.True(tr.RequestDue(devA, now))
+}
@@ -0,0 +1,250 @@
+package lorawan
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FrameField defines an annotated byte range of a raw LoRaWAN frame.
+type FrameField struct {
+	// Name holds the name of the field (e.g. MHDR, DevAddr or FCnt).
+	Name string
+
+	// Offset holds the offset of the field within the frame.
+	Offset int
+
+	// Bytes holds the raw bytes of the field.
+	Bytes []byte
+
+	// Value holds the (optional) decoded value of the field.
+	Value string
+}
+
+// AnnotateFrame splits the given raw frame (PHYPayload) into annotated byte
+// ranges, based on the MType. Unlike PHYPayload.UnmarshalBinary, this does
+// not validate the content of the fields. When the frame is malformed (e.g.
+// truncated), it returns the fields annotated so far, the remaining bytes
+// as an "Unknown" field and an error describing the problem.
+func AnnotateFrame(data []byte) ([]FrameField, error) {
+	a := frameAnnotator{data: data}
+
+	if len(data) == 0 {
+		return nil, errors.New("lorawan: frame is empty")
+	}
+
+	mhdr := MHDR{
+		MType: MType(data[0] >> 5),
+		Major: Major(data[0] & 0x03),
+	}
+	a.add("MHDR", 1, fmt.Sprintf("MType: %s, Major: %s", mhdr.MType, mhdr.Major))
+
+	// all frame types, except for proprietary frames, end with the MIC
+	payloadLen := len(data) - 1 - 4
+	if mhdr.MType == Proprietary {
+		payloadLen = len(data) - 1
+	}
+
+	switch mhdr.MType {
+	case JoinRequest:
+		a.addEUI64("JoinEUI")
+		a.addEUI64("DevEUI")
+		a.addUint16("DevNonce")
+	case JoinAccept:
+		a.add("JoinAcceptPayload", payloadLen, "encrypted")
+	case UnconfirmedDataUp, UnconfirmedDataDown, ConfirmedDataUp, ConfirmedDataDown:
+		a.annotateMACPayload(payloadLen)
+	case RejoinRequest:
+		if a.available() >= 1 && a.data[a.offset] == 1 {
+			a.add("RejoinType", 1, "1")
+			a.addEUI64("JoinEUI")
+			a.addEUI64("DevEUI")
+			a.addUint16("RJcount1")
+		} else {
+			if a.available() >= 1 {
+				a.add("RejoinType", 1, fmt.Sprintf("%d", a.data[a.offset]))
+			}
+			a.addNetID("NetID")
+			a.addEUI64("DevEUI")
+			a.addUint16("RJcount0")
+		}
+	case Proprietary:
+		a.add("Payload", payloadLen, "")
+	}
+
+	if mhdr.MType != Proprietary && a.err == nil && a.available() != 4 {
+		a.fail(fmt.Errorf("lorawan: expected 4 bytes MIC at offset %d, %d bytes remaining", a.offset, a.available()))
+	}
+	if mhdr.MType != Proprietary && a.err == nil {
+		a.add("MIC", 4, "")
+	}
+
+	if a.available() > 0 {
+		a.fields = append(a.fields, FrameField{
+			Name:   "Unknown",
+			Offset: a.offset,
+			Bytes:  a.data[a.offset:],
+		})
+	}
+
+	return a.fields, a.err
+}
+
+// DumpFrame returns an annotated hex dump of the given raw frame
+// (PHYPayload), one field per line. See AnnotateFrame.
+func DumpFrame(data []byte) string {
+	fields, err := AnnotateFrame(data)
+
+	var sb strings.Builder
+	for _, f := range fields {
+		line := fmt.Sprintf("%04x  %-17s %-47s %s", f.Offset, f.Name, hexBytes(f.Bytes), f.Value)
+		sb.WriteString(strings.TrimRight(line, " "))
+		sb.WriteString("\n")
+	}
+	if err != nil {
+		fmt.Fprintf(&sb, "error: %s\n", err)
+	}
+
+	return sb.String()
+}
+
+type frameAnnotator struct {
+	data   []byte
+	offset int
+	fields []FrameField
+	err    error
+}
+
+func (a *frameAnnotator) available() int {
+	return len(a.data) - a.offset
+}
+
+func (a *frameAnnotator) fail(err error) {
+	if a.err == nil {
+		a.err = err
+	}
+}
+
+// take returns the next n bytes or nil (and sets the error) when the frame
+// does not contain enough bytes.
+func (a *frameAnnotator) take(name string, n int) []byte {
+	if a.err != nil {
+		return nil
+	}
+	if n < 0 || a.available() < n {
+		a.fail(fmt.Errorf("lorawan: frame too short for %s at offset %d", name, a.offset))
+		return nil
+	}
+
+	b := a.data[a.offset : a.offset+n]
+	a.offset += n
+	return b
+}
+
+func (a *frameAnnotator) add(name string, n int, value string) []byte {
+	offset := a.offset
+	b := a.take(name, n)
+	if b == nil {
+		return nil
+	}
+
+	a.fields = append(a.fields, FrameField{
+		Name:   name,
+		Offset: offset,
+		Bytes:  b,
+		Value:  value,
+	})
+	return b
+}
+
+func (a *frameAnnotator) addEUI64(name string) {
+	offset := a.offset
+	b := a.take(name, 8)
+	if b == nil {
+		return
+	}
+
+	var eui EUI64
+	if err := eui.UnmarshalBinary(b); err != nil {
+		a.fail(err)
+		return
+	}
+	a.fields = append(a.fields, FrameField{Name: name, Offset: offset, Bytes: b, Value: eui.String()})
+}
+
+func (a *frameAnnotator) addNetID(name string) {
+	offset := a.offset
+	b := a.take(name, 3)
+	if b == nil {
+		return
+	}
+
+	var netID NetID
+	if err := netID.UnmarshalBinary(b); err != nil {
+		a.fail(err)
+		return
+	}
+	a.fields = append(a.fields, FrameField{Name: name, Offset: offset, Bytes: b, Value: netID.String()})
+}
+
+func (a *frameAnnotator) addUint16(name string) {
+	offset := a.offset
+	b := a.take(name, 2)
+	if b == nil {
+		return
+	}
+
+	a.fields = append(a.fields, FrameField{Name: name, Offset: offset, Bytes: b, Value: fmt.Sprintf("%d", binary.LittleEndian.Uint16(b))})
+}
+
+func (a *frameAnnotator) annotateMACPayload(payloadLen int) {
+	end := a.offset + payloadLen
+
+	offset := a.offset
+	b := a.take("DevAddr", 4)
+	if b == nil {
+		return
+	}
+	var devAddr DevAddr
+	if err := devAddr.UnmarshalBinary(b); err != nil {
+		a.fail(err)
+		return
+	}
+	a.fields = append(a.fields, FrameField{Name: "DevAddr", Offset: offset, Bytes: b, Value: devAddr.String()})
+
+	fCtrl := a.add("FCtrl", 1, "")
+	if fCtrl == nil {
+		return
+	}
+	fOptsLen := int(fCtrl[0] & 0x0f)
+	a.fields[len(a.fields)-1].Value = fmt.Sprintf("ADR: %t, ACK: %t, FOptsLen: %d", fCtrl[0]&0x80 != 0, fCtrl[0]&0x20 != 0, fOptsLen)
+
+	a.addUint16("FCnt")
+
+	if fOptsLen > 0 {
+		a.add("FOpts", fOptsLen, "")
+	}
+
+	if a.err != nil || a.offset >= end {
+		return
+	}
+
+	fPort := a.add("FPort", 1, "")
+	if fPort == nil {
+		return
+	}
+	a.fields[len(a.fields)-1].Value = fmt.Sprintf("%d", fPort[0])
+
+	if a.offset < end {
+		a.add("FRMPayload", end-a.offset, "")
+	}
+}
+
+func hexBytes(b []byte) string {
+	parts := make([]string, len(b))
+	for i := range b {
+		parts[i] = fmt.Sprintf("%02x", b[i])
+	}
+	return strings.Join(parts, " ")
+}
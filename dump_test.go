@@ -0,0 +1,93 @@
+package lorawan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotateFrame(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Data          []byte
+		Expected      []FrameField
+		ExpectedError string
+	}{
+		{
+			Name: "unconfirmed data up",
+			Data: []byte{0x40, 0x04, 0x03, 0x02, 0x01, 0x81, 0x0a, 0x00, 0x02, 0x0a, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06},
+			Expected: []FrameField{
+				{Name: "MHDR", Offset: 0, Bytes: []byte{0x40}, Value: "MType: UnconfirmedDataUp, Major: LoRaWANR1"},
+				{Name: "DevAddr", Offset: 1, Bytes: []byte{0x04, 0x03, 0x02, 0x01}, Value: "01020304"},
+				{Name: "FCtrl", Offset: 5, Bytes: []byte{0x81}, Value: "ADR: true, ACK: false, FOptsLen: 1"},
+				{Name: "FCnt", Offset: 6, Bytes: []byte{0x0a, 0x00}, Value: "10"},
+				{Name: "FOpts", Offset: 8, Bytes: []byte{0x02}},
+				{Name: "FPort", Offset: 9, Bytes: []byte{0x0a}, Value: "10"},
+				{Name: "FRMPayload", Offset: 10, Bytes: []byte{0x01, 0x02}},
+				{Name: "MIC", Offset: 12, Bytes: []byte{0x03, 0x04, 0x05, 0x06}},
+			},
+		},
+		{
+			Name: "join-request",
+			Data: []byte{0x00, 8, 7, 6, 5, 4, 3, 2, 1, 1, 2, 3, 4, 5, 6, 7, 8, 0x01, 0x02, 1, 2, 3, 4},
+			Expected: []FrameField{
+				{Name: "MHDR", Offset: 0, Bytes: []byte{0x00}, Value: "MType: JoinRequest, Major: LoRaWANR1"},
+				{Name: "JoinEUI", Offset: 1, Bytes: []byte{8, 7, 6, 5, 4, 3, 2, 1}, Value: "0102030405060708"},
+				{Name: "DevEUI", Offset: 9, Bytes: []byte{1, 2, 3, 4, 5, 6, 7, 8}, Value: "0807060504030201"},
+				{Name: "DevNonce", Offset: 17, Bytes: []byte{0x01, 0x02}, Value: "513"},
+				{Name: "MIC", Offset: 19, Bytes: []byte{1, 2, 3, 4}},
+			},
+		},
+		{
+			Name: "truncated FOpts",
+			Data: []byte{0x40, 0x04, 0x03, 0x02, 0x01, 0x0f, 0x0a, 0x00, 0x01, 0x02, 0x03, 0x04},
+			Expected: []FrameField{
+				{Name: "MHDR", Offset: 0, Bytes: []byte{0x40}, Value: "MType: UnconfirmedDataUp, Major: LoRaWANR1"},
+				{Name: "DevAddr", Offset: 1, Bytes: []byte{0x04, 0x03, 0x02, 0x01}, Value: "01020304"},
+				{Name: "FCtrl", Offset: 5, Bytes: []byte{0x0f}, Value: "ADR: false, ACK: false, FOptsLen: 15"},
+				{Name: "FCnt", Offset: 6, Bytes: []byte{0x0a, 0x00}, Value: "10"},
+				{Name: "Unknown", Offset: 8, Bytes: []byte{0x01, 0x02, 0x03, 0x04}},
+			},
+			ExpectedError: "lorawan: frame too short for FOpts at offset 8",
+		},
+		{
+			Name: "proprietary",
+			Data: []byte{0xe0, 0x01, 0x02},
+			Expected: []FrameField{
+				{Name: "MHDR", Offset: 0, Bytes: []byte{0xe0}, Value: "MType: Proprietary, Major: LoRaWANR1"},
+				{Name: "Payload", Offset: 1, Bytes: []byte{0x01, 0x02}},
+			},
+		},
+		{
+			Name:          "empty",
+			ExpectedError: "lorawan: frame is empty",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			fields, err := AnnotateFrame(tst.Data)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(tst.Expected, fields)
+		})
+	}
+}
+
+func TestDumpFrame(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal(`0000  MHDR              e0                                              MType: Proprietary, Major: LoRaWANR1
+0001  Payload           01 02
+`, DumpFrame([]byte{0xe0, 0x01, 0x02}))
+
+	assert.Equal(`0000  MHDR              40                                              MType: UnconfirmedDataUp, Major: LoRaWANR1
+0001  Unknown           01 02
+error: lorawan: frame too short for DevAddr at offset 1
+`, DumpFrame([]byte{0x40, 0x01, 0x02}))
+}
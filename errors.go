@@ -0,0 +1,121 @@
+package lorawan
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors which can be tested for using errors.Is. Note that the errors
+// returned by this package are wrapping these errors and that their
+// messages are more descriptive.
+var (
+	// ErrInvalidLength is returned when the data to decode has an invalid
+	// length.
+	ErrInvalidLength = errors.New("lorawan: invalid length")
+
+	// ErrInvalidValue is returned when the decoded data contains an invalid
+	// value.
+	ErrInvalidValue = errors.New("lorawan: invalid value")
+
+	// ErrInvalidPayloadType is returned when the MACPayload of the
+	// PHYPayload is not of the type expected by the operation.
+	ErrInvalidPayloadType = errors.New("lorawan: invalid MACPayload type")
+
+	// ErrInvalidMIC is returned when the MIC does not match.
+	ErrInvalidMIC = errors.New("lorawan: invalid MIC")
+)
+
+// DecodeError is returned when decoding binary data failed. It wraps
+// ErrInvalidLength or ErrInvalidValue.
+type DecodeError struct {
+	// Type holds the name of the type which failed to decode (e.g. FHDR).
+	Type string
+
+	// Offset holds the offset (in bytes) of the type which failed to
+	// decode, relative to the start of the data passed to the
+	// UnmarshalBinary method (e.g. of the PHYPayload).
+	Offset int
+
+	// Err holds the cause.
+	Err error
+
+	msg string
+}
+
+// Error implements the error interface.
+func (e *DecodeError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the cause of the error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// MICError is returned when the MIC of a frame does not match. It wraps
+// ErrInvalidMIC.
+type MICError struct {
+	// Expected holds the calculated MIC.
+	Expected MIC
+
+	// Got holds the MIC of the frame.
+	Got MIC
+}
+
+// Error implements the error interface.
+func (e *MICError) Error() string {
+	return fmt.Sprintf("lorawan: invalid MIC (expected: %s, got: %s)", e.Expected, e.Got)
+}
+
+// Unwrap returns ErrInvalidMIC.
+func (e *MICError) Unwrap() error {
+	return ErrInvalidMIC
+}
+
+// newDecodeError returns a new *DecodeError for the given type, at offset 0.
+func newDecodeError(typ string, err error, format string, a ...interface{}) error {
+	return &DecodeError{
+		Type: typ,
+		Err:  err,
+		msg:  fmt.Sprintf(format, a...),
+	}
+}
+
+// withOffset adds the given offset to the Offset of the given error in case
+// it is a *DecodeError, e.g. returned when decoding a nested type.
+func withOffset(err error, offset int) error {
+	if de, ok := err.(*DecodeError); ok {
+		de.Offset += offset
+	}
+	return err
+}
+
+// wrappedError wraps one of the sentinel errors with a descriptive message.
+type wrappedError struct {
+	err error
+	msg string
+}
+
+func (e *wrappedError) Error() string {
+	return e.msg
+}
+
+func (e *wrappedError) Unwrap() error {
+	return e.err
+}
+
+// newError returns a new error with the given message, wrapping err.
+func newError(err error, format string, a ...interface{}) error {
+	return &wrappedError{
+		err: err,
+		msg: fmt.Sprintf(format, a...),
+	}
+}
+
+// checkMIC returns a *MICError when the given MICs do not match.
+func checkMIC(expected, got MIC) error {
+	if expected != got {
+		return &MICError{Expected: expected, Got: got}
+	}
+	return nil
+}
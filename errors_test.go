@@ -0,0 +1,219 @@
+package lorawan
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeError(t *testing.T) {
+	tests := []struct {
+		name   string
+		decode func() error
+		err    string
+		is     error
+		typ    string
+		offset int
+	}{
+		{
+			name: "PHYPayload too short",
+			decode: func() error {
+				var phy PHYPayload
+				return phy.UnmarshalBinary([]byte{0x40, 0x01})
+			},
+			err:    "lorawan: at least 5 bytes needed to decode PHYPayload",
+			is:     ErrInvalidLength,
+			typ:    "PHYPayload",
+			offset: 0,
+		},
+		{
+			name: "PHYPayload invalid RejoinType",
+			decode: func() error {
+				var phy PHYPayload
+				return phy.UnmarshalBinary([]byte{0xc0, 0x03, 0x01, 0x02, 0x03, 0x04})
+			},
+			err:    "lorawan: invalid RejoinType 3",
+			is:     ErrInvalidValue,
+			typ:    "PHYPayload",
+			offset: 0,
+		},
+		{
+			name: "MACPayload too short",
+			decode: func() error {
+				var phy PHYPayload
+				return phy.UnmarshalBinary([]byte{0x40, 0x01, 0x02, 0x03, 0x04, 0x00, 0x01, 0x02, 0x03, 0x04})
+			},
+			err:    "lorawan: at least 7 bytes needed to decode FHDR",
+			is:     ErrInvalidLength,
+			typ:    "MACPayload",
+			offset: 1,
+		},
+		{
+			name: "JoinAcceptPayload invalid length",
+			decode: func() error {
+				var pl JoinAcceptPayload
+				return pl.UnmarshalBinary(false, append(make([]byte, 12), make([]byte, 15)...))
+			},
+			err:    "lorawan: 12 or 28 bytes of data are expected (28 bytes if CFList is present)",
+			is:     ErrInvalidLength,
+			typ:    "JoinAcceptPayload",
+			offset: 0,
+		},
+		{
+			name: "mac-command payload",
+			decode: func() error {
+				var mac MACCommand
+				return mac.UnmarshalBinary(false, []byte{byte(LinkCheckAns), 0x01})
+			},
+			err:    "lorawan: 2 bytes of data are expected",
+			is:     ErrInvalidLength,
+			typ:    "LinkCheckAnsPayload",
+			offset: 1,
+		},
+		{
+			name: "mac-command remaining bytes",
+			decode: func() error {
+				_, err := decodeDataPayloadToMACCommands(true, []Payload{&DataPayload{Bytes: []byte{byte(LinkCheckReq), byte(DevStatusAns), 0x01}}})
+				return err
+			},
+			err:    "lorawan: not enough remaining bytes",
+			is:     ErrInvalidLength,
+			typ:    "MACCommand",
+			offset: 1,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			err := tst.decode()
+			assert.EqualError(err, tst.err)
+			assert.True(errors.Is(err, tst.is))
+
+			var decodeErr *DecodeError
+			assert.True(errors.As(err, &decodeErr))
+			assert.Equal(tst.typ, decodeErr.Type)
+			assert.Equal(tst.offset, decodeErr.Offset)
+		})
+	}
+}
+
+func TestInvalidPayloadTypeError(t *testing.T) {
+	assert := require.New(t)
+
+	phy := PHYPayload{
+		MHDR:       MHDR{MType: JoinRequest, Major: LoRaWANR1},
+		MACPayload: &JoinRequestPayload{},
+	}
+	err := phy.EncryptFRMPayload(AES128Key{})
+	assert.EqualError(err, "lorawan: MACPayload must be of type *MACPayload")
+	assert.True(errors.Is(err, ErrInvalidPayloadType))
+}
+
+func TestEncodeError(t *testing.T) {
+	tests := []struct {
+		name   string
+		encode func() error
+		err    string
+		is     error
+	}{
+		{
+			name: "FCtrl FOptsLen",
+			encode: func() error {
+				_, err := FCtrl{fOptsLen: 16}.MarshalBinary()
+				return err
+			},
+			err: "lorawan: max value of FOptsLen is 15",
+			is:  ErrInvalidLength,
+		},
+		{
+			name: "FHDR FOpts bytes",
+			encode: func() error {
+				_, err := FHDR{FOpts: []Payload{&DataPayload{Bytes: make([]byte, 16)}}}.AppendBinary(nil)
+				return err
+			},
+			err: "lorawan: max number of FOpts bytes is 15",
+			is:  ErrInvalidLength,
+		},
+		{
+			name: "EncryptFOpts size",
+			encode: func() error {
+				_, err := EncryptFOpts(AES128Key{}, false, true, DevAddr{}, 0, make([]byte, 16))
+				return err
+			},
+			err: "lorawan: max size of FOpts is 15 bytes",
+			is:  ErrInvalidLength,
+		},
+		{
+			name: "PHYPayload nil MACPayload",
+			encode: func() error {
+				_, err := PHYPayload{}.MarshalBinary()
+				return err
+			},
+			err: "lorawan: MACPayload should not be nil",
+			is:  ErrInvalidPayloadType,
+		},
+		{
+			name: "join MIC nil MACPayload",
+			encode: func() error {
+				phy := PHYPayload{MHDR: MHDR{MType: JoinRequest, Major: LoRaWANR1}}
+				return phy.SetUplinkJoinMIC(AES128Key{})
+			},
+			err: "lorawan: MACPayload must not be empty",
+			is:  ErrInvalidPayloadType,
+		},
+		{
+			name: "rejoin MIC MType",
+			encode: func() error {
+				phy := PHYPayload{MHDR: MHDR{MType: JoinRequest, Major: LoRaWANR1}}
+				return phy.SetRejoinRequestMIC(AES128Key{}, AES128Key{})
+			},
+			err: "lorawan: MType must be RejoinRequest",
+			is:  ErrInvalidValue,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			err := tst.encode()
+			assert.EqualError(err, tst.err)
+			assert.True(errors.Is(err, tst.is))
+		})
+	}
+}
+
+func TestMICError(t *testing.T) {
+	assert := require.New(t)
+
+	key := AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	phy := PHYPayload{
+		MHDR: MHDR{MType: JoinRequest, Major: LoRaWANR1},
+		MACPayload: &JoinRequestPayload{
+			JoinEUI:  EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+			DevEUI:   EUI64{8, 7, 6, 5, 4, 3, 2, 1},
+			DevNonce: 258,
+		},
+	}
+	assert.NoError(phy.SetUplinkJoinMIC(key))
+	assert.NoError(phy.VerifyUplinkJoinMIC(key))
+
+	expected := phy.MIC
+	phy.MIC = MIC{1, 2, 3, 4}
+
+	err := phy.VerifyUplinkJoinMIC(key)
+	assert.True(errors.Is(err, ErrInvalidMIC))
+
+	var micErr *MICError
+	assert.True(errors.As(err, &micErr))
+	assert.Equal(expected, micErr.Expected)
+	assert.Equal(MIC{1, 2, 3, 4}, micErr.Got)
+	assert.Equal("lorawan: invalid MIC (expected: "+expected.String()+", got: 01020304)", err.Error())
+
+	ok, err := phy.ValidateUplinkJoinMIC(key)
+	assert.NoError(err)
+	assert.False(ok)
+}
@@ -4,71 +4,64 @@ import (
 	"database/sql/driver"
 	"encoding/binary"
 	"encoding/hex"
-	"errors"
-	"fmt"
+	"encoding/json"
 )
 
 // DevAddr represents the device address.
 type DevAddr [4]byte
 
-// NetIDType returns the NetID type of the DevAddr.
+// netIDTypeLengths contains per NetID type the length of the DevAddr type
+// prefix and the number of NwkID bits.
+var netIDTypeLengths = [8]struct {
+	prefixLength int
+	nwkIDBits    int
+}{
+	{1, 6},
+	{2, 6},
+	{3, 9},
+	{4, 11},
+	{5, 12},
+	{6, 13},
+	{7, 15},
+	{8, 17},
+}
+
+// NetIDType returns the NetID type of the DevAddr, which is encoded by the
+// variable-length type prefix (0, 10, 110, ..., 11111110). It returns -1
+// when the DevAddr starts with the reserved 11111111 prefix.
 func (a DevAddr) NetIDType() int {
 	for i := 7; i >= 0; i-- {
 		if a[0]&(1<<byte(i)) == 0 {
 			return 7 - i
 		}
 	}
-	panic("NetIDType bug!")
+	return -1
 }
 
-// NwkID returns the NwkID bits of the DevAddr.
+// NwkID returns the NwkID bits of the DevAddr. It returns nil when the
+// DevAddr does not contain a valid type prefix.
 func (a DevAddr) NwkID() []byte {
-	switch a.NetIDType() {
-	case 0:
-		return a.getNwkID(1, 6)
-	case 1:
-		return a.getNwkID(2, 6)
-	case 2:
-		return a.getNwkID(3, 9)
-	case 3:
-		return a.getNwkID(4, 11)
-	case 4:
-		return a.getNwkID(5, 12)
-	case 5:
-		return a.getNwkID(6, 13)
-	case 6:
-		return a.getNwkID(7, 15)
-	case 7:
-		return a.getNwkID(8, 17)
-	default:
+	t := a.NetIDType()
+	if t < 0 {
 		return nil
 	}
+	return a.getNwkID(netIDTypeLengths[t].prefixLength, netIDTypeLengths[t].nwkIDBits)
 }
 
 // SetAddrPrefix sets the NetID based AddrPrefix.
 func (a *DevAddr) SetAddrPrefix(netID NetID) {
-	switch netID.Type() {
-	case 0:
-		a.setAddrPrefix(1, 6, netID)
-	case 1:
-		a.setAddrPrefix(2, 6, netID)
-	case 2:
-		a.setAddrPrefix(3, 9, netID)
-	case 3:
-		a.setAddrPrefix(4, 11, netID)
-	case 4:
-		a.setAddrPrefix(5, 12, netID)
-	case 5:
-		a.setAddrPrefix(6, 13, netID)
-	case 6:
-		a.setAddrPrefix(7, 15, netID)
-	case 7:
-		a.setAddrPrefix(8, 17, netID)
-	}
+	t := netID.Type()
+	a.setAddrPrefix(netIDTypeLengths[t].prefixLength, netIDTypeLengths[t].nwkIDBits, netID)
 }
 
 // IsNetID returns a bool indicating if the NwkID matches the given NetID.
+// Only the LSB of the NetID ID, which fit in the NwkID of the NetID type,
+// are compared.
 func (a DevAddr) IsNetID(netID NetID) bool {
+	if a.NetIDType() != netID.Type() {
+		return false
+	}
+
 	tempDevAddr := a
 	tempDevAddr.SetAddrPrefix(netID)
 
@@ -140,7 +133,7 @@ func (a DevAddr) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (a *DevAddr) UnmarshalBinary(data []byte) error {
 	if len(data) != len(a) {
-		return fmt.Errorf("lorawan: %d bytes of data are expected", len(a))
+		return newDecodeError("DevAddr", ErrInvalidLength, "lorawan: %d bytes of data are expected", len(a))
 	}
 	for i, v := range data {
 		// little endian
@@ -162,28 +155,26 @@ func (a *DevAddr) UnmarshalText(text []byte) error {
 	}
 
 	if len(b) != len(a) {
-		return fmt.Errorf("lorawan: exactly %d bytes are expected", len(a))
+		return newError(ErrInvalidLength, "lorawan: exactly %d bytes are expected", len(a))
 	}
 	copy(a[:], b)
 	return nil
 }
 
+// Set implements flag.Value.
+func (a *DevAddr) Set(s string) error {
+	return a.UnmarshalText([]byte(s))
+}
+
 // String implements fmt.Stringer.
 func (a DevAddr) String() string {
 	return hex.EncodeToString(a[:])
 }
 
-// Scan implements sql.Scanner.
+// Scan implements sql.Scanner. Both the binary form (e.g. bytea column)
+// and the HEX encoded form (e.g. text column) are supported.
 func (a *DevAddr) Scan(src interface{}) error {
-	b, ok := src.([]byte)
-	if !ok {
-		return errors.New("lorawan: []byte type expected")
-	}
-	if len(b) != len(a) {
-		return fmt.Errorf("lorawan []byte must have length %d", len(a))
-	}
-	copy(a[:], b)
-	return nil
+	return scanBytes(a[:], src)
 }
 
 // Value implements driver.Valuer.
@@ -206,8 +197,16 @@ type FCtrl struct {
 
 // MarshalBinary marshals the object in binary form.
 func (c FCtrl) MarshalBinary() ([]byte, error) {
+	b, err := c.marshalByte()
+	if err != nil {
+		return []byte{}, err
+	}
+	return []byte{b}, nil
+}
+
+func (c FCtrl) marshalByte() (byte, error) {
 	if c.fOptsLen > 15 {
-		return []byte{}, errors.New("lorawan: max value of FOptsLen is 15")
+		return 0, newError(ErrInvalidLength, "lorawan: max value of FOptsLen is 15")
 	}
 
 	var b byte
@@ -225,13 +224,13 @@ func (c FCtrl) MarshalBinary() ([]byte, error) {
 	}
 	b |= byte(c.fOptsLen) & 0x0f
 
-	return []byte{b}, nil
+	return b, nil
 }
 
 // UnmarshalBinary decodes the object from binary form.
 func (c *FCtrl) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is expected")
+		return newDecodeError("FCtrl", ErrInvalidLength, "lorawan: 1 byte of data is expected")
 	}
 
 	c.ADR = data[0]&0x80 != 0
@@ -244,6 +243,30 @@ func (c *FCtrl) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler. The FCtrl is encoded as HEX
+// string of its binary form.
+func (c FCtrl) MarshalText() ([]byte, error) {
+	return marshalBinaryText(c)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (c *FCtrl) UnmarshalText(text []byte) error {
+	return unmarshalBinaryText(c, text)
+}
+
+// MarshalJSON encodes the FCtrl into JSON. This preserves the object
+// representation, rather than using the HEX encoded text form.
+func (c FCtrl) MarshalJSON() ([]byte, error) {
+	type fCtrlAlias FCtrl
+	return json.Marshal(fCtrlAlias(c))
+}
+
+// UnmarshalJSON decodes the FCtrl from its JSON object representation.
+func (c *FCtrl) UnmarshalJSON(data []byte) error {
+	type fCtrlAlias FCtrl
+	return json.Unmarshal(data, (*fCtrlAlias)(c))
+}
+
 // FHDR represents the frame header.
 type FHDR struct {
 	DevAddr DevAddr   `json:"devAddr"`
@@ -254,53 +277,55 @@ type FHDR struct {
 
 // MarshalBinary marshals the object in binary form.
 func (h FHDR) MarshalBinary() ([]byte, error) {
-	var b []byte
-	var err error
-	var opts []byte
+	b, err := h.AppendBinary(make([]byte, 0, 7+15))
+	if err != nil {
+		return []byte{}, err
+	}
+	return b, nil
+}
 
+// AppendBinary appends the binary form of the FHDR to b and returns the
+// extended slice. This can be used with a (pooled) buffer to avoid the
+// allocations of MarshalBinary.
+func (h FHDR) AppendBinary(b []byte) ([]byte, error) {
+	start := len(b)
+
+	// DevAddr (little endian), FCtrl (set after the FOpts) and FCnt
+	b = append(b, h.DevAddr[3], h.DevAddr[2], h.DevAddr[1], h.DevAddr[0], 0, byte(h.FCnt), byte(h.FCnt>>8))
+
+	var err error
 	for _, mac := range h.FOpts {
-		b, err = mac.MarshalBinary()
+		b, err = appendPayload(b, mac)
 		if err != nil {
-			return []byte{}, err
+			return b[:start], err
 		}
-		opts = append(opts, b...)
-	}
-	h.FCtrl.fOptsLen = uint8(len(opts))
-	if h.FCtrl.fOptsLen > 15 {
-		return []byte{}, errors.New("lorawan: max number of FOpts bytes is 15")
 	}
 
-	out := make([]byte, 0, 7+h.FCtrl.fOptsLen)
-	b, err = h.DevAddr.MarshalBinary()
-	if err != nil {
-		return []byte{}, err
+	fOptsLen := len(b) - start - 7
+	if fOptsLen > 15 {
+		return b[:start], newError(ErrInvalidLength, "lorawan: max number of FOpts bytes is 15")
 	}
-	out = append(out, b...)
+	h.FCtrl.fOptsLen = uint8(fOptsLen)
 
-	b, err = h.FCtrl.MarshalBinary()
+	b[start+4], err = h.FCtrl.marshalByte()
 	if err != nil {
-		return []byte{}, err
+		return b[:start], err
 	}
-	out = append(out, b...)
-	fCntBytes := make([]byte, 4)
-	binary.LittleEndian.PutUint32(fCntBytes, h.FCnt)
-	out = append(out, fCntBytes[0:2]...)
-	out = append(out, opts...)
 
-	return out, nil
+	return b, nil
 }
 
 // UnmarshalBinary decodes the object from binary form.
 func (h *FHDR) UnmarshalBinary(uplink bool, data []byte) error {
 	if len(data) < 7 {
-		return errors.New("lorawan: at least 7 bytes are expected")
+		return newDecodeError("FHDR", ErrInvalidLength, "lorawan: at least 7 bytes are expected")
 	}
 
 	if err := h.DevAddr.UnmarshalBinary(data[0:4]); err != nil {
 		return err
 	}
 	if err := h.FCtrl.UnmarshalBinary(data[4:5]); err != nil {
-		return err
+		return withOffset(err, 4)
 	}
 	fCntBytes := make([]byte, 4)
 	copy(fCntBytes, data[5:7])
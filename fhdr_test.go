@@ -84,6 +84,13 @@ func TestDevAddr(t *testing.T) {
 				Bytes:     []byte{127, 219, 182, 254},
 				String:    "feb6db7f",
 			},
+			{
+				Name:      "Reserved prefix",
+				DevAddr:   DevAddr{255, 182, 219, 127},
+				NetIDType: -1,
+				Bytes:     []byte{127, 219, 182, 255},
+				String:    "ffb6db7f",
+			},
 		}
 
 		for i, test := range tests {
@@ -168,6 +175,10 @@ func TestDevAddrSetAddrPrefix(t *testing.T) {
 			},
 		}
 
+		Convey("A DevAddr with reserved prefix does not match any NetID", func() {
+			So(DevAddr{255, 182, 219, 127}.IsNetID(NetID{224, 0, 0}), ShouldBeFalse)
+		})
+
 		for i, test := range tests {
 			Convey(fmt.Sprintf("Testing: %s [%d]", test.Name, i), func() {
 				for i := range test.NetID {
@@ -228,7 +239,8 @@ func TestFCtrl(t *testing.T) {
 				if test.ExpectedError != nil {
 					Convey("Then the expected error is returned", func() {
 						So(err, ShouldNotBeNil)
-						So(err, ShouldResemble, test.ExpectedError)
+						So(err.Error(), ShouldEqual, test.ExpectedError.Error())
+						So(errors.Is(err, ErrInvalidLength), ShouldBeTrue)
 					})
 					return
 				}
@@ -296,7 +308,8 @@ func TestFHDR(t *testing.T) {
 			}
 			Convey("Then MarshalBinary does return an error", func() {
 				_, err := h.MarshalBinary()
-				So(err, ShouldResemble, errors.New("lorawan: max number of FOpts bytes is 15"))
+				So(err.Error(), ShouldEqual, "lorawan: max number of FOpts bytes is 15")
+				So(errors.Is(err, ErrInvalidLength), ShouldBeTrue)
 			})
 		})
 
@@ -377,7 +390,8 @@ func TestFHDR(t *testing.T) {
 				err := h.UnmarshalBinary(false, b)
 				So(err, ShouldBeNil)
 				h.FOpts, err = decodeDataPayloadToMACCommands(false, h.FOpts)
-				So(err, ShouldResemble, errors.New("lorawan: not enough remaining bytes"))
+				So(err.Error(), ShouldEqual, "lorawan: not enough remaining bytes")
+				So(errors.Is(err, ErrInvalidLength), ShouldBeTrue)
 			})
 		})
 
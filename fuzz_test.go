@@ -0,0 +1,114 @@
+//go:build go1.18
+// +build go1.18
+
+package lorawan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func FuzzPHYPayloadUnmarshalBinary(f *testing.F) {
+	f.Add([]byte{0x40, 0x04, 0x03, 0x02, 0x01, 0x80, 0x01, 0x00, 0x01, 0xa6, 0x94, 0x64, 0x26, 0x15, 0xd6, 0xc3, 0xb5, 0x82})
+	f.Add([]byte{0x80, 0x04, 0x03, 0x02, 0x01, 0x03, 0x00, 0x00, 0x06, 0x73, 0x07, 0x0a, 0xe2, 0x64, 0xd4, 0xf7, 0xe1, 0x17, 0xd2, 0xc0})
+	f.Add([]byte{0x60, 0x04, 0x03, 0x02, 0x01, 0x05, 0x00, 0x00, 0x03, 0x07, 0xff, 0x00, 0x01, 0x00, 0x01, 0x02, 0x03, 0x04})
+	f.Add([]byte{0x00, 0x04, 0x03, 0x02, 0x01, 0x04, 0x03, 0x02, 0x01, 0x05, 0x04, 0x03, 0x02, 0x05, 0x04, 0x03, 0x02, 0x2d, 0x10, 0x6a, 0x99, 0x0e, 0x12})
+	f.Add([]byte{0x20, 0x49, 0x3e, 0xeb, 0x51, 0xfb, 0xa2, 0x11, 0x6f, 0x81, 0x0e, 0xdb, 0x37, 0x42, 0x97, 0x51, 0x42})
+	f.Add([]byte{0xc0, 0x00, 0x01, 0x02, 0x03, 0x02, 0x01, 0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01, 0x01, 0x02, 0x03, 0x04, 0x05})
+	f.Add([]byte{0xe0, 0x01, 0x02, 0x03, 0x04, 0x05})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var phy PHYPayload
+		if err := phy.UnmarshalBinary(data); err != nil {
+			return
+		}
+
+		// the decoder accepts some frames which the encoder rejects (e.g.
+		// FOpts with FPort 0) and RFU bits are not preserved, but what is
+		// encoded must decode to the same frame
+		if b, err := phy.MarshalBinary(); err == nil {
+			var phy2 PHYPayload
+			if err := phy2.UnmarshalBinary(b); err != nil {
+				t.Fatalf("unmarshal binary error: %s", err)
+			}
+			if !reflect.DeepEqual(phy, phy2) {
+				t.Fatalf("binary round-trip mismatch: %x != %x", data, b)
+			}
+		}
+
+		if _, err := phy.MarshalJSON(); err != nil {
+			t.Fatalf("marshal json error: %s", err)
+		}
+
+		// errors are expected below (invalid key or mac-commands), these
+		// calls must not panic
+		switch phy.MHDR.MType {
+		case JoinAccept:
+			if err := phy.DecryptJoinAcceptPayload(AES128Key{}); err == nil {
+				_, _ = phy.MarshalBinary()
+			}
+		case UnconfirmedDataUp, UnconfirmedDataDown, ConfirmedDataUp, ConfirmedDataDown:
+			_ = phy.DecodeFOptsToMACCommands()
+			_ = phy.DecodeFRMPayloadToMACCommands()
+			_, _ = phy.MarshalBinary()
+			_, _ = phy.MarshalJSON()
+		}
+	})
+}
+
+func FuzzMACCommandsDecode(f *testing.F) {
+	f.Add(true, []byte{0x02})
+	f.Add(true, []byte{0x06, 0x73, 0x07})
+	f.Add(true, []byte{0x03, 0x07, 0x05, 0x00})
+	f.Add(false, []byte{0x03, 0x51, 0x07, 0x00, 0x00})
+	f.Add(false, []byte{0x07, 0x03, 0x18, 0x4f, 0x84, 0x50})
+	f.Add(false, []byte{0x0d, 0x01, 0x02, 0x03, 0x04, 0x05})
+	f.Add(false, []byte{0x80, 0xff})
+
+	f.Fuzz(func(t *testing.T, uplink bool, data []byte) {
+		cmds, err := decodeDataPayloadToMACCommands(uplink, []Payload{&DataPayload{Bytes: data}})
+		if err != nil {
+			return
+		}
+
+		for _, cmd := range cmds {
+			// a mac-command with an invalid payload is returned without
+			// payload, which can fail to marshal
+			b, err := cmd.MarshalBinary()
+			if err != nil {
+				continue
+			}
+
+			var mc MACCommand
+			if err := mc.UnmarshalBinary(uplink, b); err != nil {
+				t.Fatalf("unmarshal mac-command %x error: %s", b, err)
+			}
+		}
+	})
+}
+
+func FuzzCFListUnmarshalBinary(f *testing.F) {
+	f.Add([]byte{0x18, 0x4f, 0x84, 0xe8, 0x56, 0x84, 0xb8, 0x5e, 0x84, 0x88, 0x66, 0x84, 0x58, 0x6e, 0x84, 0x00})
+	f.Add([]byte{0xff, 0x00, 0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01})
+	f.Add([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var cfList CFList
+		if err := cfList.UnmarshalBinary(data); err != nil {
+			return
+		}
+
+		b, err := cfList.MarshalBinary()
+		if err != nil {
+			t.Fatalf("marshal binary error: %s", err)
+		}
+
+		var cfList2 CFList
+		if err := cfList2.UnmarshalBinary(b); err != nil {
+			t.Fatalf("unmarshal binary error: %s", err)
+		}
+		if !reflect.DeepEqual(cfList, cfList2) {
+			t.Fatalf("round-trip mismatch: %+v != %+v", cfList, cfList2)
+		}
+	})
+}
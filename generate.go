@@ -0,0 +1,46 @@
+package lorawan
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// GenerateAES128Key returns a random AES128Key, read from crypto/rand.
+func GenerateAES128Key() (AES128Key, error) {
+	return RandomAES128Key(rand.Reader)
+}
+
+// RandomAES128Key returns a random AES128Key, read from the given reader.
+// Use GenerateAES128Key for production keys, a deterministic reader (e.g.
+// math/rand with a fixed seed) can be used for reproducible tests.
+func RandomAES128Key(rand io.Reader) (AES128Key, error) {
+	var key AES128Key
+	if _, err := io.ReadFull(rand, key[:]); err != nil {
+		return key, fmt.Errorf("lorawan: read random bytes error: %s", err)
+	}
+	return key, nil
+}
+
+// GenerateDevAddr returns a random DevAddr, read from crypto/rand, with the
+// AddrPrefix set to the given NetID.
+func GenerateDevAddr(netID NetID) (DevAddr, error) {
+	return netID.RandomDevAddr(rand.Reader)
+}
+
+// GenerateDevNonce returns a random DevNonce, read from crypto/rand.
+// Note that LoRaWAN 1.1 devices must use an incrementing DevNonce
+// (counter) instead.
+func GenerateDevNonce() (DevNonce, error) {
+	return RandomDevNonce(rand.Reader)
+}
+
+// RandomDevNonce returns a random DevNonce, read from the given reader.
+func RandomDevNonce(rand io.Reader) (DevNonce, error) {
+	b := make([]byte, 2)
+	if _, err := io.ReadFull(rand, b); err != nil {
+		return 0, fmt.Errorf("lorawan: read random bytes error: %s", err)
+	}
+	return DevNonce(binary.LittleEndian.Uint16(b)), nil
+}
@@ -0,0 +1,78 @@
+package lorawan
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAES128Key(t *testing.T) {
+	assert := require.New(t)
+
+	key1, err := GenerateAES128Key()
+	assert.NoError(err)
+	key2, err := GenerateAES128Key()
+	assert.NoError(err)
+	assert.NotEqual(key1, key2)
+}
+
+func TestGenerateDevAddr(t *testing.T) {
+	assert := require.New(t)
+
+	for _, netID := range []NetID{{0x00, 0x00, 0x13}, {0x60, 0x00, 0x2d}, {0xe0, 0x00, 0x01}} {
+		devAddr, err := GenerateDevAddr(netID)
+		assert.NoError(err)
+		assert.True(devAddr.IsNetID(netID))
+		assert.Equal(netID.Type(), devAddr.NetIDType())
+	}
+}
+
+func TestNetIDRandomDevAddr(t *testing.T) {
+	assert := require.New(t)
+
+	for i := 0; i < 8; i++ {
+		netID := NetID{byte(i << 5), 0xff, 0xff}
+
+		devAddr, err := netID.RandomDevAddr(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff}))
+		assert.NoError(err)
+		assert.True(netID.DevAddrPrefix().Matches(devAddr))
+
+		devAddr, err = netID.RandomDevAddr(bytes.NewReader([]byte{0x00, 0x00, 0x00, 0x00}))
+		assert.NoError(err)
+		assert.True(netID.DevAddrPrefix().Matches(devAddr))
+	}
+
+	_, err := NetID{}.RandomDevAddr(bytes.NewReader([]byte{0x01}))
+	assert.Error(err)
+}
+
+func TestGenerateDevNonce(t *testing.T) {
+	assert := require.New(t)
+
+	_, err := GenerateDevNonce()
+	assert.NoError(err)
+}
+
+func TestRandomAES128Key(t *testing.T) {
+	assert := require.New(t)
+
+	b := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	key, err := RandomAES128Key(bytes.NewReader(b))
+	assert.NoError(err)
+	assert.Equal(AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, key)
+
+	_, err = RandomAES128Key(bytes.NewReader(b[:15]))
+	assert.Error(err)
+}
+
+func TestRandomDevNonce(t *testing.T) {
+	assert := require.New(t)
+
+	nonce, err := RandomDevNonce(bytes.NewReader([]byte{0x01, 0x02}))
+	assert.NoError(err)
+	assert.Equal(DevNonce(0x0201), nonce)
+
+	_, err = RandomDevNonce(bytes.NewReader([]byte{0x01}))
+	assert.Error(err)
+}
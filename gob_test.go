@@ -0,0 +1,104 @@
+package lorawan
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGobEncoding(t *testing.T) {
+	fPort := uint8(10)
+
+	type cacheEntry struct {
+		DevEUI     EUI64
+		DevAddr    DevAddr
+		AppKey     AES128Key
+		PHYPayload PHYPayload
+		Session    SessionContext
+		Rekey      RekeyContext
+	}
+
+	session := SessionContext{
+		MACVersion:  LoRaWAN1_1,
+		DevAddr:     DevAddr{1, 2, 3, 4},
+		FNwkSIntKey: AES128Key{1},
+		SNwkSIntKey: AES128Key{2},
+		NwkSEncKey:  AES128Key{3},
+		AppSKey:     AES128Key{4},
+		FCntUp:      10,
+		NFCntDown:   11,
+		AFCntDown:   12,
+		ConfFCnt:    13,
+		CMACCache:   NewCMACCache(4),
+	}
+
+	in := cacheEntry{
+		DevEUI:  EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		DevAddr: DevAddr{1, 2, 3, 4},
+		AppKey:  AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8},
+		PHYPayload: PHYPayload{
+			MHDR: MHDR{MType: UnconfirmedDataUp, Major: LoRaWANR1},
+			MACPayload: &MACPayload{
+				FHDR: FHDR{
+					DevAddr: DevAddr{1, 2, 3, 4},
+					FCnt:    10,
+				},
+				FPort:      &fPort,
+				FRMPayload: []Payload{&DataPayload{Bytes: []byte{1, 2, 3}}},
+			},
+			MIC: MIC{1, 2, 3, 4},
+		},
+		Session: session,
+		Rekey: RekeyContext{
+			Current: &session,
+		},
+	}
+
+	assert := require.New(t)
+
+	var buf bytes.Buffer
+	assert.NoError(gob.NewEncoder(&buf).Encode(in))
+
+	var out cacheEntry
+	assert.NoError(gob.NewDecoder(&buf).Decode(&out))
+
+	// the CMACCache is not encoded
+	in.Session.CMACCache = nil
+	in.Rekey.Current.CMACCache = nil
+
+	assert.Equal(in.DevEUI, out.DevEUI)
+	assert.Equal(in.DevAddr, out.DevAddr)
+	assert.Equal(in.AppKey, out.AppKey)
+	assert.Equal(in.Session, out.Session)
+	assert.Equal(in.Rekey, out.Rekey)
+
+	b1, err := in.PHYPayload.MarshalBinary()
+	assert.NoError(err)
+	b2, err := out.PHYPayload.MarshalBinary()
+	assert.NoError(err)
+	assert.Equal(b1, b2)
+}
+
+func TestSessionContextBinary(t *testing.T) {
+	assert := require.New(t)
+
+	s := SessionContext{
+		MACVersion: LoRaWAN1_0,
+		DevAddr:    DevAddr{1, 2, 3, 4},
+		FCntUp:     1,
+	}
+	s.SetNwkSKey(AES128Key{1, 2, 3})
+
+	b, err := s.MarshalBinary()
+	assert.NoError(err)
+	assert.Len(b, 86)
+
+	var s2 SessionContext
+	assert.NoError(s2.UnmarshalBinary(b))
+	assert.Equal(s, s2)
+
+	assert.EqualError(s2.UnmarshalBinary([]byte{2}), "lorawan: unsupported session-context encoding version")
+	assert.EqualError(s2.UnmarshalBinary(b[:10]), "lorawan: 86 bytes of data are expected")
+}
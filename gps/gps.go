@@ -2,16 +2,22 @@
 package gps
 
 import (
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 )
 
 var gpsEpochTime = time.Date(1980, time.January, 6, 0, 0, 0, 0, time.UTC)
 
-var leapSecondsTable = []struct {
+type leapSecond struct {
 	Time     time.Time
 	Duration time.Duration
-}{
+}
+
+var leapSecondsMu sync.RWMutex
+
+var leapSecondsTable = []leapSecond{
 	{Time: time.Date(1981, time.June, 30, 23, 59, 59, 0, time.UTC), Duration: time.Second},
 	{Time: time.Date(1982, time.June, 30, 23, 59, 59, 0, time.UTC), Duration: time.Second},
 	{Time: time.Date(1983, time.June, 30, 23, 59, 59, 0, time.UTC), Duration: time.Second},
@@ -32,12 +38,51 @@ var leapSecondsTable = []struct {
 	{Time: time.Date(2016, time.December, 31, 23, 59, 59, 0, time.UTC), Duration: time.Second},
 }
 
+// AddLeapSecond extends the leap-second table with a leap second inserted
+// (duration of time.Second) or removed (duration of -time.Second) after the
+// given UTC time, e.g. 2016-12-31 23:59:59 UTC. The leap second must be
+// announced after the last leap second in the table.
+func AddLeapSecond(t time.Time, d time.Duration) error {
+	if d != time.Second && d != -time.Second {
+		return errors.New("gps: leap second duration must be 1s or -1s")
+	}
+
+	leapSecondsMu.Lock()
+	defer leapSecondsMu.Unlock()
+
+	if last := leapSecondsTable[len(leapSecondsTable)-1]; !t.After(last.Time) {
+		return fmt.Errorf("gps: leap second must be after %s", last.Time)
+	}
+
+	leapSecondsTable = append(leapSecondsTable, leapSecond{Time: t.UTC(), Duration: d})
+	return nil
+}
+
+// LeapSeconds returns the offset between GPS time and UTC (the number of
+// leap seconds since the GPS epoch) at the given time.
+func LeapSeconds(t time.Time) time.Duration {
+	leapSecondsMu.RLock()
+	defer leapSecondsMu.RUnlock()
+
+	var offset time.Duration
+	for _, ls := range leapSecondsTable {
+		if ls.Time.Before(t) {
+			offset += ls.Duration
+		}
+	}
+
+	return offset
+}
+
 // Time represents a GPS time wrapper.
 type Time time.Time
 
 // NewTimeFromTimeSinceGPSEpoch returns a new Time given a time since GPS epoch
 // and will apply the leap second correction.
 func NewTimeFromTimeSinceGPSEpoch(sinceEpoch time.Duration) Time {
+	leapSecondsMu.RLock()
+	defer leapSecondsMu.RUnlock()
+
 	t := gpsEpochTime.Add(sinceEpoch)
 	for _, ls := range leapSecondsTable {
 		if ls.Time.Before(t) {
@@ -51,14 +96,12 @@ func NewTimeFromTimeSinceGPSEpoch(sinceEpoch time.Duration) Time {
 // TimeSinceGPSEpoch returns the time duration since GPS epoch, corrected with
 // the leap seconds.
 func (t Time) TimeSinceGPSEpoch() time.Duration {
-	var offset time.Duration
-	for _, ls := range leapSecondsTable {
-		if ls.Time.Before(time.Time(t)) {
-			offset += ls.Duration
-		}
-	}
+	return time.Time(t).Sub(gpsEpochTime) + LeapSeconds(time.Time(t))
+}
 
-	return time.Time(t).Sub(gpsEpochTime) + offset
+// Time returns the time.Time (UTC) of the GPS time.
+func (t Time) Time() time.Time {
+	return time.Time(t).UTC()
 }
 
 // String implements the Stringer interface.
@@ -33,3 +33,28 @@ func TestTime(t *testing.T) {
 		})
 	}
 }
+
+func TestAddLeapSecond(t *testing.T) {
+	assert := require.New(t)
+
+	leapSecondsMu.RLock()
+	table := append([]leapSecond(nil), leapSecondsTable...)
+	leapSecondsMu.RUnlock()
+	defer func() {
+		leapSecondsMu.Lock()
+		leapSecondsTable = table
+		leapSecondsMu.Unlock()
+	}()
+
+	before := time.Date(2030, time.July, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(18*time.Second, LeapSeconds(before))
+	sinceEpoch := Time(before).TimeSinceGPSEpoch()
+
+	assert.Error(AddLeapSecond(time.Date(2016, time.December, 31, 23, 59, 59, 0, time.UTC), time.Second))
+	assert.Error(AddLeapSecond(time.Date(2029, time.December, 31, 23, 59, 59, 0, time.UTC), 2*time.Second))
+	assert.NoError(AddLeapSecond(time.Date(2029, time.December, 31, 23, 59, 59, 0, time.UTC), time.Second))
+
+	assert.Equal(19*time.Second, LeapSeconds(before))
+	assert.Equal(sinceEpoch+time.Second, Time(before).TimeSinceGPSEpoch())
+	assert.True(NewTimeFromTimeSinceGPSEpoch(sinceEpoch + time.Second).Time().Equal(before))
+}
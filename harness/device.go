@@ -0,0 +1,198 @@
+package harness
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/brocaar/lorawan"
+)
+
+// Device simulates an OTAA activated end-device. It implements the device
+// side of the join procedure and of the data exchange, using the lorawan
+// frame and session helpers.
+//
+// The Device is not safe for concurrent use.
+type Device struct {
+	DevEUI  lorawan.EUI64
+	JoinEUI lorawan.EUI64
+
+	// NwkKey holds the NwkKey (the AppKey in LoRaWAN 1.0.x).
+	NwkKey lorawan.AES128Key
+
+	// AppKey holds the AppKey (LoRaWAN 1.1 only).
+	AppKey lorawan.AES128Key
+
+	// DevNonce holds the DevNonce of the next join-request. It is
+	// incremented after every join-request.
+	DevNonce lorawan.DevNonce
+
+	// Session holds the session-context, this is nil until the device has
+	// joined.
+	Session *lorawan.SessionContext
+
+	joinDevNonce lorawan.DevNonce
+}
+
+// JoinRequest returns a new join-request (PHYPayload).
+func (d *Device) JoinRequest() ([]byte, error) {
+	phy := lorawan.PHYPayload{
+		MHDR: lorawan.MHDR{
+			MType: lorawan.JoinRequest,
+			Major: lorawan.LoRaWANR1,
+		},
+		MACPayload: &lorawan.JoinRequestPayload{
+			JoinEUI:  d.JoinEUI,
+			DevEUI:   d.DevEUI,
+			DevNonce: d.DevNonce,
+		},
+	}
+
+	if err := phy.SetUplinkJoinMIC(d.NwkKey); err != nil {
+		return nil, fmt.Errorf("lorawan/harness: set join-request mic error: %w", err)
+	}
+
+	b, err := phy.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("lorawan/harness: marshal join-request error: %w", err)
+	}
+
+	d.joinDevNonce = d.DevNonce
+	d.DevNonce++
+
+	return b, nil
+}
+
+// HandleJoinAccept decrypts and validates the given join-accept (PHYPayload)
+// and activates the session. The LoRaWAN 1.1 key derivation is used when
+// the OptNeg bit is set by the join-server.
+func (d *Device) HandleJoinAccept(b []byte) error {
+	var phy lorawan.PHYPayload
+	if err := phy.UnmarshalBinary(b); err != nil {
+		return fmt.Errorf("lorawan/harness: unmarshal join-accept error: %w", err)
+	}
+	if phy.MHDR.MType != lorawan.JoinAccept {
+		return fmt.Errorf("lorawan/harness: expected %s, got %s", lorawan.JoinAccept, phy.MHDR.MType)
+	}
+
+	if err := phy.DecryptJoinAcceptPayload(d.NwkKey); err != nil {
+		return fmt.Errorf("lorawan/harness: decrypt join-accept error: %w", err)
+	}
+	ja, ok := phy.MACPayload.(*lorawan.JoinAcceptPayload)
+	if !ok {
+		return fmt.Errorf("lorawan/harness: expected *lorawan.JoinAcceptPayload, got %T", phy.MACPayload)
+	}
+
+	micKey := d.NwkKey
+	if ja.DLSettings.OptNeg {
+		var err error
+		micKey, err = deriveJSIntKey(d.NwkKey, d.DevEUI)
+		if err != nil {
+			return fmt.Errorf("lorawan/harness: derive JSIntKey error: %w", err)
+		}
+	}
+	if err := phy.VerifyDownlinkJoinMIC(lorawan.JoinRequestType, d.JoinEUI, d.joinDevNonce, micKey); err != nil {
+		return err
+	}
+
+	s, err := deriveSessionKeys(ja.DLSettings.OptNeg, d.NwkKey, d.AppKey, ja.HomeNetID, d.JoinEUI, ja.JoinNonce, d.joinDevNonce)
+	if err != nil {
+		return fmt.Errorf("lorawan/harness: derive session keys error: %w", err)
+	}
+	s.DevAddr = ja.DevAddr
+	d.Session = &s
+
+	return nil
+}
+
+// Uplink returns a new uplink data frame (PHYPayload) containing the given
+// application payload.
+func (d *Device) Uplink(fPort uint8, data []byte, confirmed bool) ([]byte, error) {
+	if d.Session == nil {
+		return nil, errors.New("lorawan/harness: device has not joined")
+	}
+
+	mType := lorawan.UnconfirmedDataUp
+	if confirmed {
+		mType = lorawan.ConfirmedDataUp
+	}
+
+	phy := lorawan.PHYPayload{
+		MHDR: lorawan.MHDR{
+			MType: mType,
+			Major: lorawan.LoRaWANR1,
+		},
+		MACPayload: &lorawan.MACPayload{
+			FHDR: lorawan.FHDR{
+				DevAddr: d.Session.DevAddr,
+				FCnt:    d.Session.FCntUp,
+			},
+			FPort:      &fPort,
+			FRMPayload: []lorawan.Payload{&lorawan.DataPayload{Bytes: data}},
+		},
+	}
+
+	if err := d.Session.EncryptFRMPayload(&phy); err != nil {
+		return nil, fmt.Errorf("lorawan/harness: encrypt frmpayload error: %w", err)
+	}
+	if err := d.Session.SetUplinkDataMIC(&phy, 0, 0); err != nil {
+		return nil, fmt.Errorf("lorawan/harness: set uplink mic error: %w", err)
+	}
+
+	b, err := phy.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("lorawan/harness: marshal uplink error: %w", err)
+	}
+
+	d.Session.FCntUp++
+
+	return b, nil
+}
+
+// HandleDownlink validates and decrypts the given downlink data frame
+// (PHYPayload).
+func (d *Device) HandleDownlink(b []byte) (Message, error) {
+	if d.Session == nil {
+		return Message{}, errors.New("lorawan/harness: device has not joined")
+	}
+
+	var phy lorawan.PHYPayload
+	if err := phy.UnmarshalBinary(b); err != nil {
+		return Message{}, fmt.Errorf("lorawan/harness: unmarshal downlink error: %w", err)
+	}
+	if phy.MHDR.MType != lorawan.UnconfirmedDataDown && phy.MHDR.MType != lorawan.ConfirmedDataDown {
+		return Message{}, fmt.Errorf("lorawan/harness: unexpected downlink MType: %s", phy.MHDR.MType)
+	}
+	macPL, ok := phy.MACPayload.(*lorawan.MACPayload)
+	if !ok {
+		return Message{}, fmt.Errorf("lorawan/harness: expected *lorawan.MACPayload, got %T", phy.MACPayload)
+	}
+	if macPL.FHDR.DevAddr != d.Session.DevAddr {
+		return Message{}, fmt.Errorf("lorawan/harness: unexpected DevAddr: %s", macPL.FHDR.DevAddr)
+	}
+
+	// a 1.1 downlink acknowledges the last uplink
+	d.Session.ConfFCnt = d.Session.FCntUp - 1
+
+	fCnt := fullFCnt(d.Session.GetFCntDown(macPL.FPort), macPL.FHDR.FCnt)
+	macPL.FHDR.FCnt = fCnt
+
+	ok, err := d.Session.ValidateDownlinkDataMIC(phy)
+	if err != nil {
+		return Message{}, fmt.Errorf("lorawan/harness: validate downlink mic error: %w", err)
+	}
+	if !ok {
+		return Message{}, errors.New("lorawan/harness: invalid downlink mic")
+	}
+
+	if err := d.Session.DecryptFRMPayload(&phy); err != nil {
+		return Message{}, fmt.Errorf("lorawan/harness: decrypt frmpayload error: %w", err)
+	}
+
+	if d.Session.MACVersion != lorawan.LoRaWAN1_0 && macPL.FPort != nil && *macPL.FPort > 0 {
+		d.Session.AFCntDown = fCnt + 1
+	} else {
+		d.Session.NFCntDown = fCnt + 1
+	}
+
+	return newMessage(d.DevEUI, phy)
+}
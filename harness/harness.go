@@ -0,0 +1,221 @@
+// Package harness wires a simulated device, a minimal network-server and a
+// join-server (using the backend/joinserver handler) into one in-process
+// LoRaWAN network. It runs the OTAA join and the uplink / downlink data
+// exchange end-to-end, using the frame, session and backend helpers of this
+// module. It serves both as executable documentation of how these helpers
+// fit together and as an integration test.
+//
+// Radio aspects (data-rates, channels, timing) are not simulated.
+package harness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/backend"
+	"github.com/brocaar/lorawan/backend/joinserver"
+)
+
+// asKEKLabel defines the KEK label used for the application-server.
+const asKEKLabel = "as"
+
+// Message holds a (decrypted) application payload exchanged between the
+// device and the network-server.
+type Message struct {
+	DevEUI    lorawan.EUI64
+	FCnt      uint32
+	FPort     uint8
+	Data      []byte
+	Confirmed bool
+	ACK       bool
+}
+
+// Config holds the harness configuration.
+type Config struct {
+	// MACVersion holds the MAC version of the device.
+	MACVersion lorawan.MACVersion
+
+	// NetID holds the NetID of the network-server.
+	NetID lorawan.NetID
+
+	// Device identifiers and root keys. For LoRaWAN 1.0.x, the NwkKey
+	// holds the AppKey and the AppKey is ignored.
+	DevEUI  lorawan.EUI64
+	JoinEUI lorawan.EUI64
+	NwkKey  lorawan.AES128Key
+	AppKey  lorawan.AES128Key
+
+	// NSKEK and ASKEK hold the optional KEKs used by the join-server to
+	// wrap the network and application session keys. When not set, the
+	// keys are returned in plaintext.
+	NSKEK []byte
+	ASKEK []byte
+}
+
+// Harness holds an in-process network consisting of a single Device, a
+// NetworkServer and a join-server.
+type Harness struct {
+	Device        *Device
+	NetworkServer *NetworkServer
+
+	joinServer *httptest.Server
+
+	mu        sync.Mutex
+	joinNonce int
+}
+
+// New creates a new Harness. Call Close to shut down the join-server.
+func New(config Config) (*Harness, error) {
+	h := Harness{
+		Device: &Device{
+			DevEUI:  config.DevEUI,
+			JoinEUI: config.JoinEUI,
+			NwkKey:  config.NwkKey,
+			AppKey:  config.AppKey,
+		},
+	}
+
+	kekStore := backend.NewKEKStore(map[string][]byte{
+		config.NetID.String(): config.NSKEK,
+		asKEKLabel:            config.ASKEK,
+	})
+
+	handler, err := joinserver.NewHandler(joinserver.HandlerConfig{
+		GetDeviceKeysByDevEUIFunc: func(devEUI lorawan.EUI64) (joinserver.DeviceKeys, error) {
+			if devEUI != config.DevEUI {
+				return joinserver.DeviceKeys{}, joinserver.ErrDevEUINotFound
+			}
+
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			h.joinNonce++
+
+			return joinserver.DeviceKeys{
+				DevEUI:    config.DevEUI,
+				NwkKey:    config.NwkKey,
+				AppKey:    config.AppKey,
+				JoinNonce: h.joinNonce,
+			}, nil
+		},
+		GetKEKByLabelFunc: kekStore.GetKEK,
+		GetASKEKLabelByDevEUIFunc: func(devEUI lorawan.EUI64) (string, error) {
+			if len(config.ASKEK) == 0 {
+				return "", nil
+			}
+			return asKEKLabel, nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("lorawan/harness: new join-server handler error: %w", err)
+	}
+	h.joinServer = httptest.NewServer(handler)
+
+	client, err := backend.NewClient(backend.ClientConfig{
+		SenderID:   config.NetID.String(),
+		ReceiverID: config.JoinEUI.String(),
+		Server:     h.joinServer.URL,
+	})
+	if err != nil {
+		h.joinServer.Close()
+		return nil, fmt.Errorf("lorawan/harness: new join-server client error: %w", err)
+	}
+
+	h.NetworkServer, err = NewNetworkServer(NetworkServerConfig{
+		NetID:      config.NetID,
+		MACVersion: config.MACVersion,
+		JoinServer: client,
+		KEKStore:   kekStore,
+	})
+	if err != nil {
+		h.joinServer.Close()
+		return nil, err
+	}
+
+	return &h, nil
+}
+
+// Close shuts down the join-server.
+func (h *Harness) Close() {
+	h.joinServer.Close()
+}
+
+// Join performs the OTAA join of the device: the join-request is sent by
+// the device to the network-server, which forwards it to the join-server.
+// The join-accept is returned to the device, which activates its session.
+func (h *Harness) Join(ctx context.Context) error {
+	b, err := h.Device.JoinRequest()
+	if err != nil {
+		return err
+	}
+
+	ja, err := h.NetworkServer.HandleUplink(ctx, b)
+	if err != nil {
+		return err
+	}
+
+	return h.Device.HandleJoinAccept(ja)
+}
+
+// Uplink sends the given application payload from the device to the
+// network-server. When the network-server responds with a downlink, this
+// is handled by the device and returned. Else, nil is returned.
+func (h *Harness) Uplink(ctx context.Context, fPort uint8, data []byte, confirmed bool) (*Message, error) {
+	b, err := h.Device.Uplink(fPort, data, confirmed)
+	if err != nil {
+		return nil, err
+	}
+
+	dl, err := h.NetworkServer.HandleUplink(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	if dl == nil {
+		return nil, nil
+	}
+
+	msg, err := h.Device.HandleDownlink(dl)
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// newMessage returns the Message for the given (decrypted) data frame.
+func newMessage(devEUI lorawan.EUI64, phy lorawan.PHYPayload) (Message, error) {
+	macPL, ok := phy.MACPayload.(*lorawan.MACPayload)
+	if !ok {
+		return Message{}, errors.New("lorawan/harness: expected *lorawan.MACPayload")
+	}
+
+	msg := Message{
+		DevEUI:    devEUI,
+		FCnt:      macPL.FHDR.FCnt,
+		Confirmed: phy.MHDR.MType == lorawan.ConfirmedDataUp || phy.MHDR.MType == lorawan.ConfirmedDataDown,
+		ACK:       macPL.FHDR.FCtrl.ACK,
+	}
+
+	if macPL.FPort != nil {
+		msg.FPort = *macPL.FPort
+	}
+	if len(macPL.FRMPayload) == 1 {
+		if pl, ok := macPL.FRMPayload[0].(*lorawan.DataPayload); ok {
+			msg.Data = pl.Bytes
+		}
+	}
+
+	return msg, nil
+}
+
+// fullFCnt returns the full 32 bit frame-counter, given the expected
+// frame-counter and the 16 LSB as transmitted over the air.
+func fullFCnt(expected, fCnt uint32) uint32 {
+	full := expected&0xffff0000 | fCnt&0xffff
+	if full < expected {
+		full += 1 << 16
+	}
+	return full
+}
@@ -0,0 +1,175 @@
+package harness
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/cryptotest"
+)
+
+func testConfig(macVersion lorawan.MACVersion) Config {
+	return Config{
+		MACVersion: macVersion,
+		NetID:      lorawan.NetID{0x00, 0x00, 0x13},
+		DevEUI:     lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		JoinEUI:    lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1},
+		NwkKey:     lorawan.AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8},
+		AppKey:     lorawan.AES128Key{8, 7, 6, 5, 4, 3, 2, 1, 8, 7, 6, 5, 4, 3, 2, 1},
+	}
+}
+
+func TestHarness(t *testing.T) {
+	kek := []byte{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8}
+
+	tests := []struct {
+		name       string
+		macVersion lorawan.MACVersion
+		kek        []byte
+	}{
+		{"LoRaWAN 1.0", lorawan.LoRaWAN1_0, nil},
+		{"LoRaWAN 1.0 with KEK", lorawan.LoRaWAN1_0, kek},
+		{"LoRaWAN 1.1", lorawan.LoRaWAN1_1, nil},
+		{"LoRaWAN 1.1 with KEK", lorawan.LoRaWAN1_1, kek},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			assert := require.New(t)
+			ctx := context.Background()
+
+			config := testConfig(tst.macVersion)
+			config.NSKEK = tst.kek
+			config.ASKEK = tst.kek
+
+			h, err := New(config)
+			assert.NoError(err)
+			defer h.Close()
+
+			// join
+			assert.NoError(h.Join(ctx))
+			assert.NotNil(h.Device.Session)
+			assert.Equal(tst.macVersion, h.Device.Session.MACVersion)
+			assert.True(h.Device.Session.DevAddr.IsNetID(config.NetID))
+
+			nsSession, ok := h.NetworkServer.Session(config.DevEUI)
+			assert.True(ok)
+			assert.Equal(*h.Device.Session, nsSession)
+
+			// unconfirmed uplink without downlink
+			dl, err := h.Uplink(ctx, 10, []byte{1, 2, 3}, false)
+			assert.NoError(err)
+			assert.Nil(dl)
+
+			// confirmed uplink is acknowledged
+			dl, err = h.Uplink(ctx, 10, []byte{4, 5, 6}, true)
+			assert.NoError(err)
+			assert.NotNil(dl)
+			assert.True(dl.ACK)
+			assert.Nil(dl.Data)
+
+			// downlink application payload, LoRaWAN 1.1 uses a separate
+			// application downlink frame-counter
+			fCntDown := uint32(1)
+			if tst.macVersion == lorawan.LoRaWAN1_1 {
+				fCntDown = 0
+			}
+			assert.NoError(h.NetworkServer.Enqueue(config.DevEUI, 20, []byte{7, 8, 9}, false))
+			dl, err = h.Uplink(ctx, 10, []byte{10}, false)
+			assert.NoError(err)
+			assert.Equal(&Message{
+				DevEUI: config.DevEUI,
+				FCnt:   fCntDown,
+				FPort:  20,
+				Data:   []byte{7, 8, 9},
+			}, dl)
+
+			assert.Equal([]Message{
+				{DevEUI: config.DevEUI, FCnt: 0, FPort: 10, Data: []byte{1, 2, 3}},
+				{DevEUI: config.DevEUI, FCnt: 1, FPort: 10, Data: []byte{4, 5, 6}, Confirmed: true},
+				{DevEUI: config.DevEUI, FCnt: 2, FPort: 10, Data: []byte{10}},
+			}, h.NetworkServer.Uplinks())
+
+			// re-join resets the session
+			assert.NoError(h.Join(ctx))
+			assert.Equal(uint32(0), h.Device.Session.FCntUp)
+			_, err = h.Uplink(ctx, 10, []byte{1}, false)
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestHarnessInvalidKey(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h, err := New(testConfig(lorawan.LoRaWAN1_0))
+	assert.NoError(err)
+	defer h.Close()
+
+	h.Device.NwkKey = lorawan.AES128Key{}
+	err = h.Join(ctx)
+	assert.Error(err)
+	assert.Contains(err.Error(), "MICFailed")
+
+	_, err = h.Uplink(ctx, 10, []byte{1}, false)
+	assert.EqualError(err, "lorawan/harness: device has not joined")
+}
+
+func TestHarnessReplay(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h, err := New(testConfig(lorawan.LoRaWAN1_0))
+	assert.NoError(err)
+	defer h.Close()
+	assert.NoError(h.Join(ctx))
+
+	b, err := h.Device.Uplink(10, []byte{1}, false)
+	assert.NoError(err)
+	_, err = h.NetworkServer.HandleUplink(ctx, b)
+	assert.NoError(err)
+
+	_, err = h.NetworkServer.HandleUplink(ctx, b)
+	assert.EqualError(err, "lorawan/harness: invalid uplink mic")
+}
+
+func TestDeriveSessionKeys(t *testing.T) {
+	cryptotest.CheckSessionKeys(t, func(optNeg bool, nwkKey, appKey lorawan.AES128Key, netID lorawan.NetID, joinEUI lorawan.EUI64, joinNonce lorawan.JoinNonce, devNonce lorawan.DevNonce) (cryptotest.SessionKeys, error) {
+		s, err := deriveSessionKeys(optNeg, nwkKey, appKey, netID, joinEUI, joinNonce, devNonce)
+		return cryptotest.SessionKeys{
+			FNwkSIntKey: s.FNwkSIntKey,
+			SNwkSIntKey: s.SNwkSIntKey,
+			NwkSEncKey:  s.NwkSEncKey,
+			AppSKey:     s.AppSKey,
+		}, err
+	})
+}
+
+func TestDeriveJSIntKey(t *testing.T) {
+	for _, v := range cryptotest.JSKeyVectors {
+		key, err := deriveJSIntKey(v.NwkKey, v.DevEUI)
+		require.NoError(t, err, v.Name)
+		require.Equal(t, v.ExpectedJSIntKey, key, v.Name)
+	}
+}
+
+func TestFullFCnt(t *testing.T) {
+	tests := []struct {
+		expected uint32
+		fCnt     uint32
+		out      uint32
+	}{
+		{0, 0, 0},
+		{1, 5, 5},
+		{0xffff, 0, 0x10000},
+		{0x1fffe, 0xffff, 0x1ffff},
+		{0x10001, 0x0002, 0x10002},
+	}
+
+	for _, tst := range tests {
+		require.Equal(t, tst.out, fullFCnt(tst.expected, tst.fCnt))
+	}
+}
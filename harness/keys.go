@@ -0,0 +1,106 @@
+package harness
+
+import (
+	"crypto/aes"
+
+	"github.com/brocaar/lorawan"
+)
+
+// deriveSessionKeys derives the session keys on the device side. For
+// LoRaWAN 1.0.x (optNeg=false), the appKey is ignored and the NwkSKey is
+// returned as FNwkSIntKey, SNwkSIntKey and NwkSEncKey.
+func deriveSessionKeys(optNeg bool, nwkKey, appKey lorawan.AES128Key, netID lorawan.NetID, joinEUI lorawan.EUI64, joinNonce lorawan.JoinNonce, devNonce lorawan.DevNonce) (lorawan.SessionContext, error) {
+	var s lorawan.SessionContext
+	var err error
+
+	if !optNeg {
+		s.MACVersion = lorawan.LoRaWAN1_0
+
+		nwkSKey, err := deriveSKey(optNeg, 0x01, nwkKey, netID, joinEUI, joinNonce, devNonce)
+		if err != nil {
+			return s, err
+		}
+		s.SetNwkSKey(nwkSKey)
+
+		s.AppSKey, err = deriveSKey(optNeg, 0x02, nwkKey, netID, joinEUI, joinNonce, devNonce)
+		return s, err
+	}
+
+	s.MACVersion = lorawan.LoRaWAN1_1
+
+	if s.FNwkSIntKey, err = deriveSKey(optNeg, 0x01, nwkKey, netID, joinEUI, joinNonce, devNonce); err != nil {
+		return s, err
+	}
+	if s.AppSKey, err = deriveSKey(optNeg, 0x02, appKey, netID, joinEUI, joinNonce, devNonce); err != nil {
+		return s, err
+	}
+	if s.SNwkSIntKey, err = deriveSKey(optNeg, 0x03, nwkKey, netID, joinEUI, joinNonce, devNonce); err != nil {
+		return s, err
+	}
+	if s.NwkSEncKey, err = deriveSKey(optNeg, 0x04, nwkKey, netID, joinEUI, joinNonce, devNonce); err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
+
+func deriveSKey(optNeg bool, typ byte, key lorawan.AES128Key, netID lorawan.NetID, joinEUI lorawan.EUI64, joinNonce lorawan.JoinNonce, devNonce lorawan.DevNonce) (lorawan.AES128Key, error) {
+	var out lorawan.AES128Key
+	b := make([]byte, 16)
+	b[0] = typ
+
+	joinNonceB, err := joinNonce.MarshalBinary()
+	if err != nil {
+		return out, err
+	}
+	devNonceB, err := devNonce.MarshalBinary()
+	if err != nil {
+		return out, err
+	}
+
+	copy(b[1:4], joinNonceB)
+	if optNeg {
+		joinEUIB, err := joinEUI.MarshalBinary()
+		if err != nil {
+			return out, err
+		}
+		copy(b[4:12], joinEUIB)
+		copy(b[12:14], devNonceB)
+	} else {
+		netIDB, err := netID.MarshalBinary()
+		if err != nil {
+			return out, err
+		}
+		copy(b[4:7], netIDB)
+		copy(b[7:9], devNonceB)
+	}
+
+	return encryptBlock(key, b)
+}
+
+// deriveJSIntKey derives the JSIntKey (LoRaWAN 1.1), used for the
+// join-accept MIC.
+func deriveJSIntKey(nwkKey lorawan.AES128Key, devEUI lorawan.EUI64) (lorawan.AES128Key, error) {
+	b := make([]byte, 16)
+	b[0] = 0x06
+
+	devEUIB, err := devEUI.MarshalBinary()
+	if err != nil {
+		return lorawan.AES128Key{}, err
+	}
+	copy(b[1:9], devEUIB)
+
+	return encryptBlock(nwkKey, b)
+}
+
+func encryptBlock(key lorawan.AES128Key, b []byte) (lorawan.AES128Key, error) {
+	var out lorawan.AES128Key
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return out, err
+	}
+	block.Encrypt(out[:], b)
+
+	return out, nil
+}
@@ -0,0 +1,283 @@
+package harness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/backend"
+)
+
+// NetworkServerConfig holds the NetworkServer configuration.
+type NetworkServerConfig struct {
+	// NetID holds the NetID of the network-server.
+	NetID lorawan.NetID
+
+	// MACVersion holds the MAC version of the devices. In case of
+	// LoRaWAN 1.1, the OptNeg bit is set in the join-request to the
+	// join-server.
+	MACVersion lorawan.MACVersion
+
+	// JoinServer holds the backend client for the join-server.
+	JoinServer backend.Client
+
+	// KEKStore holds the KEKs for unwrapping the session keys. As the
+	// NetworkServer also acts as application-server, this is used for
+	// both the network and application session keys. It can be nil when
+	// the keys are not wrapped.
+	KEKStore backend.KEKStore
+}
+
+// NetworkServer implements a minimal network-server (and application-server)
+// handling the OTAA join through the join-server and the data exchange,
+// using the lorawan frame and session helpers. It does not implement the
+// MAC layer (mac-commands, ADR, ...) and does not de-duplicate uplinks.
+//
+// The NetworkServer is safe for concurrent use.
+type NetworkServer struct {
+	config   NetworkServerConfig
+	devAddrs lorawan.DevAddrAllocator
+
+	mu       sync.Mutex
+	devices  map[lorawan.DevAddr]*nsDevice
+	uplinks  []Message
+	downlink map[lorawan.EUI64][]Message
+}
+
+type nsDevice struct {
+	devEUI  lorawan.EUI64
+	session lorawan.SessionContext
+}
+
+// NewNetworkServer creates a new NetworkServer.
+func NewNetworkServer(config NetworkServerConfig) (*NetworkServer, error) {
+	if config.JoinServer == nil {
+		return nil, errors.New("lorawan/harness: JoinServer must be set")
+	}
+
+	return &NetworkServer{
+		config:   config,
+		devAddrs: lorawan.NewSequentialDevAddrAllocator(config.NetID, 1),
+		devices:  make(map[lorawan.DevAddr]*nsDevice),
+		downlink: make(map[lorawan.EUI64][]Message),
+	}, nil
+}
+
+// HandleUplink handles the given uplink (PHYPayload) and returns the
+// downlink (PHYPayload) which must be sent to the device. It returns nil
+// when there is no downlink.
+func (n *NetworkServer) HandleUplink(ctx context.Context, b []byte) ([]byte, error) {
+	var phy lorawan.PHYPayload
+	if err := phy.UnmarshalBinary(b); err != nil {
+		return nil, fmt.Errorf("lorawan/harness: unmarshal uplink error: %w", err)
+	}
+
+	switch phy.MHDR.MType {
+	case lorawan.JoinRequest:
+		return n.handleJoinRequest(ctx, phy, b)
+	case lorawan.UnconfirmedDataUp, lorawan.ConfirmedDataUp:
+		return n.handleDataUp(phy)
+	default:
+		return nil, fmt.Errorf("lorawan/harness: unexpected uplink MType: %s", phy.MHDR.MType)
+	}
+}
+
+// Enqueue enqueues the given application payload for the device. It is sent
+// as response to the next uplink of the device.
+func (n *NetworkServer) Enqueue(devEUI lorawan.EUI64, fPort uint8, data []byte, confirmed bool) error {
+	if fPort == 0 {
+		return errors.New("lorawan/harness: FPort must be > 0")
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.downlink[devEUI] = append(n.downlink[devEUI], Message{
+		DevEUI:    devEUI,
+		FPort:     fPort,
+		Data:      data,
+		Confirmed: confirmed,
+	})
+	return nil
+}
+
+// Uplinks returns the received application payloads.
+func (n *NetworkServer) Uplinks() []Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append([]Message(nil), n.uplinks...)
+}
+
+// Session returns the session-context of the given device.
+func (n *NetworkServer) Session(devEUI lorawan.EUI64) (lorawan.SessionContext, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, d := range n.devices {
+		if d.devEUI == devEUI {
+			return d.session, true
+		}
+	}
+	return lorawan.SessionContext{}, false
+}
+
+func (n *NetworkServer) handleJoinRequest(ctx context.Context, phy lorawan.PHYPayload, b []byte) ([]byte, error) {
+	jr, ok := phy.MACPayload.(*lorawan.JoinRequestPayload)
+	if !ok {
+		return nil, fmt.Errorf("lorawan/harness: expected *lorawan.JoinRequestPayload, got %T", phy.MACPayload)
+	}
+
+	devAddr, err := n.devAddrs.Allocate()
+	if err != nil {
+		return nil, fmt.Errorf("lorawan/harness: allocate devaddr error: %w", err)
+	}
+
+	macVersion := "1.0.3"
+	if n.config.MACVersion == lorawan.LoRaWAN1_1 {
+		macVersion = "1.1.0"
+	}
+
+	ans, err := n.config.JoinServer.JoinReq(ctx, backend.JoinReqPayload{
+		MACVersion: macVersion,
+		PHYPayload: backend.HEXBytes(b),
+		DevEUI:     jr.DevEUI,
+		DevAddr:    devAddr,
+		DLSettings: lorawan.DLSettings{
+			OptNeg: n.config.MACVersion == lorawan.LoRaWAN1_1,
+		},
+		RxDelay: 1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("lorawan/harness: join-request error: %w", err)
+	}
+
+	ns, err := backend.GetNetworkSessionFromJoinAns(ans, n.config.KEKStore)
+	if err != nil {
+		return nil, fmt.Errorf("lorawan/harness: get network session error: %w", err)
+	}
+
+	session := ns.GetSessionContext(devAddr)
+	session.AppSKey, err = backend.UnwrapKey(n.config.KEKStore, ns.AppSKey)
+	if err != nil {
+		return nil, fmt.Errorf("lorawan/harness: unwrap AppSKey error: %w", err)
+	}
+
+	n.mu.Lock()
+	for addr, d := range n.devices {
+		if d.devEUI == jr.DevEUI {
+			delete(n.devices, addr)
+		}
+	}
+	n.devices[devAddr] = &nsDevice{
+		devEUI:  jr.DevEUI,
+		session: session,
+	}
+	n.mu.Unlock()
+
+	return ns.PHYPayload.MarshalBinary()
+}
+
+func (n *NetworkServer) handleDataUp(phy lorawan.PHYPayload) ([]byte, error) {
+	macPL, ok := phy.MACPayload.(*lorawan.MACPayload)
+	if !ok {
+		return nil, fmt.Errorf("lorawan/harness: expected *lorawan.MACPayload, got %T", phy.MACPayload)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	d, ok := n.devices[macPL.FHDR.DevAddr]
+	if !ok {
+		return nil, fmt.Errorf("lorawan/harness: unknown DevAddr: %s", macPL.FHDR.DevAddr)
+	}
+
+	fCnt := fullFCnt(d.session.FCntUp, macPL.FHDR.FCnt)
+	macPL.FHDR.FCnt = fCnt
+
+	ok, err := d.session.ValidateUplinkDataMIC(phy, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("lorawan/harness: validate uplink mic error: %w", err)
+	}
+	if !ok {
+		return nil, errors.New("lorawan/harness: invalid uplink mic")
+	}
+
+	if err := d.session.DecryptFRMPayload(&phy); err != nil {
+		return nil, fmt.Errorf("lorawan/harness: decrypt frmpayload error: %w", err)
+	}
+	d.session.FCntUp = fCnt + 1
+
+	msg, err := newMessage(d.devEUI, phy)
+	if err != nil {
+		return nil, err
+	}
+	n.uplinks = append(n.uplinks, msg)
+
+	var next *Message
+	if queue := n.downlink[d.devEUI]; len(queue) != 0 {
+		next = &queue[0]
+		n.downlink[d.devEUI] = queue[1:]
+	}
+
+	if !msg.Confirmed && next == nil {
+		return nil, nil
+	}
+
+	return n.dataDown(d, msg, next)
+}
+
+// dataDown returns the downlink data frame for the given device, which
+// acknowledges the given uplink when confirmed and contains the given
+// application payload, when not nil.
+func (n *NetworkServer) dataDown(d *nsDevice, up Message, down *Message) ([]byte, error) {
+	mType := lorawan.UnconfirmedDataDown
+	if down != nil && down.Confirmed {
+		mType = lorawan.ConfirmedDataDown
+	}
+
+	macPL := lorawan.MACPayload{
+		FHDR: lorawan.FHDR{
+			DevAddr: d.session.DevAddr,
+			FCtrl: lorawan.FCtrl{
+				ACK: up.Confirmed,
+			},
+		},
+	}
+	if down != nil {
+		fPort := down.FPort
+		macPL.FPort = &fPort
+		macPL.FRMPayload = []lorawan.Payload{&lorawan.DataPayload{Bytes: down.Data}}
+	}
+
+	fCnt := d.session.GetFCntDown(macPL.FPort)
+	macPL.FHDR.FCnt = fCnt
+	d.session.ConfFCnt = up.FCnt
+
+	phy := lorawan.PHYPayload{
+		MHDR: lorawan.MHDR{
+			MType: mType,
+			Major: lorawan.LoRaWANR1,
+		},
+		MACPayload: &macPL,
+	}
+
+	if down != nil {
+		if err := d.session.EncryptFRMPayload(&phy); err != nil {
+			return nil, fmt.Errorf("lorawan/harness: encrypt frmpayload error: %w", err)
+		}
+	}
+	if err := d.session.SetDownlinkDataMIC(&phy); err != nil {
+		return nil, fmt.Errorf("lorawan/harness: set downlink mic error: %w", err)
+	}
+
+	if d.session.MACVersion != lorawan.LoRaWAN1_0 && macPL.FPort != nil && *macPL.FPort > 0 {
+		d.session.AFCntDown = fCnt + 1
+	} else {
+		d.session.NFCntDown = fCnt + 1
+	}
+
+	return phy.MarshalBinary()
+}
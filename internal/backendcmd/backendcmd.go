@@ -0,0 +1,84 @@
+// Package backendcmd contains the helpers shared by the Backend Interfaces
+// commands (lorawan-backend-send and lorawan-backend-conformance).
+package backendcmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/brocaar/lorawan/backend"
+)
+
+// requests contains the supported request message-types.
+var requests = map[backend.MessageType]func() backend.Request{
+	backend.JoinReq:      func() backend.Request { return &backend.JoinReqPayload{} },
+	backend.RejoinReq:    func() backend.Request { return &backend.RejoinReqPayload{} },
+	backend.AppSKeyReq:   func() backend.Request { return &backend.AppSKeyReqPayload{} },
+	backend.VSMcKEKeyReq: func() backend.Request { return &backend.McKEKeyReqPayload{} },
+	backend.PRStartReq:   func() backend.Request { return &backend.PRStartReqPayload{} },
+	backend.PRStopReq:    func() backend.Request { return &backend.PRStopReqPayload{} },
+	backend.HRStartReq:   func() backend.Request { return &backend.HRStartReqPayload{} },
+	backend.HRStopReq:    func() backend.Request { return &backend.HRStopReqPayload{} },
+	backend.HomeNSReq:    func() backend.Request { return &backend.HomeNSReqPayload{} },
+	backend.ProfileReq:   func() backend.Request { return &backend.ProfileReqPayload{} },
+	backend.XmitDataReq:  func() backend.Request { return &backend.XmitDataReqPayload{} },
+}
+
+// NewRequest returns a pointer to a new (empty) request payload of the given
+// message-type, to unmarshal the request into. It returns false when the
+// message-type is not supported.
+func NewRequest(mt backend.MessageType) (backend.Request, bool) {
+	newFunc, ok := requests[mt]
+	if !ok {
+		return nil, false
+	}
+	return newFunc(), true
+}
+
+// TLSConfig holds the TLS configuration of the commands.
+type TLSConfig struct {
+	CACert  string `json:"ca_cert"`
+	TLSCert string `json:"tls_cert"`
+	TLSKey  string `json:"tls_key"`
+}
+
+// NewHTTPClient returns the HTTP client for the TLS configuration. When no
+// certificates are configured, http.DefaultClient is returned.
+func (c TLSConfig) NewHTTPClient() (*http.Client, error) {
+	if c.CACert == "" && c.TLSCert == "" && c.TLSKey == "" {
+		return http.DefaultClient, nil
+	}
+
+	tlsConfig := &tls.Config{}
+
+	if c.CACert != "" {
+		rawCACert, err := ioutil.ReadFile(c.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("read ca cert error: %w", err)
+		}
+
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(rawCACert) {
+			return nil, errors.New("append ca cert to pool error")
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	if c.TLSCert != "" || c.TLSKey != "" {
+		cert, err := tls.LoadX509KeyPair(c.TLSCert, c.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("load x509 keypair error: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+		},
+	}, nil
+}
@@ -0,0 +1,32 @@
+package backendcmd
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan/backend"
+)
+
+func TestNewRequest(t *testing.T) {
+	assert := require.New(t)
+
+	req, ok := NewRequest(backend.PRStartReq)
+	assert.True(ok)
+	assert.IsType(&backend.PRStartReqPayload{}, req)
+
+	_, ok = NewRequest(backend.PRStartAns)
+	assert.False(ok)
+}
+
+func TestTLSConfigNewHTTPClient(t *testing.T) {
+	assert := require.New(t)
+
+	client, err := TLSConfig{}.NewHTTPClient()
+	assert.NoError(err)
+	assert.Equal(http.DefaultClient, client)
+
+	_, err = TLSConfig{CACert: "/does/not/exist"}.NewHTTPClient()
+	assert.Error(err)
+}
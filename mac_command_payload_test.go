@@ -38,7 +38,7 @@ func (ts MACCommandPayloadTestSuite) run(newPLFunc func() MACCommandPayload, tes
 		if tst.Error == nil {
 			assert.NoError(err)
 		} else {
-			assert.Equal(tst.Error, err)
+			assert.EqualError(err, tst.Error.Error())
 		}
 
 		if err == nil {
@@ -196,7 +196,7 @@ func (m MACCommand) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (m *MACCommand) UnmarshalBinary(uplink bool, data []byte) error {
 	if len(data) == 0 {
-		return errors.New("lorawan: at least 1 byte of data is expected")
+		return newDecodeError("MACCommand", ErrInvalidLength, "lorawan: at least 1 byte of data is expected")
 	}
 
 	m.CID = CID(data[0])
@@ -208,7 +208,7 @@ func (m *MACCommand) UnmarshalBinary(uplink bool, data []byte) error {
 		}
 		m.Payload = p
 		if err := m.Payload.UnmarshalBinary(data[1:]); err != nil {
-			return err
+			return withOffset(err, 1)
 		}
 	}
 	return nil
@@ -244,7 +244,7 @@ func (p LinkCheckAnsPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (p *LinkCheckAnsPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 2 {
-		return errors.New("lorawan: 2 bytes of data are expected")
+		return newDecodeError("LinkCheckAnsPayload", ErrInvalidLength, "lorawan: 2 bytes of data are expected")
 	}
 	p.Margin = uint8(data[0])
 	p.GwCnt = uint8(data[1])
@@ -273,7 +273,7 @@ func (m ChMask) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (m *ChMask) UnmarshalBinary(data []byte) error {
 	if len(data) != 2 {
-		return errors.New("lorawan: 2 bytes of data are expected")
+		return newDecodeError("ChMask", ErrInvalidLength, "lorawan: 2 bytes of data are expected")
 	}
 
 	n := binary.LittleEndian.Uint16(data)
@@ -308,7 +308,7 @@ func (r Redundancy) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (r *Redundancy) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is expected")
+		return newDecodeError("Redundancy", ErrInvalidLength, "lorawan: 1 byte of data is expected")
 	}
 	r.NbRep = data[0] & ((1 << 3) ^ (1 << 2) ^ (1 << 1) ^ (1 << 0))
 	r.ChMaskCntl = (data[0] & ((1 << 6) ^ (1 << 5) ^ (1 << 4))) >> 4
@@ -352,7 +352,7 @@ func (p LinkADRReqPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (p *LinkADRReqPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 4 {
-		return errors.New("lorawan: 4 bytes of data are expected")
+		return newDecodeError("LinkADRReqPayload", ErrInvalidLength, "lorawan: 4 bytes of data are expected")
 	}
 	p.DataRate = (data[0] & ((1 << 7) ^ (1 << 6) ^ (1 << 5) ^ (1 << 4))) >> 4
 	p.TXPower = data[0] & ((1 << 3) ^ (1 << 2) ^ (1 << 1) ^ (1 << 0))
@@ -388,7 +388,7 @@ func (p LinkADRAnsPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (p *LinkADRAnsPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is expected")
+		return newDecodeError("LinkADRAnsPayload", ErrInvalidLength, "lorawan: 1 byte of data is expected")
 	}
 	if data[0]&(1<<0) > 0 {
 		p.ChannelMaskACK = true
@@ -420,7 +420,7 @@ func (p DutyCycleReqPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (p *DutyCycleReqPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is expected")
+		return newDecodeError("DutyCycleReqPayload", ErrInvalidLength, "lorawan: 1 byte of data is expected")
 	}
 	p.MaxDCycle = data[0]
 	return nil
@@ -465,7 +465,7 @@ func (s DLSettings) MarshalText() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (s *DLSettings) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is expected")
+		return newDecodeError("DLSettings", ErrInvalidLength, "lorawan: 1 byte of data is expected")
 	}
 
 	s.OptNeg = (data[0] & (1 << 7)) != 0
@@ -515,7 +515,7 @@ func (p RXParamSetupReqPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (p *RXParamSetupReqPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 4 {
-		return errors.New("lorawan: 4 bytes of data are expected")
+		return newDecodeError("RXParamSetupReqPayload", ErrInvalidLength, "lorawan: 4 bytes of data are expected")
 	}
 	if err := p.DLSettings.UnmarshalBinary(data[0:1]); err != nil {
 		return err
@@ -554,7 +554,7 @@ func (p RXParamSetupAnsPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (p *RXParamSetupAnsPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is expected")
+		return newDecodeError("RXParamSetupAnsPayload", ErrInvalidLength, "lorawan: 1 byte of data is expected")
 	}
 	p.ChannelACK = data[0]&(1<<0) > 0
 	p.RX2DataRateACK = data[0]&(1<<1) > 0
@@ -563,6 +563,8 @@ func (p *RXParamSetupAnsPayload) UnmarshalBinary(data []byte) error {
 }
 
 // DevStatusAnsPayload represents the DevStatusAns payload.
+// The Margin contains the demodulation SNR margin (dB) of the DevStatusReq.
+// See ExternalPower and BatteryLevel for interpreting the Battery value.
 type DevStatusAnsPayload struct {
 	Battery uint8 `json:"battery"`
 	Margin  int8  `json:"margin"`
@@ -590,7 +592,7 @@ func (p DevStatusAnsPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (p *DevStatusAnsPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 2 {
-		return errors.New("lorawan: 2 bytes of data are expected")
+		return newDecodeError("DevStatusAnsPayload", ErrInvalidLength, "lorawan: 2 bytes of data are expected")
 	}
 	p.Battery = data[0]
 	if data[1] > 31 {
@@ -637,7 +639,7 @@ func (p NewChannelReqPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (p *NewChannelReqPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 5 {
-		return errors.New("lorawan: 5 bytes of data are expected")
+		return newDecodeError("NewChannelReqPayload", ErrInvalidLength, "lorawan: 5 bytes of data are expected")
 	}
 	p.ChIndex = data[0]
 	p.MinDR = data[4] & ((1 << 3) ^ (1 << 2) ^ (1 << 1) ^ (1 << 0))
@@ -671,7 +673,7 @@ func (p NewChannelAnsPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (p *NewChannelAnsPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is expected")
+		return newDecodeError("NewChannelAnsPayload", ErrInvalidLength, "lorawan: 1 byte of data is expected")
 	}
 	p.ChannelFrequencyOK = data[0]&(1<<0) > 0
 	p.DataRateRangeOK = data[0]&(1<<1) > 0
@@ -694,7 +696,7 @@ func (p RXTimingSetupReqPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (p *RXTimingSetupReqPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is expected")
+		return newDecodeError("RXTimingSetupReqPayload", ErrInvalidLength, "lorawan: 1 byte of data is expected")
 	}
 	p.Delay = data[0]
 	return nil
@@ -732,7 +734,7 @@ func (p TXParamSetupReqPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from bytes.
 func (p *TXParamSetupReqPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is expected")
+		return newDecodeError("TXParamSetupReqPayload", ErrInvalidLength, "lorawan: 1 byte of data is expected")
 	}
 
 	if data[0]&(1<<4) > 0 {
@@ -773,7 +775,7 @@ func (p DLChannelReqPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from bytes.
 func (p *DLChannelReqPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 4 {
-		return errors.New("lorawan: 4 bytes of data are expected")
+		return newDecodeError("DLChannelReqPayload", ErrInvalidLength, "lorawan: 4 bytes of data are expected")
 	}
 
 	p.ChIndex = data[0]
@@ -804,7 +806,7 @@ func (p DLChannelAnsPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from bytes.
 func (p *DLChannelAnsPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is expected")
+		return newDecodeError("DLChannelAnsPayload", ErrInvalidLength, "lorawan: 1 byte of data is expected")
 	}
 
 	p.ChannelFrequencyOK = data[0]&1 > 0
@@ -829,7 +831,7 @@ func (p PingSlotInfoReqPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from bytes.
 func (p *PingSlotInfoReqPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is expected")
+		return newDecodeError("PingSlotInfoReqPayload", ErrInvalidLength, "lorawan: 1 byte of data is expected")
 	}
 
 	// first 3 bits
@@ -863,7 +865,7 @@ func (p BeaconFreqReqPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from bytes.
 func (p *BeaconFreqReqPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 3 {
-		return errors.New("lorawan: 3 bytes of data are expected")
+		return newDecodeError("BeaconFreqReqPayload", ErrInvalidLength, "lorawan: 3 bytes of data are expected")
 	}
 
 	// we need 4 bytes for Uint32
@@ -892,7 +894,7 @@ func (p BeaconFreqAnsPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from bytes.
 func (p *BeaconFreqAnsPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is expected")
+		return newDecodeError("BeaconFreqAnsPayload", ErrInvalidLength, "lorawan: 1 byte of data is expected")
 	}
 
 	p.BeaconFrequencyOK = data[0]&(1<<0) != 0
@@ -930,7 +932,7 @@ func (p PingSlotChannelReqPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from bytes.
 func (p *PingSlotChannelReqPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 4 {
-		return errors.New("lorawan: 4 bytes of data are expected")
+		return newDecodeError("PingSlotChannelReqPayload", ErrInvalidLength, "lorawan: 4 bytes of data are expected")
 	}
 
 	b := make([]byte, 4)
@@ -965,7 +967,7 @@ func (p PingSlotChannelAnsPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from bytes.
 func (p *PingSlotChannelAnsPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is expected")
+		return newDecodeError("PingSlotChannelAnsPayload", ErrInvalidLength, "lorawan: 1 byte of data is expected")
 	}
 
 	p.ChannelFrequencyOK = data[0]&(1<<0) != 0
@@ -995,7 +997,7 @@ func (p DeviceTimeAnsPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from bytes.
 func (p *DeviceTimeAnsPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 5 {
-		return errors.New("lorawan: 5 bytes of data is expected")
+		return newDecodeError("DeviceTimeAnsPayload", ErrInvalidLength, "lorawan: 5 bytes of data is expected")
 	}
 
 	seconds := binary.LittleEndian.Uint32(data[0:4])
@@ -1021,7 +1023,7 @@ func (v Version) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from bytes.
 func (v *Version) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is expected")
+		return newDecodeError("Version", ErrInvalidLength, "lorawan: 1 byte of data is expected")
 	}
 	v.Minor = data[0]
 	return nil
@@ -1108,7 +1110,7 @@ func (p ADRParam) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from bytes.
 func (p *ADRParam) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is expected")
+		return newDecodeError("ADRParam", ErrInvalidLength, "lorawan: 1 byte of data is expected")
 	}
 
 	p.DelayExp = data[0] & ((1 << 3) | (1 << 2) | (1 << 1) | 1)
@@ -1161,7 +1163,7 @@ func (p ForceRejoinReqPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from bytes.
 func (p *ForceRejoinReqPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 2 {
-		return errors.New("lorawan: 2 bytes of data are expected")
+		return newDecodeError("ForceRejoinReqPayload", ErrInvalidLength, "lorawan: 2 bytes of data are expected")
 	}
 
 	p.DR = data[0] & ((1 << 3) | (1 << 2) | (1 << 1) | 1)
@@ -1193,7 +1195,7 @@ func (p RejoinParamSetupReqPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from bytes.
 func (p *RejoinParamSetupReqPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is exepcted")
+		return newDecodeError("RejoinParamSetupReqPayload", ErrInvalidLength, "lorawan: 1 byte of data is exepcted")
 	}
 
 	p.MaxCountN = data[0] & ((1 << 3) | (1 << 2) | (1 << 1) | 1)
@@ -1220,7 +1222,7 @@ func (p RejoinParamSetupAnsPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from bytes.
 func (p *RejoinParamSetupAnsPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is expected")
+		return newDecodeError("RejoinParamSetupAnsPayload", ErrInvalidLength, "lorawan: 1 byte of data is expected")
 	}
 
 	p.TimeOK = data[0]&1 != 0
@@ -1250,7 +1252,7 @@ func (p DeviceModeIndPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from bytes.
 func (p *DeviceModeIndPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is expected")
+		return newDecodeError("DeviceModeIndPayload", ErrInvalidLength, "lorawan: 1 byte of data is expected")
 	}
 
 	p.Class = DeviceModeClass(data[0])
@@ -1271,7 +1273,7 @@ func (p DeviceModeConfPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from bytes.
 func (p *DeviceModeConfPayload) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is expected")
+		return newDecodeError("DeviceModeConfPayload", ErrInvalidLength, "lorawan: 1 byte of data is expected")
 	}
 
 	p.Class = DeviceModeClass(data[0])
@@ -1302,7 +1304,7 @@ func decodeDataPayloadToMACCommands(uplink bool, payloads []Payload) ([]Payload,
 		}
 
 		if len(dataPL.Bytes[i:]) < plLen+1 {
-			return nil, errors.New("lorawan: not enough remaining bytes")
+			return nil, withOffset(newDecodeError("MACCommand", ErrInvalidLength, "lorawan: not enough remaining bytes"), i)
 		}
 
 		mc := &MACCommand{}
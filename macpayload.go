@@ -13,54 +13,58 @@ type MACPayload struct {
 }
 
 func (p MACPayload) marshalPayload() ([]byte, error) {
-	var out []byte
-	var b []byte
+	b, err := p.appendFRMPayload(nil)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (p MACPayload) appendFRMPayload(b []byte) ([]byte, error) {
 	var err error
 	for _, fp := range p.FRMPayload {
-		if mac, ok := fp.(*MACCommand); ok {
+		if _, ok := fp.(*MACCommand); ok {
 			if p.FPort == nil || (p.FPort != nil && *p.FPort != 0) {
-				return []byte{}, errors.New("lorawan: a MAC command is only allowed when FPort=0")
+				return b, errors.New("lorawan: a MAC command is only allowed when FPort=0")
 			}
-			b, err = mac.MarshalBinary()
-		} else {
-			b, err = fp.MarshalBinary()
 		}
-		if err != nil {
-			return nil, err
+		if b, err = appendPayload(b, fp); err != nil {
+			return b, err
 		}
-		out = append(out, b...)
 	}
-	return out, nil
+	return b, nil
 }
 
 // MarshalBinary marshals the object in binary form.
 func (p MACPayload) MarshalBinary() ([]byte, error) {
-	var b []byte
-	var out []byte
-	var err error
+	return p.AppendBinary(nil)
+}
+
+// AppendBinary appends the binary form of the MACPayload to b and returns
+// the extended slice. This can be used with a (pooled) buffer to avoid the
+// allocations of MarshalBinary.
+func (p MACPayload) AppendBinary(b []byte) ([]byte, error) {
+	start := len(b)
 
-	b, err = p.FHDR.MarshalBinary()
+	b, err := p.FHDR.AppendBinary(b)
 	if err != nil {
-		return nil, err
+		return b[:start], err
 	}
-	out = append(out, b...)
 
 	if p.FPort == nil {
 		if len(p.FRMPayload) != 0 {
-			return nil, errors.New("lorawan: FPort must be set when FRMPayload is not empty")
+			return b[:start], errors.New("lorawan: FPort must be set when FRMPayload is not empty")
 		}
-		return out, nil
+		return b, nil
 	} else if len(p.FHDR.FOpts) != 0 && *p.FPort == 0 {
-		return nil, errors.New("lorawan: FPort must not be 0 when FOpts are set")
+		return b[:start], errors.New("lorawan: FPort must not be 0 when FOpts are set")
 	}
 
-	out = append(out, *p.FPort)
-
-	if b, err = p.marshalPayload(); err != nil {
-		return nil, err
+	b = append(b, *p.FPort)
+	if b, err = p.appendFRMPayload(b); err != nil {
+		return b[:start], err
 	}
-	out = append(out, b...)
-	return out, nil
+	return b, nil
 }
 
 // UnmarshalBinary decodes the object from binary form.
@@ -69,17 +73,17 @@ func (p *MACPayload) UnmarshalBinary(uplink bool, data []byte) error {
 
 	// check that there are enough bytes to decode a minimal FHDR
 	if dataLen < 7 {
-		return errors.New("lorawan: at least 7 bytes needed to decode FHDR")
+		return newDecodeError("MACPayload", ErrInvalidLength, "lorawan: at least 7 bytes needed to decode FHDR")
 	}
 
 	// unmarshal FCtrl so we know the FOptsLen
 	if err := p.FHDR.FCtrl.UnmarshalBinary(data[4:5]); err != nil {
-		return err
+		return withOffset(err, 4)
 	}
 
 	// check that there are at least as many bytes as FOptsLen claims
 	if dataLen < 7+int(p.FHDR.FCtrl.fOptsLen) {
-		return errors.New("lorawan: not enough bytes to decode FHDR")
+		return newDecodeError("MACPayload", ErrInvalidLength, "lorawan: not enough bytes to decode FHDR")
 	}
 
 	// decode the full FHDR (including optional FOpts)
@@ -96,7 +100,7 @@ func (p *MACPayload) UnmarshalBinary(uplink bool, data []byte) error {
 	// decode the rest of the payload (if present)
 	if dataLen > 7+int(p.FHDR.FCtrl.fOptsLen)+1 {
 		if p.FPort != nil && *p.FPort == 0 && p.FHDR.FCtrl.fOptsLen > 0 {
-			return errors.New("lorawan: FPort must not be 0 when FOpts are set")
+			return newDecodeError("MACPayload", ErrInvalidValue, "lorawan: FPort must not be 0 when FOpts are set")
 		}
 
 		// even when FPort = 0, we store the mac-commands within a DataPayload.
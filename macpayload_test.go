@@ -85,7 +85,8 @@ func TestMACPayload(t *testing.T) {
 			b := []byte{4, 3, 2, 1, 0, 0}
 			Convey("Then UnmarshalBinary returns an error", func() {
 				err := p.UnmarshalBinary(true, b)
-				So(err, ShouldResemble, errors.New("lorawan: at least 7 bytes needed to decode FHDR"))
+				So(err.Error(), ShouldEqual, "lorawan: at least 7 bytes needed to decode FHDR")
+				So(errors.Is(err, ErrInvalidLength), ShouldBeTrue)
 			})
 		})
 
@@ -93,7 +94,8 @@ func TestMACPayload(t *testing.T) {
 			b := []byte{4, 3, 2, 1, 3, 0, 0, 0, 0}
 			Convey("Then UnmarshalBinary returns an error", func() {
 				err := p.UnmarshalBinary(true, b)
-				So(err, ShouldResemble, errors.New("lorawan: not enough bytes to decode FHDR"))
+				So(err.Error(), ShouldEqual, "lorawan: not enough bytes to decode FHDR")
+				So(errors.Is(err, ErrInvalidLength), ShouldBeTrue)
 			})
 		})
 
@@ -113,7 +115,8 @@ func TestMACPayload(t *testing.T) {
 
 				// normally the mac commands are unmarshaled after decryption
 				_, err = decodeDataPayloadToMACCommands(true, p.FRMPayload)
-				So(err, ShouldResemble, errors.New("lorawan: not enough remaining bytes"))
+				So(err.Error(), ShouldEqual, "lorawan: not enough remaining bytes")
+				So(errors.Is(err, ErrInvalidLength), ShouldBeTrue)
 			})
 		})
 
@@ -0,0 +1,114 @@
+//go:build go1.18
+// +build go1.18
+
+package lorawan
+
+import (
+	"encoding"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// binaryRoundTrip decodes the given data into a new instance of the type of
+// v, encodes it again and checks that decoding the result returns the same
+// object. The same is checked for the text form.
+func binaryRoundTrip(t *testing.T, newFunc func() encoding.BinaryUnmarshaler, data []byte) {
+	v1 := newFunc()
+	if err := v1.UnmarshalBinary(data); err != nil {
+		return
+	}
+
+	// the decoder is more lenient than the encoder (e.g. RFU bits are not
+	// masked), values rejected by the encoder are not round-tripped
+	b, err := v1.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return
+	}
+
+	v2 := newFunc()
+	if err := v2.UnmarshalBinary(b); err != nil {
+		t.Fatalf("%T: unmarshal binary error: %s", v2, err)
+	}
+	if !reflect.DeepEqual(v1, v2) {
+		t.Fatalf("%T: binary round-trip mismatch: %v != %v", v1, v1, v2)
+	}
+
+	tm, ok := v1.(encoding.TextMarshaler)
+	if !ok {
+		return
+	}
+	text, err := tm.MarshalText()
+	if err != nil {
+		t.Fatalf("%T: marshal text error: %s", v1, err)
+	}
+
+	v3 := newFunc()
+	if err := v3.(encoding.TextUnmarshaler).UnmarshalText(text); err != nil {
+		t.Fatalf("%T: unmarshal text error: %s", v3, err)
+	}
+	if !reflect.DeepEqual(v1, v3) {
+		t.Fatalf("%T: text round-trip mismatch: %v != %v", v1, v1, v3)
+	}
+}
+
+func FuzzCoreTypesRoundTrip(f *testing.F) {
+	f.Add([]byte{0x40})
+	f.Add([]byte{0xff})
+	f.Add([]byte{0x01, 0x02, 0x03, 0x04})
+	f.Add([]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08})
+	f.Add([]byte{0x18, 0x4f, 0x84, 0xe8, 0x56, 0x84, 0xb8, 0x5e, 0x84, 0x88, 0x66, 0x84, 0x58, 0x6e, 0x84, 0x00})
+	f.Add([]byte{0xff, 0x00, 0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01})
+
+	types := []func() encoding.BinaryUnmarshaler{
+		func() encoding.BinaryUnmarshaler { return &MHDR{} },
+		func() encoding.BinaryUnmarshaler { return &FCtrl{} },
+		func() encoding.BinaryUnmarshaler { return &CFList{} },
+		func() encoding.BinaryUnmarshaler { return &MIC{} },
+		func() encoding.BinaryUnmarshaler { return &AES128Key{} },
+		func() encoding.BinaryUnmarshaler { return &EUI64{} },
+		func() encoding.BinaryUnmarshaler { return &DevAddr{} },
+		func() encoding.BinaryUnmarshaler { return &NetID{} },
+		func() encoding.BinaryUnmarshaler { return new(DevNonce) },
+		func() encoding.BinaryUnmarshaler { return new(JoinNonce) },
+		func() encoding.BinaryUnmarshaler { return &DLSettings{} },
+		func() encoding.BinaryUnmarshaler { return &ChMask{} },
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, newFunc := range types {
+			binaryRoundTrip(t, newFunc, data)
+		}
+	})
+}
+
+func FuzzMACCommandPayloadRoundTrip(f *testing.F) {
+	f.Add([]byte{0x00})
+	f.Add([]byte{0x1f})
+	f.Add([]byte{0xff})
+	f.Add([]byte{0xff, 0xff})
+	f.Add([]byte{0x01, 0x02, 0x03, 0x04, 0x05})
+	f.Add([]byte{0x51, 0x07, 0x00, 0x00, 0xff})
+
+	var payloads []func() encoding.BinaryUnmarshaler
+	for _, uplink := range []bool{false, true} {
+		var cids []int
+		for cid := range macPayloadRegistry[uplink] {
+			cids = append(cids, int(cid))
+		}
+		sort.Ints(cids)
+
+		for _, cid := range cids {
+			info := macPayloadRegistry[uplink][CID(cid)]
+			payloads = append(payloads, func() encoding.BinaryUnmarshaler {
+				return info.payload()
+			})
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, newFunc := range payloads {
+			binaryRoundTrip(t, newFunc, data)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package lorawan
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextMarshalerPreservesJSON(t *testing.T) {
+	assert := require.New(t)
+
+	mhdr := MHDR{MType: ConfirmedDataUp, Major: LoRaWANR1}
+	b, err := json.Marshal(mhdr)
+	assert.NoError(err)
+	assert.Equal(`{"mType":"ConfirmedDataUp","major":"LoRaWANR1"}`, string(b))
+
+	var mhdr2 MHDR
+	assert.NoError(json.Unmarshal([]byte(`{"mType":4,"major":0}`), &mhdr2))
+	assert.Equal(mhdr, mhdr2)
+
+	text, err := mhdr.MarshalText()
+	assert.NoError(err)
+	assert.Equal("80", string(text))
+
+	fCtrl := FCtrl{ADR: true, ACK: true}
+	b, err = json.Marshal(fCtrl)
+	assert.NoError(err)
+	assert.Equal(`{"adr":true,"adrAckReq":false,"ack":true,"fPending":false,"classB":false}`, string(b))
+
+	var fCtrl2 FCtrl
+	assert.NoError(json.Unmarshal(b, &fCtrl2))
+	assert.Equal(fCtrl, fCtrl2)
+
+	text, err = fCtrl.MarshalText()
+	assert.NoError(err)
+	assert.Equal("a0", string(text))
+
+	cfList := CFList{
+		CFListType: CFListChannel,
+		Payload:    &CFListChannelPayload{Channels: [5]uint32{867100000}},
+	}
+	b, err = json.Marshal(cfList)
+	assert.NoError(err)
+	assert.Equal(`{"payload":{"Channels":[867100000,0,0,0,0]},"cFListType":0}`, string(b))
+
+	var mic MIC
+	assert.NoError(mic.UnmarshalText([]byte("01020304")))
+	assert.Equal(MIC{1, 2, 3, 4}, mic)
+}
@@ -0,0 +1,45 @@
+package lorawan
+
+// MustEUI64 returns the EUI64 for the given HEX encoded string. It panics
+// on error and is intended for tests, fixtures and tools with hard-coded
+// values.
+func MustEUI64(s string) EUI64 {
+	var eui EUI64
+	if err := eui.UnmarshalText([]byte(s)); err != nil {
+		panic(err)
+	}
+	return eui
+}
+
+// MustDevAddr returns the DevAddr for the given HEX encoded string. It
+// panics on error and is intended for tests, fixtures and tools with
+// hard-coded values.
+func MustDevAddr(s string) DevAddr {
+	var devAddr DevAddr
+	if err := devAddr.UnmarshalText([]byte(s)); err != nil {
+		panic(err)
+	}
+	return devAddr
+}
+
+// MustKey returns the AES128Key for the given HEX encoded string. It panics
+// on error and is intended for tests, fixtures and tools with hard-coded
+// values.
+func MustKey(s string) AES128Key {
+	var key AES128Key
+	if err := key.UnmarshalText([]byte(s)); err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// MustNetID returns the NetID for the given HEX encoded string. It panics
+// on error and is intended for tests, fixtures and tools with hard-coded
+// values.
+func MustNetID(s string) NetID {
+	netID, err := ParseNetID(s)
+	if err != nil {
+		panic(err)
+	}
+	return netID
+}
@@ -0,0 +1,21 @@
+package lorawan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMust(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal(EUI64{1, 2, 3, 4, 5, 6, 7, 8}, MustEUI64("0102030405060708"))
+	assert.Equal(DevAddr{1, 2, 3, 4}, MustDevAddr("01020304"))
+	assert.Equal(AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8}, MustKey("01020304050607080102030405060708"))
+	assert.Equal(NetID{1, 2, 3}, MustNetID("010203"))
+
+	assert.Panics(func() { MustEUI64("0102") })
+	assert.Panics(func() { MustDevAddr("zz") })
+	assert.Panics(func() { MustKey("") })
+	assert.Panics(func() { MustNetID("01020304") })
+}
@@ -4,13 +4,22 @@ import (
 	"database/sql/driver"
 	"encoding/binary"
 	"encoding/hex"
-	"errors"
 	"fmt"
+	"io"
 )
 
 // NetID represents the NetID.
 type NetID [3]byte
 
+// ParseNetID parses the given HEX encoded NetID.
+func ParseNetID(s string) (NetID, error) {
+	var n NetID
+	if err := n.UnmarshalText([]byte(s)); err != nil {
+		return NetID{}, err
+	}
+	return n, nil
+}
+
 // Type returns the NetID type.
 func (n NetID) Type() int {
 	return int(n[0] >> 5)
@@ -30,6 +39,30 @@ func (n NetID) ID() []byte {
 	}
 }
 
+// DevAddrPrefix returns the DevAddr prefix owned by the NetID, consisting
+// of the type prefix and the NwkID (the LSB of the NetID ID). All DevAddrs
+// assigned by the NetID operator must match this prefix.
+func (n NetID) DevAddrPrefix() DevAddrPrefix {
+	var p DevAddrPrefix
+	p.Addr.SetAddrPrefix(n)
+
+	l := netIDTypeLengths[n.Type()]
+	p.Length = l.prefixLength + l.nwkIDBits
+
+	return p
+}
+
+// RandomDevAddr returns a random DevAddr, read from the given reader (e.g.
+// crypto/rand.Reader), within the DevAddr prefix of the NetID.
+func (n NetID) RandomDevAddr(rand io.Reader) (DevAddr, error) {
+	var devAddr DevAddr
+	if _, err := io.ReadFull(rand, devAddr[:]); err != nil {
+		return devAddr, fmt.Errorf("lorawan: read random bytes error: %s", err)
+	}
+	devAddr.SetAddrPrefix(n)
+	return devAddr, nil
+}
+
 func (n NetID) getID(bits int) []byte {
 	// convert NetID to uint32
 	b := make([]byte, 4)
@@ -67,7 +100,7 @@ func (n *NetID) UnmarshalText(text []byte) error {
 		return err
 	}
 	if len(b) != len(n) {
-		return fmt.Errorf("lorawan: exactly %d bytes are expected", len(n))
+		return newError(ErrInvalidLength, "lorawan: exactly %d bytes are expected", len(n))
 	}
 	copy(n[:], b)
 	return nil
@@ -88,7 +121,7 @@ func (n NetID) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary implements encoding.BinaryUnmarshaler.
 func (n *NetID) UnmarshalBinary(data []byte) error {
 	if len(data) != len(n) {
-		return fmt.Errorf("lorawan: %d bytes of data are expected", len(n))
+		return newDecodeError("NetID", ErrInvalidLength, "lorawan: %d bytes of data are expected", len(n))
 	}
 
 	for i, v := range data {
@@ -104,15 +137,8 @@ func (n NetID) Value() (driver.Value, error) {
 	return n[:], nil
 }
 
-// Scan implements sql.Scanner.
+// Scan implements sql.Scanner. Both the binary form (e.g. bytea column)
+// and the HEX encoded form (e.g. text column) are supported.
 func (n *NetID) Scan(src interface{}) error {
-	b, ok := src.([]byte)
-	if !ok {
-		return errors.New("lorawan: []byte type expected")
-	}
-	if len(b) != len(n) {
-		return fmt.Errorf("lorawan: []byte must have length %d", len(n))
-	}
-	copy(n[:], b)
-	return nil
+	return scanBytes(n[:], src)
 }
@@ -10,79 +10,93 @@ import (
 func TestNetID(t *testing.T) {
 	Convey("Given a set of tests", t, func() {
 		tests := []struct {
-			Name   string
-			NetID  NetID
-			Type   int
-			ID     []byte
-			Bytes  []byte
-			String string
+			Name          string
+			NetID         NetID
+			Type          int
+			ID            []byte
+			Bytes         []byte
+			String        string
+			DevAddrPrefix DevAddrPrefix
 		}{
 			{
-				Name:   "NetID type 0",
-				NetID:  NetID{0, 0, 109},
-				Type:   0,
-				ID:     []byte{45},
-				Bytes:  []byte{109, 0, 0},
-				String: "00006d",
+				Name:          "NetID type 0",
+				NetID:         NetID{0, 0, 109},
+				Type:          0,
+				ID:            []byte{45},
+				Bytes:         []byte{109, 0, 0},
+				String:        "00006d",
+				DevAddrPrefix: DevAddrPrefix{Addr: DevAddr{90, 0, 0, 0}, Length: 7},
 			},
 			{
-				Name:   "NetID type 1",
-				NetID:  NetID{32, 0, 109},
-				Type:   1,
-				ID:     []byte{45},
-				Bytes:  []byte{109, 0, 32},
-				String: "20006d",
+				Name:          "NetID type 1",
+				NetID:         NetID{32, 0, 109},
+				Type:          1,
+				ID:            []byte{45},
+				Bytes:         []byte{109, 0, 32},
+				String:        "20006d",
+				DevAddrPrefix: DevAddrPrefix{Addr: DevAddr{173, 0, 0, 0}, Length: 8},
 			},
 			{
-				Name:   "NetID type 2",
-				NetID:  NetID{64, 3, 109},
-				Type:   2,
-				ID:     []byte{1, 109},
-				Bytes:  []byte{109, 3, 64},
-				String: "40036d",
+				Name:          "NetID type 2",
+				NetID:         NetID{64, 3, 109},
+				Type:          2,
+				ID:            []byte{1, 109},
+				Bytes:         []byte{109, 3, 64},
+				String:        "40036d",
+				DevAddrPrefix: DevAddrPrefix{Addr: DevAddr{214, 208, 0, 0}, Length: 12},
 			},
 			{
-				Name:   "NetID type 3",
-				NetID:  NetID{118, 219, 109},
-				Type:   3,
-				ID:     []byte{22, 219, 109},
-				Bytes:  []byte{109, 219, 118},
-				String: "76db6d",
+				Name:          "NetID type 3",
+				NetID:         NetID{118, 219, 109},
+				Type:          3,
+				ID:            []byte{22, 219, 109},
+				Bytes:         []byte{109, 219, 118},
+				String:        "76db6d",
+				DevAddrPrefix: DevAddrPrefix{Addr: DevAddr{230, 218, 0, 0}, Length: 15},
 			},
 			{
-				Name:   "NetID type 4",
-				NetID:  NetID{150, 219, 109},
-				Type:   4,
-				ID:     []byte{22, 219, 109},
-				Bytes:  []byte{109, 219, 150},
-				String: "96db6d",
+				Name:          "NetID type 4",
+				NetID:         NetID{150, 219, 109},
+				Type:          4,
+				ID:            []byte{22, 219, 109},
+				Bytes:         []byte{109, 219, 150},
+				String:        "96db6d",
+				DevAddrPrefix: DevAddrPrefix{Addr: DevAddr{245, 182, 128, 0}, Length: 17},
 			},
 			{
-				Name:   "NetID type 5",
-				NetID:  NetID{182, 219, 109},
-				Type:   5,
-				ID:     []byte{22, 219, 109},
-				Bytes:  []byte{109, 219, 182},
-				String: "b6db6d",
+				Name:          "NetID type 5",
+				NetID:         NetID{182, 219, 109},
+				Type:          5,
+				ID:            []byte{22, 219, 109},
+				Bytes:         []byte{109, 219, 182},
+				String:        "b6db6d",
+				DevAddrPrefix: DevAddrPrefix{Addr: DevAddr{251, 109, 160, 0}, Length: 19},
 			},
 			{
-				Name:   "NetID type 6",
-				NetID:  NetID{214, 219, 109},
-				Type:   6,
-				ID:     []byte{22, 219, 109},
-				Bytes:  []byte{109, 219, 214},
-				String: "d6db6d",
+				Name:          "NetID type 6",
+				NetID:         NetID{214, 219, 109},
+				Type:          6,
+				ID:            []byte{22, 219, 109},
+				Bytes:         []byte{109, 219, 214},
+				String:        "d6db6d",
+				DevAddrPrefix: DevAddrPrefix{Addr: DevAddr{253, 109, 180, 0}, Length: 22},
 			},
 			{
-				Name:   "NetID type 7",
-				NetID:  NetID{246, 219, 109},
-				Type:   7,
-				ID:     []byte{22, 219, 109},
-				Bytes:  []byte{109, 219, 246},
-				String: "f6db6d",
+				Name:          "NetID type 7",
+				NetID:         NetID{246, 219, 109},
+				Type:          7,
+				ID:            []byte{22, 219, 109},
+				Bytes:         []byte{109, 219, 246},
+				String:        "f6db6d",
+				DevAddrPrefix: DevAddrPrefix{Addr: DevAddr{254, 109, 182, 128}, Length: 25},
 			},
 		}
 
+		Convey("ParseNetID returns an error on invalid input", func() {
+			_, err := ParseNetID("0102")
+			So(err, ShouldNotBeNil)
+		})
+
 		for i, test := range tests {
 			Convey(fmt.Sprintf("Testing: %s [%d]", test.Name, i), func() {
 				So(test.NetID.Type(), ShouldEqual, test.Type)
@@ -102,6 +116,14 @@ func TestNetID(t *testing.T) {
 
 				So(netID.UnmarshalText([]byte(test.String)), ShouldBeNil)
 				So(netID, ShouldEqual, test.NetID)
+
+				netID, err = ParseNetID(test.String)
+				So(err, ShouldBeNil)
+				So(netID, ShouldEqual, test.NetID)
+
+				prefix := test.NetID.DevAddrPrefix()
+				So(prefix, ShouldResemble, test.DevAddrPrefix)
+				So(prefix.Addr.IsNetID(test.NetID), ShouldBeTrue)
 			})
 		}
 	})
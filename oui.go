@@ -0,0 +1,107 @@
+package lorawan
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// OUI represents the IEEE Organizationally Unique Identifier (MA-L), which
+// is contained by the three MSB of an EUI64.
+type OUI [3]byte
+
+// String implements fmt.Stringer.
+func (o OUI) String() string {
+	return hex.EncodeToString(o[:])
+}
+
+// OUISource defines the interface for looking up the vendor (organization)
+// name of an OUI.
+type OUISource interface {
+	// LookupOUI returns the vendor name for the given OUI. It returns false
+	// when the OUI is unknown.
+	LookupOUI(oui OUI) (string, bool)
+}
+
+// OUIMap implements an in-memory OUISource.
+type OUIMap map[OUI]string
+
+// LookupOUI implements the OUISource interface.
+func (m OUIMap) LookupOUI(oui OUI) (string, bool) {
+	name, ok := m[oui]
+	return name, ok
+}
+
+// ReadOUICSV reads the OUI registry in the CSV format as published by the
+// IEEE (oui.csv), which contains the columns Registry, Assignment,
+// Organization Name and Organization Address. The header row and rows of
+// other registries than MA-L are skipped.
+func ReadOUICSV(r io.Reader) (OUIMap, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	out := make(OUIMap)
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("lorawan: read oui csv error: %s", err)
+		}
+
+		if len(record) < 3 || record[0] != "MA-L" {
+			continue
+		}
+
+		b, err := hex.DecodeString(record[1])
+		if err != nil || len(b) != len(OUI{}) {
+			return nil, fmt.Errorf("lorawan: invalid oui assignment: %s", record[1])
+		}
+
+		var oui OUI
+		copy(oui[:], b)
+		out[oui] = strings.TrimSpace(record[2])
+	}
+
+	return out, nil
+}
+
+var (
+	ouiSourceMu sync.RWMutex
+	ouiSource   OUISource
+)
+
+// SetOUISource sets the OUISource used by EUI64.Vendor. By default no
+// OUISource is set, in which case the vendor is always unknown.
+func SetOUISource(s OUISource) {
+	ouiSourceMu.Lock()
+	defer ouiSourceMu.Unlock()
+
+	ouiSource = s
+}
+
+// OUI returns the OUI of the EUI64.
+func (e EUI64) OUI() OUI {
+	var oui OUI
+	copy(oui[:], e[:3])
+	return oui
+}
+
+// Vendor returns the vendor name of the EUI64 using the OUISource set by
+// SetOUISource. It returns false when no OUISource is set or when the OUI is
+// unknown.
+func (e EUI64) Vendor() (string, bool) {
+	ouiSourceMu.RLock()
+	defer ouiSourceMu.RUnlock()
+
+	if ouiSource == nil {
+		return "", false
+	}
+
+	return ouiSource.LookupOUI(e.OUI())
+}
@@ -0,0 +1,41 @@
+package lorawan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEUI64Vendor(t *testing.T) {
+	assert := require.New(t)
+
+	csv := `Registry,Assignment,Organization Name,Organization Address
+MA-L,0016C0,Semtech,"200 Flynn Road Camarillo CA US 93012 "
+MA-L,70B3D5,IEEE Registration Authority,"445 Hoes Lane Piscataway NJ US 08554 "
+MA-M,70B3D57ED,Example,"Somewhere"
+`
+	m, err := ReadOUICSV(strings.NewReader(csv))
+	assert.NoError(err)
+	assert.Len(m, 2)
+
+	eui := EUI64{0x00, 0x16, 0xc0, 0x01, 0x02, 0x03, 0x04, 0x05}
+	assert.Equal(OUI{0x00, 0x16, 0xc0}, eui.OUI())
+	assert.Equal("0016c0", eui.OUI().String())
+
+	_, ok := eui.Vendor()
+	assert.False(ok)
+
+	SetOUISource(m)
+	defer SetOUISource(nil)
+
+	vendor, ok := eui.Vendor()
+	assert.True(ok)
+	assert.Equal("Semtech", vendor)
+
+	_, ok = EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}.Vendor()
+	assert.False(ok)
+
+	_, err = ReadOUICSV(strings.NewReader("MA-L,XYZ,Foo,Bar\n"))
+	assert.EqualError(err, "lorawan: invalid oui assignment: XYZ")
+}
@@ -2,10 +2,13 @@ package lorawan
 
 import (
 	"database/sql/driver"
+	"encoding"
 	"encoding/binary"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 )
 
 // JoinType defines the join-request type.
@@ -27,25 +30,46 @@ func (e EUI64) MarshalText() ([]byte, error) {
 	return []byte(e.String()), nil
 }
 
-// UnmarshalText implements encoding.TextUnmarshaler.
+// UnmarshalText implements encoding.TextUnmarshaler. Besides the canonical
+// form, an optional 0x prefix and colon or dash separators are accepted
+// (e.g. 01:02:03:04:05:06:07:08).
 func (e *EUI64) UnmarshalText(text []byte) error {
-	b, err := hex.DecodeString(string(text))
+	b, err := decodeHEXText(text)
 	if err != nil {
 		return err
 	}
 	if len(e) != len(b) {
-		return fmt.Errorf("lorawan: exactly %d bytes are expected", len(e))
+		return newError(ErrInvalidLength, "lorawan: exactly %d bytes are expected", len(e))
 	}
 	copy(e[:], b)
 	return nil
 }
 
+// Set implements flag.Value.
+func (e *EUI64) Set(s string) error {
+	return e.UnmarshalText([]byte(s))
+}
+
 // String implement fmt.Stringer.
 func (e EUI64) String() string {
 	return hex.EncodeToString(e[:])
 }
 
-// MarshalBinary implements encoding.BinaryMarshaler.
+// Reverse returns the EUI64 with reversed byte order. This can be used to
+// convert EUIs which are listed in little-endian (wire) byte order, e.g. by
+// some provisioning tools, to the canonical (big-endian) representation
+// and vice versa.
+func (e EUI64) Reverse() EUI64 {
+	var out EUI64
+	for i, v := range e {
+		out[len(e)-i-1] = v
+	}
+	return out
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The EUI64 is encoded
+// in little-endian byte order, as used in the LoRaWAN frames (e.g. the
+// DevEUI and JoinEUI of the join-request).
 func (e EUI64) MarshalBinary() ([]byte, error) {
 	out := make([]byte, len(e))
 	// little endian
@@ -55,10 +79,11 @@ func (e EUI64) MarshalBinary() ([]byte, error) {
 	return out, nil
 }
 
-// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The data must be
+// in little-endian byte order (see MarshalBinary).
 func (e *EUI64) UnmarshalBinary(data []byte) error {
 	if len(data) != len(e) {
-		return fmt.Errorf("lorawan: %d bytes of data are expected", len(e))
+		return newDecodeError("EUI64", ErrInvalidLength, "lorawan: %d bytes of data are expected", len(e))
 	}
 	for i, v := range data {
 		// little endian
@@ -67,22 +92,92 @@ func (e *EUI64) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
-// Scan implements sql.Scanner.
+// Scan implements sql.Scanner. Both the binary form (e.g. bytea column)
+// and the HEX encoded form (e.g. text column) are supported.
 func (e *EUI64) Scan(src interface{}) error {
-	b, ok := src.([]byte)
-	if !ok {
-		return errors.New("lorawan: []byte type expected")
+	return scanBytes(e[:], src)
+}
+
+// Value implements driver.Valuer.
+func (e EUI64) Value() (driver.Value, error) {
+	return e[:], nil
+}
+
+// decodeHEXText decodes the given HEX encoded text, after removing an
+// optional 0x prefix and colon or dash separators.
+func decodeHEXText(text []byte) ([]byte, error) {
+	s := string(text)
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		s = s[2:]
 	}
-	if len(b) != len(e) {
-		return fmt.Errorf("lorawan: []byte must have length %d", len(e))
+	s = strings.NewReplacer(":", "", "-", "").Replace(s)
+
+	return hex.DecodeString(s)
+}
+
+// marshalBinaryText returns the HEX encoded binary form of the given object.
+func marshalBinaryText(m encoding.BinaryMarshaler) ([]byte, error) {
+	b, err := m.MarshalBinary()
+	if err != nil {
+		return nil, err
 	}
-	copy(e[:], b)
+	return []byte(hex.EncodeToString(b)), nil
+}
+
+// unmarshalBinaryText decodes the given HEX encoded binary form into the
+// given object.
+func unmarshalBinaryText(u encoding.BinaryUnmarshaler, text []byte) error {
+	b, err := hex.DecodeString(string(text))
+	if err != nil {
+		return err
+	}
+	return u.UnmarshalBinary(b)
+}
+
+// scanBytes scans the given src into dst. The src must contain either
+// exactly len(dst) bytes or the HEX encoded representation of dst.
+func scanBytes(dst []byte, src interface{}) error {
+	var b []byte
+	switch v := src.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return errors.New("lorawan: []byte or string type expected")
+	}
+
+	if len(b) == 2*len(dst) {
+		out := make([]byte, len(dst))
+		if _, err := hex.Decode(out, b); err != nil {
+			return fmt.Errorf("lorawan: decode hex error: %w", err)
+		}
+		copy(dst, out)
+		return nil
+	}
+
+	if _, ok := src.(string); ok || len(b) != len(dst) {
+		return fmt.Errorf("lorawan: %d bytes or %d hex characters are expected", len(dst), 2*len(dst))
+	}
+	copy(dst, b)
 	return nil
 }
 
+// HEXValue wraps the given EUI64, DevAddr, NetID or AES128Key so that it
+// is stored in its HEX encoded form (e.g. text column), as the Value method
+// of these types returns the binary form (e.g. bytea column). Scan accepts
+// both forms.
+func HEXValue(v fmt.Stringer) driver.Valuer {
+	return hexValue{v: v}
+}
+
+type hexValue struct {
+	v fmt.Stringer
+}
+
 // Value implements driver.Valuer.
-func (e EUI64) Value() (driver.Value, error) {
-	return e[:], nil
+func (h hexValue) Value() (driver.Value, error) {
+	return h.v.String(), nil
 }
 
 // DevNonce represents the dev-nonce.
@@ -98,7 +193,7 @@ func (n DevNonce) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary implements encoding.BinaryUnmarshaler.
 func (n *DevNonce) UnmarshalBinary(data []byte) error {
 	if len(data) != 2 {
-		return errors.New("lorawan: 2 bytes are expected")
+		return newDecodeError("DevNonce", ErrInvalidLength, "lorawan: 2 bytes are expected")
 	}
 	*n = DevNonce(binary.LittleEndian.Uint16(data))
 	return nil
@@ -122,7 +217,7 @@ func (n JoinNonce) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary implements encoding.BinaryUnmarshaler.
 func (n *JoinNonce) UnmarshalBinary(data []byte) error {
 	if len(data) != 3 {
-		return errors.New("lorawan: 3 bytes are expected")
+		return newDecodeError("JoinNonce", ErrInvalidLength, "lorawan: 3 bytes are expected")
 	}
 
 	b := make([]byte, 4)
@@ -141,6 +236,22 @@ type Payload interface {
 	UnmarshalBinary(uplink bool, data []byte) error
 }
 
+// appendPayload appends the binary form of the given payload to b. When the
+// payload implements AppendBinary, this is used to avoid allocations.
+func appendPayload(b []byte, p Payload) ([]byte, error) {
+	if a, ok := p.(interface {
+		AppendBinary([]byte) ([]byte, error)
+	}); ok {
+		return a.AppendBinary(b)
+	}
+
+	pb, err := p.MarshalBinary()
+	if err != nil {
+		return b, err
+	}
+	return append(b, pb...), nil
+}
+
 // DataPayload represents a slice of bytes.
 type DataPayload struct {
 	Bytes []byte `json:"bytes"`
@@ -191,7 +302,7 @@ func (p JoinRequestPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (p *JoinRequestPayload) UnmarshalBinary(uplink bool, data []byte) error {
 	if len(data) != 18 {
-		return errors.New("lorawan: 18 bytes of data are expected")
+		return newDecodeError("JoinRequestPayload", ErrInvalidLength, "lorawan: 18 bytes of data are expected")
 	}
 	if err := p.JoinEUI.UnmarshalBinary(data[0:8]); err != nil {
 		return err
@@ -238,7 +349,7 @@ func (l CFList) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (l *CFList) UnmarshalBinary(data []byte) error {
 	if len(data) != 16 {
-		return errors.New("lorawan: 16 bytes of data are expected")
+		return newDecodeError("CFList", ErrInvalidLength, "lorawan: 16 bytes of data are expected")
 	}
 
 	l.CFListType = CFListType(data[15])
@@ -257,6 +368,24 @@ func (l *CFList) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler. The CFList is encoded as
+// HEX string of its binary form.
+func (l CFList) MarshalText() ([]byte, error) {
+	return marshalBinaryText(l)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (l *CFList) UnmarshalText(text []byte) error {
+	return unmarshalBinaryText(l, text)
+}
+
+// MarshalJSON encodes the CFList into JSON. This preserves the object
+// representation, rather than using the HEX encoded text form.
+func (l CFList) MarshalJSON() ([]byte, error) {
+	type cfListAlias CFList
+	return json.Marshal(cfListAlias(l))
+}
+
 // CFListChannelPayload holds a list of (up to 5) channel frequencies.
 // Each frequency is in Hz and must be a multiple of 100.
 type CFListChannelPayload struct {
@@ -288,11 +417,11 @@ func (p CFListChannelPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (p *CFListChannelPayload) UnmarshalBinary(uplink bool, data []byte) error {
 	if len(data) > 15 {
-		return errors.New("lorawan: max length is 15 bytes")
+		return newDecodeError("CFListChannelPayload", ErrInvalidLength, "lorawan: max length is 15 bytes")
 	}
 
 	if len(data)%3 != 0 {
-		return errors.New("lorawan: length must be a multiple of 3")
+		return newDecodeError("CFListChannelPayload", ErrInvalidLength, "lorawan: length must be a multiple of 3")
 	}
 
 	for i := 0; i < len(data)/3; i++ {
@@ -332,7 +461,7 @@ func (p CFListChannelMaskPayload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (p *CFListChannelMaskPayload) UnmarshalBinary(uplink bool, data []byte) error {
 	if len(data) > 15 {
-		return errors.New("lorawan: max 15 bytes are expected")
+		return newDecodeError("CFListChannelMaskPayload", ErrInvalidLength, "lorawan: max 15 bytes are expected")
 	}
 
 	// make data a multiple of 2
@@ -419,7 +548,7 @@ func (p JoinAcceptPayload) MarshalBinary() ([]byte, error) {
 func (p *JoinAcceptPayload) UnmarshalBinary(uplink bool, data []byte) error {
 	l := len(data)
 	if l != 12 && l != 28 {
-		return errors.New("lorawan: 12 or 28 bytes of data are expected (28 bytes if CFList is present)")
+		return newDecodeError("JoinAcceptPayload", ErrInvalidLength, "lorawan: 12 or 28 bytes of data are expected (28 bytes if CFList is present)")
 	}
 
 	if err := p.JoinNonce.UnmarshalBinary(data[0:3]); err != nil {
@@ -427,21 +556,21 @@ func (p *JoinAcceptPayload) UnmarshalBinary(uplink bool, data []byte) error {
 	}
 
 	if err := p.HomeNetID.UnmarshalBinary(data[3:6]); err != nil {
-		return err
+		return withOffset(err, 3)
 	}
 
 	if err := p.DevAddr.UnmarshalBinary(data[6:10]); err != nil {
-		return err
+		return withOffset(err, 6)
 	}
 	if err := p.DLSettings.UnmarshalBinary(data[10:11]); err != nil {
-		return err
+		return withOffset(err, 10)
 	}
 	p.RXDelay = uint8(data[11])
 
 	if l == 28 {
 		p.CFList = &CFList{}
 		if err := p.CFList.UnmarshalBinary(data[12:]); err != nil {
-			return err
+			return withOffset(err, 12)
 		}
 	}
 
@@ -488,7 +617,7 @@ func (p RejoinRequestType02Payload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (p *RejoinRequestType02Payload) UnmarshalBinary(uplink bool, data []byte) error {
 	if len(data) != 14 {
-		return errors.New("lorawan: 14 bytes of data are expected")
+		return newDecodeError("RejoinRequestType02Payload", ErrInvalidLength, "lorawan: 14 bytes of data are expected")
 	}
 
 	p.RejoinType = JoinType(data[0])
@@ -546,7 +675,7 @@ func (p RejoinRequestType1Payload) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the object from binary form.
 func (p *RejoinRequestType1Payload) UnmarshalBinary(uplink bool, data []byte) error {
 	if len(data) != 19 {
-		return errors.New("lorawan: 19 bytes of data are expected")
+		return newDecodeError("RejoinRequestType1Payload", ErrInvalidLength, "lorawan: 19 bytes of data are expected")
 	}
 
 	p.RejoinType = JoinType(data[0])
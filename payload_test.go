@@ -3,10 +3,15 @@ package lorawan
 import (
 	"database/sql/driver"
 	"errors"
+	"flag"
 	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEUI64(t *testing.T) {
@@ -49,6 +54,180 @@ func TestEUI64(t *testing.T) {
 	})
 }
 
+func TestEUI64Reverse(t *testing.T) {
+	assert := require.New(t)
+
+	eui := EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	assert.Equal(EUI64{8, 7, 6, 5, 4, 3, 2, 1}, eui.Reverse())
+	assert.Equal(eui, eui.Reverse().Reverse())
+
+	// the reversed EUI64 equals the wire (little-endian) encoding
+	b, err := eui.MarshalBinary()
+	assert.NoError(err)
+	reversed := eui.Reverse()
+	assert.Equal(reversed[:], b)
+}
+
+func TestUnmarshalTextNotations(t *testing.T) {
+	tests := []struct {
+		Text  string
+		Error bool
+	}{
+		{Text: "0102030405060a0b"},
+		{Text: "0102030405060A0B"},
+		{Text: "0x0102030405060a0b"},
+		{Text: "0X0102030405060A0B"},
+		{Text: "01:02:03:04:05:06:0a:0b"},
+		{Text: "01-02-03-04-05-06-0A-0B"},
+		{Text: "0x01:02:03:04:05:06:0a:0b"},
+		{Text: "01 02 03 04 05 06 0a 0b", Error: true},
+		{Text: "0102030405060a", Error: true},
+		{Text: "x0102030405060a0b", Error: true},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Text, func(t *testing.T) {
+			assert := require.New(t)
+
+			var eui EUI64
+			err := eui.UnmarshalText([]byte(tst.Text))
+			if tst.Error {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(EUI64{1, 2, 3, 4, 5, 6, 10, 11}, eui)
+			assert.Equal("0102030405060a0b", eui.String())
+
+			var key AES128Key
+			assert.NoError(key.UnmarshalText([]byte(tst.Text + strings.TrimPrefix(strings.TrimPrefix(tst.Text, "0x"), "0X"))))
+			assert.Equal(AES128Key{1, 2, 3, 4, 5, 6, 10, 11, 1, 2, 3, 4, 5, 6, 10, 11}, key)
+			assert.Equal("0102030405060a0b0102030405060a0b", key.String())
+		})
+	}
+}
+
+func TestScanAndSet(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Src      interface{}
+		Expected EUI64
+		Error    bool
+	}{
+		{
+			Name:     "bytes",
+			Src:      []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			Expected: EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		},
+		{
+			Name:     "hex bytes",
+			Src:      []byte("0102030405060708"),
+			Expected: EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		},
+		{
+			Name:     "hex string",
+			Src:      "0102030405060708",
+			Expected: EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		},
+		{
+			Name:  "invalid hex",
+			Src:   "010203040506070z",
+			Error: true,
+		},
+		{
+			Name:  "invalid length",
+			Src:   []byte{1, 2, 3},
+			Error: true,
+		},
+		{
+			Name:  "raw string",
+			Src:   "01234567",
+			Error: true,
+		},
+		{
+			Name:  "invalid type",
+			Src:   int64(10),
+			Error: true,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var eui EUI64
+			err := eui.Scan(tst.Src)
+			if tst.Error {
+				assert.Error(err)
+				assert.Equal(EUI64{}, eui)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tst.Expected, eui)
+		})
+	}
+
+	t.Run("DevAddr and AES128Key", func(t *testing.T) {
+		assert := require.New(t)
+
+		var devAddr DevAddr
+		assert.NoError(devAddr.Scan("01020304"))
+		assert.Equal(DevAddr{1, 2, 3, 4}, devAddr)
+
+		var key AES128Key
+		assert.NoError(key.Scan([]byte{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8}))
+		assert.Equal(AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8}, key)
+	})
+
+	t.Run("HEXValue", func(t *testing.T) {
+		assert := require.New(t)
+
+		values := []struct {
+			Valuer  driver.Valuer
+			Scanner interface{ Scan(interface{}) error }
+		}{
+			{EUI64{1, 2, 3, 4, 5, 6, 7, 8}, &EUI64{}},
+			{DevAddr{1, 2, 3, 4}, &DevAddr{}},
+			{NetID{1, 2, 3}, &NetID{}},
+			{AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8}, &AES128Key{}},
+		}
+
+		for _, v := range values {
+			val, err := HEXValue(v.Valuer.(fmt.Stringer)).Value()
+			assert.NoError(err)
+			assert.Equal(v.Valuer.(fmt.Stringer).String(), val)
+
+			assert.NoError(v.Scanner.Scan(val))
+			assert.Equal(v.Valuer, reflect.ValueOf(v.Scanner).Elem().Interface())
+		}
+	})
+
+	t.Run("flag.Value", func(t *testing.T) {
+		assert := require.New(t)
+
+		var devEUI EUI64
+		var devAddr DevAddr
+		var appKey AES128Key
+
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.Var(&devEUI, "dev-eui", "DevEUI")
+		fs.Var(&devAddr, "dev-addr", "DevAddr")
+		fs.Var(&appKey, "app-key", "AppKey")
+
+		assert.NoError(fs.Parse([]string{
+			"-dev-eui", "0102030405060708",
+			"-dev-addr", "01020304",
+			"-app-key", "01020304050607080102030405060708",
+		}))
+		assert.Equal(EUI64{1, 2, 3, 4, 5, 6, 7, 8}, devEUI)
+		assert.Equal(DevAddr{1, 2, 3, 4}, devAddr)
+		assert.Equal(AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8}, appKey)
+
+		fs.SetOutput(ioutil.Discard)
+		assert.Error(fs.Parse([]string{"-dev-addr", "0102"}))
+	})
+}
+
 func TestDevNonce(t *testing.T) {
 	Convey("Given an empty DevNonce", t, func() {
 		var nonce DevNonce
@@ -145,7 +324,8 @@ func TestJoinRequestPayload(t *testing.T) {
 			b := make([]byte, 17)
 			Convey("Then UnmarshalBinary returns an error", func() {
 				err := p.UnmarshalBinary(false, b)
-				So(err, ShouldResemble, errors.New("lorawan: 18 bytes of data are expected"))
+				So(err.Error(), ShouldEqual, "lorawan: 18 bytes of data are expected")
+				So(errors.Is(err, ErrInvalidLength), ShouldBeTrue)
 			})
 		})
 
@@ -319,7 +499,8 @@ func TestJoinAcceptPayload(t *testing.T) {
 			b := make([]byte, 11)
 			Convey("Then UnmarshalBinary returns an error", func() {
 				err := p.UnmarshalBinary(false, b)
-				So(err, ShouldResemble, errors.New("lorawan: 12 or 28 bytes of data are expected (28 bytes if CFList is present)"))
+				So(err.Error(), ShouldEqual, "lorawan: 12 or 28 bytes of data are expected (28 bytes if CFList is present)")
+				So(errors.Is(err, ErrInvalidLength), ShouldBeTrue)
 			})
 		})
 
@@ -73,30 +73,29 @@ func (k AES128Key) MarshalText() ([]byte, error) {
 	return []byte(k.String()), nil
 }
 
-// UnmarshalText implements encoding.TextUnmarshaler.
+// UnmarshalText implements encoding.TextUnmarshaler. Besides the canonical
+// form, an optional 0x prefix and colon or dash separators are accepted.
 func (k *AES128Key) UnmarshalText(text []byte) error {
-	b, err := hex.DecodeString(string(text))
+	b, err := decodeHEXText(text)
 	if err != nil {
 		return err
 	}
 	if len(b) != len(k) {
-		return fmt.Errorf("lorawan: exactly %d bytes are expected", len(k))
+		return newError(ErrInvalidLength, "lorawan: exactly %d bytes are expected", len(k))
 	}
 	copy(k[:], b)
 	return nil
 }
 
-// Scan implements sql.Scanner.
+// Set implements flag.Value.
+func (k *AES128Key) Set(s string) error {
+	return k.UnmarshalText([]byte(s))
+}
+
+// Scan implements sql.Scanner. Both the binary form (e.g. bytea column)
+// and the HEX encoded form (e.g. text column) are supported.
 func (k *AES128Key) Scan(src interface{}) error {
-	b, ok := src.([]byte)
-	if !ok {
-		return errors.New("lorawan: []byte type expected")
-	}
-	if len(b) != len(k) {
-		return fmt.Errorf("lorawan []byte must have length %d", len(k))
-	}
-	copy(k[:], b)
-	return nil
+	return scanBytes(k[:], src)
 }
 
 // Value implements driver.Valuer.
@@ -117,7 +116,7 @@ func (k AES128Key) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary decodes the key from a slice of bytes.
 func (k *AES128Key) UnmarshalBinary(data []byte) error {
 	if len(data) != len(k) {
-		return fmt.Errorf("lorawan: %d bytes of data are expected", len(k))
+		return newDecodeError("AES128Key", ErrInvalidLength, "lorawan: %d bytes of data are expected", len(k))
 	}
 
 	for i, v := range data {
@@ -141,6 +140,25 @@ func (m MIC) MarshalText() ([]byte, error) {
 	return []byte(m.String()), nil
 }
 
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *MIC) UnmarshalText(text []byte) error {
+	return unmarshalBinaryText(m, text)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (m MIC) MarshalBinary() ([]byte, error) {
+	return m[:], nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (m *MIC) UnmarshalBinary(data []byte) error {
+	if len(data) != len(m) {
+		return newDecodeError("MIC", ErrInvalidLength, "lorawan: %d bytes of data are expected", len(m))
+	}
+	copy(m[:], data)
+	return nil
+}
+
 // MHDR represents the MAC header.
 type MHDR struct {
 	MType MType `json:"mType"`
@@ -149,19 +167,47 @@ type MHDR struct {
 
 // MarshalBinary marshals the object in binary form.
 func (h MHDR) MarshalBinary() ([]byte, error) {
-	return []byte{(byte(h.MType) << 5) | (byte(h.Major) & 0x03)}, nil
+	return []byte{h.marshalByte()}, nil
+}
+
+func (h MHDR) marshalByte() byte {
+	return (byte(h.MType) << 5) | (byte(h.Major) & 0x03)
 }
 
 // UnmarshalBinary decodes the object from binary form.
 func (h *MHDR) UnmarshalBinary(data []byte) error {
 	if len(data) != 1 {
-		return errors.New("lorawan: 1 byte of data is expected")
+		return newDecodeError("MHDR", ErrInvalidLength, "lorawan: 1 byte of data is expected")
 	}
 	h.MType = MType(data[0] >> 5)
 	h.Major = Major(data[0] & 0x03)
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler. The MHDR is encoded as HEX
+// string of its binary form.
+func (h MHDR) MarshalText() ([]byte, error) {
+	return marshalBinaryText(h)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (h *MHDR) UnmarshalText(text []byte) error {
+	return unmarshalBinaryText(h, text)
+}
+
+// MarshalJSON encodes the MHDR into JSON. This preserves the object
+// representation, rather than using the HEX encoded text form.
+func (h MHDR) MarshalJSON() ([]byte, error) {
+	type mhdrAlias MHDR
+	return json.Marshal(mhdrAlias(h))
+}
+
+// UnmarshalJSON decodes the MHDR from its JSON object representation.
+func (h *MHDR) UnmarshalJSON(data []byte) error {
+	type mhdrAlias MHDR
+	return json.Unmarshal(data, (*mhdrAlias)(h))
+}
+
 // PHYPayload represents the physical payload.
 type PHYPayload struct {
 	MHDR       MHDR    `json:"mhdr"`
@@ -173,7 +219,7 @@ type PHYPayload struct {
 // The confirmed frame-counter, TX data-rate TX channel index and SNwkSIntKey
 // are only required for LoRaWAN 1.1 and can be left blank otherwise.
 func (p *PHYPayload) SetUplinkDataMIC(macVersion MACVersion, confFCnt uint32, txDR, txCh uint8, fNwkSIntKey, sNwkSIntKey AES128Key) error {
-	mic, err := p.calculateUplinkDataMIC(macVersion, confFCnt, txDR, txCh, fNwkSIntKey, sNwkSIntKey)
+	mic, err := p.calculateUplinkDataMIC(nil, macVersion, confFCnt, txDR, txCh, fNwkSIntKey, sNwkSIntKey)
 	if err != nil {
 		return err
 	}
@@ -188,13 +234,23 @@ func (p *PHYPayload) SetUplinkDataMIC(macVersion MACVersion, confFCnt uint32, tx
 // The confirmed frame-counter, TX data-rate TX channel index and SNwkSIntKey
 // are only required for LoRaWAN 1.1 and can be left blank otherwise.
 func (p PHYPayload) ValidateUplinkDataMIC(macVersion MACVersion, confFCnt uint32, txDR, txCh uint8, fNwkSIntKey, sNwkSIntKey AES128Key) (bool, error) {
-	mic, err := p.calculateUplinkDataMIC(macVersion, confFCnt, txDR, txCh, fNwkSIntKey, sNwkSIntKey)
+	mic, err := p.calculateUplinkDataMIC(nil, macVersion, confFCnt, txDR, txCh, fNwkSIntKey, sNwkSIntKey)
 	if err != nil {
 		return false, err
 	}
 	return p.MIC == mic, nil
 }
 
+// VerifyUplinkDataMIC is similar to ValidateUplinkDataMIC, but returns a
+// *MICError when the MIC does not match.
+func (p PHYPayload) VerifyUplinkDataMIC(macVersion MACVersion, confFCnt uint32, txDR, txCh uint8, fNwkSIntKey, sNwkSIntKey AES128Key) error {
+	mic, err := p.calculateUplinkDataMIC(nil, macVersion, confFCnt, txDR, txCh, fNwkSIntKey, sNwkSIntKey)
+	if err != nil {
+		return err
+	}
+	return checkMIC(mic, p.MIC)
+}
+
 // ValidateUplinkDataMICF validates the cmacF part of the uplink data MIC (LoRaWAN 1.1 only).
 // In order to validate the MIC, the FCnt value must first be set to the
 // full 32 bit frame-counter value, as only the 16 least-significant bits
@@ -202,7 +258,7 @@ func (p PHYPayload) ValidateUplinkDataMIC(macVersion MACVersion, confFCnt uint32
 func (p PHYPayload) ValidateUplinkDataMICF(fNwkSIntKey AES128Key) (bool, error) {
 	// We are only interested in mic[2:] (cmacF bytes), therefore there is no
 	// need to pass the correct confFCnt, txDR, txCh and sNwkSIntKey parameters.
-	mic, err := p.calculateUplinkDataMIC(LoRaWAN1_1, 0, 0, 0, fNwkSIntKey, fNwkSIntKey)
+	mic, err := p.calculateUplinkDataMIC(nil, LoRaWAN1_1, 0, 0, 0, fNwkSIntKey, fNwkSIntKey)
 	if err != nil {
 		return false, err
 	}
@@ -214,7 +270,7 @@ func (p PHYPayload) ValidateUplinkDataMICF(fNwkSIntKey AES128Key) (bool, error)
 // The confirmed frame-counter and is only required for LoRaWAN 1.1 and can be
 // left blank otherwise.
 func (p *PHYPayload) SetDownlinkDataMIC(macVersion MACVersion, confFCnt uint32, sNwkSIntKey AES128Key) error {
-	mic, err := p.calculateDownlinkDataMIC(macVersion, confFCnt, sNwkSIntKey)
+	mic, err := p.calculateDownlinkDataMIC(nil, macVersion, confFCnt, sNwkSIntKey)
 	if err != nil {
 		return err
 	}
@@ -229,13 +285,23 @@ func (p *PHYPayload) SetDownlinkDataMIC(macVersion MACVersion, confFCnt uint32,
 // The confirmed frame-counter and is only required for LoRaWAN 1.1 and can be
 // left blank otherwise.
 func (p PHYPayload) ValidateDownlinkDataMIC(macVersion MACVersion, confFCnt uint32, sNwkSIntKey AES128Key) (bool, error) {
-	mic, err := p.calculateDownlinkDataMIC(macVersion, confFCnt, sNwkSIntKey)
+	mic, err := p.calculateDownlinkDataMIC(nil, macVersion, confFCnt, sNwkSIntKey)
 	if err != nil {
 		return false, err
 	}
 	return p.MIC == mic, nil
 }
 
+// VerifyDownlinkDataMIC is similar to ValidateDownlinkDataMIC, but returns a
+// *MICError when the MIC does not match.
+func (p PHYPayload) VerifyDownlinkDataMIC(macVersion MACVersion, confFCnt uint32, sNwkSIntKey AES128Key) error {
+	mic, err := p.calculateDownlinkDataMIC(nil, macVersion, confFCnt, sNwkSIntKey)
+	if err != nil {
+		return err
+	}
+	return checkMIC(mic, p.MIC)
+}
+
 // SetUplinkJoinMIC calculates and sets the MIC field for uplink join requests.
 func (p *PHYPayload) SetUplinkJoinMIC(key AES128Key) error {
 	mic, err := p.calculateUplinkJoinMIC(key)
@@ -255,6 +321,63 @@ func (p PHYPayload) ValidateUplinkJoinMIC(key AES128Key) (bool, error) {
 	return p.MIC == mic, nil
 }
 
+// VerifyUplinkJoinMIC is similar to ValidateUplinkJoinMIC, but returns a
+// *MICError when the MIC does not match.
+func (p PHYPayload) VerifyUplinkJoinMIC(key AES128Key) error {
+	mic, err := p.calculateUplinkJoinMIC(key)
+	if err != nil {
+		return err
+	}
+	return checkMIC(mic, p.MIC)
+}
+
+// SetRejoinRequestMIC calculates and sets the MIC field for rejoin-requests.
+// For rejoin-request type 0 and 2 the MIC is calculated using the
+// SNwkSIntKey, for type 1 using the JSIntKey. The key that is not used by
+// the rejoin-type can be left blank.
+func (p *PHYPayload) SetRejoinRequestMIC(sNwkSIntKey, jsIntKey AES128Key) error {
+	key, err := p.getRejoinRequestMICKey(sNwkSIntKey, jsIntKey)
+	if err != nil {
+		return err
+	}
+	mic, err := p.calculateUplinkJoinMIC(key)
+	if err != nil {
+		return err
+	}
+	p.MIC = mic
+	return nil
+}
+
+// ValidateRejoinRequestMIC validates the MIC of a rejoin-request.
+// For rejoin-request type 0 and 2 the MIC is validated using the
+// SNwkSIntKey, for type 1 using the JSIntKey. The key that is not used by
+// the rejoin-type can be left blank.
+func (p PHYPayload) ValidateRejoinRequestMIC(sNwkSIntKey, jsIntKey AES128Key) (bool, error) {
+	key, err := p.getRejoinRequestMICKey(sNwkSIntKey, jsIntKey)
+	if err != nil {
+		return false, err
+	}
+	mic, err := p.calculateUplinkJoinMIC(key)
+	if err != nil {
+		return false, err
+	}
+	return p.MIC == mic, nil
+}
+
+// VerifyRejoinRequestMIC is similar to ValidateRejoinRequestMIC, but returns
+// a *MICError when the MIC does not match.
+func (p PHYPayload) VerifyRejoinRequestMIC(sNwkSIntKey, jsIntKey AES128Key) error {
+	key, err := p.getRejoinRequestMICKey(sNwkSIntKey, jsIntKey)
+	if err != nil {
+		return err
+	}
+	mic, err := p.calculateUplinkJoinMIC(key)
+	if err != nil {
+		return err
+	}
+	return checkMIC(mic, p.MIC)
+}
+
 // SetDownlinkJoinMIC calculates and sets the MIC field for downlink join requests.
 func (p *PHYPayload) SetDownlinkJoinMIC(joinReqType JoinType, joinEUI EUI64, devNonce DevNonce, key AES128Key) error {
 	mic, err := p.calculateDownlinkJoinMIC(joinReqType, joinEUI, devNonce, key)
@@ -275,6 +398,16 @@ func (p PHYPayload) ValidateDownlinkJoinMIC(joinReqType JoinType, joinEUI EUI64,
 	return p.MIC == mic, nil
 }
 
+// VerifyDownlinkJoinMIC is similar to ValidateDownlinkJoinMIC, but returns a
+// *MICError when the MIC does not match.
+func (p PHYPayload) VerifyDownlinkJoinMIC(joinReqType JoinType, joinEUI EUI64, devNonce DevNonce, key AES128Key) error {
+	mic, err := p.calculateDownlinkJoinMIC(joinReqType, joinEUI, devNonce, key)
+	if err != nil {
+		return err
+	}
+	return checkMIC(mic, p.MIC)
+}
+
 // EncryptJoinAcceptPayload encrypts the join-accept payload with the given
 // key. Note that encrypted must be performed after calling SetMIC
 // (since the MIC is part of the encrypted payload).
@@ -283,7 +416,7 @@ func (p PHYPayload) ValidateDownlinkJoinMIC(joinReqType JoinType, joinEUI EUI64,
 //       for rejoin-request 0, 1, 2 response, use JSEncKey
 func (p *PHYPayload) EncryptJoinAcceptPayload(key AES128Key) error {
 	if _, ok := p.MACPayload.(*JoinAcceptPayload); !ok {
-		return errors.New("lorawan: MACPayload value must be of type *JoinAcceptPayload")
+		return newError(ErrInvalidPayloadType, "lorawan: MACPayload value must be of type *JoinAcceptPayload")
 	}
 
 	pt, err := p.MACPayload.MarshalBinary()
@@ -296,7 +429,7 @@ func (p *PHYPayload) EncryptJoinAcceptPayload(key AES128Key) error {
 
 	pt = append(pt, p.MIC[0:4]...)
 	if len(pt)%16 != 0 {
-		return errors.New("lorawan: plaintext must be a multiple of 16 bytes")
+		return newError(ErrInvalidLength, "lorawan: plaintext must be a multiple of 16 bytes")
 	}
 
 	block, err := aes.NewCipher(key[:])
@@ -324,14 +457,14 @@ func (p *PHYPayload) EncryptJoinAcceptPayload(key AES128Key) error {
 func (p *PHYPayload) DecryptJoinAcceptPayload(key AES128Key) error {
 	dp, ok := p.MACPayload.(*DataPayload)
 	if !ok {
-		return errors.New("lorawan: MACPayload must be of type *DataPayload")
+		return newError(ErrInvalidPayloadType, "lorawan: MACPayload must be of type *DataPayload")
 	}
 
 	// append MIC to the ciphertext since it is encrypted too
 	ct := append(dp.Bytes, p.MIC[:]...)
 
 	if len(ct)%16 != 0 {
-		return errors.New("lorawan: plaintext must be a multiple of 16 bytes")
+		return newError(ErrInvalidLength, "lorawan: plaintext must be a multiple of 16 bytes")
 	}
 
 	block, err := aes.NewCipher(key[:])
@@ -356,7 +489,7 @@ func (p *PHYPayload) DecryptJoinAcceptPayload(key AES128Key) error {
 func (p *PHYPayload) EncryptFOpts(nwkSEncKey AES128Key) error {
 	macPL, ok := p.MACPayload.(*MACPayload)
 	if !ok {
-		return errors.New("lorawan: MACPayload must be of type *MACPayload")
+		return newError(ErrInvalidPayloadType, "lorawan: MACPayload must be of type *MACPayload")
 	}
 
 	// nothing to encrypt
@@ -405,7 +538,7 @@ func (p *PHYPayload) DecryptFOpts(nwkSEncKey AES128Key) error {
 func (p *PHYPayload) EncryptFRMPayload(key AES128Key) error {
 	macPL, ok := p.MACPayload.(*MACPayload)
 	if !ok {
-		return errors.New("lorawan: MACPayload must be of type *MACPayload")
+		return newError(ErrInvalidPayloadType, "lorawan: MACPayload must be of type *MACPayload")
 	}
 
 	// nothing to encrypt
@@ -437,7 +570,7 @@ func (p *PHYPayload) DecryptFRMPayload(key AES128Key) error {
 
 	macPL, ok := p.MACPayload.(*MACPayload)
 	if !ok {
-		return errors.New("lorawan: MACPayload must be of type *MACPayload")
+		return newError(ErrInvalidPayloadType, "lorawan: MACPayload must be of type *MACPayload")
 	}
 
 	// the FRMPayload contains MAC commands, which we need to unmarshal
@@ -455,7 +588,7 @@ func (p *PHYPayload) DecryptFRMPayload(key AES128Key) error {
 func (p *PHYPayload) DecodeFRMPayloadToMACCommands() error {
 	macPL, ok := p.MACPayload.(*MACPayload)
 	if !ok {
-		return errors.New("lorawan: MACPayload must be of type *MACPayload")
+		return newError(ErrInvalidPayloadType, "lorawan: MACPayload must be of type *MACPayload")
 	}
 
 	var err error
@@ -468,7 +601,7 @@ func (p *PHYPayload) DecodeFRMPayloadToMACCommands() error {
 func (p *PHYPayload) DecodeFOptsToMACCommands() error {
 	macPL, ok := p.MACPayload.(*MACPayload)
 	if !ok {
-		return errors.New("lorawan: MACPayload must be of type *MACPayload")
+		return newError(ErrInvalidPayloadType, "lorawan: MACPayload must be of type *MACPayload")
 	}
 
 	if len(macPL.FHDR.FOpts) == 0 {
@@ -482,31 +615,36 @@ func (p *PHYPayload) DecodeFOptsToMACCommands() error {
 
 // MarshalBinary marshals the object in binary form.
 func (p PHYPayload) MarshalBinary() ([]byte, error) {
+	b, err := p.AppendBinary(nil)
+	if err != nil {
+		return []byte{}, err
+	}
+	return b, nil
+}
+
+// AppendBinary appends the binary form of the PHYPayload to b and returns
+// the extended slice. This can be used with a (pooled) buffer to avoid the
+// allocations of MarshalBinary, e.g. in high-throughput servers.
+func (p PHYPayload) AppendBinary(b []byte) ([]byte, error) {
 	if p.MACPayload == nil {
-		return []byte{}, errors.New("lorawan: MACPayload should not be nil")
+		return b, newError(ErrInvalidPayloadType, "lorawan: MACPayload should not be nil")
 	}
 
-	var out []byte
-	var b []byte
-	var err error
+	start := len(b)
+	b = append(b, p.MHDR.marshalByte())
 
-	if b, err = p.MHDR.MarshalBinary(); err != nil {
-		return []byte{}, err
+	b, err := appendPayload(b, p.MACPayload)
+	if err != nil {
+		return b[:start], err
 	}
-	out = append(out, b...)
 
-	if b, err = p.MACPayload.MarshalBinary(); err != nil {
-		return []byte{}, err
-	}
-	out = append(out, b...)
-	out = append(out, p.MIC[0:len(p.MIC)]...)
-	return out, nil
+	return append(b, p.MIC[:]...), nil
 }
 
 // UnmarshalBinary decodes the object from binary form.
 func (p *PHYPayload) UnmarshalBinary(data []byte) error {
 	if len(data) < 5 {
-		return errors.New("lorawan: at least 5 bytes needed to decode PHYPayload")
+		return newDecodeError("PHYPayload", ErrInvalidLength, "lorawan: at least 5 bytes needed to decode PHYPayload")
 	}
 
 	// MHDR
@@ -527,7 +665,7 @@ func (p *PHYPayload) UnmarshalBinary(data []byte) error {
 		case 1:
 			p.MACPayload = &RejoinRequestType1Payload{}
 		default:
-			return fmt.Errorf("lorawan: invalid RejoinType %d", data[1])
+			return newDecodeError("PHYPayload", ErrInvalidValue, "lorawan: invalid RejoinType %d", data[1])
 		}
 	case Proprietary:
 		p.MACPayload = &DataPayload{}
@@ -537,7 +675,7 @@ func (p *PHYPayload) UnmarshalBinary(data []byte) error {
 
 	isUplink := p.isUplink()
 	if err := p.MACPayload.UnmarshalBinary(isUplink, data[1:len(data)-4]); err != nil {
-		return err
+		return withOffset(err, 1)
 	}
 
 	// MIC
@@ -585,11 +723,26 @@ func (p PHYPayload) isUplink() bool {
 	}
 }
 
+func (p PHYPayload) getRejoinRequestMICKey(sNwkSIntKey, jsIntKey AES128Key) (AES128Key, error) {
+	if p.MHDR.MType != RejoinRequest {
+		return AES128Key{}, newError(ErrInvalidValue, "lorawan: MType must be RejoinRequest")
+	}
+
+	switch p.MACPayload.(type) {
+	case *RejoinRequestType02Payload:
+		return sNwkSIntKey, nil
+	case *RejoinRequestType1Payload:
+		return jsIntKey, nil
+	default:
+		return AES128Key{}, newError(ErrInvalidPayloadType, "lorawan: MACPayload must be of type *RejoinRequestType02Payload or *RejoinRequestType1Payload")
+	}
+}
+
 func (p PHYPayload) calculateUplinkJoinMIC(key AES128Key) (MIC, error) {
 	var mic MIC
 
 	if p.MACPayload == nil {
-		return mic, errors.New("lorawan: MACPayload must not be empty")
+		return mic, newError(ErrInvalidPayloadType, "lorawan: MACPayload must not be empty")
 	}
 
 	var micBytes []byte
@@ -626,12 +779,12 @@ func (p PHYPayload) calculateDownlinkJoinMIC(joinReqType JoinType, joinEUI EUI64
 	var mic MIC
 
 	if p.MACPayload == nil {
-		return mic, errors.New("lorawan: MACPayload most not be empty")
+		return mic, newError(ErrInvalidPayloadType, "lorawan: MACPayload must not be empty")
 	}
 
 	joinAccPL, ok := p.MACPayload.(*JoinAcceptPayload)
 	if !ok {
-		return mic, errors.New("lorawan: MACPayload field must be of type *JoinAcceptPayload")
+		return mic, newError(ErrInvalidPayloadType, "lorawan: MACPayload field must be of type *JoinAcceptPayload")
 	}
 
 	var micBytes []byte
@@ -683,16 +836,16 @@ func (p PHYPayload) calculateDownlinkJoinMIC(joinReqType JoinType, joinEUI EUI64
 	return mic, nil
 }
 
-func (p *PHYPayload) calculateUplinkDataMIC(macVersion MACVersion, confFCnt uint32, txDR, txCh uint8, fNwkSIntKey, sNwkSIntKey AES128Key) (MIC, error) {
+func (p *PHYPayload) calculateUplinkDataMIC(c *CMACCache, macVersion MACVersion, confFCnt uint32, txDR, txCh uint8, fNwkSIntKey, sNwkSIntKey AES128Key) (MIC, error) {
 	var mic MIC
 
 	if p.MACPayload == nil {
-		return mic, errors.New("lorawan: MACPayload must not be nil")
+		return mic, newError(ErrInvalidPayloadType, "lorawan: MACPayload must not be nil")
 	}
 
 	macPL, ok := p.MACPayload.(*MACPayload)
 	if !ok {
-		return mic, errors.New("lorawan: MACPayload field must be of type *MACPayload")
+		return mic, newError(ErrInvalidPayloadType, "lorawan: MACPayload field must be of type *MACPayload")
 	}
 
 	// set to 0 when the uplink does not contain an ACK
@@ -702,18 +855,14 @@ func (p *PHYPayload) calculateUplinkDataMIC(macVersion MACVersion, confFCnt uint
 
 	confFCnt = confFCnt % (1 << 16)
 
-	var micBytes []byte
-	b, err := p.MHDR.MarshalBinary()
-	if err != nil {
-		return mic, err
-	}
-	micBytes = append(micBytes, b...)
+	buf := getFrameBuffer()
+	defer putFrameBuffer(buf)
 
-	b, err = macPL.MarshalBinary()
+	micBytes, err := macPL.AppendBinary(append(*buf, p.MHDR.marshalByte()))
+	*buf = micBytes
 	if err != nil {
 		return mic, err
 	}
-	micBytes = append(micBytes, b...)
 
 	b0 := make([]byte, 16)
 	b1 := make([]byte, 16)
@@ -722,7 +871,7 @@ func (p *PHYPayload) calculateUplinkDataMIC(macVersion MACVersion, confFCnt uint
 	b1[0] = 0x49
 
 	// devaddr
-	b, err = macPL.FHDR.DevAddr.MarshalBinary()
+	b, err := macPL.FHDR.DevAddr.MarshalBinary()
 	if err != nil {
 		return mic, err
 	}
@@ -742,34 +891,18 @@ func (p *PHYPayload) calculateUplinkDataMIC(macVersion MACVersion, confFCnt uint
 	b1[3] = txDR
 	b1[4] = txCh
 
-	hash, err := cmac.New(sNwkSIntKey[:])
+	cmacS, err := c.sum(sNwkSIntKey, b1, micBytes)
 	if err != nil {
 		return mic, err
 	}
-	if _, err = hash.Write(b1); err != nil {
-		return mic, err
-	}
-	if _, err = hash.Write(micBytes); err != nil {
-		return mic, err
-	}
-
-	cmacS := hash.Sum([]byte{})
 	if len(cmacS) < 4 {
 		return mic, errors.New("lorawan: the hash returned less than 4 bytes")
 	}
 
-	hash, err = cmac.New(fNwkSIntKey[:])
+	cmacF, err := c.sum(fNwkSIntKey, b0, micBytes)
 	if err != nil {
 		return mic, err
 	}
-	if _, err = hash.Write(b0); err != nil {
-		return mic, err
-	}
-	if _, err = hash.Write(micBytes); err != nil {
-		return mic, err
-	}
-
-	cmacF := hash.Sum([]byte{})
 	if len(cmacF) < 2 {
 		return mic, errors.New("lorawan: the hash returned less than 2 bytes")
 	}
@@ -784,16 +917,16 @@ func (p *PHYPayload) calculateUplinkDataMIC(macVersion MACVersion, confFCnt uint
 	return mic, nil
 }
 
-func (p *PHYPayload) calculateDownlinkDataMIC(macVersion MACVersion, confFCnt uint32, sNwkSIntKey AES128Key) (MIC, error) {
+func (p *PHYPayload) calculateDownlinkDataMIC(c *CMACCache, macVersion MACVersion, confFCnt uint32, sNwkSIntKey AES128Key) (MIC, error) {
 	var mic MIC
 
 	if p.MACPayload == nil {
-		return mic, errors.New("lorawan: MACPayload must not be nil")
+		return mic, newError(ErrInvalidPayloadType, "lorawan: MACPayload must not be nil")
 	}
 
 	macPL, ok := p.MACPayload.(*MACPayload)
 	if !ok {
-		return mic, errors.New("lorawan: MACPayload field must be of type *MACPayload")
+		return mic, newError(ErrInvalidPayloadType, "lorawan: MACPayload field must be of type *MACPayload")
 	}
 
 	// The confirmed FCnt is only used in case of LoRaWAN 1.1 when the ACK
@@ -803,25 +936,21 @@ func (p *PHYPayload) calculateDownlinkDataMIC(macVersion MACVersion, confFCnt ui
 	}
 	confFCnt = confFCnt % (1 << 16)
 
-	var micBytes []byte
-	b, err := p.MHDR.MarshalBinary()
-	if err != nil {
-		return mic, err
-	}
-	micBytes = append(micBytes, b...)
+	buf := getFrameBuffer()
+	defer putFrameBuffer(buf)
 
-	b, err = macPL.MarshalBinary()
+	micBytes, err := macPL.AppendBinary(append(*buf, p.MHDR.marshalByte()))
+	*buf = micBytes
 	if err != nil {
 		return mic, err
 	}
-	micBytes = append(micBytes, b...)
 
 	b0 := make([]byte, 16)
 	b0[0] = 0x49
 	binary.LittleEndian.PutUint16(b0[1:3], uint16(confFCnt))
 	b0[5] = 0x01
 
-	b, err = macPL.FHDR.DevAddr.MarshalBinary()
+	b, err := macPL.FHDR.DevAddr.MarshalBinary()
 	if err != nil {
 		return mic, err
 	}
@@ -829,19 +958,10 @@ func (p *PHYPayload) calculateDownlinkDataMIC(macVersion MACVersion, confFCnt ui
 	binary.LittleEndian.PutUint32(b0[10:14], macPL.FHDR.FCnt)
 	b0[15] = byte(len(micBytes))
 
-	hash, err := cmac.New(sNwkSIntKey[:])
+	hb, err := c.sum(sNwkSIntKey, b0, micBytes)
 	if err != nil {
 		return mic, err
 	}
-
-	if _, err = hash.Write(b0); err != nil {
-		return mic, err
-	}
-	if _, err = hash.Write(micBytes); err != nil {
-		return mic, err
-	}
-
-	hb := hash.Sum([]byte{})
 	if len(hb) < 4 {
 		return mic, errors.New("lorawan: the hash returned less than 4 bytes")
 	}
@@ -902,7 +1022,7 @@ func EncryptFRMPayload(key AES128Key, uplink bool, devAddr DevAddr, fCnt uint32,
 //   Set the aFCntDown to true and use the AFCntDown
 func EncryptFOpts(nwkSEncKey AES128Key, aFCntDown, uplink bool, devAddr DevAddr, fCnt uint32, data []byte) ([]byte, error) {
 	if len(data) > 15 {
-		return nil, errors.New("lorawan: max size of FOpts is 15 bytes")
+		return nil, newError(ErrInvalidLength, "lorawan: max size of FOpts is 15 bytes")
 	}
 
 	block, err := aes.NewCipher(nwkSEncKey[:])
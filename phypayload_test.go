@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAES128Key(t *testing.T) {
@@ -419,9 +420,9 @@ func TestPHYPayloadMACPayloadLoRaWAN11(t *testing.T) {
 					var mic MIC
 					switch phy.MHDR.MType {
 					case UnconfirmedDataUp, ConfirmedDataUp:
-						mic, err = phy.calculateUplinkDataMIC(LoRaWAN1_1, 1, 2, 3, test.FNwkSIntKey, test.SNwkSIntKey)
+						mic, err = phy.calculateUplinkDataMIC(nil, LoRaWAN1_1, 1, 2, 3, test.FNwkSIntKey, test.SNwkSIntKey)
 					case UnconfirmedDataDown, ConfirmedDataDown:
-						mic, err = phy.calculateDownlinkDataMIC(LoRaWAN1_1, 1, test.SNwkSIntKey)
+						mic, err = phy.calculateDownlinkDataMIC(nil, LoRaWAN1_1, 1, test.SNwkSIntKey)
 					default:
 						t.Fatalf("unexpected MType %s", phy.MHDR.MType)
 					}
@@ -640,6 +641,20 @@ func TestPHYPayloadRejoinRequest02(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(valid, ShouldBeTrue)
 
+			Convey("Then SetRejoinRequestMIC uses the SNwkSIntKey", func() {
+				phy.MIC = MIC{}
+				So(phy.SetRejoinRequestMIC(key, AES128Key{1}), ShouldBeNil)
+				So(phy.MIC, ShouldEqual, MIC{60, 134, 66, 174})
+
+				valid, err := phy.ValidateRejoinRequestMIC(key, AES128Key{1})
+				So(err, ShouldBeNil)
+				So(valid, ShouldBeTrue)
+
+				valid, err = phy.ValidateRejoinRequestMIC(AES128Key{1}, key)
+				So(err, ShouldBeNil)
+				So(valid, ShouldBeFalse)
+			})
+
 			Convey("Then MarshalBinary returns the expected value", func() {
 				b, err := phy.MarshalBinary()
 				So(err, ShouldBeNil)
@@ -675,6 +690,20 @@ func TestPHYPayloadRejoinRequest1(t *testing.T) {
 			So(phy.SetUplinkJoinMIC(key), ShouldBeNil)
 			So(phy.MIC, ShouldEqual, MIC{234, 195, 16, 114})
 
+			Convey("Then SetRejoinRequestMIC uses the JSIntKey", func() {
+				phy.MIC = MIC{}
+				So(phy.SetRejoinRequestMIC(AES128Key{1}, key), ShouldBeNil)
+				So(phy.MIC, ShouldEqual, MIC{234, 195, 16, 114})
+
+				valid, err := phy.ValidateRejoinRequestMIC(AES128Key{1}, key)
+				So(err, ShouldBeNil)
+				So(valid, ShouldBeTrue)
+
+				valid, err = phy.ValidateRejoinRequestMIC(key, AES128Key{1})
+				So(err, ShouldBeNil)
+				So(valid, ShouldBeFalse)
+			})
+
 			Convey("Then MarshalBinary returns the expected value", func() {
 				b, err := phy.MarshalBinary()
 				So(err, ShouldBeNil)
@@ -1122,3 +1151,33 @@ func ExamplePHYPayload_readJoinRequest() {
 	// 0203040502030405
 	// 4141
 }
+
+func TestPHYPayloadAppendBinary(t *testing.T) {
+	assert := require.New(t)
+
+	phy := benchmarkDataPHYPayload()
+	expected, err := phy.MarshalBinary()
+	assert.NoError(err)
+
+	// appended to the given prefix
+	b, err := phy.AppendBinary([]byte{0xff})
+	assert.NoError(err)
+	assert.Equal(append([]byte{0xff}, expected...), b)
+
+	// buffer re-use
+	buf := make([]byte, 0, 255)
+	b, err = phy.AppendBinary(buf)
+	assert.NoError(err)
+	assert.Equal(expected, b)
+	assert.Equal(&buf[:1][0], &b[0])
+
+	// on error, the prefix is returned
+	fPort := uint8(0)
+	phy.MACPayload.(*MACPayload).FPort = &fPort
+	b, err = phy.AppendBinary([]byte{0xff})
+	assert.EqualError(err, "lorawan: FPort must not be 0 when FOpts are set")
+	assert.Equal([]byte{0xff}, b)
+
+	_, err = PHYPayload{}.AppendBinary(nil)
+	assert.EqualError(err, "lorawan: MACPayload should not be nil")
+}
@@ -0,0 +1,31 @@
+package lorawan
+
+import "sync"
+
+// maxPHYPayloadSize defines the max. size of a PHYPayload.
+const maxPHYPayloadSize = 255
+
+// frameBufferPool holds the buffers used internally for marshaling frames,
+// e.g. for the MIC calculation.
+var frameBufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, maxPHYPayloadSize)
+		return &b
+	},
+}
+
+// getFrameBuffer returns an empty buffer from the pool.
+func getFrameBuffer() *[]byte {
+	b := frameBufferPool.Get().(*[]byte)
+	*b = (*b)[:0]
+	return b
+}
+
+// putFrameBuffer returns the given buffer to the pool. Buffers which have
+// grown beyond the max. PHYPayload size are not re-used.
+func putFrameBuffer(b *[]byte) {
+	if cap(*b) > 4*maxPHYPayloadSize {
+		return
+	}
+	frameBufferPool.Put(b)
+}
@@ -0,0 +1,194 @@
+// Package qrcode implements the LoRa Alliance TR005 LoRaWAN Device
+// Identification QR Code format.
+//
+// A TR005 QR code has the following format:
+//
+//	LW:D0:<JoinEUI>:<DevEUI>:<ProfileID>[:O<OwnerToken>][:S<SerNum>][:P<Proprietary>][:C<CheckSum>]
+//
+// The ProfileID consists of the 2 byte VendorID followed by the 2 byte
+// VendorProfileID. The CheckSum is the CRC-16 (CCITT-FALSE) of all the
+// characters preceding the ":C" separator, encoded as 4 hex characters.
+package qrcode
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/brocaar/lorawan"
+)
+
+// Prefix defines the QR code prefix.
+const Prefix = "LW"
+
+// SchemaD0 defines the D0 schema identifier.
+const SchemaD0 = "D0"
+
+// ErrInvalidChecksum is returned when the checksum field does not match
+// the checksum of the QR code content.
+var ErrInvalidChecksum = errors.New("qrcode: invalid checksum")
+
+// ProfileID holds the device profile identifier.
+type ProfileID struct {
+	VendorID        uint16
+	VendorProfileID uint16
+}
+
+// String implements fmt.Stringer.
+func (p ProfileID) String() string {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint16(b[0:2], p.VendorID)
+	binary.BigEndian.PutUint16(b[2:4], p.VendorProfileID)
+	return strings.ToUpper(hex.EncodeToString(b))
+}
+
+// DeviceID holds the device identification fields of a TR005 QR code.
+type DeviceID struct {
+	JoinEUI   lorawan.EUI64
+	DevEUI    lorawan.EUI64
+	ProfileID ProfileID
+
+	// Optional fields.
+	OwnerToken  string
+	SerNum      string
+	Proprietary string
+
+	// Checksum indicates if the CheckSum field must be added when
+	// generating the QR code. When parsing, it is set to true when the
+	// (valid) CheckSum field was present.
+	Checksum bool
+}
+
+// MarshalText implements encoding.TextMarshaler. It returns the QR code
+// content.
+func (d DeviceID) MarshalText() ([]byte, error) {
+	for _, f := range []string{d.OwnerToken, d.SerNum, d.Proprietary} {
+		if strings.Contains(f, ":") {
+			return nil, errors.New("qrcode: fields must not contain ':'")
+		}
+	}
+
+	parts := []string{
+		Prefix,
+		SchemaD0,
+		strings.ToUpper(d.JoinEUI.String()),
+		strings.ToUpper(d.DevEUI.String()),
+		d.ProfileID.String(),
+	}
+
+	if d.OwnerToken != "" {
+		parts = append(parts, "O"+d.OwnerToken)
+	}
+	if d.SerNum != "" {
+		parts = append(parts, "S"+d.SerNum)
+	}
+	if d.Proprietary != "" {
+		parts = append(parts, "P"+d.Proprietary)
+	}
+
+	s := strings.Join(parts, ":")
+	if d.Checksum {
+		s = fmt.Sprintf("%s:C%04X", s, crc16(s))
+	}
+
+	return []byte(s), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It parses the QR code
+// content.
+func (d *DeviceID) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	parts := strings.Split(s, ":")
+
+	if len(parts) < 5 {
+		return fmt.Errorf("qrcode: at least 5 fields expected, got %d", len(parts))
+	}
+	if parts[0] != Prefix {
+		return fmt.Errorf("qrcode: invalid prefix: %s", parts[0])
+	}
+	if parts[1] != SchemaD0 {
+		return fmt.Errorf("qrcode: unsupported schema: %s", parts[1])
+	}
+
+	*d = DeviceID{}
+
+	if err := d.JoinEUI.UnmarshalText([]byte(parts[2])); err != nil {
+		return fmt.Errorf("qrcode: invalid JoinEUI: %s", err)
+	}
+	if err := d.DevEUI.UnmarshalText([]byte(parts[3])); err != nil {
+		return fmt.Errorf("qrcode: invalid DevEUI: %s", err)
+	}
+
+	b, err := hex.DecodeString(parts[4])
+	if err != nil || len(b) != 4 {
+		return fmt.Errorf("qrcode: invalid ProfileID: %s", parts[4])
+	}
+	d.ProfileID.VendorID = binary.BigEndian.Uint16(b[0:2])
+	d.ProfileID.VendorProfileID = binary.BigEndian.Uint16(b[2:4])
+
+	for i, p := range parts[5:] {
+		if p == "" {
+			return errors.New("qrcode: empty field")
+		}
+
+		switch p[0] {
+		case 'O':
+			d.OwnerToken = p[1:]
+		case 'S':
+			d.SerNum = p[1:]
+		case 'P':
+			d.Proprietary = p[1:]
+		case 'C':
+			if i != len(parts[5:])-1 {
+				return errors.New("qrcode: CheckSum must be the last field")
+			}
+
+			var sum uint16
+			if _, err := fmt.Sscanf(p[1:], "%04X", &sum); err != nil || len(p) != 5 {
+				return fmt.Errorf("qrcode: invalid CheckSum: %s", p[1:])
+			}
+			if crc16(s[:strings.LastIndex(s, ":C")]) != sum {
+				return ErrInvalidChecksum
+			}
+			d.Checksum = true
+		default:
+			// Unknown fields must be ignored for forward compatibility.
+		}
+	}
+
+	return nil
+}
+
+// String implements fmt.Stringer.
+func (d DeviceID) String() string {
+	b, err := d.MarshalText()
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// Parse parses the given QR code content.
+func Parse(s string) (DeviceID, error) {
+	var d DeviceID
+	err := d.UnmarshalText([]byte(s))
+	return d, err
+}
+
+// crc16 implements the CRC-16/CCITT-FALSE checksum.
+func crc16(s string) uint16 {
+	crc := uint16(0xffff)
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,86 @@
+package qrcode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestCRC16(t *testing.T) {
+	assert := require.New(t)
+
+	// CRC-16/CCITT-FALSE check value
+	assert.Equal(uint16(0x29b1), crc16("123456789"))
+}
+
+func TestDeviceID(t *testing.T) {
+	tests := []struct {
+		Name          string
+		DeviceID      DeviceID
+		Text          string
+		ExpectedError string
+	}{
+		{
+			Name: "mandatory fields only",
+			DeviceID: DeviceID{
+				JoinEUI:   lorawan.EUI64{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88},
+				DevEUI:    lorawan.EUI64{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff, 0x00, 0x11},
+				ProfileID: ProfileID{VendorID: 0xaabb, VendorProfileID: 0x1122},
+			},
+			Text: "LW:D0:1122334455667788:AABBCCDDEEFF0011:AABB1122",
+		},
+		{
+			Name: "all fields",
+			DeviceID: DeviceID{
+				JoinEUI:     lorawan.EUI64{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88},
+				DevEUI:      lorawan.EUI64{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff, 0x00, 0x11},
+				ProfileID:   ProfileID{VendorID: 0xaabb, VendorProfileID: 0x1122},
+				OwnerToken:  "AABBCCDDEEFF",
+				SerNum:      "YYWWNNNNNN",
+				Proprietary: "FOOBAR",
+				Checksum:    true,
+			},
+			Text: "LW:D0:1122334455667788:AABBCCDDEEFF0011:AABB1122:OAABBCCDDEEFF:SYYWWNNNNNN:PFOOBAR:CEFAA",
+		},
+		{
+			Name:          "invalid prefix",
+			Text:          "XX:D0:1122334455667788:AABBCCDDEEFF0011:AABB1122",
+			ExpectedError: "qrcode: invalid prefix: XX",
+		},
+		{
+			Name:          "unsupported schema",
+			Text:          "LW:D1:1122334455667788:AABBCCDDEEFF0011:AABB1122",
+			ExpectedError: "qrcode: unsupported schema: D1",
+		},
+		{
+			Name:          "invalid DevEUI",
+			Text:          "LW:D0:1122334455667788:AABBCCDDEEFF00:AABB1122",
+			ExpectedError: "qrcode: invalid DevEUI: lorawan: exactly 8 bytes are expected",
+		},
+		{
+			Name:          "invalid checksum",
+			Text:          "LW:D0:1122334455667788:AABBCCDDEEFF0011:AABB1122:SYYWWNNNNNN:CEFAA",
+			ExpectedError: "qrcode: invalid checksum",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			d, err := Parse(tst.Text)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tst.DeviceID, d)
+
+			b, err := tst.DeviceID.MarshalText()
+			assert.NoError(err)
+			assert.Equal(tst.Text, string(b))
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package lorawan
+
+import (
+	"errors"
+)
+
+// ErrNoMatchingSession is returned when the frame could not be validated
+// using any of the available session-contexts.
+var ErrNoMatchingSession = errors.New("lorawan: no session-context matches the frame MIC")
+
+// RekeyContext implements the server-side rekey procedure as defined by the
+// LoRaWAN 1.1 specification. After a (re)join, the network-server must keep
+// the current session-context until the device confirms that it is using
+// the new session-context by sending a RekeyInd mac-command protected by
+// the new session keys. Until then, frames are accepted under either
+// session-context.
+type RekeyContext struct {
+	// Current holds the session-context currently in use.
+	Current *SessionContext `json:"current"`
+
+	// Pending holds the session-context resulting from the (re)join which
+	// has not yet been confirmed by the device using RekeyInd.
+	Pending *SessionContext `json:"pending"`
+}
+
+// SetPending sets the new session-context resulting from a (re)join.
+// Any previously pending session-context is overwritten.
+func (r *RekeyContext) SetPending(s SessionContext) {
+	r.Pending = &s
+}
+
+// IsPending returns true when the rekey procedure has not yet completed.
+func (r RekeyContext) IsPending() bool {
+	return r.Pending != nil
+}
+
+// ValidateUplinkDataMIC validates the MIC of the given uplink data frame,
+// trying the pending session-context first and the current session-context
+// second. On success, the FCnt of the frame is set to the full 32 bit
+// frame-counter of the matching session-context and the matching
+// session-context is returned. When no session-context matches,
+// ErrNoMatchingSession is returned.
+func (r *RekeyContext) ValidateUplinkDataMIC(p *PHYPayload, txDR, txCh uint8) (*SessionContext, error) {
+	macPL, ok := p.MACPayload.(*MACPayload)
+	if !ok {
+		return nil, newError(ErrInvalidPayloadType, "lorawan: MACPayload must be of type *MACPayload")
+	}
+	fCnt := macPL.FHDR.FCnt
+
+	for _, s := range []*SessionContext{r.Pending, r.Current} {
+		if s == nil || s.DevAddr != macPL.FHDR.DevAddr {
+			continue
+		}
+
+		macPL.FHDR.FCnt = getFullFCnt(s.FCntUp, fCnt)
+		ok, err := s.ValidateUplinkDataMIC(*p, txDR, txCh)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return s, nil
+		}
+	}
+
+	macPL.FHDR.FCnt = fCnt
+	return nil, ErrNoMatchingSession
+}
+
+// HandleRekeyInd handles a RekeyInd mac-command received within a frame
+// that was validated using the given session-context (see
+// ValidateUplinkDataMIC). When this is the pending session-context, it
+// becomes the current session-context and the old session-context is
+// retired. The returned RekeyConf payload must be sent to the device.
+// As the device repeats the RekeyInd until it receives the RekeyConf, a
+// RekeyInd validated using the current session-context is answered too.
+func (r *RekeyContext) HandleRekeyInd(s *SessionContext, pl RekeyIndPayload) (RekeyConfPayload, error) {
+	if s == nil || (s != r.Pending && s != r.Current) {
+		return RekeyConfPayload{}, errors.New("lorawan: unknown session-context")
+	}
+
+	if s == r.Pending {
+		r.Current = r.Pending
+		r.Pending = nil
+	}
+
+	// The server version is the minimum of the device and server supported
+	// LoRaWAN 1.x minor version.
+	minor := pl.DevLoRaWANVersion.Minor
+	if minor > 1 {
+		minor = 1
+	}
+
+	return RekeyConfPayload{
+		ServLoRaWANVersion: Version{Minor: minor},
+	}, nil
+}
+
+// getFullFCnt returns the full 32 bit frame-counter, given the expected
+// frame-counter and the (16 least-significant bits of the) received
+// frame-counter.
+func getFullFCnt(expected, fCnt uint32) uint32 {
+	full := (expected &^ 0xffff) | (fCnt & 0xffff)
+	if full < expected {
+		full += 1 << 16
+	}
+	return full
+}
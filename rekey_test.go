@@ -0,0 +1,131 @@
+package lorawan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFullFCnt(t *testing.T) {
+	assert := require.New(t)
+
+	tests := []struct {
+		Expected uint32
+		FCnt     uint32
+		Full     uint32
+	}{
+		{0, 0, 0},
+		{10, 10, 10},
+		{10, 11, 11},
+		{65535, 0, 65536},
+		{65536, 1, 65537},
+		{131072, 5, 131077},
+	}
+
+	for _, tst := range tests {
+		assert.Equal(tst.Full, getFullFCnt(tst.Expected, tst.FCnt))
+	}
+}
+
+func TestRekeyContext(t *testing.T) {
+	assert := require.New(t)
+
+	var fPort1 uint8 = 1
+	oldSession := SessionContext{
+		MACVersion:  LoRaWAN1_1,
+		DevAddr:     DevAddr{1, 2, 3, 4},
+		FNwkSIntKey: AES128Key{1},
+		SNwkSIntKey: AES128Key{2},
+		FCntUp:      65540,
+	}
+	newSession := SessionContext{
+		MACVersion:  LoRaWAN1_1,
+		DevAddr:     DevAddr{1, 2, 3, 4},
+		FNwkSIntKey: AES128Key{3},
+		SNwkSIntKey: AES128Key{4},
+	}
+
+	newFrame := func(s SessionContext) PHYPayload {
+		phy := PHYPayload{
+			MHDR: MHDR{
+				MType: UnconfirmedDataUp,
+				Major: LoRaWANR1,
+			},
+			MACPayload: &MACPayload{
+				FHDR: FHDR{
+					DevAddr: s.DevAddr,
+					FCnt:    s.FCntUp,
+				},
+				FPort: &fPort1,
+			},
+		}
+		assert.NoError(s.SetUplinkDataMIC(&phy, 0, 0))
+
+		// simulate transmission, only the 16 LSB are transmitted
+		b, err := phy.MarshalBinary()
+		assert.NoError(err)
+		assert.NoError(phy.UnmarshalBinary(b))
+		return phy
+	}
+
+	rc := RekeyContext{
+		Current: &oldSession,
+	}
+	assert.False(rc.IsPending())
+	rc.SetPending(newSession)
+	assert.True(rc.IsPending())
+
+	t.Run("Frame using old session", func(t *testing.T) {
+		assert := require.New(t)
+
+		phy := newFrame(oldSession)
+		s, err := rc.ValidateUplinkDataMIC(&phy, 0, 0)
+		assert.NoError(err)
+		assert.True(s == rc.Current)
+		assert.Equal(uint32(65540), phy.MACPayload.(*MACPayload).FHDR.FCnt)
+	})
+
+	t.Run("Frame using unknown session", func(t *testing.T) {
+		assert := require.New(t)
+
+		phy := newFrame(SessionContext{MACVersion: LoRaWAN1_1, DevAddr: DevAddr{1, 2, 3, 4}, FCntUp: 3})
+		s, err := rc.ValidateUplinkDataMIC(&phy, 0, 0)
+		assert.Equal(ErrNoMatchingSession, err)
+		assert.Nil(s)
+		assert.Equal(uint32(3), phy.MACPayload.(*MACPayload).FHDR.FCnt)
+	})
+
+	t.Run("Frame using new session with RekeyInd", func(t *testing.T) {
+		assert := require.New(t)
+
+		phy := newFrame(newSession)
+		s, err := rc.ValidateUplinkDataMIC(&phy, 0, 0)
+		assert.NoError(err)
+		assert.True(s == rc.Pending)
+
+		conf, err := rc.HandleRekeyInd(s, RekeyIndPayload{DevLoRaWANVersion: Version{Minor: 1}})
+		assert.NoError(err)
+		assert.Equal(RekeyConfPayload{ServLoRaWANVersion: Version{Minor: 1}}, conf)
+		assert.False(rc.IsPending())
+		assert.Equal(newSession, *rc.Current)
+	})
+
+	t.Run("Old session has been retired", func(t *testing.T) {
+		assert := require.New(t)
+
+		phy := newFrame(oldSession)
+		_, err := rc.ValidateUplinkDataMIC(&phy, 0, 0)
+		assert.Equal(ErrNoMatchingSession, err)
+	})
+
+	t.Run("Repeated RekeyInd", func(t *testing.T) {
+		assert := require.New(t)
+
+		conf, err := rc.HandleRekeyInd(rc.Current, RekeyIndPayload{DevLoRaWANVersion: Version{Minor: 2}})
+		assert.NoError(err)
+		assert.Equal(RekeyConfPayload{ServLoRaWANVersion: Version{Minor: 1}}, conf)
+
+		_, err = rc.HandleRekeyInd(&oldSession, RekeyIndPayload{})
+		assert.Error(err)
+	})
+}
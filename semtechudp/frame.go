@@ -0,0 +1,156 @@
+package semtechudp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/backend"
+	"github.com/brocaar/lorawan/band"
+)
+
+// UplinkFrame holds a decoded uplink frame and its meta-data.
+type UplinkFrame struct {
+	PHYPayload lorawan.PHYPayload
+	ULMetaData backend.ULMetaData
+}
+
+// DownlinkFrame holds a decoded downlink frame and its meta-data.
+type DownlinkFrame struct {
+	PHYPayload lorawan.PHYPayload
+	DLMetaData backend.DLMetaData
+}
+
+// UplinkFrames returns the uplink frames of the PUSH_DATA packet, using the
+// given band for resolving the data-rate index. Frames with an invalid or
+// without CRC are skipped.
+func (p PushDataPacket) UplinkFrames(b band.Band) ([]UplinkFrame, error) {
+	var out []UplinkFrame
+
+	for i, rxpk := range p.Payload.RXPK {
+		if rxpk.Stat != 1 {
+			continue
+		}
+
+		frame, err := NewUplinkFrame(p.GatewayMAC, rxpk, b)
+		if err != nil {
+			return nil, fmt.Errorf("%w (rxpk %d)", err, i)
+		}
+		out = append(out, frame)
+	}
+
+	return out, nil
+}
+
+// NewUplinkFrame decodes the given rxpk, received by the given gateway, into
+// an UplinkFrame. The meta-data device fields (e.g. DevAddr, FPort and
+// FCntUp) are set from the (unencrypted) frame header. Note that FCntUp
+// only contains the 16 LSB of the frame-counter as transmitted over the
+// air.
+func NewUplinkFrame(gatewayMAC lorawan.EUI64, rxpk RXPK, b band.Band) (UplinkFrame, error) {
+	var out UplinkFrame
+
+	if err := out.PHYPayload.UnmarshalBinary(rxpk.Data); err != nil {
+		return out, fmt.Errorf("lorawan/semtechudp: unmarshal phypayload error: %w", err)
+	}
+
+	dr, err := DataRate(rxpk.Modu, rxpk.DatR)
+	if err != nil {
+		return out, err
+	}
+	drIndex, err := b.GetDataRateIndex(true, dr)
+	if err != nil {
+		return out, fmt.Errorf("lorawan/semtechudp: get data-rate index error: %w", err)
+	}
+
+	freq := rxpk.Freq
+	rssi := int(rxpk.RSSI)
+	snr := rxpk.LSNR
+	gwCnt := 1
+
+	out.ULMetaData = backend.ULMetaData{
+		DataRate: &drIndex,
+		ULFreq:   &freq,
+		RFRegion: b.Name(),
+		GWCnt:    &gwCnt,
+		GWInfo: []backend.GWInfoElement{
+			{
+				ID:        backend.HEXBytes(gatewayMAC[:]),
+				RFRegion:  b.Name(),
+				RSSI:      &rssi,
+				SNR:       &snr,
+				DLAllowed: true,
+			},
+		},
+	}
+	if rxpk.Time != nil {
+		out.ULMetaData.RecvTime = backend.ISO8601Time(time.Time(*rxpk.Time))
+	}
+
+	switch pl := out.PHYPayload.MACPayload.(type) {
+	case *lorawan.JoinRequestPayload:
+		devEUI := pl.DevEUI
+		out.ULMetaData.DevEUI = &devEUI
+	case *lorawan.RejoinRequestType02Payload:
+		devEUI := pl.DevEUI
+		out.ULMetaData.DevEUI = &devEUI
+	case *lorawan.RejoinRequestType1Payload:
+		devEUI := pl.DevEUI
+		out.ULMetaData.DevEUI = &devEUI
+	case *lorawan.MACPayload:
+		devAddr := pl.FHDR.DevAddr
+		fCnt := pl.FHDR.FCnt
+		out.ULMetaData.DevAddr = &devAddr
+		out.ULMetaData.FCntUp = &fCnt
+		out.ULMetaData.Confirmed = out.PHYPayload.MHDR.MType == lorawan.ConfirmedDataUp
+		if pl.FPort != nil {
+			fPort := *pl.FPort
+			out.ULMetaData.FPort = &fPort
+		}
+	}
+
+	return out, nil
+}
+
+// DownlinkFrame returns the downlink frame of the PULL_RESP packet, using
+// the given band for resolving the data-rate index.
+func (p PullRespPacket) DownlinkFrame(b band.Band) (DownlinkFrame, error) {
+	return NewDownlinkFrame(p.Payload.TXPK, b)
+}
+
+// NewDownlinkFrame decodes the given txpk into a DownlinkFrame. The
+// frequency and data-rate are set as DLFreq1 and DataRate1.
+func NewDownlinkFrame(txpk TXPK, b band.Band) (DownlinkFrame, error) {
+	var out DownlinkFrame
+
+	if err := out.PHYPayload.UnmarshalBinary(txpk.Data); err != nil {
+		return out, fmt.Errorf("lorawan/semtechudp: unmarshal phypayload error: %w", err)
+	}
+
+	dr, err := DataRate(txpk.Modu, txpk.DatR)
+	if err != nil {
+		return out, err
+	}
+	drIndex, err := b.GetDataRateIndex(false, dr)
+	if err != nil {
+		return out, fmt.Errorf("lorawan/semtechudp: get data-rate index error: %w", err)
+	}
+
+	freq := txpk.Freq
+	out.DLMetaData = backend.DLMetaData{
+		DLFreq1:   &freq,
+		DataRate1: &drIndex,
+	}
+
+	if pl, ok := out.PHYPayload.MACPayload.(*lorawan.MACPayload); ok {
+		fCnt := pl.FHDR.FCnt
+		out.DLMetaData.FCntDown = &fCnt
+		out.DLMetaData.Confirmed = out.PHYPayload.MHDR.MType == lorawan.ConfirmedDataDown
+		if pl.FPort != nil {
+			fPort := *pl.FPort
+			out.DLMetaData.FPort = &fPort
+		}
+	}
+
+	return out, nil
+}
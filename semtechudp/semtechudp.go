@@ -0,0 +1,272 @@
+// Package semtechudp implements a decoder for the Semtech UDP packet-forwarder
+// protocol. It converts the PUSH_DATA (rxpk) and PULL_RESP (txpk) gateway
+// frames into PHYPayload and Backend Interfaces meta-data structures, e.g.
+// for replaying gateway captures through the roaming stack in end-to-end
+// tests.
+package semtechudp
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
+)
+
+// PacketType defines the packet type.
+type PacketType byte
+
+// Available packet types.
+const (
+	PushData PacketType = 0x00
+	PushACK  PacketType = 0x01
+	PullData PacketType = 0x02
+	PullResp PacketType = 0x03
+	PullACK  PacketType = 0x04
+	TXACK    PacketType = 0x05
+)
+
+// Supported protocol versions.
+const (
+	ProtocolVersion1 uint8 = 0x01
+	ProtocolVersion2 uint8 = 0x02
+)
+
+func (t PacketType) String() string {
+	switch t {
+	case PushData:
+		return "PUSH_DATA"
+	case PushACK:
+		return "PUSH_ACK"
+	case PullData:
+		return "PULL_DATA"
+	case PullResp:
+		return "PULL_RESP"
+	case PullACK:
+		return "PULL_ACK"
+	case TXACK:
+		return "TX_ACK"
+	default:
+		return fmt.Sprintf("PacketType(%d)", byte(t))
+	}
+}
+
+// GetPacketType returns the packet type of the given UDP packet.
+func GetPacketType(data []byte) (PacketType, error) {
+	if len(data) < 4 {
+		return PacketType(0), errors.New("lorawan/semtechudp: at least 4 bytes of data are expected")
+	}
+	if data[0] != ProtocolVersion1 && data[0] != ProtocolVersion2 {
+		return PacketType(0), fmt.Errorf("lorawan/semtechudp: unsupported protocol version: %d", data[0])
+	}
+
+	return PacketType(data[3]), nil
+}
+
+// PushDataPacket is used by the gateway mainly to forward the RF packets
+// received, and associated metadata, to the server.
+type PushDataPacket struct {
+	ProtocolVersion uint8
+	RandomToken     uint16
+	GatewayMAC      lorawan.EUI64
+	Payload         PushDataPayload
+}
+
+// UnmarshalBinary decodes the object from binary form.
+func (p *PushDataPacket) UnmarshalBinary(data []byte) error {
+	if len(data) < 13 {
+		return errors.New("lorawan/semtechudp: at least 13 bytes are expected")
+	}
+	if t, err := GetPacketType(data); err != nil {
+		return err
+	} else if t != PushData {
+		return fmt.Errorf("lorawan/semtechudp: expected %s packet, got %s", PushData, t)
+	}
+
+	p.ProtocolVersion = data[0]
+	p.RandomToken = binary.LittleEndian.Uint16(data[1:3])
+	copy(p.GatewayMAC[:], data[4:12])
+
+	if err := json.Unmarshal(data[12:], &p.Payload); err != nil {
+		return fmt.Errorf("lorawan/semtechudp: unmarshal PUSH_DATA payload error: %w", err)
+	}
+
+	return nil
+}
+
+// PushDataPayload represents the JSON payload of a PushDataPacket.
+type PushDataPayload struct {
+	RXPK []RXPK         `json:"rxpk,omitempty"`
+	Stat *StatusPayload `json:"stat,omitempty"`
+}
+
+// StatusPayload contains the gateway status.
+type StatusPayload struct {
+	Time string  `json:"time"` // UTC 'system' time of the gateway, ISO 8601 'expanded' format (e.g 2014-01-12 08:59:28 GMT)
+	Lati float64 `json:"lati"` // GPS latitude of the gateway in degree (float, N is +)
+	Long float64 `json:"long"` // GPS latitude of the gateway in degree (float, E is +)
+	Alti int32   `json:"alti"` // GPS altitude of the gateway in meter RX (integer)
+	RXNb uint32  `json:"rxnb"` // Number of radio packets received (unsigned integer)
+	RXOK uint32  `json:"rxok"` // Number of radio packets received with a valid PHY CRC
+	RXFW uint32  `json:"rxfw"` // Number of radio packets forwarded (unsigned integer)
+	ACKR float64 `json:"ackr"` // Percentage of upstream datagrams that were acknowledged
+	DWNb uint32  `json:"dwnb"` // Number of downlink datagrams received (unsigned integer)
+	TXNb uint32  `json:"txnb"` // Number of packets emitted (unsigned integer)
+}
+
+// RXPK contains a RF packet and associated metadata.
+type RXPK struct {
+	Time *CompactTime `json:"time,omitempty"` // UTC time of pkt RX, us precision, ISO 8601 'compact' format (e.g. 2013-03-31T16:21:17.528002Z)
+	Tmms *int64       `json:"tmms,omitempty"` // GPS time of pkt RX, number of milliseconds since 06.Jan.1980
+	Tmst uint32       `json:"tmst"`           // Internal timestamp of "RX finished" event (32b unsigned)
+	Freq float64      `json:"freq"`           // RX central frequency in MHz (unsigned float, Hz precision)
+	Chan uint8        `json:"chan"`           // Concentrator "IF" channel used for RX (unsigned integer)
+	RFCh uint8        `json:"rfch"`           // Concentrator "RF chain" used for RX (unsigned integer)
+	Stat int8         `json:"stat"`           // CRC status: 1 = OK, -1 = fail, 0 = no CRC
+	Modu string       `json:"modu"`           // Modulation identifier "LORA" or "FSK"
+	DatR DatR         `json:"datr"`           // LoRa datarate identifier (eg. SF12BW500) || FSK datarate (unsigned, in bits per second)
+	CodR string       `json:"codr"`           // LoRa ECC coding rate identifier
+	RSSI int16        `json:"rssi"`           // RSSI in dBm (signed integer, 1 dB precision)
+	LSNR float64      `json:"lsnr"`           // Lora SNR ratio in dB (signed float, 0.1 dB precision)
+	Size uint16       `json:"size"`           // RF packet payload size in bytes (unsigned integer)
+	Data []byte       `json:"data"`           // Base64 encoded RF packet payload, padded
+}
+
+// PullRespPacket is used by the server to send RF packets and associated
+// metadata that will have to be emitted by the gateway.
+type PullRespPacket struct {
+	ProtocolVersion uint8
+	RandomToken     uint16
+	Payload         PullRespPayload
+}
+
+// UnmarshalBinary decodes the object from binary form.
+func (p *PullRespPacket) UnmarshalBinary(data []byte) error {
+	if len(data) < 5 {
+		return errors.New("lorawan/semtechudp: at least 5 bytes are expected")
+	}
+	if t, err := GetPacketType(data); err != nil {
+		return err
+	} else if t != PullResp {
+		return fmt.Errorf("lorawan/semtechudp: expected %s packet, got %s", PullResp, t)
+	}
+
+	p.ProtocolVersion = data[0]
+	p.RandomToken = binary.LittleEndian.Uint16(data[1:3])
+
+	if err := json.Unmarshal(data[4:], &p.Payload); err != nil {
+		return fmt.Errorf("lorawan/semtechudp: unmarshal PULL_RESP payload error: %w", err)
+	}
+
+	return nil
+}
+
+// PullRespPayload represents the JSON payload of a PullRespPacket.
+type PullRespPayload struct {
+	TXPK TXPK `json:"txpk"`
+}
+
+// TXPK contains a RF packet to be emitted and associated metadata.
+type TXPK struct {
+	Imme bool    `json:"imme"`           // Send packet immediately (will ignore tmst & time)
+	Tmst *uint32 `json:"tmst,omitempty"` // Send packet on a certain timestamp value (will ignore time)
+	Tmms *int64  `json:"tmms,omitempty"` // Send packet at a certain GPS time (GPS synchronization required)
+	Freq float64 `json:"freq"`           // TX central frequency in MHz (unsigned float, Hz precision)
+	RFCh uint8   `json:"rfch"`           // Concentrator "RF chain" used for TX (unsigned integer)
+	Powe uint8   `json:"powe"`           // TX output power in dBm (unsigned integer, dBm precision)
+	Modu string  `json:"modu"`           // Modulation identifier "LORA" or "FSK"
+	DatR DatR    `json:"datr"`           // LoRa datarate identifier (eg. SF12BW500) || FSK datarate (unsigned, in bits per second)
+	CodR string  `json:"codr,omitempty"` // LoRa ECC coding rate identifier
+	FDev uint16  `json:"fdev,omitempty"` // FSK frequency deviation (unsigned integer, in Hz)
+	IPol bool    `json:"ipol"`           // Lora modulation polarization inversion
+	Prea uint16  `json:"prea,omitempty"` // RF preamble size (unsigned integer)
+	Size uint16  `json:"size"`           // RF packet payload size in bytes (unsigned integer)
+	NCRC bool    `json:"ncrc,omitempty"` // If true, disable the CRC of the physical layer (optional)
+	Data []byte  `json:"data"`           // Base64 encoded RF packet payload, padding optional
+}
+
+// CompactTime implements the ISO 8601 'compact' time format.
+type CompactTime time.Time
+
+// MarshalJSON implements the json.Marshaler interface.
+func (t CompactTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Time(t).UTC().Format(time.RFC3339Nano))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (t *CompactTime) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	t2, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return err
+	}
+	*t = CompactTime(t2)
+	return nil
+}
+
+// DatR implements the data-rate identifier, which is a string for LoRa
+// (e.g. SF12BW500) and an integer for FSK (bits per second).
+type DatR struct {
+	LoRa string
+	FSK  uint32
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (d DatR) MarshalJSON() ([]byte, error) {
+	if d.LoRa != "" {
+		return json.Marshal(d.LoRa)
+	}
+	return json.Marshal(d.FSK)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (d *DatR) UnmarshalJSON(data []byte) error {
+	if strings.HasPrefix(string(data), `"`) {
+		return json.Unmarshal(data, &d.LoRa)
+	}
+	return json.Unmarshal(data, &d.FSK)
+}
+
+// DataRate returns the modulation parameters for the given modulation
+// identifier ("LORA" or "FSK") and data-rate identifier.
+func DataRate(modu string, datr DatR) (band.DataRate, error) {
+	switch modu {
+	case "LORA":
+		s := strings.TrimPrefix(datr.LoRa, "SF")
+		parts := strings.SplitN(s, "BW", 2)
+		if len(parts) != 2 || s == datr.LoRa {
+			return band.DataRate{}, fmt.Errorf("lorawan/semtechudp: invalid LoRa data-rate identifier: %s", datr.LoRa)
+		}
+
+		sf, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return band.DataRate{}, fmt.Errorf("lorawan/semtechudp: invalid spreading-factor: %w", err)
+		}
+		bw, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return band.DataRate{}, fmt.Errorf("lorawan/semtechudp: invalid bandwidth: %w", err)
+		}
+
+		return band.DataRate{
+			Modulation:   band.LoRaModulation,
+			SpreadFactor: sf,
+			Bandwidth:    bw,
+		}, nil
+	case "FSK":
+		return band.DataRate{
+			Modulation: band.FSKModulation,
+			BitRate:    int(datr.FSK),
+		}, nil
+	default:
+		return band.DataRate{}, fmt.Errorf("lorawan/semtechudp: unsupported modulation: %s", modu)
+	}
+}
@@ -0,0 +1,184 @@
+package semtechudp
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/backend"
+	"github.com/brocaar/lorawan/band"
+)
+
+func pushData(payload string) []byte {
+	return append([]byte{0x02, 0x01, 0x02, 0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, []byte(payload)...)
+}
+
+func TestGetPacketType(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		typ  PacketType
+		err  string
+	}{
+		{"push data", []byte{0x02, 0x01, 0x02, 0x00}, PushData, ""},
+		{"pull resp", []byte{0x01, 0x01, 0x02, 0x03}, PullResp, ""},
+		{"too short", []byte{0x02, 0x01, 0x02}, 0, "lorawan/semtechudp: at least 4 bytes of data are expected"},
+		{"invalid version", []byte{0x03, 0x01, 0x02, 0x00}, 0, "lorawan/semtechudp: unsupported protocol version: 3"},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			typ, err := GetPacketType(tst.data)
+			if tst.err != "" {
+				assert.EqualError(err, tst.err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tst.typ, typ)
+		})
+	}
+}
+
+func TestPushDataPacket(t *testing.T) {
+	assert := require.New(t)
+
+	b, err := band.GetConfig(band.EU868, false, lorawan.DwellTimeNoLimit)
+	assert.NoError(err)
+
+	var pkt PushDataPacket
+	assert.NoError(pkt.UnmarshalBinary(pushData(`{"rxpk":[
+		{"time":"2021-03-15T10:00:00.123456Z","tmst":3512348611,"chan":2,"rfch":0,"freq":868.5,"stat":1,"modu":"LORA","datr":"SF7BW125","codr":"4/5","rssi":-35,"lsnr":5.1,"size":16,"data":"QAQDAgGAAQABmcTl5D4LBQ=="},
+		{"tmst":3512348612,"chan":2,"rfch":0,"freq":868.5,"stat":-1,"modu":"LORA","datr":"SF7BW125","codr":"4/5","rssi":-35,"lsnr":5.1,"size":16,"data":"QAQDAgGAAQABmcTl5D4LBQ=="},
+		{"tmst":3512348613,"chan":8,"rfch":1,"freq":868.8,"stat":1,"modu":"FSK","datr":50000,"rssi":-75,"size":23,"data":"AAgHBgUEAwIBAQIDBAUGBwgBAqq7zN0="}
+	]}`)))
+
+	assert.Equal(uint8(2), pkt.ProtocolVersion)
+	assert.Equal(uint16(0x0201), pkt.RandomToken)
+	assert.Equal(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, pkt.GatewayMAC)
+	assert.Len(pkt.Payload.RXPK, 3)
+	assert.Equal(DatR{LoRa: "SF7BW125"}, pkt.Payload.RXPK[0].DatR)
+	assert.Equal(DatR{FSK: 50000}, pkt.Payload.RXPK[2].DatR)
+
+	frames, err := pkt.UplinkFrames(b)
+	assert.NoError(err)
+	assert.Len(frames, 2)
+
+	// LoRa data uplink
+	assert.Equal(lorawan.UnconfirmedDataUp, frames[0].PHYPayload.MHDR.MType)
+	dr := 5
+	freq := 868.5
+	rssi := -35
+	snr := 5.1
+	gwCnt := 1
+	devAddr := lorawan.DevAddr{1, 2, 3, 4}
+	fCnt := uint32(1)
+	fPort := uint8(1)
+	assert.Equal(backend.ULMetaData{
+		DevAddr:  &devAddr,
+		FPort:    &fPort,
+		FCntUp:   &fCnt,
+		DataRate: &dr,
+		ULFreq:   &freq,
+		RecvTime: backend.ISO8601Time(time.Date(2021, 3, 15, 10, 0, 0, 123456000, time.UTC)),
+		RFRegion: "EU868",
+		GWCnt:    &gwCnt,
+		GWInfo: []backend.GWInfoElement{
+			{
+				ID:        backend.HEXBytes{1, 2, 3, 4, 5, 6, 7, 8},
+				RFRegion:  "EU868",
+				RSSI:      &rssi,
+				SNR:       &snr,
+				DLAllowed: true,
+			},
+		},
+	}, frames[0].ULMetaData)
+
+	// FSK join-request
+	assert.Equal(lorawan.JoinRequest, frames[1].PHYPayload.MHDR.MType)
+	assert.Equal(7, *frames[1].ULMetaData.DataRate)
+	assert.Equal(&lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1}, frames[1].ULMetaData.DevEUI)
+	assert.Nil(frames[1].ULMetaData.DevAddr)
+}
+
+func TestPushDataPacketErrors(t *testing.T) {
+	b, err := band.GetConfig(band.EU868, false, lorawan.DwellTimeNoLimit)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"too short", []byte{0x02, 0x01, 0x02, 0x00}, "lorawan/semtechudp: at least 13 bytes are expected"},
+		{"pull resp", append([]byte{0x02, 0x01, 0x02, 0x03}, []byte(`{"txpk":{}}`)...), "lorawan/semtechudp: expected PUSH_DATA packet, got PULL_RESP"},
+		{"invalid json", pushData(`{"rxpk":`), "lorawan/semtechudp: unmarshal PUSH_DATA payload error: unexpected end of JSON input"},
+		{"invalid datr", pushData(`{"rxpk":[{"stat":1,"modu":"LORA","datr":"SF7","data":"QAQDAgGAAQABmcTl5D4LBQ=="}]}`), "lorawan/semtechudp: invalid LoRa data-rate identifier: SF7 (rxpk 0)"},
+		{"unknown data-rate", pushData(`{"rxpk":[{"stat":1,"modu":"LORA","datr":"SF7BW500","data":"QAQDAgGAAQABmcTl5D4LBQ=="}]}`), "lorawan/semtechudp: get data-rate index error: lorawan/band: data-rate not found (rxpk 0)"},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			var pkt PushDataPacket
+			err := pkt.UnmarshalBinary(tst.data)
+			if err == nil {
+				_, err = pkt.UplinkFrames(b)
+			}
+			require.EqualError(t, err, tst.err)
+		})
+	}
+}
+
+func TestPullRespPacket(t *testing.T) {
+	assert := require.New(t)
+
+	b, err := band.GetConfig(band.EU868, false, lorawan.DwellTimeNoLimit)
+	assert.NoError(err)
+
+	var pkt PullRespPacket
+	assert.NoError(pkt.UnmarshalBinary(append([]byte{0x02, 0x01, 0x02, 0x03}, []byte(`{"txpk":{"imme":false,"tmst":3513348611,"freq":869.525,"rfch":0,"powe":27,"modu":"LORA","datr":"SF9BW125","codr":"4/5","ipol":true,"size":13,"data":"YAQDAgEAAQABAQIDBA=="}}`)...)))
+	assert.Equal(uint32(3513348611), *pkt.Payload.TXPK.Tmst)
+
+	frame, err := pkt.DownlinkFrame(b)
+	assert.NoError(err)
+	assert.Equal(lorawan.UnconfirmedDataDown, frame.PHYPayload.MHDR.MType)
+
+	dr := 3
+	freq := 869.525
+	fCnt := uint32(1)
+	fPort := uint8(1)
+	assert.Equal(backend.DLMetaData{
+		FPort:     &fPort,
+		FCntDown:  &fCnt,
+		DLFreq1:   &freq,
+		DataRate1: &dr,
+	}, frame.DLMetaData)
+}
+
+func TestDatR(t *testing.T) {
+	tests := []struct {
+		datr DatR
+		json string
+	}{
+		{DatR{LoRa: "SF12BW500"}, `"SF12BW500"`},
+		{DatR{FSK: 50000}, `50000`},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.json, func(t *testing.T) {
+			assert := require.New(t)
+
+			b, err := json.Marshal(tst.datr)
+			assert.NoError(err)
+			assert.Equal(tst.json, string(b))
+
+			var datr DatR
+			assert.NoError(json.Unmarshal(b, &datr))
+			assert.Equal(tst.datr, datr)
+		})
+	}
+}
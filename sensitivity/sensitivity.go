@@ -2,6 +2,7 @@
 package sensitivity
 
 import (
+	"fmt"
 	"math"
 )
 
@@ -19,3 +20,44 @@ func CalculateLinkBudget(bandwidth int, noiseFigure, snr, txPower float32) float
 	// see also: http://www.techplayon.com/lora-link-budget-sensitivity-calculations-example-explained/
 	return txPower - CalculateSensitivity(bandwidth, noiseFigure, snr)
 }
+
+// requiredSNR contains the LoRa demodulation floor (dB) by spreading-factor.
+var requiredSNR = map[int]float32{
+	5:  -2.5,
+	6:  -5,
+	7:  -7.5,
+	8:  -10,
+	9:  -12.5,
+	10: -15,
+	11: -17.5,
+	12: -20,
+}
+
+// GetRequiredSNR returns the minimum SNR (dB) required to demodulate a LoRa
+// frame using the given spreading-factor.
+func GetRequiredSNR(sf int) (float32, error) {
+	snr, ok := requiredSNR[sf]
+	if !ok {
+		return 0, fmt.Errorf("sensitivity: invalid spreading-factor: %d", sf)
+	}
+	return snr, nil
+}
+
+// CalculateLoRaSensitivity calculates the theoretical LoRa sensitivity
+// (dBm) for the given spreading-factor, bandwidth and noise figure.
+// The bandwidth must be given in Hz!
+func CalculateLoRaSensitivity(sf, bandwidth int, noiseFigure float32) (float32, error) {
+	snr, err := GetRequiredSNR(sf)
+	if err != nil {
+		return 0, err
+	}
+	return CalculateSensitivity(bandwidth, noiseFigure, snr), nil
+}
+
+// CalculateMaxCouplingLoss calculates the maximum coupling loss (dB), this
+// is the max. loss between the transmitter and receiver which still allows
+// the frame to be received. It equals the link budget, increased by the
+// antenna gains (dBi) and reduced by the cable and connector losses (dB).
+func CalculateMaxCouplingLoss(linkBudget, txAntennaGain, rxAntennaGain, cableLoss float32) float32 {
+	return linkBudget + txAntennaGain + rxAntennaGain - cableLoss
+}
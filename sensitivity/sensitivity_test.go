@@ -1,6 +1,8 @@
 package sensitivity
 
 import (
+	"fmt"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -19,3 +21,38 @@ func TestCalculateLinkBudget(t *testing.T) {
 	lb := CalculateLinkBudget(125000, 6, -20, 17)
 	assert.Equal(154, int(lb))
 }
+
+func TestCalculateLoRaSensitivity(t *testing.T) {
+	tests := []struct {
+		SF          int
+		Bandwidth   int
+		Sensitivity int
+		Error       bool
+	}{
+		{SF: 7, Bandwidth: 125000, Sensitivity: -125},
+		{SF: 12, Bandwidth: 125000, Sensitivity: -137},
+		{SF: 12, Bandwidth: 500000, Sensitivity: -131},
+		{SF: 13, Bandwidth: 125000, Error: true},
+	}
+
+	for _, tst := range tests {
+		t.Run(fmt.Sprintf("SF%d BW%d", tst.SF, tst.Bandwidth), func(t *testing.T) {
+			assert := require.New(t)
+
+			s, err := CalculateLoRaSensitivity(tst.SF, tst.Bandwidth, 6)
+			if tst.Error {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tst.Sensitivity, int(math.Round(float64(s))))
+		})
+	}
+}
+
+func TestCalculateMaxCouplingLoss(t *testing.T) {
+	assert := require.New(t)
+
+	lb := CalculateLinkBudget(125000, 6, -20, 14)
+	assert.InDelta(lb+2+3-1, CalculateMaxCouplingLoss(lb, 2, 3, 1), 0.0001)
+}
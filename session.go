@@ -0,0 +1,215 @@
+package lorawan
+
+import "encoding/binary"
+
+// sessionContextVersion defines the version of the SessionContext binary
+// encoding.
+const sessionContextVersion = 1
+
+// sessionContextSize defines the size of the SessionContext binary
+// encoding: version, MACVersion, DevAddr, 4 keys and 4 frame-counters.
+const sessionContextSize = 1 + 1 + 4 + 4*16 + 4*4
+
+// SessionContext holds the session keys, frame-counters and MAC version of
+// an activated device session. It can be used as an alternative to passing
+// the individual keys and counters to the PHYPayload MIC and encryption
+// methods, as it selects the correct key and frame-counter based on the
+// MAC version, direction and FPort of the frame.
+//
+// For LoRaWAN 1.0 sessions, use SetNwkSKey to set the NwkSKey. In this case
+// the NFCntDown is used as the (single) downlink frame-counter and the
+// AFCntDown is ignored.
+type SessionContext struct {
+	MACVersion MACVersion `json:"macVersion"`
+	DevAddr    DevAddr    `json:"devAddr"`
+
+	// Session keys.
+	FNwkSIntKey AES128Key `json:"fNwkSIntKey"`
+	SNwkSIntKey AES128Key `json:"sNwkSIntKey"`
+	NwkSEncKey  AES128Key `json:"nwkSEncKey"`
+	AppSKey     AES128Key `json:"appSKey"`
+
+	// Frame-counters.
+	FCntUp    uint32 `json:"fCntUp"`
+	NFCntDown uint32 `json:"nFCntDown"`
+	AFCntDown uint32 `json:"aFCntDown"`
+
+	// ConfFCnt holds the frame-counter of the last confirmed frame that
+	// must be acknowledged (LoRaWAN 1.1 only). For uplink this is the
+	// frame-counter of the confirmed downlink and for downlink this is the
+	// frame-counter of the confirmed uplink. It is only used when the ACK
+	// bit is set.
+	ConfFCnt uint32 `json:"confFCnt"`
+
+	// CMACCache is an optional cache used for the data MIC computations.
+	// As the same session keys are used for every frame, caching the CMAC
+	// state avoids re-computing the key schedule and subkeys per frame.
+	// The cache can be shared by multiple session-contexts.
+	CMACCache *CMACCache `json:"-"`
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. This provides a
+// compact, versioned encoding of the session-context which is also used by
+// encoding/gob (and other encoders supporting encoding.BinaryMarshaler, e.g.
+// msgpack), for example to store session-contexts in a cache. The
+// CMACCache is not encoded.
+func (s SessionContext) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, sessionContextSize)
+	b = append(b, sessionContextVersion, byte(s.MACVersion))
+	b = append(b, s.DevAddr[:]...)
+	b = append(b, s.FNwkSIntKey[:]...)
+	b = append(b, s.SNwkSIntKey[:]...)
+	b = append(b, s.NwkSEncKey[:]...)
+	b = append(b, s.AppSKey[:]...)
+
+	for _, fCnt := range []uint32{s.FCntUp, s.NFCntDown, s.AFCntDown, s.ConfFCnt} {
+		var buf [4]byte
+		binary.LittleEndian.PutUint32(buf[:], fCnt)
+		b = append(b, buf[:]...)
+	}
+
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The CMACCache is
+// not modified.
+func (s *SessionContext) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != sessionContextVersion {
+		return newDecodeError("SessionContext", ErrInvalidValue, "lorawan: unsupported session-context encoding version")
+	}
+	if len(data) != sessionContextSize {
+		return newDecodeError("SessionContext", ErrInvalidLength, "lorawan: %d bytes of data are expected", sessionContextSize)
+	}
+
+	s.MACVersion = MACVersion(data[1])
+	data = data[2:]
+
+	data = data[copy(s.DevAddr[:], data):]
+	data = data[copy(s.FNwkSIntKey[:], data):]
+	data = data[copy(s.SNwkSIntKey[:], data):]
+	data = data[copy(s.NwkSEncKey[:], data):]
+	data = data[copy(s.AppSKey[:], data):]
+
+	for _, fCnt := range []*uint32{&s.FCntUp, &s.NFCntDown, &s.AFCntDown, &s.ConfFCnt} {
+		*fCnt = binary.LittleEndian.Uint32(data[:4])
+		data = data[4:]
+	}
+
+	return nil
+}
+
+// SetNwkSKey sets the LoRaWAN 1.0 NwkSKey. In LoRaWAN 1.0 the
+// FNwkSIntKey, SNwkSIntKey and NwkSEncKey are all equal to the NwkSKey.
+func (s *SessionContext) SetNwkSKey(nwkSKey AES128Key) {
+	s.FNwkSIntKey = nwkSKey
+	s.SNwkSIntKey = nwkSKey
+	s.NwkSEncKey = nwkSKey
+}
+
+// GetFCntDown returns the downlink frame-counter to use for the given FPort.
+// For LoRaWAN 1.1 the AFCntDown is used when FPort > 0, else the NFCntDown
+// is returned.
+func (s SessionContext) GetFCntDown(fPort *uint8) uint32 {
+	if s.MACVersion != LoRaWAN1_0 && fPort != nil && *fPort > 0 {
+		return s.AFCntDown
+	}
+	return s.NFCntDown
+}
+
+// SetUplinkDataMIC calculates and sets the MIC of the given uplink data frame.
+func (s SessionContext) SetUplinkDataMIC(p *PHYPayload, txDR, txCh uint8) error {
+	mic, err := p.calculateUplinkDataMIC(s.CMACCache, s.MACVersion, s.ConfFCnt, txDR, txCh, s.FNwkSIntKey, s.SNwkSIntKey)
+	if err != nil {
+		return err
+	}
+	p.MIC = mic
+	return nil
+}
+
+// ValidateUplinkDataMIC validates the MIC of the given uplink data frame.
+// In order to validate the MIC, the FCnt value must first be set to the
+// full 32 bit frame-counter value, as only the 16 least-significant bits
+// are transmitted.
+func (s SessionContext) ValidateUplinkDataMIC(p PHYPayload, txDR, txCh uint8) (bool, error) {
+	mic, err := p.calculateUplinkDataMIC(s.CMACCache, s.MACVersion, s.ConfFCnt, txDR, txCh, s.FNwkSIntKey, s.SNwkSIntKey)
+	if err != nil {
+		return false, err
+	}
+	return p.MIC == mic, nil
+}
+
+// SetDownlinkDataMIC calculates and sets the MIC of the given downlink
+// data frame.
+func (s SessionContext) SetDownlinkDataMIC(p *PHYPayload) error {
+	mic, err := p.calculateDownlinkDataMIC(s.CMACCache, s.MACVersion, s.ConfFCnt, s.SNwkSIntKey)
+	if err != nil {
+		return err
+	}
+	p.MIC = mic
+	return nil
+}
+
+// ValidateDownlinkDataMIC validates the MIC of the given downlink data frame.
+// In order to validate the MIC, the FCnt value must first be set to the
+// full 32 bit frame-counter value, as only the 16 least-significant bits
+// are transmitted.
+func (s SessionContext) ValidateDownlinkDataMIC(p PHYPayload) (bool, error) {
+	mic, err := p.calculateDownlinkDataMIC(s.CMACCache, s.MACVersion, s.ConfFCnt, s.SNwkSIntKey)
+	if err != nil {
+		return false, err
+	}
+	return p.MIC == mic, nil
+}
+
+// EncryptFOpts encrypts the FOpts of the given frame. As FOpts encryption
+// was introduced in LoRaWAN 1.1, this is a no-op for LoRaWAN 1.0 sessions.
+func (s SessionContext) EncryptFOpts(p *PHYPayload) error {
+	if s.MACVersion == LoRaWAN1_0 {
+		return nil
+	}
+	return p.EncryptFOpts(s.NwkSEncKey)
+}
+
+// DecryptFOpts decrypts the FOpts of the given frame and decodes these
+// into mac-commands. For LoRaWAN 1.0 sessions the FOpts are only decoded.
+func (s SessionContext) DecryptFOpts(p *PHYPayload) error {
+	if s.MACVersion != LoRaWAN1_0 {
+		if err := p.EncryptFOpts(s.NwkSEncKey); err != nil {
+			return err
+		}
+	}
+	return p.DecodeFOptsToMACCommands()
+}
+
+// EncryptFRMPayload encrypts the FRMPayload of the given frame. When
+// FPort=0, the NwkSEncKey is used, else the AppSKey.
+func (s SessionContext) EncryptFRMPayload(p *PHYPayload) error {
+	key, err := s.getFRMPayloadKey(p)
+	if err != nil {
+		return err
+	}
+	return p.EncryptFRMPayload(key)
+}
+
+// DecryptFRMPayload decrypts the FRMPayload of the given frame. When
+// FPort=0, the NwkSEncKey is used and the FRMPayload is decoded into
+// mac-commands, else the AppSKey is used.
+func (s SessionContext) DecryptFRMPayload(p *PHYPayload) error {
+	key, err := s.getFRMPayloadKey(p)
+	if err != nil {
+		return err
+	}
+	return p.DecryptFRMPayload(key)
+}
+
+func (s SessionContext) getFRMPayloadKey(p *PHYPayload) (AES128Key, error) {
+	macPL, ok := p.MACPayload.(*MACPayload)
+	if !ok {
+		return AES128Key{}, newError(ErrInvalidPayloadType, "lorawan: MACPayload must be of type *MACPayload")
+	}
+
+	if macPL.FPort != nil && *macPL.FPort == 0 {
+		return s.NwkSEncKey, nil
+	}
+	return s.AppSKey, nil
+}
@@ -0,0 +1,161 @@
+package lorawan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionContextGetFCntDown(t *testing.T) {
+	assert := require.New(t)
+
+	var fPort0 uint8
+	var fPort1 uint8 = 1
+
+	tests := []struct {
+		MACVersion   MACVersion
+		FPort        *uint8
+		ExpectedFCnt uint32
+	}{
+		{LoRaWAN1_0, nil, 10},
+		{LoRaWAN1_0, &fPort0, 10},
+		{LoRaWAN1_0, &fPort1, 10},
+		{LoRaWAN1_1, nil, 10},
+		{LoRaWAN1_1, &fPort0, 10},
+		{LoRaWAN1_1, &fPort1, 20},
+	}
+
+	for _, tst := range tests {
+		s := SessionContext{
+			MACVersion: tst.MACVersion,
+			NFCntDown:  10,
+			AFCntDown:  20,
+		}
+		assert.Equal(tst.ExpectedFCnt, s.GetFCntDown(tst.FPort))
+	}
+}
+
+func TestSessionContextSetNwkSKey(t *testing.T) {
+	assert := require.New(t)
+
+	key := AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8}
+
+	var s SessionContext
+	s.SetNwkSKey(key)
+	assert.Equal(key, s.FNwkSIntKey)
+	assert.Equal(key, s.SNwkSIntKey)
+	assert.Equal(key, s.NwkSEncKey)
+}
+
+func TestSessionContextUplinkLoRaWAN11(t *testing.T) {
+	assert := require.New(t)
+
+	var fPort1 uint8 = 1
+	s := SessionContext{
+		MACVersion:  LoRaWAN1_1,
+		DevAddr:     DevAddr{1, 2, 3, 4},
+		SNwkSIntKey: AES128Key{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2},
+		FNwkSIntKey: AES128Key{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 3},
+		NwkSEncKey:  AES128Key{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 4},
+		AppSKey:     AES128Key{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+		FCntUp:      1,
+		ConfFCnt:    1,
+	}
+	expBytes := []byte{64, 4, 3, 2, 1, 160, 1, 0, 1, 166, 148, 100, 38, 21, 248, 66, 196, 185}
+
+	phy := PHYPayload{
+		MHDR: MHDR{
+			MType: UnconfirmedDataUp,
+			Major: LoRaWANR1,
+		},
+		MACPayload: &MACPayload{
+			FHDR: FHDR{
+				DevAddr: s.DevAddr,
+				FCtrl: FCtrl{
+					ADR: true,
+					ACK: true,
+				},
+				FCnt: s.FCntUp,
+			},
+			FPort: &fPort1,
+			FRMPayload: []Payload{
+				&DataPayload{Bytes: []byte("hello")},
+			},
+		},
+	}
+
+	assert.NoError(s.EncryptFOpts(&phy))
+	assert.NoError(s.EncryptFRMPayload(&phy))
+	assert.NoError(s.SetUplinkDataMIC(&phy, 2, 3))
+
+	b, err := phy.MarshalBinary()
+	assert.NoError(err)
+	assert.Equal(expBytes, b)
+
+	var rx PHYPayload
+	assert.NoError(rx.UnmarshalBinary(expBytes))
+
+	ok, err := s.ValidateUplinkDataMIC(rx, 2, 3)
+	assert.NoError(err)
+	assert.True(ok)
+
+	ok, err = s.ValidateUplinkDataMIC(rx, 2, 4)
+	assert.NoError(err)
+	assert.False(ok)
+
+	assert.NoError(s.DecryptFOpts(&rx))
+	assert.NoError(s.DecryptFRMPayload(&rx))
+	assert.Equal([]Payload{&DataPayload{Bytes: []byte("hello")}}, rx.MACPayload.(*MACPayload).FRMPayload)
+}
+
+func TestSessionContextDownlinkLoRaWAN10(t *testing.T) {
+	assert := require.New(t)
+
+	var fPort0 uint8
+	s := SessionContext{
+		MACVersion: LoRaWAN1_0,
+		DevAddr:    DevAddr{1, 2, 3, 4},
+		AppSKey:    AES128Key{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+		NFCntDown:  5,
+	}
+	s.SetNwkSKey(AES128Key{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2})
+
+	macCommands := []Payload{
+		&MACCommand{
+			CID: DevStatusReq,
+		},
+	}
+
+	phy := PHYPayload{
+		MHDR: MHDR{
+			MType: UnconfirmedDataDown,
+			Major: LoRaWANR1,
+		},
+		MACPayload: &MACPayload{
+			FHDR: FHDR{
+				DevAddr: s.DevAddr,
+				FCnt:    s.GetFCntDown(&fPort0),
+			},
+			FPort:      &fPort0,
+			FRMPayload: macCommands,
+		},
+	}
+
+	assert.NoError(s.EncryptFOpts(&phy))
+	assert.NoError(s.EncryptFRMPayload(&phy))
+	assert.NoError(s.SetDownlinkDataMIC(&phy))
+
+	b, err := phy.MarshalBinary()
+	assert.NoError(err)
+
+	var rx PHYPayload
+	assert.NoError(rx.UnmarshalBinary(b))
+
+	ok, err := s.ValidateDownlinkDataMIC(rx)
+	assert.NoError(err)
+	assert.True(ok)
+
+	assert.NoError(s.DecryptFOpts(&rx))
+	assert.NoError(s.DecryptFRMPayload(&rx))
+	assert.Equal(macCommands, rx.MACPayload.(*MACPayload).FRMPayload)
+}
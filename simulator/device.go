@@ -0,0 +1,640 @@
+package simulator
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
+	"github.com/brocaar/lorawan/harness"
+)
+
+// maxFOptsLen defines the max. number of FOpts bytes.
+const maxFOptsLen = 15
+
+// Errors.
+var (
+	ErrNotActivated     = errors.New("lorawan/simulator: device is not activated")
+	ErrNoReceiveWindow  = errors.New("lorawan/simulator: no receive window is open")
+	ErrPayloadTooLarge  = errors.New("lorawan/simulator: payload exceeds max. payload size")
+	ErrNoUplinkChannels = errors.New("lorawan/simulator: no uplink channel available for data-rate")
+)
+
+// Class defines the device class.
+type Class int
+
+// Available device classes.
+const (
+	ClassA Class = iota
+	ClassB
+	ClassC
+)
+
+// String implements fmt.Stringer.
+func (c Class) String() string {
+	return [...]string{"A", "B", "C"}[c]
+}
+
+// Uplink holds an uplink frame together with its TX parameters.
+type Uplink struct {
+	// PHYPayload holds the frame.
+	PHYPayload []byte
+
+	// Channel holds the uplink channel index.
+	Channel int
+
+	// Frequency holds the frequency (Hz).
+	Frequency int
+
+	// DR holds the data-rate index.
+	DR int
+
+	// TXPower holds the TX power index.
+	TXPower int
+
+	// Time holds the TX timestamp.
+	Time time.Time
+}
+
+// Downlink holds a received (decrypted) downlink frame.
+type Downlink struct {
+	FCnt        uint32
+	FPort       *uint8
+	Data        []byte
+	Confirmed   bool
+	ACK         bool
+	MACCommands []lorawan.MACCommand
+}
+
+// DeviceConfig holds the Device configuration.
+type DeviceConfig struct {
+	// Band holds the regional band configuration, used for the channels
+	// and data-rates. As the Band holds state, the device only uses it to
+	// look up channels and data-rates. It can be shared by devices.
+	Band band.Band
+
+	// Class holds the device class.
+	Class Class
+
+	// OTAA parameters. For LoRaWAN 1.0.x, the NwkKey holds the AppKey and
+	// the AppKey is ignored.
+	DevEUI  lorawan.EUI64
+	JoinEUI lorawan.EUI64
+	NwkKey  lorawan.AES128Key
+	AppKey  lorawan.AES128Key
+
+	// Session holds the session-context of an ABP activated device. When
+	// set, the device does not need to join.
+	Session *lorawan.SessionContext
+
+	// DR holds the initial uplink data-rate index.
+	DR int
+
+	// Battery and Margin hold the values returned in the DevStatusAns.
+	Battery uint8
+	Margin  int8
+
+	// Rand holds the optional random source, used for the channel
+	// selection. When nil, a source seeded by the DevEUI is used, making
+	// the channel selection reproducible.
+	Rand *rand.Rand
+
+	// Clock holds the optional function returning the current time. When
+	// nil, time.Now is used.
+	Clock func() time.Time
+}
+
+// Device simulates an end-device. It generates valid (encrypted and
+// signed) uplinks on the enabled channels of the regional band, handles the
+// downlinks and answers the received mac-commands in the FOpts of the next
+// uplink.
+//
+// Receive windows are modelled, but not timed: a Class-A device only
+// accepts a single downlink after each uplink. Class-B ping-slots are not
+// simulated, a Class-B device accepts downlinks at any time (like
+// Class-C), but sets the ClassB bit in its uplinks.
+//
+// The Device is safe for concurrent use.
+type Device struct {
+	config DeviceConfig
+
+	mu       sync.Mutex
+	otaa     *harness.Device
+	session  *lorawan.SessionContext
+	dr       int
+	txPower  int
+	channels map[int]band.Channel
+
+	rxOpen     bool
+	ackPending bool
+	ackFCnt    uint32
+	macAnswers []lorawan.MACCommand
+	stats      Stats
+}
+
+// NewDevice creates a new Device.
+func NewDevice(config DeviceConfig) (*Device, error) {
+	if config.Band == nil {
+		return nil, errors.New("lorawan/simulator: Band must be set")
+	}
+	if config.Rand == nil {
+		config.Rand = rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(config.DevEUI[:]))))
+	}
+	if config.Clock == nil {
+		config.Clock = time.Now
+	}
+
+	d := Device{
+		config: config,
+		otaa: &harness.Device{
+			DevEUI:  config.DevEUI,
+			JoinEUI: config.JoinEUI,
+			NwkKey:  config.NwkKey,
+			AppKey:  config.AppKey,
+		},
+	}
+	d.reset()
+
+	if config.Session != nil {
+		s := *config.Session
+		d.session = &s
+	}
+
+	return &d, nil
+}
+
+// DevEUI returns the DevEUI of the device.
+func (d *Device) DevEUI() lorawan.EUI64 {
+	return d.config.DevEUI
+}
+
+// Activated returns true when the device is activated (ABP or joined).
+func (d *Device) Activated() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.session != nil
+}
+
+// Session returns the session-context of the device.
+func (d *Device) Session() (lorawan.SessionContext, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.session == nil {
+		return lorawan.SessionContext{}, false
+	}
+	return *d.session, true
+}
+
+// DR returns the current uplink data-rate index.
+func (d *Device) DR() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.dr
+}
+
+// EnabledChannels returns the enabled uplink channel indices.
+func (d *Device) EnabledChannels() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.enabledChannels()
+}
+
+// Stats returns the device statistics.
+func (d *Device) Stats() Stats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.stats
+}
+
+// JoinRequest returns a new join-request.
+func (d *Device) JoinRequest() (Uplink, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ch, err := d.selectChannel()
+	if err != nil {
+		return Uplink{}, err
+	}
+
+	b, err := d.otaa.JoinRequest()
+	if err != nil {
+		return Uplink{}, err
+	}
+
+	d.rxOpen = true
+	d.stats.JoinRequests++
+	return d.newUplink(b, ch), nil
+}
+
+// HandleJoinAccept handles the given join-accept and activates the device.
+// This resets the MAC state (data-rate, channels and pending mac-command
+// answers). Note that the CFList is not applied.
+func (d *Device) HandleJoinAccept(b []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.rxOpen {
+		return ErrNoReceiveWindow
+	}
+
+	if err := d.otaa.HandleJoinAccept(b); err != nil {
+		d.stats.Errors++
+		return err
+	}
+
+	s := *d.otaa.Session
+	d.session = &s
+	d.reset()
+	d.stats.JoinAccepts++
+
+	return nil
+}
+
+// Uplink returns a new uplink data frame, containing the given application
+// payload and the pending mac-command answers. When fPort is 0, the
+// application payload is ignored.
+func (d *Device) Uplink(fPort uint8, data []byte, confirmed bool) (Uplink, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.session == nil {
+		return Uplink{}, ErrNotActivated
+	}
+
+	maxPL, err := d.config.Band.GetMaxPayloadSizeForDataRateIndex("", "", d.dr)
+	if err != nil {
+		return Uplink{}, fmt.Errorf("lorawan/simulator: get max. payload size error: %w", err)
+	}
+	if len(data) > maxPL.N {
+		return Uplink{}, ErrPayloadTooLarge
+	}
+
+	ch, err := d.selectChannel()
+	if err != nil {
+		return Uplink{}, err
+	}
+
+	mType := lorawan.UnconfirmedDataUp
+	if confirmed {
+		mType = lorawan.ConfirmedDataUp
+	}
+
+	macPL := lorawan.MACPayload{
+		FHDR: lorawan.FHDR{
+			DevAddr: d.session.DevAddr,
+			FCtrl: lorawan.FCtrl{
+				ADR:    true,
+				ACK:    d.ackPending,
+				ClassB: d.config.Class == ClassB,
+			},
+			FCnt: d.session.FCntUp,
+		},
+	}
+
+	var size int
+	for i := range d.macAnswers {
+		b, err := d.macAnswers[i].MarshalBinary()
+		if err != nil {
+			return Uplink{}, fmt.Errorf("lorawan/simulator: marshal mac-command error: %w", err)
+		}
+		if size+len(b) > maxFOptsLen {
+			break
+		}
+		size += len(b)
+		macPL.FHDR.FOpts = append(macPL.FHDR.FOpts, &d.macAnswers[i])
+	}
+
+	if fPort != 0 {
+		macPL.FPort = &fPort
+		macPL.FRMPayload = []lorawan.Payload{&lorawan.DataPayload{Bytes: data}}
+	}
+
+	phy := lorawan.PHYPayload{
+		MHDR: lorawan.MHDR{
+			MType: mType,
+			Major: lorawan.LoRaWANR1,
+		},
+		MACPayload: &macPL,
+	}
+
+	if err := d.session.EncryptFOpts(&phy); err != nil {
+		return Uplink{}, fmt.Errorf("lorawan/simulator: encrypt fopts error: %w", err)
+	}
+	if macPL.FPort != nil {
+		if err := d.session.EncryptFRMPayload(&phy); err != nil {
+			return Uplink{}, fmt.Errorf("lorawan/simulator: encrypt frmpayload error: %w", err)
+		}
+	}
+	if d.ackPending {
+		d.session.ConfFCnt = d.ackFCnt
+	}
+	if err := d.session.SetUplinkDataMIC(&phy, uint8(d.dr), uint8(ch)); err != nil {
+		return Uplink{}, fmt.Errorf("lorawan/simulator: set uplink mic error: %w", err)
+	}
+
+	b, err := phy.MarshalBinary()
+	if err != nil {
+		return Uplink{}, fmt.Errorf("lorawan/simulator: marshal uplink error: %w", err)
+	}
+
+	d.macAnswers = d.macAnswers[len(macPL.FHDR.FOpts):]
+	d.ackPending = false
+	d.rxOpen = true
+	d.session.FCntUp++
+	d.stats.Uplinks++
+
+	return d.newUplink(b, ch), nil
+}
+
+// HandleDownlink validates and decrypts the given downlink data frame and
+// handles the mac-commands it contains. The answers are sent with the next
+// uplink.
+func (d *Device) HandleDownlink(b []byte) (Downlink, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dl, err := d.handleDownlink(b)
+	if err != nil {
+		d.stats.Errors++
+		return dl, err
+	}
+
+	d.stats.Downlinks++
+	d.stats.MACCommands += len(dl.MACCommands)
+	return dl, nil
+}
+
+func (d *Device) handleDownlink(b []byte) (Downlink, error) {
+	if d.session == nil {
+		return Downlink{}, ErrNotActivated
+	}
+	if d.config.Class == ClassA && !d.rxOpen {
+		return Downlink{}, ErrNoReceiveWindow
+	}
+
+	var phy lorawan.PHYPayload
+	if err := phy.UnmarshalBinary(b); err != nil {
+		return Downlink{}, fmt.Errorf("lorawan/simulator: unmarshal downlink error: %w", err)
+	}
+	if phy.MHDR.MType != lorawan.UnconfirmedDataDown && phy.MHDR.MType != lorawan.ConfirmedDataDown {
+		return Downlink{}, fmt.Errorf("lorawan/simulator: unexpected downlink MType: %s", phy.MHDR.MType)
+	}
+	macPL, ok := phy.MACPayload.(*lorawan.MACPayload)
+	if !ok {
+		return Downlink{}, fmt.Errorf("lorawan/simulator: expected *lorawan.MACPayload, got %T", phy.MACPayload)
+	}
+	if macPL.FHDR.DevAddr != d.session.DevAddr {
+		return Downlink{}, fmt.Errorf("lorawan/simulator: unexpected DevAddr: %s", macPL.FHDR.DevAddr)
+	}
+
+	// the ACK of a confirmed uplink is included in the LoRaWAN 1.1 MIC
+	if macPL.FHDR.FCtrl.ACK && d.session.FCntUp > 0 {
+		d.session.ConfFCnt = d.session.FCntUp - 1
+	}
+
+	expected := d.session.GetFCntDown(macPL.FPort)
+	fCnt := expected&0xffff0000 | macPL.FHDR.FCnt&0xffff
+	if fCnt < expected {
+		fCnt += 1 << 16
+	}
+	macPL.FHDR.FCnt = fCnt
+
+	ok, err := d.session.ValidateDownlinkDataMIC(phy)
+	if err != nil {
+		return Downlink{}, fmt.Errorf("lorawan/simulator: validate downlink mic error: %w", err)
+	}
+	if !ok {
+		return Downlink{}, errors.New("lorawan/simulator: invalid downlink mic")
+	}
+
+	if err := d.session.DecryptFOpts(&phy); err != nil {
+		return Downlink{}, fmt.Errorf("lorawan/simulator: decrypt fopts error: %w", err)
+	}
+	if macPL.FPort != nil {
+		if err := d.session.DecryptFRMPayload(&phy); err != nil {
+			return Downlink{}, fmt.Errorf("lorawan/simulator: decrypt frmpayload error: %w", err)
+		}
+	}
+
+	if d.session.MACVersion != lorawan.LoRaWAN1_0 && macPL.FPort != nil && *macPL.FPort > 0 {
+		d.session.AFCntDown = fCnt + 1
+	} else {
+		d.session.NFCntDown = fCnt + 1
+	}
+
+	dl := Downlink{
+		FCnt:      fCnt,
+		FPort:     macPL.FPort,
+		Confirmed: phy.MHDR.MType == lorawan.ConfirmedDataDown,
+		ACK:       macPL.FHDR.FCtrl.ACK,
+	}
+
+	payloads := macPL.FHDR.FOpts
+	if macPL.FPort != nil && *macPL.FPort == 0 {
+		payloads = macPL.FRMPayload
+	} else if len(macPL.FRMPayload) == 1 {
+		if pl, ok := macPL.FRMPayload[0].(*lorawan.DataPayload); ok {
+			dl.Data = pl.Bytes
+		}
+	}
+	for _, pl := range payloads {
+		if cmd, ok := pl.(*lorawan.MACCommand); ok {
+			dl.MACCommands = append(dl.MACCommands, *cmd)
+			d.handleMACCommand(*cmd)
+		}
+	}
+
+	d.ackPending = dl.Confirmed
+	d.ackFCnt = fCnt
+	if d.config.Class == ClassA {
+		d.rxOpen = false
+	}
+
+	return dl, nil
+}
+
+// handleMACCommand applies the given mac-command and queues its answer.
+func (d *Device) handleMACCommand(cmd lorawan.MACCommand) {
+	var ans *lorawan.MACCommand
+
+	switch cmd.CID {
+	case lorawan.LinkADRReq:
+		pl, ok := cmd.Payload.(*lorawan.LinkADRReqPayload)
+		if !ok {
+			return
+		}
+		ans = &lorawan.MACCommand{CID: lorawan.LinkADRAns, Payload: d.handleLinkADRReq(*pl)}
+	case lorawan.DevStatusReq:
+		ans = &lorawan.MACCommand{CID: lorawan.DevStatusAns, Payload: &lorawan.DevStatusAnsPayload{
+			Battery: d.config.Battery,
+			Margin:  d.config.Margin,
+		}}
+	case lorawan.DutyCycleReq:
+		ans = &lorawan.MACCommand{CID: lorawan.DutyCycleAns}
+	case lorawan.RXParamSetupReq:
+		ans = &lorawan.MACCommand{CID: lorawan.RXParamSetupAns, Payload: &lorawan.RXParamSetupAnsPayload{
+			ChannelACK:     true,
+			RX2DataRateACK: true,
+			RX1DROffsetACK: true,
+		}}
+	case lorawan.RXTimingSetupReq:
+		ans = &lorawan.MACCommand{CID: lorawan.RXTimingSetupAns}
+	case lorawan.TXParamSetupReq:
+		ans = &lorawan.MACCommand{CID: lorawan.TXParamSetupAns}
+	case lorawan.NewChannelReq:
+		pl, ok := cmd.Payload.(*lorawan.NewChannelReqPayload)
+		if !ok {
+			return
+		}
+		ans = &lorawan.MACCommand{CID: lorawan.NewChannelAns, Payload: d.handleNewChannelReq(*pl)}
+	case lorawan.DLChannelReq:
+		ans = &lorawan.MACCommand{CID: lorawan.DLChannelAns, Payload: &lorawan.DLChannelAnsPayload{
+			UplinkFrequencyExists: true,
+			ChannelFrequencyOK:    true,
+		}}
+	case lorawan.PingSlotChannelReq:
+		ans = &lorawan.MACCommand{CID: lorawan.PingSlotChannelAns, Payload: &lorawan.PingSlotChannelAnsPayload{
+			DataRateOK:         true,
+			ChannelFrequencyOK: true,
+		}}
+	case lorawan.ADRParamSetupReq:
+		ans = &lorawan.MACCommand{CID: lorawan.ADRParamSetupAns}
+	case lorawan.RejoinParamSetupReq:
+		ans = &lorawan.MACCommand{CID: lorawan.RejoinParamSetupAns, Payload: &lorawan.RejoinParamSetupAnsPayload{}}
+	default:
+		// answers (e.g. LinkCheckAns, DeviceTimeAns) and confirmations do
+		// not require an answer
+		return
+	}
+
+	d.macAnswers = append(d.macAnswers, *ans)
+}
+
+func (d *Device) handleLinkADRReq(pl lorawan.LinkADRReqPayload) *lorawan.LinkADRAnsPayload {
+	var ans lorawan.LinkADRAnsPayload
+
+	enabled, err := d.config.Band.GetEnabledUplinkChannelIndicesForLinkADRReqPayloads(d.enabledChannels(), []lorawan.LinkADRReqPayload{pl})
+	ans.ChannelMaskACK = err == nil && len(enabled) != 0
+
+	// 0xF means: keep the current value
+	_, err = d.config.Band.GetDataRate(int(pl.DataRate))
+	ans.DataRateACK = pl.DataRate == 0x0f || err == nil
+
+	_, err = d.config.Band.GetTXPowerOffset(int(pl.TXPower))
+	ans.PowerACK = pl.TXPower == 0x0f || err == nil
+
+	// the command is only applied when all parameters are valid
+	if !ans.ChannelMaskACK || !ans.DataRateACK || !ans.PowerACK {
+		return &ans
+	}
+
+	channels := make(map[int]band.Channel)
+	for _, i := range enabled {
+		if c, ok := d.channels[i]; ok {
+			channels[i] = c
+		} else if c, err := d.config.Band.GetUplinkChannel(i); err == nil {
+			channels[i] = c
+		}
+	}
+	d.channels = channels
+
+	if pl.DataRate != 0x0f {
+		d.dr = int(pl.DataRate)
+	}
+	if pl.TXPower != 0x0f {
+		d.txPower = int(pl.TXPower)
+	}
+
+	return &ans
+}
+
+func (d *Device) handleNewChannelReq(pl lorawan.NewChannelReqPayload) *lorawan.NewChannelAnsPayload {
+	ans := lorawan.NewChannelAnsPayload{
+		ChannelFrequencyOK: true,
+		DataRateRangeOK:    pl.MinDR <= pl.MaxDR,
+	}
+	if !ans.DataRateRangeOK {
+		return &ans
+	}
+
+	// a frequency of 0 disables the channel
+	if pl.Freq == 0 {
+		delete(d.channels, int(pl.ChIndex))
+	} else {
+		d.channels[int(pl.ChIndex)] = band.Channel{
+			Frequency: int(pl.Freq),
+			MinDR:     int(pl.MinDR),
+			MaxDR:     int(pl.MaxDR),
+		}
+	}
+
+	return &ans
+}
+
+// reset resets the MAC state to the band defaults.
+func (d *Device) reset() {
+	d.dr = d.config.DR
+	d.txPower = 0
+	d.ackPending = false
+	d.macAnswers = nil
+	d.channels = make(map[int]band.Channel)
+
+	for _, i := range d.config.Band.GetStandardUplinkChannelIndices() {
+		if c, err := d.config.Band.GetUplinkChannel(i); err == nil {
+			d.channels[i] = c
+		}
+	}
+}
+
+func (d *Device) enabledChannels() []int {
+	var out []int
+	for i := range d.channels {
+		out = append(out, i)
+	}
+	sort.Ints(out)
+	return out
+}
+
+// selectChannel returns a random enabled channel supporting the current
+// data-rate.
+func (d *Device) selectChannel() (int, error) {
+	var candidates []int
+	for _, i := range d.enabledChannels() {
+		c := d.channels[i]
+		if d.dr >= c.MinDR && d.dr <= c.MaxDR {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return 0, ErrNoUplinkChannels
+	}
+	return candidates[d.config.Rand.Intn(len(candidates))], nil
+}
+
+func (d *Device) newUplink(b []byte, ch int) Uplink {
+	return Uplink{
+		PHYPayload: b,
+		Channel:    ch,
+		Frequency:  d.channels[ch].Frequency,
+		DR:         d.dr,
+		TXPower:    d.txPower,
+		Time:       d.config.Clock(),
+	}
+}
+
+func (d *Device) recordError() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.stats.Errors++
+}
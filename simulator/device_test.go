@@ -0,0 +1,248 @@
+package simulator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
+)
+
+func testBand(t *testing.T) band.Band {
+	b, err := band.GetConfig(band.EU868, false, lorawan.DwellTimeNoLimit)
+	require.NoError(t, err)
+	return b
+}
+
+func testSession(macVersion lorawan.MACVersion) lorawan.SessionContext {
+	return lorawan.SessionContext{
+		MACVersion:  macVersion,
+		DevAddr:     lorawan.DevAddr{1, 2, 3, 4},
+		FNwkSIntKey: lorawan.AES128Key{1},
+		SNwkSIntKey: lorawan.AES128Key{2},
+		NwkSEncKey:  lorawan.AES128Key{3},
+		AppSKey:     lorawan.AES128Key{4},
+	}
+}
+
+// nsUplink validates and decrypts the given uplink on the network-server
+// side.
+func nsUplink(t *testing.T, s *lorawan.SessionContext, up Uplink) (lorawan.PHYPayload, *lorawan.MACPayload) {
+	assert := require.New(t)
+
+	var phy lorawan.PHYPayload
+	assert.NoError(phy.UnmarshalBinary(up.PHYPayload))
+	macPL := phy.MACPayload.(*lorawan.MACPayload)
+	macPL.FHDR.FCnt = s.FCntUp
+
+	ok, err := s.ValidateUplinkDataMIC(phy, uint8(up.DR), uint8(up.Channel))
+	assert.NoError(err)
+	assert.True(ok)
+	assert.NoError(s.DecryptFOpts(&phy))
+	assert.NoError(s.DecryptFRMPayload(&phy))
+	s.FCntUp++
+
+	return phy, macPL
+}
+
+// nsDownlink returns a downlink with the given mac-commands in the FOpts.
+func nsDownlink(t *testing.T, s *lorawan.SessionContext, confirmed, ack bool, cmds ...lorawan.MACCommand) []byte {
+	assert := require.New(t)
+
+	mType := lorawan.UnconfirmedDataDown
+	if confirmed {
+		mType = lorawan.ConfirmedDataDown
+	}
+
+	macPL := lorawan.MACPayload{
+		FHDR: lorawan.FHDR{
+			DevAddr: s.DevAddr,
+			FCtrl:   lorawan.FCtrl{ACK: ack},
+			FCnt:    s.NFCntDown,
+		},
+	}
+	for i := range cmds {
+		macPL.FHDR.FOpts = append(macPL.FHDR.FOpts, &cmds[i])
+	}
+
+	phy := lorawan.PHYPayload{
+		MHDR:       lorawan.MHDR{MType: mType, Major: lorawan.LoRaWANR1},
+		MACPayload: &macPL,
+	}
+	if ack {
+		s.ConfFCnt = s.FCntUp - 1
+	}
+	assert.NoError(s.EncryptFOpts(&phy))
+	assert.NoError(s.SetDownlinkDataMIC(&phy))
+	s.NFCntDown++
+
+	b, err := phy.MarshalBinary()
+	assert.NoError(err)
+	return b
+}
+
+func TestDeviceMACCommands(t *testing.T) {
+	tests := []struct {
+		name       string
+		macVersion lorawan.MACVersion
+	}{
+		{"LoRaWAN 1.0", lorawan.LoRaWAN1_0},
+		{"LoRaWAN 1.1", lorawan.LoRaWAN1_1},
+	}
+
+	for _, tst := range tests {
+		macVersion := tst.macVersion
+		t.Run(tst.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			ns := testSession(macVersion)
+			d, err := NewDevice(DeviceConfig{
+				Band:    testBand(t),
+				DevEUI:  lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+				Session: &ns,
+				Battery: 100,
+				Margin:  5,
+			})
+			assert.NoError(err)
+			assert.True(d.Activated())
+			assert.Equal([]int{0, 1, 2}, d.EnabledChannels())
+
+			up, err := d.Uplink(10, []byte{1, 2, 3}, true)
+			assert.NoError(err)
+			assert.Equal(0, up.DR)
+			assert.Contains([]int{868100000, 868300000, 868500000}, up.Frequency)
+
+			phy, macPL := nsUplink(t, &ns, up)
+			assert.Equal(lorawan.ConfirmedDataUp, phy.MHDR.MType)
+			assert.Equal([]lorawan.Payload{&lorawan.DataPayload{Bytes: []byte{1, 2, 3}}}, macPL.FRMPayload)
+
+			dl, err := d.HandleDownlink(nsDownlink(t, &ns, true, true,
+				lorawan.MACCommand{CID: lorawan.LinkADRReq, Payload: &lorawan.LinkADRReqPayload{
+					DataRate:   5,
+					TXPower:    1,
+					ChMask:     lorawan.ChMask{true, true},
+					Redundancy: lorawan.Redundancy{NbRep: 1},
+				}},
+				lorawan.MACCommand{CID: lorawan.DevStatusReq},
+			))
+			assert.NoError(err)
+			assert.True(dl.ACK)
+			assert.True(dl.Confirmed)
+			assert.Len(dl.MACCommands, 2)
+			assert.Equal(5, d.DR())
+			assert.Equal([]int{0, 1}, d.EnabledChannels())
+
+			// the answers are sent in the next uplink, which acknowledges
+			// the confirmed downlink
+			up, err = d.Uplink(10, nil, false)
+			assert.NoError(err)
+			assert.Equal(5, up.DR)
+			assert.Equal(1, up.TXPower)
+
+			// LoRaWAN 1.1: the MIC includes the FCnt of the acknowledged
+			// downlink
+			ns.ConfFCnt = 0
+			_, macPL = nsUplink(t, &ns, up)
+			assert.True(macPL.FHDR.FCtrl.ACK)
+			assert.Equal([]lorawan.Payload{
+				&lorawan.MACCommand{CID: lorawan.LinkADRAns, Payload: &lorawan.LinkADRAnsPayload{
+					ChannelMaskACK: true,
+					DataRateACK:    true,
+					PowerACK:       true,
+				}},
+				&lorawan.MACCommand{CID: lorawan.DevStatusAns, Payload: &lorawan.DevStatusAnsPayload{
+					Battery: 100,
+					Margin:  5,
+				}},
+			}, macPL.FHDR.FOpts)
+
+			assert.Equal(Stats{Uplinks: 2, Downlinks: 1, MACCommands: 2}, d.Stats())
+		})
+	}
+}
+
+func TestDeviceLinkADRReqRejected(t *testing.T) {
+	assert := require.New(t)
+
+	ns := testSession(lorawan.LoRaWAN1_0)
+	d, err := NewDevice(DeviceConfig{
+		Band:    testBand(t),
+		Session: &ns,
+	})
+	assert.NoError(err)
+
+	_, err = d.Uplink(10, nil, false)
+	assert.NoError(err)
+
+	// all channels disabled
+	_, err = d.HandleDownlink(nsDownlink(t, &ns, false, false,
+		lorawan.MACCommand{CID: lorawan.LinkADRReq, Payload: &lorawan.LinkADRReqPayload{
+			DataRate: 3,
+		}},
+	))
+	assert.NoError(err)
+	assert.Equal(0, d.DR())
+	assert.Equal([]int{0, 1, 2}, d.EnabledChannels())
+}
+
+func TestDeviceReceiveWindows(t *testing.T) {
+	tests := []struct {
+		class Class
+		err   error
+	}{
+		{ClassA, ErrNoReceiveWindow},
+		{ClassB, nil},
+		{ClassC, nil},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.class.String(), func(t *testing.T) {
+			assert := require.New(t)
+
+			ns := testSession(lorawan.LoRaWAN1_0)
+			d, err := NewDevice(DeviceConfig{
+				Band:    testBand(t),
+				Class:   tst.class,
+				Session: &ns,
+			})
+			assert.NoError(err)
+
+			up, err := d.Uplink(10, nil, false)
+			assert.NoError(err)
+			_, macPL := nsUplink(t, &ns, up)
+			assert.Equal(tst.class == ClassB, macPL.FHDR.FCtrl.ClassB)
+
+			_, err = d.HandleDownlink(nsDownlink(t, &ns, false, false))
+			assert.NoError(err)
+
+			_, err = d.HandleDownlink(nsDownlink(t, &ns, false, false))
+			assert.Equal(tst.err, err)
+		})
+	}
+}
+
+func TestDeviceErrors(t *testing.T) {
+	assert := require.New(t)
+
+	_, err := NewDevice(DeviceConfig{})
+	assert.EqualError(err, "lorawan/simulator: Band must be set")
+
+	d, err := NewDevice(DeviceConfig{Band: testBand(t)})
+	assert.NoError(err)
+	assert.False(d.Activated())
+
+	_, err = d.Uplink(10, nil, false)
+	assert.Equal(ErrNotActivated, err)
+
+	ns := testSession(lorawan.LoRaWAN1_0)
+	d, err = NewDevice(DeviceConfig{Band: testBand(t), Session: &ns})
+	assert.NoError(err)
+
+	// max. 51 bytes at DR0
+	_, err = d.Uplink(10, make([]byte, 52), false)
+	assert.Equal(ErrPayloadTooLarge, err)
+
+	d.HandleDownlink([]byte{})
+	assert.Equal(1, d.Stats().Errors)
+}
@@ -0,0 +1,171 @@
+// Package simulator models LoRaWAN end-devices (OTAA / ABP, Class-A / B / C)
+// for load and conformance testing of network-servers and roaming paths.
+// The devices generate valid uplinks on the channels of the configured
+// regional band, consume the downlinks and answer the received
+// mac-commands, using the frame, session and crypto helpers of this module.
+//
+// Radio aspects (gateways, RSSI / SNR, collisions and receive window
+// timing) are not simulated: the Handler passed to Run receives every
+// uplink and returns the (optional) downlink for the device.
+package simulator
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// UplinkHandler handles the given uplink of the given device, e.g. by
+// forwarding it to the network-server under test. It returns the downlink
+// (PHYPayload) which must be sent to the device in its receive windows, or
+// nil when there is no downlink. Class-B and Class-C downlinks can also be
+// passed to Device.HandleDownlink at any time.
+type UplinkHandler func(ctx context.Context, d *Device, up Uplink) ([]byte, error)
+
+// Stats holds the device statistics.
+type Stats struct {
+	JoinRequests int
+	JoinAccepts  int
+	Uplinks      int
+	Downlinks    int
+	MACCommands  int
+	Errors       int
+}
+
+// Add adds the given stats.
+func (s *Stats) Add(o Stats) {
+	s.JoinRequests += o.JoinRequests
+	s.JoinAccepts += o.JoinAccepts
+	s.Uplinks += o.Uplinks
+	s.Downlinks += o.Downlinks
+	s.MACCommands += o.MACCommands
+	s.Errors += o.Errors
+}
+
+// Config holds the simulation configuration.
+type Config struct {
+	// Devices holds the simulated devices.
+	Devices []*Device
+
+	// Handler handles the uplinks.
+	Handler UplinkHandler
+
+	// Interval defines the uplink interval of each device. The first
+	// uplink of each device is sent at a random offset within the
+	// interval, to spread the load.
+	Interval time.Duration
+
+	// Uplinks defines the number of uplinks sent by each device. When 0,
+	// devices send uplinks until the context is cancelled. Join-requests
+	// are not counted.
+	Uplinks int
+
+	// FPort holds the FPort of the uplinks. When 0, FPort 1 is used.
+	FPort uint8
+
+	// Payload returns the application payload of the next uplink of the
+	// given device. When nil, empty payloads are sent.
+	Payload func(d *Device) []byte
+
+	// Confirmed defines if confirmed uplinks are sent.
+	Confirmed bool
+
+	// Rand holds the optional random source used for the start offsets.
+	// When nil, a time seeded source is used.
+	Rand *rand.Rand
+}
+
+// Run runs the simulation until every device has sent the configured
+// number of uplinks or until the context is cancelled. Devices which are
+// not activated, join first. Errors (e.g. a rejected join or an invalid
+// downlink) are counted in the device stats and do not stop the
+// simulation. It returns the aggregated stats of all devices.
+func Run(ctx context.Context, config Config) (Stats, error) {
+	if config.Handler == nil {
+		return Stats{}, errors.New("lorawan/simulator: Handler must be set")
+	}
+	if config.FPort == 0 {
+		config.FPort = 1
+	}
+	if config.Rand == nil {
+		config.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	var wg sync.WaitGroup
+	for _, d := range config.Devices {
+		var offset time.Duration
+		if config.Interval > 0 {
+			offset = time.Duration(config.Rand.Int63n(int64(config.Interval)))
+		}
+
+		wg.Add(1)
+		go func(d *Device) {
+			defer wg.Done()
+			runDevice(ctx, config, d, offset)
+		}(d)
+	}
+	wg.Wait()
+
+	var stats Stats
+	for _, d := range config.Devices {
+		stats.Add(d.Stats())
+	}
+
+	return stats, nil
+}
+
+func runDevice(ctx context.Context, config Config, d *Device, wait time.Duration) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for uplinks := 0; config.Uplinks == 0 || uplinks < config.Uplinks; {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			timer.Reset(config.Interval)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		if !d.Activated() {
+			up, err := d.JoinRequest()
+			if err != nil {
+				d.recordError()
+				continue
+			}
+
+			if b, err := config.Handler(ctx, d, up); err != nil || b == nil {
+				d.recordError()
+			} else {
+				// the error is counted by the device
+				_ = d.HandleJoinAccept(b)
+			}
+			continue
+		}
+
+		var data []byte
+		if config.Payload != nil {
+			data = config.Payload(d)
+		}
+
+		uplinks++
+		up, err := d.Uplink(config.FPort, data, config.Confirmed)
+		if err != nil {
+			d.recordError()
+			continue
+		}
+
+		b, err := config.Handler(ctx, d, up)
+		if err != nil {
+			d.recordError()
+			continue
+		}
+		if b != nil {
+			_, _ = d.HandleDownlink(b)
+		}
+	}
+}
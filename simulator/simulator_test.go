@@ -0,0 +1,89 @@
+package simulator
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/harness"
+)
+
+func TestRun(t *testing.T) {
+	assert := require.New(t)
+
+	// the harness network-server validates the LoRaWAN 1.1 uplink MIC
+	// using DR0 and channel 0, therefore LoRaWAN 1.0 is used
+	config := harness.Config{
+		MACVersion: lorawan.LoRaWAN1_0,
+		NetID:      lorawan.NetID{0x00, 0x00, 0x13},
+		DevEUI:     lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		JoinEUI:    lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1},
+		NwkKey:     lorawan.AES128Key{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8},
+	}
+	h, err := harness.New(config)
+	assert.NoError(err)
+	defer h.Close()
+
+	d, err := NewDevice(DeviceConfig{
+		Band:    testBand(t),
+		DevEUI:  config.DevEUI,
+		JoinEUI: config.JoinEUI,
+		NwkKey:  config.NwkKey,
+	})
+	assert.NoError(err)
+
+	stats, err := Run(context.Background(), Config{
+		Devices: []*Device{d},
+		Handler: func(ctx context.Context, d *Device, up Uplink) ([]byte, error) {
+			return h.NetworkServer.HandleUplink(ctx, up.PHYPayload)
+		},
+		Interval:  time.Millisecond,
+		Uplinks:   5,
+		Confirmed: true,
+		Payload: func(d *Device) []byte {
+			return []byte{1, 2, 3}
+		},
+		Rand: rand.New(rand.NewSource(1)),
+	})
+	assert.NoError(err)
+	assert.Equal(Stats{
+		JoinRequests: 1,
+		JoinAccepts:  1,
+		Uplinks:      5,
+		Downlinks:    5,
+	}, stats)
+
+	session, ok := d.Session()
+	assert.True(ok)
+	assert.Equal(uint32(5), session.FCntUp)
+	assert.Len(h.NetworkServer.Uplinks(), 5)
+}
+
+func TestRunCancel(t *testing.T) {
+	assert := require.New(t)
+
+	ns := testSession(lorawan.LoRaWAN1_0)
+	d, err := NewDevice(DeviceConfig{Band: testBand(t), Session: &ns})
+	assert.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	uplinks := 0
+
+	stats, err := Run(ctx, Config{
+		Devices: []*Device{d},
+		Handler: func(ctx context.Context, d *Device, up Uplink) ([]byte, error) {
+			uplinks++
+			if uplinks == 3 {
+				cancel()
+			}
+			return nil, nil
+		},
+		Interval: time.Millisecond,
+	})
+	assert.NoError(err)
+	assert.Equal(3, stats.Uplinks)
+}